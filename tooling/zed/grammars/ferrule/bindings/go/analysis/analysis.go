@@ -0,0 +1,307 @@
+// Package analysis defines the interface between lint rules and the tools
+// that run them.
+//
+// It is modeled on golang.org/x/tools/go/analysis, cut down to what a
+// single-file syntax check needs: an Analyzer describes a rule, and its Run
+// function receives a Pass holding the parse tree and source of one file,
+// through which it reports diagnostics. Rules are composed simply by running
+// several analyzers over the same file; see Run. Rules that look across
+// files set NeedsIndex and get the symbol index of the project as well; see
+// RunProject.
+//
+// Analyzers defined outside this module plug into the standard driver by
+// passing them to multichecker.Main from their own main package, the same
+// way cmd/ferrule-lint does with the built-in rules.
+package analysis
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+// An Analyzer describes a lint rule.
+type Analyzer struct {
+	// Name identifies the analyzer. It must be a valid Go identifier and is
+	// used on the command line and as the category of its diagnostics.
+	Name string
+	// Doc is the documentation of the analyzer. The first line is a
+	// one-sentence summary.
+	Doc string
+	// Severity is the severity of diagnostics that do not set their own.
+	// Zero means ferrule.SeverityWarning.
+	Severity ferrule.Severity
+	// NeedsIndex asks drivers to index the project the file belongs to and
+	// pass the index in Pass.Index. The analyzer must still cope with a
+	// nil index, when a file is analyzed on its own.
+	NeedsIndex bool
+	// Optional analyzers are off unless turned on, on the command line or
+	// in the [lint] table of ferrule.toml; see package config.
+	Optional bool
+	// Requires are the analyzers whose findings this one builds on. Run
+	// and RunProject run them first, whether asked to or not, and pass
+	// their diagnostics in Pass.ResultOf. A required analyzer that was not
+	// asked for reports nothing itself, and neither do the analyzers
+	// repeating its findings.
+	Requires []*Analyzer
+	// Flags are the options of the analyzer. Drivers offer them under the
+	// name of the analyzer and a dot, as in -spelling.words.
+	Flags flag.FlagSet
+	// Run applies the analyzer to a file.
+	Run func(*Pass) error
+}
+
+func (a *Analyzer) String() string { return a.Name }
+
+// A Pass is the state of one analyzer running over one file.
+type Pass struct {
+	Analyzer *Analyzer
+	// Tree is the parse tree of the file. It is shared between analyzers and
+	// must not be edited or closed.
+	Tree *ferrule.Tree
+	// Source is the text the tree was parsed from.
+	Source []byte
+	// Index is the symbol index of the project, or nil. It must not be
+	// modified.
+	Index *index.Index
+	// Path is the path of the file in Index, or "".
+	Path string
+	// ResultOf holds the diagnostics on the file of each analyzer in
+	// Analyzer.Requires, suppressed or not.
+	ResultOf map[*Analyzer][]Diagnostic
+	// Report records a diagnostic.
+	Report func(Diagnostic)
+}
+
+// Reportf reports a diagnostic covering n.
+func (p *Pass) Reportf(n *tree_sitter.Node, format string, args ...any) {
+	p.Report(Diagnostic{Range: n.Range(), Message: fmt.Sprintf(format, args...)})
+}
+
+// A Diagnostic is a problem reported by an analyzer.
+type Diagnostic struct {
+	Range tree_sitter.Range
+	// Severity defaults to the analyzer's severity.
+	Severity ferrule.Severity
+	// Category is the name of the reporting analyzer. It is filled in by Run.
+	Category string
+	Message  string
+	// SuggestedFixes are alternative ways to resolve the problem. Drivers
+	// apply the first one when asked to fix.
+	SuggestedFixes []SuggestedFix
+	// Tags classify the diagnostic for editors, which may render the code
+	// it covers differently.
+	Tags []Tag
+}
+
+// A Tag classifies a diagnostic.
+type Tag int
+
+const (
+	// TagDeprecated marks a use of a deprecated declaration; editors strike
+	// it through.
+	TagDeprecated Tag = iota + 1
+)
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Range.StartPoint.Row+1, d.Range.StartPoint.Column+1, d.Message, d.Category)
+}
+
+// Validate reports an error if the analyzers are malformed or share a name.
+func Validate(analyzers []*Analyzer) error {
+	seen := make(map[string]bool)
+	for _, a := range analyzers {
+		switch {
+		case a == nil:
+			return errors.New("analysis: nil analyzer")
+		case !validName(a.Name):
+			return fmt.Errorf("analysis: invalid analyzer name %q", a.Name)
+		case a.Run == nil:
+			return fmt.Errorf("analysis: analyzer %s has no Run function", a.Name)
+		case seen[a.Name]:
+			return fmt.Errorf("analysis: duplicate analyzer %s", a.Name)
+		}
+		seen[a.Name] = true
+	}
+	return nil
+}
+
+// Run applies the analyzers to tree and returns their diagnostics sorted by
+// position, diagnostics at the same position in the order the analyzers
+// run: that given, each after those it requires. A diagnostic with the
+// range and message of an earlier one is reported once, so that rules
+// that build on others may repeat their findings, and counts as reported
+// by each. It is dropped if a Suppression covers any of them, or if any
+// of them was only run as a requirement. The Stale analyzer, when given,
+// reports the suppressions left unused. Trees with syntax errors are
+// analyzed as well; rules should be prepared to meet ERROR nodes.
+func Run(tree *ferrule.Tree, analyzers ...*Analyzer) ([]Diagnostic, error) {
+	return RunProject(nil, "", tree, analyzers...)
+}
+
+// RunProject is like Run for the file path of the project indexed by idx.
+func RunProject(idx *index.Index, path string, tree *ferrule.Tree, analyzers ...*Analyzer) ([]Diagnostic, error) {
+	if err := Validate(analyzers); err != nil {
+		return nil, err
+	}
+	order, err := plan(analyzers)
+	if err != nil {
+		return nil, err
+	}
+	ran := make(map[string]bool)
+	judge := false
+	for _, a := range analyzers {
+		if a == Stale {
+			judge = true
+			continue
+		}
+		ran[a.Name] = true
+	}
+
+	// A finding is a diagnostic and the analyzers that reported it.
+	type key struct {
+		start, end uint
+		message    string
+	}
+	type finding struct {
+		d  Diagnostic
+		by []string
+	}
+	var found []*finding
+	seen := make(map[key]*finding)
+	results := make(map[string][]Diagnostic)
+	for _, a := range order {
+		severity := a.Severity
+		if severity == 0 {
+			severity = ferrule.SeverityWarning
+		}
+		pass := &Pass{
+			Analyzer: a,
+			Tree:     tree,
+			Source:   tree.Source(),
+			Index:    idx,
+			Path:     path,
+			ResultOf: make(map[*Analyzer][]Diagnostic, len(a.Requires)),
+			Report: func(d Diagnostic) {
+				if d.Severity == 0 {
+					d.Severity = severity
+				}
+				d.Category = a.Name
+				results[a.Name] = append(results[a.Name], d)
+				k := key{d.Range.StartByte, d.Range.EndByte, d.Message}
+				if f := seen[k]; f != nil {
+					f.by = append(f.by, a.Name)
+					return
+				}
+				f := &finding{d: d, by: []string{a.Name}}
+				seen[k] = f
+				found = append(found, f)
+			},
+		}
+		for _, r := range a.Requires {
+			pass.ResultOf[r] = results[r.Name]
+		}
+		if err := a.Run(pass); err != nil {
+			return nil, fmt.Errorf("%s: %w", a.Name, err)
+		}
+	}
+
+	sups := Suppressions(tree)
+	used := make(map[*Suppression]map[string]bool)
+	var out []Diagnostic
+	for _, f := range found {
+		keep := true
+		for _, name := range f.by {
+			for _, s := range sups {
+				if s.Covers(name, f.d) {
+					if used[s] == nil {
+						used[s] = make(map[string]bool)
+					}
+					used[s][name] = true
+					keep = false
+				}
+			}
+			if !ran[name] {
+				keep = false
+			}
+		}
+		if keep {
+			out = append(out, f.d)
+		}
+	}
+	if judge {
+		for _, s := range sups {
+			stale(tree.Source(), s, used[s], ran, func(d Diagnostic) {
+				d.Severity = ferrule.SeverityWarning
+				d.Category = Stale.Name
+				out = append(out, d)
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Range.StartByte < out[j].Range.StartByte
+	})
+	return out, nil
+}
+
+// plan returns the analyzers in the order they run, each after those it
+// requires, which are added when not given. A required analyzer with the
+// name of one given is the one given, which may be a copy with another
+// severity.
+func plan(analyzers []*Analyzer) ([]*Analyzer, error) {
+	given := make(map[string]*Analyzer)
+	for _, a := range analyzers {
+		given[a.Name] = a
+	}
+	var order []*Analyzer
+	const visiting, done = 1, 2
+	state := make(map[string]int)
+	var visit func(a *Analyzer) error
+	visit = func(a *Analyzer) error {
+		if g := given[a.Name]; g != nil {
+			a = g
+		}
+		switch state[a.Name] {
+		case visiting:
+			return fmt.Errorf("analysis: analyzer %s requires itself", a.Name)
+		case done:
+			return nil
+		}
+		state[a.Name] = visiting
+		for _, r := range a.Requires {
+			if err := Validate([]*Analyzer{r}); err != nil {
+				return fmt.Errorf("requirement of %s: %w", a.Name, err)
+			}
+			if err := visit(r); err != nil {
+				return err
+			}
+		}
+		state[a.Name] = done
+		order = append(order, a)
+		return nil
+	}
+	for _, a := range analyzers {
+		if err := visit(a); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func validName(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case i > 0 && '0' <= r && r <= '9':
+		default:
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,272 @@
+package analysis_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// everyConst reports each const declaration, in reverse order.
+var everyConst = &analysis.Analyzer{
+	Name: "everyconst",
+	Doc:  "report every const declaration",
+	Run: func(pass *analysis.Pass) error {
+		root := pass.Tree.RootNode()
+		for i := int(root.NamedChildCount()) - 1; i >= 0; i-- {
+			if c := root.NamedChild(uint(i)); c.Kind() == kind.ConstDeclaration {
+				pass.Reportf(c, "const %s", c.ChildByFieldName("name").Utf8Text(pass.Source))
+			}
+		}
+		return nil
+	},
+}
+
+var loud = &analysis.Analyzer{
+	Name:     "loud",
+	Doc:      "report the file as an error",
+	Severity: ferrule.SeverityError,
+	Run: func(pass *analysis.Pass) error {
+		pass.Reportf(pass.Tree.RootNode(), "file")
+		return nil
+	},
+}
+
+func TestRun(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("const a = 1;\nconst b = 2;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	diags, err := analysis.Run(tree, everyConst, loud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// diagnostics at the same position keep the order of the analyzers.
+	want := []string{"1:1: const a (everyconst)", "1:1: file (loud)", "2:1: const b (everyconst)"}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, d := range diags {
+		if d.String() != want[i] {
+			t.Errorf("diagnostic %d = %q, want %q", i, d, want[i])
+		}
+	}
+	if diags[0].Severity != ferrule.SeverityWarning || diags[1].Severity != ferrule.SeverityError {
+		t.Errorf("severities = %v, %v", diags[0].Severity, diags[1].Severity)
+	}
+}
+
+func TestFilterByDiff(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("const a = 1;\nconst b = 2;\nconst c = 3;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	diags, err := analysis.Run(tree, everyConst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := "--- a/src/main.fe\n+++ b/src/main.fe\n@@ -2 +2 @@\n-const b = 1;\n+const b = 2;\n"
+	for _, tt := range []struct {
+		path string
+		want []string
+	}{
+		{"src/main.fe", []string{"2:1: const b (everyconst)"}},
+		{"/home/me/project/src/main.fe", []string{"2:1: const b (everyconst)"}},
+		{"main.fe", nil},
+		{"other/src/main.fe.bak", nil},
+	} {
+		kept, err := analysis.FilterByDiff(diags, []byte(diff), tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range kept {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("FilterByDiff(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if _, err := analysis.FilterByDiff(diags, []byte("+++ b/a.fe\n@@ bad @@\n"), "a.fe"); err == nil {
+		t.Error("FilterByDiff of a malformed diff succeeded")
+	}
+}
+
+func TestRunDuplicates(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("const a = 1;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	// repeats the findings of everyconst.
+	again := &analysis.Analyzer{Name: "again", Doc: "repeat everyconst", Run: everyConst.Run}
+	diags, err := analysis.Run(tree, everyConst, again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Category != "everyconst" {
+		t.Errorf("got %v, want the everyconst diagnostic only", diags)
+	}
+}
+
+func TestRunRequires(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("const a = 1;\nconst b = 2;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	// repeats the first finding of everyconst, which it requires.
+	first := &analysis.Analyzer{
+		Name:     "first",
+		Doc:      "repeat the first everyconst diagnostic",
+		Requires: []*analysis.Analyzer{everyConst},
+		Run: func(pass *analysis.Pass) error {
+			if ds := pass.ResultOf[everyConst]; len(ds) > 0 {
+				pass.Report(ds[0])
+			}
+			return nil
+		},
+	}
+	for _, tt := range []struct {
+		analyzers []*analysis.Analyzer
+		want      string
+	}{
+		{[]*analysis.Analyzer{first, everyConst}, "1:1: const a (everyconst)|2:1: const b (everyconst)"},
+		// everyconst runs for first only and reports nothing.
+		{[]*analysis.Analyzer{first}, ""},
+	} {
+		diags, err := analysis.Run(tree, tt.analyzers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "|") != tt.want {
+			t.Errorf("%v: got %q, want %s", tt.analyzers, got, tt.want)
+		}
+	}
+
+	loop := &analysis.Analyzer{Name: "loop", Doc: "require itself", Run: first.Run}
+	loop.Requires = []*analysis.Analyzer{loop}
+	if _, err := analysis.Run(tree, loop); err == nil {
+		t.Error("no error for an analyzer requiring itself")
+	}
+}
+
+func TestSuppressions(t *testing.T) {
+	src := "// ferrule:disable everyconst generated\n" +
+		"const a = 1;\n" +
+		"const b = 2; // ferrule:ignore everyconst\n" +
+		"const c = 3; // ferrule:disable loud, everyconst\n" +
+		"// ferrule:disable everyconst\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	sups := analysis.Suppressions(tree)
+	if len(sups) != 4 {
+		t.Fatalf("got %d suppressions, want 4", len(sups))
+	}
+	if s := sups[0]; s.Reason != "generated" || s.StartRow != 1 || s.EndRow != 1 {
+		t.Errorf("first suppression %+v", s)
+	}
+	if s := sups[3]; s.EndRow >= s.StartRow {
+		t.Errorf("last suppression covers rows %d to %d", s.StartRow, s.EndRow)
+	}
+
+	diags, err := analysis.Run(tree, everyConst, loud, analysis.Stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"1:1: file (loud)",
+		"4:14: ferrule:disable of loud suppresses nothing (stalesuppress)",
+		"5:1: ferrule:disable of everyconst suppresses nothing (stalesuppress)",
+	}
+	var got []string
+	var fixes []analysis.SuggestedFix
+	for _, d := range diags {
+		got = append(got, d.String())
+		fixes = append(fixes, d.SuggestedFixes...)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	fixed, _, err := analysis.ApplyFixes([]byte(src), fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFixed := "// ferrule:disable everyconst generated\n" +
+		"const a = 1;\n" +
+		"const b = 2; // ferrule:ignore everyconst\n" +
+		"const c = 3; // ferrule:disable everyconst\n"
+	if string(fixed) != wantFixed {
+		t.Errorf("fixed:\n%s\nwant:\n%s", fixed, wantFixed)
+	}
+
+	// without the meta-rule, unused suppressions go unreported.
+	diags, err = analysis.Run(tree, everyConst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("got %v, want no diagnostics", diags)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	run := func(*analysis.Pass) error { return nil }
+	tests := []struct {
+		name      string
+		analyzers []*analysis.Analyzer
+		ok        bool
+	}{
+		{"valid", []*analysis.Analyzer{everyConst, loud}, true},
+		{"duplicate", []*analysis.Analyzer{loud, loud}, false},
+		{"bad name", []*analysis.Analyzer{{Name: "no-dash", Run: run}}, false},
+		{"empty name", []*analysis.Analyzer{{Run: run}}, false},
+		{"no run", []*analysis.Analyzer{{Name: "norun"}}, false},
+		{"nil", []*analysis.Analyzer{nil}, false},
+	}
+	for _, tt := range tests {
+		if err := analysis.Validate(tt.analyzers); (err == nil) != tt.ok {
+			t.Errorf("%s: Validate() = %v", tt.name, err)
+		}
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	src := []byte("abcdefgh")
+	edit := func(start, end uint, text string) analysis.TextEdit {
+		return analysis.TextEdit{Start: start, End: end, NewText: []byte(text)}
+	}
+	fixes := []analysis.SuggestedFix{
+		{Message: "upper ef", TextEdits: []analysis.TextEdit{edit(4, 6, "EF")}},
+		{Message: "drop b and g", TextEdits: []analysis.TextEdit{edit(1, 2, ""), edit(6, 7, "")}},
+		{Message: "conflicts with ef", TextEdits: []analysis.TextEdit{edit(5, 8, "")}},
+		{Message: "insert", TextEdits: []analysis.TextEdit{edit(0, 0, ">")}},
+	}
+	got, applied, err := analysis.ApplyFixes(src, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != ">acdEFh" || applied != 3 {
+		t.Errorf("ApplyFixes() = %q, %d; want %q, 3", got, applied, ">acdEFh")
+	}
+	if _, _, err := analysis.ApplyFixes(src, []analysis.SuggestedFix{{TextEdits: []analysis.TextEdit{edit(2, 20, "")}}}); err == nil {
+		t.Error("ApplyFixes accepted an edit out of range")
+	}
+}
@@ -0,0 +1,175 @@
+// Package analysistest checks analyzers against annotated test files.
+//
+// A test file is ferrule source in which every line that should produce a
+// diagnostic carries a comment of the form
+//
+//	const x = 1; // want "declared and not used"
+//
+// holding one or more quoted regular expressions. Each expression must match
+// the message of a distinct diagnostic reported on that line, and every
+// diagnostic must be matched by some expression.
+//
+// The analyzers an analyzer requires run with it and report as well, so
+// that the want comments cover their findings. Analyzers that set
+// NeedsIndex are given the index of dir, so that the files of a test
+// directory form a project.
+//
+// RunWithSuggestedFixes additionally applies the first suggested fix of
+// every diagnostic and compares the result with the file of the same name
+// plus ".golden".
+package analysistest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+var (
+	wantComment = regexp.MustCompile(`//\s*want\s+(.*)$`)
+	wantPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+)
+
+// Run applies a to every .fe file in dir and reports mismatches between the
+// diagnostics and the want comments through t. It returns the diagnostics
+// of each file by name.
+func Run(t testing.TB, dir string, a *analysis.Analyzer) map[string][]analysis.Diagnostic {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.fe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no .fe files in %s", dir)
+	}
+	analyzers := withRequired(nil, a)
+	var idx *index.Index
+	if slices.ContainsFunc(analyzers, func(a *analysis.Analyzer) bool { return a.NeedsIndex }) {
+		if idx, err = index.Build(context.Background(), dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results := make(map[string][]analysis.Diagnostic)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.HasError() {
+			t.Errorf("%s: syntax errors: %v", file, tree.Diagnostics())
+		}
+		path := ""
+		if idx != nil {
+			path = filepath.Base(file)
+		}
+		diags, err := analysis.RunProject(idx, path, tree, analyzers...)
+		tree.Close()
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		results[file] = diags
+		check(t, file, src, diags)
+	}
+	return results
+}
+
+// withRequired appends a and the analyzers it requires to list, once each.
+func withRequired(list []*analysis.Analyzer, a *analysis.Analyzer) []*analysis.Analyzer {
+	if slices.Contains(list, a) {
+		return list
+	}
+	list = append(list, a)
+	for _, r := range a.Requires {
+		list = withRequired(list, r)
+	}
+	return list
+}
+
+func check(t testing.TB, file string, src []byte, diags []analysis.Diagnostic) {
+	t.Helper()
+	want := make(map[int][]*regexp.Regexp)
+	for i, line := range regexp.MustCompile("\r?\n").Split(string(src), -1) {
+		m := wantComment.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, q := range wantPattern.FindAllString(m[1], -1) {
+			s, err := strconv.Unquote(q)
+			if err != nil {
+				t.Fatalf("%s:%d: bad want pattern %s: %v", file, i+1, q, err)
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				t.Fatalf("%s:%d: bad want pattern %s: %v", file, i+1, q, err)
+			}
+			want[i+1] = append(want[i+1], re)
+		}
+	}
+
+	for _, d := range diags {
+		line := int(d.Range.StartPoint.Row) + 1
+		matched := false
+		for i, re := range want[line] {
+			if re.MatchString(d.Message) {
+				want[line] = append(want[line][:i], want[line][i+1:]...)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			t.Errorf("%s:%d: unexpected diagnostic: %s", file, line, d.Message)
+		}
+	}
+	for line, res := range want {
+		for _, re := range res {
+			t.Errorf("%s:%d: no diagnostic matching %q", file, line, re)
+		}
+	}
+}
+
+// RunWithSuggestedFixes is like Run and also checks the fixed source of
+// each file against its golden file.
+func RunWithSuggestedFixes(t testing.TB, dir string, a *analysis.Analyzer) map[string][]analysis.Diagnostic {
+	t.Helper()
+	results := Run(t, dir, a)
+	for file, diags := range results {
+		golden, err := os.ReadFile(file + ".golden")
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixes []analysis.SuggestedFix
+		for _, d := range diags {
+			if len(d.SuggestedFixes) > 0 {
+				fixes = append(fixes, d.SuggestedFixes[0])
+			}
+		}
+		got, _, err := analysis.ApplyFixes(src, fixes)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		if !bytes.Equal(got, golden) {
+			t.Errorf("%s: fixed source differs from golden file:\n%s", file, got)
+		}
+	}
+	return results
+}
@@ -0,0 +1,156 @@
+// Package baseline records the findings a code base has already, so that
+// a linter adopted late reports only the findings of new code.
+//
+// A finding is recorded by the path of its file, its category and message
+// and the fingerprint of the syntax node it covers, not by its line: code
+// moved around, or pushed down by lines added above it, keeps its
+// findings recorded, and a finding is new once the code it covers
+// changes. Identical findings of one file are counted, so that a copy of
+// recorded code is new again.
+//
+// The file is JSON, written sorted so that it diffs well:
+//
+//	{
+//	  "version": 1,
+//	  "findings": [
+//	    {"path": "src/a.fe", "category": "unused", "message": "x declared and not used", "node": "5fd4…"}
+//	  ]
+//	}
+//
+// Paths are relative to the directory of the baseline file, so it serves
+// wherever the linter runs from.
+package baseline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/fingerprint"
+)
+
+// version is the version of the file format.
+const version = 1
+
+// Entry is a recorded finding.
+type Entry struct {
+	Path     string `json:"path"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+	// Node is the fingerprint of the smallest named node covering the
+	// finding.
+	Node string `json:"node"`
+}
+
+type file struct {
+	Version  int     `json:"version"`
+	Findings []Entry `json:"findings"`
+}
+
+// Baseline is a set of recorded findings.
+type Baseline struct {
+	name   string
+	dir    string // absolute
+	counts map[Entry]int
+}
+
+// New returns an empty baseline to be written to the file name.
+func New(name string) (*Baseline, error) {
+	dir, err := filepath.Abs(filepath.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	return &Baseline{name: name, dir: dir, counts: make(map[Entry]int)}, nil
+}
+
+// Load reads the baseline file name.
+func Load(name string) (*Baseline, error) {
+	b, err := New(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	if f.Version != version {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", name, f.Version)
+	}
+	for _, e := range f.Findings {
+		b.counts[e]++
+	}
+	return b, nil
+}
+
+// entry returns the entry of d, found in the file at path parsed as tree.
+func (b *Baseline) entry(path string, tree *ferrule.Tree, d analysis.Diagnostic) Entry {
+	rel := path
+	if abs, err := filepath.Abs(path); err == nil {
+		if r, err := filepath.Rel(b.dir, abs); err == nil {
+			rel = r
+		}
+	}
+	n := tree.RootNode().NamedDescendantForByteRange(d.Range.StartByte, d.Range.EndByte)
+	return Entry{
+		Path:     filepath.ToSlash(rel),
+		Category: d.Category,
+		Message:  d.Message,
+		Node:     fingerprint.Node(n, tree.Source()).String(),
+	}
+}
+
+// Add records d, found in the file at path parsed as tree.
+func (b *Baseline) Add(path string, tree *ferrule.Tree, d analysis.Diagnostic) {
+	b.counts[b.entry(path, tree, d)]++
+}
+
+// Match reports whether d, found in the file at path parsed as tree, is
+// recorded, and uses the record up: of two identical findings where one
+// was recorded, only the first matches.
+func (b *Baseline) Match(path string, tree *ferrule.Tree, d analysis.Diagnostic) bool {
+	e := b.entry(path, tree, d)
+	if b.counts[e] == 0 {
+		return false
+	}
+	b.counts[e]--
+	return true
+}
+
+// Write writes the findings recorded to the file of b, less those
+// matched.
+func (b *Baseline) Write() error {
+	f := file{Version: version, Findings: []Entry{}}
+	for e, c := range b.counts {
+		for range c {
+			f.Findings = append(f.Findings, e)
+		}
+	}
+	sort.Slice(f.Findings, func(i, j int) bool {
+		a, b := f.Findings[i], f.Findings[j]
+		switch {
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		case a.Category != b.Category:
+			return a.Category < b.Category
+		case a.Message != b.Message:
+			return a.Message < b.Message
+		}
+		return a.Node < b.Node
+	})
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	return os.WriteFile(b.name, out.Bytes(), 0o644)
+}
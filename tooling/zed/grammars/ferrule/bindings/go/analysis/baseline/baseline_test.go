@@ -0,0 +1,82 @@
+package baseline_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/baseline"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func lint(t *testing.T, src string) (*ferrule.Tree, []analysis.Diagnostic) {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	diags, err := analysis.Run(tree, unused.Analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, diags
+}
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "baseline.json")
+	path := filepath.Join(dir, "src", "a.fe")
+	const f = "function f() -> i32 { const x = 1; return 2; }\n"
+
+	b, err := baseline.New(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, diags := lint(t, f)
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	b.Add(path, tree, diags[0])
+	if err := b.Write(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(name)
+	if !strings.Contains(string(data), `"path": "src/a.fe"`) {
+		t.Errorf("baseline file:\n%s", data)
+	}
+
+	b, err = baseline.Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the recorded finding has moved down and has been copied.
+	tree, diags = lint(t, "const pad = 0;\n\n"+f+"function g() -> i32 { const x = 1; return 2; }\n")
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2", len(diags))
+	}
+	if !b.Match(path, tree, diags[0]) {
+		t.Error("moved finding does not match")
+	}
+	if b.Match(path, tree, diags[1]) {
+		t.Error("copied finding matches though only one was recorded")
+	}
+
+	b, _ = baseline.Load(name)
+	tree, diags = lint(t, strings.Replace(f, "x", "y", 1))
+	if b.Match(path, tree, diags[0]) {
+		t.Error("finding of changed code matches")
+	}
+	if b.Match(filepath.Join(dir, "b.fe"), tree, diags[0]) {
+		t.Error("finding of another file matches")
+	}
+
+	os.WriteFile(name, []byte(`{"version": 9, "findings": []}`), 0o644)
+	if _, err := baseline.Load(name); err == nil {
+		t.Error("Load of an unknown version succeeded")
+	}
+}
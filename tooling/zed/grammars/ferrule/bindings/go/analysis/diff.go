@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"path/filepath"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/udiff"
+)
+
+// A Diff holds the lines a unified diff adds or changes in each file, so
+// that drivers can report only the findings in changed code and a legacy
+// code base can adopt a rule one change at a time.
+type Diff struct {
+	files []udiff.File
+}
+
+// ParseDiff parses a unified diff, as printed by diff -u or git diff.
+func ParseDiff(unified []byte) (*Diff, error) {
+	files, err := udiff.Parse(unified)
+	if err != nil {
+		return nil, err
+	}
+	return &Diff{files: files}, nil
+}
+
+// Touches reports whether r, a range of the file at path, overlaps a line
+// the diff adds or changes. Paths match when they are the same or when the
+// path in the diff ends the other one, so that the paths of git diff,
+// relative to the top of the work tree, match those linted from any
+// directory of it. A diagnostic touches the diff when its range does, so a
+// finding on a declaration changed in one line is kept.
+func (d *Diff) Touches(path string, r tree_sitter.Range) bool {
+	f := d.file(path)
+	if f == nil {
+		return false
+	}
+	start, end := int(r.StartPoint.Row)+1, int(r.EndPoint.Row)+1
+	if r.EndPoint.Column == 0 && end > start {
+		end-- // the range ends with a newline
+	}
+	return f.Overlaps(start, end)
+}
+
+// file returns the file of the diff matching path, the one with the
+// longest path if several do.
+func (d *Diff) file(path string) *udiff.File {
+	path = filepath.ToSlash(filepath.Clean(path))
+	var best *udiff.File
+	for i := range d.files {
+		f := &d.files[i]
+		name := filepath.ToSlash(filepath.Clean(f.Path))
+		if name != path && !strings.HasSuffix(path, "/"+name) {
+			continue
+		}
+		if best == nil || len(f.Path) > len(best.Path) {
+			best = f
+		}
+	}
+	return best
+}
+
+// Filter returns the diagnostics of diags, found in the file at path, that
+// touch the diff.
+func (d *Diff) Filter(path string, diags []Diagnostic) []Diagnostic {
+	var out []Diagnostic
+	for _, diag := range diags {
+		if d.Touches(path, diag.Range) {
+			out = append(out, diag)
+		}
+	}
+	return out
+}
+
+// FilterByDiff returns the diagnostics of diags, found in the file at
+// path, that touch the lines unifiedDiff adds or changes. Drivers that
+// filter many files parse the diff once with ParseDiff instead.
+func FilterByDiff(diags []Diagnostic, unifiedDiff []byte, path string) ([]Diagnostic, error) {
+	d, err := ParseDiff(unifiedDiff)
+	if err != nil {
+		return nil, err
+	}
+	return d.Filter(path, diags), nil
+}
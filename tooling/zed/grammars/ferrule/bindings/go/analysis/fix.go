@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// A SuggestedFix is a change that resolves a diagnostic. Its edits are
+// applied together or not at all.
+type SuggestedFix struct {
+	// Message describes the fix, e.g. "remove unused x".
+	Message   string
+	TextEdits []TextEdit
+}
+
+// A TextEdit replaces the bytes between Start and End with NewText.
+type TextEdit struct {
+	Start, End uint
+	NewText    []byte
+}
+
+// Replace returns an edit replacing the text of n.
+func Replace(n *tree_sitter.Node, text string) TextEdit {
+	return TextEdit{Start: n.StartByte(), End: n.EndByte(), NewText: []byte(text)}
+}
+
+// Delete returns an edit removing n. When n is the only thing on its
+// lines, apart from a trailing line comment, the lines go with it;
+// otherwise the spaces before n are removed.
+func Delete(src []byte, n *tree_sitter.Node) TextEdit {
+	start, end := n.StartByte(), n.EndByte()
+	for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+		start--
+	}
+	if start == 0 || src[start-1] == '\n' {
+		rest := end
+		for rest < uint(len(src)) && (src[rest] == ' ' || src[rest] == '\t' || src[rest] == '\r') {
+			rest++
+		}
+		if bytes.HasPrefix(src[rest:], []byte("//")) {
+			if i := bytes.IndexByte(src[rest:], '\n'); i >= 0 {
+				rest += uint(i)
+			} else {
+				rest = uint(len(src))
+			}
+		}
+		if rest == uint(len(src)) || src[rest] == '\n' {
+			return TextEdit{Start: start, End: min(rest+1, uint(len(src)))}
+		}
+		start = n.StartByte()
+	}
+	return TextEdit{Start: start, End: end}
+}
+
+// ApplyFixes applies as many of the fixes to src as possible. Fixes are
+// taken in order of their first edit; a fix whose edits overlap an edit
+// already taken, or each other, is skipped. It returns the new source and
+// the number of fixes applied.
+func ApplyFixes(src []byte, fixes []SuggestedFix) ([]byte, int, error) {
+	for _, f := range fixes {
+		for _, e := range f.TextEdits {
+			if e.Start > e.End || e.End > uint(len(src)) {
+				return nil, 0, fmt.Errorf("analysis: fix %q: edit %d-%d out of range", f.Message, e.Start, e.End)
+			}
+		}
+	}
+	ordered := make([]SuggestedFix, 0, len(fixes))
+	for _, f := range fixes {
+		if len(f.TextEdits) > 0 {
+			ordered = append(ordered, f)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return first(ordered[i]) < first(ordered[j])
+	})
+
+	var taken []TextEdit
+	applied := 0
+	for _, f := range ordered {
+		if overlapsAny(f.TextEdits, taken) || selfOverlap(f.TextEdits) {
+			continue
+		}
+		taken = append(taken, f.TextEdits...)
+		applied++
+	}
+	sort.SliceStable(taken, func(i, j int) bool {
+		if taken[i].Start != taken[j].Start {
+			return taken[i].Start < taken[j].Start
+		}
+		return taken[i].End < taken[j].End
+	})
+
+	var out bytes.Buffer
+	pos := uint(0)
+	for _, e := range taken {
+		out.Write(src[pos:e.Start])
+		out.Write(e.NewText)
+		pos = e.End
+	}
+	out.Write(src[pos:])
+	return out.Bytes(), applied, nil
+}
+
+func first(f SuggestedFix) uint {
+	start := f.TextEdits[0].Start
+	for _, e := range f.TextEdits[1:] {
+		start = min(start, e.Start)
+	}
+	return start
+}
+
+func overlapsAny(edits, taken []TextEdit) bool {
+	for _, e := range edits {
+		for _, t := range taken {
+			if overlaps(e, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func selfOverlap(edits []TextEdit) bool {
+	for i := range edits {
+		if overlapsAny(edits[i+1:], edits[i:i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether two edits touch the same bytes. Two insertions
+// at the same offset overlap too, since their order would be ambiguous.
+func overlaps(a, b TextEdit) bool {
+	if a.Start == a.End && b.Start == b.End {
+		return a.Start == b.Start
+	}
+	return a.Start < b.End && b.Start < a.End
+}
@@ -0,0 +1,224 @@
+// Package scopes resolves the local names of a ferrule file for the
+// built-in lint rules.
+//
+// Locals are the parameters of functions, const and var declarations inside
+// blocks, for loop variables and the names bound by match patterns. A
+// declaration in a block is visible from the end of the declaration to the
+// end of the block. Top-level functions and constants are declared in the
+// file scope so that references to them resolve, but they are never
+// reported as locals.
+package scopes
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+type Node = tree_sitter.Node
+
+// BindingKind says how a name was introduced.
+type BindingKind int
+
+const (
+	Global BindingKind = iota
+	Param
+	Const
+	Var
+	For
+	PatternBinding
+)
+
+func (k BindingKind) String() string {
+	switch k {
+	case Global:
+		return "global"
+	case Param:
+		return "parameter"
+	case Const:
+		return "const"
+	case Var:
+		return "var"
+	case For:
+		return "loop variable"
+	case PatternBinding:
+		return "pattern binding"
+	}
+	return "unknown"
+}
+
+// A Binding is a declared name.
+type Binding struct {
+	Name string
+	Kind BindingKind
+	// Ident is the identifier node that declares the name.
+	Ident *Node
+	// Uses are the identifiers that refer to the binding.
+	Uses []*Node
+	// Shadows is the binding of the same name in an enclosing local scope
+	// that this one hides, if any.
+	Shadows *Binding
+}
+
+type scope struct {
+	parent *scope
+	names  map[string]*Binding
+}
+
+func (s *scope) lookup(name string) *Binding {
+	for ; s != nil; s = s.parent {
+		if b, ok := s.names[name]; ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// Info is the result of resolving a file.
+type Info struct {
+	// Bindings lists every binding in declaration order.
+	Bindings []*Binding
+}
+
+// Resolve resolves the names declared and used in the tree rooted at root.
+func Resolve(root *Node, src []byte) *Info {
+	r := &resolver{src: src, info: &Info{}}
+	file := &scope{names: make(map[string]*Binding)}
+	for _, c := range children(root) {
+		switch c.Kind() {
+		case kind.FunctionDeclaration, kind.ConstDeclaration:
+			if name := c.ChildByFieldName("name"); name != nil {
+				r.declare(file, name, Global)
+			}
+		}
+	}
+	r.walk(root, file)
+	return r.info
+}
+
+type resolver struct {
+	src  []byte
+	info *Info
+}
+
+func (r *resolver) declare(s *scope, ident *Node, k BindingKind) {
+	name := ident.Utf8Text(r.src)
+	b := &Binding{Name: name, Kind: k, Ident: ident}
+	if outer := s.parent.lookup(name); outer != nil && outer.Kind != Global {
+		b.Shadows = outer
+	}
+	if prev := s.names[name]; prev != nil && prev.Kind != Global && b.Shadows == nil {
+		b.Shadows = prev
+	}
+	s.names[name] = b
+	if k != Global {
+		r.info.Bindings = append(r.info.Bindings, b)
+	}
+}
+
+func (r *resolver) walk(n *Node, s *scope) {
+	switch n.Kind() {
+	case kind.Identifier:
+		if b := s.lookup(n.Utf8Text(r.src)); b != nil {
+			b.Uses = append(b.Uses, n)
+		}
+	case kind.FunctionDeclaration, kind.AnonymousFunction:
+		inner := &scope{parent: s, names: make(map[string]*Binding)}
+		for _, c := range children(n) {
+			switch {
+			case c.Kind() == kind.ParameterList:
+				for _, p := range children(c) {
+					if name := p.ChildByFieldName("name"); p.Kind() == kind.Parameter && name != nil {
+						r.declare(inner, name, Param)
+					}
+				}
+			case c.Kind() == kind.Block:
+				r.walk(c, inner)
+			}
+		}
+	case kind.Block:
+		inner := &scope{parent: s, names: make(map[string]*Binding)}
+		for _, c := range children(n) {
+			r.walk(c, inner)
+		}
+	case kind.ConstDeclaration:
+		if value := n.ChildByFieldName("value"); value != nil {
+			r.walk(value, s)
+		}
+		// top-level declarations were hoisted by Resolve.
+		if name := n.ChildByFieldName("name"); name != nil && s.parent != nil {
+			k := Const
+			if n.Child(0).Kind() == "var" {
+				k = Var
+			}
+			r.declare(s, name, k)
+		}
+	case kind.ForStatement:
+		inner := &scope{parent: s, names: make(map[string]*Binding)}
+		for _, c := range children(n) {
+			switch {
+			case c.Kind() == kind.Identifier && len(inner.names) == 0:
+				r.declare(inner, c, For)
+			case c.Kind() == kind.Block:
+				r.walk(c, inner)
+			default:
+				r.walk(c, s)
+			}
+		}
+	case kind.MatchArm:
+		inner := &scope{parent: s, names: make(map[string]*Binding)}
+		for _, c := range children(n) {
+			if c.Kind() == kind.Pattern {
+				r.bindPattern(c, inner)
+				continue
+			}
+			r.walk(c, inner)
+		}
+	case kind.MemberExpression:
+		// the property is not a reference.
+		if c := n.NamedChild(0); c != nil {
+			r.walk(c, s)
+		}
+	case kind.RecordExpression:
+		// field names precede a ':' and are not references.
+		kids := children(n)
+		for i, c := range kids {
+			if c.Kind() == kind.Identifier && i+1 < len(kids) && kids[i+1].Kind() == ":" {
+				continue
+			}
+			r.walk(c, s)
+		}
+	case kind.PackageDeclaration, kind.ImportDeclaration, kind.TypeDeclaration, kind.DomainDeclaration,
+		kind.ErrorDeclaration, kind.CapabilityDeclaration, kind.UseDeclaration:
+		// nothing in here refers to locals.
+	default:
+		for _, c := range children(n) {
+			r.walk(c, s)
+		}
+	}
+}
+
+func (r *resolver) bindPattern(p *Node, s *scope) {
+	c := p.NamedChild(0)
+	if c == nil {
+		return
+	}
+	switch c.Kind() {
+	case kind.Identifier:
+		r.declare(s, c, PatternBinding)
+	case kind.DestructuringPattern:
+		for _, id := range children(c) {
+			if id.Kind() == kind.Identifier {
+				r.declare(s, id, PatternBinding)
+			}
+		}
+	}
+}
+
+func children(n *Node) []*Node {
+	out := make([]*Node, n.ChildCount())
+	for i := range out {
+		out[i] = n.Child(uint(i))
+	}
+	return out
+}
@@ -0,0 +1,674 @@
+// Package multichecker is the command-line driver shared by ferrule lint
+// tools.
+//
+// A tool is a main package that passes its analyzers to Main:
+//
+//	func main() {
+//		multichecker.Main(unused.Analyzer, mycompany.Analyzer)
+//	}
+//
+// The resulting command takes files and directories, parses every .fe file
+// found and prints the diagnostics of the enabled analyzers as
+//
+//	file:line:col: message (analyzer)
+//
+// Every analyzer gets a boolean flag of its own name, so -shadow=false turns
+// the shadow analyzer off, and its own flags under its name and a dot.
+// Optional analyzers are off unless their flag or the configuration turns
+// them on. With -fix the first suggested fix of every
+// diagnostic is applied to the file in place, skipping fixes that conflict
+// with one already applied. The exit status is 1 if any diagnostic or
+// syntax error was reported and 2 on usage, configuration or I/O errors.
+//
+// The [lint] table of the project's ferrule.toml, looked up from each path
+// given, turns analyzers off or changes their severity, and files its
+// ignore patterns exclude are skipped when walking directories; see
+// package config. So are .git directories and what .gitignore and
+// .ferruleignore files exclude, as by package walk. An analyzer turned off on the command line stays off.
+// Generated files, with a "// Code generated ... DO NOT EDIT." header or
+// matching the generated paths of the configuration, are only checked for
+// syntax errors, unless its generated.lint setting is true.
+//
+// A Markdown file, one ending in .md, named on the command line has the
+// ferrule code blocks of its examples checked for syntax errors, reported
+// at their place in the file; see package markdown. Analyzers do not run
+// on examples, which are mostly fragments.
+//
+// Analyzers that need the index of the project are given one of the
+// directory holding ferrule.toml or, without one, of the path given or the
+// directory of the file given, built once per run.
+//
+// Instead of paths, the command can lint standard input: with
+// -stdin-filepath path, as the file at path, which need not exist but
+// selects the configuration and names the file in diagnostics, and with
+// -batch, as a series of records of a path and a source, each followed by
+// a NUL byte. In batch mode the diagnostics of every record are printed as
+// soon as it is read, followed by a NUL byte, so that a client can tell
+// where the answer to each ends. Neither applies fixes; the index, if
+// needed, is that of the files on disk.
+//
+// With -diff file, only the diagnostics and syntax errors on lines the
+// unified diff in file adds or changes are reported, so that a code base
+// can adopt the linter without first fixing everything it finds:
+//
+//	git diff -U0 main | ferrule-lint -diff - .
+//
+// Paths in the diff match the files linted if they end them, as those of
+// git diff, relative to the top of the work tree, do. Fixes are applied
+// only for the diagnostics reported. The diff cannot come from standard
+// input in batch mode.
+//
+// With -format, the findings are printed all at once, in batch mode once
+// per record, as a checkstyle XML report, GitHub Actions workflow
+// commands, a JSON array, a reviewdog rdjson result or a SARIF log, for
+// Jenkins, annotations of pull requests, scripts, reviewdog and GitHub
+// code scanning; see package report. Syntax errors have the category
+// "syntax" there.
+//
+// With -baseline file, findings recorded in the baseline file are not
+// reported, and with -write-baseline as well the findings are recorded in
+// it instead, so that a code base adopting the linter is held to it for
+// new findings only; see package baseline. Syntax errors are always
+// reported.
+package multichecker
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/baseline"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/report"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/internal/batch"
+	"github.com/karol-broda/ferrule/bindings/go/markdown"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+// Main runs the driver over the command line arguments and exits.
+func Main(analyzers ...*analysis.Analyzer) {
+	progname := filepath.Base(os.Args[0])
+	if err := analysis.Validate(analyzers); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+		os.Exit(2)
+	}
+	set := flag.NewFlagSet(progname, flag.ExitOnError)
+	enabled := flags(set, analyzers)
+	fix := set.Bool("fix", false, "apply suggested fixes in place")
+	stdinPath := set.String("stdin-filepath", "", "lint standard input as the file at `path`")
+	batchMode := set.Bool("batch", false, "lint NUL-delimited records of path and source read from standard input")
+	diffFile := set.String("diff", "", "report only findings on lines the unified diff in `file` changes (- for standard input)")
+	format := set.String("format", "text", "output `format`: text, "+strings.Join(report.Formats(), ", "))
+	baselineFile := set.String("baseline", "", "do not report the findings recorded in the baseline `file`")
+	writeBaseline := set.Bool("write-baseline", false, "record the findings in the -baseline file instead of reporting them")
+	set.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %[1]s [flags] path ...\n       %[1]s [flags] -stdin-filepath path\n       %[1]s [flags] -batch\n\nAnalyzers:\n", progname)
+		for _, a := range analyzers {
+			summary, _, _ := strings.Cut(a.Doc, "\n")
+			fmt.Fprintf(os.Stderr, "  %-12s %s\n", a.Name, summary)
+		}
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		set.PrintDefaults()
+	}
+	set.Parse(os.Args[1:])
+	stdin := *stdinPath != "" || *batchMode
+	if stdin && (set.NArg() > 0 || *fix || (*stdinPath != "" && *batchMode)) || !stdin && set.NArg() == 0 {
+		set.Usage()
+		os.Exit(2)
+	}
+	opts := Options{Fix: *fix, Format: *format}
+	if *diffFile != "" {
+		if *diffFile == "-" && stdin {
+			fmt.Fprintf(os.Stderr, "%s: cannot read the diff from standard input along with the source\n", progname)
+			os.Exit(2)
+		}
+		diff, err := readDiff(*diffFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+			os.Exit(2)
+		}
+		opts.Diff = diff
+	}
+	if *baselineFile != "" {
+		var err error
+		if *writeBaseline {
+			opts.Baseline, err = baseline.New(*baselineFile)
+		} else {
+			opts.Baseline, err = baseline.Load(*baselineFile)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", progname, err)
+			os.Exit(2)
+		}
+		opts.WriteBaseline = *writeBaseline
+	} else if *writeBaseline {
+		fmt.Fprintf(os.Stderr, "%s: -write-baseline needs -baseline\n", progname)
+		os.Exit(2)
+	}
+	on, undecided := enabled()
+	d := newDriver(analyzers, on, opts, os.Stdout, os.Stderr)
+	for _, a := range undecided {
+		d.on[a.Name] = true
+	}
+	if stdin {
+		os.Exit(d.runStdin(*stdinPath, os.Stdin))
+	}
+	os.Exit(d.run(set.Args()))
+}
+
+// flags registers an enable flag per analyzer, and the flags of the
+// analyzers, on set. It returns a function yielding, once set has been
+// parsed, the analyzers enabled and the optional ones whose flag was not
+// given, which the configuration may turn on.
+func flags(set *flag.FlagSet, analyzers []*analysis.Analyzer) func() (on, undecided []*analysis.Analyzer) {
+	enabled := make([]*bool, len(analyzers))
+	for i, a := range analyzers {
+		summary, _, _ := strings.Cut(a.Doc, "\n")
+		usage := "enable " + a.Name + " analysis: " + summary
+		if a.Optional {
+			usage += " (off by default)"
+		}
+		enabled[i] = set.Bool(a.Name, !a.Optional, usage)
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			set.Var(f.Value, a.Name+"."+f.Name, f.Usage)
+		})
+	}
+	return func() (on, undecided []*analysis.Analyzer) {
+		given := make(map[string]bool)
+		set.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		for i, a := range analyzers {
+			switch {
+			case *enabled[i]:
+				on = append(on, a)
+			case a.Optional && !given[a.Name]:
+				undecided = append(undecided, a)
+			}
+		}
+		return on, undecided
+	}
+}
+
+func readDiff(name string) (*analysis.Diff, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return analysis.ParseDiff(data)
+}
+
+// Options are the settings of a run that stand for the flags of the
+// command.
+type Options struct {
+	// Fix applies the suggested fixes of the diagnostics reported to the
+	// files in place. It is ignored for standard input.
+	Fix bool
+	// Diff, if not nil, limits the diagnostics reported to those on lines
+	// it changes.
+	Diff *analysis.Diff
+	// Format is the output format: "text", the default, for a line per
+	// diagnostic, or one of report.Formats.
+	Format string
+	// Baseline, if not nil, holds the findings not to report.
+	Baseline *baseline.Baseline
+	// WriteBaseline records the findings in Baseline instead of reporting
+	// them, and writes it at the end of the run.
+	WriteBaseline bool
+	// FS, if not nil, is the file system the sources and the project
+	// indexes are read from, in place of the disk. The configuration files
+	// are still read from the disk, and Fix writes to it.
+	FS vfs.FS
+}
+
+// Run applies the analyzers to every .fe file under paths, writing
+// diagnostics to stdout and errors to stderr, and returns the exit status.
+// If fix is set, suggested fixes are written back to the files.
+func Run(paths []string, analyzers []*analysis.Analyzer, fix bool, stdout, stderr io.Writer) int {
+	return RunWith(paths, analyzers, Options{Fix: fix}, stdout, stderr)
+}
+
+// RunWith is like Run with the settings opts.
+func RunWith(paths []string, analyzers []*analysis.Analyzer, opts Options, stdout, stderr io.Writer) int {
+	return newDriver(analyzers, analyzers, opts, stdout, stderr).run(paths)
+}
+
+// RunStdin lints standard input as the file at path or, if path is "", as
+// a series of batch records, writing diagnostics to stdout and errors to
+// stderr, and returns the exit status.
+func RunStdin(path string, stdin io.Reader, analyzers []*analysis.Analyzer, opts Options, stdout, stderr io.Writer) int {
+	return newDriver(analyzers, analyzers, opts, stdout, stderr).runStdin(path, stdin)
+}
+
+// driver holds the state of a run.
+type driver struct {
+	// all are the analyzers the configuration may name, and on the names
+	// of those enabled on the command line. Optional analyzers turned on
+	// there are copies in all that are no longer optional.
+	all      []*analysis.Analyzer
+	on       map[string]bool
+	opts     Options
+	indexes  map[string]*index.Index // by root
+	findings []report.Finding        // not yet printed, unless the format is text
+	stdout   io.Writer
+	stderr   io.Writer
+	progname string
+}
+
+func newDriver(all, analyzers []*analysis.Analyzer, opts Options, stdout, stderr io.Writer) *driver {
+	on := make(map[string]bool, len(analyzers))
+	for _, a := range analyzers {
+		on[a.Name] = true
+	}
+	all = slices.Clone(all)
+	for i, a := range all {
+		if a.Optional && on[a.Name] {
+			cp := *a
+			cp.Optional = false
+			all[i] = &cp
+		}
+	}
+	return &driver{
+		all:      all,
+		on:       on,
+		opts:     opts,
+		indexes:  make(map[string]*index.Index),
+		stdout:   stdout,
+		stderr:   stderr,
+		progname: filepath.Base(os.Args[0]),
+	}
+}
+
+// text reports whether diagnostics are printed as lines of text as they
+// are found, rather than gathered for report.Encode.
+// fsys returns the file system the sources are read from.
+func (d *driver) fsys() vfs.FS { return vfs.Or(d.opts.FS) }
+
+func (d *driver) text() bool {
+	return d.opts.Format == "" || d.opts.Format == "text"
+}
+
+// valid checks the options, printing what is wrong with them.
+func (d *driver) valid() bool {
+	switch {
+	case !d.text() && !slices.Contains(report.Formats(), d.opts.Format):
+		fmt.Fprintf(d.stderr, "%s: unknown format %q\n", d.progname, d.opts.Format)
+		return false
+	case d.opts.WriteBaseline && d.opts.Baseline == nil:
+		fmt.Fprintf(d.stderr, "%s: no baseline to write\n", d.progname)
+		return false
+	}
+	return true
+}
+
+// finish writes the baseline, if recording one.
+func (d *driver) finish() error {
+	if !d.opts.WriteBaseline {
+		return nil
+	}
+	return d.opts.Baseline.Write()
+}
+
+// flush prints the findings gathered since the last call.
+func (d *driver) flush() error {
+	if d.text() {
+		return nil
+	}
+	r := &report.Report{Tool: d.progname, Findings: d.findings}
+	for _, a := range d.all {
+		if d.on[a.Name] {
+			r.Analyzers = append(r.Analyzers, a)
+		}
+	}
+	d.findings = nil
+	return report.Encode(d.stdout, d.opts.Format, r)
+}
+
+// run lints the paths and returns the exit status.
+func (d *driver) run(paths []string) int {
+	if !d.valid() {
+		return 2
+	}
+	status := 0
+	for _, path := range paths {
+		err := func() error {
+			dir, err := projectDir(d.fsys(), path)
+			if err != nil {
+				return err
+			}
+			cfg, err := config.ForDir(dir)
+			if err != nil {
+				return err
+			}
+			enabled, idx, err := d.enable(dir, cfg)
+			if err != nil {
+				return err
+			}
+			return d.walk(path, cfg, enabled, idx, &status)
+		}()
+		if err != nil {
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			status = 2
+		}
+	}
+	if err := d.flush(); err != nil {
+		fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+		return 2
+	}
+	if err := d.finish(); err != nil {
+		fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+		return 2
+	}
+	return status
+}
+
+// runStdin lints standard input as the file at path or, if path is "", as
+// a series of records, and returns the exit status.
+func (d *driver) runStdin(path string, stdin io.Reader) int {
+	if !d.valid() {
+		return 2
+	}
+	if path != "" {
+		found, err := func() (bool, error) {
+			src, err := io.ReadAll(stdin)
+			if err != nil {
+				return false, err
+			}
+			found, err := d.checkInput(path, src)
+			if err != nil {
+				return false, err
+			}
+			if err := d.flush(); err != nil {
+				return false, err
+			}
+			return found, d.finish()
+		}()
+		switch {
+		case err != nil:
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			return 2
+		case found:
+			return 1
+		}
+		return 0
+	}
+
+	r := batch.NewReader(stdin)
+	status := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			if err := d.finish(); err != nil {
+				fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+				return 2
+			}
+			return status
+		}
+		if err != nil {
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			return 2
+		}
+		found, err := d.checkInput(rec.Path, rec.Source)
+		if err != nil {
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			status = 2
+		} else if found && status == 0 {
+			status = 1
+		}
+		if err := d.flush(); err != nil {
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			return 2
+		}
+		if _, err := d.stdout.Write([]byte{0}); err != nil {
+			fmt.Fprintf(d.stderr, "%s: %v\n", d.progname, err)
+			return 2
+		}
+	}
+}
+
+// checkInput lints src, read from standard input, as the file at path and
+// reports whether anything was found.
+func (d *driver) checkInput(path string, src []byte) (bool, error) {
+	dir := filepath.Dir(path)
+	cfg, err := config.ForDir(dir)
+	if err != nil {
+		return false, err
+	}
+	if cfg.Ignored(path, false) {
+		return false, nil
+	}
+	if filepath.Ext(path) == ".md" {
+		return d.checkMarkdown(path, src)
+	}
+	enabled, idx, err := d.enable(dir, cfg)
+	if err != nil {
+		return false, err
+	}
+	return d.check(path, src, forFile(cfg, path, src, enabled), idx, false)
+}
+
+// projectDir returns path if it is a directory and the directory holding
+// it otherwise.
+func projectDir(fsys vfs.FS, path string) (string, error) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	return path, nil
+}
+
+// enable returns the analyzers that cfg leaves on and that are on, with
+// the index of the project of dir if any of them needs one.
+func (d *driver) enable(dir string, cfg *config.Config) ([]*analysis.Analyzer, *index.Index, error) {
+	configured, err := cfg.Analyzers(d.all)
+	if err != nil {
+		return nil, nil, err
+	}
+	var enabled []*analysis.Analyzer
+	var idx *index.Index
+	for _, a := range configured {
+		if !d.on[a.Name] {
+			continue
+		}
+		enabled = append(enabled, a)
+		if a.NeedsIndex && idx == nil {
+			if idx, err = d.projectIndex(dir, cfg); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return enabled, idx, nil
+}
+
+// projectIndex returns the index of the project below the directory of
+// cfg or, without a configuration file, below dir, building it unless it
+// was built already.
+func (d *driver) projectIndex(dir string, cfg *config.Config) (*index.Index, error) {
+	root := cfg.Dir
+	if root == "" {
+		root = dir
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if idx := d.indexes[root]; idx != nil {
+		return idx, nil
+	}
+	idx := index.New(root)
+	idx.SetFS(d.fsys())
+	if err := idx.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	d.indexes[root] = idx
+	return idx, nil
+}
+
+// walk lints the .fe files under path that cfg does not ignore, raising
+// *status to 1 if anything is found. idx, if not nil, is the index of the
+// project.
+func (d *driver) walk(path string, cfg *config.Config, analyzers []*analysis.Analyzer, idx *index.Index, status *int) error {
+	fsys := d.fsys()
+	return walk.WalkDir(fsys, path, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && cfg.Ignored(p, e.IsDir()) {
+			if e.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if e.IsDir() || (p != path && filepath.Ext(p) != ".fe") {
+			return nil
+		}
+		src, err := fsys.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var found bool
+		if p == path && filepath.Ext(p) == ".md" {
+			found, err = d.checkMarkdown(p, src)
+		} else {
+			found, err = d.check(p, src, forFile(cfg, p, src, analyzers), idx, d.opts.Fix)
+		}
+		if err != nil {
+			return err
+		}
+		if found && *status == 0 {
+			*status = 1
+		}
+		return nil
+	})
+}
+
+// forFile returns the analyzers to run on the file name, whose source is
+// src: none if it is generated and cfg does not have it linted.
+func forFile(cfg *config.Config, name string, src []byte, analyzers []*analysis.Analyzer) []*analysis.Analyzer {
+	if !cfg.Generated.Lint && cfg.IsGenerated(name, src) {
+		return nil
+	}
+	return analyzers
+}
+
+// reported reports whether a diagnostic of the file name covering r is
+// to be reported.
+func (d *driver) reported(name string, r tree_sitter.Range) bool {
+	return d.opts.Diff == nil || d.opts.Diff.Touches(name, r)
+}
+
+// check lints one file and reports whether anything was found. Files with
+// syntax errors only get their syntax errors reported.
+func (d *driver) check(name string, src []byte, analyzers []*analysis.Analyzer, idx *index.Index, fix bool) (bool, error) {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return false, err
+	}
+	defer tree.Close()
+	if tree.HasError() {
+		return d.syntaxErrors(name, src, tree.Diagnostics()), nil
+	}
+	rel := ""
+	if idx != nil {
+		if abs, err := filepath.Abs(name); err == nil {
+			if r, err := filepath.Rel(idx.Root(), abs); err == nil {
+				rel = filepath.ToSlash(r)
+			}
+		}
+	}
+	diags, err := analysis.RunProject(idx, rel, tree, analyzers...)
+	if err != nil {
+		return false, err
+	}
+	if d.opts.Diff != nil {
+		diags = d.opts.Diff.Filter(name, diags)
+	}
+	if b := d.opts.Baseline; b != nil {
+		var kept []analysis.Diagnostic
+		for _, diag := range diags {
+			switch {
+			case d.opts.WriteBaseline:
+				b.Add(name, tree, diag)
+			case !b.Match(name, tree, diag):
+				kept = append(kept, diag)
+			}
+		}
+		diags = kept
+	}
+	var fixes []analysis.SuggestedFix
+	for _, diag := range diags {
+		if d.text() {
+			fmt.Fprintf(d.stdout, "%s:%s\n", name, diag)
+		} else {
+			d.findings = append(d.findings, report.Finding{Path: name, Source: src, Diagnostic: diag})
+		}
+		if len(diag.SuggestedFixes) > 0 {
+			fixes = append(fixes, diag.SuggestedFixes[0])
+		}
+	}
+	if fix && len(fixes) > 0 {
+		if err := applyFixes(name, src, fixes); err != nil {
+			return false, err
+		}
+	}
+	return len(diags) > 0, nil
+}
+
+// syntaxErrors reports the syntax errors of the file name, whose source
+// is src, and whether any were reported.
+func (d *driver) syntaxErrors(name string, src []byte, diags []ferrule.Diagnostic) bool {
+	found := false
+	for _, diag := range diags {
+		if !d.reported(name, diag.Range) {
+			continue
+		}
+		if d.text() {
+			fmt.Fprintf(d.stdout, "%s:%s\n", name, diag)
+		} else {
+			d.findings = append(d.findings, report.SyntaxError(name, src, diag))
+		}
+		found = true
+	}
+	return found
+}
+
+// checkMarkdown reports the syntax errors of the ferrule examples of a
+// Markdown file and whether there were any.
+func (d *driver) checkMarkdown(name string, src []byte) (bool, error) {
+	diags, err := markdown.Check(context.Background(), src)
+	if err != nil {
+		return false, err
+	}
+	return d.syntaxErrors(name, src, diags), nil
+}
+
+func applyFixes(name string, src []byte, fixes []analysis.SuggestedFix) error {
+	out, _, err := analysis.ApplyFixes(src, fixes)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, out, info.Mode().Perm())
+}
@@ -0,0 +1,398 @@
+package multichecker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/baseline"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/multichecker"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/deadcode"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"clean.fe":  "function f() -> i32 { return 1; }\n",
+		"lint.fe":   "function f() -> i32 { const x = 1; return 2; }\n",
+		"broken.fe": "function f() -> i32 { return (1; }\n",
+		"skip.txt":  "not ferrule",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unreachable.Analyzer, unused.Analyzer}
+	if code := multichecker.Run([]string{dir}, analyzers, false, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	got := stdout.String()
+	for _, want := range []string{
+		filepath.Join(dir, "lint.fe") + ":1:29: x declared and not used (unused)\n",
+		filepath.Join(dir, "broken.fe") + ":1:",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "clean.fe") || strings.Contains(got, "skip.txt") {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	stdout.Reset()
+	if code := multichecker.Run([]string{filepath.Join(dir, "clean.fe")}, analyzers, false, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("clean file: exit code %d, output %q", code, stdout.String())
+	}
+	if code := multichecker.Run([]string{filepath.Join(dir, "missing.fe")}, analyzers, false, &stdout, &stderr); code != 2 {
+		t.Errorf("missing file: exit code %d, want 2", code)
+	}
+}
+
+func TestRunFix(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "fix.fe")
+	src := "function f(n: i32) -> i32 {\n  const x = 1;\n  match n {\n    _ -> { return 0; }\n    1 -> { return 1; }\n  }\n}\n"
+	if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unreachable.Analyzer, unused.Analyzer}
+	if code := multichecker.Run([]string{name}, analyzers, true, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "function f(n: i32) -> i32 {\n  match n {\n    _ -> { return 0; }\n  }\n}\n"
+	if string(got) != want {
+		t.Errorf("fixed file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ferrule.toml":     "ignore = [\"vendor/\"]\n\n[lint]\nunused = false\n",
+		"lint.fe":          "function f() -> i32 { const x = 1; return 2; }\n",
+		"vendor/broken.fe": "function f() -> i32 { return (1; }\n",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(name), 0o755)
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unreachable.Analyzer, unused.Analyzer}
+	if code := multichecker.Run([]string{dir}, analyzers, false, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("exit code %d, output %q; stderr: %s", code, stdout.String(), stderr.String())
+	}
+
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[lint]\nshadowing = false\n"), 0o644)
+	stderr.Reset()
+	if code := multichecker.Run([]string{dir}, analyzers, false, &stdout, &stderr); code != 2 || !strings.Contains(stderr.String(), "unknown analyzers shadowing") {
+		t.Errorf("unknown analyzer: exit code %d, stderr %q", code, stderr.String())
+	}
+
+	// an optional analyzer passed to Run is on, unless turned off.
+	optional := *unused.Analyzer
+	optional.Optional = true
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("ignore = [\"vendor/\"]\n"), 0o644)
+	stdout.Reset()
+	if code := multichecker.Run([]string{dir}, []*analysis.Analyzer{&optional}, false, &stdout, &stderr); code != 1 || !strings.Contains(stdout.String(), "(unused)") {
+		t.Errorf("optional analyzer: exit code %d, output %q", code, stdout.String())
+	}
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("ignore = [\"vendor/\"]\n\n[lint]\nunused = false\n"), 0o644)
+	stdout.Reset()
+	if code := multichecker.Run([]string{dir}, []*analysis.Analyzer{&optional}, false, &stdout, &stderr); code != 0 {
+		t.Errorf("optional analyzer turned off: exit code %d, output %q", code, stdout.String())
+	}
+}
+
+func TestRunGenerated(t *testing.T) {
+	dir := t.TempDir()
+	header := "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\n"
+	files := map[string]string{
+		"api.pb.fe":    header + "function f() -> i32 { const x = 1; return 2; }\n",
+		"broken.pb.fe": header + "function f() -> i32 { return (1; }\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	if code := multichecker.Run([]string{dir}, analyzers, false, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1 for the syntax error; stderr: %s", code, stderr.String())
+	}
+	if got := stdout.String(); strings.Contains(got, "api.pb.fe") || !strings.Contains(got, "broken.pb.fe:2:") {
+		t.Errorf("output %q, want the syntax error of broken.pb.fe alone", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[generated]\nlint = true\n"), 0o644)
+	stdout.Reset()
+	multichecker.Run([]string{dir}, analyzers, false, &stdout, &stderr)
+	if got := stdout.String(); !strings.Contains(got, "api.pb.fe:2:29: x declared and not used (unused)") {
+		t.Errorf("with generated.lint: output %q", got)
+	}
+}
+
+func TestRunIndex(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.fe":    "package app;\nfunction main() -> i32 { return helper(); }\n",
+		"helpers.fe": "package app;\nfunction helper() -> i32 { return 1; }\nfunction orphan() -> i32 { return 2; }\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{deadcode.Analyzer}
+	if code := multichecker.Run([]string{filepath.Join(dir, "helpers.fe")}, analyzers, false, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	want := filepath.Join(dir, "helpers.fe") + ":3:10: function orphan is never used (deadcode)\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// TestRunRequires checks that the findings deadcode repeats from unused
+// go when unused is turned off, here by every setting but its flag.
+func TestRunRequires(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "lint.fe")
+	if err := os.WriteFile(name, []byte("function main() -> i32 { const x = 1; return 2; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	all := []*analysis.Analyzer{deadcode.Analyzer, unreachable.Analyzer, unused.Analyzer}
+
+	var stdout, stderr bytes.Buffer
+	if code := multichecker.Run([]string{name}, all, false, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := name + ":1:32: x declared and not used (unused)\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := multichecker.Run([]string{name}, all[:2], false, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("without unused: exit code %d, output %q", code, stdout.String())
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[lint]\nunused = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := multichecker.Run([]string{name}, all, false, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("unused = false: exit code %d, output %q", code, stdout.String())
+	}
+}
+
+func TestRunFS(t *testing.T) {
+	// the project exists only in memory.
+	dir := filepath.Join(t.TempDir(), "virtual")
+	fsys := vfs.NewOverlay(vfs.Map{
+		filepath.Join(dir, "main.fe"):    "package app;\nfunction main() -> i32 { return helper(); }\n",
+		filepath.Join(dir, "helpers.fe"): "package app;\nfunction helper() -> i32 { return 1; }\nfunction orphan() -> i32 { return 2; }\n",
+	})
+	analyzers := []*analysis.Analyzer{deadcode.Analyzer}
+	var stdout, stderr bytes.Buffer
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{FS: fsys}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	want := filepath.Join(dir, "helpers.fe") + ":3:10: function orphan is never used (deadcode)\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// an unsaved buffer calling orphan is seen by the index.
+	fsys.Set(filepath.Join(dir, "main.fe"), []byte("package app;\nfunction main() -> i32 { return helper() + orphan(); }\n"))
+	stdout.Reset()
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{FS: fsys}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("with the buffer: exit code %d, output %q, stderr %s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunMarkdown(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "README.md")
+	doc := "# Usage\n\n```ferrule\nfunction f() -> i32 { const x = 1; return 2; }\n```\n\n```ferrule\nconst = 1;\n```\n"
+	if err := os.WriteFile(name, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	if code := multichecker.Run([]string{name}, analyzers, false, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	got := stdout.String()
+	if !strings.HasPrefix(got, name+":8:") || strings.Count(got, "\n") != 1 {
+		t.Errorf("want one syntax error on line 8, got:\n%s", got)
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("ignore = [\"gen/\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	name := filepath.Join(dir, "new.fe")
+	src := "function f() -> i32 { const x = 1; return 2; }\n"
+	if code := multichecker.RunStdin(name, strings.NewReader(src), analyzers, multichecker.Options{}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := name + ":1:29: x declared and not used (unused)\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	ignored := filepath.Join(dir, "gen", "out.fe")
+	if code := multichecker.RunStdin(ignored, strings.NewReader(src), analyzers, multichecker.Options{}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("ignored file: exit code %d, output %q", code, stdout.String())
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	in := "lint.fe\x00function f() -> i32 { const x = 1; return 2; }\n\x00" +
+		"clean.fe\x00function f() -> i32 { return 1; }\n\x00" +
+		"broken.fe\x00function f() -> i32 { return (1; }\n\x00"
+	if code := multichecker.RunStdin("", strings.NewReader(in), analyzers, multichecker.Options{}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	answers := strings.Split(stdout.String(), "\x00")
+	if len(answers) != 4 || answers[3] != "" {
+		t.Fatalf("got %q, want three answers", stdout.String())
+	}
+	if want := "lint.fe:1:29: x declared and not used (unused)\n"; answers[0] != want {
+		t.Errorf("lint.fe: got %q, want %q", answers[0], want)
+	}
+	if answers[1] != "" {
+		t.Errorf("clean.fe: got %q, want nothing", answers[1])
+	}
+	if !strings.HasPrefix(answers[2], "broken.fe:1:") {
+		t.Errorf("broken.fe: got %q, want a syntax error", answers[2])
+	}
+
+	if code := multichecker.RunStdin("", strings.NewReader("lint.fe\x00const"), analyzers, multichecker.Options{}, &stdout, &stderr); code != 2 {
+		t.Errorf("truncated input: exit code %d, want 2", code)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	src := "function f() -> i32 { const old = 1; return 2; }\nfunction g() -> i32 { const x = 1; return (3; }\nfunction h() -> i32 { const y = 1; return 4; }\n"
+	name := filepath.Join(dir, "src", "main.fe")
+	os.MkdirAll(filepath.Dir(name), 0o755)
+	if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := analysis.ParseDiff([]byte("--- a/src/main.fe\n+++ b/src/main.fe\n@@ -2,0 +3 @@\n+function h() -> i32 { const y = 1; return 4; }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the syntax error on line 2 is outside the diff.
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Diff: diff}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("file with syntax errors: exit code %d, output %q; stderr: %s", code, stdout.String(), stderr.String())
+	}
+
+	src = strings.Replace(src, "(3;", "3;", 1)
+	os.WriteFile(name, []byte(src), 0o644)
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Diff: diff}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := name + ":3:29: y declared and not used (unused)\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lint.fe":   "function f() -> i32 { const x = 1; return 2; }\n",
+		"broken.fe": "function f() -> i32 { return (1; }\n",
+		"clean.fe":  "function f() -> i32 { return 1; }\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Format: "json"}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	var findings []struct{ Path, Category string }
+	if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout.String())
+	}
+	if len(findings) != 2 || findings[0].Path != filepath.Join(dir, "broken.fe") || findings[0].Category != "syntax" || findings[1].Category != "unused" {
+		t.Errorf("findings = %+v", findings)
+	}
+
+	stdout.Reset()
+	in := "lint.fe\x00" + files["lint.fe"] + "\x00clean.fe\x00" + files["clean.fe"] + "\x00"
+	if code := multichecker.RunStdin("", strings.NewReader(in), analyzers, multichecker.Options{Format: "json"}, &stdout, &stderr); code != 1 {
+		t.Errorf("batch: exit code = %d, want 1; stderr: %s", code, stderr.String())
+	}
+	answers := strings.Split(stdout.String(), "\x00")
+	if len(answers) != 3 || !strings.Contains(answers[0], `"unused"`) || strings.TrimSpace(answers[1]) != "[]" {
+		t.Errorf("batch answers = %q", answers)
+	}
+
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Format: "yaml"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown format: exit code %d, want 2", code)
+	}
+}
+
+func TestRunBaseline(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "lint.fe")
+	old := "function f() -> i32 { const x = 1; return 2; }\n"
+	if err := os.WriteFile(name, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "baseline.json")
+	b, err := baseline.New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	analyzers := []*analysis.Analyzer{unused.Analyzer}
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Baseline: b, WriteBaseline: true}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Fatalf("writing: exit code %d, output %q; stderr: %s", code, stdout.String(), stderr.String())
+	}
+
+	os.WriteFile(name, []byte(old+"function g() -> i32 { const y = 1; return 3; }\n"), 0o644)
+	if b, err = baseline.Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if code := multichecker.RunWith([]string{dir}, analyzers, multichecker.Options{Baseline: b}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := name + ":2:29: y declared and not used (unused)\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,134 @@
+// Package deadcode defines an analyzer that reports code that is never
+// used: private functions no file of the package calls, local bindings
+// that are never referred to and match arms that can never be selected.
+package deadcode
+
+import (
+	"path"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+const Doc = `report code that is never used
+
+The deadcode analyzer reports top-level functions without pub that are
+never referred to in their file and never called from another file of
+their package, as the project index records calls. Files declaring the
+same package, or without a package declaration and in the same
+directory, form a package. A function main and names starting with an
+underscore are exempt. It also repeats the findings of the unused and
+unreachable analyzers, on which it builds: unused local bindings and
+match arms that can never be selected. Those are reported once, under
+the name of the analyzer that found them, and only while that analyzer
+is on.
+
+A comment
+
+	// ferrule:disable deadcode
+
+silences the reports on the line it ends, or in the declaration it
+leads when it stands on a line of its own, the repeated ones included;
+one disabling unused or unreachable silences those as well.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "deadcode",
+	Doc:        Doc,
+	NeedsIndex: true,
+	Requires:   []*analysis.Analyzer{unused.Analyzer, unreachable.Analyzer},
+	Run:        run,
+}
+
+func run(pass *analysis.Pass) error {
+	checkFunctions(pass)
+	for _, r := range pass.Analyzer.Requires {
+		for _, d := range pass.ResultOf[r] {
+			pass.Report(d)
+		}
+	}
+	return nil
+}
+
+// checkFunctions reports the private top-level functions that are never
+// used.
+func checkFunctions(pass *analysis.Pass) {
+	info := scope.Resolve(pass.Tree)
+	var called map[string]bool
+	for _, d := range info.Root.Definitions {
+		decl := d.Node.Parent()
+		if d.Kind != "function" || decl.Parent() == nil || decl.Parent().Kind() != kind.SourceFile {
+			continue
+		}
+		if d.Name == "main" || strings.HasPrefix(d.Name, "_") || isPublic(decl) || len(d.References) > 0 {
+			continue
+		}
+		if called == nil {
+			called = calledElsewhere(pass)
+		}
+		if called[d.Name] {
+			continue
+		}
+		pass.Report(analysis.Diagnostic{
+			Range:   d.Node.Range(),
+			Message: "function " + d.Name + " is never used",
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   "remove function " + d.Name,
+				TextEdits: []analysis.TextEdit{analysis.Delete(pass.Source, decl)},
+			}},
+		})
+	}
+}
+
+// calledElsewhere returns the names called from the other files of the
+// package of the file, as far as the index knows them.
+func calledElsewhere(pass *analysis.Pass) map[string]bool {
+	out := make(map[string]bool)
+	if pass.Index == nil {
+		return out
+	}
+	pkg := packageOf(pass.Tree.RootNode(), pass.Source)
+	for _, f := range pass.Index.Files() {
+		if f.Path == pass.Path || !samePackage(f, pkg, pass.Path) {
+			continue
+		}
+		for _, r := range f.References {
+			out[r.Name] = true
+		}
+	}
+	return out
+}
+
+// samePackage reports whether f belongs to the package pkg of the file at
+// name.
+func samePackage(f *index.File, pkg, name string) bool {
+	if pkg != "" || f.Package != "" {
+		return f.Package == pkg
+	}
+	return path.Dir(f.Path) == path.Dir(name)
+}
+
+// packageOf returns the path of the package declaration of the file, or
+// "".
+func packageOf(root *tree_sitter.Node, src []byte) string {
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		if c := root.NamedChild(i); c.Kind() == kind.PackageDeclaration {
+			if p := c.ChildByFieldName(field.Path); p != nil {
+				return p.Utf8Text(src)
+			}
+		}
+	}
+	return ""
+}
+
+func isPublic(decl *tree_sitter.Node) bool {
+	first := decl.Child(0)
+	return first != nil && first.Kind() == kind.KeywordPub
+}
@@ -0,0 +1,59 @@
+package deadcode_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/deadcode"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func Test(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata", deadcode.Analyzer)
+}
+
+// TestRepeated checks that the findings deadcode repeats from unused are
+// reported once, silenced by disabling either analyzer and gone with
+// unused turned off.
+func TestRepeated(t *testing.T) {
+	src := "function main() -> i32 {\n" +
+		"  const x = 1; // ferrule:disable deadcode\n" +
+		"  const y = 2; // ferrule:disable unused\n" +
+		"  const z = 3; // ferrule:ignore deadcode\n" +
+		"  const w = 4;\n" +
+		"  return 0;\n" +
+		"}\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	for _, tt := range []struct {
+		analyzers []*analysis.Analyzer
+		want      []string
+	}{
+		{[]*analysis.Analyzer{deadcode.Analyzer, unused.Analyzer, unreachable.Analyzer, analysis.Stale}, []string{"5:9: w declared and not used (unused)"}},
+		{[]*analysis.Analyzer{unused.Analyzer, deadcode.Analyzer}, []string{"5:9: w declared and not used (unused)"}},
+		// unused turned off, as by -unused=false.
+		{[]*analysis.Analyzer{deadcode.Analyzer, unreachable.Analyzer}, nil},
+		{[]*analysis.Analyzer{deadcode.Analyzer}, nil},
+	} {
+		diags, err := analysis.Run(tree, tt.analyzers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%v: got %q, want %q", tt.analyzers, got, tt.want)
+		}
+	}
+}
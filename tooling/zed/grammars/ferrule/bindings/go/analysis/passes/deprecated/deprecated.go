@@ -0,0 +1,135 @@
+// Package deprecated defines an analyzer that reports uses of deprecated
+// declarations.
+package deprecated
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+const Doc = `report uses of deprecated declarations
+
+The deprecated analyzer reports the names referring to a declaration
+whose doc comment holds a directive
+
+	// ferrule:deprecated use addAll instead
+
+repeating the text after it, which tells what to use instead. The
+declarations of the file and, as the project index records them, of the
+other files of the project are looked up by name; a name that some
+declaration not marked deprecated also has is not reported, nor is one
+referring to a local binding. Uses inside a deprecated declaration are
+not reported either, so that deprecated code may go on using itself.
+
+Editors strike the names reported through.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:       "deprecated",
+	Doc:        Doc,
+	NeedsIndex: true,
+	Run:        run,
+}
+
+func run(pass *analysis.Pass) error {
+	file := index.Extract(pass.Path, pass.Tree)
+	notes := deprecations(pass, file)
+	if len(notes) == 0 {
+		return nil
+	}
+	var within []tree_sitter.Range
+	for _, d := range file.Definitions {
+		if d.Deprecated {
+			within = append(within, d.Range)
+		}
+	}
+	for _, n := range uses(pass) {
+		name := n.Utf8Text(pass.Source)
+		note, ok := notes[name]
+		if !ok || inside(n, within) {
+			continue
+		}
+		msg := name + " is deprecated"
+		if note != "" {
+			msg += ": " + note
+		}
+		pass.Report(analysis.Diagnostic{
+			Range:   n.Range(),
+			Message: msg,
+			Tags:    []analysis.Tag{analysis.TagDeprecated},
+		})
+	}
+	return nil
+}
+
+// deprecations returns the notes of the deprecated declarations of the
+// file and the other indexed files, by name, leaving out the names some
+// declaration that is not deprecated has as well.
+func deprecations(pass *analysis.Pass, file *index.File) map[string]string {
+	files := []*index.File{file}
+	if pass.Index != nil {
+		for _, f := range pass.Index.Files() {
+			if f.Path != pass.Path {
+				files = append(files, f)
+			}
+		}
+	}
+	notes := make(map[string]string)
+	current := make(map[string]bool)
+	for _, f := range files {
+		for _, d := range f.Definitions {
+			switch {
+			case !d.Deprecated:
+				current[d.Name] = true
+			case notes[d.Name] == "":
+				notes[d.Name] = d.DeprecatedNote
+			}
+		}
+	}
+	for name := range current {
+		delete(notes, name)
+	}
+	return notes
+}
+
+// uses returns the names of the file that may refer to a top-level
+// declaration: the references that resolve to no local binding or to a
+// top-level one, and the type names outside the declarations they name.
+func uses(pass *analysis.Pass) []*tree_sitter.Node {
+	info := scope.Resolve(pass.Tree)
+	out := append([]*tree_sitter.Node(nil), info.Unresolved...)
+	for _, d := range info.Root.Definitions {
+		out = append(out, d.References...)
+	}
+	cursor := pass.Tree.RootNode().Walk()
+	defer cursor.Close()
+	for {
+		n := cursor.Node()
+		if n.Kind() == kind.TypeIdentifier {
+			if name := n.Parent().ChildByFieldName(field.Name); name == nil || name.Id() != n.Id() {
+				out = append(out, n)
+			}
+		} else if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return out
+			}
+		}
+	}
+}
+
+// inside reports whether n lies within one of ranges.
+func inside(n *tree_sitter.Node, ranges []tree_sitter.Range) bool {
+	for _, r := range ranges {
+		if r.StartByte <= n.StartByte() && n.EndByte() <= r.EndByte {
+			return true
+		}
+	}
+	return false
+}
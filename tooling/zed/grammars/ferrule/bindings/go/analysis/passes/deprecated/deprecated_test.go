@@ -0,0 +1,19 @@
+package deprecated_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/deprecated"
+)
+
+func Test(t *testing.T) {
+	for name, diags := range analysistest.Run(t, "testdata", deprecated.Analyzer) {
+		for _, d := range diags {
+			if len(d.Tags) != 1 || d.Tags[0] != analysis.TagDeprecated {
+				t.Errorf("%s: %v has tags %v, want [TagDeprecated]", name, d, d.Tags)
+			}
+		}
+	}
+}
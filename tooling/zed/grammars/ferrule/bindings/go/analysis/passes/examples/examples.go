@@ -0,0 +1,87 @@
+// Package examples defines analyzers that check the examples of doc
+// comments: that they parse, and that they are formatted.
+package examples
+
+import (
+	"context"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+const Doc = `report examples in doc comments that do not parse
+
+The examples analyzer parses the fenced code blocks of doc comments and
+reports their syntax errors where they occur in the comment. An example
+may be a whole file or the statements of a function body. Examples whose
+info string holds the word ignore, as in
+
+	// ` + "```" + `ignore
+
+are skipped.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "examples",
+	Doc:  Doc,
+	Run:  run,
+}
+
+const FormatDoc = `report examples in doc comments that are not formatted
+
+The examplefmt analyzer reports the examples of doc comments that parse
+but differ from what ferrulefmt would make of them, with a fix rewriting
+them in canonical style. Examples the examples analyzer skips are skipped
+too.`
+
+var FormatAnalyzer = &analysis.Analyzer{
+	Name:     "examplefmt",
+	Doc:      FormatDoc,
+	Severity: ferrule.SeverityInformation,
+	Run:      runFormat,
+}
+
+func run(pass *analysis.Pass) error {
+	for _, e := range doc.Examples(pass.Tree) {
+		if e.Ignored() {
+			continue
+		}
+		diags, err := e.Check(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, d := range diags {
+			pass.Report(analysis.Diagnostic{Range: d.Range, Message: "example " + of(e) + " does not parse: " + d.Message})
+		}
+	}
+	return nil
+}
+
+func runFormat(pass *analysis.Pass) error {
+	for _, e := range doc.Examples(pass.Tree) {
+		if e.Ignored() {
+			continue
+		}
+		formatted, err := e.Format()
+		if err != nil || formatted == e.Source {
+			continue
+		}
+		d := analysis.Diagnostic{Range: e.Range, Message: "example " + of(e) + " is not formatted"}
+		if start, end, text, ok := e.Replace(pass.Source, formatted); ok {
+			d.SuggestedFixes = []analysis.SuggestedFix{{
+				Message:   "format the example",
+				TextEdits: []analysis.TextEdit{{Start: start, End: end, NewText: []byte(text)}},
+			}}
+		}
+		pass.Report(d)
+	}
+	return nil
+}
+
+// of names the comment holding e.
+func of(e doc.Example) string {
+	if e.Decl == "" {
+		return "in the package comment"
+	}
+	return "of " + e.Decl
+}
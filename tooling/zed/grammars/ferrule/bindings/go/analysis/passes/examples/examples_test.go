@@ -0,0 +1,16 @@
+package examples_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/examples"
+)
+
+func Test(t *testing.T) {
+	analysistest.Run(t, "testdata/examples", examples.Analyzer)
+}
+
+func TestFormat(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata/examplefmt", examples.FormatAnalyzer)
+}
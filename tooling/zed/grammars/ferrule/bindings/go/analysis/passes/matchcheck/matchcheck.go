@@ -0,0 +1,196 @@
+// Package matchcheck defines an analyzer that reports match arms matching
+// what an earlier arm already does under another spelling, and matches
+// that plainly leave cases out.
+package matchcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/eval"
+)
+
+const Doc = `report duplicate match arms and missing cases
+
+The matchcheck analyzer compares the patterns of match arms by what they
+match rather than how they are spelled: 0x10 and 16 are the same integer,
+and Circle { r } and Circle { radius } the same variant. An arm whose
+pattern an earlier arm without a guard already matches is reported, with
+a fix removing it; arms spelled exactly alike are left to the
+unreachable analyzer.
+
+It also reports matches without a catch-all arm that visibly miss cases:
+a match on true that lacks false, or the other way around, and a match
+on the variants of a union type declared in the same file that lacks
+some of them. Arms with guards cover nothing.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "matchcheck",
+	Doc:  Doc,
+	Run:  run,
+}
+
+// union is a union type declared in the file.
+type union struct {
+	name     string
+	variants []string
+}
+
+func run(pass *analysis.Pass) error {
+	root := pass.Tree.Root()
+	unions := make(map[string][]*union) // by variant name
+	for _, item := range root.Items() {
+		decl, ok := item.(*ast.TypeDeclaration)
+		if !ok || decl.Name() == nil {
+			continue
+		}
+		t, ok := decl.Type().(*ast.UnionType)
+		if !ok {
+			continue
+		}
+		u := &union{name: decl.Name().Text(pass.Source)}
+		for _, v := range t.UnionVariants() {
+			if v.Name() != nil {
+				u.variants = append(u.variants, v.Name().Text(pass.Source))
+			}
+		}
+		for _, v := range u.variants {
+			unions[v] = append(unions[v], u)
+		}
+	}
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch m := n.(type) {
+		case *ast.MatchStatement:
+			checkMatch(pass, m.Subject(), m.Arms(), unions)
+		case *ast.MatchExpression:
+			checkMatch(pass, m.Subject(), m.Arms(), unions)
+		}
+		return true
+	})
+	return nil
+}
+
+func checkMatch(pass *analysis.Pass, subject ast.Node, arms []*ast.MatchArm, unions map[string][]*union) {
+	seen := make(map[string]*ast.Pattern)
+	covered := make(map[string]bool)
+	var named []string // the variants named by any arm
+	bools := 0
+	for _, arm := range arms {
+		p := arm.Pattern()
+		if p == nil {
+			continue
+		}
+		if p.IsWildcard() {
+			return
+		}
+		if _, ok := p.Value().(*ast.Identifier); ok && arm.Guard() == nil {
+			return
+		}
+		key, variant := keyOf(p, pass.Source)
+		if key == "" {
+			continue
+		}
+		if prev := seen[key]; prev != nil {
+			if prev.Text(pass.Source) != p.Text(pass.Source) {
+				pass.Report(analysis.Diagnostic{
+					Range:   p.Raw().Range(),
+					Message: fmt.Sprintf("duplicate match arm: %s matches what %s at line %d does", p.Text(pass.Source), prev.Text(pass.Source), prev.Raw().StartPosition().Row+1),
+					SuggestedFixes: []analysis.SuggestedFix{{
+						Message:   "remove duplicate arm",
+						TextEdits: []analysis.TextEdit{analysis.Delete(pass.Source, arm.Raw())},
+					}},
+				})
+			}
+			continue
+		}
+		if variant != "" {
+			named = append(named, variant)
+		}
+		if key == "boolean true" || key == "boolean false" {
+			bools++
+		}
+		if arm.Guard() != nil {
+			continue
+		}
+		seen[key] = p
+		covered[key] = true
+	}
+
+	switch {
+	case subject == nil:
+	case bools > 0 && len(named) == 0:
+		for _, b := range []string{"true", "false"} {
+			if !covered["boolean "+b] {
+				pass.Reportf(subject.Raw(), "match is missing the case %s", b)
+			}
+		}
+	case len(named) > 0:
+		u := unionOf(named, unions)
+		if u == nil {
+			return
+		}
+		var missing []string
+		for _, v := range u.variants {
+			if !covered["variant "+v] {
+				missing = append(missing, v)
+			}
+		}
+		if len(missing) > 0 {
+			pass.Reportf(subject.Raw(), "match on %s is missing %s", u.name, strings.Join(missing, ", "))
+		}
+	}
+}
+
+// keyOf returns what the pattern p matches, alike for patterns matching
+// the same values, and the variant it names, if any. The key is empty
+// for patterns that cannot be compared.
+func keyOf(p *ast.Pattern, src []byte) (key, variant string) {
+	switch v := p.Value().(type) {
+	case nil, *ast.Identifier:
+		return "", ""
+	case *ast.TypeIdentifier:
+		name := v.Text(src)
+		return "variant " + name, name
+	case *ast.DestructuringPattern:
+		if v.TypeName() == nil {
+			return "", ""
+		}
+		name := v.TypeName().Text(src)
+		return "variant " + name, name
+	default:
+		c, err := eval.Const(v.Raw(), src)
+		if err != nil {
+			return "", ""
+		}
+		return c.Kind.String() + " " + c.String(), ""
+	}
+}
+
+// unionOf returns the one union type declaring all the variants, or nil.
+func unionOf(variants []string, unions map[string][]*union) *union {
+	var found *union
+	for _, u := range unions[variants[0]] {
+		if declaresAll(u, variants) {
+			if found != nil {
+				return nil
+			}
+			found = u
+		}
+	}
+	return found
+}
+
+func declaresAll(u *union, variants []string) bool {
+	for _, v := range variants {
+		ok := false
+		for _, w := range u.variants {
+			ok = ok || v == w
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,12 @@
+package matchcheck_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/matchcheck"
+)
+
+func Test(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata", matchcheck.Analyzer)
+}
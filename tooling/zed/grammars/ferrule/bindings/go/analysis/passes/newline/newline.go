@@ -0,0 +1,145 @@
+// Package newline defines an analyzer that reports line breaks, byte order
+// marks and missing final line breaks that the formatter would change.
+package newline
+
+import (
+	"bytes"
+	"fmt"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+const Doc = `report line endings and byte order marks the formatter would change
+
+The newline analyzer reports, with fixes, a file whose lines end
+otherwise than format.newline in ferrule.toml asks, \n unless it is set to
+"crlf"; one that starts with a UTF-8 byte order mark, unless format.bom is
+"keep"; and one that does not end with a line break, unless
+format.final_newline is false. Line breaks inside string literals are
+part of their value and are left alone, as the formatter leaves them.
+Mixed line endings are reported once per file, with a fix for all of
+them.`
+
+// Analyzer checks files against the default format options. The
+// configuration of a project replaces it with the analyzer For returns for
+// the format options it sets; see config.Config.Analyzers.
+var Analyzer = For(format.Options{})
+
+// For returns the analyzer checking files against opts.
+func For(opts format.Options) *analysis.Analyzer {
+	return &analysis.Analyzer{
+		Name: "newline",
+		Doc:  Doc,
+		Run:  func(pass *analysis.Pass) error { return run(pass, opts) },
+	}
+}
+
+func run(pass *analysis.Pass, opts format.Options) error {
+	src := pass.Source
+	if !opts.KeepBOM && bytes.HasPrefix(src, []byte(format.BOM)) {
+		pass.Report(analysis.Diagnostic{
+			Range:   span(src, 0, uint(len(format.BOM))),
+			Message: "file starts with a byte order mark",
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   "remove the byte order mark",
+				TextEdits: []analysis.TextEdit{{Start: 0, End: uint(len(format.BOM))}},
+			}},
+		})
+	}
+	checkBreaks(pass, opts.Newline)
+	if !opts.KeepFinalNewline && len(bytes.TrimSpace(bytes.TrimPrefix(src, []byte(format.BOM)))) > 0 {
+		if last := src[len(src)-1]; last != '\n' && last != '\r' {
+			end := uint(len(src))
+			pass.Report(analysis.Diagnostic{
+				Range:   span(src, end, end),
+				Message: "file does not end with a line break",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   "add a final line break",
+					TextEdits: []analysis.TextEdit{{Start: end, End: end, NewText: []byte(opts.Newline.Text())}},
+				}},
+			})
+		}
+	}
+	return nil
+}
+
+// checkBreaks reports the line breaks outside string literals that are not
+// want, at the first of them.
+func checkBreaks(pass *analysis.Pass, want format.Newline) {
+	src := pass.Source
+	literals := stringLiterals(pass.Tree.Raw().RootNode())
+	var edits []analysis.TextEdit
+	for i := 0; i < len(src); i++ {
+		if src[i] != '\n' {
+			continue
+		}
+		for len(literals) > 0 && literals[0].EndByte <= uint(i) {
+			literals = literals[1:]
+		}
+		if len(literals) > 0 && literals[0].StartByte <= uint(i) {
+			continue
+		}
+		crlf := i > 0 && src[i-1] == '\r'
+		switch {
+		case crlf && want == format.LF:
+			edits = append(edits, analysis.TextEdit{Start: uint(i - 1), End: uint(i)})
+		case !crlf && want == format.CRLF:
+			edits = append(edits, analysis.TextEdit{Start: uint(i), End: uint(i), NewText: []byte("\r")})
+		}
+	}
+	if len(edits) == 0 {
+		return
+	}
+	got := format.CRLF
+	if want == format.CRLF {
+		got = format.LF
+	}
+	msg := fmt.Sprintf("line ends with %v, not %v", got, want)
+	if len(edits) > 1 {
+		msg += fmt.Sprintf(", as do %d more", len(edits)-1)
+	}
+	first := edits[0].Start
+	pass.Report(analysis.Diagnostic{
+		Range:   span(src, first, first+uint(len(got.Text()))),
+		Message: msg,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   fmt.Sprintf("end every line with %v", want),
+			TextEdits: edits,
+		}},
+	})
+}
+
+// stringLiterals returns the ranges of the string and character literals
+// below n, in order.
+func stringLiterals(n *tree_sitter.Node) []tree_sitter.Range {
+	var out []tree_sitter.Range
+	cursor := n.Walk()
+	defer cursor.Close()
+	for {
+		n := cursor.Node()
+		if k := n.Kind(); k == kind.StringLiteral || k == kind.CharLiteral {
+			out = append(out, n.Range())
+		} else if n.StartPosition().Row != n.EndPosition().Row && cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return out
+			}
+		}
+	}
+}
+
+// span returns the range between the offsets start and end of src.
+func span(src []byte, start, end uint) tree_sitter.Range {
+	return tree_sitter.Range{StartByte: start, EndByte: end, StartPoint: point(src, start), EndPoint: point(src, end)}
+}
+
+func point(src []byte, off uint) tree_sitter.Point {
+	line := bytes.LastIndexByte(src[:off], '\n') + 1
+	return tree_sitter.Point{Row: uint(bytes.Count(src[:off], []byte("\n"))), Column: off - uint(line)}
+}
@@ -0,0 +1,81 @@
+package newline_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/newline"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+)
+
+// The sources are built here rather than read from testdata, where git
+// would turn their line endings into \n.
+func Test(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     format.Options
+		src      string
+		messages []string
+		fixed    string
+	}{
+		{
+			name:     "crlf",
+			src:      "const a = 1;\r\nconst s = \"x\r\ny\";\r\n// done\n",
+			messages: []string{"line ends with CRLF, not LF, as do 1 more"},
+			fixed:    "const a = 1;\nconst s = \"x\r\ny\";\n// done\n",
+		},
+		{
+			name:     "want crlf",
+			opts:     format.Options{Newline: format.CRLF},
+			src:      "const a = 1;\r\nconst b = 2;\n",
+			messages: []string{"line ends with LF, not CRLF"},
+			fixed:    "const a = 1;\r\nconst b = 2;\r\n",
+		},
+		{
+			name:     "bom and final newline",
+			src:      "\xef\xbb\xbfconst a = 1;",
+			messages: []string{"file starts with a byte order mark", "file does not end with a line break"},
+			fixed:    "const a = 1;\n",
+		},
+		{
+			name: "kept",
+			opts: format.Options{KeepBOM: true, KeepFinalNewline: true},
+			src:  "\xef\xbb\xbfconst a = 1;",
+		},
+		{
+			name: "empty",
+			src:  "",
+		},
+	}
+	for _, tt := range tests {
+		tree, err := ferrule.Parse(context.Background(), []byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diags, err := analysis.Run(tree, newline.For(tt.opts))
+		tree.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diags) != len(tt.messages) {
+			t.Errorf("%s: diagnostics %v, want %q", tt.name, diags, tt.messages)
+			continue
+		}
+		var fixes []analysis.SuggestedFix
+		for i, d := range diags {
+			if d.Message != tt.messages[i] {
+				t.Errorf("%s: message %q, want %q", tt.name, d.Message, tt.messages[i])
+			}
+			fixes = append(fixes, d.SuggestedFixes[0])
+		}
+		if len(fixes) == 0 {
+			continue
+		}
+		fixed, _, err := analysis.ApplyFixes([]byte(tt.src), fixes)
+		if err != nil || string(fixed) != tt.fixed {
+			t.Errorf("%s: fixed %q, %v, want %q", tt.name, fixed, err, tt.fixed)
+		}
+	}
+}
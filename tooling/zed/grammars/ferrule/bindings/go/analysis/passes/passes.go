@@ -0,0 +1,32 @@
+// Package passes lists the built-in analyzers, those ferrule-lint runs and
+// the editor integrations, the daemon and the pre-commit hook run with it.
+package passes
+
+import (
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/deadcode"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/deprecated"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/examples"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/matchcheck"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/newline"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/shadow"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/spelling"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+)
+
+// All are the built-in analyzers, by name, with analysis.Stale. It must
+// not be modified; callers turning analyzers on or off copy it.
+var All = []*analysis.Analyzer{
+	deadcode.Analyzer,
+	deprecated.Analyzer,
+	examples.FormatAnalyzer,
+	examples.Analyzer,
+	matchcheck.Analyzer,
+	newline.Analyzer,
+	shadow.Analyzer,
+	spelling.Analyzer,
+	analysis.Stale,
+	unreachable.Analyzer,
+	unused.Analyzer,
+}
@@ -0,0 +1,37 @@
+// Package shadow defines an analyzer that reports local bindings that hide
+// another local of the same name.
+package shadow
+
+import (
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/internal/scopes"
+)
+
+const Doc = `report bindings that shadow an enclosing local
+
+The shadow analyzer reports a local binding whose name is already bound by
+a parameter or local of an enclosing scope, as in
+
+	function f(n: i32) -> i32 {
+	  for n in items { ... }
+	}
+
+where reads of n inside the loop can no longer reach the parameter.
+Top-level names are not considered.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "shadow",
+	Doc:  Doc,
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) error {
+	info := scopes.Resolve(pass.Tree.RootNode(), pass.Source)
+	for _, b := range info.Bindings {
+		if b.Shadows == nil {
+			continue
+		}
+		pass.Reportf(b.Ident, "declaration of %q shadows %s at line %d", b.Name, b.Shadows.Kind, b.Shadows.Ident.StartPosition().Row+1)
+	}
+	return nil
+}
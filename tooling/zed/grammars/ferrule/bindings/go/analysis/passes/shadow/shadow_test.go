@@ -0,0 +1,12 @@
+package shadow_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/shadow"
+)
+
+func Test(t *testing.T) {
+	analysistest.Run(t, "testdata", shadow.Analyzer)
+}
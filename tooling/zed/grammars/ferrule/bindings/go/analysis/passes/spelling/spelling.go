@@ -0,0 +1,296 @@
+// Package spelling defines an optional analyzer that reports misspelled
+// words in declared names, comments and string literals.
+package spelling
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+const Doc = `report commonly misspelled English words
+
+The spelling analyzer looks up the words of the names a file declares,
+split at underscores and changes of case, of its comments and of its
+string literals in a list of common misspellings, and reports those it
+finds with a fix correcting them, as misspell does for Go. A declared
+name is corrected along with its uses in the file, except for a public
+top-level name, which other files may use. The analyzer is off unless
+turned on.
+
+The flag -spelling.words names a file extending the list: each line
+holds a misspelling and its correction, or a single word that is not to
+be reported. Lines starting with # are comments.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "spelling",
+	Doc:      Doc,
+	Optional: true,
+	Run:      run,
+}
+
+func init() {
+	Analyzer.Flags.Var(wordsFlag{}, "words", "read more misspellings from `file`")
+}
+
+// wordsFlag loads a word list into the misspellings when set.
+type wordsFlag struct{}
+
+func (wordsFlag) String() string { return "" }
+
+func (wordsFlag) Set(name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return readWords(f.Name(), bufio.NewScanner(f))
+}
+
+func readWords(name string, sc *bufio.Scanner) error {
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		switch {
+		case len(fields) == 0 || strings.HasPrefix(fields[0], "#"):
+		case len(fields) == 1:
+			delete(misspellings, strings.ToLower(fields[0]))
+		case len(fields) == 2:
+			misspellings[strings.ToLower(fields[0])] = strings.ToLower(fields[1])
+		default:
+			return fmt.Errorf("%s:%d: want a misspelling and its correction", name, line)
+		}
+	}
+	return sc.Err()
+}
+
+func run(pass *analysis.Pass) error {
+	info := scope.Resolve(pass.Tree)
+	for _, d := range info.Definitions {
+		var uses []*tree_sitter.Node
+		if !isPublic(d.Node) {
+			uses = append([]*tree_sitter.Node{d.Node}, d.References...)
+		}
+		checkName(pass, d.Node, uses)
+	}
+	types, typeRefs := checkTexts(pass)
+	for _, t := range types {
+		var uses []*tree_sitter.Node
+		if !isPublic(t) {
+			name := t.Utf8Text(pass.Source)
+			for _, r := range typeRefs {
+				if r.Utf8Text(pass.Source) == name {
+					uses = append(uses, r)
+				}
+			}
+		}
+		checkName(pass, t, uses)
+	}
+	return nil
+}
+
+// checkTexts checks the comments and string literals of the file and
+// returns the type identifiers naming declared types and variants, and
+// all type identifiers.
+func checkTexts(pass *analysis.Pass) (decls, refs []*tree_sitter.Node) {
+	cursor := pass.Tree.Raw().Walk()
+	defer cursor.Close()
+	for {
+		n := cursor.Node()
+		switch n.Kind() {
+		case kind.LineComment, kind.BlockComment:
+			checkText(pass, n, false)
+		case kind.StringLiteral:
+			checkText(pass, n, true)
+		case kind.TypeIdentifier:
+			if declares(n) {
+				decls = append(decls, n)
+			}
+			refs = append(refs, n)
+		}
+		if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return decls, refs
+			}
+		}
+	}
+}
+
+// declares reports whether the type identifier n is the name of a type
+// declaration or variant.
+func declares(n *tree_sitter.Node) bool {
+	p := n.Parent()
+	if p == nil {
+		return false
+	}
+	switch p.Kind() {
+	case kind.TypeDeclaration:
+		name := p.ChildByFieldName(field.Name)
+		return name != nil && name.Id() == n.Id()
+	case kind.UnionVariant, kind.ErrorVariant:
+		return p.NamedChild(0).Id() == n.Id()
+	}
+	return false
+}
+
+// isPublic reports whether ident names a top-level declaration marked
+// pub, or a variant of one.
+func isPublic(ident *tree_sitter.Node) bool {
+	decl := ident.Parent()
+	if decl != nil && (decl.Kind() == kind.UnionVariant || decl.Kind() == kind.ErrorVariant) {
+		for decl != nil && decl.Parent() != nil && decl.Parent().Kind() != kind.SourceFile {
+			decl = decl.Parent()
+		}
+	}
+	if decl == nil || decl.Parent() == nil || decl.Parent().Kind() != kind.SourceFile {
+		return false
+	}
+	first := decl.Child(0)
+	return first != nil && first.Kind() == kind.KeywordPub
+}
+
+// checkName reports the misspelled words of the name ident declares,
+// fixing them at uses.
+func checkName(pass *analysis.Pass, ident *tree_sitter.Node, uses []*tree_sitter.Node) {
+	name := ident.Utf8Text(pass.Source)
+	for _, w := range words(name, false) {
+		right, ok := correct(w.text)
+		if !ok {
+			continue
+		}
+		d := analysis.Diagnostic{
+			Range:   subrange(ident, name, w),
+			Message: fmt.Sprintf("%q in %s is a misspelling of %q", w.text, name, right),
+		}
+		if len(uses) > 0 {
+			fix := analysis.SuggestedFix{Message: "rename to " + name[:w.start] + right + name[w.start+len(w.text):]}
+			for _, u := range uses {
+				start := u.StartByte() + uint(w.start)
+				fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{Start: start, End: start + uint(len(w.text)), NewText: []byte(right)})
+			}
+			d.SuggestedFixes = []analysis.SuggestedFix{fix}
+		}
+		pass.Report(d)
+	}
+}
+
+// checkText reports the misspelled words of a comment or string literal.
+func checkText(pass *analysis.Pass, n *tree_sitter.Node, literal bool) {
+	text := n.Utf8Text(pass.Source)
+	for _, w := range words(text, literal) {
+		right, ok := correct(w.text)
+		if !ok {
+			continue
+		}
+		r := subrange(n, text, w)
+		pass.Report(analysis.Diagnostic{
+			Range:   r,
+			Message: fmt.Sprintf("%q is a misspelling of %q", w.text, right),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message:   "replace with " + right,
+				TextEdits: []analysis.TextEdit{{Start: r.StartByte, End: r.EndByte, NewText: []byte(right)}},
+			}},
+		})
+	}
+}
+
+// correct returns the correction of the misspelled word w, in the case
+// of w.
+func correct(w string) (string, bool) {
+	right, ok := misspellings[strings.ToLower(w)]
+	if !ok {
+		return "", false
+	}
+	first, _ := utf8.DecodeRuneInString(w)
+	switch {
+	case len(w) > 1 && strings.ToUpper(w) == w:
+		return strings.ToUpper(right), true
+	case unicode.IsUpper(first):
+		return strings.ToUpper(right[:1]) + right[1:], true
+	}
+	return right, true
+}
+
+// word is a word of a text, at byte offset start.
+type word struct {
+	text  string
+	start int
+}
+
+// words splits text into words: runs of letters, broken where lower case
+// turns to upper, or upper to upper and then lower as at the R of
+// HTTPRequest. With escapes, a backslash and the character after it
+// separate words, as in string literals.
+func words(text string, escapes bool) []word {
+	var out []word
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			out = append(out, word{text[start:end], start})
+		}
+		start = -1
+	}
+	var prev rune
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case escapes && r == '\\':
+			flush(i)
+			i += size
+			if i < len(text) {
+				_, size = utf8.DecodeRuneInString(text[i:])
+			}
+			prev = 0
+		case !unicode.IsLetter(r):
+			flush(i)
+			prev = 0
+		case start < 0:
+			start = i
+			prev = r
+		default:
+			next, _ := utf8.DecodeRuneInString(text[i+size:])
+			if unicode.IsLower(prev) && unicode.IsUpper(r) || unicode.IsUpper(prev) && unicode.IsUpper(r) && unicode.IsLower(next) {
+				flush(i)
+				start = i
+			}
+			prev = r
+		}
+		i += size
+	}
+	flush(len(text))
+	return out
+}
+
+// subrange returns the range of the word w of the single-line or
+// multi-line text of n.
+func subrange(n *tree_sitter.Node, text string, w word) tree_sitter.Range {
+	point := func(off int) tree_sitter.Point {
+		p := n.StartPosition()
+		before := text[:off]
+		if nl := strings.LastIndexByte(before, '\n'); nl >= 0 {
+			p.Row += uint(strings.Count(before, "\n"))
+			p.Column = uint(off - nl - 1)
+		} else {
+			p.Column += uint(off)
+		}
+		return p
+	}
+	return tree_sitter.Range{
+		StartByte:  n.StartByte() + uint(w.start),
+		EndByte:    n.StartByte() + uint(w.start+len(w.text)),
+		StartPoint: point(w.start),
+		EndPoint:   point(w.start + len(w.text)),
+	}
+}
@@ -0,0 +1,46 @@
+package spelling_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/spelling"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func Test(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata", spelling.Analyzer)
+}
+
+func TestWords(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(name, []byte("# project words\ncolr color\nbuffre\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := spelling.Analyzer.Flags.Set("words", name); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := ferrule.Parse(context.Background(), []byte("type Colr = | Red;\n// a buffre\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	diags, err := analysis.Run(tree, spelling.Analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Message != `"Colr" in Colr is a misspelling of "Color"` {
+		t.Errorf("got %v", diags)
+	}
+
+	if err := os.WriteFile(name, []byte("too many words\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := spelling.Analyzer.Flags.Set("words", name); err == nil {
+		t.Error("malformed word list accepted")
+	}
+}
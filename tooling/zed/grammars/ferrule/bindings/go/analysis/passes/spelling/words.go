@@ -0,0 +1,131 @@
+package spelling
+
+// misspellings maps common misspellings of English words to their
+// correction, all in lower case.
+var misspellings = map[string]string{
+	"accesible":      "accessible",
+	"accross":        "across",
+	"acheive":        "achieve",
+	"acknowlege":     "acknowledge",
+	"adress":         "address",
+	"agressive":      "aggressive",
+	"allign":         "align",
+	"alreay":         "already",
+	"amout":          "amount",
+	"anual":          "annual",
+	"apparant":       "apparent",
+	"appropiate":     "appropriate",
+	"arguement":      "argument",
+	"asign":          "assign",
+	"assertation":    "assertion",
+	"asynchonous":    "asynchronous",
+	"atribute":       "attribute",
+	"availabe":       "available",
+	"availible":      "available",
+	"begining":       "beginning",
+	"beleive":        "believe",
+	"boundry":        "boundary",
+	"buffre":         "buffer",
+	"cancelation":    "cancellation",
+	"charachter":     "character",
+	"collumn":        "column",
+	"comming":        "coming",
+	"commited":       "committed",
+	"comparision":    "comparison",
+	"compatability":  "compatibility",
+	"compatable":     "compatible",
+	"completly":      "completely",
+	"concurent":      "concurrent",
+	"conditon":       "condition",
+	"connecton":      "connection",
+	"consistant":     "consistent",
+	"containg":       "containing",
+	"correspondance": "correspondence",
+	"currenly":       "currently",
+	"decleration":    "declaration",
+	"defualt":        "default",
+	"definately":     "definitely",
+	"definiton":      "definition",
+	"delimeter":      "delimiter",
+	"dependancy":     "dependency",
+	"desciption":     "description",
+	"destory":        "destroy",
+	"diffrent":       "different",
+	"directroy":      "directory",
+	"dissable":       "disable",
+	"enviroment":     "environment",
+	"exection":       "execution",
+	"existance":      "existence",
+	"explicitely":    "explicitly",
+	"facilty":        "facility",
+	"familar":        "familiar",
+	"feild":          "field",
+	"followng":       "following",
+	"foriegn":        "foreign",
+	"fucntion":       "function",
+	"funtion":        "function",
+	"garantee":       "guarantee",
+	"guarentee":      "guarantee",
+	"heigth":         "height",
+	"hierachy":       "hierarchy",
+	"identifer":      "identifier",
+	"immediatly":     "immediately",
+	"implmentation":  "implementation",
+	"independant":    "independent",
+	"indentifier":    "identifier",
+	"initalize":      "initialize",
+	"intial":         "initial",
+	"lenght":         "length",
+	"libary":         "library",
+	"maintainance":   "maintenance",
+	"managment":      "management",
+	"mesage":         "message",
+	"metdata":        "metadata",
+	"neccessary":     "necessary",
+	"necesary":       "necessary",
+	"nubmer":         "number",
+	"occurence":      "occurrence",
+	"occured":        "occurred",
+	"occuring":       "occurring",
+	"occurrance":     "occurrence",
+	"paramter":       "parameter",
+	"parmeter":       "parameter",
+	"persistant":     "persistent",
+	"posible":        "possible",
+	"preceed":        "precede",
+	"prefered":       "preferred",
+	"privledge":      "privilege",
+	"proccess":       "process",
+	"publically":     "publicly",
+	"recieve":        "receive",
+	"recieved":       "received",
+	"recursivly":     "recursively",
+	"refered":        "referred",
+	"reponse":        "response",
+	"repositry":      "repository",
+	"responsability": "responsibility",
+	"retreive":       "retrieve",
+	"seperate":       "separate",
+	"seperator":      "separator",
+	"sequnce":        "sequence",
+	"similiar":       "similar",
+	"specifed":       "specified",
+	"succesful":      "successful",
+	"successfull":    "successful",
+	"sucess":         "success",
+	"supress":        "suppress",
+	"teh":            "the",
+	"threshhold":     "threshold",
+	"tommorow":       "tomorrow",
+	"transfered":     "transferred",
+	"truely":         "truly",
+	"unkown":         "unknown",
+	"untill":         "until",
+	"usefull":        "useful",
+	"varient":        "variant",
+	"vaule":          "value",
+	"visable":        "visible",
+	"wich":           "which",
+	"widht":          "width",
+	"writting":       "writing",
+}
@@ -0,0 +1,100 @@
+// Package unreachable defines an analyzer that reports match arms that can
+// never be selected.
+package unreachable
+
+import (
+	"fmt"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+const Doc = `report match arms that can never be selected
+
+An arm is unreachable when an earlier arm without a guard matches every
+value, because its pattern is the wildcard _ or a bare name, or when an
+earlier arm without a guard has the same literal or variant pattern. Each
+report comes with a fix removing the arm.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "unreachable",
+	Doc:  Doc,
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) error {
+	cursor := pass.Tree.Raw().Walk()
+	defer cursor.Close()
+	for {
+		n := cursor.Node()
+		if n.Kind() == kind.MatchStatement || n.Kind() == kind.MatchExpression {
+			checkMatch(pass, n)
+		}
+		if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return nil
+			}
+		}
+	}
+}
+
+func checkMatch(pass *analysis.Pass, match *tree_sitter.Node) {
+	var catchAll *tree_sitter.Node
+	seen := make(map[string]*tree_sitter.Node)
+	for i := uint(0); i < match.NamedChildCount(); i++ {
+		arm := match.NamedChild(i)
+		if arm.Kind() != kind.MatchArm {
+			continue
+		}
+		pattern := arm.NamedChild(0)
+		if pattern == nil || pattern.Kind() != kind.Pattern {
+			continue
+		}
+		if catchAll != nil {
+			report(pass, arm, pattern, fmt.Sprintf("unreachable match arm: the arm at line %d matches every value", catchAll.StartPosition().Row+1))
+			continue
+		}
+		text := pattern.Utf8Text(pass.Source)
+		if prev := seen[text]; prev != nil {
+			report(pass, arm, pattern, fmt.Sprintf("unreachable match arm: %s is already matched at line %d", text, prev.StartPosition().Row+1))
+			continue
+		}
+		if guarded(arm) {
+			continue
+		}
+		switch c := pattern.Child(0); {
+		case c.Kind() == "_", c.Kind() == kind.Identifier:
+			catchAll = pattern
+		case c.Kind() != kind.DestructuringPattern:
+			seen[text] = pattern
+		}
+	}
+}
+
+func report(pass *analysis.Pass, arm, pattern *tree_sitter.Node, msg string) {
+	pass.Report(analysis.Diagnostic{
+		Range:   pattern.Range(),
+		Message: msg,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "remove unreachable arm",
+			TextEdits: []analysis.TextEdit{analysis.Delete(pass.Source, arm)},
+		}},
+	})
+}
+
+func guarded(arm *tree_sitter.Node) bool {
+	for i := uint(0); i < arm.ChildCount(); i++ {
+		switch arm.Child(i).Kind() {
+		case "if":
+			return true
+		case "->":
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package unreachable_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+)
+
+func Test(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata", unreachable.Analyzer)
+}
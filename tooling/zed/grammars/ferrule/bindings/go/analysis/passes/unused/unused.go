@@ -0,0 +1,99 @@
+// Package unused defines an analyzer that reports local bindings that are
+// never used.
+package unused
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/internal/scopes"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+const Doc = `report local bindings that are never used
+
+The unused analyzer reports const and var declarations inside functions,
+for loop variables and match pattern bindings that are never referred to.
+Parameters are not reported, since their names are part of the function's
+signature. Names starting with an underscore are exempt.
+
+Declarations whose value has no effects come with a fix deleting them;
+loop variables and pattern names with one replacing them by _.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "unused",
+	Doc:  Doc,
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) error {
+	info := scopes.Resolve(pass.Tree.RootNode(), pass.Source)
+	for _, b := range info.Bindings {
+		if b.Kind == scopes.Param || len(b.Uses) > 0 || strings.HasPrefix(b.Name, "_") {
+			continue
+		}
+		d := analysis.Diagnostic{Range: b.Ident.Range(), Message: b.Name + " declared and not used"}
+		if fix, ok := suggest(pass, b); ok {
+			d.SuggestedFixes = []analysis.SuggestedFix{fix}
+		}
+		pass.Report(d)
+	}
+	return nil
+}
+
+// suggest proposes to delete an unused declaration whose value has no
+// effects, or to replace an unused loop variable or pattern name with _.
+func suggest(pass *analysis.Pass, b *scopes.Binding) (analysis.SuggestedFix, bool) {
+	switch b.Kind {
+	case scopes.Const, scopes.Var:
+		decl := b.Ident.Parent()
+		if !pure(decl.ChildByFieldName(field.Value)) {
+			return analysis.SuggestedFix{}, false
+		}
+		return analysis.SuggestedFix{
+			Message:   "remove unused " + b.Name,
+			TextEdits: []analysis.TextEdit{analysis.Delete(pass.Source, decl)},
+		}, true
+	case scopes.For:
+		return blank(b), true
+	case scopes.PatternBinding:
+		if b.Ident.Parent().Kind() == kind.Pattern {
+			return blank(b), true
+		}
+	}
+	return analysis.SuggestedFix{}, false
+}
+
+func blank(b *scopes.Binding) analysis.SuggestedFix {
+	return analysis.SuggestedFix{
+		Message:   "replace " + b.Name + " with _",
+		TextEdits: []analysis.TextEdit{analysis.Replace(b.Ident, "_")},
+	}
+}
+
+// pure reports whether evaluating n cannot have effects, so that dropping
+// it does not change the program.
+func pure(n *tree_sitter.Node) bool {
+	if n == nil {
+		return false
+	}
+	switch n.Kind() {
+	case kind.CallExpression, kind.CheckExpression, kind.Error:
+		return false
+	case kind.AnonymousFunction:
+		return true
+	case kind.BinaryExpression:
+		if op := n.Child(1); op != nil && op.Kind() == "=" {
+			return false
+		}
+	}
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		if !pure(n.NamedChild(i)) {
+			return false
+		}
+	}
+	return true
+}
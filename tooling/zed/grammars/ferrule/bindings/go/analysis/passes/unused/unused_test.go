@@ -0,0 +1,12 @@
+package unused_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis/analysistest"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+)
+
+func Test(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, "testdata", unused.Analyzer)
+}
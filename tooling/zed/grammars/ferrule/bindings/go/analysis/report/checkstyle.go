@@ -0,0 +1,66 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+type (
+	checkstyleLog struct {
+		XMLName xml.Name         `xml:"checkstyle"`
+		Version string           `xml:"version,attr"`
+		Files   []checkstyleFile `xml:"file"`
+	}
+	checkstyleFile struct {
+		Name   string            `xml:"name,attr"`
+		Errors []checkstyleError `xml:"error"`
+	}
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+)
+
+// Checkstyle writes r as a checkstyle XML report, with a file element per
+// file holding findings, in the order of their first findings. The source
+// of an error is the tool and the category, as in "ferrule-lint.unused".
+func Checkstyle(w io.Writer, r *Report) error {
+	log := checkstyleLog{Version: "8.0"}
+	files := make(map[string]int)
+	for _, f := range r.Findings {
+		i, ok := files[f.Path]
+		if !ok {
+			i = len(log.Files)
+			files[f.Path] = i
+			log.Files = append(log.Files, checkstyleFile{Name: f.Path})
+		}
+		e := checkstyleError{Severity: checkstyleSeverity(f.Severity), Message: f.Message, Source: r.Tool + "." + f.Category}
+		e.Line, e.Column = f.start(true)
+		log.Files[i].Errors = append(log.Files[i].Errors, e)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func checkstyleSeverity(s ferrule.Severity) string {
+	switch s {
+	case ferrule.SeverityError:
+		return "error"
+	case ferrule.SeverityInformation, ferrule.SeverityHint:
+		return "info"
+	}
+	return "warning"
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// GitHub writes the findings of r as GitHub Actions workflow commands, one
+// per line, which the runner turns into annotations of the lines of the
+// pull request:
+//
+//	::warning file=src/a.fe,line=3,col=9,endLine=3,endColumn=10,title=unused::x declared and not used
+func GitHub(w io.Writer, r *Report) error {
+	for _, f := range r.Findings {
+		line, col := f.start(true)
+		endLine, endCol := f.end(true)
+		_, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,endLine=%d,endColumn=%d,title=%s::%s\n",
+			githubLevel(f.Severity), githubProperty(f.Path), line, col, endLine, endCol,
+			githubProperty(f.Category), githubData(f.Message))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubLevel(s ferrule.Severity) string {
+	switch s {
+	case ferrule.SeverityError:
+		return "error"
+	case ferrule.SeverityInformation, ferrule.SeverityHint:
+		return "notice"
+	}
+	return "warning"
+}
+
+// githubData escapes the message of a workflow command.
+func githubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes the value of a property of a workflow command.
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type jsonFinding struct {
+	Path      string    `json:"path"`
+	Line      int       `json:"line"`
+	Column    int       `json:"column"`
+	EndLine   int       `json:"endLine"`
+	EndColumn int       `json:"endColumn"`
+	Severity  string    `json:"severity"`
+	Category  string    `json:"category"`
+	Message   string    `json:"message"`
+	Fixes     []jsonFix `json:"fixes,omitempty"`
+}
+
+type jsonFix struct {
+	Message string     `json:"message"`
+	Edits   []jsonEdit `json:"edits"`
+}
+
+// jsonEdit replaces the bytes between the offsets Start and End.
+type jsonEdit struct {
+	Start   uint   `json:"start"`
+	End     uint   `json:"end"`
+	NewText string `json:"newText"`
+}
+
+// JSON writes the findings of r as an indented JSON array of objects with
+// the path, the range, the severity, the category and message and the
+// suggested fixes of each, their edits located by byte offsets.
+func JSON(w io.Writer, r *Report) error {
+	out := []jsonFinding{}
+	for _, f := range r.Findings {
+		j := jsonFinding{Path: f.Path, Severity: f.Severity.String(), Category: f.Category, Message: f.Message}
+		j.Line, j.Column = f.start(false)
+		j.EndLine, j.EndColumn = f.end(false)
+		for _, fix := range f.SuggestedFixes {
+			jf := jsonFix{Message: fix.Message, Edits: []jsonEdit{}}
+			for _, e := range fix.TextEdits {
+				jf.Edits = append(jf.Edits, jsonEdit{Start: e.Start, End: e.End, NewText: string(e.NewText)})
+			}
+			j.Fixes = append(j.Fixes, jf)
+		}
+		out = append(out, j)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
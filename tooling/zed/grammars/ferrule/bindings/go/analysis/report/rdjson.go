@@ -0,0 +1,96 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// The objects of reviewdog's diagnostic format, with only the properties
+// used.
+type (
+	rdResult struct {
+		Source      rdSource       `json:"source"`
+		Diagnostics []rdDiagnostic `json:"diagnostics"`
+	}
+	rdSource struct {
+		Name string `json:"name"`
+	}
+	rdDiagnostic struct {
+		Message     string         `json:"message"`
+		Location    rdLocation     `json:"location"`
+		Severity    string         `json:"severity"`
+		Code        rdCode         `json:"code"`
+		Suggestions []rdSuggestion `json:"suggestions,omitempty"`
+	}
+	rdLocation struct {
+		Path  string  `json:"path"`
+		Range rdRange `json:"range"`
+	}
+	rdRange struct {
+		Start rdPosition `json:"start"`
+		End   rdPosition `json:"end"`
+	}
+	rdPosition struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+	rdCode struct {
+		Value string `json:"value"`
+	}
+	rdSuggestion struct {
+		Range rdRange `json:"range"`
+		Text  string  `json:"text"`
+	}
+)
+
+// RDJSON writes r in reviewdog's diagnostic format, for reviewdog -f=rdjson
+// to comment on pull requests. The edits of the first suggested fix of a
+// finding become its suggestions when the source of the file is known.
+// Columns count bytes, as the format has them.
+func RDJSON(w io.Writer, r *Report) error {
+	out := rdResult{Source: rdSource{r.Tool}, Diagnostics: []rdDiagnostic{}}
+	for _, f := range r.Findings {
+		d := rdDiagnostic{
+			Message:  f.Message,
+			Location: rdLocation{Path: f.Path},
+			Severity: rdSeverity(f.Severity),
+			Code:     rdCode{f.Category},
+		}
+		d.Location.Range.Start.Line, d.Location.Range.Start.Column = f.start(false)
+		d.Location.Range.End.Line, d.Location.Range.End.Column = f.end(false)
+		if len(f.SuggestedFixes) > 0 && f.Source != nil {
+			for _, e := range f.SuggestedFixes[0].TextEdits {
+				d.Suggestions = append(d.Suggestions, rdSuggestion{
+					Range: rdRange{Start: position(f.Source, e.Start), End: position(f.Source, e.End)},
+					Text:  string(e.NewText),
+				})
+			}
+		}
+		out.Diagnostics = append(out.Diagnostics, d)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// position returns the one-based line and byte column of the offset off of
+// src.
+func position(src []byte, off uint) rdPosition {
+	off = min(off, uint(len(src)))
+	before := src[:off]
+	start := bytes.LastIndexByte(before, '\n') + 1
+	return rdPosition{Line: bytes.Count(before, []byte{'\n'}) + 1, Column: len(before) - start + 1}
+}
+
+func rdSeverity(s ferrule.Severity) string {
+	switch s {
+	case ferrule.SeverityError:
+		return "ERROR"
+	case ferrule.SeverityInformation, ferrule.SeverityHint:
+		return "INFO"
+	}
+	return "WARNING"
+}
@@ -0,0 +1,99 @@
+// Package report encodes the findings of analyzers in the formats that
+// continuous integration and code review tools read, so that they show up
+// where reviewers look: SARIF 2.1 for code scanning, checkstyle XML for
+// Jenkins and the plugins built on it, GitHub Actions workflow commands
+// and reviewdog's rdjson for inline comments on pull requests, and plain
+// JSON for scripts.
+//
+// Lines are numbered from one in every format. Columns count bytes from
+// one in JSON and rdjson, as in the text output of ferrule-lint, and
+// Unicode code points from one in SARIF, checkstyle and GitHub commands,
+// as their readers expect, when the source of the file is known.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Syntax is the category of the findings made of syntax errors.
+const Syntax = "syntax"
+
+// Finding is a diagnostic found in a file.
+type Finding struct {
+	// Path is the name of the file, as given to the tool.
+	Path string
+	// Source is the text of the file, used to count columns in code
+	// points. Without it they count bytes.
+	Source []byte
+	analysis.Diagnostic
+}
+
+// SyntaxError returns the finding of a syntax error of the file at path.
+func SyntaxError(path string, src []byte, d ferrule.Diagnostic) Finding {
+	return Finding{
+		Path:       path,
+		Source:     src,
+		Diagnostic: analysis.Diagnostic{Range: d.Range, Severity: d.Severity, Category: Syntax, Message: d.Message},
+	}
+}
+
+// position returns the one-based line and column of the point at the byte
+// offset off, on the zero-based row with the byte column col, counting
+// code points if runes is set and the source is known.
+func (f *Finding) position(off, row, col uint, runes bool) (line, column int) {
+	if !runes || f.Source == nil || off > uint(len(f.Source)) || col > off {
+		return int(row) + 1, int(col) + 1
+	}
+	return int(row) + 1, utf8.RuneCount(f.Source[off-col:off]) + 1
+}
+
+func (f *Finding) start(runes bool) (line, column int) {
+	return f.position(f.Range.StartByte, f.Range.StartPoint.Row, f.Range.StartPoint.Column, runes)
+}
+
+func (f *Finding) end(runes bool) (line, column int) {
+	return f.position(f.Range.EndByte, f.Range.EndPoint.Row, f.Range.EndPoint.Column, runes)
+}
+
+// Report is what a tool found in one run.
+type Report struct {
+	// Tool is the name of the tool, such as ferrule-lint.
+	Tool string
+	// Analyzers are the analyzers that ran, described as the rules of the
+	// tool where the format has them.
+	Analyzers []*analysis.Analyzer
+	Findings  []Finding
+}
+
+var encoders = map[string]func(io.Writer, *Report) error{
+	"checkstyle": Checkstyle,
+	"github":     GitHub,
+	"json":       JSON,
+	"rdjson":     RDJSON,
+	"sarif":      SARIF,
+}
+
+// Formats returns the names of the formats Encode knows, sorted.
+func Formats() []string {
+	var out []string
+	for name := range encoders {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Encode writes r to w in the named format.
+func Encode(w io.Writer, format string, r *Report) error {
+	enc, ok := encoders[format]
+	if !ok {
+		return fmt.Errorf("report: unknown format %q", format)
+	}
+	return enc(w, r)
+}
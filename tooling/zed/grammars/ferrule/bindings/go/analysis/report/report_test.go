@@ -0,0 +1,218 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/report"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// sample returns a report of an unused binding after a string holding a
+// two-byte character, and of a syntax error.
+func sample(t *testing.T) *report.Report {
+	t.Helper()
+	src := []byte("function f() -> i32 { const s = \"é\"; const x = 1; return 2; }\n")
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	diags, err := analysis.Run(tree, unused.Analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &report.Report{Tool: "ferrule-lint", Analyzers: []*analysis.Analyzer{unused.Analyzer}}
+	for _, d := range diags {
+		r.Findings = append(r.Findings, report.Finding{Path: "src/a.fe", Source: src, Diagnostic: d})
+	}
+
+	broken := []byte("const = 1;\n")
+	tree, err = ferrule.Parse(context.Background(), broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	for _, d := range tree.Diagnostics() {
+		r.Findings = append(r.Findings, report.SyntaxError("b.fe", broken, d))
+	}
+	return r
+}
+
+func TestJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := report.Encode(&b, "json", sample(t)); err != nil {
+		t.Fatal(err)
+	}
+	var got []struct {
+		Path     string
+		Line     int
+		Column   int
+		Severity string
+		Category string
+		Message  string
+		Fixes    []struct{ Message string }
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) < 3 {
+		t.Fatalf("got %d findings, want the unused s and x and a syntax error:\n%s", len(got), b.String())
+	}
+	x := got[1]
+	if x.Path != "src/a.fe" || x.Line != 1 || x.Column != 45 || x.Category != "unused" || x.Severity != "warning" || len(x.Fixes) != 1 {
+		t.Errorf("x = %+v, want byte column 45 and a fix", x)
+	}
+	if last := got[len(got)-1]; last.Path != "b.fe" || last.Category != report.Syntax || last.Severity != "error" {
+		t.Errorf("syntax error = %+v", last)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	var b bytes.Buffer
+	if err := report.Encode(&b, "sarif", sample(t)); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				RuleIndex int
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine, StartColumn int }
+					}
+				}
+				Fixes []struct {
+					ArtifactChanges []struct {
+						Replacements []struct {
+							DeletedRegion struct{ ByteOffset, ByteLength *int }
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %s", b.String())
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "ferrule-lint" || len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[1].ID != report.Syntax {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+	x := run.Results[1]
+	loc := x.Locations[0].PhysicalLocation
+	// é is two bytes and one code point.
+	if x.RuleID != "unused" || x.RuleIndex != 0 || x.Level != "warning" || loc.ArtifactLocation.URI != "src/a.fe" || loc.Region.StartColumn != 44 {
+		t.Errorf("result = %+v", x)
+	}
+	if len(x.Fixes) != 1 || x.Fixes[0].ArtifactChanges[0].Replacements[0].DeletedRegion.ByteOffset == nil {
+		t.Errorf("fixes = %+v", x.Fixes)
+	}
+	if last := run.Results[len(run.Results)-1]; last.RuleIndex != 1 || last.Level != "error" {
+		t.Errorf("syntax error = %+v", last)
+	}
+}
+
+func TestCheckstyle(t *testing.T) {
+	var b bytes.Buffer
+	if err := report.Encode(&b, "checkstyle", sample(t)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<file name="src/a.fe">`,
+		`<error line="1" column="44" severity="warning" message="x declared and not used" source="ferrule-lint.unused"></error>`,
+		`<file name="b.fe">`,
+		`severity="error"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %s:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "<file ") != 2 {
+		t.Errorf("want two file elements:\n%s", got)
+	}
+}
+
+func TestEncodeUnknown(t *testing.T) {
+	if err := report.Encode(&bytes.Buffer{}, "yaml", &report.Report{}); err == nil {
+		t.Error("Encode with an unknown format succeeded")
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	r := sample(t)
+	r.Findings[1].Message = "x, declared: 100% unused\nreally"
+	var b bytes.Buffer
+	if err := report.Encode(&b, "github", r); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != len(r.Findings) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(r.Findings), b.String())
+	}
+	if want := "::warning file=src/a.fe,line=1,col=44,endLine=1,endColumn=45,title=unused::x, declared: 100%25 unused%0Areally"; lines[1] != want {
+		t.Errorf("got  %s\nwant %s", lines[1], want)
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], "::error file=b.fe,") {
+		t.Errorf("syntax error: %s", lines[len(lines)-1])
+	}
+}
+
+func TestRDJSON(t *testing.T) {
+	var b bytes.Buffer
+	if err := report.Encode(&b, "rdjson", sample(t)); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Source      struct{ Name string }
+		Diagnostics []struct {
+			Message  string
+			Severity string
+			Code     struct{ Value string }
+			Location struct {
+				Path  string
+				Range struct{ Start struct{ Line, Column int } }
+			}
+			Suggestions []struct {
+				Range struct{ Start, End struct{ Line, Column int } }
+				Text  string
+			}
+		}
+	}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Source.Name != "ferrule-lint" || len(got.Diagnostics) < 3 {
+		t.Fatalf("result:\n%s", b.String())
+	}
+	x := got.Diagnostics[1]
+	if x.Code.Value != "unused" || x.Severity != "WARNING" || x.Location.Path != "src/a.fe" || x.Location.Range.Start.Column != 45 {
+		t.Errorf("x = %+v", x)
+	}
+	if len(x.Suggestions) != 1 || x.Suggestions[0].Range.Start.Line != 1 || x.Suggestions[0].Range.End.Column <= x.Suggestions[0].Range.Start.Column {
+		t.Errorf("suggestions = %+v", x.Suggestions)
+	}
+	if last := got.Diagnostics[len(got.Diagnostics)-1]; last.Severity != "ERROR" || last.Code.Value != report.Syntax {
+		t.Errorf("syntax error = %+v", last)
+	}
+}
@@ -0,0 +1,170 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// The SARIF 2.1.0 objects written, with only the properties used.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool       sarifTool     `json:"tool"`
+		ColumnKind string        `json:"columnKind"`
+		Results    []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		FullDescription      sarifMessage `json:"fullDescription"`
+		DefaultConfiguration sarifConfig  `json:"defaultConfiguration"`
+	}
+	sarifConfig struct {
+		Level string `json:"level"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+		Fixes     []sarifFix      `json:"fixes,omitempty"`
+	}
+	sarifLocation struct {
+		PhysicalLocation sarifPhysical `json:"physicalLocation"`
+	}
+	sarifPhysical struct {
+		ArtifactLocation sarifArtifact `json:"artifactLocation"`
+		Region           sarifRegion   `json:"region"`
+	}
+	sarifArtifact struct {
+		URI string `json:"uri"`
+	}
+	sarifRegion struct {
+		StartLine   int `json:"startLine,omitempty"`
+		StartColumn int `json:"startColumn,omitempty"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+		// ByteOffset is not omitted when zero, so it is a pointer.
+		ByteOffset *uint `json:"byteOffset,omitempty"`
+		ByteLength *uint `json:"byteLength,omitempty"`
+	}
+	sarifFix struct {
+		Description     sarifMessage          `json:"description"`
+		ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+	}
+	sarifArtifactChange struct {
+		ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+		Replacements     []sarifReplacement `json:"replacements"`
+	}
+	sarifReplacement struct {
+		DeletedRegion   sarifRegion  `json:"deletedRegion"`
+		InsertedContent sarifMessage `json:"insertedContent"`
+	}
+)
+
+// SARIF writes r as a SARIF 2.1.0 log of one run, as GitHub code scanning
+// uploads. The analyzers are the rules of the tool, followed by a rule for
+// syntax errors, and suggested fixes become fixes replacing byte ranges.
+func SARIF(w io.Writer, r *Report) error {
+	driver := sarifDriver{Name: r.Tool, Rules: []sarifRule{}}
+	index := make(map[string]int)
+	addRule := func(id, doc string, severity ferrule.Severity) {
+		summary, _, _ := strings.Cut(doc, "\n")
+		index[id] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{summary},
+			FullDescription:      sarifMessage{doc},
+			DefaultConfiguration: sarifConfig{level(severity)},
+		})
+	}
+	for _, a := range r.Analyzers {
+		addRule(a.Name, a.Doc, a.Severity)
+	}
+	addRule(Syntax, "syntax errors", ferrule.SeverityError)
+
+	results := []sarifResult{}
+	for _, f := range r.Findings {
+		i, ok := index[f.Category]
+		if !ok {
+			addRule(f.Category, f.Category, f.Severity)
+			i = index[f.Category]
+		}
+		res := sarifResult{
+			RuleID:    f.Category,
+			RuleIndex: i,
+			Level:     level(f.Severity),
+			Message:   sarifMessage{f.Message},
+		}
+		var region sarifRegion
+		region.StartLine, region.StartColumn = f.start(true)
+		region.EndLine, region.EndColumn = f.end(true)
+		uri := artifact(f.Path)
+		res.Locations = []sarifLocation{{sarifPhysical{uri, region}}}
+		for _, fix := range f.SuggestedFixes {
+			change := sarifArtifactChange{ArtifactLocation: uri}
+			for _, e := range fix.TextEdits {
+				off, length := e.Start, e.End-e.Start
+				change.Replacements = append(change.Replacements, sarifReplacement{
+					DeletedRegion:   sarifRegion{ByteOffset: &off, ByteLength: &length},
+					InsertedContent: sarifMessage{string(e.NewText)},
+				})
+			}
+			res.Fixes = append(res.Fixes, sarifFix{Description: sarifMessage{fix.Message}, ArtifactChanges: []sarifArtifactChange{change}})
+		}
+		results = append(results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{driver}, ColumnKind: "unicodeCodePoints", Results: results}},
+	})
+}
+
+// level returns the SARIF level of a severity, warning for the default.
+func level(s ferrule.Severity) string {
+	switch s {
+	case ferrule.SeverityError:
+		return "error"
+	case ferrule.SeverityInformation, ferrule.SeverityHint:
+		return "note"
+	}
+	return "warning"
+}
+
+// artifact returns the location of the file at path: a relative reference
+// for relative paths, which readers resolve against the checkout, and a
+// file URI otherwise.
+func artifact(path string) sarifArtifact {
+	u := url.URL{Path: filepath.ToSlash(path)}
+	if filepath.IsAbs(path) {
+		u.Scheme = "file"
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Path = "/" + u.Path
+		}
+	}
+	return sarifArtifact{URI: u.String()}
+}
@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/directive"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/trivia"
+)
+
+// A Suppression is a line comment turning analyzers off for some code:
+//
+//	// ferrule:disable shadow, unused kept for the debugger
+//
+// The directive names one or more analyzers, separated by commas, and
+// may go on with a reason. A comment following code on its line covers
+// that line; a comment on a line of its own covers the declaration or
+// statement it leads, as package trivia attaches it. The older spelling
+// ferrule:ignore means the same. See package directive for the syntax of
+// directives.
+//
+// Run and RunProject drop the diagnostics of the named analyzers that
+// start on the covered lines. A finding that several analyzers report, as
+// deadcode repeats those of unused, is dropped when any of them is named.
+type Suppression struct {
+	// Comment is the comment holding the directive.
+	Comment *tree_sitter.Node
+	// Directive is the spelling used, ferrule:disable or ferrule:ignore.
+	Directive string
+	// Analyzers are the names of the analyzers turned off.
+	Analyzers []string
+	// Reason is the text after the names, if any.
+	Reason string
+	// StartRow and EndRow are the zero-based rows covered, both included.
+	// A directive covering no code, as one closing a block, has EndRow
+	// less than StartRow.
+	StartRow, EndRow uint
+}
+
+// Covers reports whether s suppresses the diagnostic of the analyzer
+// named category.
+func (s *Suppression) Covers(category string, d Diagnostic) bool {
+	row := d.Range.StartPoint.Row
+	if row < s.StartRow || row > s.EndRow {
+		return false
+	}
+	for _, name := range s.Analyzers {
+		if name == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Suppressions returns the suppression directives of tree in source
+// order. The nodes belong to tree.
+func Suppressions(tree *ferrule.Tree) []*Suppression {
+	var out []*Suppression
+	var standalone map[uintptr]*Suppression
+	for _, d := range directive.All(tree) {
+		if d.Name != directive.Disable && d.Name != directive.Ignore {
+			continue
+		}
+		s := parseSuppression(d)
+		row := d.Comment.StartPosition().Row
+		if d.Trailing {
+			s.StartRow, s.EndRow = row, row
+		} else {
+			// Covering nothing until the node it leads is found.
+			s.StartRow, s.EndRow = row+1, row
+			if standalone == nil {
+				standalone = make(map[uintptr]*Suppression)
+			}
+			standalone[d.Comment.Id()] = s
+		}
+		out = append(out, s)
+	}
+	if standalone == nil {
+		return out
+	}
+	m := trivia.Attach(tree)
+	walk(tree.RootNode(), func(n *tree_sitter.Node) {
+		for _, t := range m.Leading(n) {
+			if t.Node == nil {
+				continue
+			}
+			if s := standalone[t.Node.Id()]; s != nil {
+				s.StartRow, s.EndRow = n.StartPosition().Row, n.EndPosition().Row
+			}
+		}
+	})
+	return out
+}
+
+// parseSuppression reads the analyzer names and reason of the arguments
+// of d, a ferrule:disable or ferrule:ignore directive.
+func parseSuppression(d *directive.Directive) *Suppression {
+	s := &Suppression{Comment: d.Comment, Directive: directive.Prefix + d.Name}
+	text := d.Args
+	for {
+		text = strings.TrimLeft(text, " \t")
+		field, rest := text, ""
+		if i := strings.IndexAny(text, " \t"); i >= 0 {
+			field, rest = text[:i], text[i:]
+		}
+		for _, name := range strings.Split(field, ",") {
+			if name != "" {
+				s.Analyzers = append(s.Analyzers, name)
+			}
+		}
+		text = rest
+		if field == "" || !strings.HasSuffix(field, ",") {
+			break
+		}
+	}
+	s.Reason = strings.TrimSpace(text)
+	return s
+}
+
+// walk calls f for each node of the tree rooted at n, in source order.
+func walk(n *tree_sitter.Node, f func(*tree_sitter.Node)) {
+	cursor := n.Walk()
+	defer cursor.Close()
+	for {
+		f(cursor.Node())
+		if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return
+			}
+		}
+	}
+}
+
+// Stale is the meta-rule reporting suppressions that suppress nothing.
+// It does no work of its own: Run and RunProject judge the directives
+// when it is among their analyzers, against the others they run.
+var Stale = &Analyzer{
+	Name: "stalesuppress",
+	Doc: `report suppression comments that suppress nothing
+
+The stalesuppress analyzer reports ferrule:disable comments naming no
+analyzer, or an analyzer that reported nothing where they apply, so that suppressions
+are removed once the code they excused is fixed. Analyzers that are not
+running are not judged. The suggested fix drops the stale names, or the
+whole comment.`,
+	Run: func(*Pass) error { return nil },
+}
+
+// stale reports the analyzers s names, among those that ran, that it did
+// not suppress.
+func stale(src []byte, s *Suppression, used, ran map[string]bool, report func(Diagnostic)) {
+	var unused, kept []string
+	for _, name := range s.Analyzers {
+		if ran[name] && !used[name] {
+			unused = append(unused, name)
+		} else {
+			kept = append(kept, name)
+		}
+	}
+	if len(s.Analyzers) == 0 {
+		report(Diagnostic{Range: s.Comment.Range(), Message: s.Directive + " names no analyzer"})
+	}
+	if len(unused) == 0 {
+		return
+	}
+	fix := SuggestedFix{Message: "remove the comment"}
+	if len(kept) == 0 {
+		fix.TextEdits = []TextEdit{Delete(src, s.Comment)}
+	} else {
+		text := "// " + s.Directive + " " + strings.Join(kept, ", ")
+		if s.Reason != "" {
+			text += " " + s.Reason
+		}
+		fix.Message = "remove " + strings.Join(unused, ", ") + " from the comment"
+		fix.TextEdits = []TextEdit{Replace(s.Comment, text)}
+	}
+	report(Diagnostic{
+		Range:          s.Comment.Range(),
+		Message:        s.Directive + " of " + strings.Join(unused, ", ") + " suppresses nothing",
+		SuggestedFixes: []SuggestedFix{fix},
+	})
+}
@@ -0,0 +1,452 @@
+package ast
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// The accessors in this file cover rules whose children are not attached to
+// fields in the grammar, so their meaning depends on position. They skip
+// comments, which tree-sitter reports as ordinary named children.
+
+// significant returns the named children of the node that are not extras.
+func (b node) significant() []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < b.n.NamedChildCount(); i++ {
+		c := b.n.NamedChild(i)
+		if !c.IsExtra() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (b node) nth(i int) Node {
+	cs := b.significant()
+	if i < 0 {
+		i += len(cs)
+	}
+	if i < 0 || i >= len(cs) {
+		return nil
+	}
+	return b.wrap(cs[i])
+}
+
+// hasToken reports whether the node has an anonymous child spelled tok.
+func (b node) hasToken(tok string) bool {
+	for i := uint(0); i < b.n.ChildCount(); i++ {
+		c := b.n.Child(i)
+		if !c.IsNamed() && c.Kind() == tok {
+			return true
+		}
+	}
+	return false
+}
+
+// firstToken returns the first anonymous child that is not an extra.
+func (b node) firstToken() string {
+	for i := uint(0); i < b.n.ChildCount(); i++ {
+		c := b.n.Child(i)
+		if !c.IsNamed() && !c.IsExtra() {
+			return c.Kind()
+		}
+	}
+	return ""
+}
+
+func (b node) firstOfKind(kind string) *tree_sitter.Node {
+	for _, c := range b.significant() {
+		if c.Kind() == kind {
+			return c
+		}
+	}
+	return nil
+}
+
+func (b node) after(kind string) Node {
+	cs := b.significant()
+	for i, c := range cs {
+		if c.Kind() == kind && i+1 < len(cs) {
+			return b.wrap(cs[i+1])
+		}
+	}
+	return nil
+}
+
+// as converts n to the wrapper type T, returning nil when n is of a
+// different kind.
+func as[T Node](n Node) T {
+	t, _ := n.(T)
+	return t
+}
+
+// PackageDeclaration returns the package header, or nil.
+func (n *SourceFile) PackageDeclaration() *PackageDeclaration {
+	return as[*PackageDeclaration](n.wrap(n.firstOfKind(kind.PackageDeclaration)))
+}
+
+// Imports returns the import declarations in source order.
+func (n *SourceFile) Imports() []*ImportDeclaration {
+	var out []*ImportDeclaration
+	for _, c := range n.namedChildrenOfKind(kind.ImportDeclaration) {
+		out = append(out, newImportDeclaration(n.a, c))
+	}
+	return out
+}
+
+// FunctionDeclarations returns the top-level functions in source order.
+func (n *SourceFile) FunctionDeclarations() []*FunctionDeclaration {
+	var out []*FunctionDeclaration
+	for _, c := range n.namedChildrenOfKind(kind.FunctionDeclaration) {
+		out = append(out, newFunctionDeclaration(n.a, c))
+	}
+	return out
+}
+
+// Items returns every top-level item except the package header, skipping
+// comments.
+func (n *SourceFile) Items() []Node {
+	var out []Node
+	for _, c := range n.significant() {
+		if c.Kind() != kind.PackageDeclaration {
+			out = append(out, n.wrap(c))
+		}
+	}
+	return out
+}
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *FunctionDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// TypeParameters returns the generic parameter list, or nil.
+func (n *FunctionDeclaration) TypeParameters() *TypeParameters {
+	return as[*TypeParameters](n.wrap(n.firstOfKind(kind.TypeParameters)))
+}
+
+// ErrorClause returns the error clause, or nil.
+func (n *FunctionDeclaration) ErrorClause() *ErrorClause {
+	return as[*ErrorClause](n.wrap(n.firstOfKind(kind.ErrorClause)))
+}
+
+// EffectsClause returns the effects clause, or nil.
+func (n *FunctionDeclaration) EffectsClause() *EffectsClause {
+	return as[*EffectsClause](n.wrap(n.firstOfKind(kind.EffectsClause)))
+}
+
+// TypeParameters returns the generic parameter list, or nil.
+func (n *AnonymousFunction) TypeParameters() *TypeParameters {
+	return as[*TypeParameters](n.wrap(n.firstOfKind(kind.TypeParameters)))
+}
+
+// Parameters returns the parameter list.
+func (n *AnonymousFunction) Parameters() *ParameterList {
+	return as[*ParameterList](n.wrap(n.firstOfKind(kind.ParameterList)))
+}
+
+// ReturnType returns the declared return type.
+func (n *AnonymousFunction) ReturnType() Node { return n.after(kind.ParameterList) }
+
+// ErrorClause returns the error clause, or nil.
+func (n *AnonymousFunction) ErrorClause() *ErrorClause {
+	return as[*ErrorClause](n.wrap(n.firstOfKind(kind.ErrorClause)))
+}
+
+// EffectsClause returns the effects clause, or nil.
+func (n *AnonymousFunction) EffectsClause() *EffectsClause {
+	return as[*EffectsClause](n.wrap(n.firstOfKind(kind.EffectsClause)))
+}
+
+// Body returns the function body.
+func (n *AnonymousFunction) Body() *Block { return as[*Block](n.nth(-1)) }
+
+// Modifier returns "inout" or "cap" for modified parameters, or "".
+func (n *Parameter) Modifier() string {
+	switch {
+	case n.hasToken(kind.KeywordInout):
+		return kind.KeywordInout
+	case n.hasToken(kind.KeywordCap):
+		return kind.KeywordCap
+	}
+	return ""
+}
+
+// IsVar reports whether the binding was declared with var rather than const.
+func (n *ConstDeclaration) IsVar() bool { return n.hasToken(kind.KeywordVar) }
+
+// Type returns the explicit type annotation, or nil.
+func (n *ConstDeclaration) Type() Node {
+	if !n.hasToken(":") {
+		return nil
+	}
+	return n.after(kind.Identifier)
+}
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *TypeDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// TypeParameters returns the generic parameter list, or nil.
+func (n *TypeDeclaration) TypeParameters() *TypeParameters {
+	return as[*TypeParameters](n.wrap(n.firstOfKind(kind.TypeParameters)))
+}
+
+// Constraint returns the where expression refining the type, or nil.
+func (n *TypeDeclaration) Constraint() Node {
+	if !n.hasToken(kind.KeywordWhere) {
+		return nil
+	}
+	return n.nth(-1)
+}
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *DomainDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *ErrorDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *CapabilityDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// CapabilityMember is a single name: type entry of a capability.
+type CapabilityMember struct {
+	Name *Identifier
+	Type Node
+}
+
+// Members returns the entries of the capability in source order.
+func (n *CapabilityDeclaration) Members() []CapabilityMember {
+	var out []CapabilityMember
+	cs := n.significant()
+	for i := 0; i+1 < len(cs); i++ {
+		if cs[i].Kind() == kind.Identifier {
+			out = append(out, CapabilityMember{Name: newIdentifier(n.a, cs[i]), Type: n.wrap(cs[i+1])})
+			i++
+		}
+	}
+	return out
+}
+
+// IsPublic reports whether the declaration is marked pub.
+func (n *ComponentDeclaration) IsPublic() bool { return n.hasToken(kind.KeywordPub) }
+
+// Alias returns the name given with "as", or nil.
+func (n *ImportDeclaration) Alias() *Identifier { return n.Identifier() }
+
+// Name returns the variant name.
+func (n *ErrorVariant) Name() *TypeIdentifier { return as[*TypeIdentifier](n.nth(0)) }
+
+// Body returns the variant payload, or nil.
+func (n *ErrorVariant) Body() *RecordBody {
+	return as[*RecordBody](n.wrap(n.firstOfKind(kind.RecordBody)))
+}
+
+// Name returns the variant name.
+func (n *UnionVariant) Name() *TypeIdentifier { return as[*TypeIdentifier](n.nth(0)) }
+
+// Body returns the variant payload, or nil.
+func (n *UnionVariant) Body() *RecordBody {
+	return as[*RecordBody](n.wrap(n.firstOfKind(kind.RecordBody)))
+}
+
+// IsReadonly reports whether the field is marked readonly.
+func (n *RecordField) IsReadonly() bool { return n.hasToken(kind.KeywordReadonly) }
+
+// Name returns the field name.
+func (n *RecordField) Name() *Identifier { return as[*Identifier](n.nth(0)) }
+
+// Type returns the field type.
+func (n *RecordField) Type() Node { return n.nth(1) }
+
+// Name returns the generic type being instantiated.
+func (n *GenericType) Name() *TypeIdentifier { return as[*TypeIdentifier](n.nth(0)) }
+
+// Arguments returns the type arguments.
+func (n *GenericType) Arguments() []Node { return n.wrapAll(dropFirst(n.significant())) }
+
+// Parameters returns the parameter types.
+func (n *FunctionType) Parameters() []Node {
+	cs := n.significant()
+	if len(cs) == 0 {
+		return nil
+	}
+	return n.wrapAll(cs[:len(cs)-1])
+}
+
+// Result returns the result type.
+func (n *FunctionType) Result() Node { return n.nth(-1) }
+
+// IsWildcard reports whether the pattern is the catch-all _.
+func (n *Pattern) IsWildcard() bool { return n.hasToken("_") }
+
+// Value returns the identifier, literal or destructuring pattern matched, or
+// nil for a wildcard.
+func (n *Pattern) Value() Node { return n.nth(0) }
+
+// TypeName returns the type being destructured, or nil.
+func (n *DestructuringPattern) TypeName() *TypeIdentifier {
+	return as[*TypeIdentifier](n.wrap(n.firstOfKind(kind.TypeIdentifier)))
+}
+
+// Expression returns the expression being evaluated.
+func (n *ExpressionStatement) Expression() Node { return n.nth(0) }
+
+// Value returns the returned expression, or nil for a bare return.
+func (n *ReturnStatement) Value() Node { return n.nth(0) }
+
+// Variable returns the loop variable.
+func (n *ForStatement) Variable() *Identifier { return as[*Identifier](n.nth(0)) }
+
+// Iterable returns the expression being iterated over.
+func (n *ForStatement) Iterable() Node { return n.nth(1) }
+
+// Body returns the loop body.
+func (n *ForStatement) Body() *Block { return as[*Block](n.nth(-1)) }
+
+// Condition returns the loop condition.
+func (n *WhileStatement) Condition() Node { return n.nth(0) }
+
+// Body returns the loop body.
+func (n *WhileStatement) Body() *Block { return as[*Block](n.nth(-1)) }
+
+// Subject returns the expression being matched.
+func (n *MatchStatement) Subject() Node { return n.nth(0) }
+
+// Arms returns the match arms in source order.
+func (n *MatchStatement) Arms() []*MatchArm { return matchArms(n.node) }
+
+// Subject returns the expression being matched.
+func (n *MatchExpression) Subject() Node { return n.nth(0) }
+
+// Arms returns the match arms in source order.
+func (n *MatchExpression) Arms() []*MatchArm { return matchArms(n.node) }
+
+func matchArms(b node) []*MatchArm {
+	var out []*MatchArm
+	for _, c := range b.namedChildrenOfKind(kind.MatchArm) {
+		out = append(out, newMatchArm(b.a, c))
+	}
+	return out
+}
+
+// Pattern returns the pattern of the arm.
+func (n *MatchArm) Pattern() *Pattern { return as[*Pattern](n.nth(0)) }
+
+// Guard returns the if guard of the arm, or nil.
+func (n *MatchArm) Guard() Node {
+	if !n.hasToken(kind.KeywordIf) {
+		return nil
+	}
+	return n.nth(1)
+}
+
+// Body returns the expression or block the arm evaluates to.
+func (n *MatchArm) Body() Node { return n.nth(-1) }
+
+// Condition returns the tested expression.
+func (n *IfExpression) Condition() Node { return n.nth(0) }
+
+// Consequence returns the block evaluated when the condition holds.
+func (n *IfExpression) Consequence() *Block { return as[*Block](n.nth(1)) }
+
+// Alternative returns the else branch, a *Block or *IfExpression.
+func (n *IfExpression) Alternative() Node { return n.nth(2) }
+
+// Operator returns the operator token, e.g. "+" or "is".
+func (n *BinaryExpression) Operator() string { return n.firstToken() }
+
+// Left returns the left operand.
+func (n *BinaryExpression) Left() Node { return n.nth(0) }
+
+// Right returns the right operand.
+func (n *BinaryExpression) Right() Node { return n.nth(-1) }
+
+// Operator returns the prefix operator token.
+func (n *UnaryExpression) Operator() string { return n.firstToken() }
+
+// Operand returns the operand.
+func (n *UnaryExpression) Operand() Node { return n.nth(0) }
+
+// Function returns the expression being called.
+func (n *CallExpression) Function() Node { return n.nth(0) }
+
+// Arguments returns the call arguments.
+func (n *CallExpression) Arguments() []Node { return n.wrapAll(dropFirst(n.significant())) }
+
+// Object returns the expression whose member is accessed.
+func (n *MemberExpression) Object() Node { return n.nth(0) }
+
+// Property returns the accessed member name.
+func (n *MemberExpression) Property() *Identifier { return as[*Identifier](n.nth(-1)) }
+
+// Object returns the indexed expression.
+func (n *IndexExpression) Object() Node { return n.nth(0) }
+
+// Index returns the index expression.
+func (n *IndexExpression) Index() Node { return n.nth(1) }
+
+// Expression returns the wrapped expression.
+func (n *ParenthesizedExpression) Expression() Node { return n.nth(0) }
+
+// Elements returns the array elements.
+func (n *ArrayExpression) Elements() []Node { return n.wrapAll(n.significant()) }
+
+// RecordExpressionField is a single name: value entry of a record literal.
+type RecordExpressionField struct {
+	Name  *Identifier
+	Value Node
+}
+
+// TypeName returns the record type being constructed, or nil for an
+// anonymous record.
+func (n *RecordExpression) TypeName() *TypeIdentifier {
+	cs := n.significant()
+	if len(cs) > 0 && cs[0].Kind() == kind.TypeIdentifier {
+		return newTypeIdentifier(n.a, cs[0])
+	}
+	return nil
+}
+
+// Fields returns the record entries in source order.
+func (n *RecordExpression) Fields() []RecordExpressionField {
+	cs := n.significant()
+	if n.TypeName() != nil {
+		cs = cs[1:]
+	}
+	var out []RecordExpressionField
+	for i := 0; i+1 < len(cs); i += 2 {
+		out = append(out, RecordExpressionField{Name: newIdentifier(n.a, cs[i]), Value: n.wrap(cs[i+1])})
+	}
+	return out
+}
+
+// Expression returns the wrapped value.
+func (n *OkExpression) Expression() Node { return n.nth(0) }
+
+// Expression returns the checked expression.
+func (n *CheckExpression) Expression() Node { return n.nth(0) }
+
+// Variant returns the error variant being constructed.
+func (n *ErrExpression) Variant() *TypeIdentifier { return as[*TypeIdentifier](n.nth(0)) }
+
+// Payload returns the error payload, or nil.
+func (n *ErrExpression) Payload() *RecordExpression {
+	return as[*RecordExpression](n.wrap(n.firstOfKind(kind.RecordExpression)))
+}
+
+// Value returns true or false.
+func (n *BooleanLiteral) Value() bool { return n.hasToken(kind.KeywordTrue) }
+
+func dropFirst(ns []*tree_sitter.Node) []*tree_sitter.Node {
+	if len(ns) == 0 {
+		return nil
+	}
+	return ns[1:]
+}
+
+func (b node) wrapAll(ns []*tree_sitter.Node) []Node {
+	out := make([]Node, len(ns))
+	for i, c := range ns {
+		out[i] = b.wrap(c)
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package ast
+
+import tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+// slabSize is the number of wrappers of a type allocated at a time.
+const slabSize = 256
+
+// An Arena allocates wrappers in slabs of a kind, so that walking a tree
+// costs a few large allocations rather than one small one per node, and
+// frees them together with Reset.
+//
+// The wrappers of an arena must not be used after it is reset; Reset
+// keeps the slabs for the wrappers allocated next, so that a tool indexing
+// file after file through one arena allocates only for the largest. The
+// zero Arena is ready to use. An Arena is not safe for concurrent use.
+type Arena struct {
+	slabs   slabs
+	tokens  slab[Token]
+	errors  slab[ErrorNode]
+	unknown slab[Unknown]
+}
+
+// NewArena returns an empty arena.
+func NewArena() *Arena { return new(Arena) }
+
+// Wrap returns the typed wrapper for n, allocated from a. It returns nil
+// when n is nil.
+func (a *Arena) Wrap(n *tree_sitter.Node) Node { return wrapIn(a, n) }
+
+// Root wraps the root node of tree as a SourceFile allocated from a.
+func (a *Arena) Root(tree *tree_sitter.Tree) *SourceFile {
+	return newSourceFile(a, tree.RootNode())
+}
+
+// Reset frees every wrapper allocated from a.
+func (a *Arena) Reset() {
+	a.slabs.reset()
+	a.tokens.reset()
+	a.errors.reset()
+	a.unknown.reset()
+}
+
+// arenaOf returns the arena n was allocated from, or nil.
+func arenaOf(n Node) *Arena {
+	if w, ok := n.(interface{ arena() *Arena }); ok {
+		return w.arena()
+	}
+	return nil
+}
+
+// slab allocates values of type T in chunks of slabSize.
+type slab[T any] struct {
+	chunks [][]T
+	// next is the index of the chunk being filled and used the number of
+	// its values handed out.
+	next, used int
+}
+
+func (s *slab[T]) alloc() *T {
+	if s.next < len(s.chunks) && s.used == slabSize {
+		s.next, s.used = s.next+1, 0
+	}
+	if s.next == len(s.chunks) {
+		s.chunks = append(s.chunks, make([]T, slabSize))
+	}
+	s.used++
+	return &s.chunks[s.next][s.used-1]
+}
+
+// reset makes the whole slab free again. The values handed out are zeroed,
+// so that they no longer keep tree-sitter nodes alive.
+func (s *slab[T]) reset() {
+	for i := 0; i < s.next && i < len(s.chunks); i++ {
+		clear(s.chunks[i])
+	}
+	if s.next < len(s.chunks) {
+		clear(s.chunks[s.next][:s.used])
+	}
+	s.next, s.used = 0, 0
+}
+
+func newToken(a *Arena, n *tree_sitter.Node) *Token {
+	if a == nil {
+		return &Token{node{n: n}}
+	}
+	w := a.tokens.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+func newErrorNode(a *Arena, n *tree_sitter.Node) *ErrorNode {
+	if a == nil {
+		return &ErrorNode{node{n: n}}
+	}
+	w := a.errors.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+func newUnknown(a *Arena, n *tree_sitter.Node) *Unknown {
+	if a == nil {
+		return &Unknown{node{n: n}}
+	}
+	w := a.unknown.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
@@ -0,0 +1,173 @@
+// Package ast provides strongly typed wrappers over the raw tree-sitter
+// nodes produced by the ferrule grammar.
+//
+// Every named node kind in node-types.json has a matching Go type with
+// accessors for its fields and named children, so callers do not have to
+// juggle kind strings and field names by hand. The wrappers are thin: they
+// hold the underlying *tree_sitter.Node and resolve children lazily.
+//
+// Wrappers are small heap objects of their own, one per node visited.
+// Tools that walk many trees, such as indexers, can allocate them from an
+// Arena instead: the wrappers reached from a node of an arena, through its
+// accessors or by walking it, come from the same arena, which frees them
+// all at once.
+//
+// PathAt returns the nodes enclosing a position, each with a short label,
+// for editor breadcrumbs and commands that act on what surrounds the
+// cursor.
+package ast
+
+//go:generate go run ../cmd/ferrule-nodegen -what ast -o nodes.go ../../../src/node-types.json
+
+import (
+	"iter"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Node is implemented by every typed wrapper.
+type Node interface {
+	// Raw returns the underlying tree-sitter node.
+	Raw() *tree_sitter.Node
+	// Kind returns the grammar kind of the node, e.g. "function_declaration".
+	Kind() string
+	// Text returns the source text covered by the node.
+	Text(source []byte) string
+	// Children yields every child, including anonymous tokens.
+	Children() iter.Seq[Node]
+	// NamedChildren yields the named children, including comments and
+	// error nodes.
+	NamedChildren() iter.Seq[Node]
+	// Descendants yields every node below this one in depth-first order.
+	Descendants() iter.Seq[Node]
+}
+
+type node struct {
+	n *tree_sitter.Node
+	// a is the arena the node and those reached from it are allocated
+	// from, or nil for the heap.
+	a *Arena
+}
+
+func (b node) Raw() *tree_sitter.Node { return b.n }
+
+func (b node) Kind() string { return b.n.Kind() }
+
+func (b node) Text(source []byte) string { return b.n.Utf8Text(source) }
+
+// Range returns the byte and point range of the node.
+func (b node) Range() tree_sitter.Range { return b.n.Range() }
+
+// Children yields every child wrapped in its typed form, including
+// anonymous tokens.
+func (b node) Children() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for i := uint(0); i < b.n.ChildCount(); i++ {
+			if !yield(b.wrap(b.n.Child(i))) {
+				return
+			}
+		}
+	}
+}
+
+// NamedChildren yields every named child wrapped in its typed form,
+// including comments and error nodes.
+func (b node) NamedChildren() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for i := uint(0); i < b.n.NamedChildCount(); i++ {
+			if !yield(b.wrap(b.n.NamedChild(i))) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants yields every node below b in depth-first order, tokens
+// included.
+func (b node) Descendants() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		cursor := b.n.Walk()
+		defer cursor.Close()
+		if !cursor.GotoFirstChild() {
+			return
+		}
+		for {
+			if !yield(b.wrap(cursor.Node())) {
+				return
+			}
+			if cursor.GotoFirstChild() || cursor.GotoNextSibling() {
+				continue
+			}
+			for {
+				if !cursor.GotoParent() || cursor.Depth() == 0 {
+					return
+				}
+				if cursor.GotoNextSibling() {
+					break
+				}
+			}
+		}
+	}
+}
+
+// namedChildrenOfKind returns the named children of the given kind that are
+// not attached to a field.
+func (b node) namedChildrenOfKind(kind string) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < b.n.NamedChildCount(); i++ {
+		c := b.n.NamedChild(i)
+		if c.Kind() == kind && b.n.FieldNameForNamedChild(uint32(i)) == "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (b node) childrenByField(field string) []*tree_sitter.Node {
+	cursor := b.n.Walk()
+	defer cursor.Close()
+	children := b.n.ChildrenByFieldName(field, cursor)
+	out := make([]*tree_sitter.Node, len(children))
+	for i := range children {
+		out[i] = &children[i]
+	}
+	return out
+}
+
+// Token wraps an anonymous node such as a keyword or punctuation.
+type Token struct{ node }
+
+// ErrorNode wraps an ERROR node inserted by the parser during recovery.
+type ErrorNode struct{ node }
+
+// Unknown wraps a named node whose kind is not known to this package,
+// which happens when the grammar gains a rule before the wrappers are
+// regenerated.
+type Unknown struct{ node }
+
+// wrap wraps c, a node reached from b, in the arena of b.
+func (b node) wrap(c *tree_sitter.Node) Node { return wrapIn(b.a, c) }
+
+func (b node) arena() *Arena { return b.a }
+
+// Wrap returns the typed wrapper for n. It returns nil when n is nil.
+func Wrap(n *tree_sitter.Node) Node { return wrapIn(nil, n) }
+
+// wrapIn returns the typed wrapper for n, allocated from a.
+func wrapIn(a *Arena, n *tree_sitter.Node) Node {
+	if n == nil {
+		return nil
+	}
+	if n.IsError() {
+		return newErrorNode(a, n)
+	}
+	if !n.IsNamed() {
+		return newToken(a, n)
+	}
+	return wrapNamed(a, n)
+}
+
+// Root wraps the root node of tree as a SourceFile.
+func Root(tree *tree_sitter.Tree) *SourceFile {
+	return newSourceFile(nil, tree.RootNode())
+}
@@ -0,0 +1,335 @@
+package ast_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/printer"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+const source = `package example.hello;
+
+// adds numbers
+pub function add<T>(x: i32, inout y: i32) -> i32 error IoError effects [io] {
+  const total: i32 = x + y;
+  match total {
+    0 -> log("zero");
+    n if n > 10 -> { return n; }
+    _ -> check add(1, 2);
+  }
+  for i in items {
+    print(i.name);
+  }
+  return total;
+}
+`
+
+func parse(t *testing.T, src string) *tree_sitter.Tree {
+	t.Helper()
+	parser := tree_sitter.NewParser()
+	t.Cleanup(parser.Close)
+	if err := parser.SetLanguage(tree_sitter.NewLanguage(tree_sitter_ferrule.Language())); err != nil {
+		t.Fatalf("Error setting language: %v", err)
+	}
+	tree := parser.Parse([]byte(src), nil)
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+func TestFunctionDeclaration(t *testing.T) {
+	src := []byte(source)
+	root := ast.Root(parse(t, source))
+
+	if got := root.PackageDeclaration().Path().Text(src); got != "example.hello" {
+		t.Errorf("package path = %q", got)
+	}
+	fns := root.FunctionDeclarations()
+	if len(fns) != 1 {
+		t.Fatalf("got %d functions, want 1", len(fns))
+	}
+	fn := fns[0]
+	if got := fn.Name().Text(src); got != "add" {
+		t.Errorf("name = %q", got)
+	}
+	if !fn.IsPublic() {
+		t.Error("expected function to be public")
+	}
+	if fn.TypeParameters() == nil || fn.ErrorClause() == nil || fn.EffectsClause() == nil {
+		t.Error("expected type parameters, error clause and effects clause")
+	}
+	params := fn.Parameters().Parameters()
+	if len(params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(params))
+	}
+	if params[1].Modifier() != "inout" || params[1].Type().Text(src) != "i32" {
+		t.Errorf("unexpected second parameter %q", params[1].Text(src))
+	}
+	if _, ok := fn.ReturnType().(*ast.PrimitiveType); !ok {
+		t.Errorf("return type is %T, want *ast.PrimitiveType", fn.ReturnType())
+	}
+}
+
+func TestStatements(t *testing.T) {
+	src := []byte(source)
+	body := ast.Root(parse(t, source)).FunctionDeclarations()[0].Body()
+	stmts := slices.Collect(body.NamedChildren())
+
+	decl, ok := stmts[0].(*ast.ConstDeclaration)
+	if !ok {
+		t.Fatalf("first statement is %T", stmts[0])
+	}
+	if decl.IsVar() || decl.Type().Text(src) != "i32" {
+		t.Errorf("unexpected declaration %q", decl.Text(src))
+	}
+	bin, ok := decl.Value().(*ast.BinaryExpression)
+	if !ok || bin.Operator() != "+" || bin.Left().Text(src) != "x" || bin.Right().Text(src) != "y" {
+		t.Errorf("unexpected value %q", decl.Value().Text(src))
+	}
+
+	match := stmts[1].(*ast.MatchStatement)
+	arms := match.Arms()
+	if match.Subject().Text(src) != "total" || len(arms) != 3 {
+		t.Fatalf("unexpected match %q", match.Text(src))
+	}
+	if arms[1].Guard() == nil || arms[0].Guard() != nil {
+		t.Error("expected only the second arm to have a guard")
+	}
+	if !arms[2].Pattern().IsWildcard() {
+		t.Error("expected wildcard in last arm")
+	}
+	check := arms[2].Body().(*ast.CheckExpression)
+	call := check.Expression().(*ast.CallExpression)
+	if call.Function().Text(src) != "add" || len(call.Arguments()) != 2 {
+		t.Errorf("unexpected call %q", call.Text(src))
+	}
+
+	loop := stmts[2].(*ast.ForStatement)
+	if loop.Variable().Text(src) != "i" || loop.Iterable().Text(src) != "items" || loop.Body() == nil {
+		t.Errorf("unexpected loop %q", loop.Text(src))
+	}
+
+	ret := stmts[3].(*ast.ReturnStatement)
+	if ret.Value().Text(src) != "total" {
+		t.Errorf("unexpected return %q", ret.Text(src))
+	}
+}
+
+func TestWrapErrorAndComment(t *testing.T) {
+	src := "// note\nfunction broken( -> i32 {}\n"
+	root := ast.Root(parse(t, src))
+
+	var sawComment, sawError bool
+	for c := range root.NamedChildren() {
+		switch c.(type) {
+		case *ast.LineComment:
+			sawComment = true
+		case *ast.ErrorNode:
+			sawError = true
+		}
+	}
+	if !sawComment {
+		t.Error("expected a line comment child")
+	}
+	if !sawError && !root.Raw().HasError() {
+		t.Error("expected an error in the tree")
+	}
+	if ast.Wrap(nil) != nil {
+		t.Error("Wrap(nil) should be nil")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tree := parse(t, "const a = f(1);\n")
+	var events []string
+	ast.Walk(ast.Root(tree), ast.Funcs{
+		Pre: func(n ast.Node) bool {
+			events = append(events, "+"+n.Kind())
+			return n.Kind() != "call_expression"
+		},
+		Post: func(n ast.Node) { events = append(events, "-"+n.Kind()) },
+	})
+	want := "+source_file +const_declaration +const -const +identifier -identifier +=" +
+		" -= +call_expression +; -; -const_declaration -source_file"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestArena(t *testing.T) {
+	tree := parse(t, source)
+	kinds := func(root ast.Node) []string {
+		var out []string
+		ast.InspectNamed(root, func(n ast.Node) bool {
+			if n != nil {
+				out = append(out, n.Kind())
+			}
+			return true
+		})
+		return out
+	}
+	a := ast.NewArena()
+	want := kinds(ast.Root(tree))
+	if got := kinds(a.Root(tree)); !slices.Equal(got, want) {
+		t.Errorf("arena walk:\n%v\nwant:\n%v", got, want)
+	}
+	fn := a.Root(tree).FunctionDeclarations()[0]
+	if got := fn.Name().Text([]byte(source)); got != "add" {
+		t.Errorf("name = %q", got)
+	}
+
+	heap := testing.AllocsPerRun(10, func() { kinds(ast.Root(tree)) })
+	arena := testing.AllocsPerRun(10, func() {
+		kinds(a.Root(tree))
+		a.Reset()
+	})
+	if arena >= heap {
+		t.Errorf("walking allocates %v times from an arena, %v from the heap", arena, heap)
+	}
+}
+
+func TestInspectNamed(t *testing.T) {
+	tree := parse(t, source)
+	counts := make(map[string]int)
+	depth, maxDepth := 0, 0
+	ast.InspectNamed(ast.Root(tree), func(n ast.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		if tok, ok := n.(*ast.Token); ok {
+			t.Errorf("InspectNamed visited token %q", tok.Kind())
+		}
+		counts[n.Kind()]++
+		// skip the bodies of match arms.
+		if n.Kind() == "match_arm" {
+			return false
+		}
+		depth++
+		maxDepth = max(maxDepth, depth)
+		return true
+	})
+	if depth != 0 {
+		t.Errorf("unbalanced f(nil) calls: depth %d", depth)
+	}
+	if counts["match_arm"] != 3 || counts["pattern"] != 0 || counts["for_statement"] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+	if counts["line_comment"] != 1 || maxDepth < 5 {
+		t.Errorf("line comments %d, max depth %d", counts["line_comment"], maxDepth)
+	}
+}
+
+func TestIterators(t *testing.T) {
+	src := "function main() -> Unit { add(1, 2); }\n"
+	root := ast.Root(parse(t, src))
+
+	var calls []string
+	for n := range root.Descendants() {
+		if call, ok := n.(*ast.CallExpression); ok {
+			calls = append(calls, call.Text([]byte(src)))
+		}
+	}
+	if len(calls) != 1 || calls[0] != "add(1, 2)" {
+		t.Errorf("calls = %q", calls)
+	}
+
+	fn := root.FunctionDeclarations()[0]
+	var kinds []string
+	for c := range fn.Children() {
+		kinds = append(kinds, c.Kind())
+	}
+	if got := strings.Join(kinds, " "); got != "function identifier parameter_list -> primitive_type block" {
+		t.Errorf("children = %s", got)
+	}
+	for range fn.Body().Descendants() {
+		break
+	}
+}
+
+func TestBuild(t *testing.T) {
+	point := ast.NewType("Point", ast.NewRecord().
+		Field("x", ast.TypeName("f64")).
+		Field("y", ast.TypeName("f64")).
+		Build()).Pub().Build()
+	fn := ast.NewFunc("scale").Pub().
+		Doc("scale multiplies both coordinates of p by k.").
+		Param("p", ast.TypeName("Point")).
+		Param("k", ast.TypeName("f64")).
+		Returns(ast.TypeName("Point")).
+		Effects("io").
+		Body(
+			ast.Const("label", ast.StringLit("scale \"p\"\n")),
+			ast.If(ast.Binary(ast.Ident("k"), "==", ast.IntLit(1)),
+				ast.BlockOf(ast.Return(ast.Ident("p"))), nil),
+			ast.ExprStmt(ast.Call(ast.Member(ast.Ident("log"), "info"), ast.Ident("label"), ast.IntLit(-1))),
+			ast.Return(ast.Call(ast.Ident("point"),
+				ast.Binary(ast.Member(ast.Ident("p"), "x"), "*", ast.Ident("k")),
+				ast.Binary(ast.Member(ast.Ident("p"), "y"), "*", ast.Ident("k")))),
+		).Build()
+	file := ast.NewFile("geometry.points").Import("std.log", "").Decl(point, fn).Build()
+
+	got, err := printer.Print(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package geometry.points;
+
+import std.log;
+
+pub type Point = { x: f64, y: f64 };
+
+// scale multiplies both coordinates of p by k.
+pub function scale(p: Point, k: f64) -> Point effects [io] {
+  const label = "scale \"p\"\n";
+  if k == 1 {
+    return p;
+  }
+  log.info(label, -1);
+  return point(p.x * k, p.y * k);
+}
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if tree := parse(t, string(got)); tree.RootNode().HasError() {
+		t.Errorf("built code does not parse: %s", tree.RootNode().ToSexp())
+	}
+	formatted, err := format.Source(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(formatted) != string(got) {
+		t.Errorf("built code is not formatted; format.Source gives:\n%s", formatted)
+	}
+}
+
+func TestPathAt(t *testing.T) {
+	src := []byte(source)
+	tree := parse(t, source)
+	// the 10 of the guard n > 10.
+	path := ast.PathAt(tree, src, tree_sitter.Point{Row: 7, Column: 13})
+	if got, want := path.String(), "package example.hello > fn add > match > n"; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if last := path[len(path)-1]; last.Node.Kind() != "integer_literal" || last.Label != "integer literal" {
+		t.Errorf("innermost crumb %q of kind %s", last.Label, last.Node.Kind())
+	}
+	if _, ok := path[0].Node.(*ast.SourceFile); !ok {
+		t.Errorf("path starts at %T", path[0].Node)
+	}
+	if fn := ast.Innermost[*ast.FunctionDeclaration](path); fn == nil || fn.Name().Text(src) != "add" {
+		t.Errorf("Innermost function = %v", fn)
+	}
+	if loop := ast.Innermost[*ast.ForStatement](path); loop != nil {
+		t.Errorf("Innermost for = %v", loop)
+	}
+
+	if got, want := ast.PathAt(tree, src, tree_sitter.Point{Row: 11, Column: 10}).String(), "package example.hello > fn add > for"; got != want {
+		t.Errorf("in the loop: %q, want %q", got, want)
+	}
+}
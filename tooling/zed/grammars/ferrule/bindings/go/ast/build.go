@@ -0,0 +1,376 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/literal"
+)
+
+// The builders below construct synthetic nodes for code generators. The
+// nodes they return are laid out by package printer:
+//
+//	fn := ast.NewFunc("add").Pub().
+//		Param("x", ast.TypeName("i32")).
+//		Param("y", ast.TypeName("i32")).
+//		Returns(ast.TypeName("i32")).
+//		Body(ast.Return(ast.Binary(ast.Ident("x"), "+", ast.Ident("y"))))
+//	file := ast.NewFile("math").Decl(fn.Build()).Build()
+//	src, err := printer.Print(file)
+
+// FileBuilder builds a source file.
+type FileBuilder struct {
+	pkg     string
+	imports []*Synthetic
+	decls   []Node
+}
+
+// NewFile starts a source file in package pkg, or outside of any package
+// if pkg is empty.
+func NewFile(pkg string) *FileBuilder { return &FileBuilder{pkg: pkg} }
+
+// Import adds an import of the package path, under the name alias unless
+// it is empty.
+func (b *FileBuilder) Import(path, alias string) *FileBuilder {
+	parts := []Part{Child(NewToken(kind.KeywordImport)), Field("path", packagePath(path))}
+	if alias != "" {
+		parts = append(parts, Child(NewToken(kind.KeywordAs)), Child(Ident(alias)))
+	}
+	b.imports = append(b.imports, NewNode(kind.ImportDeclaration, append(parts, Child(NewToken(";")))...))
+	return b
+}
+
+// Decl adds top-level declarations.
+func (b *FileBuilder) Decl(decls ...Node) *FileBuilder {
+	b.decls = append(b.decls, decls...)
+	return b
+}
+
+// Build returns the source file.
+func (b *FileBuilder) Build() *Synthetic {
+	var parts []Part
+	if b.pkg != "" {
+		parts = append(parts, Child(NewNode(kind.PackageDeclaration,
+			Child(NewToken(kind.KeywordPackage)), Field("path", packagePath(b.pkg)), Child(NewToken(";")))))
+	}
+	for _, n := range b.imports {
+		parts = append(parts, Child(n))
+	}
+	for _, n := range b.decls {
+		parts = append(parts, Child(n))
+	}
+	return NewNode(kind.SourceFile, parts...)
+}
+
+func packagePath(path string) *Synthetic {
+	var parts []Part
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			parts = append(parts, Child(NewToken(".")))
+		}
+		parts = append(parts, Child(Ident(name)))
+	}
+	return NewNode(kind.PackagePath, parts...)
+}
+
+// FuncBuilder builds a function declaration. A function returns Unit
+// unless Returns is called.
+type FuncBuilder struct {
+	name       string
+	pub        bool
+	doc        []string
+	typeParams []string
+	params     []Part
+	result     Node
+	errorType  string
+	effects    []string
+	body       []Node
+}
+
+// NewFunc starts a function declaration named name.
+func NewFunc(name string) *FuncBuilder { return &FuncBuilder{name: name} }
+
+// Pub makes the function public.
+func (b *FuncBuilder) Pub() *FuncBuilder {
+	b.pub = true
+	return b
+}
+
+// Doc sets the doc comment of the function, printed as line comments.
+func (b *FuncBuilder) Doc(text string) *FuncBuilder {
+	b.doc = lineComments(text)
+	return b
+}
+
+// TypeParam adds a type parameter.
+func (b *FuncBuilder) TypeParam(name string) *FuncBuilder {
+	b.typeParams = append(b.typeParams, name)
+	return b
+}
+
+// Param adds a parameter of type typ.
+func (b *FuncBuilder) Param(name string, typ Node) *FuncBuilder {
+	b.params = append(b.params, Child(NewNode(kind.Parameter,
+		Field("name", Ident(name)), Child(NewToken(":")), Field("type", typ))))
+	return b
+}
+
+// Returns sets the return type.
+func (b *FuncBuilder) Returns(typ Node) *FuncBuilder {
+	b.result = typ
+	return b
+}
+
+// Error sets the error domain of the function.
+func (b *FuncBuilder) Error(domain string) *FuncBuilder {
+	b.errorType = domain
+	return b
+}
+
+// Effects adds effects to the effects clause.
+func (b *FuncBuilder) Effects(effects ...string) *FuncBuilder {
+	b.effects = append(b.effects, effects...)
+	return b
+}
+
+// Body adds statements to the body.
+func (b *FuncBuilder) Body(stmts ...Node) *FuncBuilder {
+	b.body = append(b.body, stmts...)
+	return b
+}
+
+// Build returns the function declaration.
+func (b *FuncBuilder) Build() *Synthetic {
+	var parts []Part
+	if b.pub {
+		parts = append(parts, Child(NewToken(kind.KeywordPub)))
+	}
+	parts = append(parts, Child(NewToken(kind.KeywordFunction)), Field("name", Ident(b.name)))
+	if len(b.typeParams) > 0 {
+		var params []Node
+		for _, name := range b.typeParams {
+			params = append(params, NewNode(kind.TypeParameter, Field("name", NewLeaf(kind.TypeIdentifier, name))))
+		}
+		parts = append(parts, Child(NewNode(kind.TypeParameters, list("<", params, ">")...)))
+	}
+	params := NewNode(kind.ParameterList, Child(NewToken("(")))
+	for i, p := range b.params {
+		if i > 0 {
+			params.parts = append(params.parts, Child(NewToken(",")))
+		}
+		params.parts = append(params.parts, p)
+	}
+	params.parts = append(params.parts, Child(NewToken(")")))
+	result := b.result
+	if result == nil {
+		result = TypeName(kind.KeywordUnit)
+	}
+	parts = append(parts, Field("parameters", params), Child(NewToken("->")), Field("return_type", result))
+	if b.errorType != "" {
+		parts = append(parts, Child(NewNode(kind.ErrorClause,
+			Child(NewToken(kind.KeywordError)), Child(NewLeaf(kind.TypeIdentifier, b.errorType)))))
+	}
+	if len(b.effects) > 0 {
+		var effects []Node
+		for _, e := range b.effects {
+			effects = append(effects, Ident(e))
+		}
+		parts = append(parts, Child(NewNode(kind.EffectsClause,
+			append([]Part{Child(NewToken(kind.KeywordEffects))}, list("[", effects, "]")...)...)))
+	}
+	parts = append(parts, Field("body", BlockOf(b.body...)))
+	n := NewNode(kind.FunctionDeclaration, parts...)
+	n.Comments = b.doc
+	return n
+}
+
+// TypeBuilder builds a type declaration.
+type TypeBuilder struct {
+	name string
+	pub  bool
+	doc  []string
+	typ  Node
+}
+
+// NewType starts a declaration of the type name, as an alias of typ.
+func NewType(name string, typ Node) *TypeBuilder { return &TypeBuilder{name: name, typ: typ} }
+
+// Pub makes the type public.
+func (b *TypeBuilder) Pub() *TypeBuilder {
+	b.pub = true
+	return b
+}
+
+// Doc sets the doc comment of the type, printed as line comments.
+func (b *TypeBuilder) Doc(text string) *TypeBuilder {
+	b.doc = lineComments(text)
+	return b
+}
+
+// Build returns the type declaration.
+func (b *TypeBuilder) Build() *Synthetic {
+	var parts []Part
+	if b.pub {
+		parts = append(parts, Child(NewToken(kind.KeywordPub)))
+	}
+	parts = append(parts,
+		Child(NewToken(kind.KeywordType)),
+		Field("name", NewLeaf(kind.TypeIdentifier, b.name)),
+		Child(NewToken("=")),
+		Field("type", b.typ),
+		Child(NewToken(";")))
+	n := NewNode(kind.TypeDeclaration, parts...)
+	n.Comments = b.doc
+	return n
+}
+
+// RecordBuilder builds a record type.
+type RecordBuilder struct {
+	fields []Node
+}
+
+// NewRecord starts a record type without fields.
+func NewRecord() *RecordBuilder { return &RecordBuilder{} }
+
+// Field adds a field of type typ.
+func (b *RecordBuilder) Field(name string, typ Node) *RecordBuilder {
+	b.fields = append(b.fields, NewNode(kind.RecordField, Child(Ident(name)), Child(NewToken(":")), Child(typ)))
+	return b
+}
+
+// Build returns the record type.
+func (b *RecordBuilder) Build() *Synthetic {
+	return NewNode(kind.RecordType, list("{", b.fields, "}")...)
+}
+
+// list returns the parts of a list of nodes separated by commas between
+// the tokens open and end.
+func list(open string, nodes []Node, end string) []Part {
+	parts := []Part{Child(NewToken(open))}
+	for i, n := range nodes {
+		if i > 0 {
+			parts = append(parts, Child(NewToken(",")))
+		}
+		parts = append(parts, Child(n))
+	}
+	return append(parts, Child(NewToken(end)))
+}
+
+func lineComments(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		lines = append(lines, strings.TrimRight("// "+line, " "))
+	}
+	return lines
+}
+
+// Ident returns an identifier.
+func Ident(name string) *Synthetic { return NewLeaf(kind.Identifier, name) }
+
+// TypeName returns the named type: a primitive type such as i32 or
+// String, or a type identifier.
+func TypeName(name string) *Synthetic {
+	if primitive[name] {
+		return NewNode(kind.PrimitiveType, Child(NewToken(name)))
+	}
+	return NewLeaf(kind.TypeIdentifier, name)
+}
+
+var primitive = map[string]bool{
+	"i8": true, "i16": true, "i32": true, "i64": true, "i128": true,
+	"u8": true, "u16": true, "u32": true, "u64": true, "u128": true, "usize": true,
+	"f16": true, "f32": true, "f64": true,
+	"Bool": true, "Char": true, "String": true, "Bytes": true, "Unit": true, "Never": true,
+}
+
+// Generic returns the generic type name applied to args, as in
+// List<i32>.
+func Generic(name string, args ...Node) *Synthetic {
+	return NewNode(kind.GenericType, append([]Part{Child(NewLeaf(kind.TypeIdentifier, name))}, list("<", args, ">")...)...)
+}
+
+// IntLit returns an integer literal.
+func IntLit(v int64) *Synthetic {
+	n := NewLeaf(kind.IntegerLiteral, strconv.FormatInt(v, 10))
+	if v < 0 {
+		// The grammar has no negative literals.
+		return NewNode(kind.UnaryExpression, Child(NewToken("-")), Child(NewLeaf(kind.IntegerLiteral, n.Value[1:])))
+	}
+	return n
+}
+
+// BoolLit returns true or false.
+func BoolLit(v bool) *Synthetic {
+	return NewNode(kind.BooleanLiteral, Child(NewToken(strconv.FormatBool(v))))
+}
+
+// StringLit returns a string literal holding s, escaped as needed.
+func StringLit(s string) *Synthetic {
+	return NewLeaf(kind.StringLiteral, literal.Quote(s))
+}
+
+// Call returns the call of callee with args.
+func Call(callee Node, args ...Node) *Synthetic {
+	return NewNode(kind.CallExpression, append([]Part{Child(callee)}, list("(", args, ")")...)...)
+}
+
+// Member returns the selection of the member name of x.
+func Member(x Node, name string) *Synthetic {
+	return NewNode(kind.MemberExpression, Child(x), Child(NewToken(".")), Child(Ident(name)))
+}
+
+// Binary returns the binary expression x op y. Operands are taken as they
+// are: wrap them with Paren where precedence requires it.
+func Binary(x Node, op string, y Node) *Synthetic {
+	return NewNode(kind.BinaryExpression, Child(x), Child(NewToken(op)), Child(y))
+}
+
+// Paren returns x in parentheses.
+func Paren(x Node) *Synthetic {
+	return NewNode(kind.ParenthesizedExpression, Child(NewToken("(")), Child(x), Child(NewToken(")")))
+}
+
+// BlockOf returns a block of statements.
+func BlockOf(stmts ...Node) *Synthetic {
+	parts := []Part{Child(NewToken("{"))}
+	for _, s := range stmts {
+		parts = append(parts, Child(s))
+	}
+	return NewNode(kind.Block, append(parts, Child(NewToken("}")))...)
+}
+
+// Const returns the declaration of the constant name, initialized to
+// value.
+func Const(name string, value Node) *Synthetic { return binding(kind.KeywordConst, name, value) }
+
+// Var returns the declaration of the variable name, initialized to value.
+func Var(name string, value Node) *Synthetic { return binding(kind.KeywordVar, name, value) }
+
+func binding(keyword, name string, value Node) *Synthetic {
+	return NewNode(kind.ConstDeclaration,
+		Child(NewToken(keyword)), Field("name", Ident(name)), Child(NewToken("=")), Field("value", value), Child(NewToken(";")))
+}
+
+// Return returns a return statement, of x unless it is nil.
+func Return(x Node) *Synthetic {
+	if x == nil {
+		return NewNode(kind.ReturnStatement, Child(NewToken(kind.KeywordReturn)), Child(NewToken(";")))
+	}
+	return NewNode(kind.ReturnStatement, Child(NewToken(kind.KeywordReturn)), Child(x), Child(NewToken(";")))
+}
+
+// ExprStmt returns the statement evaluating x.
+func ExprStmt(x Node) *Synthetic {
+	return NewNode(kind.ExpressionStatement, Child(x), Child(NewToken(";")))
+}
+
+// If returns an if statement running then when cond holds, and otherwise,
+// unless it is nil, else, which must be a block or an if statement.
+func If(cond Node, then *Synthetic, otherwise Node) *Synthetic {
+	n := NewNode(kind.IfStatement, Child(NewToken(kind.KeywordIf)), Field("condition", cond), Field("consequence", then))
+	if otherwise != nil {
+		n.parts = append(n.parts, Child(NewToken(kind.KeywordElse)), Field("alternative", otherwise))
+	}
+	return n
+}
@@ -0,0 +1,1395 @@
+// Code generated by ferrule-nodegen from node-types.json. DO NOT EDIT.
+
+package ast
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+func wrapNamed(a *Arena, n *tree_sitter.Node) Node {
+	switch n.Kind() {
+	case kind.AnonymousFunction:
+		return newAnonymousFunction(a, n)
+	case kind.ArrayExpression:
+		return newArrayExpression(a, n)
+	case kind.BinaryExpression:
+		return newBinaryExpression(a, n)
+	case kind.Block:
+		return newBlock(a, n)
+	case kind.BlockComment:
+		return newBlockComment(a, n)
+	case kind.BooleanLiteral:
+		return newBooleanLiteral(a, n)
+	case kind.CallExpression:
+		return newCallExpression(a, n)
+	case kind.CapabilityDeclaration:
+		return newCapabilityDeclaration(a, n)
+	case kind.CharLiteral:
+		return newCharLiteral(a, n)
+	case kind.CheckExpression:
+		return newCheckExpression(a, n)
+	case kind.ComponentDeclaration:
+		return newComponentDeclaration(a, n)
+	case kind.ConstDeclaration:
+		return newConstDeclaration(a, n)
+	case kind.DestructuringPattern:
+		return newDestructuringPattern(a, n)
+	case kind.DomainDeclaration:
+		return newDomainDeclaration(a, n)
+	case kind.EffectsClause:
+		return newEffectsClause(a, n)
+	case kind.ErrExpression:
+		return newErrExpression(a, n)
+	case kind.ErrorClause:
+		return newErrorClause(a, n)
+	case kind.ErrorDeclaration:
+		return newErrorDeclaration(a, n)
+	case kind.ErrorVariant:
+		return newErrorVariant(a, n)
+	case kind.EscapeSequence:
+		return newEscapeSequence(a, n)
+	case kind.ExpressionStatement:
+		return newExpressionStatement(a, n)
+	case kind.FloatLiteral:
+		return newFloatLiteral(a, n)
+	case kind.ForStatement:
+		return newForStatement(a, n)
+	case kind.FunctionDeclaration:
+		return newFunctionDeclaration(a, n)
+	case kind.FunctionType:
+		return newFunctionType(a, n)
+	case kind.GenericType:
+		return newGenericType(a, n)
+	case kind.Identifier:
+		return newIdentifier(a, n)
+	case kind.IfExpression:
+		return newIfExpression(a, n)
+	case kind.IfStatement:
+		return newIfStatement(a, n)
+	case kind.ImportDeclaration:
+		return newImportDeclaration(a, n)
+	case kind.IndexExpression:
+		return newIndexExpression(a, n)
+	case kind.IntegerLiteral:
+		return newIntegerLiteral(a, n)
+	case kind.LineComment:
+		return newLineComment(a, n)
+	case kind.MatchArm:
+		return newMatchArm(a, n)
+	case kind.MatchExpression:
+		return newMatchExpression(a, n)
+	case kind.MatchStatement:
+		return newMatchStatement(a, n)
+	case kind.MemberExpression:
+		return newMemberExpression(a, n)
+	case kind.OkExpression:
+		return newOkExpression(a, n)
+	case kind.PackageDeclaration:
+		return newPackageDeclaration(a, n)
+	case kind.PackagePath:
+		return newPackagePath(a, n)
+	case kind.Parameter:
+		return newParameter(a, n)
+	case kind.ParameterList:
+		return newParameterList(a, n)
+	case kind.ParenthesizedExpression:
+		return newParenthesizedExpression(a, n)
+	case kind.Pattern:
+		return newPattern(a, n)
+	case kind.PrimitiveType:
+		return newPrimitiveType(a, n)
+	case kind.RecordBody:
+		return newRecordBody(a, n)
+	case kind.RecordExpression:
+		return newRecordExpression(a, n)
+	case kind.RecordField:
+		return newRecordField(a, n)
+	case kind.RecordType:
+		return newRecordType(a, n)
+	case kind.ReturnStatement:
+		return newReturnStatement(a, n)
+	case kind.SourceFile:
+		return newSourceFile(a, n)
+	case kind.StringLiteral:
+		return newStringLiteral(a, n)
+	case kind.TypeDeclaration:
+		return newTypeDeclaration(a, n)
+	case kind.TypeIdentifier:
+		return newTypeIdentifier(a, n)
+	case kind.TypeParameter:
+		return newTypeParameter(a, n)
+	case kind.TypeParameters:
+		return newTypeParameters(a, n)
+	case kind.UnaryExpression:
+		return newUnaryExpression(a, n)
+	case kind.UnionType:
+		return newUnionType(a, n)
+	case kind.UnionVariant:
+		return newUnionVariant(a, n)
+	case kind.UseDeclaration:
+		return newUseDeclaration(a, n)
+	case kind.WhileStatement:
+		return newWhileStatement(a, n)
+	}
+	return newUnknown(a, n)
+}
+
+// slabs holds a slab per wrapper type.
+type slabs struct {
+	AnonymousFunction       slab[AnonymousFunction]
+	ArrayExpression         slab[ArrayExpression]
+	BinaryExpression        slab[BinaryExpression]
+	Block                   slab[Block]
+	BlockComment            slab[BlockComment]
+	BooleanLiteral          slab[BooleanLiteral]
+	CallExpression          slab[CallExpression]
+	CapabilityDeclaration   slab[CapabilityDeclaration]
+	CharLiteral             slab[CharLiteral]
+	CheckExpression         slab[CheckExpression]
+	ComponentDeclaration    slab[ComponentDeclaration]
+	ConstDeclaration        slab[ConstDeclaration]
+	DestructuringPattern    slab[DestructuringPattern]
+	DomainDeclaration       slab[DomainDeclaration]
+	EffectsClause           slab[EffectsClause]
+	ErrExpression           slab[ErrExpression]
+	ErrorClause             slab[ErrorClause]
+	ErrorDeclaration        slab[ErrorDeclaration]
+	ErrorVariant            slab[ErrorVariant]
+	EscapeSequence          slab[EscapeSequence]
+	ExpressionStatement     slab[ExpressionStatement]
+	FloatLiteral            slab[FloatLiteral]
+	ForStatement            slab[ForStatement]
+	FunctionDeclaration     slab[FunctionDeclaration]
+	FunctionType            slab[FunctionType]
+	GenericType             slab[GenericType]
+	Identifier              slab[Identifier]
+	IfExpression            slab[IfExpression]
+	IfStatement             slab[IfStatement]
+	ImportDeclaration       slab[ImportDeclaration]
+	IndexExpression         slab[IndexExpression]
+	IntegerLiteral          slab[IntegerLiteral]
+	LineComment             slab[LineComment]
+	MatchArm                slab[MatchArm]
+	MatchExpression         slab[MatchExpression]
+	MatchStatement          slab[MatchStatement]
+	MemberExpression        slab[MemberExpression]
+	OkExpression            slab[OkExpression]
+	PackageDeclaration      slab[PackageDeclaration]
+	PackagePath             slab[PackagePath]
+	Parameter               slab[Parameter]
+	ParameterList           slab[ParameterList]
+	ParenthesizedExpression slab[ParenthesizedExpression]
+	Pattern                 slab[Pattern]
+	PrimitiveType           slab[PrimitiveType]
+	RecordBody              slab[RecordBody]
+	RecordExpression        slab[RecordExpression]
+	RecordField             slab[RecordField]
+	RecordType              slab[RecordType]
+	ReturnStatement         slab[ReturnStatement]
+	SourceFile              slab[SourceFile]
+	StringLiteral           slab[StringLiteral]
+	TypeDeclaration         slab[TypeDeclaration]
+	TypeIdentifier          slab[TypeIdentifier]
+	TypeParameter           slab[TypeParameter]
+	TypeParameters          slab[TypeParameters]
+	UnaryExpression         slab[UnaryExpression]
+	UnionType               slab[UnionType]
+	UnionVariant            slab[UnionVariant]
+	UseDeclaration          slab[UseDeclaration]
+	WhileStatement          slab[WhileStatement]
+}
+
+func (s *slabs) reset() {
+	s.AnonymousFunction.reset()
+	s.ArrayExpression.reset()
+	s.BinaryExpression.reset()
+	s.Block.reset()
+	s.BlockComment.reset()
+	s.BooleanLiteral.reset()
+	s.CallExpression.reset()
+	s.CapabilityDeclaration.reset()
+	s.CharLiteral.reset()
+	s.CheckExpression.reset()
+	s.ComponentDeclaration.reset()
+	s.ConstDeclaration.reset()
+	s.DestructuringPattern.reset()
+	s.DomainDeclaration.reset()
+	s.EffectsClause.reset()
+	s.ErrExpression.reset()
+	s.ErrorClause.reset()
+	s.ErrorDeclaration.reset()
+	s.ErrorVariant.reset()
+	s.EscapeSequence.reset()
+	s.ExpressionStatement.reset()
+	s.FloatLiteral.reset()
+	s.ForStatement.reset()
+	s.FunctionDeclaration.reset()
+	s.FunctionType.reset()
+	s.GenericType.reset()
+	s.Identifier.reset()
+	s.IfExpression.reset()
+	s.IfStatement.reset()
+	s.ImportDeclaration.reset()
+	s.IndexExpression.reset()
+	s.IntegerLiteral.reset()
+	s.LineComment.reset()
+	s.MatchArm.reset()
+	s.MatchExpression.reset()
+	s.MatchStatement.reset()
+	s.MemberExpression.reset()
+	s.OkExpression.reset()
+	s.PackageDeclaration.reset()
+	s.PackagePath.reset()
+	s.Parameter.reset()
+	s.ParameterList.reset()
+	s.ParenthesizedExpression.reset()
+	s.Pattern.reset()
+	s.PrimitiveType.reset()
+	s.RecordBody.reset()
+	s.RecordExpression.reset()
+	s.RecordField.reset()
+	s.RecordType.reset()
+	s.ReturnStatement.reset()
+	s.SourceFile.reset()
+	s.StringLiteral.reset()
+	s.TypeDeclaration.reset()
+	s.TypeIdentifier.reset()
+	s.TypeParameter.reset()
+	s.TypeParameters.reset()
+	s.UnaryExpression.reset()
+	s.UnionType.reset()
+	s.UnionVariant.reset()
+	s.UseDeclaration.reset()
+	s.WhileStatement.reset()
+}
+
+// AnonymousFunction wraps a anonymous_function node.
+type AnonymousFunction struct{ node }
+
+func newAnonymousFunction(a *Arena, n *tree_sitter.Node) *AnonymousFunction {
+	if a == nil {
+		return &AnonymousFunction{node{n: n}}
+	}
+	w := a.slabs.AnonymousFunction.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// ArrayExpression wraps a array_expression node.
+type ArrayExpression struct{ node }
+
+func newArrayExpression(a *Arena, n *tree_sitter.Node) *ArrayExpression {
+	if a == nil {
+		return &ArrayExpression{node{n: n}}
+	}
+	w := a.slabs.ArrayExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// BinaryExpression wraps a binary_expression node.
+type BinaryExpression struct{ node }
+
+func newBinaryExpression(a *Arena, n *tree_sitter.Node) *BinaryExpression {
+	if a == nil {
+		return &BinaryExpression{node{n: n}}
+	}
+	w := a.slabs.BinaryExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Block wraps a block node.
+type Block struct{ node }
+
+func newBlock(a *Arena, n *tree_sitter.Node) *Block {
+	if a == nil {
+		return &Block{node{n: n}}
+	}
+	w := a.slabs.Block.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// BlockComment wraps a block_comment node.
+type BlockComment struct{ node }
+
+func newBlockComment(a *Arena, n *tree_sitter.Node) *BlockComment {
+	if a == nil {
+		return &BlockComment{node{n: n}}
+	}
+	w := a.slabs.BlockComment.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// BooleanLiteral wraps a boolean_literal node.
+type BooleanLiteral struct{ node }
+
+func newBooleanLiteral(a *Arena, n *tree_sitter.Node) *BooleanLiteral {
+	if a == nil {
+		return &BooleanLiteral{node{n: n}}
+	}
+	w := a.slabs.BooleanLiteral.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// CallExpression wraps a call_expression node.
+type CallExpression struct{ node }
+
+func newCallExpression(a *Arena, n *tree_sitter.Node) *CallExpression {
+	if a == nil {
+		return &CallExpression{node{n: n}}
+	}
+	w := a.slabs.CallExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// CapabilityDeclaration wraps a capability_declaration node.
+type CapabilityDeclaration struct{ node }
+
+func newCapabilityDeclaration(a *Arena, n *tree_sitter.Node) *CapabilityDeclaration {
+	if a == nil {
+		return &CapabilityDeclaration{node{n: n}}
+	}
+	w := a.slabs.CapabilityDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *CapabilityDeclaration) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// CharLiteral wraps a char_literal node.
+type CharLiteral struct{ node }
+
+func newCharLiteral(a *Arena, n *tree_sitter.Node) *CharLiteral {
+	if a == nil {
+		return &CharLiteral{node{n: n}}
+	}
+	w := a.slabs.CharLiteral.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// EscapeSequence returns the escape_sequence child, or nil when it is absent.
+func (n *CharLiteral) EscapeSequence() *EscapeSequence {
+	cs := n.namedChildrenOfKind(kind.EscapeSequence)
+	if len(cs) == 0 {
+		return nil
+	}
+	return newEscapeSequence(n.a, cs[0])
+}
+
+// CheckExpression wraps a check_expression node.
+type CheckExpression struct{ node }
+
+func newCheckExpression(a *Arena, n *tree_sitter.Node) *CheckExpression {
+	if a == nil {
+		return &CheckExpression{node{n: n}}
+	}
+	w := a.slabs.CheckExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// ComponentDeclaration wraps a component_declaration node.
+type ComponentDeclaration struct{ node }
+
+func newComponentDeclaration(a *Arena, n *tree_sitter.Node) *ComponentDeclaration {
+	if a == nil {
+		return &ComponentDeclaration{node{n: n}}
+	}
+	w := a.slabs.ComponentDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *ComponentDeclaration) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// FunctionDeclarations returns the function_declaration children.
+func (n *ComponentDeclaration) FunctionDeclarations() []*FunctionDeclaration {
+	var out []*FunctionDeclaration
+	for _, c := range n.namedChildrenOfKind(kind.FunctionDeclaration) {
+		out = append(out, newFunctionDeclaration(n.a, c))
+	}
+	return out
+}
+
+// TypeDeclarations returns the type_declaration children.
+func (n *ComponentDeclaration) TypeDeclarations() []*TypeDeclaration {
+	var out []*TypeDeclaration
+	for _, c := range n.namedChildrenOfKind(kind.TypeDeclaration) {
+		out = append(out, newTypeDeclaration(n.a, c))
+	}
+	return out
+}
+
+// ConstDeclaration wraps a const_declaration node.
+type ConstDeclaration struct{ node }
+
+func newConstDeclaration(a *Arena, n *tree_sitter.Node) *ConstDeclaration {
+	if a == nil {
+		return &ConstDeclaration{node{n: n}}
+	}
+	w := a.slabs.ConstDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *ConstDeclaration) Name() *Identifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newIdentifier(n.a, c)
+}
+
+// Value returns the "value" field, or nil when it is absent.
+func (n *ConstDeclaration) Value() Node {
+	c := n.n.ChildByFieldName(field.Value)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// DestructuringPattern wraps a destructuring_pattern node.
+type DestructuringPattern struct{ node }
+
+func newDestructuringPattern(a *Arena, n *tree_sitter.Node) *DestructuringPattern {
+	if a == nil {
+		return &DestructuringPattern{node{n: n}}
+	}
+	w := a.slabs.DestructuringPattern.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Identifiers returns the identifier children.
+func (n *DestructuringPattern) Identifiers() []*Identifier {
+	var out []*Identifier
+	for _, c := range n.namedChildrenOfKind(kind.Identifier) {
+		out = append(out, newIdentifier(n.a, c))
+	}
+	return out
+}
+
+// TypeIdentifiers returns the type_identifier children.
+func (n *DestructuringPattern) TypeIdentifiers() []*TypeIdentifier {
+	var out []*TypeIdentifier
+	for _, c := range n.namedChildrenOfKind(kind.TypeIdentifier) {
+		out = append(out, newTypeIdentifier(n.a, c))
+	}
+	return out
+}
+
+// DomainDeclaration wraps a domain_declaration node.
+type DomainDeclaration struct{ node }
+
+func newDomainDeclaration(a *Arena, n *tree_sitter.Node) *DomainDeclaration {
+	if a == nil {
+		return &DomainDeclaration{node{n: n}}
+	}
+	w := a.slabs.DomainDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *DomainDeclaration) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// ErrorVariants returns the error_variant children.
+func (n *DomainDeclaration) ErrorVariants() []*ErrorVariant {
+	var out []*ErrorVariant
+	for _, c := range n.namedChildrenOfKind(kind.ErrorVariant) {
+		out = append(out, newErrorVariant(n.a, c))
+	}
+	return out
+}
+
+// TypeIdentifiers returns the type_identifier children.
+func (n *DomainDeclaration) TypeIdentifiers() []*TypeIdentifier {
+	var out []*TypeIdentifier
+	for _, c := range n.namedChildrenOfKind(kind.TypeIdentifier) {
+		out = append(out, newTypeIdentifier(n.a, c))
+	}
+	return out
+}
+
+// EffectsClause wraps a effects_clause node.
+type EffectsClause struct{ node }
+
+func newEffectsClause(a *Arena, n *tree_sitter.Node) *EffectsClause {
+	if a == nil {
+		return &EffectsClause{node{n: n}}
+	}
+	w := a.slabs.EffectsClause.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Identifiers returns the identifier children.
+func (n *EffectsClause) Identifiers() []*Identifier {
+	var out []*Identifier
+	for _, c := range n.namedChildrenOfKind(kind.Identifier) {
+		out = append(out, newIdentifier(n.a, c))
+	}
+	return out
+}
+
+// ErrExpression wraps a err_expression node.
+type ErrExpression struct{ node }
+
+func newErrExpression(a *Arena, n *tree_sitter.Node) *ErrExpression {
+	if a == nil {
+		return &ErrExpression{node{n: n}}
+	}
+	w := a.slabs.ErrExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordExpressions returns the record_expression children.
+func (n *ErrExpression) RecordExpressions() []*RecordExpression {
+	var out []*RecordExpression
+	for _, c := range n.namedChildrenOfKind(kind.RecordExpression) {
+		out = append(out, newRecordExpression(n.a, c))
+	}
+	return out
+}
+
+// TypeIdentifiers returns the type_identifier children.
+func (n *ErrExpression) TypeIdentifiers() []*TypeIdentifier {
+	var out []*TypeIdentifier
+	for _, c := range n.namedChildrenOfKind(kind.TypeIdentifier) {
+		out = append(out, newTypeIdentifier(n.a, c))
+	}
+	return out
+}
+
+// ErrorClause wraps a error_clause node.
+type ErrorClause struct{ node }
+
+func newErrorClause(a *Arena, n *tree_sitter.Node) *ErrorClause {
+	if a == nil {
+		return &ErrorClause{node{n: n}}
+	}
+	w := a.slabs.ErrorClause.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// TypeIdentifier returns the type_identifier child, or nil when it is absent.
+func (n *ErrorClause) TypeIdentifier() *TypeIdentifier {
+	cs := n.namedChildrenOfKind(kind.TypeIdentifier)
+	if len(cs) == 0 {
+		return nil
+	}
+	return newTypeIdentifier(n.a, cs[0])
+}
+
+// ErrorDeclaration wraps a error_declaration node.
+type ErrorDeclaration struct{ node }
+
+func newErrorDeclaration(a *Arena, n *tree_sitter.Node) *ErrorDeclaration {
+	if a == nil {
+		return &ErrorDeclaration{node{n: n}}
+	}
+	w := a.slabs.ErrorDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *ErrorDeclaration) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// RecordBody returns the record_body child, or nil when it is absent.
+func (n *ErrorDeclaration) RecordBody() *RecordBody {
+	cs := n.namedChildrenOfKind(kind.RecordBody)
+	if len(cs) == 0 {
+		return nil
+	}
+	return newRecordBody(n.a, cs[0])
+}
+
+// ErrorVariant wraps a error_variant node.
+type ErrorVariant struct{ node }
+
+func newErrorVariant(a *Arena, n *tree_sitter.Node) *ErrorVariant {
+	if a == nil {
+		return &ErrorVariant{node{n: n}}
+	}
+	w := a.slabs.ErrorVariant.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordBodies returns the record_body children.
+func (n *ErrorVariant) RecordBodies() []*RecordBody {
+	var out []*RecordBody
+	for _, c := range n.namedChildrenOfKind(kind.RecordBody) {
+		out = append(out, newRecordBody(n.a, c))
+	}
+	return out
+}
+
+// TypeIdentifiers returns the type_identifier children.
+func (n *ErrorVariant) TypeIdentifiers() []*TypeIdentifier {
+	var out []*TypeIdentifier
+	for _, c := range n.namedChildrenOfKind(kind.TypeIdentifier) {
+		out = append(out, newTypeIdentifier(n.a, c))
+	}
+	return out
+}
+
+// EscapeSequence wraps a escape_sequence node.
+type EscapeSequence struct{ node }
+
+func newEscapeSequence(a *Arena, n *tree_sitter.Node) *EscapeSequence {
+	if a == nil {
+		return &EscapeSequence{node{n: n}}
+	}
+	w := a.slabs.EscapeSequence.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// ExpressionStatement wraps a expression_statement node.
+type ExpressionStatement struct{ node }
+
+func newExpressionStatement(a *Arena, n *tree_sitter.Node) *ExpressionStatement {
+	if a == nil {
+		return &ExpressionStatement{node{n: n}}
+	}
+	w := a.slabs.ExpressionStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// FloatLiteral wraps a float_literal node.
+type FloatLiteral struct{ node }
+
+func newFloatLiteral(a *Arena, n *tree_sitter.Node) *FloatLiteral {
+	if a == nil {
+		return &FloatLiteral{node{n: n}}
+	}
+	w := a.slabs.FloatLiteral.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// ForStatement wraps a for_statement node.
+type ForStatement struct{ node }
+
+func newForStatement(a *Arena, n *tree_sitter.Node) *ForStatement {
+	if a == nil {
+		return &ForStatement{node{n: n}}
+	}
+	w := a.slabs.ForStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// FunctionDeclaration wraps a function_declaration node.
+type FunctionDeclaration struct{ node }
+
+func newFunctionDeclaration(a *Arena, n *tree_sitter.Node) *FunctionDeclaration {
+	if a == nil {
+		return &FunctionDeclaration{node{n: n}}
+	}
+	w := a.slabs.FunctionDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Body returns the "body" field, or nil when it is absent.
+func (n *FunctionDeclaration) Body() *Block {
+	c := n.n.ChildByFieldName(field.Body)
+	if c == nil {
+		return nil
+	}
+	return newBlock(n.a, c)
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *FunctionDeclaration) Name() *Identifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newIdentifier(n.a, c)
+}
+
+// Parameters returns the "parameters" field, or nil when it is absent.
+func (n *FunctionDeclaration) Parameters() *ParameterList {
+	c := n.n.ChildByFieldName(field.Parameters)
+	if c == nil {
+		return nil
+	}
+	return newParameterList(n.a, c)
+}
+
+// ReturnType returns the "return_type" field, or nil when it is absent.
+func (n *FunctionDeclaration) ReturnType() Node {
+	c := n.n.ChildByFieldName(field.ReturnType)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// FunctionType wraps a function_type node.
+type FunctionType struct{ node }
+
+func newFunctionType(a *Arena, n *tree_sitter.Node) *FunctionType {
+	if a == nil {
+		return &FunctionType{node{n: n}}
+	}
+	w := a.slabs.FunctionType.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// GenericType wraps a generic_type node.
+type GenericType struct{ node }
+
+func newGenericType(a *Arena, n *tree_sitter.Node) *GenericType {
+	if a == nil {
+		return &GenericType{node{n: n}}
+	}
+	w := a.slabs.GenericType.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Identifier wraps a identifier node.
+type Identifier struct{ node }
+
+func newIdentifier(a *Arena, n *tree_sitter.Node) *Identifier {
+	if a == nil {
+		return &Identifier{node{n: n}}
+	}
+	w := a.slabs.Identifier.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// IfExpression wraps a if_expression node.
+type IfExpression struct{ node }
+
+func newIfExpression(a *Arena, n *tree_sitter.Node) *IfExpression {
+	if a == nil {
+		return &IfExpression{node{n: n}}
+	}
+	w := a.slabs.IfExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// IfStatement wraps a if_statement node.
+type IfStatement struct{ node }
+
+func newIfStatement(a *Arena, n *tree_sitter.Node) *IfStatement {
+	if a == nil {
+		return &IfStatement{node{n: n}}
+	}
+	w := a.slabs.IfStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Alternative returns the "alternative" field, or nil when it is absent.
+func (n *IfStatement) Alternative() Node {
+	c := n.n.ChildByFieldName(field.Alternative)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// Condition returns the "condition" field, or nil when it is absent.
+func (n *IfStatement) Condition() Node {
+	c := n.n.ChildByFieldName(field.Condition)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// Consequence returns the "consequence" field, or nil when it is absent.
+func (n *IfStatement) Consequence() *Block {
+	c := n.n.ChildByFieldName(field.Consequence)
+	if c == nil {
+		return nil
+	}
+	return newBlock(n.a, c)
+}
+
+// ImportDeclaration wraps a import_declaration node.
+type ImportDeclaration struct{ node }
+
+func newImportDeclaration(a *Arena, n *tree_sitter.Node) *ImportDeclaration {
+	if a == nil {
+		return &ImportDeclaration{node{n: n}}
+	}
+	w := a.slabs.ImportDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Path returns the "path" field, or nil when it is absent.
+func (n *ImportDeclaration) Path() *PackagePath {
+	c := n.n.ChildByFieldName(field.Path)
+	if c == nil {
+		return nil
+	}
+	return newPackagePath(n.a, c)
+}
+
+// Identifier returns the identifier child, or nil when it is absent.
+func (n *ImportDeclaration) Identifier() *Identifier {
+	cs := n.namedChildrenOfKind(kind.Identifier)
+	if len(cs) == 0 {
+		return nil
+	}
+	return newIdentifier(n.a, cs[0])
+}
+
+// IndexExpression wraps a index_expression node.
+type IndexExpression struct{ node }
+
+func newIndexExpression(a *Arena, n *tree_sitter.Node) *IndexExpression {
+	if a == nil {
+		return &IndexExpression{node{n: n}}
+	}
+	w := a.slabs.IndexExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// IntegerLiteral wraps a integer_literal node.
+type IntegerLiteral struct{ node }
+
+func newIntegerLiteral(a *Arena, n *tree_sitter.Node) *IntegerLiteral {
+	if a == nil {
+		return &IntegerLiteral{node{n: n}}
+	}
+	w := a.slabs.IntegerLiteral.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// LineComment wraps a line_comment node.
+type LineComment struct{ node }
+
+func newLineComment(a *Arena, n *tree_sitter.Node) *LineComment {
+	if a == nil {
+		return &LineComment{node{n: n}}
+	}
+	w := a.slabs.LineComment.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// MatchArm wraps a match_arm node.
+type MatchArm struct{ node }
+
+func newMatchArm(a *Arena, n *tree_sitter.Node) *MatchArm {
+	if a == nil {
+		return &MatchArm{node{n: n}}
+	}
+	w := a.slabs.MatchArm.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// MatchExpression wraps a match_expression node.
+type MatchExpression struct{ node }
+
+func newMatchExpression(a *Arena, n *tree_sitter.Node) *MatchExpression {
+	if a == nil {
+		return &MatchExpression{node{n: n}}
+	}
+	w := a.slabs.MatchExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// MatchStatement wraps a match_statement node.
+type MatchStatement struct{ node }
+
+func newMatchStatement(a *Arena, n *tree_sitter.Node) *MatchStatement {
+	if a == nil {
+		return &MatchStatement{node{n: n}}
+	}
+	w := a.slabs.MatchStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// MemberExpression wraps a member_expression node.
+type MemberExpression struct{ node }
+
+func newMemberExpression(a *Arena, n *tree_sitter.Node) *MemberExpression {
+	if a == nil {
+		return &MemberExpression{node{n: n}}
+	}
+	w := a.slabs.MemberExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// OkExpression wraps a ok_expression node.
+type OkExpression struct{ node }
+
+func newOkExpression(a *Arena, n *tree_sitter.Node) *OkExpression {
+	if a == nil {
+		return &OkExpression{node{n: n}}
+	}
+	w := a.slabs.OkExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// PackageDeclaration wraps a package_declaration node.
+type PackageDeclaration struct{ node }
+
+func newPackageDeclaration(a *Arena, n *tree_sitter.Node) *PackageDeclaration {
+	if a == nil {
+		return &PackageDeclaration{node{n: n}}
+	}
+	w := a.slabs.PackageDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Path returns the "path" field, or nil when it is absent.
+func (n *PackageDeclaration) Path() *PackagePath {
+	c := n.n.ChildByFieldName(field.Path)
+	if c == nil {
+		return nil
+	}
+	return newPackagePath(n.a, c)
+}
+
+// PackagePath wraps a package_path node.
+type PackagePath struct{ node }
+
+func newPackagePath(a *Arena, n *tree_sitter.Node) *PackagePath {
+	if a == nil {
+		return &PackagePath{node{n: n}}
+	}
+	w := a.slabs.PackagePath.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Identifiers returns the identifier children.
+func (n *PackagePath) Identifiers() []*Identifier {
+	var out []*Identifier
+	for _, c := range n.namedChildrenOfKind(kind.Identifier) {
+		out = append(out, newIdentifier(n.a, c))
+	}
+	return out
+}
+
+// Parameter wraps a parameter node.
+type Parameter struct{ node }
+
+func newParameter(a *Arena, n *tree_sitter.Node) *Parameter {
+	if a == nil {
+		return &Parameter{node{n: n}}
+	}
+	w := a.slabs.Parameter.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *Parameter) Name() *Identifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newIdentifier(n.a, c)
+}
+
+// Type returns the "type" field, or nil when it is absent.
+func (n *Parameter) Type() Node {
+	c := n.n.ChildByFieldName(field.Type)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// ParameterList wraps a parameter_list node.
+type ParameterList struct{ node }
+
+func newParameterList(a *Arena, n *tree_sitter.Node) *ParameterList {
+	if a == nil {
+		return &ParameterList{node{n: n}}
+	}
+	w := a.slabs.ParameterList.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Parameters returns the parameter children.
+func (n *ParameterList) Parameters() []*Parameter {
+	var out []*Parameter
+	for _, c := range n.namedChildrenOfKind(kind.Parameter) {
+		out = append(out, newParameter(n.a, c))
+	}
+	return out
+}
+
+// ParenthesizedExpression wraps a parenthesized_expression node.
+type ParenthesizedExpression struct{ node }
+
+func newParenthesizedExpression(a *Arena, n *tree_sitter.Node) *ParenthesizedExpression {
+	if a == nil {
+		return &ParenthesizedExpression{node{n: n}}
+	}
+	w := a.slabs.ParenthesizedExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Pattern wraps a pattern node.
+type Pattern struct{ node }
+
+func newPattern(a *Arena, n *tree_sitter.Node) *Pattern {
+	if a == nil {
+		return &Pattern{node{n: n}}
+	}
+	w := a.slabs.Pattern.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// PrimitiveType wraps a primitive_type node.
+type PrimitiveType struct{ node }
+
+func newPrimitiveType(a *Arena, n *tree_sitter.Node) *PrimitiveType {
+	if a == nil {
+		return &PrimitiveType{node{n: n}}
+	}
+	w := a.slabs.PrimitiveType.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordBody wraps a record_body node.
+type RecordBody struct{ node }
+
+func newRecordBody(a *Arena, n *tree_sitter.Node) *RecordBody {
+	if a == nil {
+		return &RecordBody{node{n: n}}
+	}
+	w := a.slabs.RecordBody.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordFields returns the record_field children.
+func (n *RecordBody) RecordFields() []*RecordField {
+	var out []*RecordField
+	for _, c := range n.namedChildrenOfKind(kind.RecordField) {
+		out = append(out, newRecordField(n.a, c))
+	}
+	return out
+}
+
+// RecordExpression wraps a record_expression node.
+type RecordExpression struct{ node }
+
+func newRecordExpression(a *Arena, n *tree_sitter.Node) *RecordExpression {
+	if a == nil {
+		return &RecordExpression{node{n: n}}
+	}
+	w := a.slabs.RecordExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordField wraps a record_field node.
+type RecordField struct{ node }
+
+func newRecordField(a *Arena, n *tree_sitter.Node) *RecordField {
+	if a == nil {
+		return &RecordField{node{n: n}}
+	}
+	w := a.slabs.RecordField.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordType wraps a record_type node.
+type RecordType struct{ node }
+
+func newRecordType(a *Arena, n *tree_sitter.Node) *RecordType {
+	if a == nil {
+		return &RecordType{node{n: n}}
+	}
+	w := a.slabs.RecordType.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordFields returns the record_field children.
+func (n *RecordType) RecordFields() []*RecordField {
+	var out []*RecordField
+	for _, c := range n.namedChildrenOfKind(kind.RecordField) {
+		out = append(out, newRecordField(n.a, c))
+	}
+	return out
+}
+
+// ReturnStatement wraps a return_statement node.
+type ReturnStatement struct{ node }
+
+func newReturnStatement(a *Arena, n *tree_sitter.Node) *ReturnStatement {
+	if a == nil {
+		return &ReturnStatement{node{n: n}}
+	}
+	w := a.slabs.ReturnStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// SourceFile wraps a source_file node.
+type SourceFile struct{ node }
+
+func newSourceFile(a *Arena, n *tree_sitter.Node) *SourceFile {
+	if a == nil {
+		return &SourceFile{node{n: n}}
+	}
+	w := a.slabs.SourceFile.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// StringLiteral wraps a string_literal node.
+type StringLiteral struct{ node }
+
+func newStringLiteral(a *Arena, n *tree_sitter.Node) *StringLiteral {
+	if a == nil {
+		return &StringLiteral{node{n: n}}
+	}
+	w := a.slabs.StringLiteral.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// EscapeSequences returns the escape_sequence children.
+func (n *StringLiteral) EscapeSequences() []*EscapeSequence {
+	var out []*EscapeSequence
+	for _, c := range n.namedChildrenOfKind(kind.EscapeSequence) {
+		out = append(out, newEscapeSequence(n.a, c))
+	}
+	return out
+}
+
+// TypeDeclaration wraps a type_declaration node.
+type TypeDeclaration struct{ node }
+
+func newTypeDeclaration(a *Arena, n *tree_sitter.Node) *TypeDeclaration {
+	if a == nil {
+		return &TypeDeclaration{node{n: n}}
+	}
+	w := a.slabs.TypeDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *TypeDeclaration) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// Type returns the "type" field, or nil when it is absent.
+func (n *TypeDeclaration) Type() Node {
+	c := n.n.ChildByFieldName(field.Type)
+	if c == nil {
+		return nil
+	}
+	return n.wrap(c)
+}
+
+// TypeIdentifier wraps a type_identifier node.
+type TypeIdentifier struct{ node }
+
+func newTypeIdentifier(a *Arena, n *tree_sitter.Node) *TypeIdentifier {
+	if a == nil {
+		return &TypeIdentifier{node{n: n}}
+	}
+	w := a.slabs.TypeIdentifier.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// TypeParameter wraps a type_parameter node.
+type TypeParameter struct{ node }
+
+func newTypeParameter(a *Arena, n *tree_sitter.Node) *TypeParameter {
+	if a == nil {
+		return &TypeParameter{node{n: n}}
+	}
+	w := a.slabs.TypeParameter.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// Name returns the "name" field, or nil when it is absent.
+func (n *TypeParameter) Name() *TypeIdentifier {
+	c := n.n.ChildByFieldName(field.Name)
+	if c == nil {
+		return nil
+	}
+	return newTypeIdentifier(n.a, c)
+}
+
+// TypeParameters wraps a type_parameters node.
+type TypeParameters struct{ node }
+
+func newTypeParameters(a *Arena, n *tree_sitter.Node) *TypeParameters {
+	if a == nil {
+		return &TypeParameters{node{n: n}}
+	}
+	w := a.slabs.TypeParameters.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// UnaryExpression wraps a unary_expression node.
+type UnaryExpression struct{ node }
+
+func newUnaryExpression(a *Arena, n *tree_sitter.Node) *UnaryExpression {
+	if a == nil {
+		return &UnaryExpression{node{n: n}}
+	}
+	w := a.slabs.UnaryExpression.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// UnionType wraps a union_type node.
+type UnionType struct{ node }
+
+func newUnionType(a *Arena, n *tree_sitter.Node) *UnionType {
+	if a == nil {
+		return &UnionType{node{n: n}}
+	}
+	w := a.slabs.UnionType.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// UnionVariants returns the union_variant children.
+func (n *UnionType) UnionVariants() []*UnionVariant {
+	var out []*UnionVariant
+	for _, c := range n.namedChildrenOfKind(kind.UnionVariant) {
+		out = append(out, newUnionVariant(n.a, c))
+	}
+	return out
+}
+
+// UnionVariant wraps a union_variant node.
+type UnionVariant struct{ node }
+
+func newUnionVariant(a *Arena, n *tree_sitter.Node) *UnionVariant {
+	if a == nil {
+		return &UnionVariant{node{n: n}}
+	}
+	w := a.slabs.UnionVariant.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// RecordBodies returns the record_body children.
+func (n *UnionVariant) RecordBodies() []*RecordBody {
+	var out []*RecordBody
+	for _, c := range n.namedChildrenOfKind(kind.RecordBody) {
+		out = append(out, newRecordBody(n.a, c))
+	}
+	return out
+}
+
+// TypeIdentifiers returns the type_identifier children.
+func (n *UnionVariant) TypeIdentifiers() []*TypeIdentifier {
+	var out []*TypeIdentifier
+	for _, c := range n.namedChildrenOfKind(kind.TypeIdentifier) {
+		out = append(out, newTypeIdentifier(n.a, c))
+	}
+	return out
+}
+
+// UseDeclaration wraps a use_declaration node.
+type UseDeclaration struct{ node }
+
+func newUseDeclaration(a *Arena, n *tree_sitter.Node) *UseDeclaration {
+	if a == nil {
+		return &UseDeclaration{node{n: n}}
+	}
+	w := a.slabs.UseDeclaration.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
+
+// TypeIdentifier returns the type_identifier child, or nil when it is absent.
+func (n *UseDeclaration) TypeIdentifier() *TypeIdentifier {
+	cs := n.namedChildrenOfKind(kind.TypeIdentifier)
+	if len(cs) == 0 {
+		return nil
+	}
+	return newTypeIdentifier(n.a, cs[0])
+}
+
+// WhileStatement wraps a while_statement node.
+type WhileStatement struct{ node }
+
+func newWhileStatement(a *Arena, n *tree_sitter.Node) *WhileStatement {
+	if a == nil {
+		return &WhileStatement{node{n: n}}
+	}
+	w := a.slabs.WhileStatement.alloc()
+	w.node = node{n: n, a: a}
+	return w
+}
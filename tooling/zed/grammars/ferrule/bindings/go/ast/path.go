@@ -0,0 +1,144 @@
+package ast
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Crumb is a node of a Path and its display label, such as "fn main",
+// "component Store" or "match".
+type Crumb struct {
+	Node  Node
+	Label string
+}
+
+// Path is the chain of named nodes enclosing a position, from the root to
+// the smallest.
+type Path []Crumb
+
+// PathAt returns the path to the smallest named node of tree at p, parsed
+// from src.
+func PathAt(tree *tree_sitter.Tree, src []byte, p tree_sitter.Point) Path {
+	var out Path
+	for n := tree.RootNode().NamedDescendantForPointRange(p, p); n != nil; n = n.Parent() {
+		w := Wrap(n)
+		out = append(out, Crumb{Node: w, Label: label(w, src)})
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Breadcrumbs returns the crumbs of the nodes an editor shows as
+// breadcrumbs: the file, declarations, functions, and the matches,
+// match arms, conditionals and loops of their bodies.
+func (p Path) Breadcrumbs() Path {
+	var out Path
+	for _, c := range p {
+		if landmark(c.Node) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// String joins the labels of the breadcrumbs of p with " > ", as in
+// "package app > fn main > match".
+func (p Path) String() string {
+	var labels []string
+	for _, c := range p.Breadcrumbs() {
+		labels = append(labels, c.Label)
+	}
+	return strings.Join(labels, " > ")
+}
+
+// Innermost returns the innermost node of p of type T, or the zero T.
+func Innermost[T Node](p Path) T {
+	for i := len(p) - 1; i >= 0; i-- {
+		if n, ok := p[i].Node.(T); ok {
+			return n
+		}
+	}
+	var zero T
+	return zero
+}
+
+func landmark(n Node) bool {
+	switch n.Kind() {
+	case kind.SourceFile, kind.FunctionDeclaration, kind.AnonymousFunction, kind.ComponentDeclaration,
+		kind.CapabilityDeclaration, kind.DomainDeclaration, kind.TypeDeclaration, kind.ErrorDeclaration,
+		kind.ConstDeclaration, kind.MatchStatement, kind.MatchExpression, kind.MatchArm,
+		kind.IfStatement, kind.IfExpression, kind.ForStatement, kind.WhileStatement:
+		return true
+	}
+	return false
+}
+
+// maxArmLabel is the length beyond which the pattern of a match arm is cut
+// short in its label.
+const maxArmLabel = 24
+
+func label(n Node, src []byte) string {
+	switch n := n.(type) {
+	case *SourceFile:
+		if d := n.PackageDeclaration(); d != nil && d.Path() != nil {
+			return "package " + d.Path().Text(src)
+		}
+		return "file"
+	case *FunctionDeclaration:
+		return "fn" + ident(n.Name(), src)
+	case *AnonymousFunction:
+		return "fn"
+	case *ComponentDeclaration:
+		return "component" + typeIdent(n.Name(), src)
+	case *CapabilityDeclaration:
+		return "capability" + typeIdent(n.Name(), src)
+	case *DomainDeclaration:
+		return "domain" + typeIdent(n.Name(), src)
+	case *TypeDeclaration:
+		return "type" + typeIdent(n.Name(), src)
+	case *ErrorDeclaration:
+		return "error" + typeIdent(n.Name(), src)
+	case *ConstDeclaration:
+		if n.IsVar() {
+			return "var" + ident(n.Name(), src)
+		}
+		return "const" + ident(n.Name(), src)
+	case *MatchStatement, *MatchExpression:
+		return "match"
+	case *MatchArm:
+		if pat := n.Pattern(); pat != nil {
+			text := []rune(strings.Join(strings.Fields(pat.Text(src)), " "))
+			if len(text) > maxArmLabel {
+				return string(text[:maxArmLabel]) + "…"
+			}
+			return string(text)
+		}
+		return "arm"
+	case *IfStatement, *IfExpression:
+		return "if"
+	case *ForStatement:
+		return "for"
+	case *WhileStatement:
+		return "while"
+	}
+	return strings.ReplaceAll(n.Kind(), "_", " ")
+}
+
+func ident(n *Identifier, src []byte) string {
+	if n == nil {
+		return ""
+	}
+	return " " + n.Text(src)
+}
+
+func typeIdent(n *TypeIdentifier, src []byte) string {
+	if n == nil {
+		return ""
+	}
+	return " " + n.Text(src)
+}
@@ -0,0 +1,277 @@
+package ast
+
+import (
+	"iter"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Synthetic is a node held in memory rather than in a tree-sitter tree, so
+// that it can be changed. Synthetic nodes are built from scratch, or copied
+// from a parse tree with Modify, and turned back into source text by
+// package printer.
+//
+// A leaf holds its text in Value; other nodes consist of parts, the
+// children in order. Copies of parsed nodes remember the text between
+// their parts, comments included, so they print as they were read;
+// nodes built from scratch are laid out by the printer.
+type Synthetic struct {
+	kind  string
+	named bool
+	// Value is the text of a leaf.
+	Value string
+	// Comments are printed before the node, each on a line of its own
+	// unless it is a block comment.
+	Comments []string
+
+	parts []Part
+	// parsed is set on copies of parsed nodes, whose gaps are verbatim.
+	parsed bool
+	// tail is the source text after the last part of a copied root.
+	tail string
+}
+
+// Part is a child of a synthetic node.
+type Part struct {
+	// Field is the grammar field of the child, if any.
+	Field string
+	Node  Node
+	// gap is the source text before the child in a parsed node.
+	gap string
+}
+
+// Field returns a part holding n as the field name.
+func Field(name string, n Node) Part { return Part{Field: name, Node: n} }
+
+// Child returns a part holding n outside of any field.
+func Child(n Node) Part { return Part{Node: n} }
+
+// Gap returns the source text before the part, for parts of nodes copied
+// from a parse tree.
+func (p Part) Gap() string { return p.gap }
+
+// NewNode returns a named node of the given kind made of parts.
+func NewNode(kind string, parts ...Part) *Synthetic {
+	return &Synthetic{kind: kind, named: true, parts: parts}
+}
+
+// NewLeaf returns a named node of the given kind without children, such
+// as an identifier or a literal.
+func NewLeaf(kind, text string) *Synthetic {
+	return &Synthetic{kind: kind, named: true, Value: text}
+}
+
+// NewToken returns an anonymous token such as a keyword or punctuation.
+func NewToken(text string) *Synthetic {
+	return &Synthetic{kind: text, Value: text}
+}
+
+// Modify returns a synthetic copy of the parsed node n, whose source is
+// src. The copy prints as the original text until it is changed. Nodes the
+// parser inserted during error recovery are left out.
+func Modify(n Node, src []byte) *Synthetic {
+	if s, ok := n.(*Synthetic); ok {
+		return s
+	}
+	raw := n.Raw()
+	if raw.Parent() != nil {
+		s, _ := modify(raw, src, raw.StartByte())
+		return s
+	}
+	// The root may not span the space around the contents of the file.
+	s, end := modify(raw, src, 0)
+	s.tail = string(src[end:])
+	return s
+}
+
+// modify copies raw, taking the text from offset start up to the first
+// child as the gap of that child. It returns the copy and the end of the
+// last child copied.
+func modify(raw *tree_sitter.Node, src []byte, start uint) (*Synthetic, uint) {
+	s := &Synthetic{kind: raw.Kind(), named: raw.IsNamed(), parsed: true}
+	if raw.ChildCount() == 0 {
+		s.Value = raw.Utf8Text(src)
+		return s, raw.EndByte()
+	}
+	prev := start
+	for i := uint(0); i < raw.ChildCount(); i++ {
+		c := raw.Child(i)
+		if c.IsMissing() {
+			continue
+		}
+		child, _ := modify(c, src, c.StartByte())
+		s.parts = append(s.parts, Part{
+			Field: raw.FieldNameForChild(uint32(i)),
+			Node:  child,
+			gap:   string(src[prev:c.StartByte()]),
+		})
+		prev = c.EndByte()
+	}
+	return s, prev
+}
+
+// Tail returns the source text after the last part, which only the copy
+// of a root node has: the white space and comments ending the file.
+func (s *Synthetic) Tail() string { return s.tail }
+
+// Raw returns nil: synthetic nodes have no tree-sitter node.
+func (s *Synthetic) Raw() *tree_sitter.Node { return nil }
+
+// Kind returns the grammar kind of the node, or the text of a token.
+func (s *Synthetic) Kind() string { return s.kind }
+
+// Named reports whether the node is named rather than a token.
+func (s *Synthetic) Named() bool { return s.named }
+
+// Parsed reports whether s was copied from a parse tree.
+func (s *Synthetic) Parsed() bool { return s.parsed }
+
+// Text returns the text of the node, with single spaces between parts
+// whose layout is not known. The argument is ignored. Use package printer
+// for properly laid out text.
+func (s *Synthetic) Text([]byte) string {
+	if len(s.parts) == 0 {
+		return s.Value
+	}
+	var b strings.Builder
+	for i, p := range s.parts {
+		switch {
+		case s.parsed:
+			b.WriteString(p.gap)
+		case i > 0:
+			b.WriteByte(' ')
+		}
+		b.WriteString(p.Node.Text(nil))
+	}
+	b.WriteString(s.tail)
+	return b.String()
+}
+
+// Parts returns the parts of the node. The slice must not be modified.
+func (s *Synthetic) Parts() []Part { return s.parts }
+
+// FieldNode returns the first child in the field name, or nil.
+func (s *Synthetic) FieldNode(name string) Node {
+	for _, p := range s.parts {
+		if p.Field == name {
+			return p.Node
+		}
+	}
+	return nil
+}
+
+// SetField replaces the child in the field name by n, keeping the text
+// before it, or appends n as the field if there is none.
+func (s *Synthetic) SetField(name string, n Node) {
+	for i, p := range s.parts {
+		if p.Field == name {
+			s.parts[i].Node = n
+			return
+		}
+	}
+	s.Append(Field(name, n))
+}
+
+// Replace replaces the child at index i by n, keeping the text before it.
+func (s *Synthetic) Replace(i int, n Node) { s.parts[i].Node = n }
+
+// Remove removes the child at index i along with the text before it.
+func (s *Synthetic) Remove(i int) {
+	s.parts = append(s.parts[:i], s.parts[i+1:]...)
+}
+
+// Insert inserts p before the child at index i. In a node copied from a
+// parse tree, the new child is placed like its nearest named sibling, so
+// that a statement inserted into a block gets a line of its own. Comments
+// ending the line before the new child stay there.
+func (s *Synthetic) Insert(i int, p Part) {
+	if s.parsed {
+		for i < len(s.parts) && isComment(s.parts[i].Node) && !strings.Contains(s.parts[i].gap, "\n") {
+			i++
+		}
+		p.gap = " "
+		for _, j := range []int{i - 1, i} {
+			if j >= 0 && j < len(s.parts) && isNamed(s.parts[j].Node) && !isComment(s.parts[j].Node) {
+				p.gap = layout(s.parts[j].gap)
+				break
+			}
+		}
+		if i < len(s.parts) && strings.Contains(p.gap, "\n") {
+			g := s.parts[i].gap
+			if k := strings.IndexByte(g, '\n'); k >= 0 {
+				p.gap = g[:k] + p.gap
+				s.parts[i].gap = g[k:]
+			}
+		}
+	}
+	s.parts = append(s.parts, Part{})
+	copy(s.parts[i+1:], s.parts[i:])
+	s.parts[i] = p
+}
+
+// layout returns the white space of gap without its comments: the line
+// breaks before the first comment, at most two, and the indentation of the
+// last line.
+func layout(gap string) string {
+	k := strings.LastIndexByte(gap, '\n')
+	if k < 0 {
+		return gap
+	}
+	space := gap[:len(gap)-len(strings.TrimLeft(gap, " \t\r\n"))]
+	return strings.Repeat("\n", min(strings.Count(space, "\n"), 2)) + gap[k+1:]
+}
+
+// Append adds p after the last child.
+func (s *Synthetic) Append(p Part) { s.Insert(len(s.parts), p) }
+
+func isComment(n Node) bool {
+	return n.Kind() == kind.LineComment || n.Kind() == kind.BlockComment
+}
+
+func isNamed(n Node) bool {
+	if s, ok := n.(*Synthetic); ok {
+		return s.named
+	}
+	return n.Raw() != nil && n.Raw().IsNamed()
+}
+
+// Children yields the child nodes.
+func (s *Synthetic) Children() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for _, p := range s.parts {
+			if !yield(p.Node) {
+				return
+			}
+		}
+	}
+}
+
+// NamedChildren yields the named child nodes.
+func (s *Synthetic) NamedChildren() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for _, p := range s.parts {
+			if isNamed(p.Node) && !yield(p.Node) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants yields every node below s in depth-first order.
+func (s *Synthetic) Descendants() iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		var visit func(n Node) bool
+		visit = func(n Node) bool {
+			for c := range n.Children() {
+				if !yield(c) || !visit(c) {
+					return false
+				}
+			}
+			return true
+		}
+		visit(s)
+	}
+}
@@ -0,0 +1,120 @@
+package ast
+
+// A Visitor receives the nodes met by Walk. Enter is called before the
+// children of a node and Leave after them; when Enter returns false the
+// children are skipped and Leave is not called for that node.
+type Visitor interface {
+	Enter(n Node) bool
+	Leave(n Node)
+}
+
+// Funcs adapts a pair of functions to a Visitor, Pre serving as Enter and
+// Post as Leave. Either may be nil: a nil Pre descends into every node.
+type Funcs struct {
+	Pre  func(Node) bool
+	Post func(Node)
+}
+
+func (f Funcs) Enter(n Node) bool { return f.Pre == nil || f.Pre(n) }
+
+func (f Funcs) Leave(n Node) {
+	if f.Post != nil {
+		f.Post(n)
+	}
+}
+
+// Walk traverses the tree rooted at n depth-first, in source order,
+// including anonymous tokens.
+func Walk(n Node, v Visitor) {
+	walk(n, v, false)
+}
+
+// WalkNamed is like Walk but only visits named nodes.
+func WalkNamed(n Node, v Visitor) {
+	walk(n, v, true)
+}
+
+// Inspect traverses the tree rooted at n depth-first, calling f for each
+// node. If f returns true, Inspect descends into the node's children and
+// then calls f(nil), in the manner of go/ast.Inspect.
+func Inspect(n Node, f func(Node) bool) {
+	Walk(n, inspector(f))
+}
+
+// InspectNamed is like Inspect but only visits named nodes.
+func InspectNamed(n Node, f func(Node) bool) {
+	WalkNamed(n, inspector(f))
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Enter(n Node) bool { return f(n) }
+
+func (f inspector) Leave(Node) { f(nil) }
+
+func walk(root Node, v Visitor, named bool) {
+	if root == nil {
+		return
+	}
+	if root.Raw() == nil {
+		walkSynthetic(root, v, named)
+		return
+	}
+	arena := arenaOf(root)
+	cursor := root.Raw().Walk()
+	defer cursor.Close()
+
+	// stack holds the entered node at each depth of the cursor, or nil where
+	// the node was skipped.
+	var stack []Node
+	enter := func() {
+		raw := cursor.Node()
+		if named && !raw.IsNamed() {
+			stack = append(stack, nil)
+			return
+		}
+		n := wrapIn(arena, raw)
+		if !v.Enter(n) {
+			n = nil
+		}
+		stack = append(stack, n)
+	}
+
+	enter()
+	for {
+		if stack[len(stack)-1] != nil && cursor.GotoFirstChild() {
+			enter()
+			continue
+		}
+		for {
+			top := len(stack) - 1
+			if n := stack[top]; n != nil {
+				v.Leave(n)
+			}
+			stack = stack[:top]
+			if len(stack) == 0 {
+				return
+			}
+			if cursor.GotoNextSibling() {
+				enter()
+				break
+			}
+			cursor.GotoParent()
+		}
+	}
+}
+
+// walkSynthetic walks a tree of synthetic nodes, which may hold parsed
+// subtrees.
+func walkSynthetic(n Node, v Visitor, named bool) {
+	if named && !isNamed(n) {
+		return
+	}
+	if !v.Enter(n) {
+		return
+	}
+	for c := range n.Children() {
+		walk(c, v, named)
+	}
+	v.Leave(n)
+}
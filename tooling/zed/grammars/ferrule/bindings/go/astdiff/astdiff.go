@@ -0,0 +1,461 @@
+// Package astdiff compares ferrule sources by their syntax trees rather
+// than by their lines, so that changes of layout alone do not show up.
+//
+// Diff matches the nodes of the old tree with those of the new one, in the
+// manner of the GumTree algorithm: first identical subtrees, largest
+// first, then nodes of the same kind whose descendants mostly match, then
+// the remaining children of matched nodes. The unmatched and displaced
+// nodes make up the edit script, and Match returns the matching itself.
+// Equivalent merely tells whether two sources differ in anything but
+// layout and comments.
+package astdiff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Op is the kind of a change.
+type Op int
+
+const (
+	// Delete removes a node of the old tree.
+	Delete Op = iota
+	// Update changes the text of a token.
+	Update
+	// Move places a node under another parent or in another order among
+	// its siblings.
+	Move
+	// Insert adds a node of the new tree.
+	Insert
+)
+
+func (o Op) String() string {
+	switch o {
+	case Delete:
+		return "delete"
+	case Update:
+		return "update"
+	case Move:
+		return "move"
+	case Insert:
+		return "insert"
+	}
+	return fmt.Sprintf("Op(%d)", int(o))
+}
+
+// Change is one step of an edit script. Old describes the node in the old
+// source and New in the new one; Old is zero for an Insert and New for a
+// Delete.
+type Change struct {
+	Op   Op
+	Kind string
+	Old  Side
+	New  Side
+}
+
+// Side locates the node of a change in one of the sources.
+type Side struct {
+	Range tree_sitter.Range
+	Text  string
+}
+
+// Minimum size, in nodes, of the identical subtrees matched in the first
+// phase, and minimum share of matched descendants for two nodes of the
+// same kind to be matched in the second.
+const (
+	minSubtree     = 2
+	minSimilarity  = 0.5
+	maxLabelLength = 40
+)
+
+// Diff returns the edit script turning the syntax tree of oldSrc into that
+// of newSrc: deletions, then updates, moves and insertions, each in source
+// order. Only the topmost node of a deleted, inserted or moved subtree is
+// reported.
+func Diff(oldSrc, newSrc []byte) ([]Change, error) {
+	oldTree, err := ferrule.Parse(context.Background(), oldSrc)
+	if err != nil {
+		return nil, err
+	}
+	defer oldTree.Close()
+	newTree, err := ferrule.Parse(context.Background(), newSrc)
+	if err != nil {
+		return nil, err
+	}
+	defer newTree.Close()
+
+	a := build(oldTree.RootNode(), oldSrc)
+	b := build(newTree.RootNode(), newSrc)
+	match(a, b)
+	return script(a, b), nil
+}
+
+// Pair is a node of the old tree and the node of the new tree matched
+// with it.
+type Pair struct {
+	Old, New *tree_sitter.Node
+}
+
+// Match returns the nodes of oldTree matched with nodes of newTree, as
+// Diff matches them, in preorder of the old tree. Zero width nodes
+// inserted by error recovery are left out.
+func Match(oldTree, newTree *ferrule.Tree) []Pair {
+	a := build(oldTree.RootNode(), oldTree.Source())
+	b := build(newTree.RootNode(), newTree.Source())
+	match(a, b)
+	var out []Pair
+	for _, x := range a {
+		if x.partner != nil {
+			out = append(out, Pair{Old: x.raw, New: x.partner.raw})
+		}
+	}
+	return out
+}
+
+// Equivalent reports whether a and b have the same syntax tree once their
+// comments are left out, that is whether they differ only in layout and
+// comments. Sources that cannot be parsed are not equivalent.
+func Equivalent(a, b []byte) bool {
+	aTree, err := ferrule.Parse(context.Background(), a)
+	if err != nil {
+		return false
+	}
+	defer aTree.Close()
+	bTree, err := ferrule.Parse(context.Background(), b)
+	if err != nil {
+		return false
+	}
+	defer bTree.Close()
+	return equivalent(aTree.RootNode(), bTree.RootNode(), a, b)
+}
+
+func equivalent(x, y *tree_sitter.Node, xSrc, ySrc []byte) bool {
+	if x.Kind() != y.Kind() || x.IsMissing() != y.IsMissing() {
+		return false
+	}
+	xs, ys := uncommented(x), uncommented(y)
+	if len(xs) != len(ys) {
+		return false
+	}
+	if x.ChildCount() == 0 && y.ChildCount() == 0 {
+		return x.Utf8Text(xSrc) == y.Utf8Text(ySrc)
+	}
+	for i := range xs {
+		if !equivalent(xs[i], ys[i], xSrc, ySrc) {
+			return false
+		}
+	}
+	return true
+}
+
+// uncommented returns the children of n other than comments.
+func uncommented(n *tree_sitter.Node) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if k := c.Kind(); k != kind.LineComment && k != kind.BlockComment {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// node is a node of the trees compared: every syntax node but the zero
+// width ones inserted by error recovery.
+type node struct {
+	raw      *tree_sitter.Node
+	src      []byte
+	kind     string
+	text     string // of leaves
+	parent   *node
+	children []*node
+	hash     string
+	size     int
+	index    int // in preorder
+	partner  *node
+}
+
+func build(raw *tree_sitter.Node, src []byte) []*node {
+	var all []*node
+	var visit func(raw *tree_sitter.Node, parent *node) *node
+	visit = func(raw *tree_sitter.Node, parent *node) *node {
+		n := &node{raw: raw, src: src, kind: raw.Kind(), parent: parent, size: 1, index: len(all)}
+		all = append(all, n)
+		var h strings.Builder
+		h.WriteString(n.kind)
+		for i := uint(0); i < raw.ChildCount(); i++ {
+			c := raw.Child(i)
+			if c.IsMissing() {
+				continue
+			}
+			child := visit(c, n)
+			n.children = append(n.children, child)
+			n.size += child.size
+		}
+		if len(n.children) == 0 {
+			n.text = raw.Utf8Text(src)
+			fmt.Fprintf(&h, "%q", n.text)
+		} else {
+			h.WriteByte('(')
+			for _, c := range n.children {
+				h.WriteString(c.hash)
+				h.WriteByte(' ')
+			}
+			h.WriteByte(')')
+		}
+		n.hash = h.String()
+		return n
+	}
+	visit(raw, nil)
+	return all
+}
+
+// match pairs the nodes of a and b, setting their partner fields.
+func match(a, b []*node) {
+	link := func(x, y *node) { x.partner, y.partner = y, x }
+	var linkSubtree func(x, y *node)
+	linkSubtree = func(x, y *node) {
+		link(x, y)
+		for i := range x.children {
+			linkSubtree(x.children[i], y.children[i])
+		}
+	}
+
+	// Identical subtrees, largest first.
+	byHash := make(map[string][]*node)
+	for _, y := range b {
+		byHash[y.hash] = append(byHash[y.hash], y)
+	}
+	order := append([]*node(nil), a...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].size > order[j].size })
+	for _, x := range order {
+		if x.partner != nil || x.size < minSubtree {
+			continue
+		}
+		for _, y := range byHash[x.hash] {
+			if y.partner == nil {
+				linkSubtree(x, y)
+				break
+			}
+		}
+	}
+
+	// Containers whose descendants mostly match, innermost first.
+	if a[0].kind == b[0].kind && a[0].partner == nil && b[0].partner == nil {
+		link(a[0], b[0])
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		x := a[i]
+		if x.partner != nil || len(x.children) == 0 {
+			continue
+		}
+		var best *node
+		bestScore := minSimilarity
+		for _, y := range candidates(x, b) {
+			if s := similarity(x, y); s >= bestScore {
+				best, bestScore = y, s
+			}
+		}
+		if best != nil {
+			link(x, best)
+		}
+	}
+
+	// Leftover children of matched nodes, by kind and text.
+	for _, x := range a {
+		if x.partner == nil {
+			continue
+		}
+		for _, exact := range []bool{true, false} {
+			for _, c := range x.children {
+				if c.partner != nil {
+					continue
+				}
+				for _, d := range x.partner.children {
+					if d.partner == nil && d.kind == c.kind && (!exact || d.hash == c.hash) {
+						if exact {
+							linkSubtree(c, d)
+						} else {
+							link(c, d)
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// candidates returns the unmatched nodes of b of the kind of x that contain
+// the partner of a descendant of x, in preorder.
+func candidates(x *node, b []*node) []*node {
+	seen := make(map[*node]bool)
+	var out []*node
+	var visit func(n *node)
+	visit = func(n *node) {
+		for _, c := range n.children {
+			if c.partner != nil {
+				for p := c.partner.parent; p != nil; p = p.parent {
+					if p.partner == nil && p.kind == x.kind && !seen[p] {
+						seen[p] = true
+						out = append(out, p)
+					}
+				}
+			}
+			visit(c)
+		}
+	}
+	visit(x)
+	sort.Slice(out, func(i, j int) bool { return out[i].index < out[j].index })
+	return out
+}
+
+// similarity is the dice coefficient of the descendants of x and y that
+// are matched with each other.
+func similarity(x, y *node) float64 {
+	inY := make(map[*node]bool)
+	var mark func(n *node)
+	mark = func(n *node) {
+		for _, c := range n.children {
+			inY[c] = true
+			mark(c)
+		}
+	}
+	mark(y)
+	common := 0
+	var count func(n *node)
+	count = func(n *node) {
+		for _, c := range n.children {
+			if c.partner != nil && inY[c.partner] {
+				common++
+			}
+			count(c)
+		}
+	}
+	count(x)
+	return 2 * float64(common) / float64(x.size-1+y.size-1)
+}
+
+func script(a, b []*node) []Change {
+	var out []Change
+	for _, x := range a {
+		if x.partner == nil && (x.parent == nil || x.parent.partner != nil) {
+			out = append(out, Change{Op: Delete, Kind: x.kind, Old: side(x)})
+		}
+	}
+	for _, y := range b {
+		if x := y.partner; x != nil && len(y.children) == 0 && x.text != y.text {
+			out = append(out, Change{Op: Update, Kind: y.kind, Old: side(x), New: side(y)})
+		}
+	}
+	moved := make(map[*node]bool)
+	for _, y := range b {
+		// Matched children of y whose partners are not children of y's
+		// partner moved in; those out of order among their siblings moved
+		// within y.
+		var stay []*node
+		for _, d := range y.children {
+			if d.partner != nil {
+				if y.partner == nil || d.partner.parent != y.partner {
+					moved[d] = true
+				} else {
+					stay = append(stay, d)
+				}
+			}
+		}
+		inOrder := longestIncreasing(stay)
+		for _, d := range stay {
+			if !inOrder[d] {
+				moved[d] = true
+			}
+		}
+	}
+	for _, y := range b {
+		if moved[y] {
+			out = append(out, Change{Op: Move, Kind: y.kind, Old: side(y.partner), New: side(y)})
+		}
+	}
+	for _, y := range b {
+		if y.partner == nil && (y.parent == nil || y.parent.partner != nil) {
+			out = append(out, Change{Op: Insert, Kind: y.kind, New: side(y)})
+		}
+	}
+	return out
+}
+
+// longestIncreasing returns the nodes of the longest subsequence of ns
+// whose partners are in increasing preorder.
+func longestIncreasing(ns []*node) map[*node]bool {
+	n := len(ns)
+	length := make([]int, n)
+	prev := make([]int, n)
+	best := -1
+	for i := range ns {
+		length[i], prev[i] = 1, -1
+		for j := 0; j < i; j++ {
+			if ns[j].partner.index < ns[i].partner.index && length[j]+1 > length[i] {
+				length[i], prev[i] = length[j]+1, j
+			}
+		}
+		if best < 0 || length[i] > length[best] {
+			best = i
+		}
+	}
+	out := make(map[*node]bool)
+	for i := best; i >= 0; i = prev[i] {
+		out[ns[i]] = true
+	}
+	return out
+}
+
+func side(n *node) Side {
+	return Side{Range: n.raw.Range(), Text: text(n)}
+}
+
+// text returns the source of n with its white space collapsed.
+func text(n *node) string {
+	return strings.Join(strings.Fields(n.raw.Utf8Text(n.src)), " ")
+}
+
+// Render writes the changes one per line, with one-based positions and the
+// code involved shortened to fit on the line:
+//
+//	update identifier 3:9 → 3:9: "count" → "total"
+//	insert return_statement 7:3: return total;
+func Render(w io.Writer, changes []Change) error {
+	var b strings.Builder
+	for _, c := range changes {
+		switch c.Op {
+		case Delete:
+			fmt.Fprintf(&b, "delete %s %s: %s\n", c.Kind, pos(c.Old), shorten(c.Old.Text))
+		case Insert:
+			fmt.Fprintf(&b, "insert %s %s: %s\n", c.Kind, pos(c.New), shorten(c.New.Text))
+		case Update:
+			fmt.Fprintf(&b, "update %s %s → %s: %q → %q\n", c.Kind, pos(c.Old), pos(c.New), c.Old.Text, c.New.Text)
+		case Move:
+			fmt.Fprintf(&b, "move %s %s → %s: %s\n", c.Kind, pos(c.Old), pos(c.New), shorten(c.New.Text))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func pos(s Side) string {
+	return fmt.Sprintf("%d:%d", s.Range.StartPoint.Row+1, s.Range.StartPoint.Column+1)
+}
+
+func shorten(s string) string {
+	if r := []rune(s); len(r) > maxLabelLength {
+		return string(r[:maxLabelLength-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package astdiff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/astdiff"
+)
+
+const base = `function add(x: i32, y: i32) -> i32 {
+  return x + y;
+}
+
+function main() -> i32 {
+  const a = add(1, 2);
+  const b = add(a, 3);
+  return b;
+}
+`
+
+func render(t *testing.T, oldSrc, newSrc string) string {
+	t.Helper()
+	changes, err := astdiff.Diff([]byte(oldSrc), []byte(newSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := astdiff.Render(&b, changes); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name, newSrc, want string
+	}{
+		{"layout", strings.ReplaceAll(strings.ReplaceAll(base, "  ", "    "), "(1, 2)", "(\n1,\n2\n)"), ""},
+		{"update", strings.Replace(base, "add(1, 2)", "add(1, 20)", 1),
+			"update integer_literal 6:20 → 6:20: \"2\" → \"20\"\n"},
+		{"delete", strings.Replace(base, "  const b = add(a, 3);\n  return b;\n", "  return a;\n", 1),
+			"delete const_declaration 7:3: const b = add(a, 3);\nupdate identifier 8:10 → 7:10: \"b\" → \"a\"\n"},
+		{"insert", base + "\nfunction sub(x: i32, y: i32) -> i32 {\n  return x - y;\n}\n",
+			"insert function_declaration 11:1: function sub(x: i32, y: i32) -> i32 { r…\n"},
+		{"move", strings.Replace(base, "  const a = add(1, 2);\n  const b = add(a, 3);\n", "  const b = add(a, 3);\n  const a = add(1, 2);\n", 1),
+			"move const_declaration 6:3 → 7:3: const a = add(1, 2);\n"},
+	}
+	for _, tt := range tests {
+		if got := render(t, base, tt.newSrc); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	tests := []struct {
+		name, newSrc string
+		want         bool
+	}{
+		{"same", base, true},
+		{"layout", strings.ReplaceAll(base, "  ", "\t"), true},
+		{"comments", "// adds\n" + strings.Replace(base, "x + y;", "x + /* sum */ y; // done", 1), true},
+		{"change", strings.Replace(base, "x + y", "y + x", 1), false},
+		{"syntax error", strings.Replace(base, "return b;", "return b", 1), false},
+	}
+	for _, tt := range tests {
+		if got := astdiff.Equivalent([]byte(base), []byte(tt.newSrc)); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
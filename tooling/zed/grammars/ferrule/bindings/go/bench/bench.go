@@ -0,0 +1,91 @@
+// Package bench holds the corpus the benchmarks of the binding run over.
+//
+// The corpus is generated, not read from disk, so that it is the same on
+// every machine and at every revision and throughput numbers compare
+// across them. It covers the common constructs of the language in
+// proportions resembling real code, at three sizes:
+//
+//	go test -bench . -benchmem ./bindings/go/bench
+//
+// reports, for each benchmark and size, the time per operation, the
+// throughput in MB/s and the allocations.
+package bench
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Input is a source of the corpus.
+type Input struct {
+	Name   string
+	Source []byte
+}
+
+// unit is the text repeated to make up the corpus, with %[1]d standing
+// for the number of the repetition so that names differ.
+const unit = `/// Errors of the store, number %[1]d.
+domain StoreError%[1]d {
+  NotFound { key: String }
+  Full { capacity: u32 }
+}
+
+type Entry%[1]d = { key: String, value: i64, tags: Array<String> };
+
+const limit%[1]d: u32 = %[1]d;
+
+// lookup finds the value of key, or the default.
+function lookup%[1]d(entries: Array<Entry%[1]d>, key: String, fallback: i64) -> i64 {
+  var total = 0;
+  for entry in entries {
+    if entry.key == key {
+      return entry.value;
+    }
+    total = total + entry.value * 2 - 1;
+  }
+  while total > 100 {
+    total = total / 2;
+  }
+  const message = "lookup %[1]d: not found";
+  return fallback + total;
+}
+
+pub function main%[1]d() -> i32 {
+  const entries = [];
+  const found = lookup%[1]d(entries, "key", -1);
+  if found < 0 {
+    return 1;
+  } else {
+    return 0;
+  }
+}
+
+`
+
+// Sizes of the inputs of the corpus, in repetitions of the unit.
+const (
+	Small  = 1
+	Medium = 16
+	Large  = 1024
+)
+
+// Corpus returns the inputs of the corpus, from the smallest: "small", a
+// file of about a kilobyte; "medium", about 16 kilobytes; and "large",
+// about a megabyte.
+func Corpus() []Input {
+	return []Input{
+		{"small", Generate(Small)},
+		{"medium", Generate(Medium)},
+		{"large", Generate(Large)},
+	}
+}
+
+// Generate returns n repetitions of the unit of the corpus.
+func Generate(n int) []byte {
+	var b strings.Builder
+	b.WriteString("package bench.corpus;\n\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, unit, i)
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,178 @@
+package bench_test
+
+import (
+	"context"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/bench"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+func TestCorpus(t *testing.T) {
+	for _, in := range bench.Corpus() {
+		tree, err := ferrule.Parse(context.Background(), in.Source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.HasError() {
+			t.Errorf("%s: %v", in.Name, tree.Diagnostics()[0])
+		}
+		tree.Close()
+	}
+}
+
+// each runs f as a sub-benchmark for every input of the corpus, counting
+// its size as the bytes processed per operation.
+func each(b *testing.B, f func(b *testing.B, p *ferrule.Parser, src []byte)) {
+	for _, in := range bench.Corpus() {
+		b.Run(in.Name, func(b *testing.B) {
+			p, err := ferrule.NewParser()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer p.Close()
+			b.SetBytes(int64(len(in.Source)))
+			b.ReportAllocs()
+			f(b, p, in.Source)
+		})
+	}
+}
+
+func parse(b *testing.B, p *ferrule.Parser, src []byte, old *ferrule.Tree) *ferrule.Tree {
+	tree, err := p.Parse(context.Background(), src, old)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return tree
+}
+
+// BenchmarkParseCold parses every input from scratch.
+func BenchmarkParseCold(b *testing.B) {
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		for i := 0; i < b.N; i++ {
+			parse(b, p, src, nil).Close()
+		}
+	})
+}
+
+// BenchmarkReparseWarm reparses unchanged inputs, passing the previous
+// tree, as an editor does after a save without changes.
+func BenchmarkReparseWarm(b *testing.B) {
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		old := parse(b, p, src, nil)
+		defer old.Close()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			parse(b, p, src, old).Close()
+		}
+	})
+}
+
+// BenchmarkIncrementalEdit replaces one character in the middle of every
+// input and reparses incrementally, as an editor does while typing.
+func BenchmarkIncrementalEdit(b *testing.B) {
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		// the digit of an integer literal in the middle of the input.
+		at := len(src) / 2
+		for src[at] < '0' || src[at] > '9' {
+			at++
+		}
+		edited := append([]byte(nil), src...)
+		versions := [2][]byte{src, edited}
+		tree := parse(b, p, src, nil)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			next := versions[(i+1)%2]
+			next[at] = '0' + byte(i%10)
+			tree.Raw().Edit(&tree_sitter.InputEdit{
+				StartByte:      uint(at),
+				OldEndByte:     uint(at + 1),
+				NewEndByte:     uint(at + 1),
+				StartPosition:  point(next, at),
+				OldEndPosition: point(next, at+1),
+				NewEndPosition: point(next, at+1),
+			})
+			updated := parse(b, p, next, tree)
+			tree.Close()
+			tree = updated
+		}
+		b.StopTimer()
+		tree.Close()
+	})
+}
+
+// BenchmarkQuery runs the highlights query over the tree of every input.
+func BenchmarkQuery(b *testing.B) {
+	q := query.MustCompile(string(queries.Highlights))
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		tree := parse(b, p, src, nil)
+		defer tree.Close()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			n := 0
+			for range q.Matches(tree.Root(), src) {
+				n++
+			}
+			if n == 0 {
+				b.Fatal("no captures")
+			}
+		}
+	})
+}
+
+// BenchmarkWalkHeap walks the typed tree of every input, each wrapper a
+// heap object of its own.
+func BenchmarkWalkHeap(b *testing.B) {
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		tree := parse(b, p, src, nil)
+		defer tree.Close()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			walkTyped(tree.Root())
+		}
+	})
+}
+
+// BenchmarkWalkArena is BenchmarkWalkHeap with the wrappers allocated from
+// an arena reset after each walk.
+func BenchmarkWalkArena(b *testing.B) {
+	each(b, func(b *testing.B, p *ferrule.Parser, src []byte) {
+		tree := parse(b, p, src, nil)
+		defer tree.Close()
+		a := ast.NewArena()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			walkTyped(a.Root(tree.Raw()))
+			a.Reset()
+		}
+	})
+}
+
+// walkTyped visits every named node below root and the fields of the
+// declarations, as an indexer does.
+func walkTyped(root *ast.SourceFile) {
+	ast.InspectNamed(root, func(n ast.Node) bool {
+		if f, ok := n.(*ast.FunctionDeclaration); ok {
+			_ = f.Name()
+			_ = f.Parameters()
+		}
+		return true
+	})
+}
+
+func point(src []byte, off int) tree_sitter.Point {
+	var p tree_sitter.Point
+	for _, c := range src[:off] {
+		p.Column++
+		if c == '\n' {
+			p.Row++
+			p.Column = 0
+		}
+	}
+	return p
+}
@@ -1,3 +1,5 @@
+//go:build cgo && !ferrule_wasm
+
 package tree_sitter_ferrule
 
 // #cgo CFLAGS: -std=c11 -fPIC
@@ -7,9 +9,25 @@ package tree_sitter_ferrule
 // #endif
 import "C"
 
-import "unsafe"
+import (
+	"fmt"
+	"unsafe"
+)
 
 // Get the tree-sitter Language for this grammar.
 func Language() unsafe.Pointer {
 	return unsafe.Pointer(C.tree_sitter_ferrule())
 }
+
+// Version returns the semantic version of the grammar, such as "0.1.0".
+func Version() string {
+	m := C.tree_sitter_ferrule().metadata
+	return fmt.Sprintf("%d.%d.%d", m.major_version, m.minor_version, m.patch_version)
+}
+
+// GrammarABI returns the tree-sitter ABI version the parser was generated
+// for. A tree-sitter runtime can only load grammars within the range of
+// ABI versions it supports.
+func GrammarABI() uint32 {
+	return uint32(C.tree_sitter_ferrule().abi_version)
+}
@@ -1,6 +1,10 @@
+//go:build cgo && !ferrule_wasm
+
 package tree_sitter_ferrule_test
 
 import (
+	"encoding/json"
+	"os"
 	"testing"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
@@ -13,3 +17,50 @@ func TestCanLoadGrammar(t *testing.T) {
 		t.Errorf("Error loading ferrule grammar")
 	}
 }
+
+func TestQueriesCompile(t *testing.T) {
+	language := tree_sitter.NewLanguage(tree_sitter_ferrule.Language())
+	queries := map[string][]byte{
+		"highlights":  tree_sitter_ferrule.HighlightsQuery(),
+		"locals":      tree_sitter_ferrule.LocalsQuery(),
+		"injections":  tree_sitter_ferrule.InjectionsQuery(),
+		"tags":        tree_sitter_ferrule.TagsQuery(),
+		"folds":       tree_sitter_ferrule.FoldsQuery(),
+		"indents":     tree_sitter_ferrule.IndentsQuery(),
+		"textobjects": tree_sitter_ferrule.TextObjectsQuery(),
+	}
+	for name, source := range queries {
+		if len(source) == 0 {
+			t.Errorf("%s query is empty", name)
+			continue
+		}
+		query, err := tree_sitter.NewQuery(language, string(source))
+		if err != nil {
+			t.Errorf("Error compiling %s query: %v", name, err)
+			continue
+		}
+		query.Close()
+	}
+}
+
+func TestVersion(t *testing.T) {
+	data, err := os.ReadFile("../../tree-sitter.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Metadata struct {
+			Version string `json:"version"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree_sitter_ferrule.Version(); got != config.Metadata.Version {
+		t.Errorf("Version() = %q, tree-sitter.json has %q", got, config.Metadata.Version)
+	}
+	language := tree_sitter.NewLanguage(tree_sitter_ferrule.Language())
+	if got, want := tree_sitter_ferrule.GrammarABI(), language.AbiVersion(); got != want {
+		t.Errorf("GrammarABI() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !cgo || ferrule_wasm
+
+package tree_sitter_ferrule
+
+import "github.com/karol-broda/ferrule/bindings/go/wasm"
+
+// Builds without cgo, and builds with the ferrule_wasm build tag, have no
+// Language for go-tree-sitter, which needs cgo: they parse with package
+// wasm, which runs the grammar compiled to WebAssembly under wazero.
+
+// Version returns the semantic version of the grammar, such as "0.1.0".
+func Version() string { return wasm.Version() }
+
+// GrammarABI returns the tree-sitter ABI version the parser was generated
+// for.
+func GrammarABI() uint32 { return wasm.GrammarABI() }
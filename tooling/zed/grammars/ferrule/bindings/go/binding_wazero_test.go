@@ -0,0 +1,37 @@
+//go:build !cgo || ferrule_wasm
+
+package tree_sitter_ferrule_test
+
+import (
+	"context"
+	"testing"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+	"github.com/karol-broda/ferrule/bindings/go/wasm"
+)
+
+func TestWasm(t *testing.T) {
+	parser, err := wasm.NewParser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close(context.Background())
+	tree, err := parser.Parse(context.Background(), []byte("const x = 1;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.RootNode().ToSexp(); got != "(source_file (const_declaration name: (identifier) value: (integer_literal)))" {
+		t.Errorf("tree %s", got)
+	}
+	if tree_sitter_ferrule.Version() != wasm.Version() || tree_sitter_ferrule.GrammarABI() != wasm.GrammarABI() {
+		t.Errorf("version %s, ABI %d", tree_sitter_ferrule.Version(), tree_sitter_ferrule.GrammarABI())
+	}
+	for name, q := range map[string][]byte{
+		"highlights": tree_sitter_ferrule.HighlightsQuery(),
+		"tags":       tree_sitter_ferrule.TagsQuery(),
+	} {
+		if len(q) == 0 {
+			t.Errorf("%s query is empty", name)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package cache memoizes syntax trees, so that tools running several passes
+// over the same files, such as linting, formatting and indexing, parse
+// each file once.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// DefaultMaxBytes is the capacity of a cache created with a capacity of
+// zero or less: the trees of 64 MiB of source.
+const DefaultMaxBytes = 64 << 20
+
+// TreeCache holds the trees of recently parsed sources, keyed by the
+// SHA-256 hash of their content. When the sources held exceed its capacity
+// the least recently used trees are dropped.
+//
+// Trees are handed out as clones, which share the cached tree's nodes
+// without copying them but are independent of it and of each other: each
+// can be walked, edited or closed without affecting the others, and they
+// stay usable after the cached tree is evicted.
+//
+// A TreeCache is safe for concurrent use.
+type TreeCache struct {
+	parser   *ferrule.ParserPool
+	maxBytes int64
+
+	mu    sync.Mutex
+	lru   list.List // of *entry, most recently used first
+	items map[[sha256.Size]byte]*list.Element
+	bytes int64
+	hits  uint64
+	miss  uint64
+}
+
+type entry struct {
+	key  [sha256.Size]byte
+	tree *ferrule.Tree
+}
+
+// New returns a cache holding the trees of up to maxBytes of source.
+func New(maxBytes int64) *TreeCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &TreeCache{
+		parser:   ferrule.NewParserPool(0),
+		maxBytes: maxBytes,
+		items:    make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Parse returns the tree of src, parsing it only if no tree of the same
+// content is cached. The tree is the caller's to close. Its Source is a
+// copy of src shared with the cache and must not be modified.
+func (c *TreeCache) Parse(ctx context.Context, src []byte) (*ferrule.Tree, error) {
+	key := sha256.Sum256(src)
+	if t := c.lookup(key); t != nil {
+		return t, nil
+	}
+	tree, err := c.parser.Parse(ctx, append([]byte(nil), src...), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		// another goroutine parsed the same source meanwhile.
+		tree.Close()
+		c.lru.MoveToFront(e)
+		return e.Value.(*entry).tree.Clone(), nil
+	}
+	if int64(len(src)) > c.maxBytes {
+		return tree, nil
+	}
+	c.items[key] = c.lru.PushFront(&entry{key: key, tree: tree})
+	c.bytes += int64(len(src))
+	c.evict()
+	return tree.Clone(), nil
+}
+
+// lookup returns a clone of the cached tree of the content with hash key,
+// or nil.
+func (c *TreeCache) lookup(key [sha256.Size]byte) *ferrule.Tree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		c.miss++
+		return nil
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*entry).tree.Clone()
+}
+
+// evict drops the least recently used trees until the cache is within its
+// capacity.
+func (c *TreeCache) evict() {
+	for c.bytes > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *TreeCache) remove(e *list.Element) {
+	ent := c.lru.Remove(e).(*entry)
+	delete(c.items, ent.key)
+	c.bytes -= int64(len(ent.tree.Source()))
+	ent.tree.Close()
+}
+
+// Forget drops the tree of src, if it is cached.
+func (c *TreeCache) Forget(src []byte) {
+	key := sha256.Sum256(src)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+}
+
+// Stats describes the use of a cache.
+type Stats struct {
+	Hits, Misses uint64
+	// Entries is the number of trees held, and Bytes the size of their
+	// sources.
+	Entries int
+	Bytes   int64
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Stats returns the current statistics of c.
+func (c *TreeCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Hits: c.hits, Misses: c.miss, Entries: len(c.items), Bytes: c.bytes}
+}
+
+// Close drops every cached tree and releases the parsers of c. Trees
+// handed out remain the callers' to close.
+func (c *TreeCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+	c.parser.Close()
+}
@@ -0,0 +1,97 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/cache"
+)
+
+func TestParse(t *testing.T) {
+	c := cache.New(0)
+	defer c.Close()
+	src := []byte("function f() -> i32 { return 1; }\n")
+	a, err := c.Parse(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src[0] = 'F' // the cache keeps a copy of its own.
+	b, err := c.Parse(context.Background(), []byte("function f() -> i32 { return 1; }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b || a.RootNode().ToSexp() != b.RootNode().ToSexp() || string(b.Source()[:1]) != "f" {
+		t.Errorf("second parse did not return a clone of the first")
+	}
+	// closing one clone leaves the others usable.
+	a.Close()
+	if b.HasError() || len(b.Root().FunctionDeclarations()) != 1 {
+		t.Errorf("tree unusable after closing its sibling")
+	}
+	b.Close()
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 || s.Entries != 1 || s.HitRate() != 0.5 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	src := func(i int) []byte { return []byte(fmt.Sprintf("const a%d = %08d;\n", i, i)) }
+	size := int64(len(src(0)))
+	c := cache.New(3 * size)
+	defer c.Close()
+	parse := func(i int) {
+		tree, err := c.Parse(context.Background(), src(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Close()
+	}
+	parse(0)
+	parse(1)
+	parse(2)
+	parse(0) // 0 is now the most recently used, 1 the least.
+	parse(3)
+	if s := c.Stats(); s.Entries != 3 || s.Bytes != 3*size {
+		t.Errorf("stats = %+v, want 3 entries of %d bytes", s, 3*size)
+	}
+	before := c.Stats().Misses
+	parse(0)
+	parse(1)
+	if misses := c.Stats().Misses - before; misses != 1 {
+		t.Errorf("%d misses, want 1 for the evicted source", misses)
+	}
+
+	c.Forget(src(1))
+	if s := c.Stats(); s.Entries != 2 {
+		t.Errorf("%d entries after Forget, want 2", s.Entries)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	c := cache.New(0)
+	defer c.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				tree, err := c.Parse(context.Background(), []byte(fmt.Sprintf("const a = %d;\n", j%5)))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if tree.HasError() {
+					t.Errorf("syntax error in %s", tree.Source())
+				}
+				tree.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if s := c.Stats(); s.Entries != 5 {
+		t.Errorf("%d entries, want 5", s.Entries)
+	}
+}
@@ -0,0 +1,353 @@
+// Package charset decodes ferrule source stored in encodings other than
+// UTF-8, which is what the parser and every package built on it expect,
+// and maps offsets into the decoded source back to the original bytes so
+// that edits can be written back in the encoding the file was read in.
+//
+// The encoding is detected from a byte order mark when the data starts
+// with one. Without a mark, data that looks like ASCII text interleaved
+// with zero bytes is taken as UTF-16, as Windows tools write it, other
+// valid UTF-8 as UTF-8, and anything else as ISO-8859-1 (Latin-1), which
+// decodes every byte sequence and encodes it back unchanged.
+package charset
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnencodable is returned, wrapped, when text holds a character the
+// encoding it is written in cannot represent.
+var ErrUnencodable = errors.New("charset: character cannot be encoded")
+
+// Encoding is an encoding source can be stored in.
+type Encoding int
+
+const (
+	// UTF8 is UTF-8, the encoding of the decoded source.
+	UTF8 Encoding = iota
+	// UTF16LE is little-endian UTF-16.
+	UTF16LE
+	// UTF16BE is big-endian UTF-16.
+	UTF16BE
+	// Latin1 is ISO-8859-1, a byte per character.
+	Latin1
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	case Latin1:
+		return "ISO-8859-1"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// boms are the byte order marks, by encoding.
+var boms = map[Encoding]string{
+	UTF8:    "\xef\xbb\xbf",
+	UTF16LE: "\xff\xfe",
+	UTF16BE: "\xfe\xff",
+}
+
+// sniffLen is the number of bytes looked at to tell UTF-16 without a byte
+// order mark.
+const sniffLen = 512
+
+// Detect returns the encoding of data, and whether data starts with its
+// byte order mark.
+func Detect(data []byte) (Encoding, bool) {
+	for _, e := range []Encoding{UTF8, UTF16LE, UTF16BE} {
+		if len(data) >= len(boms[e]) && string(data[:len(boms[e])]) == boms[e] {
+			return e, true
+		}
+	}
+	if e, ok := sniffUTF16(data); ok {
+		return e, false
+	}
+	if utf8.Valid(data) {
+		return UTF8, false
+	}
+	return Latin1, false
+}
+
+// sniffUTF16 reports whether the start of data reads as UTF-16 text that
+// is mostly ASCII: half or more of the bytes on one side of each pair are
+// zero, and few on the other side are. Source text has no zero bytes of
+// its own.
+func sniffUTF16(data []byte) (Encoding, bool) {
+	n := min(len(data), sniffLen) &^ 1
+	if n == 0 {
+		return 0, false
+	}
+	var zeros [2]int
+	for i := 0; i < n; i++ {
+		if data[i] == 0 {
+			zeros[i&1]++
+		}
+	}
+	pairs := n / 2
+	switch {
+	case 2*zeros[1] >= pairs && 8*zeros[0] < pairs:
+		return UTF16LE, true
+	case 2*zeros[0] >= pairs && 8*zeros[1] < pairs:
+		return UTF16BE, true
+	}
+	return 0, false
+}
+
+// markEvery is the number of bytes of decoded source between the offsets
+// a Text records in both encodings.
+const markEvery = 128
+
+// mark is an offset into the decoded source and the offset of the same
+// character in the original data.
+type mark struct {
+	src, orig uint
+}
+
+// Text is decoded source and the encoding it was decoded from.
+type Text struct {
+	// Source is the UTF-8 text, without a byte order mark.
+	Source []byte
+	// Encoding is the encoding of the original data.
+	Encoding Encoding
+	// BOM reports whether the original data started with a byte order
+	// mark.
+	BOM bool
+	// Lossy reports that the original data held sequences invalid in its
+	// encoding, which were decoded as U+FFFD, so that Encode does not give
+	// back the original bytes.
+	Lossy bool
+
+	size  uint
+	marks []mark
+}
+
+// Decode decodes data in the encoding Detect finds. Valid UTF-8 without a
+// byte order mark is used as it is, without copying.
+func Decode(data []byte) *Text {
+	e, bom := Detect(data)
+	return DecodeAs(data, e, bom)
+}
+
+// DecodeAs decodes data in encoding e, skipping a byte order mark at its
+// start if bom is true.
+func DecodeAs(data []byte, e Encoding, bom bool) *Text {
+	t := &Text{Encoding: e, BOM: bom, size: uint(len(data))}
+	start := 0
+	if bom {
+		start = min(len(boms[e]), len(data))
+	}
+	switch e {
+	case UTF8:
+		t.Source = data[start:]
+		t.Lossy = !utf8.Valid(t.Source)
+	case UTF16LE, UTF16BE:
+		t.decodeUTF16(data, start, e == UTF16BE)
+	case Latin1:
+		t.decodeLatin1(data)
+	default:
+		panic(fmt.Sprintf("charset: unknown encoding %d", int(e)))
+	}
+	return t
+}
+
+// ReadFile reads and decodes the file name.
+func ReadFile(name string) (*Text, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data), nil
+}
+
+func (t *Text) decodeUTF16(data []byte, start int, bigEndian bool) {
+	unit := func(i int) rune {
+		if bigEndian {
+			return rune(data[i])<<8 | rune(data[i+1])
+		}
+		return rune(data[i+1])<<8 | rune(data[i])
+	}
+	src := make([]byte, 0, len(data)-start)
+	for i := start; i < len(data); {
+		t.mark(len(src), i)
+		r, width := utf8.RuneError, 2
+		switch {
+		case i+1 == len(data):
+			// an odd byte at the end.
+			width = 1
+			t.Lossy = true
+		case utf16.IsSurrogate(unit(i)):
+			if i+3 < len(data) {
+				if dec := utf16.DecodeRune(unit(i), unit(i+2)); dec != utf8.RuneError {
+					r, width = dec, 4
+					break
+				}
+			}
+			t.Lossy = true
+		default:
+			r = unit(i)
+		}
+		src = utf8.AppendRune(src, r)
+		i += width
+	}
+	t.Source = src
+}
+
+func (t *Text) decodeLatin1(data []byte) {
+	src := make([]byte, 0, len(data)+len(data)/8)
+	for i, c := range data {
+		t.mark(len(src), i)
+		src = utf8.AppendRune(src, rune(c))
+	}
+	t.Source = src
+}
+
+// mark records the offsets of the character decoded next, if it starts
+// markEvery bytes or more after the last one recorded.
+func (t *Text) mark(src, orig int) {
+	if n := len(t.marks); n == 0 || uint(src) >= t.marks[n-1].src+markEvery {
+		t.marks = append(t.marks, mark{uint(src), uint(orig)})
+	}
+}
+
+// width returns the number of bytes r takes in the original encoding.
+func (t *Text) width(r rune) uint {
+	switch t.Encoding {
+	case UTF16LE, UTF16BE:
+		if r >= 0x10000 {
+			return 4
+		}
+		return 2
+	case Latin1:
+		return 1
+	}
+	return uint(utf8.RuneLen(r))
+}
+
+// bomLen returns the length of the byte order mark of the original data.
+func (t *Text) bomLen() uint {
+	if !t.BOM {
+		return 0
+	}
+	return min(uint(len(boms[t.Encoding])), t.size)
+}
+
+// Offset returns the offset in the original data of the character at the
+// byte offset off of Source. An offset inside a character counts as its
+// start, and one past the end of Source as the end of the data.
+func (t *Text) Offset(off uint) uint {
+	if off >= uint(len(t.Source)) {
+		return t.size
+	}
+	if t.Encoding == UTF8 {
+		return t.bomLen() + off
+	}
+	m := t.marks[sort.Search(len(t.marks), func(i int) bool { return t.marks[i].src > off })-1]
+	src, orig := m.src, m.orig
+	for {
+		r, size := utf8.DecodeRune(t.Source[src:])
+		if src+uint(size) > off {
+			return orig
+		}
+		src += uint(size)
+		orig += t.width(r)
+	}
+}
+
+// SourceOffset returns the byte offset in Source of the character at the
+// offset orig of the original data: the inverse of Offset. An offset inside
+// a character or its byte order mark counts as its start.
+func (t *Text) SourceOffset(orig uint) uint {
+	if orig >= t.size {
+		return uint(len(t.Source))
+	}
+	if orig < t.bomLen() {
+		return 0
+	}
+	if t.Encoding == UTF8 {
+		return orig - t.bomLen()
+	}
+	m := t.marks[sort.Search(len(t.marks), func(i int) bool { return t.marks[i].orig > orig })-1]
+	src, at := m.src, m.orig
+	for src < uint(len(t.Source)) {
+		r, size := utf8.DecodeRune(t.Source[src:])
+		if at+t.width(r) > orig {
+			break
+		}
+		src += uint(size)
+		at += t.width(r)
+	}
+	return src
+}
+
+// Encode returns src, UTF-8 text such as an edited Source, in the encoding
+// of t, starting with a byte order mark if the original data did.
+func (t *Text) Encode(src []byte) ([]byte, error) {
+	var out []byte
+	if t.BOM {
+		out = append(out, boms[t.Encoding]...)
+	}
+	return t.appendEncoded(out, src)
+}
+
+// Edit is a change to the original data: the bytes between Start and End
+// are replaced with New.
+type Edit struct {
+	Start, End uint
+	New        []byte
+}
+
+// Edit returns the change to the original data that replaces the bytes of
+// Source between start and end with text.
+func (t *Text) Edit(start, end uint, text string) (Edit, error) {
+	b, err := t.appendEncoded(nil, []byte(text))
+	if err != nil {
+		return Edit{}, err
+	}
+	return Edit{Start: t.Offset(start), End: t.Offset(end), New: b}, nil
+}
+
+func (t *Text) appendEncoded(out, src []byte) ([]byte, error) {
+	switch t.Encoding {
+	case UTF8:
+		return append(out, src...), nil
+	case UTF16LE, UTF16BE:
+		for _, r := range string(src) {
+			r1, r2 := r, rune(-1)
+			if r >= 0x10000 {
+				r1, r2 = utf16.EncodeRune(r)
+			}
+			out = t.appendUnit(out, r1)
+			if r2 >= 0 {
+				out = t.appendUnit(out, r2)
+			}
+		}
+		return out, nil
+	case Latin1:
+		for i, r := range string(src) {
+			if r > 0xff {
+				return nil, fmt.Errorf("%w: %U at offset %d in %v", ErrUnencodable, r, i, t.Encoding)
+			}
+			out = append(out, byte(r))
+		}
+		return out, nil
+	}
+	panic(fmt.Sprintf("charset: unknown encoding %d", int(t.Encoding)))
+}
+
+func (t *Text) appendUnit(out []byte, u rune) []byte {
+	if t.Encoding == UTF16BE {
+		return append(out, byte(u>>8), byte(u))
+	}
+	return append(out, byte(u), byte(u>>8))
+}
@@ -0,0 +1,130 @@
+package charset_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+)
+
+func utf16le(s string) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u), byte(u>>8))
+	}
+	return out
+}
+
+func utf16be(s string) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return out
+}
+
+func TestDetect(t *testing.T) {
+	src := "const π = 3.14; // 🥧\n"
+	for _, tc := range []struct {
+		name string
+		data []byte
+		enc  charset.Encoding
+		bom  bool
+	}{
+		{"utf-8", []byte(src), charset.UTF8, false},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), src...), charset.UTF8, true},
+		{"utf-16le bom", append([]byte{0xff, 0xfe}, utf16le(src)...), charset.UTF16LE, true},
+		{"utf-16be bom", append([]byte{0xfe, 0xff}, utf16be(src)...), charset.UTF16BE, true},
+		{"utf-16le", utf16le(src), charset.UTF16LE, false},
+		{"utf-16be", utf16be(src), charset.UTF16BE, false},
+		{"latin-1", []byte("const caf\xe9 = 1;\n"), charset.Latin1, false},
+		{"empty", nil, charset.UTF8, false},
+	} {
+		enc, bom := charset.Detect(tc.data)
+		if enc != tc.enc || bom != tc.bom {
+			t.Errorf("%s: Detect = %v, %v, want %v, %v", tc.name, enc, bom, tc.enc, tc.bom)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	src := "const π = 3.14; // 🥧\r\n" + strings.Repeat("let x = \"ü\";\n", 40)
+	latin := strings.ReplaceAll(strings.ReplaceAll(src, "π", "p"), "🥧", "pie")
+	for _, data := range [][]byte{
+		[]byte(src),
+		append([]byte("\xef\xbb\xbf"), src...),
+		append([]byte{0xff, 0xfe}, utf16le(src)...),
+		utf16be(src),
+		[]byte(strings.ReplaceAll(latin, "ü", "\xfc")),
+	} {
+		text := charset.Decode(data)
+		want := src
+		if text.Encoding == charset.Latin1 {
+			want = latin
+		}
+		if string(text.Source) != want {
+			t.Errorf("%v: decoded %q", text.Encoding, text.Source)
+		}
+		out, err := text.Encode(text.Source)
+		if err != nil || !bytes.Equal(out, data) {
+			t.Errorf("%v: Encode = %q, %v, want %q", text.Encoding, out, err, data)
+		}
+		if text.Lossy {
+			t.Errorf("%v: decoding was lossy", text.Encoding)
+		}
+
+		// every character boundary maps to the offset of its encoding.
+		for i := range string(text.Source) {
+			off := text.Offset(uint(i))
+			if got := text.SourceOffset(off); got != uint(i) {
+				t.Fatalf("%v: SourceOffset(Offset(%d)) = %d", text.Encoding, i, got)
+			}
+			prefix, _ := text.Encode(text.Source[:i])
+			if off != uint(len(prefix)) {
+				t.Fatalf("%v: Offset(%d) = %d, want %d", text.Encoding, i, off, len(prefix))
+			}
+		}
+		if off := text.Offset(uint(len(text.Source))); off != uint(len(data)) {
+			t.Errorf("%v: end maps to %d, want %d", text.Encoding, off, len(data))
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	src := "let a = \"é\";\nlet b = a;\n"
+	data := append([]byte{0xff, 0xfe}, utf16le(src)...)
+	text := charset.Decode(data)
+	start := uint(strings.Index(src, "b"))
+	e, err := text.Edit(start, start+1, "ñ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := append(append(append([]byte(nil), data[:e.Start]...), e.New...), data[e.End:]...)
+	want := append([]byte{0xff, 0xfe}, utf16le(strings.Replace(src, "b", "ñ", 1))...)
+	if !bytes.Equal(got, want) {
+		t.Errorf("edited data %q, want %q", got, want)
+	}
+
+	latin := charset.Decode([]byte("let caf\xe9 = 1;\n"))
+	if _, err := latin.Edit(0, 3, "λ"); !errors.Is(err, charset.ErrUnencodable) {
+		t.Errorf("encoding λ in Latin-1: %v", err)
+	}
+}
+
+func TestLossy(t *testing.T) {
+	// an unpaired surrogate and an odd byte at the end.
+	data := append(utf16le("let a = 1;"), 0x00, 0xd8, 'x', 0x00, 'y')
+	text := charset.Decode(data)
+	if text.Encoding != charset.UTF16LE || !text.Lossy {
+		t.Fatalf("decoded as %v, lossy %v", text.Encoding, text.Lossy)
+	}
+	if want := "let a = 1;�x�"; string(text.Source) != want {
+		t.Errorf("decoded %q, want %q", text.Source, want)
+	}
+	if off := text.Offset(uint(len("let a = 1;�"))); off != 22 {
+		t.Errorf("offset of x is %d, want 22", off)
+	}
+}
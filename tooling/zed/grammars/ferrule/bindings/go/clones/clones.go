@@ -0,0 +1,244 @@
+// Package clones finds duplicated code in a ferrule project.
+//
+// Every subtree of every indexed file is hashed after normalization:
+// layout and comments never count, and identifiers and literals may be
+// ignored as well, so that code copied and then renamed is still found.
+// Subtrees with the same hash, and at least a minimum number of nodes,
+// are clones of one another. Only the largest clones are reported: a
+// group whose every member lies inside a member of a larger group, with
+// as many members, is left out.
+package clones
+
+import (
+	"context"
+	"crypto/sha256"
+	"path/filepath"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Options adjust what counts as the same code.
+type Options struct {
+	// Identifiers makes subtrees that differ only in their names clones.
+	Identifiers bool
+	// Literals makes subtrees that differ only in their literal values
+	// clones.
+	Literals bool
+}
+
+// Similarity tells how alike the members of a group are.
+type Similarity int
+
+const (
+	// Identical members differ at most in layout and comments.
+	Identical Similarity = iota
+	// Renamed members differ in the identifiers or literals the options
+	// ignore as well.
+	Renamed
+)
+
+func (s Similarity) String() string {
+	if s == Identical {
+		return "identical"
+	}
+	return "renamed"
+}
+
+// Group is a set of clones.
+type Group struct {
+	// Kind is the node kind of the members.
+	Kind string
+	// Nodes is the size of each member, in syntax nodes other than
+	// comments.
+	Nodes      int
+	Similarity Similarity
+	// Clones are the members, ordered by path and position.
+	Clones []index.Location
+}
+
+// Detect returns the groups of clones of at least minNodes nodes found in
+// the files of idx, largest first. The files are read from the root of
+// idx. opts may be nil.
+func Detect(ctx context.Context, idx *index.Index, minNodes int, opts *Options) ([]Group, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	d := &detector{opts: opts, min: max(minNodes, 1), byHash: make(map[[sha256.Size]byte]*candidate)}
+	for _, f := range idx.Files() {
+		text, err := charset.ReadFile(filepath.Join(idx.Root(), filepath.FromSlash(f.Path)))
+		if err != nil {
+			return nil, err
+		}
+		src := text.Source
+		tree, err := ferrule.Parse(ctx, src)
+		if err != nil {
+			return nil, err
+		}
+		d.file(f.Path, tree.RootNode(), src)
+		tree.Close()
+	}
+	return d.groups(), nil
+}
+
+type hash = [sha256.Size]byte
+
+// candidate gathers the subtrees sharing a normalized hash.
+type candidate struct {
+	kind    string
+	nodes   int
+	members []member
+}
+
+type member struct {
+	loc    index.Location
+	exact  hash
+	parent hash // the normalized hash of the parent, if any
+	orphan bool // the member is the root of its file
+}
+
+type detector struct {
+	opts   *Options
+	min    int
+	byHash map[hash]*candidate
+	order  []hash // in order of first sight, for stable output
+}
+
+// sums are the hashes and size of a subtree.
+type sums struct {
+	normal, exact hash
+	nodes         int
+	ok            bool // the subtree is free of syntax errors
+}
+
+func (d *detector) file(path string, root *tree_sitter.Node, src []byte) {
+	s := d.visit(path, root, src)
+	d.add(path, root, s, hash{}, true)
+}
+
+// visit returns the sums of n and records its descendants.
+func (d *detector) visit(path string, n *tree_sitter.Node, src []byte) sums {
+	normal, exact := sha256.New(), sha256.New()
+	normal.Write([]byte(n.Kind()))
+	exact.Write([]byte(n.Kind()))
+	s := sums{nodes: 1, ok: !n.IsError()}
+	type child struct {
+		n *tree_sitter.Node
+		s sums
+	}
+	var children []child
+	if n.ChildCount() == 0 || d.ignored(n) {
+		if !d.ignored(n) {
+			normal.Write([]byte{0})
+			normal.Write([]byte(n.Utf8Text(src)))
+		}
+		exact.Write([]byte{0})
+		exact.Write([]byte(n.Utf8Text(src)))
+	} else {
+		normal.Write([]byte{'('})
+		exact.Write([]byte{'('})
+		for i := uint(0); i < n.ChildCount(); i++ {
+			c := n.Child(i)
+			if c.IsMissing() {
+				s.ok = false
+				continue
+			}
+			if c.Kind() == kind.LineComment || c.Kind() == kind.BlockComment {
+				continue
+			}
+			cs := d.visit(path, c, src)
+			normal.Write(cs.normal[:])
+			exact.Write(cs.exact[:])
+			s.nodes += cs.nodes
+			s.ok = s.ok && cs.ok
+			children = append(children, child{c, cs})
+		}
+		normal.Write([]byte{')'})
+		exact.Write([]byte{')'})
+	}
+	copy(s.normal[:], normal.Sum(nil))
+	copy(s.exact[:], exact.Sum(nil))
+	for _, c := range children {
+		d.add(path, c.n, c.s, s.normal, false)
+	}
+	return s
+}
+
+// ignored reports whether the options skip the text of n.
+func (d *detector) ignored(n *tree_sitter.Node) bool {
+	switch n.Kind() {
+	case kind.Identifier, kind.TypeIdentifier:
+		return d.opts.Identifiers
+	case kind.BooleanLiteral, kind.CharLiteral, kind.FloatLiteral, kind.IntegerLiteral, kind.StringLiteral:
+		return d.opts.Literals
+	}
+	return false
+}
+
+func (d *detector) add(path string, n *tree_sitter.Node, s sums, parent hash, orphan bool) {
+	if !s.ok || s.nodes < d.min || !n.IsNamed() {
+		return
+	}
+	c := d.byHash[s.normal]
+	if c == nil {
+		c = &candidate{kind: n.Kind(), nodes: s.nodes}
+		d.byHash[s.normal] = c
+		d.order = append(d.order, s.normal)
+	}
+	c.members = append(c.members, member{
+		loc:    index.Location{Path: path, Range: n.Range()},
+		exact:  s.exact,
+		parent: parent,
+		orphan: orphan,
+	})
+}
+
+// groups returns the candidates with several members that are not part of
+// larger clones.
+func (d *detector) groups() []Group {
+	var out []Group
+	for _, h := range d.order {
+		c := d.byHash[h]
+		if len(c.members) < 2 || d.enclosed(c) {
+			continue
+		}
+		g := Group{Kind: c.kind, Nodes: c.nodes}
+		for _, m := range c.members {
+			if m.exact != c.members[0].exact {
+				g.Similarity = Renamed
+			}
+			g.Clones = append(g.Clones, m.loc)
+		}
+		sort.SliceStable(g.Clones, func(i, j int) bool {
+			a, b := g.Clones[i], g.Clones[j]
+			if a.Path != b.Path {
+				return a.Path < b.Path
+			}
+			return a.Range.StartByte < b.Range.StartByte
+		})
+		out = append(out, g)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Nodes > out[j].Nodes })
+	return out
+}
+
+// enclosed reports whether the members of c all have parents that are
+// the members of one larger group, so that c adds nothing to it.
+func (d *detector) enclosed(c *candidate) bool {
+	first := c.members[0]
+	if first.orphan {
+		return false
+	}
+	for _, m := range c.members[1:] {
+		if m.orphan || m.parent != first.parent {
+			return false
+		}
+	}
+	p := d.byHash[first.parent]
+	return p != nil && len(p.members) == len(c.members)
+}
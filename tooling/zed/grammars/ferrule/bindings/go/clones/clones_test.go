@@ -0,0 +1,95 @@
+package clones_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/clones"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+var files = map[string]string{
+	"a.fe": `function total(items: Array<i32>) -> i32 {
+  var sum = 0;
+  for item in items {
+    if item > 0 {
+      sum = sum + item;
+    }
+  }
+  return sum;
+}
+`,
+	"lib/b.fe": `function count(items: Array<i32>) -> i32 {
+  var sum = 0;
+  // the same loop
+  for item in items {
+    if item > 0 { sum = sum + item; }
+  }
+  return sum;
+}
+
+function positive(xs: Array<i32>) -> i32 {
+  var acc = 0;
+  for x in xs {
+    if x > 10 {
+      acc = acc + x;
+    }
+  }
+  return acc;
+}
+`,
+}
+
+func detect(t *testing.T, min int, opts *clones.Options) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(name), 0o755)
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.Build(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := clones.Detect(context.Background(), idx, min, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, g := range groups {
+		fmt.Fprintf(&b, "%s %s %d:", g.Similarity, g.Kind, g.Nodes)
+		for _, c := range g.Clones {
+			fmt.Fprintf(&b, " %s:%d", c.Path, c.Range.StartPoint.Row+1)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		min  int
+		opts *clones.Options
+		want string
+	}{
+		{"exact", 10, nil,
+			"identical block 39: a.fe:1 lib/b.fe:1\n" +
+				"identical parameter_list 12: a.fe:1 lib/b.fe:1\n"},
+		{"renamed", 10, &clones.Options{Identifiers: true, Literals: true},
+			"renamed function_declaration 57: a.fe:1 lib/b.fe:1 lib/b.fe:10\n"},
+		{"large", 40, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := detect(t, tt.min, tt.opts); got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
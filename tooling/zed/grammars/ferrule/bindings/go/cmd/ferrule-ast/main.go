@@ -0,0 +1,288 @@
+// Command ferrule-ast parses a ferrule source file and prints its syntax
+// tree.
+//
+//	ferrule-ast [flags] [file]
+//	ferrule-ast -outline [-watch] [file | dir]
+//
+// Without a file it reads standard input. The flags are:
+//
+//	-f format      output format: sexpr (the default), json, or source,
+//	               which lists every node, anonymous tokens included, next
+//	               to its range and the text of the tokens
+//	-query file    print the captures of the matches of the queries in
+//	               file instead of the tree
+//	-point L:C     print the path of nodes from the root down to the
+//	               deepest node at line L, column C instead of the tree
+//	-dot           write the tree as a Graphviz graph instead, for
+//	               rendering with dot -Tsvg
+//	-outline       print the outline and syntax errors of the file, or of
+//	               every file of a directory, as JSON lines instead
+//	-watch         with -outline, go on printing those of the files as
+//	               they change, until interrupted
+//
+// Positions are one-based and columns count bytes, both in -point and in
+// the output, but for the ranges of -f json and -outline.
+//
+// The -outline output is meant for editors and other tools that want the
+// symbols and diagnostics of ferrule files without speaking the Language
+// Server Protocol. Every line is a JSON object, an event, whose event
+// field tells what it holds:
+//
+//	{"event":"outline","file":"app/main.fe","symbols":[...],"diagnostics":[...]}
+//	{"event":"removed","file":"app/old.fe"}
+//	{"event":"error","file":"app/main.fe","message":"..."}
+//
+// An outline event holds the symbols of package symbols, each with its
+// name, kind, detail, range, selectionRange and children, and the syntax
+// errors, each with its range, severity and message; either is left out
+// when there are none, which for diagnostics means those printed before
+// for the file are gone. Its ranges are those of -f json: byte offsets
+// and zero-based rows and byte columns. Files are named as given, or for
+// a directory as the directory joined with their path in it, which is
+// walked as by package index, honoring .gitignore and .ferruleignore
+// files. With -watch, an outline event is printed for each file when the
+// watch starts and again whenever it changes, and a removed event when it
+// is deleted; errors met while watching are error events and do not stop
+// it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+)
+
+var (
+	format    = flag.String("f", "sexpr", "output `format`: sexpr, json or source")
+	queryFile = flag.String("query", "", "print the matches of the queries in `file`")
+	point     = flag.String("point", "", "print the node path at `line:column`")
+	dot       = flag.Bool("dot", false, "write the tree in the Graphviz DOT language")
+	outline   = flag.Bool("outline", false, "print the outline and diagnostics as JSON lines")
+	watch     = flag.Bool("watch", false, "with -outline, print them again as files change")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-ast [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprintf(stderr, "usage: ferrule-ast [flags] [file]\n")
+		return 2
+	}
+	if *watch && (!*outline || len(args) == 0) {
+		fmt.Fprintf(stderr, "ferrule-ast: -watch needs -outline and a file or directory\n")
+		return 2
+	}
+	if *outline {
+		var src []byte
+		path := ""
+		if len(args) == 0 {
+			var err error
+			if src, err = io.ReadAll(stdin); err != nil {
+				fmt.Fprintf(stderr, "ferrule-ast: %v\n", err)
+				return 2
+			}
+		} else {
+			path = args[0]
+		}
+		if err := runOutline(ctx, path, src, stdout); err != nil {
+			fmt.Fprintf(stderr, "ferrule-ast: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	switch *format {
+	case "sexpr", "json", "source":
+	default:
+		fmt.Fprintf(stderr, "ferrule-ast: unknown format %q\n", *format)
+		return 2
+	}
+	var src []byte
+	var err error
+	if len(args) == 0 {
+		src, err = io.ReadAll(stdin)
+	} else {
+		src, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-ast: %v\n", err)
+		return 2
+	}
+	tree, err := ferrule.Parse(ctx, src)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-ast: %v\n", err)
+		return 2
+	}
+	defer tree.Close()
+
+	switch {
+	case *queryFile != "":
+		err = printMatches(stdout, tree, *queryFile)
+	case *point != "":
+		err = printPath(stdout, tree, *point)
+	case *dot:
+		_, err = io.WriteString(stdout, dump.DOT(tree))
+	case *format == "json":
+		var data []byte
+		if data, err = dump.JSON(tree.RootNode(), src); err == nil {
+			_, err = fmt.Fprintf(stdout, "%s\n", data)
+		}
+	case *format == "source":
+		err = printSource(stdout, tree)
+	default:
+		_, err = fmt.Fprintln(stdout, dump.SExpr(tree.RootNode()))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-ast: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// printMatches prints one line per capture: the match number, the capture
+// name, its range and kind, and its text.
+func printMatches(w io.Writer, tree *ferrule.Tree, name string) error {
+	text, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	q, err := query.New(string(text))
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer q.Close()
+	var b strings.Builder
+	for c, n := range q.Matches(tree.Root(), tree.Source()) {
+		fmt.Fprintf(&b, "%d: pattern %d @%s %s %s %s\n", c.Match, c.Pattern, c.Name, span(n.Raw()), n.Kind(), strconv.Quote(n.Text(tree.Source())))
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// printPath prints the nodes containing the position at s, outermost
+// first.
+func printPath(w io.Writer, tree *ferrule.Tree, s string) error {
+	p, err := parsePoint(s)
+	if err != nil {
+		return err
+	}
+	n := tree.RootNode().DescendantForPointRange(p, p)
+	var path []string
+	for ; n != nil; n = n.Parent() {
+		label := n.Kind()
+		if !n.IsNamed() {
+			label = strconv.Quote(label)
+		}
+		if parent := n.Parent(); parent != nil {
+			if f := fieldOf(parent, n); f != "" {
+				label = f + ": " + label
+			}
+		}
+		path = append(path, label+" "+span(n))
+	}
+	var b strings.Builder
+	for i := len(path) - 1; i >= 0; i-- {
+		b.WriteString(strings.Repeat("  ", len(path)-1-i))
+		b.WriteString(path[i])
+		b.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// printSource lists every node with its range, aligning the ranges in a
+// column, followed by the text of the tokens in another.
+func printSource(w io.Writer, tree *ferrule.Tree) error {
+	type line struct {
+		label, span, text string
+	}
+	var lines []line
+	var visit func(n *tree_sitter.Node, field string, depth int)
+	visit = func(n *tree_sitter.Node, field string, depth int) {
+		l := line{label: strings.Repeat("  ", depth), span: span(n)}
+		if field != "" {
+			l.label += field + ": "
+		}
+		switch {
+		case n.IsMissing():
+			l.label += "MISSING " + n.Kind()
+		case n.IsNamed():
+			l.label += n.Kind()
+		default:
+			l.label += strconv.Quote(n.Kind())
+		}
+		if n.ChildCount() == 0 && !n.IsMissing() {
+			l.text = strconv.Quote(n.Utf8Text(tree.Source()))
+		}
+		lines = append(lines, l)
+		for i := uint(0); i < n.ChildCount(); i++ {
+			visit(n.Child(i), n.FieldNameForChild(uint32(i)), depth+1)
+		}
+	}
+	visit(tree.RootNode(), "", 0)
+
+	width, spanWidth := 0, 0
+	for _, l := range lines {
+		width = max(width, len(l.label))
+		spanWidth = max(spanWidth, len(l.span))
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		if l.text == "" {
+			fmt.Fprintf(&b, "%-*s  %s\n", width, l.label, l.span)
+		} else {
+			fmt.Fprintf(&b, "%-*s  %-*s  %s\n", width, l.label, spanWidth, l.span, l.text)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fieldOf(parent, child *tree_sitter.Node) string {
+	for i := uint(0); i < parent.ChildCount(); i++ {
+		if c := parent.Child(i); c.Id() == child.Id() {
+			return parent.FieldNameForChild(uint32(i))
+		}
+	}
+	return ""
+}
+
+// span formats the range of n as one-based start-end positions.
+func span(n *tree_sitter.Node) string {
+	s, e := n.StartPosition(), n.EndPosition()
+	return fmt.Sprintf("%d:%d-%d:%d", s.Row+1, s.Column+1, e.Row+1, e.Column+1)
+}
+
+// parsePoint parses a one-based line:column into a zero-based point.
+func parsePoint(s string) (tree_sitter.Point, error) {
+	bad := fmt.Errorf("invalid position %q, want line:column", s)
+	l, c, ok := strings.Cut(s, ":")
+	if !ok {
+		return tree_sitter.Point{}, bad
+	}
+	line, err1 := strconv.ParseUint(l, 10, 32)
+	col, err2 := strconv.ParseUint(c, 10, 32)
+	if err1 != nil || err2 != nil || line == 0 || col == 0 {
+		return tree_sitter.Point{}, bad
+	}
+	return tree_sitter.Point{Row: uint(line - 1), Column: uint(col - 1)}, nil
+}
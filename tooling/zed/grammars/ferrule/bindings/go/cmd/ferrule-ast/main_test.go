@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+)
+
+const source = "package app;\nconst x = 1;\n"
+
+func TestSExpr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, "(source_file\n  (package_declaration\n") || !strings.Contains(got, "value: (integer_literal)))\n") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestSource(t *testing.T) {
+	*format = "source"
+	defer func() { *format = "sexpr" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if want := `    name: identifier        2:7-2:8    "x"`; lines[8] != want {
+		t.Errorf("line 9 is %q, want %q", lines[8], want)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	q := filepath.Join(t.TempDir(), "q.scm")
+	if err := os.WriteFile(q, []byte("(const_declaration name: (identifier) @name)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*queryFile = q
+	defer func() { *queryFile = "" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "0: pattern 0 @name 2:7-2:8 identifier \"x\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPoint(t *testing.T) {
+	*point = "2:11"
+	defer func() { *point = "" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	want := "source_file 1:1-3:1\n  const_declaration 2:1-2:13\n    value: integer_literal 2:11-2:12\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	*point = "2"
+	stderr.Reset()
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d for a bad position", code)
+	}
+}
+
+func TestDOT(t *testing.T) {
+	*dot = true
+	defer func() { *dot = false }()
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), nil, strings.NewReader(source), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); !strings.HasPrefix(got, "digraph tree {") || !strings.Contains(got, `[label="value"]`) {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestOutline(t *testing.T) {
+	*outline = true
+	defer func() { *outline = false }()
+	var stdout, stderr bytes.Buffer
+	src := "function scale(v: u32) -> u32 {\n  return v;\n}\nconst = 1;\n"
+	if code := run(context.Background(), nil, strings.NewReader(src), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var ev event
+	if err := json.Unmarshal(stdout.Bytes(), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Event != "outline" || len(ev.Symbols) != 1 || ev.Symbols[0].Name != "scale" || ev.Symbols[0].Kind != "function" ||
+		ev.Symbols[0].SelectionRange.Start != (dump.Point{Row: 0, Column: 9}) {
+		t.Errorf("event %+v", ev)
+	}
+	if len(ev.Diagnostics) == 0 || ev.Diagnostics[0].Severity != "error" || ev.Diagnostics[0].Range.Start.Row != 3 {
+		t.Errorf("diagnostics %+v", ev.Diagnostics)
+	}
+
+	*watch = true
+	defer func() { *watch = false }()
+	if code := run(context.Background(), nil, strings.NewReader(src), &stdout, &stderr); code != 2 {
+		t.Errorf("-watch of standard input: exit code %d", code)
+	}
+}
+
+// syncBuffer is a buffer written by a watch while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestOutlineWatch(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.fe", "function first() -> Unit {}\n")
+	write("b.fe", "const b = 1;\n")
+
+	*outline, *watch = true, true
+	defer func() { *outline, *watch = false, false }()
+	ctx, cancel := context.WithCancel(context.Background())
+	var stdout syncBuffer
+	var stderr bytes.Buffer
+	done := make(chan int)
+	go func() { done <- run(ctx, []string{dir}, nil, &stdout, &stderr) }()
+
+	// wait waits for the output to hold n events and returns them.
+	wait := func(n int) []event {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+			if len(lines) >= n && lines[0] != "" {
+				var out []event
+				for _, l := range lines {
+					var ev event
+					if err := json.Unmarshal([]byte(l), &ev); err != nil {
+						t.Fatalf("%v: %s", err, l)
+					}
+					out = append(out, ev)
+				}
+				return out
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("got %d events, want %d:\n%s", len(lines), n, stdout.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	describe := func(ev event) string {
+		s := ev.Event + " " + filepath.Base(ev.File)
+		for _, sym := range ev.Symbols {
+			s += " " + sym.Name
+		}
+		return s
+	}
+
+	events := wait(2)
+	if got := describe(events[0]) + "|" + describe(events[1]); got != "outline a.fe first|outline b.fe b" {
+		t.Errorf("first events %q", got)
+	}
+	write("a.fe", "function first() -> Unit {}\nfunction second() -> Unit {}\n")
+	if got := describe(wait(3)[2]); got != "outline a.fe first second" {
+		t.Errorf("event after a change %q", got)
+	}
+	if err := os.Remove(filepath.Join(dir, "b.fe")); err != nil {
+		t.Fatal(err)
+	}
+	if got := describe(wait(4)[3]); got != "removed b.fe" {
+		t.Errorf("event after a removal %q", got)
+	}
+
+	cancel()
+	if code := <-done; code != 0 {
+		t.Errorf("exit code %d: %s", code, stderr.String())
+	}
+}
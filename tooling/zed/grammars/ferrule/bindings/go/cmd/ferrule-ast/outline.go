@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// event is a line of -outline output.
+type event struct {
+	// Event is "outline" for the outline and diagnostics of a file,
+	// "removed" for a file deleted while watching and "error" for an
+	// error that did not stop the watch.
+	Event       string       `json:"event"`
+	File        string       `json:"file,omitempty"`
+	Symbols     []symbol     `json:"symbols,omitempty"`
+	Diagnostics []diagnostic `json:"diagnostics,omitempty"`
+	Message     string       `json:"message,omitempty"`
+}
+
+type symbol struct {
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`
+	Detail         string     `json:"detail,omitempty"`
+	Range          dump.Range `json:"range"`
+	SelectionRange dump.Range `json:"selectionRange"`
+	Children       []symbol   `json:"children,omitempty"`
+}
+
+type diagnostic struct {
+	Range    dump.Range `json:"range"`
+	Severity string     `json:"severity"`
+	Message  string     `json:"message"`
+}
+
+// emitter writes events, one JSON object per line, for the goroutines of
+// a watch.
+type emitter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+func (e *emitter) emit(ev event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil {
+		e.err = e.enc.Encode(ev)
+	}
+}
+
+// outlineEvent returns the outline event of src, the content of file.
+func outlineEvent(ctx context.Context, file string, src []byte) (event, error) {
+	tree, err := ferrule.Parse(ctx, charset.Decode(src).Source)
+	if err != nil {
+		return event{}, err
+	}
+	defer tree.Close()
+	ev := event{Event: "outline", File: file, Symbols: convert(symbols.Outline(tree))}
+	for _, d := range tree.Diagnostics() {
+		ev.Diagnostics = append(ev.Diagnostics, diagnostic{Range: rangeOf(d.Range), Severity: d.Severity.String(), Message: d.Message})
+	}
+	return ev, nil
+}
+
+func convert(syms []symbols.Symbol) []symbol {
+	var out []symbol
+	for _, s := range syms {
+		out = append(out, symbol{
+			Name:           s.Name,
+			Kind:           s.Kind.String(),
+			Detail:         s.Detail,
+			Range:          rangeOf(s.Range),
+			SelectionRange: rangeOf(s.SelectionRange),
+			Children:       convert(s.Children),
+		})
+	}
+	return out
+}
+
+func rangeOf(r tree_sitter.Range) dump.Range {
+	return dump.Range{
+		StartByte: r.StartByte,
+		EndByte:   r.EndByte,
+		Start:     dump.Point{Row: r.StartPoint.Row, Column: r.StartPoint.Column},
+		End:       dump.Point{Row: r.EndPoint.Row, Column: r.EndPoint.Column},
+	}
+}
+
+// runOutline writes the outline events of the file or directory at path,
+// or of src read from standard input if path is empty, and with -watch
+// goes on writing those of the files that change until ctx is done.
+func runOutline(ctx context.Context, path string, src []byte, stdout io.Writer) error {
+	e := &emitter{enc: json.NewEncoder(stdout)}
+	if path == "" {
+		ev, err := outlineEvent(ctx, "", src)
+		if err != nil {
+			return err
+		}
+		e.emit(ev)
+		return e.err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// a file is reported under the name given, and the files of a
+	// directory under the directory joined with their path in it.
+	dir, only := path, ""
+	if !info.IsDir() {
+		dir, only = filepath.Dir(path), filepath.Base(path)
+	}
+	name := func(rel string) string {
+		if only != "" {
+			return path
+		}
+		return filepath.Join(path, filepath.FromSlash(rel))
+	}
+	report := func(rel string) {
+		if only != "" && rel != only {
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			e.emit(event{Event: "removed", File: name(rel)})
+			return
+		case err != nil:
+			e.emit(event{Event: "error", File: name(rel), Message: err.Error()})
+			return
+		}
+		ev, err := outlineEvent(ctx, name(rel), data)
+		if err != nil {
+			e.emit(event{Event: "error", File: name(rel), Message: err.Error()})
+			return
+		}
+		e.emit(ev)
+	}
+
+	if !*watch {
+		if only != "" {
+			report(only)
+			return e.err
+		}
+		idx := index.New(dir)
+		if err := idx.Refresh(ctx); err != nil {
+			return err
+		}
+		for _, f := range idx.Files() {
+			report(f.Path)
+		}
+		return e.err
+	}
+
+	updates := make(chan []string, 1)
+	w, err := index.Watch(ctx, dir, &index.WatchOptions{
+		OnUpdate: func(paths []string) {
+			select {
+			case updates <- paths:
+			case <-ctx.Done():
+			}
+		},
+		OnError: func(err error) {
+			e.emit(event{Event: "error", Message: err.Error()})
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	if only != "" {
+		report(only)
+	} else {
+		for _, f := range w.Index().Files() {
+			report(f.Path)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return e.err
+		case paths := <-updates:
+			for _, p := range paths {
+				report(p)
+			}
+		}
+		if e.err != nil {
+			return e.err
+		}
+	}
+}
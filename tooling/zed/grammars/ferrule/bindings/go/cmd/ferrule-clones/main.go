@@ -0,0 +1,149 @@
+// Command ferrule-clones reports duplicated code in ferrule projects.
+//
+//	ferrule-clones [flags] [dir ...]
+//
+// Without arguments the current directory is searched. Directories are
+// walked as by package index, honoring .gitignore and .ferruleignore
+// files, and each is a project of its own: clones are not looked for
+// across them. The flags are:
+//
+//	-min n          report clones of at least n syntax nodes (default 40)
+//	-identifiers    count code that differs only in names as cloned
+//	-literals       count code that differs only in literal values as cloned
+//	-format f       output format: text (the default) or json
+//
+// The groups of clones are listed identical ones first, then those that
+// differ in the names or literals ignored, each largest first. The exit
+// status is 1 if any clones were found and 2 on errors.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/karol-broda/ferrule/bindings/go/clones"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+var (
+	minNodes    = flag.Int("min", 40, "report clones of at least `n` syntax nodes")
+	identifiers = flag.Bool("identifiers", false, "count code differing only in identifiers as cloned")
+	literals    = flag.Bool("literals", false, "count code differing only in literals as cloned")
+	format      = flag.String("format", "text", "output `format`: text or json")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-clones [flags] [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// group is the report of a group of clones.
+type group struct {
+	Kind       string  `json:"kind"`
+	Nodes      int     `json:"nodes"`
+	Similarity string  `json:"similarity"`
+	Clones     []clone `json:"clones"`
+
+	similarity clones.Similarity
+}
+
+// clone locates a member of a group, with one-based lines and columns.
+type clone struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+}
+
+func run(dirs []string, stdout, stderr io.Writer) int {
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "ferrule-clones: unknown format %q\n", *format)
+		return 2
+	}
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	opts := &clones.Options{Identifiers: *identifiers, Literals: *literals}
+	var groups []group
+	for _, dir := range dirs {
+		idx, err := index.Build(context.Background(), dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-clones: %v\n", err)
+			return 2
+		}
+		found, err := clones.Detect(context.Background(), idx, *minNodes, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-clones: %v\n", err)
+			return 2
+		}
+		for _, g := range found {
+			r := group{Kind: g.Kind, Nodes: g.Nodes, Similarity: g.Similarity.String(), similarity: g.Similarity}
+			for _, c := range g.Clones {
+				r.Clones = append(r.Clones, clone{
+					File:      filepath.Join(dir, filepath.FromSlash(c.Path)),
+					Line:      int(c.Range.StartPoint.Row) + 1,
+					Column:    int(c.Range.StartPoint.Column) + 1,
+					EndLine:   int(c.Range.EndPoint.Row) + 1,
+					EndColumn: int(c.Range.EndPoint.Column) + 1,
+				})
+			}
+			groups = append(groups, r)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].similarity != groups[j].similarity {
+			return groups[i].similarity < groups[j].similarity
+		}
+		return groups[i].Nodes > groups[j].Nodes
+	})
+
+	var err error
+	if *format == "json" {
+		err = writeJSON(stdout, groups)
+	} else {
+		err = writeText(stdout, groups)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-clones: %v\n", err)
+		return 2
+	}
+	if len(groups) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func writeJSON(w io.Writer, groups []group) error {
+	if groups == nil {
+		groups = []group{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(groups)
+}
+
+func writeText(w io.Writer, groups []group) error {
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%d %s clones of %s, %d nodes:\n", len(g.Clones), g.Similarity, g.Kind, g.Nodes)
+		for _, c := range g.Clones {
+			if _, err := fmt.Fprintf(w, "\t%s:%d:%d-%d:%d\n", c.File, c.Line, c.Column, c.EndLine, c.EndColumn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const loop = `(items: Array<i32>) -> i32 {
+  var sum = 0;
+  for item in items {
+    if item > 0 {
+      sum = sum + item;
+    }
+  }
+  return sum;
+}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.fe": "function total" + loop,
+		"b.fe": "function count" + strings.Replace(loop, "0;", "1;", 1),
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := setup(t)
+	defer func() { *minNodes, *identifiers, *literals, *format = 40, false, false, "text" }()
+
+	*minNodes = 20
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	want := "2 identical clones of for_statement, 26 nodes:\n" +
+		"\t" + filepath.Join(dir, "a.fe") + ":3:3-7:4\n" +
+		"\t" + filepath.Join(dir, "b.fe") + ":3:3-7:4\n"
+	if stdout.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout.String(), want)
+	}
+
+	*identifiers, *literals, *format = true, true, "json"
+	stdout.Reset()
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var groups []group
+	if err := json.Unmarshal(stdout.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Kind != "source_file" || groups[0].Similarity != "renamed" || len(groups[0].Clones) != 2 {
+		t.Errorf("groups %+v", groups)
+	}
+
+	*minNodes = 100
+	stdout.Reset()
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 || strings.TrimSpace(stdout.String()) != "[]" {
+		t.Errorf("exit code %d, output %q", code, stdout.String())
+	}
+}
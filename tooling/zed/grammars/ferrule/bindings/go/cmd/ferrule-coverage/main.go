@@ -0,0 +1,209 @@
+// Command ferrule-coverage reports which node kinds of the grammar a corpus
+// of ferrule code produces, so that constructs without tests show up
+// before a release.
+//
+//	ferrule-coverage [flags] [path ...]
+//
+// Each path is a file or a directory walked for files, skipping hidden
+// directories and what .gitignore and .ferruleignore files exclude as
+// package walk does. Files ending in
+// .fe are parsed whole; files ending in .txt are read as tree-sitter test
+// corpora, such as test/corpus, and the input of every test is parsed on
+// its own. Without arguments the current directory is walked. The flags
+// are:
+//
+//	-tokens    count anonymous tokens, such as keywords and operators,
+//	           besides named nodes
+//	-missing   list only the kinds that were never produced
+//	-min pct   exit with status 1 if less than pct percent of the kinds
+//	           were produced
+//
+// For every kind the report gives the number of nodes of it that were
+// produced and the percentage of inputs containing one. Nodes inside ERROR
+// nodes and missing nodes inserted by error recovery are not counted.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	tokens  = flag.Bool("tokens", false, "count anonymous tokens besides named nodes")
+	missing = flag.Bool("missing", false, "list only the kinds never produced")
+	minimum = flag.Float64("min", 0, "exit with status 1 if less than `pct` percent of the kinds were produced")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-coverage [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// usage counts how often a kind was produced.
+type usage struct {
+	nodes  int
+	inputs int
+}
+
+func run(paths []string, stdout, stderr io.Writer) int {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var inputs [][]byte
+	for _, path := range paths {
+		found, err := collect(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-coverage: %v\n", err)
+			return 2
+		}
+		inputs = append(inputs, found...)
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintf(stderr, "ferrule-coverage: no .fe or .txt files found\n")
+		return 2
+	}
+
+	kinds := grammarKinds(*tokens)
+	counts := make(map[string]*usage, len(kinds))
+	for _, k := range kinds {
+		counts[k] = &usage{}
+	}
+	for _, src := range inputs {
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-coverage: %v\n", err)
+			return 2
+		}
+		seen := map[string]bool{}
+		count(tree.RootNode(), func(k string) {
+			if u := counts[k]; u != nil {
+				u.nodes++
+				if !seen[k] {
+					seen[k] = true
+					u.inputs++
+				}
+			}
+		})
+		tree.Close()
+	}
+
+	covered := 0
+	for _, k := range kinds {
+		if counts[k].nodes > 0 {
+			covered++
+		}
+	}
+	pct := 100 * float64(covered) / float64(len(kinds))
+	fmt.Fprintf(stdout, "%d of %d kinds produced (%.1f%%) by %d inputs\n", covered, len(kinds), pct, len(inputs))
+
+	if *missing {
+		for _, k := range kinds {
+			if counts[k].nodes == 0 {
+				fmt.Fprintf(stdout, "%s\n", k)
+			}
+		}
+	} else {
+		w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "kind\tnodes\tinputs\n")
+		for _, k := range kinds {
+			u := counts[k]
+			fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", k, u.nodes, 100*float64(u.inputs)/float64(len(inputs)))
+		}
+		w.Flush()
+	}
+	if pct < *minimum {
+		fmt.Fprintf(stderr, "ferrule-coverage: coverage %.1f%% is below %.1f%%\n", pct, *minimum)
+		return 1
+	}
+	return 0
+}
+
+// grammarKinds returns the sorted visible node kinds of the grammar: the
+// named ones, and the anonymous ones too if tokens is set.
+func grammarKinds(tokens bool) []string {
+	lang := ferrule.Language()
+	set := map[string]bool{}
+	for id := uint16(0); uint32(id) < lang.NodeKindCount(); id++ {
+		if !lang.NodeKindIsVisible(id) || (!tokens && !lang.NodeKindIsNamed(id)) {
+			continue
+		}
+		if k := lang.NodeKindForId(id); k != "" && k != "ERROR" {
+			set[k] = true
+		}
+	}
+	kinds := make([]string, 0, len(set))
+	for k := range set {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// count calls f with the kind of every node below n, skipping ERROR nodes
+// with their contents and missing nodes.
+func count(n *tree_sitter.Node, f func(string)) {
+	if n.IsError() || n.IsMissing() {
+		return
+	}
+	f(n.Kind())
+	for i := uint(0); i < n.ChildCount(); i++ {
+		count(n.Child(i), f)
+	}
+}
+
+var (
+	header  = regexp.MustCompile(`(?m)^={3,}[^\n]*\n[^\n]*\n={3,}[^\n]*\n`)
+	divider = regexp.MustCompile(`(?m)^-{3,}[^\n]*$`)
+)
+
+// collect returns the inputs found at path: the contents of .fe files and
+// the test inputs of .txt corpus files.
+func collect(path string) ([][]byte, error) {
+	var inputs [][]byte
+	err := walk.WalkDir(nil, path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(name)
+		if ext != ".fe" && ext != ".txt" {
+			return nil
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if ext == ".fe" {
+			inputs = append(inputs, data)
+			return nil
+		}
+		for _, test := range header.Split(string(data), -1)[1:] {
+			inputs = append(inputs, []byte(divider.Split(test, 2)[0]))
+		}
+		return nil
+	})
+	return inputs, err
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const corpus = `==================
+Function
+==================
+
+function main() -> i32 {
+  return 0;
+}
+
+---
+
+(source_file)
+
+==================
+Constant
+==================
+
+const a = 1;
+
+---
+
+(source_file)
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"corpus/decls.txt": corpus,
+		"src/main.fe":      "const s = \"x\";\n",
+		"src/notes.md":     "function ignored() -> i32 {}\n",
+	}
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { *tokens, *missing, *minimum = false, false, 0 })
+	return dir
+}
+
+func TestReport(t *testing.T) {
+	dir := setup(t)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, " by 3 inputs\n") {
+		t.Errorf("want 3 inputs, got:\n%s", out)
+	}
+	for _, want := range []string{
+		"function_declaration      1      33.3%",
+		"const_declaration         2      66.7%",
+		"match_expression          0      0.0%",
+	} {
+		if !strings.Contains(out, "\n"+want+"\n") {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	dir := setup(t)
+	*missing = true
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")[1:]
+	has := map[string]bool{}
+	for _, l := range lines {
+		has[l] = true
+	}
+	if !has["match_expression"] || has["function_declaration"] || has["string_literal"] {
+		t.Errorf("wrong kinds never produced:\n%s", stdout.String())
+	}
+}
+
+func TestMinimum(t *testing.T) {
+	dir := setup(t)
+	*missing, *minimum = true, 90
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "is below 90.0%") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
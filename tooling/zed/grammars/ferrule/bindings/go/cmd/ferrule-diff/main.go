@@ -0,0 +1,70 @@
+// Command ferrule-diff compares two ferrule source files by their syntax
+// trees and prints the changes between them, ignoring changes of layout.
+//
+//	ferrule-diff [-q] old.fe new.fe
+//
+// Each change is printed on a line of its own, as described in
+// astdiff.Render. With -q nothing is printed, and comments are ignored as
+// well as layout, so that CI can tell formatting churn from changes of the
+// code. Like diff, the exit status is 0 if the files do not differ, 1 if
+// they do and 2 if an error occurred. The command can serve as a git
+// difftool:
+//
+//	git difftool -x ferrule-diff
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/karol-broda/ferrule/bindings/go/astdiff"
+)
+
+var quiet = flag.Bool("q", false, "only report whether the files differ other than in layout and comments")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-diff [-q] old.fe new.fe\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 2 {
+		fmt.Fprintf(stderr, "usage: ferrule-diff [-q] old.fe new.fe\n")
+		return 2
+	}
+	oldSrc, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-diff: %v\n", err)
+		return 2
+	}
+	newSrc, err := os.ReadFile(args[1])
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-diff: %v\n", err)
+		return 2
+	}
+	if *quiet {
+		if astdiff.Equivalent(oldSrc, newSrc) {
+			return 0
+		}
+		return 1
+	}
+	changes, err := astdiff.Diff(oldSrc, newSrc)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-diff: %v\n", err)
+		return 2
+	}
+	if err := astdiff.Render(stdout, changes); err != nil {
+		fmt.Fprintf(stderr, "ferrule-diff: %v\n", err)
+		return 2
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
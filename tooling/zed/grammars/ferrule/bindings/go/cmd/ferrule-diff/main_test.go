@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, name, src string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	a := write(t, "a.fe", "const x = 1;\n")
+	b := write(t, "b.fe", "const   x =\n  1;\n")
+	c := write(t, "c.fe", "const y = 1;\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{a, b}, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("layout change: exit code %d, output %q", code, stdout.String())
+	}
+	if code := run([]string{a, c}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if got, want := stdout.String(), "update identifier 1:7 → 1:7: \"x\" → \"y\"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if code := run([]string{a}, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	a := write(t, "a.fe", "const x = 1;\n")
+	b := write(t, "b.fe", "// the answer\nconst x = 1; // for now\n")
+	c := write(t, "c.fe", "const x = 2;\n")
+	*quiet = true
+	defer func() { *quiet = false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{a, b}, &stdout, &stderr); code != 0 {
+		t.Errorf("comment change: exit code %d, want 0", code)
+	}
+	if code := run([]string{a, c}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("output %q, want none", stdout.String())
+	}
+}
@@ -0,0 +1,135 @@
+// Command ferrule-doc generates API documentation for ferrule packages.
+//
+//	ferrule-doc [flags] [path ...]
+//
+// The .fe files found below the paths, or the current directory, but for
+// those .gitignore and .ferruleignore files exclude, are grouped by their
+// package declarations, and a Markdown page is rendered
+// for each package, as described in package doc. Files without a package
+// declaration are grouped by directory. Only declarations marked pub are
+// documented unless -all is given. The flags are:
+//
+//	-all     document declarations that are not marked pub as well
+//	-html    render HTML pages instead of Markdown
+//	-o dir   write one page per package into dir, named after the
+//	         package, instead of printing the pages to standard output
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	all    = flag.Bool("all", false, "document declarations not marked pub")
+	html   = flag.Bool("html", false, "render HTML instead of Markdown")
+	outDir = flag.String("o", "", "write one page per package into `dir`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-doc [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+func run(paths []string, stdout, stderr io.Writer) int {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	pkgs := make(map[string]*doc.Package)
+	for _, path := range paths {
+		err := walk.WalkDir(nil, path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (p != path && filepath.Ext(p) != ".fe") {
+				return nil
+			}
+			src, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			tree, err := ferrule.Parse(context.Background(), src)
+			if err != nil {
+				return err
+			}
+			defer tree.Close()
+			file := doc.Extract(tree)
+			file.Files = []string{p}
+			if file.Path == "" {
+				file.Path = filepath.ToSlash(filepath.Dir(p))
+			}
+			if pkg := pkgs[file.Path]; pkg != nil {
+				pkg.Add(file)
+			} else {
+				pkgs[file.Path] = file
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-doc: %v\n", err)
+			return 2
+		}
+	}
+
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	render, ext := doc.Markdown, ".md"
+	if *html {
+		render, ext = doc.HTML, ".html"
+	}
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			fmt.Fprintf(stderr, "ferrule-doc: %v\n", err)
+			return 2
+		}
+	}
+	for i, name := range names {
+		pkg := pkgs[name]
+		if !*all {
+			pkg = pkg.Exported()
+		}
+		if err := write(pkg, render, ext, i, stdout); err != nil {
+			fmt.Fprintf(stderr, "ferrule-doc: %v\n", err)
+			return 2
+		}
+	}
+	return 0
+}
+
+// write renders the i-th page, pkg, to its file or to stdout.
+func write(pkg *doc.Package, render func(io.Writer, *doc.Package) error, ext string, i int, stdout io.Writer) error {
+	if *outDir == "" {
+		if i > 0 {
+			if _, err := io.WriteString(stdout, "\n"); err != nil {
+				return err
+			}
+		}
+		return render(stdout, pkg)
+	}
+	f, err := os.Create(filepath.Join(*outDir, filepath.FromSlash(pkg.Path)+ext))
+	if err != nil {
+		return err
+	}
+	if err := render(f, pkg); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
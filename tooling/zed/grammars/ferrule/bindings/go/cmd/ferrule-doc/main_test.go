@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.fe": "// Package shapes draws.\npackage shapes;\n\n// Square squares.\npub function square(x: i32) -> i32 { return x * x; }\n",
+		"b.fe": "package shapes;\n\nfunction hidden() -> i32 { return 0; }\npub type Sides = u8;\n",
+		"c.fe": "package algebra;\n\npub function one() -> i32 { return 1; }\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if a, s := strings.Index(out, "# package algebra"), strings.Index(out, "# package shapes"); a < 0 || s < a {
+		t.Errorf("packages missing or out of order:\n%s", out)
+	}
+	for _, want := range []string{"Package shapes draws.", "## function square", "Square squares.", "## type Sides"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "hidden") {
+		t.Errorf("unexported function documented:\n%s", out)
+	}
+
+	out2 := filepath.Join(dir, "out")
+	*outDir, *html = out2, true
+	defer func() { *outDir, *html = "", false }()
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	page, err := os.ReadFile(filepath.Join(out2, "shapes.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(page, []byte("<h1>package shapes</h1>")) {
+		t.Errorf("page:\n%s", page)
+	}
+}
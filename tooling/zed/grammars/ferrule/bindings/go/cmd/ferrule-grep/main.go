@@ -0,0 +1,206 @@
+// Command ferrule-grep searches ferrule source files for code matching a
+// structural pattern.
+//
+//	ferrule-grep [flags] pattern [path ...]
+//
+// The pattern is ferrule code with metavariables, as described in package
+// pattern, for example 'match $x { $$$ }' or 'add($a, $a)'. With -q it is
+// a tree-sitter query instead, and every match of the query is reported.
+// Directories are searched recursively for .fe files, skipping what
+// .gitignore and .ferruleignore files exclude as package walk does;
+// without paths the current directory is searched.
+//
+// Each match is printed as file:line:column followed by the source line it
+// starts on. The flags are:
+//
+//	-q      the pattern is a tree-sitter query
+//	-json   print one JSON object per match, with its range, text and
+//	        the code bound to each metavariable or capture
+//
+// The exit status is 0 if there were matches, 1 if there were none and 2
+// if an error occurred.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/pattern"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	isQuery  = flag.Bool("q", false, "the pattern is a tree-sitter query")
+	jsonMode = flag.Bool("json", false, "print matches as JSON lines")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-grep [flags] pattern [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// result is one match, as printed by -json.
+type result struct {
+	Path     string            `json:"path"`
+	Start    position          `json:"start"`
+	End      position          `json:"end"`
+	Text     string            `json:"text"`
+	Captures map[string]string `json:"captures,omitempty"`
+}
+
+// position is a one-based line and byte column.
+type position struct {
+	Line   uint `json:"line"`
+	Column uint `json:"column"`
+}
+
+// searcher finds the matches in one parsed file.
+type searcher func(tree *ferrule.Tree) []result
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, "usage: ferrule-grep [flags] pattern [path ...]\n")
+		return 2
+	}
+	var search searcher
+	if *isQuery {
+		q, err := query.New(args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-grep: %v\n", err)
+			return 2
+		}
+		defer q.Close()
+		search = queryMatches(q)
+	} else {
+		p, err := pattern.Compile(args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-grep: %v\n", err)
+			return 2
+		}
+		defer p.Close()
+		search = patternMatches(p)
+	}
+	paths := args[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 1
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	for _, path := range paths {
+		err := walk.WalkDir(nil, path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (p != path && filepath.Ext(p) != ".fe") {
+				return nil
+			}
+			src, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			tree, err := ferrule.Parse(context.Background(), src)
+			if err != nil {
+				return err
+			}
+			defer tree.Close()
+			for _, r := range search(tree) {
+				if status == 1 {
+					status = 0
+				}
+				r.Path = p
+				if *jsonMode {
+					err = enc.Encode(r)
+				} else {
+					_, err = fmt.Fprintf(stdout, "%s:%d:%d: %s\n", p, r.Start.Line, r.Start.Column, line(src, r.Start.Line))
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-grep: %v\n", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+func patternMatches(p *pattern.Pattern) searcher {
+	return func(tree *ferrule.Tree) []result {
+		src := tree.Source()
+		var out []result
+		for _, m := range p.Find(tree) {
+			r := newResult(m.Node, src)
+			for name, b := range m.Bindings {
+				if r.Captures == nil {
+					r.Captures = make(map[string]string)
+				}
+				r.Captures[name] = b.Text(src)
+			}
+			out = append(out, r)
+		}
+		return out
+	}
+}
+
+// queryMatches reports each match of q at its first capture. Captures
+// whose names start with an underscore are left out of the result.
+func queryMatches(q *query.Query) searcher {
+	return func(tree *ferrule.Tree) []result {
+		src := tree.Source()
+		var out []result
+		last := -1
+		for c, n := range q.Matches(tree.Root(), src) {
+			if int(c.Match) != last {
+				last = int(c.Match)
+				out = append(out, newResult(n.Raw(), src))
+			}
+			if strings.HasPrefix(c.Name, "_") {
+				continue
+			}
+			r := &out[len(out)-1]
+			if r.Captures == nil {
+				r.Captures = make(map[string]string)
+			}
+			r.Captures[c.Name] = n.Text(src)
+		}
+		return out
+	}
+}
+
+func newResult(n *tree_sitter.Node, src []byte) result {
+	s, e := n.StartPosition(), n.EndPosition()
+	return result{
+		Start: position{Line: s.Row + 1, Column: s.Column + 1},
+		End:   position{Line: e.Row + 1, Column: e.Column + 1},
+		Text:  n.Utf8Text(src),
+	}
+}
+
+// line returns the one-based line n of src.
+func line(src []byte, n uint) string {
+	lines := strings.SplitN(string(src), "\n", int(n)+1)
+	if int(n) > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[n-1], " \t\r")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := "function main() -> i32 {\n  const a = add(1, 1);\n  return add(\n    a,\n    a\n  );\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "main.fe"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("add(a, a)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestPattern(t *testing.T) {
+	dir := setup(t)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"add($x, $x)", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	name := filepath.Join(dir, "main.fe")
+	want := name + ":2:13:   const a = add(1, 1);\n" + name + ":3:10:   return add(\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	dir := setup(t)
+	*jsonMode = true
+	defer func() { *jsonMode = false }()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"return add($x, $y);", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var r result
+	if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+		t.Fatal(err)
+	}
+	want := result{
+		Path:     filepath.Join(dir, "main.fe"),
+		Start:    position{Line: 3, Column: 3},
+		End:      position{Line: 6, Column: 5},
+		Text:     "return add(\n    a,\n    a\n  );",
+		Captures: map[string]string{"x": "a", "y": "a"},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("got %+v, want %+v", r, want)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	dir := setup(t)
+	*isQuery = true
+	defer func() { *isQuery = false }()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"(return_statement) @ret", dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); !strings.HasSuffix(got, "main.fe:3:3:   return add(\n") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNoMatch(t *testing.T) {
+	dir := setup(t)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"sub($x, $y)", dir}, &stdout, &stderr); code != 1 || stdout.Len() != 0 {
+		t.Errorf("exit code %d, output %q", code, stdout.String())
+	}
+	if code := run([]string{"add(", dir}, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d for a bad pattern", code)
+	}
+}
@@ -0,0 +1,107 @@
+// Command ferrule-highlight prints ferrule source with syntax highlighting.
+//
+//	ferrule-highlight [flags] [file ...]
+//
+// Without files it reads standard input. The flags are:
+//
+//	-f format   output format: ansi (the default) or html
+//	-standalone with -f html, write a complete HTML document that embeds
+//	            the stylesheet
+//	-css        write the stylesheet for the HTML classes and exit
+//	-prefix p   prefix of the HTML class names (default "fe-")
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+)
+
+var (
+	format     = flag.String("f", "ansi", "output `format`: ansi or html")
+	standalone = flag.Bool("standalone", false, "write a complete HTML document")
+	css        = flag.Bool("css", false, "write the stylesheet and exit")
+	prefix     = flag.String("prefix", highlight.DefaultClassPrefix, "`prefix` of HTML class names")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-highlight [flags] [file ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(paths []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if *css {
+		if err := highlight.CSS(stdout, highlight.DefaultTheme, *prefix); err != nil {
+			fmt.Fprintf(stderr, "ferrule-highlight: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if *format != "ansi" && *format != "html" {
+		fmt.Fprintf(stderr, "ferrule-highlight: unknown format %q\n", *format)
+		return 2
+	}
+
+	if *format == "html" && *standalone {
+		fmt.Fprint(stdout, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<style>\n")
+		highlight.CSS(stdout, highlight.DefaultTheme, *prefix)
+		fmt.Fprint(stdout, "</style>\n</head>\n<body>\n")
+	}
+	status := 0
+	if len(paths) == 0 {
+		src, err := io.ReadAll(stdin)
+		if err == nil {
+			err = render("", src, stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-highlight: %v\n", err)
+			status = 2
+		}
+	}
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err == nil {
+			err = render(path, src, stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-highlight: %v\n", err)
+			status = 2
+		}
+	}
+	if *format == "html" && *standalone {
+		fmt.Fprint(stdout, "</body>\n</html>\n")
+	}
+	return status
+}
+
+func render(name string, src []byte, w io.Writer) error {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return err
+	}
+	defer tree.Close()
+	spans := highlight.Spans(tree)
+	if *format == "ansi" {
+		return highlight.ANSI(w, spans, src, highlight.DefaultTheme)
+	}
+	if name != "" {
+		fmt.Fprintf(w, "<pre class=\"ferrule\" title=\"%s\"><code>", html.EscapeString(name))
+	} else {
+		fmt.Fprint(w, "<pre class=\"ferrule\"><code>")
+	}
+	if err := highlight.HTML(w, spans, src, *prefix); err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, "</code></pre>\n")
+	return err
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestANSI(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("const x = 1;\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); !strings.Contains(got, "\x1b[") || !strings.Contains(got, "x") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStandaloneHTML(t *testing.T) {
+	*format, *standalone = "html", true
+	defer func() { *format, *standalone = "ansi", false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("const x = 1;\n"), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got := stdout.String()
+	for _, want := range []string{"<!DOCTYPE html>", ".fe-keyword {", `<pre class="ferrule"><code>`, `<span class="fe-number">1</span>`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestBadFormat(t *testing.T) {
+	*format = "svg"
+	defer func() { *format = "ansi" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
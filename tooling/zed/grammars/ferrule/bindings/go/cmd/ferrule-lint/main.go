@@ -0,0 +1,73 @@
+// Command ferrule-lint reports likely mistakes in ferrule source.
+//
+//	ferrule-lint [flags] path ...
+//
+// It runs the built-in analyzers:
+//
+//	deadcode       private functions, bindings and match arms never used
+//	deprecated     uses of declarations marked ferrule:deprecated
+//	examplefmt     examples in doc comments that are not formatted
+//	examples       examples in doc comments that do not parse
+//	matchcheck     match arms duplicating others and matches missing cases
+//	newline        line endings and byte order marks the formatter would change
+//	shadow         bindings that shadow an enclosing local
+//	spelling       misspelled words in names, comments and strings (optional)
+//	stalesuppress  ferrule:disable comments that suppress nothing
+//	unreachable    match arms that can never be selected
+//	unused         local bindings that are never used
+//
+// deadcode looks across the files of a package and covers what unused and
+// unreachable find as well; findings they share are reported once.
+// deprecated looks across the project as well, for the declarations the
+// names it checks refer to.
+// examplefmt reports at information severity, so stale formatting of
+// examples stands apart from examples that no longer parse.
+//
+// A comment // ferrule:disable name [reason] silences an analyzer on the
+// line it ends, or in the declaration or statement it leads; see
+// analysis.Suppression.
+//
+// Each can be turned off with a flag of its name, e.g. -shadow=false, and
+// -fix applies the suggested fixes in place. The [lint] table of the
+// project's ferrule.toml can turn them off or change their severity too,
+// and its ignore patterns exclude files. Optional analyzers run only when
+// turned on, with -spelling or spelling = true in ferrule.toml, and
+// -spelling.words file adds to the list of misspellings. Markdown files
+// named on the command line have the syntax of their ferrule examples
+// checked.
+//
+// For editors and pre-commit hooks, -stdin-filepath path lints standard
+// input as the file at path, and -batch lints many files sent over
+// standard input as NUL-delimited records of a path and a source; see
+// package multichecker. With -diff file, only findings on the lines the
+// unified diff in file changes are reported, so that a code base can take
+// the linter up one change at a time:
+//
+//	git diff -U0 main | ferrule-lint -diff - .
+//
+// -format sarif prints the findings as a SARIF log for GitHub code
+// scanning, -format checkstyle as checkstyle XML for Jenkins and -format
+// json as a JSON array. -format github prints workflow commands that
+// annotate the pull request a GitHub Actions job checks, and -format
+// rdjson feeds reviewdog:
+//
+//	ferrule-lint -format rdjson . | reviewdog -f=rdjson -reporter=github-pr-review
+//
+// To take the linter up in a code base with many findings, record them
+// once and report only new ones from then on:
+//
+//	ferrule-lint -baseline baseline.json -write-baseline .
+//	ferrule-lint -baseline baseline.json .
+//
+// To add rules of your own, write a main package that passes them
+// together with these to multichecker.Main.
+package main
+
+import (
+	"github.com/karol-broda/ferrule/bindings/go/analysis/multichecker"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes"
+)
+
+func main() {
+	multichecker.Main(passes.All...)
+}
@@ -0,0 +1,110 @@
+package main
+
+import "container/list"
+
+// defaultRetainedBytes is the default capacity of the retained closed
+// documents: the trees of 16 MiB of source.
+const defaultRetainedBytes = 16 << 20
+
+// documents holds the parse trees of the session: those of the open
+// documents, and those of recently closed ones, kept in case they are
+// opened again unchanged, as editors do when switching between files. The
+// closed documents are dropped, least recently closed first, when their
+// sources add up to more than maxBytes; open documents are never dropped.
+//
+// Every tree is owned by exactly one document, which closes it when it is
+// replaced or dropped.
+type documents struct {
+	open map[string]*document
+	// closed holds the retained closed documents, most recently closed
+	// first; byURI indexes it.
+	closed   list.List // of *closedDocument
+	byURI    map[string]*list.Element
+	bytes    int64
+	maxBytes int64
+}
+
+type closedDocument struct {
+	uri string
+	doc *document
+}
+
+func newDocuments(maxBytes int64) *documents {
+	if maxBytes < 0 {
+		maxBytes = defaultRetainedBytes
+	}
+	return &documents{open: make(map[string]*document), byURI: make(map[string]*list.Element), maxBytes: maxBytes}
+}
+
+// get returns the open document at uri.
+func (d *documents) get(uri string) (*document, bool) {
+	doc, ok := d.open[uri]
+	return doc, ok
+}
+
+// add opens doc at uri, closing the tree of a document already open
+// there.
+func (d *documents) add(uri string, doc *document) {
+	if old, ok := d.open[uri]; ok && old != doc {
+		old.tree.Close()
+	}
+	d.open[uri] = doc
+}
+
+// reopen returns the retained closed document at uri, open again, if its
+// text is text.
+func (d *documents) reopen(uri, text string) (*document, bool) {
+	e, ok := d.byURI[uri]
+	if !ok {
+		return nil, false
+	}
+	doc := d.drop(e)
+	if string(doc.tree.Source()) != text {
+		doc.tree.Close()
+		return nil, false
+	}
+	d.add(uri, doc)
+	return doc, true
+}
+
+// close closes the open document at uri and retains its tree, dropping
+// the oldest closed documents past the capacity.
+func (d *documents) close(uri string) {
+	doc, ok := d.open[uri]
+	if !ok {
+		return
+	}
+	delete(d.open, uri)
+	if e, ok := d.byURI[uri]; ok {
+		d.drop(e).tree.Close()
+	}
+	doc.tokens, doc.tokensID, doc.lint = nil, "", nil
+	d.byURI[uri] = d.closed.PushFront(&closedDocument{uri: uri, doc: doc})
+	d.bytes += int64(len(doc.tree.Source()))
+	for d.bytes > d.maxBytes {
+		d.drop(d.closed.Back()).tree.Close()
+	}
+}
+
+// drop removes the closed document e and returns it.
+func (d *documents) drop(e *list.Element) *document {
+	c := d.closed.Remove(e).(*closedDocument)
+	delete(d.byURI, c.uri)
+	d.bytes -= int64(len(c.doc.tree.Source()))
+	return c.doc
+}
+
+// retained returns the number of closed documents retained and the size of
+// their sources.
+func (d *documents) retained() (int, int64) { return d.closed.Len(), d.bytes }
+
+// closeAll closes every tree, open or retained.
+func (d *documents) closeAll() {
+	for uri, doc := range d.open {
+		doc.tree.Close()
+		delete(d.open, uri)
+	}
+	for d.closed.Len() > 0 {
+		d.drop(d.closed.Front()).tree.Close()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestDocuments(t *testing.T) {
+	open := func(d *documents, uri, text string) *document {
+		t.Helper()
+		tree, err := ferrule.Parse(context.Background(), []byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := &document{tree: tree}
+		d.add(uri, doc)
+		return doc
+	}
+	a := "const a = 1;\n"
+	b := "const b = 22;\n"
+	d := newDocuments(int64(len(a) + len(b)))
+	defer d.closeAll()
+
+	docA := open(d, "a", a)
+	open(d, "b", b)
+	d.close("a")
+	d.close("b")
+	if n, size := d.retained(); n != 2 || size != int64(len(a)+len(b)) {
+		t.Fatalf("retained %d documents of %d bytes", n, size)
+	}
+	if _, ok := d.get("a"); ok {
+		t.Error("closed document is open")
+	}
+	if doc, ok := d.reopen("a", a); !ok || doc != docA {
+		t.Error("unchanged document was not reopened")
+	}
+	if _, ok := d.reopen("b", "const b = 3;\n"); ok {
+		t.Error("changed document was reopened")
+	}
+	if n, _ := d.retained(); n != 0 {
+		t.Errorf("retained %d documents after reopening", n)
+	}
+
+	// past the capacity, the least recently closed go first.
+	d.close("a")
+	open(d, "c", "const c = 333;\n")
+	d.close("c")
+	if _, ok := d.byURI["a"]; ok {
+		t.Error("a was kept past the capacity")
+	}
+	if n, size := d.retained(); n != 1 || size != int64(len("const c = 333;\n")) {
+		t.Errorf("retained %d documents of %d bytes", n, size)
+	}
+}
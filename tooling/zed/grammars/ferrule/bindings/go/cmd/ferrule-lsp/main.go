@@ -0,0 +1,56 @@
+// Command ferrule-lsp is a language server for ferrule.
+//
+// It speaks the Language Server Protocol over standard input and output
+// and is meant to be started by an editor:
+//
+//	ferrule-lsp [--stdio]
+//
+// The --stdio flag is accepted for clients that pass it and changes
+// nothing.
+//
+// Documents are synchronized incrementally and reparsed with tree-sitter
+// on every change. The trees of closed documents are kept, up to 16 MiB of
+// source or the retainedBytes of the client's initialization options, so
+// that opening them again unchanged needs no parse. The server publishes syntax errors and the diagnostics
+// of the ferrule-lint analyzers, uses of deprecated code tagged so that
+// editors strike them through, and answers requests for document
+// symbols, folding and selection ranges, semantic tokens (full, delta and range),
+// whole-document and range formatting, indentation of new lines and
+// closing brackets as they are typed and closing of brackets left open
+// by a new line, rename of local names, and code
+// actions: the suggested fixes of lint diagnostics, extracting constants
+// and functions, inlining local bindings, adding missing match arms and
+// organizing imports; see package codeaction. Positions are exchanged in UTF-16 code units.
+//
+// The workspace folders the client opens are indexed on initialization,
+// each starting from the index cached under its .ferrule-cache directory,
+// which is written back on shutdown. Open documents are reindexed as they
+// change, and the indexes read their unsaved text in place of the saved
+// one; see package vfs. The indexes answer workspace symbol searches,
+// ranked as by index.SearchSymbols, call hierarchy requests, see package
+// hierarchy, hovers, which describe the symbol under the cursor as package
+// hover does, completions, see package complete, signature help, see
+// package signature, and the reference counts of code lenses; a document belongs
+// to the innermost folder holding it. Lenses also mark the entry points
+// and tests of a program, named as the [codelens] table of ferrule.toml
+// says, with the commands ferrule.showReferences, ferrule.run and
+// ferrule.test for the client to carry out; see package codelens.
+//
+// Formatting and linting follow the ferrule.toml found from the directory
+// of each file:// document when it is opened; see package config.
+// Generated documents are neither formatted nor analyzed, unless it says
+// otherwise; their syntax errors are still published.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) > 2 || len(os.Args) == 2 && os.Args[1] != "--stdio" {
+		fmt.Fprintln(os.Stderr, "usage: ferrule-lsp [--stdio]")
+		os.Exit(2)
+	}
+	os.Exit(serve(os.Stdin, os.Stdout, os.Stderr))
+}
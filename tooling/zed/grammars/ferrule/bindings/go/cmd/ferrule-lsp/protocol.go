@@ -0,0 +1,245 @@
+package main
+
+import (
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/hierarchy"
+	"github.com/karol-broda/ferrule/bindings/go/semantictokens"
+)
+
+// The subset of the protocol types the server reads and writes. Positions
+// and ranges are those of package edits, which share the protocol's JSON
+// form.
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int32  `json:"version"`
+	Text       string `json:"text"`
+}
+
+type versionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int32  `json:"version"`
+}
+
+type initializeParams struct {
+	InitializationOptions struct {
+		// RetainedBytes caps the sources of the closed documents whose
+		// trees are kept.
+		RetainedBytes *int64 `json:"retainedBytes"`
+	} `json:"initializationOptions"`
+	RootURI          string `json:"rootUri"`
+	WorkspaceFolders []struct {
+		URI string `json:"uri"`
+	} `json:"workspaceFolders"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   versionedTextDocumentIdentifier `json:"textDocument"`
+	ContentChanges []edits.Change                  `json:"contentChanges"`
+}
+
+type documentParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type positionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     edits.Position         `json:"position"`
+}
+
+type rangeParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Range        edits.Range            `json:"range"`
+}
+
+type renameParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     edits.Position         `json:"position"`
+	NewName      string                 `json:"newName"`
+}
+
+type codeActionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Range        edits.Range            `json:"range"`
+	Context      struct {
+		Only []string `json:"only"`
+	} `json:"context"`
+}
+
+type onTypeFormattingParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     edits.Position         `json:"position"`
+	Ch           string                 `json:"ch"`
+}
+
+type selectionRangeParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Positions    []edits.Position       `json:"positions"`
+}
+
+type selectionRange struct {
+	Range  edits.Range     `json:"range"`
+	Parent *selectionRange `json:"parent,omitempty"`
+}
+
+type semanticTokensDeltaParams struct {
+	TextDocument     textDocumentIdentifier `json:"textDocument"`
+	PreviousResultID string                 `json:"previousResultId"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     *int32       `json:"version,omitempty"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type diagnostic struct {
+	Range    edits.Range `json:"range"`
+	Severity int         `json:"severity"`
+	Code     string      `json:"code,omitempty"`
+	Source   string      `json:"source"`
+	Message  string      `json:"message"`
+	Tags     []int       `json:"tags,omitempty"`
+}
+
+// diagnosticTagDeprecated is the DiagnosticTag of uses of deprecated
+// code, which clients strike through.
+const diagnosticTagDeprecated = 2
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          edits.Range      `json:"range"`
+	SelectionRange edits.Range      `json:"selectionRange"`
+	Children       []documentSymbol `json:"children,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hoverResult struct {
+	Contents markupContent `json:"contents"`
+	Range    *edits.Range  `json:"range,omitempty"`
+}
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type signatureHelp struct {
+	Signatures      []signatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+type signatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation *markupContent         `json:"documentation,omitempty"`
+	Parameters    []parameterInformation `json:"parameters"`
+}
+
+type parameterInformation struct {
+	Label         string `json:"label"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+type textEdit struct {
+	Range   edits.Range `json:"range"`
+	NewText string      `json:"newText"`
+}
+
+type workspaceEdit struct {
+	Changes map[string][]textEdit `json:"changes"`
+}
+
+type codeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind"`
+	Diagnostics []diagnostic   `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *workspaceEdit `json:"edit,omitempty"`
+}
+
+// codeLensItem is a code lens, named so as not to clash with package
+// codelens.
+type codeLensItem struct {
+	Range   edits.Range `json:"range"`
+	Command command     `json:"command"`
+}
+
+type command struct {
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+type workspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+type location struct {
+	URI   string      `json:"uri"`
+	Range edits.Range `json:"range"`
+}
+
+type symbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+type callHierarchyItem struct {
+	Name           string            `json:"name"`
+	Kind           int               `json:"kind"`
+	Detail         string            `json:"detail,omitempty"`
+	URI            string            `json:"uri"`
+	Range          edits.Range       `json:"range"`
+	SelectionRange edits.Range       `json:"selectionRange"`
+	Data           callHierarchyData `json:"data"`
+}
+
+// callHierarchyData is the data of a call hierarchy item: the function and
+// the workspace folder whose index it is from.
+type callHierarchyData struct {
+	Root string `json:"root"`
+	hierarchy.Item
+}
+
+type callHierarchyParams struct {
+	Item callHierarchyItem `json:"item"`
+}
+
+type incomingCall struct {
+	From       callHierarchyItem `json:"from"`
+	FromRanges []edits.Range     `json:"fromRanges"`
+}
+
+type outgoingCall struct {
+	To         callHierarchyItem `json:"to"`
+	FromRanges []edits.Range     `json:"fromRanges"`
+}
+
+type semanticTokens struct {
+	ResultID string   `json:"resultId,omitempty"`
+	Data     []uint32 `json:"data"`
+}
+
+type semanticTokensDelta struct {
+	ResultID string                `json:"resultId"`
+	Edits    []semantictokens.Edit `json:"edits"`
+}
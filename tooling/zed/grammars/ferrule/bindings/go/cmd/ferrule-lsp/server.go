@@ -0,0 +1,1008 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes"
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/codeaction"
+	"github.com/karol-broda/ferrule/bindings/go/codelens"
+	"github.com/karol-broda/ferrule/bindings/go/complete"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/folding"
+	"github.com/karol-broda/ferrule/bindings/go/hierarchy"
+	"github.com/karol-broda/ferrule/bindings/go/hover"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/internal/jsonrpc"
+	"github.com/karol-broda/ferrule/bindings/go/ontype"
+	"github.com/karol-broda/ferrule/bindings/go/position"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+	"github.com/karol-broda/ferrule/bindings/go/selection"
+	"github.com/karol-broda/ferrule/bindings/go/semantictokens"
+	"github.com/karol-broda/ferrule/bindings/go/signature"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// The error codes of the Language Server Protocol beyond those of
+// JSON-RPC.
+const (
+	codeServerNotInitialized = -32002
+	codeRequestFailed        = -32803
+)
+
+// errExit stops the server loop once the client sends exit.
+var errExit = errors.New("exit")
+
+// server holds the state of one client session. Messages are handled one
+// at a time, in the order they arrive.
+type server struct {
+	conn   *jsonrpc.Conn
+	parser *ferrule.Parser
+	docs   *documents
+	// roots holds the indexes of the workspace folders, none when the
+	// client opened no folder. Open documents are indexed as edited.
+	roots []*index.Index
+	// files is what the indexes read: the disk, with the text of the open
+	// documents in place of the saved one.
+	files *vfs.Overlay
+	// lastResult numbers semantic token results.
+	lastResult  int
+	initialized bool
+	shutdown    bool
+}
+
+// document is an open text document.
+type document struct {
+	version int32
+	tree    *ferrule.Tree
+	// lines is the index of the tree's source, which converts the ranges
+	// of its diagnostics, symbols and semantic tokens.
+	lines *position.Index
+	// cfg is the configuration of the project the document is in, and
+	// analyzers the analyzers it leaves on.
+	cfg       *config.Config
+	analyzers []*analysis.Analyzer
+	// lint holds the diagnostics of the analyzers on the current tree,
+	// whose fixes code actions offer.
+	lint []analysis.Diagnostic
+	// tokens and tokensID are the last semantic tokens sent for the
+	// document, for delta requests.
+	tokens   []uint32
+	tokensID string
+}
+
+// serve runs the server loop until the client sends exit or closes the
+// stream. It returns the process exit code the protocol prescribes: 0 if
+// shutdown was requested before exit, 1 otherwise.
+func serve(r io.Reader, w io.Writer, log io.Writer) int {
+	parser, err := ferrule.NewParser()
+	if err != nil {
+		fmt.Fprintf(log, "ferrule-lsp: %v\n", err)
+		return 1
+	}
+	defer parser.Close()
+	s := &server{conn: jsonrpc.NewConn(r, w), parser: parser, docs: newDocuments(-1), files: vfs.NewOverlay(vfs.OS)}
+	s.conn.FailureCode = codeRequestFailed
+	defer s.docs.closeAll()
+
+	for {
+		body, err := s.conn.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(log, "ferrule-lsp: %v\n", err)
+			}
+			return 1
+		}
+		var req jsonrpc.Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.conn.Reply(json.RawMessage("null"), nil, &jsonrpc.Error{Code: jsonrpc.ParseError, Message: err.Error()})
+			continue
+		}
+		result, err := s.handle(&req)
+		if errors.Is(err, errExit) {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		if req.ID == nil {
+			if err != nil {
+				fmt.Fprintf(log, "ferrule-lsp: %s: %v\n", req.Method, err)
+			}
+			continue
+		}
+		if err := s.conn.Reply(req.ID, result, err); err != nil {
+			fmt.Fprintf(log, "ferrule-lsp: %v\n", err)
+			return 1
+		}
+	}
+}
+
+func (s *server) handle(req *jsonrpc.Request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		var p initializeParams
+		return decode(req, &p, func() (any, error) { return s.initialize(p) })
+	case "exit":
+		return nil, errExit
+	}
+	if !s.initialized {
+		return nil, &jsonrpc.Error{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+	if s.shutdown {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch req.Method {
+	case "initialized", "textDocument/didSave", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		for _, idx := range s.roots {
+			// a cache that cannot be written is only slower to start.
+			idx.WriteCache()
+		}
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		return decode(req, &p, func() (any, error) { return nil, s.didOpen(p) })
+	case "textDocument/didChange":
+		var p didChangeParams
+		return decode(req, &p, func() (any, error) { return nil, s.didChange(p) })
+	case "textDocument/didClose":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return nil, s.didClose(p) })
+	case "textDocument/documentSymbol":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return s.documentSymbol(p) })
+	case "textDocument/foldingRange":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return s.foldingRange(p) })
+	case "textDocument/selectionRange":
+		var p selectionRangeParams
+		return decode(req, &p, func() (any, error) { return s.selectionRange(p) })
+	case "textDocument/semanticTokens/full":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return s.semanticTokensFull(p) })
+	case "textDocument/semanticTokens/full/delta":
+		var p semanticTokensDeltaParams
+		return decode(req, &p, func() (any, error) { return s.semanticTokensDelta(p) })
+	case "textDocument/semanticTokens/range":
+		var p rangeParams
+		return decode(req, &p, func() (any, error) { return s.semanticTokensRange(p) })
+	case "textDocument/formatting":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return s.formatting(p) })
+	case "textDocument/rangeFormatting":
+		var p rangeParams
+		return decode(req, &p, func() (any, error) { return s.rangeFormatting(p) })
+	case "textDocument/onTypeFormatting":
+		var p onTypeFormattingParams
+		return decode(req, &p, func() (any, error) { return s.onTypeFormatting(p) })
+	case "textDocument/hover":
+		var p positionParams
+		return decode(req, &p, func() (any, error) { return s.hover(p) })
+	case "textDocument/completion":
+		var p positionParams
+		return decode(req, &p, func() (any, error) { return s.completion(p) })
+	case "textDocument/signatureHelp":
+		var p positionParams
+		return decode(req, &p, func() (any, error) { return s.signatureHelp(p) })
+	case "textDocument/prepareRename":
+		var p positionParams
+		return decode(req, &p, func() (any, error) { return s.prepareRename(p) })
+	case "textDocument/rename":
+		var p renameParams
+		return decode(req, &p, func() (any, error) { return s.rename(p) })
+	case "textDocument/codeAction":
+		var p codeActionParams
+		return decode(req, &p, func() (any, error) { return s.codeAction(p) })
+	case "textDocument/codeLens":
+		var p documentParams
+		return decode(req, &p, func() (any, error) { return s.codeLens(p) })
+	case "workspace/symbol":
+		var p workspaceSymbolParams
+		return decode(req, &p, func() (any, error) { return s.workspaceSymbol(p) })
+	case "textDocument/prepareCallHierarchy":
+		var p positionParams
+		return decode(req, &p, func() (any, error) { return s.prepareCallHierarchy(p) })
+	case "callHierarchy/incomingCalls":
+		var p callHierarchyParams
+		return decode(req, &p, func() (any, error) { return s.incomingCalls(p) })
+	case "callHierarchy/outgoingCalls":
+		var p callHierarchyParams
+		return decode(req, &p, func() (any, error) { return s.outgoingCalls(p) })
+	}
+	return nil, &jsonrpc.Error{Code: jsonrpc.MethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// decode unmarshals the request parameters into p and calls f.
+func decode(req *jsonrpc.Request, p any, f func() (any, error)) (any, error) {
+	if err := json.Unmarshal(req.Params, p); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: err.Error()}
+	}
+	return f()
+}
+
+func (s *server) capabilities() any {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"positionEncoding": "utf-16",
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				// incremental
+				"change": 2,
+			},
+			"documentSymbolProvider": true,
+			"hoverProvider":          true,
+			"completionProvider":     map[string]any{"resolveProvider": false},
+			"signatureHelpProvider":  map[string]any{"triggerCharacters": []string{"(", ","}},
+			"foldingRangeProvider":   true,
+			"selectionRangeProvider": true,
+			"semanticTokensProvider": map[string]any{
+				"legend": semantictokens.Legend,
+				"full":   map[string]any{"delta": true},
+				"range":  true,
+			},
+			"documentFormattingProvider":      true,
+			"documentRangeFormattingProvider": true,
+			"documentOnTypeFormattingProvider": map[string]any{
+				"firstTriggerCharacter": ontype.Triggers[0],
+				"moreTriggerCharacter":  ontype.Triggers[1:],
+			},
+			"renameProvider":          map[string]any{"prepareProvider": true},
+			"codeActionProvider":      map[string]any{"codeActionKinds": actionKinds},
+			"codeLensProvider":        map[string]any{"resolveProvider": false},
+			"callHierarchyProvider":   true,
+			"workspaceSymbolProvider": true,
+		},
+		"serverInfo": map[string]any{"name": "ferrule-lsp"},
+	}
+}
+
+// initialize indexes the workspace folders, or the root of clients that
+// do not send folders, each starting from the index cached in it.
+func (s *server) initialize(p initializeParams) (any, error) {
+	s.initialized = true
+	if n := p.InitializationOptions.RetainedBytes; n != nil {
+		s.docs.maxBytes = *n
+	}
+	roots := []string{p.RootURI}
+	if len(p.WorkspaceFolders) > 0 {
+		roots = roots[:0]
+		for _, f := range p.WorkspaceFolders {
+			roots = append(roots, f.URI)
+		}
+	}
+	for _, root := range roots {
+		dir, ok := filePath(root)
+		if !ok {
+			continue
+		}
+		idx, err := index.Open(context.Background(), dir)
+		if err != nil {
+			return nil, err
+		}
+		idx.SetFS(s.files)
+		s.roots = append(s.roots, idx)
+	}
+	return s.capabilities(), nil
+}
+
+func (s *server) document(uri string) (*document, error) {
+	doc, ok := s.docs.get(uri)
+	if !ok {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "unknown document " + uri}
+	}
+	return doc, nil
+}
+
+// didOpen parses the document, unless it was closed recently and is
+// opened again unchanged.
+func (s *server) didOpen(p didOpenParams) error {
+	doc, ok := s.docs.reopen(p.TextDocument.URI, p.TextDocument.Text)
+	if !ok {
+		tree, err := s.parser.Parse(context.Background(), []byte(p.TextDocument.Text), nil)
+		if err != nil {
+			return err
+		}
+		doc = &document{tree: tree, lines: position.New(tree.Source())}
+		s.docs.add(p.TextDocument.URI, doc)
+	}
+	cfg, cfgErr := configFor(p.TextDocument.URI)
+	enabled, err := cfg.Analyzers(passes.All)
+	if cfgErr == nil {
+		cfgErr = err
+	}
+	doc.version, doc.cfg, doc.analyzers = p.TextDocument.Version, cfg, enabled
+	s.reindex(p.TextDocument.URI, doc)
+	if err := s.publishDiagnostics(p.TextDocument.URI, doc); err != nil {
+		return err
+	}
+	return cfgErr
+}
+
+// configFor returns the configuration of the project the document at uri
+// is in. Documents that are not files, and those whose configuration
+// cannot be read, get the zero configuration, the latter with the error.
+func configFor(uri string) (*config.Config, error) {
+	p, ok := filePath(uri)
+	if !ok {
+		return &config.Config{}, nil
+	}
+	cfg, err := config.ForDir(filepath.Dir(p))
+	if err != nil {
+		return &config.Config{}, err
+	}
+	return cfg, nil
+}
+
+func (s *server) didChange(p didChangeParams) error {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	tree, _, err := edits.Reparse(context.Background(), s.parser, doc.tree, p.ContentChanges)
+	if err != nil {
+		return err
+	}
+	doc.tree.Close()
+	doc.tree, doc.lines, doc.version = tree, position.New(tree.Source()), p.TextDocument.Version
+	s.reindex(p.TextDocument.URI, doc)
+	return s.publishDiagnostics(p.TextDocument.URI, doc)
+}
+
+func (s *server) didClose(p documentParams) error {
+	if _, err := s.document(p.TextDocument.URI); err != nil {
+		return err
+	}
+	s.docs.close(p.TextDocument.URI)
+	if path, ok := filePath(p.TextDocument.URI); ok {
+		s.files.Remove(path)
+	}
+	if idx, name, ok := s.indexFor(p.TextDocument.URI); ok {
+		// the saved file replaces the unsaved changes.
+		if err := idx.Update(context.Background(), name); err != nil {
+			return err
+		}
+	}
+	return s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// reindex puts the current text of the document at uri in place of the
+// saved one, and its tree into the index if the document is in the
+// workspace.
+func (s *server) reindex(uri string, doc *document) {
+	if path, ok := filePath(uri); ok {
+		s.files.Set(path, doc.tree.Source())
+	}
+	if idx, name, ok := s.indexFor(uri); ok {
+		idx.Put(index.Extract(name, doc.tree))
+	}
+}
+
+// indexFor returns the index of the workspace folder holding the document
+// at uri, the innermost of nested folders, and the document's path in it,
+// if it is a file of the workspace.
+func (s *server) indexFor(uri string) (*index.Index, string, bool) {
+	p, ok := filePath(uri)
+	if !ok {
+		return nil, "", false
+	}
+	var found *index.Index
+	var name string
+	for _, idx := range s.roots {
+		rel, err := filepath.Rel(idx.Root(), p)
+		if err != nil || !filepath.IsLocal(rel) {
+			continue
+		}
+		if found == nil || len(idx.Root()) > len(found.Root()) {
+			found, name = idx, filepath.ToSlash(rel)
+		}
+	}
+	return found, name, found != nil
+}
+
+// project is like indexFor, but for a document in no workspace folder
+// returns an index holding the document alone, named by the base name of
+// its file, or document.fe.
+func (s *server) project(uri string, doc *document) (*index.Index, string) {
+	if idx, name, ok := s.indexFor(uri); ok {
+		return idx, name
+	}
+	name := "document.fe"
+	if p, ok := filePath(uri); ok {
+		name = filepath.Base(p)
+	}
+	idx := index.New("")
+	idx.Put(index.Extract(name, doc.tree))
+	return idx, name
+}
+
+// root returns the index of the workspace folder dir, or nil.
+func (s *server) root(dir string) *index.Index {
+	for _, idx := range s.roots {
+		if idx.Root() == dir {
+			return idx
+		}
+	}
+	return nil
+}
+
+// fileURI returns the URI of the file name of idx.
+func fileURI(idx *index.Index, name string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(idx.Root(), filepath.FromSlash(name)))}).String()
+}
+
+// filePath returns the path of the file a file:// URI names.
+func filePath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || u.Path == "" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+func (s *server) publishDiagnostics(uri string, doc *document) error {
+	diags := []diagnostic{}
+	for _, d := range doc.tree.Diagnostics() {
+		diags = append(diags, diagnostic{
+			Range:    edits.RangeIn(doc.lines, d.Range),
+			Severity: int(d.Severity),
+			Source:   "ferrule",
+			Message:  d.Message,
+		})
+	}
+	enabled := doc.analyzers
+	if !doc.cfg.Generated.Lint && isGenerated(uri, doc) {
+		enabled = nil
+	}
+	idx, name, _ := s.indexFor(uri)
+	lint, err := analysis.RunProject(idx, name, doc.tree, enabled...)
+	if err != nil {
+		return err
+	}
+	doc.lint = lint
+	for _, d := range lint {
+		diags = append(diags, lintDiagnostic(doc.lines, d))
+	}
+	version := doc.version
+	return s.conn.Notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Version:     &version,
+		Diagnostics: diags,
+	})
+}
+
+// lintDiagnostic converts the diagnostic of an analyzer, coded with its
+// name.
+func lintDiagnostic(lines *position.Index, d analysis.Diagnostic) diagnostic {
+	out := diagnostic{
+		Range:    edits.RangeIn(lines, d.Range),
+		Severity: int(d.Severity),
+		Code:     d.Category,
+		Source:   "ferrule-lint",
+		Message:  d.Message,
+	}
+	for _, t := range d.Tags {
+		if t == analysis.TagDeprecated {
+			out.Tags = append(out.Tags, diagnosticTagDeprecated)
+		}
+	}
+	return out
+}
+
+func (s *server) documentSymbol(p documentParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	var convert func([]symbols.Symbol) []documentSymbol
+	convert = func(syms []symbols.Symbol) []documentSymbol {
+		out := make([]documentSymbol, len(syms))
+		for i, sym := range syms {
+			out[i] = documentSymbol{
+				Name:           sym.Name,
+				Detail:         sym.Detail,
+				Kind:           int(sym.Kind),
+				Range:          edits.RangeIn(doc.lines, sym.Range),
+				SelectionRange: edits.RangeIn(doc.lines, sym.SelectionRange),
+				Children:       convert(sym.Children),
+			}
+		}
+		return out
+	}
+	return convert(symbols.Outline(doc.tree)), nil
+}
+
+func (s *server) foldingRange(p documentParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ranges := folding.Ranges(doc.tree, nil)
+	if ranges == nil {
+		ranges = []folding.Range{}
+	}
+	return ranges, nil
+}
+
+func (s *server) selectionRange(p selectionRangeParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	src := doc.tree.Source()
+	ranges := make([]tree_sitter.Range, len(p.Positions))
+	for i, pos := range p.Positions {
+		off := edits.Offset(src, pos)
+		ranges[i] = tree_sitter.Range{StartByte: off, EndByte: off}
+	}
+	var convert func(*selection.Range) *selectionRange
+	convert = func(sel *selection.Range) *selectionRange {
+		if sel == nil {
+			return nil
+		}
+		return &selectionRange{Range: edits.RangeOf(src, sel.Range), Parent: convert(sel.Parent)}
+	}
+	out := []*selectionRange{}
+	for i, sel := range selection.Expand(doc.tree, ranges) {
+		r := convert(sel)
+		if r == nil {
+			// an empty file has no node to select.
+			r = &selectionRange{Range: edits.RangeOf(src, ranges[i])}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *server) semanticTokensFull(p documentParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	s.lastResult++
+	doc.tokens, doc.tokensID = semantictokens.Encode(doc.tree, doc.lines), strconv.Itoa(s.lastResult)
+	return semanticTokens{ResultID: doc.tokensID, Data: nonNil(doc.tokens)}, nil
+}
+
+func (s *server) semanticTokensDelta(p semanticTokensDeltaParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if doc.tokensID == "" || p.PreviousResultID != doc.tokensID {
+		return s.semanticTokensFull(documentParams{TextDocument: p.TextDocument})
+	}
+	prev := doc.tokens
+	s.lastResult++
+	doc.tokens, doc.tokensID = semantictokens.Encode(doc.tree, doc.lines), strconv.Itoa(s.lastResult)
+	delta := semantictokens.Delta(prev, doc.tokens)
+	if delta == nil {
+		delta = []semantictokens.Edit{}
+	}
+	return semanticTokensDelta{ResultID: doc.tokensID, Edits: delta}, nil
+}
+
+func (s *server) semanticTokensRange(p rangeParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return semanticTokens{Data: nonNil(semantictokens.EncodeRange(doc.tree, doc.lines, p.Range))}, nil
+}
+
+// isGenerated reports whether the document at uri is a generated file;
+// see config.Config.IsGenerated.
+func isGenerated(uri string, doc *document) bool {
+	name, _ := filePath(uri)
+	return doc.cfg.IsGenerated(name, doc.tree.Source())
+}
+
+func (s *server) formatting(p documentParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if !doc.cfg.Generated.Format && isGenerated(p.TextDocument.URI, doc) {
+		return []textEdit{}, nil
+	}
+	src := doc.tree.Source()
+	out, err := doc.cfg.Format.Tree(doc.tree)
+	if err != nil {
+		return nil, err
+	}
+	if string(out) == string(src) {
+		return []textEdit{}, nil
+	}
+	whole := edits.Range{End: edits.PositionOf(src, uint(len(src)))}
+	return []textEdit{{Range: whole, NewText: string(out)}}, nil
+}
+
+func (s *server) rangeFormatting(p rangeParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if !doc.cfg.Generated.Format && isGenerated(p.TextDocument.URI, doc) {
+		return []textEdit{}, nil
+	}
+	src := doc.tree.Source()
+	e, err := doc.cfg.Format.TreeRange(doc.tree, point(src, p.Range.Start), point(src, p.Range.End))
+	if err != nil {
+		return nil, err
+	}
+	if string(src[e.Start:e.End]) == e.Text {
+		return []textEdit{}, nil
+	}
+	r := edits.Range{Start: edits.PositionOf(src, e.Start), End: edits.PositionOf(src, e.End)}
+	return []textEdit{{Range: r, NewText: e.Text}}, nil
+}
+
+// hover describes the symbol at the position, looking it up across the
+// workspace folder the document is in; see package hover.
+func (s *server) hover(p positionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	idx, name := s.project(p.TextDocument.URI, doc)
+	src := doc.tree.Source()
+	h, err := hover.At(idx, hover.File{Path: name, Tree: doc.tree}, point(src, p.Position))
+	if err != nil || h == nil {
+		return nil, err
+	}
+	r := edits.RangeOf(src, h.Range)
+	return hoverResult{Contents: markupContent{Kind: "markdown", Value: h.Markdown()}, Range: &r}, nil
+}
+
+// completion proposes the names and keywords valid at the position, with
+// the definitions of the workspace folder the document is in; see package
+// complete.
+func (s *server) completion(p positionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	idx, _, _ := s.indexFor(p.TextDocument.URI)
+	out := []completionItem{}
+	for _, item := range complete.At(idx, doc.tree, point(doc.tree.Source(), p.Position)) {
+		out = append(out, completionItem{Label: item.Label, Kind: int(item.Kind), Detail: item.Detail})
+	}
+	return out, nil
+}
+
+// signatureHelp gives the parameters of the function called at the
+// position, looking it up across the workspace folder the document is in;
+// see package signature.
+func (s *server) signatureHelp(p positionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	idx, _, _ := s.indexFor(p.TextDocument.URI)
+	h, err := signature.At(idx, doc.tree, point(doc.tree.Source(), p.Position))
+	if err != nil || h == nil {
+		return nil, err
+	}
+	sig := signatureInformation{Label: h.Label, Parameters: []parameterInformation{}}
+	if md := h.Doc.Markdown(); md != "" {
+		sig.Documentation = &markupContent{Kind: "markdown", Value: md}
+	}
+	for _, param := range h.Parameters {
+		sig.Parameters = append(sig.Parameters, parameterInformation{Label: param.Label, Documentation: param.Doc})
+	}
+	return signatureHelp{Signatures: []signatureInformation{sig}, ActiveParameter: h.ActiveParameter}, nil
+}
+
+func (s *server) prepareRename(p positionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	src := doc.tree.Source()
+	def := scope.Resolve(doc.tree).ResolveAt(point(src, p.Position))
+	if def == nil {
+		return nil, nil
+	}
+	n := doc.tree.RootNode().NamedDescendantForPointRange(point(src, p.Position), point(src, p.Position))
+	return edits.RangeOf(src, n.Range()), nil
+}
+
+// onTypeFormatting indents the line of the position, on which a line was
+// just started or a closing bracket typed, and closes a bracket left open
+// on the line before; see package ontype.
+func (s *server) onTypeFormatting(p onTypeFormattingParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	src := doc.tree.Source()
+	out := []textEdit{}
+	for _, e := range ontype.Edits(doc.tree, src, point(src, p.Position), p.Ch) {
+		r := edits.Range{Start: edits.PositionOf(src, e.Start), End: edits.PositionOf(src, e.End)}
+		out = append(out, textEdit{Range: r, NewText: e.Text})
+	}
+	return out, nil
+}
+
+func (s *server) rename(p renameParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	src := doc.tree.Source()
+	renames, err := refactor.Rename(doc.tree, point(src, p.Position), p.NewName)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]textEdit, len(renames))
+	for i, e := range renames {
+		changes[i] = textEdit{Range: edits.RangeOf(src, e.Range), NewText: e.NewText}
+	}
+	return workspaceEdit{Changes: map[string][]textEdit{p.TextDocument.URI: changes}}, nil
+}
+
+// actionKinds are the kinds of code action the server offers.
+var actionKinds = []codeaction.Kind{
+	codeaction.QuickFix,
+	codeaction.RefactorExtract,
+	codeaction.RefactorInline,
+	codeaction.RefactorRewrite,
+	codeaction.SourceOrganizeImports,
+}
+
+func (s *server) codeAction(p codeActionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	src := doc.tree.Source()
+	start, end := edits.Offset(src, p.Range.Start), edits.Offset(src, p.Range.End)
+	req := &codeaction.Request{
+		Tree: doc.tree,
+		Range: tree_sitter.Range{
+			StartByte:  start,
+			EndByte:    end,
+			StartPoint: edits.Point(src, start),
+			EndPoint:   edits.Point(src, end),
+		},
+		Diagnostics: doc.lint,
+		Format:      doc.cfg.Format,
+	}
+	for _, k := range p.Context.Only {
+		req.Only = append(req.Only, codeaction.Kind(k))
+	}
+	actions := []codeAction{}
+	for _, a := range codeaction.Actions(req) {
+		changes := make([]textEdit, len(a.Edits))
+		for i, e := range a.Edits {
+			changes[i] = textEdit{Range: edits.RangeOf(src, e.Range), NewText: e.NewText}
+		}
+		out := codeAction{
+			Title:       a.Title,
+			Kind:        string(a.Kind),
+			IsPreferred: a.Preferred,
+			Edit:        &workspaceEdit{Changes: map[string][]textEdit{p.TextDocument.URI: changes}},
+		}
+		if a.Diagnostic != nil {
+			out.Diagnostics = []diagnostic{lintDiagnostic(doc.lines, *a.Diagnostic)}
+		}
+		actions = append(actions, out)
+	}
+	return actions, nil
+}
+
+// The commands of code lenses, which the client carries out:
+// lensShowReferences with the document URI, the position of the function
+// name and the locations of its references, lensRun and lensTest with the
+// document URI and the name of the function.
+const (
+	lensShowReferences = "ferrule.showReferences"
+	lensRun            = "ferrule.run"
+	lensTest           = "ferrule.test"
+)
+
+// codeLens returns the lenses of the document, counting the references of
+// its functions across the workspace folder it is in, or the document
+// alone when it is in none.
+func (s *server) codeLens(p documentParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	idx, name := s.project(p.TextDocument.URI, doc)
+	src := doc.tree.Source()
+	sources := map[string][]byte{name: src}
+	out := []codeLensItem{}
+	for _, l := range codelens.Compute(idx, name, &doc.cfg.CodeLens) {
+		r := edits.RangeOf(src, l.Range)
+		cmd := command{Title: l.Title}
+		switch l.Kind {
+		case codelens.References:
+			locs := []location{}
+			for _, ref := range l.References {
+				refSrc, ok := sources[ref.Path]
+				if !ok {
+					if refSrc, err = s.source(idx, ref.Path); err != nil {
+						return nil, err
+					}
+					sources[ref.Path] = refSrc
+				}
+				uri := p.TextDocument.URI
+				if ref.Path != name {
+					uri = fileURI(idx, ref.Path)
+				}
+				locs = append(locs, location{URI: uri, Range: edits.RangeOf(refSrc, ref.Range)})
+			}
+			cmd.Command, cmd.Arguments = lensShowReferences, []any{p.TextDocument.URI, r.Start, locs}
+		case codelens.Run:
+			cmd.Command, cmd.Arguments = lensRun, []any{p.TextDocument.URI, l.Name}
+		case codelens.Test:
+			cmd.Command, cmd.Arguments = lensTest, []any{p.TextDocument.URI, l.Name}
+		}
+		out = append(out, codeLensItem{Range: r, Command: cmd})
+	}
+	return out, nil
+}
+
+// workspaceSymbolLimit is the most symbols a workspace/symbol request
+// returns; clients ask again as the user narrows the query.
+const workspaceSymbolLimit = 200
+
+func (s *server) workspaceSymbol(p workspaceSymbolParams) (any, error) {
+	out := []symbolInformation{}
+	for _, idx := range s.roots {
+		sources := make(map[string][]byte)
+		for _, sym := range idx.SearchSymbols(p.Query, &index.SearchOptions{Limit: workspaceSymbolLimit - len(out)}) {
+			src, ok := sources[sym.Path]
+			if !ok {
+				var err error
+				if src, err = s.source(idx, sym.Path); err != nil {
+					return nil, err
+				}
+				sources[sym.Path] = src
+			}
+			out = append(out, symbolInformation{
+				Name:          sym.Name,
+				Kind:          int(sym.Kind),
+				Location:      location{URI: fileURI(idx, sym.Path), Range: edits.RangeOf(src, sym.SelectionRange)},
+				ContainerName: sym.Container,
+			})
+		}
+		if len(out) >= workspaceSymbolLimit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (s *server) prepareCallHierarchy(p positionParams) (any, error) {
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	idx, name, ok := s.indexFor(p.TextDocument.URI)
+	if !ok {
+		return nil, nil
+	}
+	items := hierarchy.At(idx, name, point(doc.tree.Source(), p.Position))
+	if items == nil {
+		return nil, nil
+	}
+	out := make([]callHierarchyItem, len(items))
+	for i, item := range items {
+		src, err := s.source(idx, item.Path)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = callHierarchyItemOf(idx, src, item)
+	}
+	return out, nil
+}
+
+func (s *server) incomingCalls(p callHierarchyParams) (any, error) {
+	idx := s.root(p.Item.Data.Root)
+	if idx == nil {
+		return nil, nil
+	}
+	out := []incomingCall{}
+	for _, c := range hierarchy.Callers(idx, p.Item.Data.Item) {
+		src, err := s.source(idx, c.Item.Path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, incomingCall{From: callHierarchyItemOf(idx, src, c.Item), FromRanges: rangesOf(src, c.Ranges)})
+	}
+	return out, nil
+}
+
+func (s *server) outgoingCalls(p callHierarchyParams) (any, error) {
+	idx := s.root(p.Item.Data.Root)
+	if idx == nil {
+		return nil, nil
+	}
+	src, err := s.source(idx, p.Item.Data.Path)
+	if err != nil {
+		return nil, err
+	}
+	out := []outgoingCall{}
+	for _, c := range hierarchy.Callees(idx, p.Item.Data.Item) {
+		to, err := s.source(idx, c.Item.Path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, outgoingCall{To: callHierarchyItemOf(idx, to, c.Item), FromRanges: rangesOf(src, c.Ranges)})
+	}
+	return out, nil
+}
+
+// callHierarchyItemOf converts item, a function of the file of idx whose
+// source is src. The item and the folder of idx travel in the data of the
+// result so that the calls of the function can be found when the client
+// asks for them.
+func callHierarchyItemOf(idx *index.Index, src []byte, item hierarchy.Item) callHierarchyItem {
+	return callHierarchyItem{
+		Name:           item.Name,
+		Kind:           int(item.Kind),
+		Detail:         item.Container,
+		URI:            fileURI(idx, item.Path),
+		Range:          edits.RangeOf(src, item.Range),
+		SelectionRange: edits.RangeOf(src, item.SelectionRange),
+		Data:           callHierarchyData{Root: idx.Root(), Item: item},
+	}
+}
+
+// source returns the text of the file name of idx: that of the document
+// if it is open, so that positions match the unsaved changes the index
+// holds, and otherwise the one the index reads, decoded as the index
+// decodes it.
+func (s *server) source(idx *index.Index, name string) ([]byte, error) {
+	if doc, ok := s.docs.get(fileURI(idx, name)); ok {
+		return doc.tree.Source(), nil
+	}
+	data, err := s.files.ReadFile(filepath.Join(idx.Root(), filepath.FromSlash(name)))
+	if err != nil {
+		return nil, err
+	}
+	return charset.Decode(data).Source, nil
+}
+
+func rangesOf(src []byte, ranges []tree_sitter.Range) []edits.Range {
+	out := make([]edits.Range, len(ranges))
+	for i, r := range ranges {
+		out[i] = edits.RangeOf(src, r)
+	}
+	return out
+}
+
+// point converts a protocol position to a tree-sitter point.
+func point(src []byte, pos edits.Position) tree_sitter.Point {
+	return edits.Point(src, edits.Offset(src, pos))
+}
+
+// nonNil makes empty token data marshal as [] rather than null.
+func nonNil(data []uint32) []uint32 {
+	if data == nil {
+		return []uint32{}
+	}
+	return data
+}
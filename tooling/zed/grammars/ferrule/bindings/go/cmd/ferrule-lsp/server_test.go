@@ -0,0 +1,615 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/jsonrpc"
+)
+
+const uri = "file:///tmp/main.fe"
+
+// session frames the messages as a client would send them.
+func session(t *testing.T, msgs ...map[string]any) *bytes.Buffer {
+	t.Helper()
+	var in bytes.Buffer
+	for _, m := range msgs {
+		m["jsonrpc"] = "2.0"
+		body, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return &in
+}
+
+// replies decodes the server output, indexing responses by ID and
+// collecting notifications.
+func replies(t *testing.T, out io.Reader) (map[int]json.RawMessage, map[int]*jsonrpc.Error, []map[string]json.RawMessage) {
+	t.Helper()
+	c := jsonrpc.NewConn(out, nil)
+	results := make(map[int]json.RawMessage)
+	errs := make(map[int]*jsonrpc.Error)
+	var notes []map[string]json.RawMessage
+	for {
+		body, err := c.Read()
+		if err == io.EOF {
+			return results, errs, notes
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m struct {
+			ID     *int
+			Result json.RawMessage
+			Error  *jsonrpc.Error
+			Method string
+			Params json.RawMessage
+		}
+		if err := json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case m.ID == nil:
+			notes = append(notes, map[string]json.RawMessage{"method": json.RawMessage(m.Method), "params": m.Params})
+		case m.Error != nil:
+			errs[*m.ID] = m.Error
+		default:
+			results[*m.ID] = m.Result
+		}
+	}
+}
+
+func doc() map[string]any { return map[string]any{"uri": uri} }
+
+func TestSession(t *testing.T) {
+	src := "function main(n: u32) -> u32 {\n  const x  = n;\n  return x;\n}\n"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "initialized", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "ferrule", "version": 1, "text": src},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/documentSymbol", "params": map[string]any{"textDocument": doc()}},
+		map[string]any{"id": 3, "method": "textDocument/foldingRange", "params": map[string]any{"textDocument": doc()}},
+		map[string]any{"id": 4, "method": "textDocument/formatting", "params": map[string]any{"textDocument": doc(), "options": map[string]any{}}},
+		map[string]any{"id": 5, "method": "textDocument/rename", "params": map[string]any{
+			"textDocument": doc(), "position": map[string]any{"line": 2, "character": 9}, "newName": "y",
+		}},
+		map[string]any{"id": 6, "method": "textDocument/semanticTokens/full", "params": map[string]any{"textDocument": doc()}},
+		map[string]any{"id": 10, "method": "textDocument/selectionRange", "params": map[string]any{
+			"textDocument": doc(), "positions": []any{map[string]any{"line": 1, "character": 8}},
+		}},
+		map[string]any{"method": "textDocument/didChange", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "version": 2},
+			"contentChanges": []any{map[string]any{
+				"range": map[string]any{"start": map[string]any{"line": 2, "character": 10}, "end": map[string]any{"line": 2, "character": 10}},
+				"text":  " +",
+			}},
+		}},
+		map[string]any{"id": 7, "method": "textDocument/semanticTokens/full/delta", "params": map[string]any{"textDocument": doc(), "previousResultId": "1"}},
+		map[string]any{"id": 8, "method": "textDocument/hover", "params": map[string]any{
+			"textDocument": doc(), "position": map[string]any{"line": 2, "character": 9},
+		}},
+		map[string]any{"id": 9, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, notes := replies(t, &out)
+
+	if !strings.Contains(string(results[1]), `"documentFormattingProvider":true`) {
+		t.Errorf("initialize result %s", results[1])
+	}
+	if !strings.Contains(string(results[2]), `"name":"main","detail":"(n: u32) -> u32","kind":12`) {
+		t.Errorf("documentSymbol result %s", results[2])
+	}
+	if string(results[3]) != `[{"startLine":0,"endLine":2,"kind":"region"}]` {
+		t.Errorf("foldingRange result %s", results[3])
+	}
+	if !strings.Contains(string(results[4]), `"newText":"function main(n: u32) -> u32 {\n  const x = n;\n  return x;\n}\n"`) {
+		t.Errorf("formatting result %s", results[4])
+	}
+	wantRename := `{"changes":{"` + uri + `":[` +
+		`{"range":{"start":{"line":1,"character":8},"end":{"line":1,"character":9}},"newText":"y"},` +
+		`{"range":{"start":{"line":2,"character":9},"end":{"line":2,"character":10}},"newText":"y"}]}}`
+	if string(results[5]) != wantRename {
+		t.Errorf("rename result %s", results[5])
+	}
+	if !strings.HasPrefix(string(results[6]), `{"resultId":"1","data":[`) {
+		t.Errorf("semanticTokens result %s", results[6])
+	}
+	wantSelection := `[{"range":{"start":{"line":1,"character":8},"end":{"line":1,"character":9}},` +
+		`"parent":{"range":{"start":{"line":1,"character":2},"end":{"line":1,"character":15}},`
+	if !strings.HasPrefix(string(results[10]), wantSelection) {
+		t.Errorf("selectionRange result %s", results[10])
+	}
+	if !strings.HasPrefix(string(results[7]), `{"resultId":"2","edits":[`) {
+		t.Errorf("semanticTokens delta result %s", results[7])
+	}
+	var h struct {
+		Contents struct{ Kind, Value string }
+		Range    struct{ Start, End struct{ Line, Character int } }
+	}
+	if err := json.Unmarshal(results[8], &h); err != nil {
+		t.Fatalf("hover result %s: %v", results[8], err)
+	}
+	if h.Contents.Kind != "markdown" || !strings.HasPrefix(h.Contents.Value, "```ferrule\nconst x") || !strings.Contains(h.Contents.Value, "Defined in main.fe:2:9") {
+		t.Errorf("hover contents %+v", h.Contents)
+	}
+	if h.Range.Start.Line != 2 || h.Range.Start.Character != 9 || h.Range.End.Character != 10 {
+		t.Errorf("hover range %+v", h.Range)
+	}
+
+	// one notification after opening, a second one reporting the error
+	// introduced by the change.
+	if len(notes) != 2 {
+		t.Fatalf("got %d notifications, want 2", len(notes))
+	}
+	if !strings.Contains(string(notes[0]["params"]), `"diagnostics":[]`) {
+		t.Errorf("first diagnostics %s", notes[0]["params"])
+	}
+	if !strings.Contains(string(notes[1]["params"]), `"version":2,"diagnostics":[{`) {
+		t.Errorf("second diagnostics %s", notes[1]["params"])
+	}
+}
+
+func TestNotInitialized(t *testing.T) {
+	in := session(t,
+		map[string]any{"id": 1, "method": "textDocument/documentSymbol", "params": map[string]any{"textDocument": doc()}},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 1 {
+		t.Errorf("exit without shutdown: code %d, want 1", code)
+	}
+	_, errs, _ := replies(t, &out)
+	if errs[1] == nil || errs[1].Code != codeServerNotInitialized {
+		t.Errorf("error %v, want server not initialized", errs[1])
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[format]\nwidth = 30\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, "main.fe"))}).String()
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": file, "languageId": "ferrule", "version": 1, "text": "const x = add(first_argument, second_argument);\n"},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/formatting", "params": map[string]any{"textDocument": map[string]any{"uri": file}, "options": map[string]any{}}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	if !strings.Contains(string(results[2]), `"newText":"const x = add(\n  first_argument,\n  second_argument,\n);\n"`) {
+		t.Errorf("formatting result %s", results[2])
+	}
+
+	gen := (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, "api.pb.fe"))}).String()
+	in = session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": gen, "languageId": "ferrule", "version": 1,
+				"text": "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\nfunction f() -> i32 { const x=1; return 2; }\n"},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/formatting", "params": map[string]any{"textDocument": map[string]any{"uri": gen}, "options": map[string]any{}}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	out.Reset()
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, notes := replies(t, &out)
+	if string(results[2]) != `[]` {
+		t.Errorf("formatting result for a generated file %s", results[2])
+	}
+	if len(notes) != 1 || !strings.Contains(string(notes[0]["params"]), `"diagnostics":[]`) {
+		t.Errorf("diagnostics for a generated file %s", notes)
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	src := "import zlib;\nimport std.io;\n\nconst x = 1;\n"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "ferrule", "version": 1, "text": src},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/codeAction", "params": map[string]any{
+			"textDocument": doc(), "range": map[string]any{"start": map[string]any{"line": 0, "character": 0}, "end": map[string]any{"line": 0, "character": 0}},
+			"context": map[string]any{"only": []string{"source"}},
+		}},
+		map[string]any{"id": 3, "method": "textDocument/codeAction", "params": map[string]any{
+			"textDocument": doc(), "range": map[string]any{"start": map[string]any{"line": 0, "character": 0}, "end": map[string]any{"line": 0, "character": 0}},
+			"context": map[string]any{"only": []string{"quickfix"}},
+		}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	want := `[{"title":"Organize imports","kind":"source.organizeImports","edit":{"changes":{"` + uri + `":[` +
+		`{"range":{"start":{"line":0,"character":0},"end":{"line":1,"character":14}},"newText":"import std.io;\n\nimport zlib;"}]}}}]`
+	if string(results[2]) != want {
+		t.Errorf("codeAction result %s", results[2])
+	}
+	if string(results[3]) != `[]` {
+		t.Errorf("quickfix codeAction result %s", results[3])
+	}
+}
+
+func TestQuickFix(t *testing.T) {
+	src := "function f(c: i32) -> i32 {\n  match c {\n    _ -> { return 1; }\n    0 -> { return 2; }\n  }\n}\n"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "ferrule", "version": 1, "text": src},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/codeAction", "params": map[string]any{
+			"textDocument": doc(), "range": map[string]any{"start": map[string]any{"line": 3, "character": 4}, "end": map[string]any{"line": 3, "character": 4}},
+			"context": map[string]any{"only": []string{"quickfix"}},
+		}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, notes := replies(t, &out)
+	if len(notes) != 1 || !strings.Contains(string(notes[0]["params"]), `"source":"ferrule-lint","message":"unreachable match arm`) {
+		t.Errorf("diagnostics %s", notes)
+	}
+	got := string(results[2])
+	for _, want := range []string{`"title":"remove unreachable arm","kind":"quickfix","diagnostics":[{`, `"isPreferred":true`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("codeAction result %s, want %s", got, want)
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	dir := t.TempDir()
+	util := "// ferrule:deprecated use scale instead\npub function double(v: u32) -> u32 {\n  return v * 2;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "util.fe"), []byte(util), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	src := "function main() -> u32 {\n  return double(1);\n}\n"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": root + "/main.fe", "languageId": "ferrule", "version": 1, "text": src},
+		}},
+		map[string]any{"id": 2, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	_, _, notes := replies(t, &out)
+	want := `"code":"deprecated","source":"ferrule-lint","message":"double is deprecated: use scale instead","tags":[2]`
+	if len(notes) != 1 || !strings.Contains(string(notes[0]["params"]), want) {
+		t.Errorf("diagnostics %s, want %s", notes, want)
+	}
+}
+
+func TestOnTypeFormatting(t *testing.T) {
+	// a line just started in a function whose body is not closed yet.
+	src := "function main() -> Unit {\n  if true {\n\n"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "ferrule", "version": 1, "text": src},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/onTypeFormatting", "params": map[string]any{
+			"textDocument": doc(), "position": map[string]any{"line": 2, "character": 0}, "ch": "\n",
+		}},
+		map[string]any{"id": 3, "method": "textDocument/onTypeFormatting", "params": map[string]any{
+			"textDocument": doc(), "position": map[string]any{"line": 1, "character": 11}, "ch": "\n",
+		}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	want := `[{"range":{"start":{"line":2,"character":0},"end":{"line":2,"character":0}},"newText":"    "},` +
+		`{"range":{"start":{"line":2,"character":0},"end":{"line":2,"character":0}},"newText":"\n  }"}]`
+	if string(results[2]) != want {
+		t.Errorf("onTypeFormatting result %s", results[2])
+	}
+	if string(results[3]) != `[]` {
+		t.Errorf("onTypeFormatting result for an indented line %s", results[3])
+	}
+}
+
+func TestCallHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	util := "pub function scale(v: u32) -> u32 {\n  return v * 2;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "util.fe"), []byte(util), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	file := root + "/main.fe"
+	// main.fe exists only in the editor.
+	src := "function main() -> u32 {\n  return scale(1) + scale(2);\n}\n"
+	start := []map[string]any{
+		{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}},
+		{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": file, "languageId": "ferrule", "version": 1, "text": src},
+		}},
+	}
+	end := []map[string]any{{"id": 9, "method": "shutdown"}, {"method": "exit"}}
+	run := func(msgs ...map[string]any) map[int]json.RawMessage {
+		in := session(t, append(append(start, msgs...), end...)...)
+		var out, log bytes.Buffer
+		if code := serve(in, &out, &log); code != 0 {
+			t.Fatalf("exit code %d: %s", code, log.String())
+		}
+		results, _, _ := replies(t, &out)
+		return results
+	}
+
+	results := run(map[string]any{"id": 2, "method": "textDocument/prepareCallHierarchy", "params": map[string]any{
+		"textDocument": map[string]any{"uri": file}, "position": map[string]any{"line": 1, "character": 10},
+	}})
+	var items []map[string]any
+	if err := json.Unmarshal(results[2], &items); err != nil || len(items) != 1 || items[0]["uri"] != root+"/util.fe" {
+		t.Fatalf("prepareCallHierarchy result %s", results[2])
+	}
+	results = run(map[string]any{"id": 3, "method": "callHierarchy/incomingCalls", "params": map[string]any{"item": items[0]}})
+	want := `"uri":"` + file + `",`
+	ranges := `"fromRanges":[{"start":{"line":1,"character":9},"end":{"line":1,"character":14}},{"start":{"line":1,"character":20},"end":{"line":1,"character":25}}]`
+	if got := string(results[3]); !strings.Contains(got, `"from":{"name":"main","kind":12,`+want) || !strings.Contains(got, ranges) {
+		t.Errorf("incomingCalls result %s", got)
+	}
+
+	results = run(map[string]any{"id": 2, "method": "textDocument/prepareCallHierarchy", "params": map[string]any{
+		"textDocument": map[string]any{"uri": file}, "position": map[string]any{"line": 0, "character": 10},
+	}})
+	if err := json.Unmarshal(results[2], &items); err != nil || len(items) != 1 {
+		t.Fatalf("prepareCallHierarchy result %s", results[2])
+	}
+	results = run(map[string]any{"id": 3, "method": "callHierarchy/outgoingCalls", "params": map[string]any{"item": items[0]}})
+	if got := string(results[3]); !strings.Contains(got, `"to":{"name":"scale","kind":12,"uri":"`+root+`/util.fe"`) || !strings.Contains(got, ranges) {
+		t.Errorf("outgoingCalls result %s", got)
+	}
+}
+
+func TestWorkspaceFolders(t *testing.T) {
+	var folders []map[string]any
+	var uris []string
+	for name, src := range map[string]string{"a": "function scale(v: u32) -> u32 {\n  return v;\n}\n", "b": "function scan() -> u32 {\n  return 1;\n}\n"} {
+		dir := filepath.Join(t.TempDir(), name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "lib.fe"), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+		folders = append(folders, map[string]any{"uri": uri, "name": name})
+		uris = append(uris, uri)
+	}
+	// draft.fe exists only in the editor until it is closed.
+	draft := uris[1] + "/draft.fe"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": uris[0], "workspaceFolders": folders}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": draft, "languageId": "ferrule", "version": 1, "text": "function scatter() -> u32 {\n  return 2;\n}\n"},
+		}},
+		map[string]any{"id": 2, "method": "workspace/symbol", "params": map[string]any{"query": "sc"}},
+		map[string]any{"method": "textDocument/didClose", "params": map[string]any{"textDocument": map[string]any{"uri": draft}}},
+		map[string]any{"id": 3, "method": "workspace/symbol", "params": map[string]any{"query": "sc"}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	for id, want := range map[int][]string{2: {"scale", "scan", "scatter"}, 3: {"scale", "scan"}} {
+		var syms []struct{ Name string }
+		if err := json.Unmarshal(results[id], &syms); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, s := range syms {
+			got = append(got, s.Name)
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("workspace/symbol %d found %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestWorkspaceSymbol(t *testing.T) {
+	dir := t.TempDir()
+	util := "function scale(v: u32) -> u32 {\n  return v * 2;\n}\n\nfunction square(v: u32) -> u32 {\n  return v * v;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "util.fe"), []byte(util), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}},
+		map[string]any{"id": 2, "method": "workspace/symbol", "params": map[string]any{"query": "sqr"}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	want := `[{"name":"square","kind":12,"location":{"uri":"` + root + `/util.fe",` +
+		`"range":{"start":{"line":4,"character":9},"end":{"line":4,"character":15}}}}]`
+	if string(results[2]) != want {
+		t.Errorf("workspace/symbol result %s", results[2])
+	}
+}
+
+func TestCodeLens(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[codelens]\ntests = [\"check*\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	docs := map[string]string{
+		root + "/util.fe": "function scale(v: u32) -> u32 {\n  return v * 2;\n}\n",
+		root + "/main.fe": "function main() -> u32 {\n  return scale(1);\n}\n\nfunction checkScale() -> Unit {\n  scale(2);\n}\n",
+	}
+	msgs := []map[string]any{{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}}}
+	for uri, src := range docs {
+		msgs = append(msgs, map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "ferrule", "version": 1, "text": src},
+		}})
+	}
+	msgs = append(msgs,
+		map[string]any{"id": 2, "method": "textDocument/codeLens", "params": map[string]any{"textDocument": map[string]any{"uri": root + "/util.fe"}}},
+		map[string]any{"id": 3, "method": "textDocument/codeLens", "params": map[string]any{"textDocument": map[string]any{"uri": root + "/main.fe"}}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(session(t, msgs...), &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	type lens struct {
+		Range   struct{ Start struct{ Line int } }
+		Command struct {
+			Title     string
+			Command   string
+			Arguments []json.RawMessage
+		}
+	}
+	decode := func(id int) ([]lens, string) {
+		var lenses []lens
+		if err := json.Unmarshal(results[id], &lenses); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, l := range lenses {
+			got = append(got, fmt.Sprintf("%d %s %s", l.Range.Start.Line, l.Command.Command, l.Command.Title))
+		}
+		return lenses, strings.Join(got, "|")
+	}
+
+	util, got := decode(2)
+	if want := "0 ferrule.showReferences 2 references"; got != want {
+		t.Errorf("util.fe lenses %q, want %q", got, want)
+	} else {
+		var locs []struct{ URI string }
+		if err := json.Unmarshal(util[0].Command.Arguments[2], &locs); err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != 2 || locs[0].URI != root+"/main.fe" || locs[1].URI != root+"/main.fe" {
+			t.Errorf("references of scale %s", util[0].Command.Arguments[2])
+		}
+	}
+	_, got = decode(3)
+	want := "0 ferrule.showReferences 0 references|0 ferrule.run run|" +
+		"4 ferrule.showReferences 0 references|4 ferrule.test test"
+	if got != want {
+		t.Errorf("main.fe lenses %q, want %q", got, want)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "util.fe"), []byte("function scale(v: u32) -> u32 {\n  return v;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	main := root + "/main.fe"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": main, "languageId": "ferrule", "version": 1, "text": "function main() -> u32 {\n  return sc\n}\n"},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/completion", "params": map[string]any{
+			"textDocument": map[string]any{"uri": main}, "position": map[string]any{"line": 1, "character": 11},
+		}},
+		map[string]any{"id": 3, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	if want := `[{"label":"scale","kind":3,"detail":"util.fe"}]`; string(results[2]) != want {
+		t.Errorf("completion result %s, want %s", results[2], want)
+	}
+	if !strings.Contains(string(results[1]), `"completionProvider":{"resolveProvider":false}`) {
+		t.Errorf("initialize result %s", results[1])
+	}
+}
+
+func TestSignatureHelp(t *testing.T) {
+	dir := t.TempDir()
+	util := "// scale scales v.\n// @param v the value\nfunction scale(v: u32, by: u32) -> u32 {\n  return v * by;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "util.fe"), []byte(util), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(dir)}).String()
+	main := root + "/main.fe"
+	in := session(t,
+		map[string]any{"id": 1, "method": "initialize", "params": map[string]any{"rootUri": root}},
+		map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": main, "languageId": "ferrule", "version": 1, "text": "function main() -> u32 {\n  return scale(1, 2);\n}\n"},
+		}},
+		map[string]any{"id": 2, "method": "textDocument/signatureHelp", "params": map[string]any{
+			"textDocument": map[string]any{"uri": main}, "position": map[string]any{"line": 1, "character": 18},
+		}},
+		map[string]any{"id": 3, "method": "textDocument/signatureHelp", "params": map[string]any{
+			"textDocument": map[string]any{"uri": main}, "position": map[string]any{"line": 0, "character": 2},
+		}},
+		map[string]any{"id": 4, "method": "shutdown"},
+		map[string]any{"method": "exit"},
+	)
+	var out, log bytes.Buffer
+	if code := serve(in, &out, &log); code != 0 {
+		t.Fatalf("exit code %d: %s", code, log.String())
+	}
+	results, _, _ := replies(t, &out)
+	want := `{"signatures":[{"label":"function scale(v: u32, by: u32) -> u32",` +
+		`"documentation":{"kind":"markdown","value":"scale scales v.\n\nParameters:\n\n- ` + "`v`" + `: the value\n"},` +
+		`"parameters":[{"label":"v: u32","documentation":"the value"},{"label":"by: u32"}]}],` +
+		`"activeSignature":0,"activeParameter":1}`
+	if string(results[2]) != want {
+		t.Errorf("signatureHelp result %s\nwant %s", results[2], want)
+	}
+	if string(results[3]) != "null" {
+		t.Errorf("signatureHelp outside a call %s, want null", results[3])
+	}
+}
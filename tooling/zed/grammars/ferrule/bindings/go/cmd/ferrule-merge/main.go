@@ -0,0 +1,91 @@
+// Command ferrule-merge merges ferrule source files at the level of syntax
+// nodes, as described in package merge. It is meant to be used as a git
+// merge driver:
+//
+//	ferrule-merge [flags] current base other
+//
+// The result is written to current unless -p is given. The flags are:
+//
+//	-p               print the result to standard output instead
+//	-marker-size n   length of the conflict markers (default 7)
+//	-ours label      label of the current side's conflict markers
+//	-theirs label    label of the other side's conflict markers
+//
+// The exit status is 0 for a clean merge, 1 if conflicts were marked and 2
+// if an error occurred. To use it for all .fe files, add to .gitattributes
+//
+//	*.fe merge=ferrule
+//
+// and to the git configuration
+//
+//	[merge "ferrule"]
+//		name = ferrule syntax-aware merge
+//		driver = ferrule-merge -marker-size %L %A %O %B
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/karol-broda/ferrule/bindings/go/merge"
+)
+
+var (
+	toStdout   = flag.Bool("p", false, "print the result to stdout instead of writing it to current")
+	markerSize = flag.Int("marker-size", 7, "length of the conflict markers")
+	ours       = flag.String("ours", "ours", "`label` of the current side")
+	theirs     = flag.String("theirs", "theirs", "`label` of the other side")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-merge [flags] current base other\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 3 {
+		fmt.Fprintf(stderr, "usage: ferrule-merge [flags] current base other\n")
+		return 2
+	}
+	var srcs [3][]byte
+	for i, name := range args {
+		src, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-merge: %v\n", err)
+			return 2
+		}
+		srcs[i] = src
+	}
+	out, conflicts, err := merge.Merge(srcs[1], srcs[0], srcs[2], &merge.Options{
+		OursLabel:   *ours,
+		TheirsLabel: *theirs,
+		MarkerSize:  *markerSize,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-merge: %v\n", err)
+		return 2
+	}
+	if *toStdout {
+		_, err = stdout.Write(out)
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(args[0]); err == nil {
+			err = os.WriteFile(args[0], out, info.Mode().Perm())
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-merge: %v\n", err)
+		return 2
+	}
+	if conflicts > 0 {
+		fmt.Fprintf(stderr, "ferrule-merge: %s: %d conflicts\n", args[0], conflicts)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, dir, name, src string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	base := "function f() -> i32 {\n  return 1;\n}\n"
+	current := write(t, dir, "current", base+"\nfunction g() -> i32 {\n  return 2;\n}\n")
+	write(t, dir, "base", base)
+	write(t, dir, "other", "function e() -> i32 {\n  return 0;\n}\n\n"+base)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{current, filepath.Join(dir, "base"), filepath.Join(dir, "other")}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "function e() -> i32 {\n  return 0;\n}\n\n" + base + "\nfunction g() -> i32 {\n  return 2;\n}\n"
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestConflict(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{
+		write(t, dir, "current", "const x = 2;\n"),
+		write(t, dir, "base", "const x = 1;\n"),
+		write(t, dir, "other", "const x = 3;\n"),
+	}
+	*toStdout, *ours = true, "HEAD"
+	defer func() { *toStdout, *ours = false, "ours" }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if got, want := stdout.String(), "<<<<<<< HEAD\nconst x = 2;\n=======\nconst x = 3;\n>>>>>>> theirs\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(stderr.String(), "1 conflicts") {
+		t.Errorf("stderr %q", stderr.String())
+	}
+}
@@ -0,0 +1,187 @@
+// Command ferrule-metrics reports the complexity of ferrule functions and
+// can fail when it exceeds a budget, to gate merges in CI.
+//
+//	ferrule-metrics [flags] [path ...]
+//
+// Each path is a file or a directory walked for .fe files, skipping what
+// .gitignore and .ferruleignore files exclude and generated files; without
+// arguments the current directory is walked. For every function it reports the
+// cyclomatic and cognitive complexity, the nesting depth and the number
+// of lines and of lines of code; see package metrics. The flags are:
+//
+//	-format f           output format: text (the default), json or csv
+//	-max-cyclomatic n   exit with status 1 if a function exceeds n
+//	-max-cognitive n    likewise for cognitive complexity
+//	-max-nesting n      likewise for nesting depth
+//
+// Functions over budget are listed on standard error. Files with syntax
+// errors are measured all the same, as far as they parse, and reported.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/metrics"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	format        = flag.String("format", "text", "output `format`: text, json or csv")
+	maxCyclomatic = flag.Int("max-cyclomatic", 0, "exit with status 1 if a function's cyclomatic complexity exceeds `n`")
+	maxCognitive  = flag.Int("max-cognitive", 0, "exit with status 1 if a function's cognitive complexity exceeds `n`")
+	maxNesting    = flag.Int("max-nesting", 0, "exit with status 1 if a function's nesting depth exceeds `n`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-metrics [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// record is the measurement of a function of a file.
+type record struct {
+	File string `json:"file"`
+	metrics.Function
+}
+
+func run(paths []string, stdout, stderr io.Writer) int {
+	if *format != "text" && *format != "json" && *format != "csv" {
+		fmt.Fprintf(stderr, "ferrule-metrics: unknown format %q\n", *format)
+		return 2
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	status := 0
+	var records []record
+	for _, path := range paths {
+		found, err := measure(path, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-metrics: %v\n", err)
+			status = 2
+		}
+		records = append(records, found...)
+	}
+
+	var err error
+	switch *format {
+	case "json":
+		err = writeJSON(stdout, records)
+	case "csv":
+		err = writeCSV(stdout, records)
+	default:
+		err = writeText(stdout, records)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-metrics: %v\n", err)
+		return 2
+	}
+
+	for _, r := range records {
+		for _, b := range []struct {
+			what       string
+			got, limit int
+		}{
+			{"cyclomatic complexity", r.Cyclomatic, *maxCyclomatic},
+			{"cognitive complexity", r.Cognitive, *maxCognitive},
+			{"nesting depth", r.Nesting, *maxNesting},
+		} {
+			if b.limit > 0 && b.got > b.limit {
+				fmt.Fprintf(stderr, "%s:%d: %s has %s %d, over the budget of %d\n", r.File, r.Line, r.Name, b.what, b.got, b.limit)
+				if status == 0 {
+					status = 1
+				}
+			}
+		}
+	}
+	return status
+}
+
+// measure returns the records of the files found at path, ordered by file
+// and line.
+func measure(path string, stderr io.Writer) ([]record, error) {
+	files, wait := walk.Files(path, walk.Options{SkipGenerated: true})
+	var out []record
+	var errs []error
+	for f := range files {
+		src, err := os.ReadFile(f.OSPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, d := range tree.Diagnostics() {
+			fmt.Fprintf(stderr, "%s:%s\n", f.OSPath, d)
+		}
+		for _, fn := range metrics.File(tree) {
+			out = append(out, record{File: f.OSPath, Function: fn})
+		}
+		tree.Close()
+	}
+	if err := wait(); err != nil {
+		errs = append(errs, err)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].Line < out[j].Line
+	})
+	return out, errors.Join(errs...)
+}
+
+var columns = []string{"file", "function", "line", "cyclomatic", "cognitive", "nesting", "lines", "code_lines"}
+
+func (r record) fields() []string {
+	return []string{
+		r.File, r.Name, strconv.Itoa(r.Line),
+		strconv.Itoa(r.Cyclomatic), strconv.Itoa(r.Cognitive), strconv.Itoa(r.Nesting),
+		strconv.Itoa(r.Lines), strconv.Itoa(r.CodeLines),
+	}
+}
+
+func writeJSON(w io.Writer, records []record) error {
+	if records == nil {
+		records = []record{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeCSV(w io.Writer, records []record) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, r := range records {
+		cw.Write(r.fields())
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeText(w io.Writer, records []record) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "function\tcyclomatic\tcognitive\tnesting\tlines\tcode\n")
+	for _, r := range records {
+		fmt.Fprintf(tw, "%s:%d:%s\t%d\t%d\t%d\t%d\t%d\n", r.File, r.Line, r.Name, r.Cyclomatic, r.Cognitive, r.Nesting, r.Lines, r.CodeLines)
+	}
+	return tw.Flush()
+}
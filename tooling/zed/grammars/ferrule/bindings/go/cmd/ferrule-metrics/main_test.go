@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.fe": "function simple() -> i32 {\n  return 0;\n}\n",
+		"b.fe": "function branchy(n: i32) -> i32 {\n  if n > 0 && n < 9 {\n    return 1;\n  }\n  return 0;\n}\n",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestFormats(t *testing.T) {
+	dir := setup(t)
+	defer func() { *format = "text" }()
+
+	*format = "json"
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var records []record
+	if err := json.Unmarshal(stdout.Bytes(), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Name != "simple" || records[1].Name != "branchy" {
+		t.Fatalf("records %+v", records)
+	}
+	if r := records[1]; r.Cyclomatic != 3 || r.Cognitive != 2 || r.Nesting != 1 || r.Lines != 6 {
+		t.Errorf("branchy: %+v", r)
+	}
+
+	*format = "csv"
+	stdout.Reset()
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || lines[0] != "file,function,line,cyclomatic,cognitive,nesting,lines,code_lines" {
+		t.Errorf("csv output:\n%s", stdout.String())
+	}
+	if want := filepath.Join(dir, "b.fe") + ",branchy,1,3,2,1,6,6"; lines[2] != want {
+		t.Errorf("csv row %q, want %q", lines[2], want)
+	}
+
+	*format = "yaml"
+	if code := run([]string{dir}, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown format: exit code %d, want 2", code)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	dir := setup(t)
+	*maxCyclomatic = 2
+	defer func() { *maxCyclomatic = 0 }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	want := filepath.Join(dir, "b.fe") + ":1: branchy has cyclomatic complexity 3, over the budget of 2\n"
+	if got := stderr.String(); got != want {
+		t.Errorf("stderr %q, want %q", got, want)
+	}
+}
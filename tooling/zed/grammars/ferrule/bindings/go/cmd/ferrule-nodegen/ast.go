@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// importBase is the import path the generated ast package uses to reach the
+// kind and field packages.
+const importBase = "github.com/karol-broda/ferrule/bindings/go"
+
+// generateAST emits a wrapper type per named node kind along with accessors
+// for its fields and, where the set of child kinds is small, its children,
+// and the slabs an Arena allocates the wrappers from.
+func generateAST(pkg string, types []nodeType) ([]byte, error) {
+	var named []nodeType
+	for _, t := range types {
+		if t.Named {
+			named = append(named, t)
+		}
+	}
+	sort.Slice(named, func(i, j int) bool { return named[i].Type < named[j].Type })
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n")
+	fmt.Fprintf(&b, "\ttree_sitter \"github.com/tree-sitter/go-tree-sitter\"\n\n")
+	fmt.Fprintf(&b, "\t\"%s/field\"\n", importBase)
+	fmt.Fprintf(&b, "\t\"%s/kind\"\n", importBase)
+	fmt.Fprintf(&b, ")\n\n")
+
+	fmt.Fprintf(&b, "func wrapNamed(a *Arena, n *tree_sitter.Node) Node {\n")
+	fmt.Fprintf(&b, "\tswitch n.Kind() {\n")
+	for _, t := range named {
+		fmt.Fprintf(&b, "\tcase kind.%s:\n\t\treturn new%s(a, n)\n", goName(t.Type), goName(t.Type))
+	}
+	fmt.Fprintf(&b, "\t}\n\treturn newUnknown(a, n)\n}\n\n")
+
+	fmt.Fprintf(&b, "// slabs holds a slab per wrapper type.\n")
+	fmt.Fprintf(&b, "type slabs struct {\n")
+	for _, t := range named {
+		fmt.Fprintf(&b, "\t%s slab[%s]\n", goName(t.Type), goName(t.Type))
+	}
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "func (s *slabs) reset() {\n")
+	for _, t := range named {
+		fmt.Fprintf(&b, "\ts.%s.reset()\n", goName(t.Type))
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	for _, t := range named {
+		name := goName(t.Type)
+		fmt.Fprintf(&b, "// %s wraps a %s node.\n", name, t.Type)
+		fmt.Fprintf(&b, "type %s struct{ node }\n\n", name)
+		fmt.Fprintf(&b, "func new%s(a *Arena, n *tree_sitter.Node) *%s {\n", name, name)
+		fmt.Fprintf(&b, "\tif a == nil {\n\t\treturn &%s{node{n: n}}\n\t}\n", name)
+		fmt.Fprintf(&b, "\tw := a.slabs.%s.alloc()\n", name)
+		fmt.Fprintf(&b, "\tw.node = node{n: n, a: a}\n\treturn w\n}\n\n")
+
+		fields := make([]string, 0, len(t.Fields))
+		for f := range t.Fields {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		used := map[string]bool{}
+		for _, f := range fields {
+			info := t.Fields[f]
+			method := goName(f)
+			used[method] = true
+			writeFieldAccessor(&b, name, f, method, info)
+		}
+		if t.Children != nil {
+			writeChildAccessors(&b, name, *t.Children, used)
+		}
+	}
+
+	return format.Source(b.Bytes())
+}
+
+const maxTypedChildren = 2
+
+func writeFieldAccessor(b *bytes.Buffer, recv, field, method string, info childInfo) {
+	result, conv := resultType(info.Types)
+	if info.Multiple {
+		fmt.Fprintf(b, "// %s returns the nodes of the %q field.\n", method, field)
+		fmt.Fprintf(b, "func (n *%s) %s() []%s {\n", recv, method, result)
+		fmt.Fprintf(b, "\tvar out []%s\n", result)
+		fmt.Fprintf(b, "\tfor _, c := range n.childrenByField(field.%s) {\n", method)
+		fmt.Fprintf(b, "\t\tout = append(out, %s)\n", conv("c"))
+		fmt.Fprintf(b, "\t}\n\treturn out\n}\n\n")
+		return
+	}
+	fmt.Fprintf(b, "// %s returns the %q field, or nil when it is absent.\n", method, field)
+	fmt.Fprintf(b, "func (n *%s) %s() %s {\n", recv, method, pointerTo(result))
+	fmt.Fprintf(b, "\tc := n.n.ChildByFieldName(field.%s)\n", method)
+	fmt.Fprintf(b, "\tif c == nil {\n\t\treturn nil\n\t}\n")
+	fmt.Fprintf(b, "\treturn %s\n}\n\n", conv("c"))
+}
+
+// writeChildAccessors emits an accessor per named child type that is not
+// already reachable through a field. Rules whose children can be any of a
+// large set of kinds (most expressions) are left to Children and the
+// hand-written accessors instead.
+func writeChildAccessors(b *bytes.Buffer, recv string, info childInfo, used map[string]bool) {
+	if len(info.Types) > maxTypedChildren {
+		return
+	}
+	for _, ref := range info.Types {
+		if !ref.Named {
+			continue
+		}
+		typ := goName(ref.Type)
+		method := typ
+		if info.Multiple {
+			method = plural(typ)
+		}
+		if used[method] || method == recv {
+			continue
+		}
+		used[method] = true
+		if info.Multiple {
+			fmt.Fprintf(b, "// %s returns the %s children.\n", method, ref.Type)
+			fmt.Fprintf(b, "func (n *%s) %s() []*%s {\n", recv, method, typ)
+			fmt.Fprintf(b, "\tvar out []*%s\n", typ)
+			fmt.Fprintf(b, "\tfor _, c := range n.namedChildrenOfKind(kind.%s) {\n", typ)
+			fmt.Fprintf(b, "\t\tout = append(out, new%s(n.a, c))\n", typ)
+			fmt.Fprintf(b, "\t}\n\treturn out\n}\n\n")
+			continue
+		}
+		fmt.Fprintf(b, "// %s returns the %s child, or nil when it is absent.\n", method, ref.Type)
+		fmt.Fprintf(b, "func (n *%s) %s() *%s {\n", recv, method, typ)
+		fmt.Fprintf(b, "\tcs := n.namedChildrenOfKind(kind.%s)\n", typ)
+		fmt.Fprintf(b, "\tif len(cs) == 0 {\n\t\treturn nil\n\t}\n")
+		fmt.Fprintf(b, "\treturn new%s(n.a, cs[0])\n}\n\n", typ)
+	}
+}
+
+// resultType picks the Go type returned for a field. Fields with a single
+// named type get the concrete wrapper, everything else falls back to Node.
+func resultType(refs []typeRef) (string, func(string) string) {
+	if len(refs) == 1 && refs[0].Named {
+		typ := goName(refs[0].Type)
+		return typ, func(expr string) string { return fmt.Sprintf("new%s(n.a, %s)", typ, expr) }
+	}
+	return "Node", func(expr string) string { return fmt.Sprintf("n.wrap(%s)", expr) }
+}
+
+func pointerTo(typ string) string {
+	if typ == "Node" {
+		return typ
+	}
+	return "*" + typ
+}
+
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"):
+		return name + "es"
+	case strings.HasSuffix(name, "y"):
+		return strings.TrimSuffix(name, "y") + "ies"
+	}
+	return name + "s"
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+)
+
+const header = "// Code generated by ferrule-nodegen from node-types.json. DO NOT EDIT.\n\n"
+
+var keywordPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// generateKinds emits a constant for every named node kind and for every
+// anonymous token that is spelled like a word. Punctuation and operators
+// are left out since they have no natural Go name.
+func generateKinds(pkg string, types []nodeType) ([]byte, error) {
+	var named, keywords []string
+	seen := map[string]bool{}
+	for _, t := range types {
+		key := fmt.Sprint(t.Named, t.Type)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		switch {
+		case t.Named:
+			named = append(named, t.Type)
+		case keywordPattern.MatchString(t.Type):
+			keywords = append(keywords, t.Type)
+		}
+	}
+	sort.Strings(named)
+	sort.Strings(keywords)
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "// Package %s defines the node kinds of the ferrule grammar.\n", pkg)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	b.WriteString("// Named node kinds.\nconst (\n")
+	fmt.Fprintf(&b, "\tError = %q\n", "ERROR")
+	for _, k := range named {
+		fmt.Fprintf(&b, "\t%s = %q\n", goName(k), k)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Anonymous keyword tokens.\nconst (\n")
+	for _, k := range keywords {
+		fmt.Fprintf(&b, "\tKeyword%s = %q\n", goName(k), k)
+	}
+	b.WriteString(")\n")
+
+	return format.Source(b.Bytes())
+}
+
+// generateFields emits a constant for every field name used by any rule,
+// and the table of the fields each node kind requires.
+func generateFields(pkg string, types []nodeType) ([]byte, error) {
+	seen := map[string]bool{}
+	var fields, kinds []string
+	required := map[string][]string{}
+	for _, t := range types {
+		for f, info := range t.Fields {
+			if !seen[f] {
+				seen[f] = true
+				fields = append(fields, f)
+			}
+			if info.Required && t.Named {
+				required[t.Type] = append(required[t.Type], f)
+			}
+		}
+		if len(required[t.Type]) > 0 {
+			kinds = append(kinds, t.Type)
+		}
+	}
+	sort.Strings(fields)
+	sort.Strings(kinds)
+
+	var b bytes.Buffer
+	b.WriteString(header)
+	fmt.Fprintf(&b, "// Package %s defines the field names of the ferrule grammar.\n", pkg)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("const (\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\t%s = %q\n", goName(f), f)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("// Required lists, by node kind, the fields that every node of that kind\n")
+	b.WriteString("// has when it is parsed without errors.\n")
+	b.WriteString("var Required = map[string][]string{\n")
+	for _, k := range kinds {
+		names := required[k]
+		sort.Strings(names)
+		fmt.Fprintf(&b, "\t%q: {", k)
+		for i, f := range names {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(goName(f))
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("}\n")
+
+	return format.Source(b.Bytes())
+}
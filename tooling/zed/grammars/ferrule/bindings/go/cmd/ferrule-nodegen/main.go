@@ -0,0 +1,112 @@
+// Command ferrule-nodegen generates Go sources from the grammar's
+// node-types.json.
+//
+// It is meant to be run through go:generate and knows three outputs:
+//
+//	ferrule-nodegen -what kinds  -p kind  -o kinds.go  node-types.json
+//	ferrule-nodegen -what fields -p field -o fields.go node-types.json
+//	ferrule-nodegen -what ast    -p ast   -o nodes.go  node-types.json
+//
+// kinds emits a constant per node kind, fields a constant per field name and
+// ast the typed node wrappers of the ast package.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+type nodeType struct {
+	Type     string               `json:"type"`
+	Named    bool                 `json:"named"`
+	Fields   map[string]childInfo `json:"fields"`
+	Children *childInfo           `json:"children"`
+}
+
+type childInfo struct {
+	Multiple bool      `json:"multiple"`
+	Required bool      `json:"required"`
+	Types    []typeRef `json:"types"`
+}
+
+type typeRef struct {
+	Type  string `json:"type"`
+	Named bool   `json:"named"`
+}
+
+var generators = map[string]func(pkg string, types []nodeType) ([]byte, error){
+	"kinds":  generateKinds,
+	"fields": generateFields,
+	"ast":    generateAST,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ferrule-nodegen: ")
+
+	what := flag.String("what", "kinds", "output to generate: kinds, fields or ast")
+	output := flag.String("o", "", "output file (default stdout)")
+	pkg := flag.String("p", "", "package name (default the value of -what)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-nodegen [flags] node-types.json\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	gen, ok := generators[*what]
+	if !ok {
+		log.Fatalf("unknown output %q", *what)
+	}
+	if *pkg == "" {
+		*pkg = strings.TrimSuffix(*what, "s")
+	}
+
+	types, err := load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := gen(*pkg, types)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func load(path string) ([]nodeType, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var types []nodeType
+	if err := json.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return types, nil
+}
+
+// goName converts a snake_case grammar name to an exported Go identifier.
+func goName(kind string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(kind, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]))
+		b.WriteString(part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var sample = []nodeType{
+	{Type: "source_file", Named: true, Children: &childInfo{Multiple: true, Types: []typeRef{{Type: "let_binding", Named: true}}}},
+	{Type: "let_binding", Named: true, Fields: map[string]childInfo{
+		"name": {Required: true, Types: []typeRef{{Type: "identifier", Named: true}}},
+	}},
+	{Type: "identifier", Named: true},
+	{Type: "let", Named: false},
+	{Type: "+", Named: false},
+}
+
+func TestGenerateKinds(t *testing.T) {
+	src, err := generateKinds("kind", sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(src)
+	for _, want := range []string{`LetBinding = "let_binding"`, `KeywordLet = "let"`, `Error = "ERROR"`} {
+		if !strings.Contains(strings.Join(strings.Fields(out), " "), want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"+"`) {
+		t.Errorf("operators should not get constants:\n%s", out)
+	}
+}
+
+func TestGenerateAST(t *testing.T) {
+	src, err := generateAST("ast", sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(src)
+	for _, want := range []string{
+		"func (n *LetBinding) Name() *Identifier",
+		"func (n *SourceFile) LetBindings() []*LetBinding",
+		"case kind.LetBinding:",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q", want)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{"function_declaration": "FunctionDeclaration", "i32": "I32", "_hidden": "Hidden"}
+	for in, want := range cases {
+		if got := goName(in); got != want {
+			t.Errorf("goName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerateFields(t *testing.T) {
+	src, err := generateFields("field", sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{`Name = "name"`, `"let_binding": {Name},`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Command ferrule-playground serves a local web page for trying out
+// tree-sitter queries against ferrule code.
+//
+//	ferrule-playground [flags]
+//
+// The page has an editor for ferrule source and one for a query. As
+// either changes, the source is parsed with the Go binding and the page
+// shows the syntax tree, with syntax errors, and the source with the
+// captures of the query painted over it and listed by match. The queries
+// that ship with the grammar can be loaded as a starting point. The flags
+// are:
+//
+//	-addr host:port   address to listen on (default localhost:8080)
+//
+// The server is meant for local use: it has no authentication and parses
+// whatever it is sent.
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var addr = flag.String("addr", "localhost:8080", "`address` to listen on")
+
+//go:embed index.html
+var page []byte
+
+// bundled are the queries shipped with the grammar, by name.
+var bundled = map[string][]byte{
+	"highlights":  queries.Highlights,
+	"locals":      queries.Locals,
+	"injections":  queries.Injections,
+	"tags":        queries.Tags,
+	"folds":       queries.Folds,
+	"indents":     queries.Indents,
+	"textobjects": queries.TextObjects,
+}
+
+// maxRequest bounds the size of a parse request, and maxText the text of
+// a capture listed.
+const (
+	maxRequest = 4 << 20
+	maxText    = 60
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-playground [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.Printf("ferrule-playground: serving on http://%s", *addr)
+	if err := http.ListenAndServe(*addr, handler()); err != nil {
+		log.Fatalf("ferrule-playground: %v", err)
+	}
+}
+
+func handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	mux.HandleFunc("GET /queries/{name}", func(w http.ResponseWriter, r *http.Request) {
+		q, ok := bundled[r.PathValue("name")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(q)
+	})
+	mux.HandleFunc("POST /parse", serveParse)
+	return mux
+}
+
+// request is the body of a parse request.
+type request struct {
+	Source string `json:"source"`
+	Query  string `json:"query"`
+}
+
+// response is the result of a parse request.
+type response struct {
+	// Tree is the S-expression of the syntax tree; see dump.SExpr.
+	Tree        string       `json:"tree"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+	// QueryError is set if the query does not compile.
+	QueryError *diagnostic `json:"queryError,omitempty"`
+	Captures   []capture   `json:"captures"`
+	// Segments split the source at the boundaries of the captures.
+	Segments []segment `json:"segments"`
+}
+
+// diagnostic is a problem at a one-based line and column.
+type diagnostic struct {
+	Line    uint   `json:"line"`
+	Column  uint   `json:"column"`
+	Message string `json:"message"`
+}
+
+type capture struct {
+	Name    string `json:"name"`
+	Pattern uint   `json:"pattern"`
+	Match   uint   `json:"match"`
+	Kind    string `json:"kind"`
+	Line    uint   `json:"line"`
+	Column  uint   `json:"column"`
+	Text    string `json:"text"`
+	start   uint
+	end     uint
+}
+
+// segment is a run of the source and the innermost capture covering it,
+// if any.
+type segment struct {
+	Text    string `json:"text"`
+	Capture string `json:"capture,omitempty"`
+}
+
+func serveParse(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequest)).Decode(&req); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := parse(r.Context(), req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parse(ctx context.Context, req request) (*response, error) {
+	src := []byte(req.Source)
+	tree, err := ferrule.Parse(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	resp := &response{Tree: dump.SExpr(tree.RootNode()), Diagnostics: []diagnostic{}, Captures: []capture{}}
+	for _, d := range tree.Diagnostics() {
+		resp.Diagnostics = append(resp.Diagnostics, diagnostic{
+			Line:    d.Range.StartPoint.Row + 1,
+			Column:  d.Range.StartPoint.Column + 1,
+			Message: d.Message,
+		})
+	}
+	if req.Query != "" {
+		q, err := query.New(req.Query)
+		var qerr *tree_sitter.QueryError
+		switch {
+		case errors.As(err, &qerr):
+			resp.QueryError = &diagnostic{Line: qerr.Row + 1, Column: qerr.Column + 1, Message: qerr.Message}
+		case err != nil:
+			resp.QueryError = &diagnostic{Line: 1, Column: 1, Message: err.Error()}
+		default:
+			for c, n := range q.Matches(ast.Root(tree.Raw()), src) {
+				raw := n.Raw()
+				resp.Captures = append(resp.Captures, capture{
+					Name:    c.Name,
+					Pattern: c.Pattern,
+					Match:   c.Match,
+					Kind:    raw.Kind(),
+					Line:    raw.StartPosition().Row + 1,
+					Column:  raw.StartPosition().Column + 1,
+					Text:    shorten(raw.Utf8Text(src)),
+					start:   raw.StartByte(),
+					end:     raw.EndByte(),
+				})
+			}
+			q.Close()
+		}
+	}
+	resp.Segments = segments(src, resp.Captures)
+	return resp, nil
+}
+
+// segments splits src at the boundaries of the captures, naming for each
+// run the narrowest capture covering it, the latest one on a tie.
+func segments(src []byte, captures []capture) []segment {
+	cuts := []uint{0, uint(len(src))}
+	for _, c := range captures {
+		cuts = append(cuts, c.start, c.end)
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i] < cuts[j] })
+	out := []segment{}
+	for i := 0; i+1 < len(cuts); i++ {
+		start, end := cuts[i], cuts[i+1]
+		if start == end {
+			continue
+		}
+		name, width := "", uint(0)
+		for _, c := range captures {
+			if c.start <= start && end <= c.end && (name == "" || c.end-c.start <= width) {
+				name, width = c.Name, c.end-c.start
+			}
+		}
+		if n := len(out); n > 0 && out[n-1].Capture == name {
+			out[n-1].Text += string(src[start:end])
+			continue
+		}
+		out = append(out, segment{Text: string(src[start:end]), Capture: name})
+	}
+	return out
+}
+
+// shorten returns the first line of s, cut to maxText runes.
+func shorten(s string) string {
+	line, _, more := strings.Cut(s, "\n")
+	if r := []rune(line); len(r) > maxText {
+		line, more = string(r[:maxText]), true
+	}
+	if more {
+		line += "…"
+	}
+	return line
+}
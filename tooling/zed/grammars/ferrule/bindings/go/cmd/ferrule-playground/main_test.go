@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, srv *httptest.Server, req request) *response {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(srv.URL+"/parse", "application/json", strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %s", resp.Status)
+	}
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
+
+func TestParse(t *testing.T) {
+	srv := httptest.NewServer(handler())
+	defer srv.Close()
+
+	r := post(t, srv, request{
+		Source: "function add(x: i32) -> i32 { return x; }\n",
+		Query:  "(function_declaration name: (identifier) @function) @decl",
+	})
+	if !strings.HasPrefix(r.Tree, "(source_file\n  (function_declaration") || len(r.Diagnostics) != 0 || r.QueryError != nil {
+		t.Errorf("tree %q, diagnostics %v, query error %v", r.Tree, r.Diagnostics, r.QueryError)
+	}
+	if len(r.Captures) != 2 || r.Captures[0].Name != "decl" || r.Captures[1].Text != "add" || r.Captures[1].Column != 10 {
+		t.Errorf("captures %+v", r.Captures)
+	}
+	var got []string
+	for _, s := range r.Segments {
+		got = append(got, s.Capture+":"+s.Text)
+	}
+	want := "decl:function |function:add|decl:(x: i32) -> i32 { return x; }|:\n"
+	if strings.Join(got, "|") != want {
+		t.Errorf("segments %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	r = post(t, srv, request{Source: "function f( -> i32 {}", Query: "(nonsense) @x"})
+	if len(r.Diagnostics) == 0 || r.QueryError == nil || r.QueryError.Line != 1 {
+		t.Errorf("diagnostics %v, query error %v", r.Diagnostics, r.QueryError)
+	}
+	if len(r.Segments) != 1 || r.Segments[0].Capture != "" {
+		t.Errorf("segments %+v", r.Segments)
+	}
+}
+
+func TestPages(t *testing.T) {
+	srv := httptest.NewServer(handler())
+	defer srv.Close()
+	for path, want := range map[string]int{
+		"/":                   http.StatusOK,
+		"/queries/highlights": http.StatusOK,
+		"/queries/nope":       http.StatusNotFound,
+		"/other":              http.StatusNotFound,
+	} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/udiff"
+)
+
+// repo runs git at the top of a work tree. Paths are slash-separated and
+// relative to the top, as git prints them.
+type repo struct {
+	dir string
+}
+
+// openRepo returns the repository whose work tree holds dir.
+func openRepo(dir string) (*repo, error) {
+	out, err := (&repo{dir: dir}).git(nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	return &repo{dir: strings.TrimSpace(string(out))}, nil
+}
+
+func (r *repo) git(stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// path returns the path of the file at p in the work tree.
+func (r *repo) path(p string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(p))
+}
+
+// stagedFiles returns the files added, copied, modified or renamed in the
+// index.
+func (r *repo) stagedFiles() ([]string, error) {
+	out, err := r.git(nil, "diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// staged returns the staged contents of the file at p and the lines of
+// them that differ from HEAD.
+func (r *repo) staged(p string) ([]byte, *udiff.File, error) {
+	src, err := r.git(nil, "cat-file", "blob", ":"+p)
+	if err != nil {
+		return nil, nil, err
+	}
+	diff, err := r.git(nil, "diff", "--cached", "-U0", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", "--", p)
+	if err != nil {
+		return nil, nil, err
+	}
+	files, err := udiff.Parse(diff)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range files {
+		if files[i].Path == p {
+			return src, &files[i], nil
+		}
+	}
+	return src, &udiff.File{Path: p}, nil
+}
+
+// unstaged reports whether the file at p in the work tree differs from
+// its staged version.
+func (r *repo) unstaged(p string) (bool, error) {
+	out, err := r.git(nil, "diff", "--name-only", "--no-ext-diff", "--", p)
+	return len(bytes.TrimSpace(out)) > 0, err
+}
+
+// stage replaces the staged contents of the file at p with src, keeping
+// its mode.
+func (r *repo) stage(p string, src []byte) error {
+	out, err := r.git(nil, "ls-files", "--stage", "-z", "--", p)
+	if err != nil {
+		return err
+	}
+	mode, _, ok := strings.Cut(string(out), " ")
+	if !ok {
+		return errors.New("git ls-files: " + p + " is not staged")
+	}
+	hash, err := r.git(src, "hash-object", "-w", "--no-filters", "--stdin")
+	if err != nil {
+		return err
+	}
+	_, err = r.git(nil, "update-index", "--cacheinfo", mode+","+strings.TrimSpace(string(hash))+","+p)
+	return err
+}
@@ -0,0 +1,339 @@
+// Command ferrule-precommit formats and lints the ferrule code staged for
+// a commit, as a git pre-commit hook.
+//
+//	ferrule-precommit [flags]
+//
+// Only the staged hunks are looked at, so a commit is not held up by
+// unformatted or suspicious code it does not touch. Every staged .fe file
+// has the constructs holding its changed lines formatted, as by ferrulefmt
+// on a range, and the result is staged and written to the work tree. The
+// analyzers of ferrule-lint then run on the staged contents, those
+// looking across the project, as deadcode does, with the index of its
+// work tree, and the findings and syntax errors on changed lines are
+// printed as
+//
+//	path:line:col: message (analyzer)
+//
+// with paths relative to the top of the work tree and positions in the
+// staged contents, which may differ from the work tree. A file with
+// changes that are not staged is not rewritten, since that would mix them
+// into the commit; the hook reports that its staged changes are not
+// formatted instead. The flags are:
+//
+//	-check     report unformatted staged changes instead of formatting them
+//	-install   write a pre-commit hook running ferrule-precommit, passing
+//	           on -check, to the repository holding the current directory
+//
+// The project's ferrule.toml applies as for ferrulefmt and ferrule-lint,
+// so generated files are left unformatted and only checked for syntax
+// errors.
+// The exit status is 1 if anything was reported, which aborts the commit,
+// and 2 on errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/internal/udiff"
+)
+
+var (
+	check   = flag.Bool("check", false, "report unformatted staged changes instead of formatting them")
+	install = flag.Bool("install", false, "write the pre-commit hook of the repository")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-precommit [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	os.Exit(run(".", os.Stdout, os.Stderr))
+}
+
+// run checks the changes staged in the repository holding dir.
+func run(dir string, stdout, stderr io.Writer) int {
+	r, err := openRepo(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-precommit: %v\n", err)
+		return 2
+	}
+	if *install {
+		if err := installHook(r, stdout); err != nil {
+			fmt.Fprintf(stderr, "ferrule-precommit: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	paths, err := r.stagedFiles()
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-precommit: %v\n", err)
+		return 2
+	}
+	status := 0
+	indexes := make(map[string]*index.Index)
+	for _, p := range paths {
+		if path.Ext(p) != ".fe" {
+			continue
+		}
+		found, err := precommit(r, p, indexes, stdout)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "ferrule-precommit: %s: %v\n", p, err)
+			status = 2
+		case found && status == 0:
+			status = 1
+		}
+	}
+	return status
+}
+
+// precommit formats and lints the staged changes of the file at p and
+// reports whether anything was found. indexes holds the project indexes
+// built so far, by root.
+func precommit(r *repo, p string, indexes map[string]*index.Index, stdout io.Writer) (bool, error) {
+	name := r.path(p)
+	cfg, err := config.ForDir(filepath.Dir(name))
+	if err != nil {
+		return false, err
+	}
+	if cfg.Ignored(name, false) {
+		return false, nil
+	}
+	enabled, err := cfg.Analyzers(passes.All)
+	if err != nil {
+		return false, err
+	}
+	src, changed, err := r.staged(p)
+	if err != nil {
+		return false, err
+	}
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return false, err
+	}
+	defer func() { tree.Close() }()
+	gen := cfg.IsGenerated(name, src)
+	if gen && !cfg.Generated.Lint {
+		enabled = nil
+	}
+
+	found := false
+	var formatted []byte
+	if !gen || cfg.Generated.Format {
+		if formatted, err = formatChanges(tree, changed, cfg.Format); err != nil {
+			return false, err
+		}
+	}
+	if formatted != nil {
+		partial, err := r.unstaged(p)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case *check:
+			fmt.Fprintf(stdout, "%s: staged changes are not formatted\n", p)
+			found = true
+		case partial:
+			fmt.Fprintf(stdout, "%s: staged changes are not formatted; the file has unstaged changes, so it was left alone\n", p)
+			found = true
+		default:
+			if err := write(name, formatted); err != nil {
+				return false, err
+			}
+			if err := r.stage(p, formatted); err != nil {
+				return false, err
+			}
+			if src, changed, err = r.staged(p); err != nil {
+				return false, err
+			}
+			tree.Close()
+			if tree, err = ferrule.Parse(context.Background(), src); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if tree.HasError() {
+		for _, d := range tree.Diagnostics() {
+			if changed.Overlaps(int(d.Range.StartPoint.Row)+1, int(d.Range.EndPoint.Row)+1) {
+				fmt.Fprintf(stdout, "%s:%s\n", p, d)
+				found = true
+			}
+		}
+		return found, nil
+	}
+	var idx *index.Index
+	rel := p
+	if slices.ContainsFunc(enabled, func(a *analysis.Analyzer) bool { return a.NeedsIndex }) {
+		if idx, err = projectIndex(r, cfg, indexes); err != nil {
+			return false, err
+		}
+		if rel, err = filepath.Rel(idx.Root(), name); err != nil {
+			return false, err
+		}
+		rel = filepath.ToSlash(rel)
+	}
+	diags, err := analysis.RunProject(idx, rel, tree, enabled...)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range diags {
+		if changed.Overlaps(int(d.Range.StartPoint.Row)+1, int(d.Range.EndPoint.Row)+1) {
+			fmt.Fprintf(stdout, "%s:%s\n", p, d)
+			found = true
+		}
+	}
+	return found, nil
+}
+
+// projectIndex returns the index of the work tree below the directory of
+// cfg or, without a configuration file, below the top of r, building it
+// unless it is in indexes.
+func projectIndex(r *repo, cfg *config.Config, indexes map[string]*index.Index) (*index.Index, error) {
+	root := cfg.Dir
+	if root == "" {
+		root = r.dir
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if idx := indexes[root]; idx != nil {
+		return idx, nil
+	}
+	idx := index.New(root)
+	if err := idx.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+	indexes[root] = idx
+	return idx, nil
+}
+
+// formatChanges returns the source of tree with the constructs holding the
+// changed lines formatted, or nil if they are formatted already. Changes
+// within syntax errors are left alone; the errors are reported instead.
+func formatChanges(tree *ferrule.Tree, changed *udiff.File, opts format.Options) ([]byte, error) {
+	src := tree.Source()
+	lines := strings.Split(string(src), "\n")
+	var edits []format.Edit
+	for _, l := range changed.Lines {
+		start, end, ok := span(lines, l)
+		if !ok {
+			continue
+		}
+		e, err := opts.TreeRange(tree, start, end)
+		var syntax *format.SyntaxError
+		if errors.As(err, &syntax) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.Text != string(src[e.Start:e.End]) {
+			edits = append(edits, e)
+		}
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	// An edit of a construct holding another one formats both.
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].Start != edits[j].Start {
+			return edits[i].Start < edits[j].Start
+		}
+		return edits[i].End > edits[j].End
+	})
+	kept := edits[:1]
+	for _, e := range edits[1:] {
+		if e.Start >= kept[len(kept)-1].End {
+			kept = append(kept, e)
+		}
+	}
+	out := src
+	for i := len(kept) - 1; i >= 0; i-- {
+		out = kept[i].Apply(out)
+	}
+	return out, nil
+}
+
+// span returns the points from the first to the last character other than
+// white space of the lines l, numbered from one, of lines. It returns ok
+// false if the lines are blank.
+func span(lines []string, l udiff.Lines) (start, end tree_sitter.Point, ok bool) {
+	for row := l.Start - 1; row < l.End && row < len(lines); row++ {
+		text := strings.TrimRight(lines[row], " \t\r")
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if !ok {
+			start = tree_sitter.Point{Row: uint(row), Column: uint(len(text) - len(trimmed))}
+			ok = true
+		}
+		end = tree_sitter.Point{Row: uint(row), Column: uint(len(text))}
+	}
+	return start, end, ok
+}
+
+func write(name string, src []byte) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, src, info.Mode().Perm())
+}
+
+// hookMarker identifies hooks written by installHook, which may be
+// overwritten.
+const hookMarker = "# Written by ferrule-precommit -install."
+
+// installHook writes the pre-commit hook of r, refusing to replace a hook
+// of another origin.
+func installHook(r *repo, stdout io.Writer) error {
+	out, err := r.git(nil, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return err
+	}
+	hook := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(r.dir, hook)
+	}
+	if old, err := os.ReadFile(hook); err == nil && !strings.Contains(string(old), hookMarker) {
+		return fmt.Errorf("%s exists; call ferrule-precommit from it instead", hook)
+	}
+	command := "exec ferrule-precommit"
+	if *check {
+		command += " -check"
+	}
+	if err := os.MkdirAll(filepath.Dir(hook), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\n"+hookMarker+"\n"+command+"\n"), 0o755); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "installed %s\n", hook)
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const committed = `function old() -> i32 { const stale = 1; return 2; }
+
+function g() -> i32 {
+  return 1;
+}
+`
+
+// setup returns a repository with committed committed as a.fe.
+func setup(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	gitIn(t, dir, "init", "-q")
+	writeFile(t, dir, "a.fe", committed)
+	gitIn(t, dir, "add", "a.fe")
+	gitIn(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func gitIn(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, dir, name, src string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := setup(t)
+	writeFile(t, dir, "a.fe", committed+"\nfunction h() -> i32 { const x=1; return 3; }\n")
+	gitIn(t, dir, "add", "a.fe")
+
+	var stdout, stderr bytes.Buffer
+	if code := run(dir, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := "a.fe:7:10: function h is never used (deadcode)\na.fe:8:9: x declared and not used (unused)\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+	want := committed + "\nfunction h() -> i32 {\n  const x = 1;\n  return 3;\n}\n"
+	if got := gitIn(t, dir, "show", ":a.fe"); got != want {
+		t.Errorf("staged:\n%s\nwant:\n%s", got, want)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.fe")); string(got) != want {
+		t.Errorf("work tree:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPartiallyStaged(t *testing.T) {
+	dir := setup(t)
+	staged := committed + "\nfunction h() -> i32 { return 3; }\n"
+	writeFile(t, dir, "a.fe", staged)
+	gitIn(t, dir, "add", "a.fe")
+	writeFile(t, dir, "a.fe", staged+"\nfunction i() -> i32 { return 4; }\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := run(dir, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "a.fe: staged changes are not formatted") {
+		t.Errorf("got %q", stdout.String())
+	}
+	if got := gitIn(t, dir, "show", ":a.fe"); got != staged {
+		t.Errorf("staged contents changed:\n%s", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := setup(t)
+	writeFile(t, dir, "a.fe", committed+"\npub function h() -> i32 { return 3; }\n")
+	gitIn(t, dir, "add", "a.fe")
+	*check = true
+	defer func() { *check = false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(dir, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1; stderr: %s", code, stderr.String())
+	}
+	if want := "a.fe: staged changes are not formatted\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+
+	gitIn(t, dir, "reset", "-q")
+	stdout.Reset()
+	if code := run(dir, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("nothing staged: exit code %d, output %q", code, stdout.String())
+	}
+}
+
+// TestDeadcode checks that deadcode looks at the other files of the
+// project, as in ferrule-lint: h is called from b.fe.
+func TestDeadcode(t *testing.T) {
+	dir := setup(t)
+	writeFile(t, dir, "b.fe", "function main() -> i32 {\n  return h();\n}\n")
+	writeFile(t, dir, "a.fe", committed+"\nfunction h() -> i32 {\n  return 3;\n}\n")
+	gitIn(t, dir, "add", "a.fe")
+
+	var stdout, stderr bytes.Buffer
+	if code := run(dir, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("exit code %d, output %q; stderr: %s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestInstall(t *testing.T) {
+	dir := setup(t)
+	*install = true
+	defer func() { *install = false }()
+
+	var stdout, stderr bytes.Buffer
+	for i := 0; i < 2; i++ {
+		if code := run(dir, &stdout, &stderr); code != 0 {
+			t.Fatalf("exit code %d: %s", code, stderr.String())
+		}
+	}
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	got, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "\nexec ferrule-precommit\n") {
+		t.Errorf("hook:\n%s", got)
+	}
+
+	os.WriteFile(hook, []byte("#!/bin/sh\nmake lint\n"), 0o755)
+	if code := run(dir, &stdout, &stderr); code != 2 {
+		t.Errorf("foreign hook: exit code %d, want 2", code)
+	}
+}
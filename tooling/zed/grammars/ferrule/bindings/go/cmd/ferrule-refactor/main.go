@@ -0,0 +1,166 @@
+// Command ferrule-refactor performs refactorings on ferrule source files.
+//
+//	ferrule-refactor rename [-w] file:line:column newname
+//	ferrule-refactor extract [-w] file:line:column line:column
+//
+// The rename command renames the local name at the given position, where
+// line and column are one-based and the column counts bytes of the file
+// decoded to UTF-8, together with all its references in the file. The
+// rename is refused when it would change what any name in the file refers
+// to.
+//
+// The extract command moves the code from the first position up to the
+// second into a new function and calls it in its place; see
+// refactor.ExtractFunction.
+//
+// The result is printed to standard output unless -w is given, in which
+// case the file is rewritten in place, in the encoding it was read in; see
+// package charset.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+const usage = `usage: ferrule-refactor rename [-w] file:line:column newname
+       ferrule-refactor extract [-w] file:line:column line:column
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "rename" && args[0] != "extract" {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	set := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	set.SetOutput(stderr)
+	set.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		set.PrintDefaults()
+	}
+	write := set.Bool("w", false, "write result to (source) file instead of stdout")
+	if err := set.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if set.NArg() != 2 {
+		set.Usage()
+		return 2
+	}
+	path, p, err := parsePosition(set.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+		return 2
+	}
+	var end tree_sitter.Point
+	if args[0] == "extract" {
+		if end, err = parsePoint(set.Arg(1)); err != nil {
+			fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+			return 2
+		}
+	}
+	text, err := charset.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+		return 2
+	}
+	src := text.Source
+
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+		return 2
+	}
+	defer tree.Close()
+	var edits []refactor.Edit
+	switch args[0] {
+	case "rename":
+		edits, err = refactor.Rename(tree, p, set.Arg(1))
+	case "extract":
+		edits, err = refactor.ExtractFunction(tree, src, tree_sitter.Range{
+			StartByte: offset(src, p), EndByte: offset(src, end), StartPoint: p, EndPoint: end,
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", set.Arg(0), err)
+		return 1
+	}
+	out, err := text.Encode(refactor.Apply(src, edits))
+	if err == nil && text.Lossy {
+		err = fmt.Errorf("%s: not valid %v", path, text.Encoding)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+		return 2
+	}
+	if *write {
+		info, err := os.Stat(path)
+		if err == nil {
+			err = os.WriteFile(path, out, info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-refactor: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	stdout.Write(out)
+	return 0
+}
+
+// parsePosition splits file:line:column into the file name and a
+// zero-based point.
+func parsePosition(s string) (string, tree_sitter.Point, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i >= 0 {
+		i = strings.LastIndexByte(s[:i], ':')
+	}
+	if i <= 0 {
+		return "", tree_sitter.Point{}, fmt.Errorf("invalid position %q, want file:line:column", s)
+	}
+	p, err := parsePoint(s[i+1:])
+	if err != nil {
+		return "", tree_sitter.Point{}, fmt.Errorf("invalid position %q, want file:line:column", s)
+	}
+	return s[:i], p, nil
+}
+
+// parsePoint converts line:column to a zero-based point.
+func parsePoint(s string) (tree_sitter.Point, error) {
+	l, c, ok := strings.Cut(s, ":")
+	line, err1 := strconv.ParseUint(l, 10, 32)
+	col, err2 := strconv.ParseUint(c, 10, 32)
+	if !ok || err1 != nil || err2 != nil || line == 0 || col == 0 {
+		return tree_sitter.Point{}, fmt.Errorf("invalid position %q, want line:column", s)
+	}
+	return tree_sitter.Point{Row: uint(line - 1), Column: uint(col - 1)}, nil
+}
+
+// offset returns the byte offset of p in src, clamped to the end of its
+// line and of src.
+func offset(src []byte, p tree_sitter.Point) uint {
+	off := uint(0)
+	for row := uint(0); row < p.Row && off < uint(len(src)); off++ {
+		if src[off] == '\n' {
+			row++
+		}
+	}
+	for col := uint(0); col < p.Column && off < uint(len(src)) && src[off] != '\n'; col++ {
+		off++
+	}
+	return off
+}
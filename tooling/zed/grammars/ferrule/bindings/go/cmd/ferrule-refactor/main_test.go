@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const src = `function main(n: u32) -> u32 {
+  const x = n + 1;
+  return x * n;
+}
+`
+
+func TestRename(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.fe")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"rename", path + ":3:10", "total"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	want := strings.ReplaceAll(src, "x", "total")
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"rename", "-w", path + ":1:15", "count"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got, _ := os.ReadFile(path)
+	want = "function main(count: u32) -> u32 {\n  const x = count + 1;\n  return x * count;\n}\n"
+	if string(got) != want {
+		t.Errorf("after -w:\n%s\nwant:\n%s", got, want)
+	}
+
+	stderr.Reset()
+	if code := run([]string{"rename", path + ":2:9", "count"}, &stdout, &stderr); code != 1 {
+		t.Errorf("conflicting rename: exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "would capture") {
+		t.Errorf("conflicting rename: stderr %q", stderr.String())
+	}
+}
+
+func TestExtract(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.fe")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"extract", path + ":3:10", "3:15"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	want := "function main(n: u32) -> u32 {\n  const x = n + 1;\n  return extracted(x, n);\n}\n\nfunction extracted(x: u32, n: u32) -> u32 {\n  return x * n;\n}\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if code := run([]string{"extract", path + ":3:10", "3:12"}, &stdout, &stderr); code != 1 {
+		t.Errorf("partial selection: exit code %d, want 1", code)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{nil, {"inline"}, {"rename", "main.fe"}, {"rename", "main.fe:0:1", "x"}, {"extract", "main.fe:1:1", "2"}} {
+		if code := run(args, &stdout, &stderr); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
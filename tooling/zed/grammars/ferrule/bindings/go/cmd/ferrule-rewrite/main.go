@@ -0,0 +1,165 @@
+// Command ferrule-rewrite applies codemods to ferrule source files.
+//
+//	ferrule-rewrite -p pattern -r replacement [flags] [path ...]
+//	ferrule-rewrite -f rules [flags] [path ...]
+//
+// The rules are described in package rewrite. A single rule is given with
+// -p and -r; -f reads a file of rules instead. Directories are rewritten
+// recursively, skipping what .gitignore and .ferruleignore files exclude
+// as package walk does; without paths the current directory is.
+//
+// The rewritten files are printed to standard output unless -l or -w is
+// given. The flags are:
+//
+//	-l	list the files the rules change instead of printing them
+//	-w	write the result back to the source files instead of stdout
+//
+// Matches that overlap code already rewritten are reported as conflicts
+// and left alone; running the command again rewrites them once the
+// enclosing rewrite is done. A file is left unchanged when rewriting it
+// would introduce syntax errors. In either case the exit status is 1.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+	"github.com/karol-broda/ferrule/bindings/go/rewrite"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	pat       = flag.String("p", "", "the `pattern` to rewrite")
+	repl      = flag.String("r", "", "the `replacement` of the pattern")
+	rulesFile = flag.String("f", "", "read the rules from `file`")
+	list      = flag.Bool("l", false, "list files changed by the rules")
+	write     = flag.Bool("w", false, "write result to (source) file instead of stdout")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-rewrite (-p pattern -r replacement | -f rules) [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+func run(paths []string, stdout, stderr io.Writer) int {
+	rules, err := loadRules()
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-rewrite: %v\n", err)
+		return 2
+	}
+	defer func() {
+		for _, r := range rules {
+			r.Close()
+		}
+	}()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	status := 0
+	for _, path := range paths {
+		err := walk.WalkDir(nil, path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || (p != path && filepath.Ext(p) != ".fe") {
+				return nil
+			}
+			ok, err := process(p, rules, stdout, stderr)
+			if !ok {
+				status = max(status, 1)
+			}
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-rewrite: %v\n", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+func loadRules() ([]*rewrite.Rule, error) {
+	switch {
+	case *rulesFile != "" && (*pat != "" || *repl != ""):
+		return nil, fmt.Errorf("-f cannot be combined with -p and -r")
+	case *rulesFile != "":
+		data, err := os.ReadFile(*rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		return rewrite.ParseRules(data)
+	case *pat == "":
+		return nil, fmt.Errorf("no rules: use -p and -r, or -f")
+	}
+	r, err := rewrite.NewRule(*pat, *repl)
+	if err != nil {
+		return nil, err
+	}
+	return []*rewrite.Rule{r}, nil
+}
+
+// process rewrites one file. It reports false when matches were left
+// alone.
+func process(name string, rules []*rewrite.Rule, stdout, stderr io.Writer) (bool, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return false, err
+	}
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return false, err
+	}
+	defer tree.Close()
+	edits, conflicts := rewrite.Rewrite(tree, rules)
+	for _, c := range conflicts {
+		p := c.Range.StartPoint
+		fmt.Fprintf(stderr, "%s:%d:%d: match of %q overlaps one of %q\n", name, p.Row+1, p.Column+1, c.Rule.Pattern, c.With.Pattern)
+	}
+	ok := len(conflicts) == 0
+	out := refactor.Apply(src, edits)
+	if len(edits) > 0 {
+		after, err := ferrule.Parse(context.Background(), out)
+		if err != nil {
+			return false, err
+		}
+		worse := len(after.Diagnostics()) > len(tree.Diagnostics())
+		after.Close()
+		if worse {
+			fmt.Fprintf(stderr, "%s: rewrite would introduce syntax errors, file left unchanged\n", name)
+			out, ok = src, false
+		}
+	}
+
+	changed := !bytes.Equal(src, out)
+	if *list && changed {
+		fmt.Fprintln(stdout, name)
+	}
+	if *write && changed {
+		info, err := os.Stat(name)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+			return false, err
+		}
+	}
+	if !*list && !*write {
+		if _, err := stdout.Write(out); err != nil {
+			return false, err
+		}
+	}
+	return ok, nil
+}
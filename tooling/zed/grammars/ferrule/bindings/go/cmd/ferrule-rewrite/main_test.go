@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T, src string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "main.fe")
+	if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestWrite(t *testing.T) {
+	name := setup(t, "function main() -> Unit {\n  old(1);\n  other(2);\n}\n")
+	*pat, *repl, *write = "old($x)", "new($x, 0)", true
+	defer func() { *pat, *repl, *write = "", "", false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{filepath.Dir(name)}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "function main() -> Unit {\n  new(1, 0);\n  other(2);\n}\n"; string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRulesFile(t *testing.T) {
+	name := setup(t, "function main() -> Unit {\n  a(b(1));\n}\n")
+	rules := filepath.Join(t.TempDir(), "rules")
+	if err := os.WriteFile(rules, []byte("a($x)\n==>\nc($x)\n---\nb($x)\n==>\n$x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*rulesFile = rules
+	defer func() { *rulesFile = "" }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{name}, &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1 for a conflict", code)
+	}
+	if got, want := stdout.String(), "function main() -> Unit {\n  c(b(1));\n}\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.Contains(stderr.String(), `main.fe:2:5: match of "b($x)" overlaps one of "a($x)"`) {
+		t.Errorf("stderr %q", stderr.String())
+	}
+}
+
+func TestInvalidResult(t *testing.T) {
+	name := setup(t, "function main() -> Unit {\n  old(1);\n}\n")
+	*pat, *repl = "old($x)", "new($x"
+	defer func() { *pat, *repl = "", "" }()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{name}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "syntax errors") {
+		t.Errorf("exit code %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestBadRules(t *testing.T) {
+	*pat, *repl = "old($x)", "new($y)"
+	defer func() { *pat, *repl = "", "" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
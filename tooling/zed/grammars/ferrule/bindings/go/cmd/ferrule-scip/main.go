@@ -0,0 +1,73 @@
+// Command ferrule-scip writes a SCIP index of the ferrule project in a
+// directory, for upload to code intelligence platforms:
+//
+//	ferrule-scip [-o file] [dir]
+//
+// Without a directory the current one is indexed. The index is written to
+// index.scip unless -o names another file; "-" is standard output.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/scip"
+)
+
+var output = flag.String("o", "index.scip", "write the index to `file` (\"-\" for standard output)")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-scip [-o file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	os.Exit(run(flag.Arg(0), os.Stdout, os.Stderr))
+}
+
+func run(dir string, stdout, stderr io.Writer) int {
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-scip: %v\n", err)
+		return 2
+	}
+	idx, err := index.Build(context.Background(), abs)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-scip: %v\n", err)
+		return 2
+	}
+	root := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	data := scip.Build(idx, root, version()).Marshal()
+
+	if *output == "-" {
+		_, err = stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ferrule-scip: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "devel"
+}
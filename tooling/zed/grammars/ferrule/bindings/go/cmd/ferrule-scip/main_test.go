@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.fe"), []byte("package app;\nfunction main() -> Unit {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*output = "-"
+	defer func() { *output = "index.scip" }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(dir, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	// the index opens with its metadata, field 1.
+	if out := stdout.Bytes(); len(out) == 0 || out[0] != 1<<3|2 || !bytes.Contains(out, []byte("main.fe")) {
+		t.Errorf("unexpected output %q", out)
+	}
+}
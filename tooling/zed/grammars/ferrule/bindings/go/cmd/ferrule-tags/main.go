@@ -0,0 +1,294 @@
+// Command ferrule-tags writes a tags file for the ferrule sources below the
+// given directories, for editors such as Vim and Emacs.
+//
+//	ferrule-tags [flags] [dir ...]
+//
+// Without arguments the current directory is indexed. Directories are
+// walked as by package index, honoring .gitignore and .ferruleignore
+// files. The flags are:
+//
+//	-e        write an Emacs TAGS file instead of a ctags one
+//	-f file   write to file instead of tags (or TAGS with -e); "-" is
+//	          standard output
+//	-search query
+//	          print the definitions matching query, best first, instead
+//	          of writing a tags file; see index.SearchSymbols
+//	-kind kinds
+//	          with -search, only print definitions of the comma-separated
+//	          kinds, such as function,method
+//	-n count  with -search, print at most count definitions
+//	-cache    keep the index of each directory in its .ferrule-cache, so
+//	          that later runs only reparse the files that changed
+//
+// Search results are printed one per line as name, kind and the position
+// of the name, file:line:column, separated by tabs. The exit code is 1
+// when nothing matches.
+//
+// The ctags output uses the extended format understood by universal-ctags
+// and Vim: every tag carries its kind, its line and, for members, the
+// scope it is declared in.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+var (
+	emacs  = flag.Bool("e", false, "write an Emacs TAGS file")
+	output = flag.String("f", "", "write tags to `file` (\"-\" for standard output)")
+	search = flag.String("search", "", "print the definitions matching `query`")
+	kinds  = flag.String("kind", "", "with -search, only print definitions of these comma-separated `kinds`")
+	limit  = flag.Int("n", 0, "with -search, print at most `count` definitions")
+	cached = flag.Bool("cache", false, "keep the index of each directory in its .ferrule-cache")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-tags [flags] [dir ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// tag is one definition to be written.
+type tag struct {
+	name, file, kind, scope string
+	// text is the line the definition is on, without its line break.
+	text string
+	line uint
+	// lineStart is the byte offset of the line, nameEnd that of the end
+	// of the name.
+	lineStart, nameEnd uint
+}
+
+func run(dirs []string, stdout, stderr io.Writer) int {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	if *search != "" {
+		return runSearch(dirs, stdout, stderr)
+	}
+	var tags []tag
+	for _, dir := range dirs {
+		found, err := collect(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-tags: %v\n", err)
+			return 2
+		}
+		tags = append(tags, found...)
+	}
+
+	var buf bytes.Buffer
+	if *emacs {
+		writeEtags(&buf, tags)
+	} else {
+		writeCtags(&buf, tags)
+	}
+
+	name := *output
+	switch {
+	case name == "-":
+		_, err := stdout.Write(buf.Bytes())
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-tags: %v\n", err)
+			return 2
+		}
+		return 0
+	case name == "" && *emacs:
+		name = "TAGS"
+	case name == "":
+		name = "tags"
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(stderr, "ferrule-tags: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// runSearch prints the definitions matching the -search query, those of
+// the directories in turn.
+func runSearch(dirs []string, stdout, stderr io.Writer) int {
+	opts := &index.SearchOptions{Limit: *limit}
+	if *kinds != "" {
+		for _, name := range strings.Split(*kinds, ",") {
+			k, ok := kindNamed(strings.TrimSpace(name))
+			if !ok {
+				fmt.Fprintf(stderr, "ferrule-tags: unknown kind %q\n", name)
+				return 2
+			}
+			opts.Kinds = append(opts.Kinds, k)
+		}
+	}
+	var found []string
+	for _, dir := range dirs {
+		idx, err := build(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-tags: %v\n", err)
+			return 2
+		}
+		for _, sym := range idx.SearchSymbols(*search, opts) {
+			p := sym.SelectionRange.StartPoint
+			file := filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(sym.Path)))
+			found = append(found, fmt.Sprintf("%s\t%s\t%s:%d:%d\n", sym.Name, sym.Kind, file, p.Row+1, p.Column+1))
+		}
+	}
+	if *limit > 0 && len(found) > *limit {
+		found = found[:*limit]
+	}
+	for _, line := range found {
+		if _, err := io.WriteString(stdout, line); err != nil {
+			fmt.Fprintf(stderr, "ferrule-tags: %v\n", err)
+			return 2
+		}
+	}
+	if len(found) == 0 {
+		return 1
+	}
+	return 0
+}
+
+// kindNamed returns the kind whose String is name.
+func kindNamed(name string) (symbols.Kind, bool) {
+	for _, k := range []symbols.Kind{
+		symbols.Module, symbols.Package, symbols.Class, symbols.Method, symbols.Enum, symbols.Interface,
+		symbols.Function, symbols.Variable, symbols.Constant, symbols.Struct, symbols.TypeAlias,
+	} {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// build indexes the files below dir, through the cache with -cache.
+func build(dir string) (*index.Index, error) {
+	if *cached {
+		return index.Open(context.Background(), dir)
+	}
+	return index.Build(context.Background(), dir)
+}
+
+// collect returns the tags of the files below dir, with file names joined
+// to dir.
+func collect(dir string) ([]tag, error) {
+	idx, err := build(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []tag
+	for _, f := range idx.Files() {
+		name := filepath.Join(dir, filepath.FromSlash(f.Path))
+		text, err := charset.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		src := text.Source
+		for _, d := range f.Definitions {
+			sel := d.SelectionRange
+			if sel.EndByte > uint(len(src)) {
+				// the file changed after it was indexed.
+				continue
+			}
+			lineStart := sel.StartByte - sel.StartPoint.Column
+			text := src[lineStart:]
+			if i := bytes.IndexByte(text, '\n'); i >= 0 {
+				text = text[:i]
+			}
+			t := tag{
+				name:      d.Name,
+				file:      name,
+				kind:      d.Kind.String(),
+				text:      strings.TrimSuffix(string(text), "\r"),
+				line:      sel.StartPoint.Row + 1,
+				lineStart: lineStart,
+				nameEnd:   sel.EndByte,
+			}
+			if d.Container != "" {
+				t.scope = containerKind(f, d) + ":" + d.Container
+			}
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// containerKind returns the kind of the definition enclosing d.
+func containerKind(f *index.File, d index.Definition) string {
+	for _, c := range f.Definitions {
+		if c.Name == d.Container && c.Range.StartByte <= d.Range.StartByte && d.Range.EndByte <= c.Range.EndByte {
+			return c.Kind.String()
+		}
+	}
+	return "scope"
+}
+
+func writeCtags(w *bytes.Buffer, tags []tag) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		return a.line < b.line
+	})
+	w.WriteString("!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/\n")
+	w.WriteString("!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n")
+	w.WriteString("!_TAG_PROGRAM_NAME\tferrule-tags\t//\n")
+	for _, t := range tags {
+		fmt.Fprintf(w, "%s\t%s\t/^%s$/;\"\tkind:%s\tline:%d\tlanguage:Ferrule", t.name, filepath.ToSlash(t.file), escapePattern(t.text), t.kind, t.line)
+		if t.scope != "" {
+			fmt.Fprintf(w, "\t%s", t.scope)
+		}
+		w.WriteByte('\n')
+	}
+}
+
+// escapePattern escapes a line for use in an ex search command.
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(s)
+}
+
+// writeEtags writes the tags in the format of Emacs' etags: a section per
+// file, made of a header with the file name and the size of the section,
+// and one entry per tag giving the text up to the end of the name, the
+// name, the line and the byte offset of the line.
+func writeEtags(w *bytes.Buffer, tags []tag) {
+	byFile := make(map[string][]tag)
+	var files []string
+	for _, t := range tags {
+		if _, ok := byFile[t.file]; !ok {
+			files = append(files, t.file)
+		}
+		byFile[t.file] = append(byFile[t.file], t)
+	}
+	for _, file := range files {
+		ts := byFile[file]
+		sort.SliceStable(ts, func(i, j int) bool { return ts[i].line < ts[j].line })
+		var section bytes.Buffer
+		for _, t := range ts {
+			prefix := t.text
+			if n := int(t.nameEnd - t.lineStart); n <= len(prefix) {
+				prefix = prefix[:n]
+			}
+			fmt.Fprintf(&section, "%s\x7f%s\x01%d,%d\n", prefix, t.name, t.line, t.lineStart)
+		}
+		fmt.Fprintf(w, "\x0c\n%s,%d\n", filepath.ToSlash(file), section.Len())
+		w.Write(section.Bytes())
+	}
+}
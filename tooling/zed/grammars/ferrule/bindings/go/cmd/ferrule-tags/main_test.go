@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const src = `package app;
+
+component Server {
+  function start(p: u16) -> Unit {}
+}
+
+function main() -> Unit {}
+`
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.fe"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	*output = "-"
+	t.Cleanup(func() { *output, *emacs, *search, *kinds, *limit, *cached = "", false, "", "", 0, false })
+	return dir
+}
+
+func TestCtags(t *testing.T) {
+	dir := setup(t)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	file := filepath.ToSlash(filepath.Join(dir, "main.fe"))
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	want := []string{
+		"Server\t" + file + "\t/^component Server {$/;\"\tkind:module\tline:3\tlanguage:Ferrule",
+		"app\t" + file + "\t/^package app;$/;\"\tkind:package\tline:1\tlanguage:Ferrule",
+		"main\t" + file + "\t/^function main() -> Unit {}$/;\"\tkind:function\tline:7\tlanguage:Ferrule",
+		"start\t" + file + "\t/^  function start(p: u16) -> Unit {}$/;\"\tkind:method\tline:4\tlanguage:Ferrule\tmodule:Server",
+	}
+	if len(lines) != 3+len(want) || !strings.HasPrefix(lines[0], "!_TAG_FILE_FORMAT\t2\t") {
+		t.Fatalf("got:\n%s", stdout.String())
+	}
+	for i, w := range want {
+		if lines[3+i] != w {
+			t.Errorf("line %d:\n got %q\nwant %q", 3+i, lines[3+i], w)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := setup(t)
+	*cached, *search = true, "main"
+	for i := 0; i < 2; i++ {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{dir}, &stdout, &stderr); code != 0 || !strings.HasPrefix(stdout.String(), "main\tfunction\t") {
+			t.Fatalf("run %d: exit code %d, output %q: %s", i, code, stdout.String(), stderr.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".ferrule-cache", "index")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEtags(t *testing.T) {
+	dir := setup(t)
+	*emacs = true
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	entries := "package app\x7fapp\x011,0\n" +
+		"component Server\x7fServer\x013,14\n" +
+		"  function start\x7fstart\x014,33\n" +
+		"function main\x7fmain\x017,72\n"
+	want := "\x0c\n" + filepath.ToSlash(filepath.Join(dir, "main.fe")) + "," + strconv.Itoa(len(entries)) + "\n" + entries
+	if got := stdout.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := setup(t)
+	file := filepath.ToSlash(filepath.Join(dir, "main.fe"))
+	tests := []struct {
+		query, kinds string
+		limit        int
+		code         int
+		want         string
+	}{
+		{"s", "", 0, 0, "start\tmethod\t" + file + ":4:12\nServer\tmodule\t" + file + ":3:11\n"},
+		{"s", "module", 0, 0, "Server\tmodule\t" + file + ":3:11\n"},
+		{"a", "", 1, 0, "app\tpackage\t" + file + ":1:9\n"},
+		{"xyz", "", 0, 1, ""},
+		{"s", "widget", 0, 2, ""},
+	}
+	for _, tt := range tests {
+		*search, *kinds, *limit = tt.query, tt.kinds, tt.limit
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{dir}, &stdout, &stderr); code != tt.code || stdout.String() != tt.want {
+			t.Errorf("-search %q -kind %q: exit code %d, output\n%s\nwant %d, output\n%s%s", tt.query, tt.kinds, code, stdout.String(), tt.code, tt.want, stderr.String())
+		}
+	}
+}
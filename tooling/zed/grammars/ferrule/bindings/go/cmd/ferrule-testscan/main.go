@@ -0,0 +1,239 @@
+// Command ferrule-testscan lists the tests of ferrule sources as a JSON
+// manifest, for test runners and the test explorers of editors to
+// enumerate them without running anything.
+//
+//	ferrule-testscan [flags] [path ...]
+//
+// Each path is a file or a directory walked for .fe files, skipping what
+// .gitignore and .ferruleignore files and the ignore patterns of the
+// configuration exclude, and generated files, as the configuration tells
+// them; without arguments the current directory is walked. The flags are:
+//
+//	-tests patterns   comma-separated patterns of the names of tests, in
+//	                  place of those of the configuration
+//
+// Tests are found by convention. A test is a top-level function whose
+// name matches the tests of the [codelens] table of the ferrule.toml that
+// applies to its file, testParse and test_parse by default; see package
+// codelens. A component whose name ends in Tests is a block of tests: its
+// functions matching the same patterns are tests too, named after it as in
+// ParserTests.testEmpty. The ferrule:tags directives of the doc comment of
+// a test, and of its block, tag it:
+//
+//	// ferrule:tags slow network
+//	function testFetch() -> Unit { ... }
+//
+// The manifest is an array with an object for each test, ordered by file
+// and position:
+//
+//	[
+//	  {
+//	    "name": "ParserTests.testEmpty",
+//	    "block": "ParserTests",
+//	    "file": "parser/parser_test.fe",
+//	    "range": {"start": {"line": 4, "character": 2}, "end": ...},
+//	    "tags": ["slow"]
+//	  }
+//	]
+//
+// Files are named as the path given or, below a directory, as the path
+// of the directory joined with theirs, with slashes. Ranges cover the
+// declaration of the test in zero-based lines and UTF-16 columns, as in
+// the Language Server Protocol. Files with syntax errors are scanned all
+// the same, as far as they parse, and reported on standard error; the
+// exit code is then 1.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/codelens"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var tests = flag.String("tests", "", "comma-separated `patterns` of the names of tests")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-testscan [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// blockSuffix ends the names of the components holding tests.
+const blockSuffix = "Tests"
+
+// test is an entry of the manifest.
+type test struct {
+	Name  string      `json:"name"`
+	Block string      `json:"block,omitempty"`
+	File  string      `json:"file"`
+	Range edits.Range `json:"range"`
+	Tags  []string    `json:"tags"`
+	// start orders the tests of a file.
+	start uint
+}
+
+func run(paths []string, stdout, stderr io.Writer) int {
+	var override *codelens.Options
+	if *tests != "" {
+		override = &codelens.Options{Tests: strings.Split(*tests, ",")}
+		for _, p := range override.Tests {
+			if _, err := path.Match(p, ""); err != nil {
+				fmt.Fprintf(stderr, "ferrule-testscan: bad pattern %q\n", p)
+				return 2
+			}
+		}
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	status := 0
+	found := []test{}
+	for _, p := range paths {
+		more, failed, err := scan(p, override, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-testscan: %v\n", err)
+			status = 2
+		} else if failed && status == 0 {
+			status = 1
+		}
+		found = append(found, more...)
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(found); err != nil {
+		fmt.Fprintf(stderr, "ferrule-testscan: %v\n", err)
+		return 2
+	}
+	return status
+}
+
+// scan returns the tests of the files found at root, ordered by file and
+// position, and reports whether one of the files has syntax errors. The
+// patterns of override, if not nil, replace those of the configuration.
+func scan(root string, override *codelens.Options, stderr io.Writer) ([]test, bool, error) {
+	files, wait := walk.Files(root, walk.Options{})
+	configs := make(map[string]*config.Config)
+	var out []test
+	var errs []error
+	failed := false
+	for f := range files {
+		dir := filepath.Dir(f.OSPath)
+		cfg, ok := configs[dir]
+		if !ok {
+			var err error
+			if cfg, err = config.ForDir(dir); err != nil {
+				errs = append(errs, err)
+			}
+			configs[dir] = cfg
+		}
+		if cfg == nil || cfg.Ignored(f.OSPath, false) {
+			continue
+		}
+		opts := override
+		if opts == nil {
+			opts = &cfg.CodeLens
+		}
+		src, err := os.ReadFile(f.OSPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cfg.IsGenerated(f.OSPath, src) {
+			continue
+		}
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, d := range tree.Diagnostics() {
+			fmt.Fprintf(stderr, "%s:%s\n", f.OSPath, d)
+			failed = true
+		}
+		out = append(out, testsOf(tree, filepath.ToSlash(f.OSPath), opts)...)
+		tree.Close()
+	}
+	if err := wait(); err != nil {
+		errs = append(errs, err)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].File != out[j].File {
+			return out[i].File < out[j].File
+		}
+		return out[i].start < out[j].start
+	})
+	return out, failed, errors.Join(errs...)
+}
+
+// testsOf returns the tests of tree, the source of file, in source order.
+func testsOf(tree *ferrule.Tree, file string, opts *codelens.Options) []test {
+	src := tree.Source()
+	var out []test
+	add := func(block string, blockTags []string, fn *ast.FunctionDeclaration) {
+		id := fn.Name()
+		if id == nil || !opts.IsTest(id.Text(src)) || block == "" && opts.IsRun(id.Text(src)) {
+			return
+		}
+		name := id.Text(src)
+		if block != "" {
+			name = block + "." + name
+		}
+		out = append(out, test{
+			Name:  name,
+			Block: block,
+			File:  file,
+			Range: edits.RangeOf(src, fn.Raw().Range()),
+			Tags:  merge(blockTags, doc.Parse(doc.Text(fn.Raw(), src)).Tags),
+			start: fn.Raw().StartByte(),
+		})
+	}
+	for _, item := range tree.Root().Items() {
+		switch item := item.(type) {
+		case *ast.FunctionDeclaration:
+			add("", nil, item)
+		case *ast.ComponentDeclaration:
+			id := item.Name()
+			if id == nil || !strings.HasSuffix(id.Text(src), blockSuffix) {
+				continue
+			}
+			tags := doc.Parse(doc.Text(item.Raw(), src)).Tags
+			for _, fn := range item.FunctionDeclarations() {
+				add(id.Text(src), tags, fn)
+			}
+		}
+	}
+	return out
+}
+
+// merge returns the tags of a block followed by those of a test it holds,
+// without repeats, and never nil, so that every test has a tags array.
+func merge(block, own []string) []string {
+	out := []string{}
+	seen := make(map[string]bool)
+	for _, t := range append(append([]string(nil), block...), own...) {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setup writes files, keyed by slash-separated paths, to a temporary
+// directory and returns the directory.
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// manifest runs the command on paths and decodes its output.
+func manifest(t *testing.T, paths ...string) ([]test, int, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(paths, &stdout, &stderr)
+	var out []test
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Fatalf("%v: %s", err, stdout.String())
+	}
+	return out, code, stderr.String()
+}
+
+func TestScan(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"parser.fe": `function main() -> Unit {}
+
+// testParse checks the parser.
+// ferrule:tags slow "needs db"
+function testParse() -> Unit {}
+
+function testament() -> Unit {}
+
+// ferrule:tags parser
+component ParserTests {
+  function test_empty() -> Unit {}
+  // ferrule:tags slow
+  function testNested() -> Unit {}
+  function helper() -> Unit {}
+}
+
+component Helpers {
+  function testNot() -> Unit {}
+}
+`,
+		"gen/api.fe":   "// Code generated by gen. DO NOT EDIT.\n\nfunction testGenerated() -> Unit {}\n",
+		"skip/a.fe":    "function testSkipped() -> Unit {}\n",
+		"ferrule.toml": "ignore = [\"skip/\"]\n",
+	})
+	tests, code, stderr := manifest(t, dir)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	var got []string
+	for _, tt := range tests {
+		got = append(got, tt.Name+" ["+strings.Join(tt.Tags, ",")+"]")
+	}
+	want := "testParse [slow,needs db]|ParserTests.test_empty [parser]|ParserTests.testNested [parser,slow]"
+	if strings.Join(got, "|") != want {
+		t.Errorf("tests %q, want %s", got, want)
+	}
+	if len(tests) != 3 {
+		return
+	}
+	if tt := tests[0]; tt.File != filepath.ToSlash(filepath.Join(dir, "parser.fe")) || tt.Block != "" ||
+		tt.Range.Start.Line != 4 || tt.Range.Start.Character != 0 || tt.Range.End.Line != 4 {
+		t.Errorf("testParse: %+v", tt)
+	}
+	if tt := tests[2]; tt.Block != "ParserTests" || tt.Range.Start.Line != 12 || tt.Range.Start.Character != 2 {
+		t.Errorf("testNested: %+v", tt)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	dir := setup(t, map[string]string{
+		"a.fe":         "function checkA() -> Unit {}\nfunction testA() -> Unit {}\nfunction specB() -> Unit {}\n",
+		"ferrule.toml": "[codelens]\ntests = [\"check*\"]\n",
+	})
+	file := filepath.Join(dir, "a.fe")
+	for patterns, want := range map[string]string{"": "checkA", "spec*,test*": "testA specB"} {
+		*tests = patterns
+		found, code, stderr := manifest(t, file)
+		*tests = ""
+		if code != 0 {
+			t.Fatalf("exit code %d: %s", code, stderr)
+		}
+		var got []string
+		for _, tt := range found {
+			got = append(got, tt.Name)
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("-tests %q found %q, want %s", patterns, got, want)
+		}
+	}
+	*tests = "["
+	defer func() { *tests = "" }()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{file}, &stdout, &stderr); code != 2 || stdout.Len() != 0 {
+		t.Errorf("bad pattern: exit code %d, output %q", code, stdout.String())
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	dir := setup(t, map[string]string{"a.fe": "function testA() -> Unit {}\nfunction ( {\n"})
+	found, code, stderr := manifest(t, dir)
+	if code != 1 || stderr == "" {
+		t.Errorf("exit code %d, stderr %q", code, stderr)
+	}
+	if len(found) != 1 || found[0].Name != "testA" {
+		t.Errorf("tests %+v", found)
+	}
+	empty, code, _ := manifest(t, t.TempDir())
+	if code != 0 || empty == nil || len(empty) != 0 {
+		t.Errorf("empty directory: %v, exit code %d", empty, code)
+	}
+}
@@ -0,0 +1,92 @@
+// Command ferrule-themes exports the highlighting of ferrule for editors
+// that color text by TextMate scopes, such as VS Code, Sublime Text and
+// Shiki, rather than by tree-sitter queries.
+//
+//	ferrule-themes [-f format] [grammar.json]
+//
+// It writes JSON to standard output. The flags are:
+//
+//	-f format   what to write: tmlanguage (the default), a TextMate grammar
+//	            as in a .tmLanguage.json file, generated from the bundled
+//	            highlights query and grammar.json, the src/grammar.json of
+//	            the parser; scopes, an object mapping each capture of the
+//	            query to its scope; or theme, the default theme of
+//	            ferrule-highlight as a VS Code color theme over the scopes
+//	-name name  the name of the theme (default "Ferrule Dark")
+//
+// The grammar colors what can be told apart a line at a time: keywords,
+// operators, literals, comments and identifiers. See package themes for
+// how it is derived from the query.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/bindings/go/themes"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var (
+	format = flag.String("f", "tmlanguage", "what to write: `tmlanguage`, scopes or theme")
+	name   = flag.String("name", "Ferrule Dark", "`name` of the theme")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-themes [-f format] [grammar.json]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	var out any
+	switch *format {
+	case "tmlanguage":
+		if len(args) != 1 {
+			fmt.Fprintf(stderr, "ferrule-themes: -f tmlanguage needs the grammar.json of the parser\n")
+			return 2
+		}
+		src, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrule-themes: %v\n", err)
+			return 2
+		}
+		if out, err = themes.Generate(queries.Highlights, src); err != nil {
+			fmt.Fprintf(stderr, "ferrule-themes: %v\n", err)
+			return 2
+		}
+	case "scopes", "theme":
+		if len(args) != 0 {
+			fmt.Fprintf(stderr, "ferrule-themes: -f %s takes no arguments\n", *format)
+			return 2
+		}
+		if *format == "theme" {
+			out = themes.ColorTheme(*name, "dark", highlight.DefaultTheme)
+			break
+		}
+		scopes := make(map[string]string)
+		for _, capture := range query.MustCompile(string(queries.Highlights)).CaptureNames() {
+			scopes[capture] = themes.Scope(capture)
+		}
+		out = scopes
+	default:
+		fmt.Fprintf(stderr, "ferrule-themes: unknown format %q\n", *format)
+		return 2
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(stderr, "ferrule-themes: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTMLanguage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"../../../../src/grammar.json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var g struct {
+		ScopeName string `json:"scopeName"`
+		Patterns  []struct {
+			Name string `json:"name"`
+		} `json:"patterns"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &g); err != nil {
+		t.Fatal(err)
+	}
+	if g.ScopeName != "source.ferrule" || len(g.Patterns) == 0 {
+		t.Errorf("grammar %+v", g)
+	}
+	if strings.Contains(stdout.String(), `\u003c`) {
+		t.Errorf("output escapes HTML:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d without a grammar.json", code)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	*format = "scopes"
+	defer func() { *format = "tmlanguage" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	var scopes map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &scopes); err != nil {
+		t.Fatal(err)
+	}
+	if scopes["keyword.control"] != "keyword.control.ferrule" || scopes["function.call"] != "entity.name.function.ferrule" {
+		t.Errorf("scopes %v", scopes)
+	}
+}
+
+func TestTheme(t *testing.T) {
+	*format = "theme"
+	defer func() { *format = "tmlanguage" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	for _, want := range []string{`"name": "Ferrule Dark"`, `"keyword.control.ferrule"`, `"fontStyle": "italic"`} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("theme lacks %s:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestBadFormat(t *testing.T) {
+	*format = "sublime"
+	defer func() { *format = "tmlanguage" }()
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("exit code %d, want 2", code)
+	}
+}
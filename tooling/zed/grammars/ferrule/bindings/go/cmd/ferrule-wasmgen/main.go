@@ -0,0 +1,230 @@
+// Command ferrule-wasmgen builds the WebAssembly module of package wasm,
+// the grammar and the tree-sitter runtime that package wasm runs under
+// wazero in builds without cgo.
+//
+// It is meant to be run through go:generate in package wasm:
+//
+//	ferrule-wasmgen [-ccgo path] parser.c
+//
+// The Go toolchain has no C compiler, so the C code goes to WebAssembly by
+// way of Go: ccgo (modernc.org/ccgo/v4, on PATH or given with -ccgo)
+// translates the runtime sources of the go-tree-sitter version required by
+// go.mod, the grammar's parser.c and guest/c/glue.c to Go, which is then
+// built for wasip1 together with the guest module. A few constructs that
+// ccgo cannot translate are patched first; see patch.go.
+//
+// It writes ferrule.wasm.gz, the gzipped module, and grammar.go, the
+// metadata of the grammar read back from the module, to the current
+// directory.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const runtimeModule = "github.com/tree-sitter/go-tree-sitter"
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("ferrule-wasmgen: ")
+
+	ccgo := flag.String("ccgo", "ccgo", "`path` of the ccgo command")
+	guest := flag.String("guest", "guest", "`directory` of the guest module")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrule-wasmgen [flags] parser.c\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir, err := os.MkdirTemp("", "ferrule-wasmgen")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bin, err := build(dir, *ccgo, *guest, flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := grammar(bin)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var gz bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	w.Write(bin)
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("ferrule.wasm.gz", gz.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("grammar.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// build builds the module in dir and returns it.
+func build(dir, ccgo, guest, parser string) ([]byte, error) {
+	runtime, err := runtimeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// The runtime, patched.
+	err = filepath.WalkDir(filepath.Join(runtime, "src"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(runtime, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		src, err := apply(filepath.Base(path), string(data))
+		if err != nil {
+			return err
+		}
+		return write(filepath.Join(dir, rel), []byte(src))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The grammar and the glue.
+	data, err := os.ReadFile(parser)
+	if err != nil {
+		return nil, err
+	}
+	src, err := rewriteActions(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := write(filepath.Join(dir, "grammar", "parser.c"), []byte(src)); err != nil {
+		return nil, err
+	}
+	glue, err := filepath.Abs(filepath.Join(guest, "c", "glue.c"))
+	if err != nil {
+		return nil, err
+	}
+	include, err := filepath.Abs(filepath.Dir(parser))
+	if err != nil {
+		return nil, err
+	}
+
+	// The guest module, with the translation of all of the above.
+	if err := os.CopyFS(filepath.Join(dir, "guest"), os.DirFS(guest)); err != nil {
+		return nil, err
+	}
+	ts := filepath.Join(dir, "guest", "ts.go")
+	cmd := exec.Command(ccgo, "-DFERRULE_NO_ATOMICS", "-o", ts,
+		"-I", filepath.Join(runtime, "include"), "-I", "src", "-I", include,
+		"src/lib.c", "grammar/parser.c", glue)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ccgo: %w", err)
+	}
+	data, err = os.ReadFile(ts)
+	if err != nil {
+		return nil, err
+	}
+	src, ok := strings.CutPrefix(string(data[bytes.IndexByte(data, '\n')+1:]), "\n//go:build linux && amd64\n")
+	if !ok {
+		return nil, fmt.Errorf("ccgo: unexpected output; run it on linux/amd64")
+	}
+	src = "// Code generated by ferrule-wasmgen. DO NOT EDIT.\n\n//go:build wasip1\n" +
+		strings.Replace(src, `"modernc.org/libc"`, `"github.com/karol-broda/ferrule/bindings/go/wasm/guest/libc"`, 1)
+	if err := os.WriteFile(ts, []byte(src), 0o644); err != nil {
+		return nil, err
+	}
+
+	wasm := filepath.Join(dir, "ferrule.wasm")
+	cmd = exec.Command("go", "build", "-buildmode=c-shared", "-trimpath", "-ldflags=-s", "-o", wasm, ".")
+	cmd.Dir = filepath.Join(dir, "guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "GOWASM=satconv,signext", "GOWORK=off", "GOFLAGS=")
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("building the guest: %w", err)
+	}
+	return os.ReadFile(wasm)
+}
+
+// runtimeDir returns the directory of the go-tree-sitter module in the
+// module cache.
+func runtimeDir() (string, error) {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", runtimeModule).Output()
+	if err != nil {
+		return "", fmt.Errorf("locating %s: %w", runtimeModule, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// grammar runs the module bin and returns grammar.go, with the metadata
+// that the language export of the guest returns.
+func grammar(bin []byte) ([]byte, error) {
+	ctx := context.Background()
+	r := wazero.NewRuntime(ctx)
+	defer r.Close(ctx)
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+	mod, err := r.InstantiateWithConfig(ctx, bin, wazero.NewModuleConfig().WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, err
+	}
+	res, err := mod.ExportedFunction("language").Call(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := mod.Memory().Read(uint32(res[0]>>32), uint32(res[0]))
+	var lang struct {
+		ABI     uint32   `json:"abi"`
+		Version [3]uint8 `json:"version"`
+		Symbols []string `json:"symbols"`
+		Fields  []string `json:"fields"`
+	}
+	if err := json.Unmarshal(data, &lang); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by ferrule-wasmgen from parser.c. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package wasm\n\n")
+	fmt.Fprintf(&b, "const (\n\tgrammarABI = %d\n\tgrammarVersion = \"%d.%d.%d\"\n)\n\n",
+		lang.ABI, lang.Version[0], lang.Version[1], lang.Version[2])
+	fmt.Fprintf(&b, "// symbolNames are the names of the node kinds, by symbol.\nvar symbolNames = [...]string{\n")
+	for _, s := range lang.Symbols {
+		fmt.Fprintf(&b, "\t%q,\n", s)
+	}
+	fmt.Fprintf(&b, "}\n\n// fieldNames are the field names, by field id.\nvar fieldNames = [...]string{\n")
+	for _, s := range lang.Fields {
+		fmt.Fprintf(&b, "\t%q,\n", s)
+	}
+	fmt.Fprintf(&b, "}\n")
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const actions = `static const TSParseActionEntry ts_parse_actions[] = {
+  [0] = {.entry = {.count = 0, .reusable = false}},
+  [1] = {.entry = {.count = 1, .reusable = false}}, RECOVER(),
+  [3] = {.entry = {.count = 1, .reusable = true}}, SHIFT_EXTRA(),
+  [5] = {.entry = {.count = 2, .reusable = true}}, REDUCE(sym_block, 3, -1, 7), SHIFT_REPEAT(42),
+  [8] = {.entry = {.count = 1, .reusable = true}}, ACCEPT_INPUT(),
+};
+
+static const TSLanguage language = {
+    .parse_actions = ts_parse_actions,
+};
+`
+
+func TestRewriteActions(t *testing.T) {
+	src, err := rewriteActions(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"static const uint16_t ts_parse_actions_raw[] = {\n  0 | 0 << 8, 0, 0, 0,\n  1 | 0 << 8, 0, 0, 0,\n  TSParseActionTypeRecover, 0, 0, 0,\n",
+		"  TSParseActionTypeShift, 0, 1, 0,\n",
+		"  2 | 1 << 8, 0, 0, 0,\n  TSParseActionTypeReduce | (3) << 8, sym_block, (uint16_t)(-1), 7,\n  TSParseActionTypeShift, 42, 1 << 8, 0,\n",
+		"  TSParseActionTypeAccept, 0, 0, 0,\n};\n",
+		".parse_actions = (const TSParseActionEntry *)ts_parse_actions_raw,",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("output lacks %q:\n%s", want, src)
+		}
+	}
+
+	for _, bad := range []string{
+		strings.Replace(actions, "[3] =", "[4] =", 1),
+		strings.Replace(actions, ".count = 2", ".count = 3", 1),
+		strings.Replace(actions, "ACCEPT_INPUT()", "ACCEPT_INPUT(1)", 1),
+		strings.Replace(actions, "ts_parse_actions,", "actions,", 1),
+	} {
+		if _, err := rewriteActions(bad); err == nil {
+			t.Errorf("no error for\n%s", bad)
+		}
+	}
+}
+
+// TestGrammar rewrites the parse actions of the grammar, so that a
+// regenerated parser.c that the rewrite does not understand fails here
+// rather than when the module is next built.
+func TestGrammar(t *testing.T) {
+	data, err := os.ReadFile("../../../../src/parser.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := rewriteActions(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(src, "SHIFT(") || strings.Contains(src, "REDUCE(") {
+		t.Errorf("actions left in the table")
+	}
+}
+
+// TestPatches applies the patches to the runtime sources of the version of
+// go-tree-sitter in go.mod.
+func TestPatches(t *testing.T) {
+	dir, err := runtimeDir()
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, p := range patches {
+		data, err := os.ReadFile(filepath.Join(dir, "src", p.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := apply(p.file, string(data)); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := apply("subtree.h", "no such code"); err == nil {
+		t.Errorf("no error for a source without the patched code")
+	}
+}
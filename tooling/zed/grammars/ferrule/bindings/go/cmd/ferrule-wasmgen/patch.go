@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// patch is a rewrite of a tree-sitter runtime source into C that ccgo
+// translates, with the same meaning.
+type patch struct {
+	file, old, new string
+}
+
+var patches = []patch{
+	// ccgo cannot take the address of a conditional expression.
+	{
+		"subtree.h",
+		"((self).data.is_inline ? NULL : (Subtree *)((self).ptr) - (self).ptr->child_count)",
+		"((Subtree *)((self).data.is_inline ? NULL : (Subtree *)((self).ptr) - (self).ptr->child_count))",
+	},
+	// nor select a field of an assignment.
+	{
+		"parser.c",
+		"while ((result = reusable_node_tree(&self->reusable_node)).ptr) {",
+		"for (;;) {\n    result = reusable_node_tree(&self->reusable_node);\n    if (!result.ptr) break;",
+	},
+	// The guest is single threaded, and ccgo lacks the __atomic builtins.
+	{"atomic.h", "#ifdef __TINYC__", "#if defined(__TINYC__) || defined(FERRULE_NO_ATOMICS)"},
+	// Static inline functions of headers are otherwise left undefined.
+	{"unicode.h", "static inline uint32_t ts_decode_", "static uint32_t ts_decode_"},
+	// ccgo lays out unions initialized inside compound literals wrongly.
+	{
+		"subtree.c",
+		`    {{
+      .visible_descendant_count = 0,
+      .production_id = production_id,
+      .first_leaf = {.symbol = 0, .parse_state = 0},
+    }}
+  };
+`,
+		"  };\n  data->production_id = production_id;\n",
+	},
+}
+
+// apply applies the patches of file to src.
+func apply(file, src string) (string, error) {
+	for _, p := range patches {
+		if p.file != file {
+			continue
+		}
+		if strings.Count(src, p.old) == 0 {
+			return "", fmt.Errorf("%s: no %q to patch; was the runtime updated?", file, p.old)
+		}
+		src = strings.ReplaceAll(src, p.old, p.new)
+	}
+	return src, nil
+}
+
+var (
+	entryRe  = regexp.MustCompile(`^\s*\[(\d+)\] = \{\.entry = \{\.count = (\d+), \.reusable = (true|false)\}\},(.*)$`)
+	actionRe = regexp.MustCompile(`(SHIFT_REPEAT|SHIFT_EXTRA|SHIFT|REDUCE|RECOVER|ACCEPT_INPUT)\(([^)]*)\)`)
+)
+
+const (
+	actionsDecl = "static const TSParseActionEntry ts_parse_actions[] = {\n"
+	actionsUse  = ".parse_actions = ts_parse_actions,"
+)
+
+// rewriteActions rewrites the parse action table of a generated parser.c,
+// an array of unions that ccgo cannot initialize, as an array of the
+// four 16-bit words of each union, which the language casts back.
+func rewriteActions(src string) (string, error) {
+	start := strings.Index(src, actionsDecl)
+	if start < 0 || !strings.Contains(src, actionsUse) {
+		return "", fmt.Errorf("parser.c: no parse action table")
+	}
+	n := strings.Index(src[start:], "\n};\n")
+	if n < 0 {
+		return "", fmt.Errorf("parser.c: unterminated parse action table")
+	}
+	body := src[start+len(actionsDecl) : start+n]
+
+	var b strings.Builder
+	b.WriteString("static const uint16_t ts_parse_actions_raw[] = {\n")
+	index := 0
+	for _, line := range strings.Split(body, "\n") {
+		m := entryRe.FindStringSubmatch(line)
+		if m == nil {
+			return "", fmt.Errorf("parser.c: unexpected parse action %q", line)
+		}
+		if m[1] != strconv.Itoa(index) {
+			return "", fmt.Errorf("parser.c: parse action [%s] at index %d", m[1], index)
+		}
+		reusable := 0
+		if m[3] == "true" {
+			reusable = 1
+		}
+		fmt.Fprintf(&b, "  %s | %d << 8, 0, 0, 0,\n", m[2], reusable)
+		actions := actionRe.FindAllStringSubmatch(m[4], -1)
+		if strconv.Itoa(len(actions)) != m[2] {
+			return "", fmt.Errorf("parser.c: parse action [%s] has %d actions, not %s", m[1], len(actions), m[2])
+		}
+		for _, a := range actions {
+			var args []string
+			if a[2] != "" {
+				args = strings.Split(a[2], ", ")
+			}
+			var words string
+			switch {
+			case a[1] == "SHIFT" && len(args) == 1:
+				words = "TSParseActionTypeShift, " + args[0] + ", 0, 0"
+			case a[1] == "SHIFT_REPEAT" && len(args) == 1:
+				words = "TSParseActionTypeShift, " + args[0] + ", 1 << 8, 0"
+			case a[1] == "SHIFT_EXTRA" && len(args) == 0:
+				words = "TSParseActionTypeShift, 0, 1, 0"
+			case a[1] == "REDUCE" && len(args) == 4:
+				words = fmt.Sprintf("TSParseActionTypeReduce | (%s) << 8, %s, (uint16_t)(%s), %s", args[1], args[0], args[2], args[3])
+			case a[1] == "RECOVER" && len(args) == 0:
+				words = "TSParseActionTypeRecover, 0, 0, 0"
+			case a[1] == "ACCEPT_INPUT" && len(args) == 0:
+				words = "TSParseActionTypeAccept, 0, 0, 0"
+			default:
+				return "", fmt.Errorf("parser.c: unexpected action %s", a[0])
+			}
+			b.WriteString("  " + words + ",\n")
+		}
+		index += 1 + len(actions)
+	}
+	b.WriteString("};\n")
+
+	src = src[:start] + b.String() + src[start+n+len("\n};\n"):]
+	return strings.Replace(src, actionsUse, ".parse_actions = (const TSParseActionEntry *)ts_parse_actions_raw,", 1), nil
+}
@@ -0,0 +1,130 @@
+// Command ferruled is a long-running daemon that parses, formats, lints
+// and outlines ferrule source for tools that are not written in Go, so
+// that they reuse warm parsers instead of starting a process per file.
+//
+//	ferruled [flags]
+//
+// It speaks JSON-RPC 2.0, one message per line, over standard input and
+// output or, with -listen, over every connection accepted on a socket.
+// The flags are:
+//
+//	-listen addr   accept connections on addr instead of serving standard
+//	               input: a Unix socket path prefixed with unix:, as in
+//	               unix:/tmp/ferruled.sock, or a TCP host:port
+//	-max-bytes n   refuse sources larger than n bytes
+//	-max-nodes n   refuse sources whose tree has more than n nodes
+//	-max-depth n   refuse sources whose tree nests deeper than n levels
+//	-parse-timeout d
+//	               give up on a parse taking longer than d, as in 2s
+//
+// The limits are off unless set; a request over one of them fails with
+// code -32002.
+//
+// The methods take the source as "source" or, without it, read the file
+// at "path"; the path also selects the ferrule.toml that applies, as for
+// ferrulefmt and ferrule-lint:
+//
+//	parse     {source, path, tree}   syntax errors, and the tree as an
+//	                                 S-expression if tree is true
+//	format    {source, path}         {source, changed}; an error with code
+//	                                 -32001 and the syntax errors as data
+//	                                 if the source does not parse
+//	lint      {source, path,         syntax errors and the findings of the
+//	           analyzers}            ferrule-lint analyzers, or of those
+//	                                 named, optional ones included
+//	symbols   {source, path}         the outline of the file
+//
+// For example:
+//
+//	{"jsonrpc": "2.0", "id": 1, "method": "format", "params": {"source": "const x=1;"}}
+//	{"jsonrpc":"2.0","id":1,"result":{"source":"const x = 1;\n","changed":true}}
+//
+// Ranges have zero-based rows and byte columns, as in the JSON dumps of
+// package dump. Requests are handled concurrently, so replies may arrive
+// in another order than the requests; match them by ID. Analyzers run on
+// one file at a time, so deadcode does not see calls from other files.
+//
+// Only JSON-RPC is served; a gRPC front end would need code generated
+// from a schema this module does not ship.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+var (
+	listen       = flag.String("listen", "", "accept connections on `addr` (unix:path or host:port)")
+	maxBytes     = flag.Int64("max-bytes", 0, "refuse sources larger than `n` bytes")
+	maxNodes     = flag.Int("max-nodes", 0, "refuse sources whose tree has more than `n` nodes")
+	maxDepth     = flag.Int("max-depth", 0, "refuse sources whose tree nests deeper than `n` levels")
+	parseTimeout = flag.Duration("parse-timeout", 0, "give up on a parse taking longer than `d`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferruled [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	limits := ferrule.Limits{Bytes: *maxBytes, Nodes: *maxNodes, Depth: *maxDepth, Duration: *parseTimeout}
+	os.Exit(run(ctx, *listen, limits, os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, addr string, limits ferrule.Limits, stdin io.Reader, stdout, stderr io.Writer) int {
+	s := newServer(limits)
+	defer s.close()
+	if addr == "" {
+		if err := s.serve(stdin, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "ferruled: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	network := "tcp"
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, addr = "unix", path
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "ferruled: %v\n", err)
+		return 2
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return 0
+			}
+			fmt.Fprintf(stderr, "ferruled: %v\n", err)
+			return 1
+		}
+		go func() {
+			defer c.Close()
+			if err := s.serve(c, c, stderr); err != nil {
+				fmt.Fprintf(stderr, "ferruled: %v\n", err)
+			}
+		}()
+	}
+}
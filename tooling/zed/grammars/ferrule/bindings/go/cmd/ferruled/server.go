@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/internal/jsonrpc"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// The error codes of the daemon, in the range JSON-RPC reserves for
+// servers.
+const (
+	codeSyntaxError   = -32001
+	codeLimitExceeded = -32002
+)
+
+// server holds what sessions share: the parsers, the limits of a parse
+// and a bound on the requests handled at once.
+type server struct {
+	pool   *ferrule.ParserPool
+	limits ferrule.Limits
+	busy   chan struct{}
+}
+
+func newServer(limits ferrule.Limits) *server {
+	return &server{pool: ferrule.NewParserPool(0), limits: limits, busy: make(chan struct{}, runtime.GOMAXPROCS(0))}
+}
+
+func (s *server) close() { s.pool.Close() }
+
+// serve runs a session until the client closes the stream. Requests are
+// handled concurrently and answered as they complete, so replies may come
+// out of order.
+func (s *server) serve(r io.Reader, w io.Writer, log io.Writer) error {
+	c := jsonrpc.NewLineConn(r, w)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		body, err := c.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		var req jsonrpc.Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			c.Reply(json.RawMessage("null"), nil, &jsonrpc.Error{Code: jsonrpc.ParseError, Message: err.Error()})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			if req.ID != nil {
+				c.Reply(req.ID, nil, &jsonrpc.Error{Code: jsonrpc.InvalidRequest, Message: "not a JSON-RPC 2.0 request"})
+			}
+			continue
+		}
+		s.busy <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-s.busy; wg.Done() }()
+			result, err := s.handle(&req)
+			if req.ID == nil {
+				if err != nil {
+					fmt.Fprintf(log, "ferruled: %s: %v\n", req.Method, err)
+				}
+				return
+			}
+			if err := c.Reply(req.ID, result, err); err != nil {
+				fmt.Fprintf(log, "ferruled: %v\n", err)
+			}
+		}()
+	}
+}
+
+func (s *server) handle(req *jsonrpc.Request) (any, error) {
+	switch req.Method {
+	case "parse":
+		var p parseParams
+		return decode(req, &p, func() (any, error) { return s.parse(p) })
+	case "format":
+		var p fileParams
+		return decode(req, &p, func() (any, error) { return s.format(p) })
+	case "lint":
+		var p lintParams
+		return decode(req, &p, func() (any, error) { return s.lint(p) })
+	case "symbols":
+		var p fileParams
+		return decode(req, &p, func() (any, error) { return s.symbols(p) })
+	}
+	return nil, &jsonrpc.Error{Code: jsonrpc.MethodNotFound, Message: "method not found: " + req.Method}
+}
+
+// decode unmarshals the request parameters, if any, into p and calls f.
+func decode(req *jsonrpc.Request, p any, f func() (any, error)) (any, error) {
+	if len(req.Params) == 0 {
+		return f()
+	}
+	if err := json.Unmarshal(req.Params, p); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: err.Error()}
+	}
+	return f()
+}
+
+// fileParams name the source a request works on.
+type fileParams struct {
+	// Source is the text of the file. Without it the file at Path is
+	// read.
+	Source *string `json:"source,omitempty"`
+	// Path is the path of the file, used to find the ferrule.toml that
+	// applies to it.
+	Path string `json:"path,omitempty"`
+}
+
+type parseParams struct {
+	fileParams
+	// Tree asks for the syntax tree as an S-expression.
+	Tree bool `json:"tree,omitempty"`
+}
+
+type lintParams struct {
+	fileParams
+	// Analyzers, if not empty, are the names of the analyzers to run, of
+	// those the configuration leaves on.
+	Analyzers []string `json:"analyzers,omitempty"`
+}
+
+// diagnostic is a syntax error or a finding of an analyzer. Ranges have
+// zero-based rows and byte columns.
+type diagnostic struct {
+	Range    dump.Range `json:"range"`
+	Severity string     `json:"severity"`
+	Message  string     `json:"message"`
+	// Category is the name of the analyzer, or "syntax".
+	Category string `json:"category"`
+	// Tags classify the finding; "deprecated" marks a use of deprecated
+	// code.
+	Tags []string `json:"tags,omitempty"`
+}
+
+type parseResult struct {
+	Diagnostics []diagnostic `json:"diagnostics"`
+	Tree        string       `json:"tree,omitempty"`
+}
+
+type formatResult struct {
+	Source  string `json:"source"`
+	Changed bool   `json:"changed"`
+}
+
+type lintResult struct {
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+type symbol struct {
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`
+	Detail         string     `json:"detail,omitempty"`
+	Range          dump.Range `json:"range"`
+	SelectionRange dump.Range `json:"selectionRange"`
+	Children       []symbol   `json:"children,omitempty"`
+}
+
+// load returns the source p names, parsed, and the configuration that
+// applies to it.
+func (s *server) load(p fileParams) (*ferrule.Tree, *config.Config, error) {
+	var src []byte
+	switch {
+	case p.Source != nil:
+		src = []byte(*p.Source)
+	case p.Path != "":
+		var err error
+		if src, err = os.ReadFile(p.Path); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "neither source nor path given"}
+	}
+	cfg := &config.Config{}
+	if p.Path != "" {
+		var err error
+		if cfg, err = config.ForDir(filepath.Dir(p.Path)); err != nil {
+			return nil, nil, err
+		}
+	}
+	tree, err := s.pool.ParseWithLimits(context.Background(), src, nil, s.limits)
+	if errors.Is(err, ferrule.ErrLimitExceeded) {
+		if tree != nil {
+			tree.Close()
+		}
+		return nil, nil, &jsonrpc.Error{Code: codeLimitExceeded, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return tree, cfg, nil
+}
+
+func (s *server) parse(p parseParams) (any, error) {
+	tree, _, err := s.load(p.fileParams)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	out := parseResult{Diagnostics: syntaxErrors(tree)}
+	if p.Tree {
+		out.Tree = dump.SExpr(tree.RootNode())
+	}
+	return out, nil
+}
+
+func (s *server) format(p fileParams) (any, error) {
+	tree, cfg, err := s.load(p)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	out, err := cfg.Format.Tree(tree)
+	var syntax *format.SyntaxError
+	if errors.As(err, &syntax) {
+		return nil, &jsonrpc.Error{Code: codeSyntaxError, Message: "source has syntax errors", Data: syntaxErrors(tree)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return formatResult{Source: string(out), Changed: string(out) != string(tree.Source())}, nil
+}
+
+func (s *server) lint(p lintParams) (any, error) {
+	tree, cfg, err := s.load(p.fileParams)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	all := passes.All
+	if len(p.Analyzers) > 0 {
+		// Naming an optional analyzer turns it on.
+		all = slices.Clone(passes.All)
+		for i, a := range all {
+			if a.Optional && slices.Contains(p.Analyzers, a.Name) {
+				cp := *a
+				cp.Optional = false
+				all[i] = &cp
+			}
+		}
+	}
+	enabled, err := cfg.Analyzers(all)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Analyzers) > 0 {
+		if enabled, err = selected(enabled, p.Analyzers); err != nil {
+			return nil, err
+		}
+	}
+	diags, err := analysis.Run(tree, enabled...)
+	if err != nil {
+		return nil, err
+	}
+	out := lintResult{Diagnostics: syntaxErrors(tree)}
+	for _, d := range diags {
+		diag := diagnostic{
+			Range:    rangeOf(d.Range),
+			Severity: d.Severity.String(),
+			Message:  d.Message,
+			Category: d.Category,
+		}
+		for _, t := range d.Tags {
+			if t == analysis.TagDeprecated {
+				diag.Tags = append(diag.Tags, "deprecated")
+			}
+		}
+		out.Diagnostics = append(out.Diagnostics, diag)
+	}
+	return out, nil
+}
+
+// selected returns the analyzers of enabled named in names. It fails for
+// names of no analyzer at all; those the configuration turned off are
+// left out.
+func selected(enabled []*analysis.Analyzer, names []string) ([]*analysis.Analyzer, error) {
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		known := false
+		for _, a := range passes.All {
+			known = known || a.Name == name
+		}
+		if !known {
+			return nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "unknown analyzer " + name}
+		}
+		want[name] = true
+	}
+	var out []*analysis.Analyzer
+	for _, a := range enabled {
+		if want[a.Name] {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s *server) symbols(p fileParams) (any, error) {
+	tree, _, err := s.load(p)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	var convert func([]symbols.Symbol) []symbol
+	convert = func(syms []symbols.Symbol) []symbol {
+		out := make([]symbol, len(syms))
+		for i, sym := range syms {
+			out[i] = symbol{
+				Name:           sym.Name,
+				Kind:           sym.Kind.String(),
+				Detail:         sym.Detail,
+				Range:          rangeOf(sym.Range),
+				SelectionRange: rangeOf(sym.SelectionRange),
+				Children:       convert(sym.Children),
+			}
+		}
+		return out
+	}
+	return convert(symbols.Outline(tree)), nil
+}
+
+func syntaxErrors(tree *ferrule.Tree) []diagnostic {
+	out := []diagnostic{}
+	for _, d := range tree.Diagnostics() {
+		out = append(out, diagnostic{Range: rangeOf(d.Range), Severity: d.Severity.String(), Message: d.Message, Category: "syntax"})
+	}
+	return out
+}
+
+func rangeOf(r tree_sitter.Range) dump.Range {
+	return dump.Range{
+		StartByte: r.StartByte,
+		EndByte:   r.EndByte,
+		Start:     dump.Point{Row: r.StartPoint.Row, Column: r.StartPoint.Column},
+		End:       dump.Point{Row: r.EndPoint.Row, Column: r.EndPoint.Column},
+	}
+}
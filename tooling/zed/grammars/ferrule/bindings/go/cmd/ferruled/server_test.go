@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/internal/jsonrpc"
+)
+
+// session runs a session over the request lines and returns the replies
+// by ID.
+func session(t *testing.T, lines ...string) map[string]reply {
+	t.Helper()
+	return limitedSession(t, ferrule.Limits{}, lines...)
+}
+
+// limitedSession is session with a server parsing within limits.
+func limitedSession(t *testing.T, limits ferrule.Limits, lines ...string) map[string]reply {
+	t.Helper()
+	s := newServer(limits)
+	defer s.close()
+	var out, log bytes.Buffer
+	if err := s.serve(strings.NewReader(strings.Join(lines, "\n")+"\n"), &out, &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Len() > 0 {
+		t.Errorf("log: %s", log.String())
+	}
+	replies := make(map[string]reply)
+	for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if l == "" {
+			continue
+		}
+		var r reply
+		if err := json.Unmarshal([]byte(l), &r); err != nil {
+			t.Fatalf("reply %s: %v", l, err)
+		}
+		replies[string(r.ID)] = r
+	}
+	return replies
+}
+
+// reply is a response or an error response.
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc.Error  `json:"error"`
+}
+
+// result decodes the result of r into v.
+func result(t *testing.T, r reply, v any) {
+	t.Helper()
+	if r.Error != nil {
+		t.Fatalf("error %d: %s", r.Error.Code, r.Error.Message)
+	}
+	if err := json.Unmarshal(r.Result, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMethods(t *testing.T) {
+	replies := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"format","params":{"source":"const x=1;"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"parse","params":{"source":"const x = ;","tree":true}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"symbols","params":{"source":"function f() -> Unit {\n}\n"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"lint","params":{"source":"function f(c: i32) -> i32 {\n  match c {\n    _ -> { return 1; }\n    0 -> { return 2; }\n  }\n}\n","analyzers":["unreachable"]}}`,
+		`{"jsonrpc":"2.0","method":"format","params":{"source":"const x=1;"}}`,
+	)
+	if len(replies) != 4 {
+		t.Errorf("got %d replies, want 4, none for the notification", len(replies))
+	}
+
+	var formatted formatResult
+	result(t, replies["1"], &formatted)
+	if formatted.Source != "const x = 1;\n" || !formatted.Changed {
+		t.Errorf("format = %+v", formatted)
+	}
+
+	var parsed parseResult
+	result(t, replies["2"], &parsed)
+	if len(parsed.Diagnostics) == 0 || parsed.Diagnostics[0].Category != "syntax" {
+		t.Errorf("parse diagnostics = %+v, want a syntax error", parsed.Diagnostics)
+	}
+	if !strings.HasPrefix(parsed.Tree, "(source_file") {
+		t.Errorf("parse tree = %q", parsed.Tree)
+	}
+
+	var syms []symbol
+	result(t, replies["3"], &syms)
+	if len(syms) != 1 || syms[0].Name != "f" || syms[0].Range.End.Row != 1 {
+		t.Errorf("symbols = %+v", syms)
+	}
+
+	var linted lintResult
+	result(t, replies["4"], &linted)
+	if len(linted.Diagnostics) != 1 || linted.Diagnostics[0].Category != "unreachable" || linted.Diagnostics[0].Range.Start.Row != 3 {
+		t.Errorf("lint = %+v", linted.Diagnostics)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	replies := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"format","params":{"source":"const x = ;"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"compile","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"parse"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"lint","params":{"source":"","analyzers":["nope"]}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"parse","params":[1]}`,
+		`{not json`,
+	)
+	for id, code := range map[string]int{
+		"1":    codeSyntaxError,
+		"2":    jsonrpc.MethodNotFound,
+		"3":    jsonrpc.InvalidParams,
+		"4":    jsonrpc.InvalidParams,
+		"5":    jsonrpc.InvalidParams,
+		"null": jsonrpc.ParseError,
+	} {
+		r, ok := replies[id]
+		if !ok || r.Error == nil || r.Error.Code != code {
+			t.Errorf("reply %s = %+v, want error %d", id, r, code)
+		}
+	}
+	if data, _ := replies["1"].Error.Data.([]any); len(data) == 0 {
+		t.Errorf("syntax error data = %v, want the diagnostics", replies["1"].Error.Data)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	replies := limitedSession(t, ferrule.Limits{Bytes: 64, Depth: 8},
+		`{"jsonrpc":"2.0","id":1,"method":"parse","params":{"source":"const x = 1;"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"format","params":{"source":"const x = ((((((((1))))))));"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"lint","params":{"source":"const x = 1;\nconst y = 2;\nconst z = 3;\nconst w = 4;\nconst v = 5;\n"}}`,
+	)
+	if r := replies["1"]; r.Error != nil {
+		t.Errorf("reply 1 = %+v, want a result", r)
+	}
+	for _, id := range []string{"2", "3"} {
+		if r := replies[id]; r.Error == nil || r.Error.Code != codeLimitExceeded {
+			t.Errorf("reply %s = %+v, want error %d", id, r, codeLimitExceeded)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[lint]\nunreachable = false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.fe")
+	if err := os.WriteFile(path, []byte("function f(c: i32) -> i32 {\n  match c {\n    _ -> { return 1; }\n    0 -> { return 2; }\n  }\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, _ := json.Marshal(path)
+	replies := session(t, `{"jsonrpc":"2.0","id":1,"method":"lint","params":{"path":`+string(p)+`,"analyzers":["unreachable"]}}`)
+	var linted lintResult
+	result(t, replies["1"], &linted)
+	if len(linted.Diagnostics) != 0 {
+		t.Errorf("lint = %+v, want nothing with unreachable turned off", linted.Diagnostics)
+	}
+}
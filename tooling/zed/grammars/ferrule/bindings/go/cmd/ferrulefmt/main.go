@@ -0,0 +1,283 @@
+// Command ferrulefmt formats ferrule source in the canonical style.
+//
+// Without arguments it formats standard input to standard output. Given
+// files or directories it formats every .fe file found:
+//
+//	ferrulefmt [flags] [path ...]
+//
+// The flags are:
+//
+//	-l	list files whose formatting differs instead of printing them
+//	-w	write the result back to the source file instead of stdout
+//	-stdin-filepath path
+//		format standard input as the file at path, which need not exist:
+//		its ferrule.toml applies and messages name it
+//	-batch	format many files read from standard input; see below
+//	-organize-imports
+//		also sort imports, drop duplicates and group them; see
+//		format.OrganizeImports
+//	-verify	check the formatter instead of formatting; see below
+//
+// The format.width setting of the project's ferrule.toml, looked up from
+// each path given or from the current directory for standard input, sets
+// the line width, format.local_imports the packages -organize-imports
+// groups with the project's, format.newline, format.bom and
+// format.final_newline the line breaks and byte order mark of the output,
+// and files its ignore patterns exclude are skipped when walking
+// directories; see package config. So are .git directories and what
+// .gitignore and .ferruleignore files exclude, as by package walk, and
+// generated files, those with a
+// "// Code generated ... DO NOT EDIT." header or matching generated.paths,
+// unless generated.format is true. Standard input read as an ignored or
+// generated file is printed unchanged, as are such records in batch mode,
+// and is not checked by -verify, which prints nothing for it.
+//
+// Source stored in UTF-16 or Latin-1, with or without a byte order mark,
+// is formatted as the text it decodes to and written in the encoding it
+// was read in; see package charset.
+//
+// With -batch, standard input is a series of records of a path and a
+// source, each followed by a NUL byte. Every record is answered as soon as
+// it is read with a record of the same path and the formatted source. A
+// source that does not format is answered unchanged, with the error on
+// standard error, so that answers and records stay in step. Editors and
+// pre-commit hooks use it to format many files with one process.
+//
+// With -verify, nothing is formatted: every source is checked to format
+// to a source that parses to the same syntax tree and formats to itself.
+// For a source that does not, a counterexample is printed, the source
+// reduced to as few syntax nodes as still fail the same way together with
+// what it formats to, and the exit code is 1, as it is for sources with
+// syntax errors, which are not checked. Releases of the formatter run it
+// over a corpus of sources to gate on its round trips being safe.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/internal/batch"
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+var (
+	list      = flag.Bool("l", false, "list files whose formatting differs from ferrulefmt's")
+	write     = flag.Bool("w", false, "write result to (source) file instead of stdout")
+	stdinPath = flag.String("stdin-filepath", "", "format standard input as the file at `path`")
+	batchMode = flag.Bool("batch", false, "format NUL-delimited records of path and source read from standard input")
+	organize  = flag.Bool("organize-imports", false, "sort, group and deduplicate imports too")
+	verifying = flag.Bool("verify", false, "check that formatting is idempotent and keeps the syntax tree, printing a minimized counterexample if not")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: ferrulefmt [flags] [path ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	os.Exit(run(flag.Args(), os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(paths []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if *batchMode {
+		if len(paths) > 0 || *list || *write || *stdinPath != "" || *verifying {
+			fmt.Fprintln(stderr, "ferrulefmt: -batch takes no paths and no other flags but -organize-imports")
+			return 2
+		}
+		return runBatch(stdin, stdout, stderr)
+	}
+	handle := process
+	if *verifying {
+		if *list || *write {
+			fmt.Fprintln(stderr, "ferrulefmt: cannot use -verify with -l or -w")
+			return 2
+		}
+		handle = verify
+	}
+	if len(paths) == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "ferrulefmt: cannot use -w with standard input")
+			return 2
+		}
+		name, dir := "<standard input>", "."
+		if *stdinPath != "" {
+			name, dir = *stdinPath, filepath.Dir(*stdinPath)
+		}
+		cfg, err := config.ForDir(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			return 2
+		}
+		if *stdinPath != "" && (cfg.Ignored(*stdinPath, false) || leaveGenerated(cfg, *stdinPath, src)) {
+			if !*list && !*verifying {
+				stdout.Write(src)
+			}
+			return 0
+		}
+		if err := handle(name, src, cfg.Format, stdout); err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if *stdinPath != "" {
+		fmt.Fprintln(stderr, "ferrulefmt: cannot use -stdin-filepath with paths")
+		return 2
+	}
+
+	status := 0
+	for _, path := range paths {
+		cfg, err := projectConfig(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			status = 2
+			continue
+		}
+		err = walk.WalkDir(nil, path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != path && cfg.Ignored(p, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || (p != path && filepath.Ext(p) != ".fe") {
+				return nil
+			}
+			src, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			if p != path && leaveGenerated(cfg, p, src) {
+				return nil
+			}
+			if err := handle(p, src, cfg.Format, stdout); err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", p, err)
+				status = 1
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			status = 2
+		}
+	}
+	return status
+}
+
+// runBatch formats the records read from stdin, answering each on stdout.
+func runBatch(stdin io.Reader, stdout, stderr io.Writer) int {
+	r := batch.NewReader(stdin)
+	status := 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return status
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			return 2
+		}
+		out := rec.Source
+		cfg, err := config.ForDir(filepath.Dir(rec.Path))
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			status = 2
+		case !cfg.Ignored(rec.Path, false) && !leaveGenerated(cfg, rec.Path, rec.Source):
+			formatted, err := formatSource(cfg.Format, rec.Source)
+			if err != nil {
+				fmt.Fprintf(stderr, "%s: %v\n", rec.Path, err)
+				status = max(status, 1)
+				break
+			}
+			out = formatted
+		}
+		if err := batch.Write(stdout, rec.Path, out); err != nil {
+			fmt.Fprintf(stderr, "ferrulefmt: %v\n", err)
+			return 2
+		}
+	}
+}
+
+// projectConfig returns the configuration that applies to path, a file or
+// a directory.
+func projectConfig(path string) (*config.Config, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	return config.ForDir(path)
+}
+
+// leaveGenerated reports whether src, the content of the file name, is
+// generated and to be left as it is.
+func leaveGenerated(cfg *config.Config, name string, src []byte) bool {
+	return !cfg.Generated.Format && cfg.IsGenerated(name, charset.Decode(src).Source)
+}
+
+// formatSource formats src with opts, organizing its imports first if
+// asked to.
+func formatSource(opts format.Options, src []byte) ([]byte, error) {
+	if *organize {
+		var err error
+		if src, err = opts.OrganizeImports(src); err != nil {
+			return nil, err
+		}
+	}
+	return opts.Source(src)
+}
+
+// process formats src, the content of the file name. Source in UTF-16 or
+// Latin-1 is formatted decoded and written back in its encoding, and a
+// UTF-8 byte order mark is kept only if opts asks to.
+func process(name string, src []byte, opts format.Options, stdout io.Writer) error {
+	text := charset.Decode(src)
+	if text.Encoding == charset.UTF8 && !opts.KeepBOM {
+		text.BOM = false
+	}
+	formatted, err := formatSource(opts, text.Source)
+	if err != nil {
+		return err
+	}
+	out, err := text.Encode(formatted)
+	if err != nil {
+		return err
+	}
+	changed := !bytes.Equal(src, out)
+	if changed && text.Lossy {
+		return fmt.Errorf("not valid %v; not formatting it", text.Encoding)
+	}
+	if *list && changed {
+		fmt.Fprintln(stdout, name)
+	}
+	if *write && changed {
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(name, out, info.Mode().Perm())
+	}
+	if !*list && !*write {
+		_, err = stdout.Write(out)
+	}
+	return err
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStdin(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run(nil, strings.NewReader("const x=1;"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := stdout.String(); got != "const x = 1;\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestListAndWrite(t *testing.T) {
+	dir := t.TempDir()
+	messy := filepath.Join(dir, "messy.fe")
+	clean := filepath.Join(dir, "clean.fe")
+	os.WriteFile(messy, []byte("const x=1;"), 0o644)
+	os.WriteFile(clean, []byte("const x = 1;\n"), 0o644)
+
+	*list, *write = true, true
+	defer func() { *list, *write = false, false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != messy {
+		t.Errorf("listed %q, want %q", got, messy)
+	}
+	data, _ := os.ReadFile(messy)
+	if string(data) != "const x = 1;\n" {
+		t.Errorf("file not rewritten: %q", data)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	// UTF-16LE with a byte order mark, as Windows editors save it.
+	utf16 := func(s string) []byte {
+		out := []byte{0xff, 0xfe}
+		for _, c := range s {
+			out = append(out, byte(c), 0)
+		}
+		return out
+	}
+	dir := t.TempDir()
+	wide := filepath.Join(dir, "wide.fe")
+	latin := filepath.Join(dir, "latin.fe")
+	os.WriteFile(wide, utf16("const x=1;"), 0o644)
+	os.WriteFile(latin, []byte("const s=\"caf\xe9\";"), 0o644)
+
+	*write = true
+	defer func() { *write = false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(wide); !bytes.Equal(data, utf16("const x = 1;\n")) {
+		t.Errorf("UTF-16 file rewritten as %q", data)
+	}
+	if data, _ := os.ReadFile(latin); string(data) != "const s = \"caf\xe9\";\n" {
+		t.Errorf("Latin-1 file rewritten as %q", data)
+	}
+}
+
+func TestSyntaxErrorExitCode(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("function ("), &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if stderr.Len() == 0 {
+		t.Error("expected an error message")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("ignore = [\"gen/\"]\n\n[format]\nwidth = 30\n"), 0o644)
+	os.Mkdir(filepath.Join(dir, "gen"), 0o755)
+	os.WriteFile(filepath.Join(dir, "gen", "skipped.fe"), []byte("const x=1;"), 0o644)
+	long := filepath.Join(dir, "long.fe")
+	os.WriteFile(long, []byte("const x = add(first_argument, second_argument);\n"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	want := "const x = add(\n  first_argument,\n  second_argument,\n);\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[format]\nwidth = \"wide\"\n"), 0o644)
+	if code := run([]string{long}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("bad configuration: exit code %d, want 2", code)
+	}
+}
+
+func TestIgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".ferruleignore"), []byte("gen/\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("ign/\n"), 0o644)
+	for _, sub := range []string{"gen", "ign", ".git"} {
+		os.Mkdir(filepath.Join(dir, sub), 0o755)
+		os.WriteFile(filepath.Join(dir, sub, "messy.fe"), []byte("const x=1;"), 0o644)
+	}
+	messy := filepath.Join(dir, "messy.fe")
+	os.WriteFile(messy, []byte("const x=1;"), 0o644)
+
+	*list = true
+	defer func() { *list = false }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got := strings.TrimSpace(stdout.String()); got != messy {
+		t.Errorf("listed %q, want %q", got, messy)
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	dir := t.TempDir()
+	gen := "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\nconst x=1;"
+	os.WriteFile(filepath.Join(dir, "api.pb.fe"), []byte(gen), 0o644)
+	os.WriteFile(filepath.Join(dir, "marked.fe"), []byte("const y=2;"), 0o644)
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[generated]\npaths = [\"marked.fe\"]\n"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("exit code %d, got %q, want generated files skipped", code, stdout.String())
+	}
+
+	*stdinPath = filepath.Join(dir, "api.pb.fe")
+	if code := run(nil, strings.NewReader(gen), &stdout, &stderr); code != 0 || stdout.String() != gen {
+		t.Errorf("generated standard input: exit code %d, got %q, want it unchanged", code, stdout.String())
+	}
+	*stdinPath = ""
+
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[generated]\nformat = true\n"), 0o644)
+	stdout.Reset()
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if want := "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\nconst x = 1;\nconst y = 2;\n"; stdout.String() != want {
+		t.Errorf("with generated.format: got %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.fe")
+	os.WriteFile(name, []byte("\xef\xbb\xbfconst x=1;\nconst y = 2;"), 0o644)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{name}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "const x = 1;\nconst y = 2;\n"; got != want {
+		t.Errorf("default options: got %q, want %q", got, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("[format]\nnewline = \"crlf\"\nbom = \"keep\"\n"), 0o644)
+	stdout.Reset()
+	if code := run([]string{name}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "\xef\xbb\xbfconst x = 1;\r\nconst y = 2;\r\n"; got != want {
+		t.Errorf("crlf and bom: got %q, want %q", got, want)
+	}
+}
+
+func TestStdinFilepath(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ferrule.toml"), []byte("ignore = [\"gen/\"]\n\n[format]\nwidth = 30\n"), 0o644)
+	*stdinPath = filepath.Join(dir, "new.fe")
+	defer func() { *stdinPath = "" }()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, strings.NewReader("const x = add(first_argument, second_argument);"), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if want := "const x = add(\n  first_argument,\n  second_argument,\n);\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+
+	*stdinPath = filepath.Join(dir, "gen", "out.fe")
+	stdout.Reset()
+	if code := run(nil, strings.NewReader("const x=1;"), &stdout, &stderr); code != 0 || stdout.String() != "const x=1;" {
+		t.Errorf("ignored file: exit code %d, got %q, want it unchanged", code, stdout.String())
+	}
+
+	*verifying = true
+	defer func() { *verifying = false }()
+	stdout.Reset()
+	if code := run(nil, strings.NewReader("const x=1;"), &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Errorf("-verify of an ignored file: exit code %d, got %q, want no output", code, stdout.String())
+	}
+
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("with paths: exit code %d, want 2", code)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	*batchMode = true
+	defer func() { *batchMode = false }()
+
+	var stdout, stderr bytes.Buffer
+	in := "a.fe\x00const x=1;\x00b.fe\x00function (\x00c.fe\x00const y=2;\x00"
+	if code := run(nil, strings.NewReader(in), &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1 for the syntax error", code)
+	}
+	want := "a.fe\x00const x = 1;\n\x00b.fe\x00function (\x00c.fe\x00const y = 2;\n\x00"
+	if got := stdout.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(stderr.String(), "b.fe: ") {
+		t.Errorf("stderr = %q, want the error of b.fe", stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run(nil, strings.NewReader("a.fe\x00const x"), &stdout, &stderr); code != 2 {
+		t.Errorf("truncated input: exit code %d, want 2", code)
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	*organize = true
+	defer func() { *organize = false }()
+
+	var stdout, stderr bytes.Buffer
+	in := "import zlib;\nimport std.io;\nimport zlib;\nconst x=1;\n"
+	if code := run(nil, strings.NewReader(in), &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "import std.io;\n\nimport zlib;\nconst x = 1;\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "messy.fe"), []byte("// scale scales v.\nfunction scale(v:u32)->u32{return v*2;}\nconst x=-1;"), 0o644)
+	os.WriteFile(filepath.Join(dir, "clean.fe"), []byte("const x = 1;\n"), 0o644)
+
+	*verifying = true
+	defer func() { *verifying = false }()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 0 || stdout.Len() != 0 {
+		t.Fatalf("exit code %d: %s%s", code, stdout.String(), stderr.String())
+	}
+	*write = true
+	defer func() { *write = false }()
+	if code := run([]string{dir}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("-verify with -w: exit code %d", code)
+	}
+}
+
+func TestVerifyCounterexample(t *testing.T) {
+	src := []byte("package demo;\n\nfunction scale(v: u32) -> u32 {\n  return v * 2;\n}\n\nconst x = -1;\nconst y = x + 1;\n")
+	tests := []struct {
+		name      string
+		formatSrc func([]byte) ([]byte, error)
+		err       error
+		want      []string
+	}{
+		{
+			// a formatter adding a space after every = on each run.
+			"unstable",
+			func(src []byte) ([]byte, error) { return bytes.ReplaceAll(src, []byte("= "), []byte("=  ")), nil },
+			errUnstable,
+			[]string{"--- input:\nconst y = x;\n--- formatted:\nconst y =  x;\n--- formatted twice:\nconst y =   x;\n"},
+		},
+		{
+			// a formatter dropping minus signs.
+			"changed tree",
+			func(src []byte) ([]byte, error) { return bytes.ReplaceAll(src, []byte("-1"), []byte("1")), nil },
+			errChangedTree,
+			[]string{"--- input:\nconst x = -1;\n", "--- tree of the input:\n", "unary_expression"},
+		},
+		{
+			// a formatter losing a closing brace.
+			"broken output",
+			func(src []byte) ([]byte, error) { return bytes.Replace(src, []byte("}"), nil, 1), nil },
+			errBrokenOutput,
+			[]string{"--- input:\nfunction scale() -> u32 {\n}\n"},
+		},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		err := verifyWith(tt.formatSrc, "demo.fe", src, &stdout)
+		if err != tt.err {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		out := stdout.String()
+		if !strings.HasPrefix(out, "demo.fe: "+tt.err.Error()+"\n") {
+			t.Errorf("%s: output %q", tt.name, out)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(out, w) {
+				t.Errorf("%s: output lacks %q:\n%s", tt.name, w, out)
+			}
+		}
+	}
+}
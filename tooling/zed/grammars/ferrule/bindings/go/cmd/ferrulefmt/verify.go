@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/reduce"
+)
+
+// The ways formatting a source can go wrong that -verify reports.
+var (
+	errUnstable     = errors.New("formatting is not idempotent")
+	errChangedTree  = errors.New("formatting changes the syntax tree")
+	errBrokenOutput = errors.New("formatted source has syntax errors")
+	errNoReformat   = errors.New("formatted source does not format")
+)
+
+// errSyntax is the error of a source with syntax errors, which -verify
+// leaves alone as the formatter need not keep its tree.
+var errSyntax = errors.New("source has syntax errors")
+
+// failure is what -verify found wrong with formatting a source.
+type failure struct {
+	err error
+	// once and twice are the source formatted once and twice, and before
+	// and after the trees of the source and of once, as far as they were
+	// computed.
+	once, twice   []byte
+	before, after string
+}
+
+// verify checks that formatting src, the content of the file name, is
+// idempotent and keeps its syntax tree; see verifyWith.
+func verify(name string, src []byte, opts format.Options, stdout io.Writer) error {
+	formatSrc := func(src []byte) ([]byte, error) { return formatSource(opts, src) }
+	return verifyWith(formatSrc, name, charset.Decode(src).Source, stdout)
+}
+
+// verifyWith checks formatSrc on src. If it fails, it writes a
+// counterexample to stdout, minimized with package reduce to a source that
+// fails the same way, and returns the error that says how it fails.
+func verifyWith(formatSrc func([]byte) ([]byte, error), name string, src []byte, stdout io.Writer) error {
+	f, err := check(formatSrc, src)
+	if err != nil || f == nil {
+		return err
+	}
+	small, err := reduce.Minimize(src, func(src []byte) bool {
+		g, err := check(formatSrc, src)
+		return err == nil && g != nil && g.err == f.err
+	})
+	if err == nil {
+		// the predicate held for small, so it fails as src does.
+		src = small
+		f, _ = check(formatSrc, small)
+	}
+	fmt.Fprintf(stdout, "%s: %v\n", name, f.err)
+	section(stdout, "input", src)
+	if f.once != nil {
+		section(stdout, "formatted", f.once)
+	}
+	if f.twice != nil {
+		section(stdout, "formatted twice", f.twice)
+	}
+	if f.before != f.after {
+		section(stdout, "tree of the input", []byte(f.before))
+		section(stdout, "tree of the formatted source", []byte(f.after))
+	}
+	return f.err
+}
+
+// check formats src with formatSrc and returns what goes wrong, or nil if
+// nothing does. It returns an error for a source with syntax errors or
+// that does not format in the first place, which is no fault of the
+// formatter.
+func check(formatSrc func([]byte) ([]byte, error), src []byte) (*failure, error) {
+	treeBefore, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return nil, err
+	}
+	defer treeBefore.Close()
+	if treeBefore.HasError() {
+		return nil, errSyntax
+	}
+	once, err := formatSrc(src)
+	if err != nil {
+		return nil, err
+	}
+	treeAfter, err := ferrule.Parse(context.Background(), once)
+	if err != nil {
+		return nil, err
+	}
+	defer treeAfter.Close()
+	f := &failure{once: once}
+	if treeAfter.HasError() {
+		f.err = errBrokenOutput
+		return f, nil
+	}
+	f.before, f.after = dump.SExpr(treeBefore.RootNode()), dump.SExpr(treeAfter.RootNode())
+	if f.before != f.after {
+		f.err = errChangedTree
+		return f, nil
+	}
+	if f.twice, err = formatSrc(once); err != nil {
+		f.twice, f.err = nil, errNoReformat
+		return f, nil
+	}
+	if string(f.twice) != string(once) {
+		f.err = errUnstable
+		return f, nil
+	}
+	return nil, nil
+}
+
+// section writes text under a heading, ending it with a line break.
+func section(w io.Writer, heading string, text []byte) {
+	fmt.Fprintf(w, "--- %s:\n%s", heading, text)
+	if len(text) > 0 && text[len(text)-1] != '\n' {
+		fmt.Fprintln(w)
+	}
+}
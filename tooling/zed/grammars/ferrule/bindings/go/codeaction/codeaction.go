@@ -0,0 +1,188 @@
+// Package codeaction computes the code actions offered on a range of a
+// ferrule file: quick fixes taken from the suggested fixes of lint
+// diagnostics, refactorings of the selected code, and source actions on
+// the whole file.
+//
+// The refactorings defined here are rewrites of the typed syntax tree;
+// others come from package refactor. The code a rewrite changes is copied
+// with ast.Modify, changed, and printed back with package printer, so that
+// the code around the change keeps its layout and comments. An action
+// carries the edits that apply it; computing actions changes nothing.
+package codeaction
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+// Kind is the kind of a code action, in the dotted hierarchy of the
+// Language Server Protocol.
+type Kind string
+
+const (
+	QuickFix              Kind = "quickfix"
+	Refactor              Kind = "refactor"
+	RefactorExtract       Kind = "refactor.extract"
+	RefactorInline        Kind = "refactor.inline"
+	RefactorRewrite       Kind = "refactor.rewrite"
+	Source                Kind = "source"
+	SourceOrganizeImports Kind = "source.organizeImports"
+)
+
+// In reports whether k passes the filter only: only is empty, or k is one
+// of its kinds or below one, as refactor.extract is below refactor.
+func (k Kind) In(only []Kind) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, o := range only {
+		if k == o || strings.HasPrefix(string(k), string(o)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// An Action is a change offered to the user.
+type Action struct {
+	Title string
+	Kind  Kind
+	// Edits carry out the action. They do not overlap.
+	Edits []refactor.Edit
+	// Diagnostic is the diagnostic a quick fix resolves, or nil.
+	Diagnostic *analysis.Diagnostic
+	// Preferred marks the action an editor may apply without asking: the
+	// only fix of a diagnostic.
+	Preferred bool
+}
+
+// A Request asks for the actions on a range of a file.
+type Request struct {
+	Tree *ferrule.Tree
+	// Range is the selection; it is empty for a cursor.
+	Range tree_sitter.Range
+	// Only, if not empty, limits the actions to those of these kinds and
+	// below.
+	Only []Kind
+	// Diagnostics are the lint diagnostics of the file, whose suggested
+	// fixes become quick fixes.
+	Diagnostics []analysis.Diagnostic
+	// Format holds the formatter options used to organize imports.
+	Format format.Options
+}
+
+// provider computes the actions of one kind.
+type provider struct {
+	kind    Kind
+	actions func(*Request) []Action
+}
+
+// providers are consulted in order, so that quick fixes come first.
+var providers = []provider{
+	{QuickFix, quickFixes},
+	{RefactorExtract, extractBinding},
+	{RefactorExtract, extractFunction},
+	{RefactorInline, inlineBinding},
+	{RefactorRewrite, addMissingArms},
+	{SourceOrganizeImports, organizeImports},
+}
+
+// Actions returns the actions req asks for. Refactorings are not offered
+// on files with syntax errors.
+func Actions(req *Request) []Action {
+	var out []Action
+	for _, p := range providers {
+		if !p.kind.In(req.Only) {
+			continue
+		}
+		if p.kind != QuickFix && req.Tree.HasError() {
+			continue
+		}
+		out = append(out, p.actions(req)...)
+	}
+	return out
+}
+
+// quickFixes offers the suggested fixes of the diagnostics overlapping the
+// range.
+func quickFixes(req *Request) []Action {
+	src := req.Tree.Source()
+	var out []Action
+	for i := range req.Diagnostics {
+		d := &req.Diagnostics[i]
+		if d.Range.StartByte > req.Range.EndByte || d.Range.EndByte < req.Range.StartByte {
+			continue
+		}
+		for _, fix := range d.SuggestedFixes {
+			a := Action{Title: fix.Message, Kind: QuickFix, Diagnostic: d, Preferred: len(d.SuggestedFixes) == 1}
+			for _, e := range fix.TextEdits {
+				a.Edits = append(a.Edits, refactor.Edit{Range: byteRange(src, e.Start, e.End), NewText: string(e.NewText)})
+			}
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// extractFunction offers to move the selection into a new function; see
+// refactor.ExtractFunction.
+func extractFunction(req *Request) []Action {
+	if req.Range.StartByte == req.Range.EndByte {
+		return nil
+	}
+	fixes, err := refactor.ExtractFunction(req.Tree, req.Tree.Source(), req.Range)
+	if err != nil {
+		return nil
+	}
+	return []Action{{Title: "Extract to function", Kind: RefactorExtract, Edits: fixes}}
+}
+
+// inlineBinding offers to replace the uses of the local binding at the
+// cursor with its value and remove its declaration; see
+// refactor.InlineBinding.
+func inlineBinding(req *Request) []Action {
+	fixes, err := refactor.InlineBinding(req.Tree, req.Tree.Source(), req.Range.StartPoint)
+	if err != nil {
+		return nil
+	}
+	def := scope.Resolve(req.Tree).ResolveAt(req.Range.StartPoint)
+	title := "Inline constant " + def.Name
+	if def.Node.Parent().Child(0).Kind() == kind.KeywordVar {
+		title = "Inline variable " + def.Name
+	}
+	return []Action{{Title: title, Kind: RefactorInline, Edits: fixes}}
+}
+
+// organizeImports offers to organize the imports of the file when they
+// are not.
+func organizeImports(req *Request) []Action {
+	fixes, err := req.Format.ImportEdits(req.Tree)
+	if err != nil || len(fixes) == 0 {
+		return nil
+	}
+	src := req.Tree.Source()
+	a := Action{Title: "Organize imports", Kind: SourceOrganizeImports}
+	for _, e := range fixes {
+		a.Edits = append(a.Edits, refactor.Edit{Range: byteRange(src, e.Start, e.End), NewText: e.Text})
+	}
+	return []Action{a}
+}
+
+// byteRange returns the range of src between the offsets start and end.
+func byteRange(src []byte, start, end uint) tree_sitter.Range {
+	return tree_sitter.Range{
+		StartByte:  start,
+		EndByte:    end,
+		StartPoint: edits.Point(src, start),
+		EndPoint:   edits.Point(src, end),
+	}
+}
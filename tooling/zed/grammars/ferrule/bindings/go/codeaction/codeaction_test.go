@@ -0,0 +1,133 @@
+package codeaction_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/codeaction"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// actions returns the actions of kind k on src, whose selection is marked
+// with « and ».
+func actions(t *testing.T, marked string, k codeaction.Kind) ([]codeaction.Action, []byte) {
+	t.Helper()
+	start := strings.Index(marked, "«")
+	src := strings.Replace(marked, "«", "", 1)
+	end := strings.Index(src, "»")
+	src = strings.Replace(src, "»", "", 1)
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	diags, err := analysis.Run(tree, unreachable.Analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := []byte(src)
+	req := &codeaction.Request{
+		Tree: tree,
+		Range: tree_sitter.Range{
+			StartByte: uint(start), EndByte: uint(end),
+			StartPoint: edits.Point(b, uint(start)), EndPoint: edits.Point(b, uint(end)),
+		},
+		Only:        []codeaction.Kind{k},
+		Diagnostics: diags,
+	}
+	return codeaction.Actions(req), b
+}
+
+func TestActions(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  codeaction.Kind
+		src   string
+		title string
+		want  string
+	}{
+		{
+			"extract",
+			codeaction.RefactorExtract,
+			"function f(a: i32) -> i32 {\n  const value = 1;\n  return «a * 2» + value;\n}\n",
+			"Extract to constant value2",
+			"function f(a: i32) -> i32 {\n  const value = 1;\n  const value2 = a * 2;\n  return value2 + value;\n}\n",
+		},
+		{
+			"extract function",
+			codeaction.RefactorExtract,
+			"function f(a: i32) -> Bool {\n  return a > 0 && «a < 10»;\n}\n",
+			"Extract to function",
+			"function f(a: i32) -> Bool {\n  return a > 0 && extracted(a);\n}\n\nfunction extracted(a: i32) -> Bool {\n  return a < 10;\n}\n",
+		},
+		{
+			"inline",
+			codeaction.RefactorInline,
+			"function f(a: i32) -> i32 {\n  const «b» = a + 1; // one more\n  return b * b;\n}\n",
+			"Inline constant b",
+			"function f(a: i32) -> i32 {\n  return (a + 1) * (a + 1);\n}\n",
+		},
+		{
+			"missing arms",
+			codeaction.RefactorRewrite,
+			"type Shape = | Circle { r: f64 } | Square | Point;\nfunction f(s: Shape) -> i32 {\n  «match s {\n    Circle { r } -> { return 1; }\n  }\n  return 0;\n}\n",
+			"Add missing match arms",
+			"type Shape = | Circle { r: f64 } | Square | Point;\nfunction f(s: Shape) -> i32 {\n  match s {\n    Circle { r } -> { return 1; }\n    Square -> {}\n    Point -> {}\n  }\n  return 0;\n}\n",
+		},
+		{
+			"quick fix",
+			codeaction.QuickFix,
+			"function f(c: i32) -> i32 {\n  match c {\n    _ -> { return 1; }\n    «0» -> { return 2; }\n  }\n}\n",
+			"remove unreachable arm",
+			"function f(c: i32) -> i32 {\n  match c {\n    _ -> { return 1; }\n  }\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		got, src := actions(t, tt.src, tt.kind)
+		var a *codeaction.Action
+		for i := range got {
+			if got[i].Title == tt.title {
+				a = &got[i]
+			}
+		}
+		if a == nil {
+			t.Errorf("%s: got %+v, want an action titled %q", tt.name, got, tt.title)
+			continue
+		}
+		if out := string(refactor.Apply(src, a.Edits)); out != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, out, tt.want)
+		}
+	}
+}
+
+func TestNotOffered(t *testing.T) {
+	tests := []struct {
+		name string
+		kind codeaction.Kind
+		src  string
+	}{
+		{"partial expression", codeaction.RefactorExtract, "function f(a: i32) -> i32 {\n  return «a *» 2;\n}\n"},
+		{"loop condition", codeaction.RefactorExtract, "function f(a: i32) -> Unit {\n  while «a > 0» {\n    a = a - 1;\n  }\n}\n"},
+		{"short circuit", codeaction.RefactorExtract, "function f(a: Bool) -> Bool {\n  return a && «g()»;\n}\n"},
+		{"call", codeaction.RefactorInline, "function f() -> i32 {\n  const «b» = g();\n  return b + b;\n}\n"},
+		{"assigned var", codeaction.RefactorInline, "function f() -> i32 {\n  var «b» = 1;\n  b = 2;\n  return b;\n}\n"},
+		{"catch-all", codeaction.RefactorRewrite, "type T = | A | B;\nfunction f(t: T) -> i32 {\n  «match t {\n    A -> { return 1; }\n    _ -> { return 2; }\n  }\n}\n"},
+	}
+	for _, tt := range tests {
+		got, _ := actions(t, tt.src, tt.kind)
+		for _, a := range got {
+			// Moving code into a function changes neither when nor how
+			// often it runs.
+			if a.Title != "Extract to function" {
+				t.Errorf("%s: got %+v, want none", tt.name, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package codeaction
+
+import (
+	"fmt"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// expressions are the kinds of expression worth binding to a name.
+var expressions = map[string]bool{
+	kind.ArrayExpression:         true,
+	kind.BinaryExpression:        true,
+	kind.BooleanLiteral:          true,
+	kind.CallExpression:          true,
+	kind.CharLiteral:             true,
+	kind.CheckExpression:         true,
+	kind.FloatLiteral:            true,
+	kind.IfExpression:            true,
+	kind.IndexExpression:         true,
+	kind.IntegerLiteral:          true,
+	kind.MatchExpression:         true,
+	kind.MemberExpression:        true,
+	kind.ParenthesizedExpression: true,
+	kind.RecordExpression:        true,
+	kind.StringLiteral:           true,
+	kind.UnaryExpression:         true,
+}
+
+// statements are the kinds of node that stand as statements in a block.
+var statements = map[string]bool{
+	kind.Block:               true,
+	kind.ConstDeclaration:    true,
+	kind.ExpressionStatement: true,
+	kind.ForStatement:        true,
+	kind.IfStatement:         true,
+	kind.MatchStatement:      true,
+	kind.ReturnStatement:     true,
+	kind.WhileStatement:      true,
+}
+
+// extractBinding offers to bind the selected expression to a new constant
+// declared before the statement holding it. It is not offered where that
+// would change when or how often the expression is evaluated: in a loop
+// condition, a deferred expression, a match arm, or on the right of && or
+// ||.
+func extractBinding(req *Request) []Action {
+	src := req.Tree.Source()
+	start, end := req.Range.StartByte, req.Range.EndByte
+	for start < end && isSpace(src[start]) {
+		start++
+	}
+	for end > start && isSpace(src[end-1]) {
+		end--
+	}
+	if start == end {
+		return nil
+	}
+	n := req.Tree.RootNode().NamedDescendantForByteRange(start, end)
+	for n != nil && n.Parent() != nil && n.Parent().StartByte() == start && n.Parent().EndByte() == end {
+		n = n.Parent()
+	}
+	if n == nil || n.StartByte() != start || n.EndByte() != end || !expressions[n.Kind()] {
+		return nil
+	}
+	stmt := n
+	for stmt.Parent() != nil && stmt.Parent().Kind() != kind.Block {
+		p := stmt.Parent()
+		if p.Kind() == kind.MatchArm || p.Kind() == kind.SourceFile {
+			return nil
+		}
+		if b, ok := ast.Wrap(p).(*ast.BinaryExpression); ok && b.Right().Raw().Id() == stmt.Id() {
+			if op := b.Operator(); op == "&&" || op == "||" {
+				return nil
+			}
+		}
+		stmt = p
+	}
+	if stmt.Parent() == nil || !statements[stmt.Kind()] || stmt.Kind() == kind.WhileStatement {
+		return nil
+	}
+
+	name := unusedName(req.Tree.RootNode(), src, "value")
+	r := newRewrite(stmt.Parent(), src)
+	parent, i := r.locate(n)
+	block, j := r.locate(stmt)
+	parent.Replace(i, ast.Ident(name))
+	block.Insert(j, ast.Child(ast.NewNode(kind.ConstDeclaration,
+		ast.Child(ast.NewToken("const")),
+		ast.Field("name", ast.Ident(name)),
+		ast.Child(ast.NewToken("=")),
+		ast.Field("value", ast.Modify(ast.Wrap(n), src)),
+		ast.Child(ast.NewToken(";")),
+	)))
+	e, err := r.edit()
+	if err != nil {
+		return nil
+	}
+	return []Action{{Title: "Extract to constant " + name, Kind: RefactorExtract, Edits: []refactor.Edit{e}}}
+}
+
+// addMissingArms offers to add an arm with an empty body for each case
+// the innermost match around the cursor lacks: the variants of a union
+// type declared in the file, or true or false. It is not offered for
+// matches with a catch-all arm.
+func addMissingArms(req *Request) []Action {
+	src := req.Tree.Source()
+	root := req.Tree.Root()
+	var match ast.Node
+	var arms []*ast.MatchArm
+	ast.InspectNamed(root, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		raw := n.Raw()
+		if raw.StartByte() > req.Range.StartByte || raw.EndByte() < req.Range.StartByte {
+			return false
+		}
+		switch m := n.(type) {
+		case *ast.MatchStatement:
+			match, arms = m, m.Arms()
+		case *ast.MatchExpression:
+			match, arms = m, m.Arms()
+		}
+		return true
+	})
+	if match == nil || len(arms) == 0 {
+		return nil
+	}
+
+	covered := make(map[string]bool)
+	var variants []string
+	bools := false
+	for _, arm := range arms {
+		p := arm.Pattern()
+		if p == nil {
+			continue
+		}
+		if _, ok := p.Value().(*ast.Identifier); p.IsWildcard() || ok && arm.Guard() == nil {
+			return nil
+		}
+		name := ""
+		switch v := p.Value().(type) {
+		case *ast.TypeIdentifier:
+			name = v.Text(src)
+		case *ast.DestructuringPattern:
+			if v.TypeName() != nil {
+				name = v.TypeName().Text(src)
+			}
+		case *ast.BooleanLiteral:
+			name, bools = v.Text(src), true
+		}
+		if name == "" {
+			continue
+		}
+		if name != "true" && name != "false" {
+			variants = append(variants, name)
+		}
+		if arm.Guard() == nil {
+			covered[name] = true
+		}
+	}
+	var cases []string
+	switch {
+	case len(variants) > 0:
+		cases = unionOf(root, src, variants)
+	case bools:
+		cases = []string{"true", "false"}
+	}
+	var missing []string
+	for _, c := range cases {
+		if !covered[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	r := newRewrite(match.Raw(), src)
+	last := arms[len(arms)-1].Raw()
+	m, i := r.locate(last)
+	for k, c := range missing {
+		pattern := ast.NewLeaf(kind.TypeIdentifier, c)
+		if bools {
+			pattern = ast.NewLeaf(kind.BooleanLiteral, c)
+		}
+		m.Insert(i+1+k, ast.Child(ast.NewNode(kind.MatchArm,
+			ast.Child(ast.NewNode(kind.Pattern, ast.Child(pattern))),
+			ast.Child(ast.NewToken("->")),
+			ast.Child(ast.NewNode(kind.Block, ast.Child(ast.NewToken("{")), ast.Child(ast.NewToken("}")))),
+		)))
+	}
+	e, err := r.edit()
+	if err != nil {
+		return nil
+	}
+	title := "Add missing match arms"
+	if len(missing) == 1 {
+		title = fmt.Sprintf("Add missing match arm %s", missing[0])
+	}
+	return []Action{{Title: title, Kind: RefactorRewrite, Edits: []refactor.Edit{e}}}
+}
+
+// unionOf returns the variants of the one union type declared in the file
+// that has all of variants, or nil.
+func unionOf(root *ast.SourceFile, src []byte, variants []string) []string {
+	var found []string
+	for _, item := range root.Items() {
+		decl, ok := item.(*ast.TypeDeclaration)
+		if !ok {
+			continue
+		}
+		t, ok := decl.Type().(*ast.UnionType)
+		if !ok {
+			continue
+		}
+		has := make(map[string]bool)
+		var names []string
+		for _, v := range t.UnionVariants() {
+			if v.Name() != nil {
+				names = append(names, v.Name().Text(src))
+				has[v.Name().Text(src)] = true
+			}
+		}
+		all := true
+		for _, v := range variants {
+			all = all && has[v]
+		}
+		if all {
+			if found != nil {
+				return nil
+			}
+			found = names
+		}
+	}
+	return found
+}
+
+// unusedName returns base, or base followed by the smallest number from 2
+// up, whichever is not an identifier of the file below root.
+func unusedName(root *tree_sitter.Node, src []byte, base string) string {
+	used := make(map[string]bool)
+	for n := range ast.Wrap(root).Descendants() {
+		if n.Kind() == kind.Identifier {
+			used[n.Text(src)] = true
+		}
+	}
+	name := base
+	for k := 2; used[name]; k++ {
+		name = fmt.Sprintf("%s%d", base, k)
+	}
+	return name
+}
+
+func isSpace(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' }
@@ -0,0 +1,65 @@
+package codeaction
+
+import (
+	"fmt"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/printer"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// A rewrite is a synthetic copy of a parsed node, changed in place and
+// printed back over the original.
+type rewrite struct {
+	raw  *tree_sitter.Node
+	root *ast.Synthetic
+}
+
+func newRewrite(n *tree_sitter.Node, src []byte) *rewrite {
+	return &rewrite{raw: n, root: ast.Modify(ast.Wrap(n), src)}
+}
+
+// locate returns the copy of the parent of n, a node below the rewritten
+// one, and the index of the copy of n among its parts. It must be called
+// before the parts of the ancestors of n are inserted or removed.
+func (r *rewrite) locate(n *tree_sitter.Node) (*ast.Synthetic, int) {
+	var path []int
+	for n.Id() != r.raw.Id() {
+		p := n.Parent()
+		path = append(path, childIndex(p, n))
+		n = p
+	}
+	s := r.root
+	for k := len(path) - 1; k > 0; k-- {
+		s = s.Parts()[path[k]].Node.(*ast.Synthetic)
+	}
+	return s, path[0]
+}
+
+// childIndex returns the index of the copy of the child c of p among the
+// parts of the copy of p, which leaves out missing nodes.
+func childIndex(p, c *tree_sitter.Node) int {
+	i := 0
+	for k := uint(0); k < p.ChildCount(); k++ {
+		child := p.Child(k)
+		if child.Id() == c.Id() {
+			return i
+		}
+		if !child.IsMissing() {
+			i++
+		}
+	}
+	panic(fmt.Sprintf("codeaction: %s is not a child of %s", c.Kind(), p.Kind()))
+}
+
+// edit returns the edit replacing the rewritten node with its changed
+// copy.
+func (r *rewrite) edit() (refactor.Edit, error) {
+	text, err := printer.Print(r.root)
+	if err != nil {
+		return refactor.Edit{}, err
+	}
+	return refactor.Edit{Range: r.raw.Range(), NewText: string(text)}, nil
+}
@@ -0,0 +1,173 @@
+// Package codelens computes the code lenses editors show above the
+// functions of a ferrule file: how often each function is referred to
+// across the project, and markers to run the entry points of a program
+// and its tests.
+//
+// References are the references of the index, matched to functions by
+// name as package hierarchy matches calls: a name defined in the file of
+// the reference denotes that definition, and otherwise every function of
+// the project with the name. The index records calls only, so other uses
+// of a function, such as passing it as a value, are not counted.
+//
+// Entry points and tests are the top-level functions whose names match
+// the patterns of Options, in the syntax of path.Match:
+//
+//	main          run
+//	testParse     test, with the default patterns
+package codelens
+
+import (
+	"fmt"
+	"path"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Kind is the kind of a lens.
+type Kind int
+
+const (
+	// References counts the references to a function.
+	References Kind = iota + 1
+	// Run marks a function run as a program.
+	Run
+	// Test marks a test function.
+	Test
+)
+
+func (k Kind) String() string {
+	switch k {
+	case References:
+		return "references"
+	case Run:
+		return "run"
+	case Test:
+		return "test"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// DefaultTests are the patterns of the names of test functions when
+// Options leave them unset: names starting with test and an upper-case
+// letter or an underscore, as in testParse and test_parse.
+var DefaultTests = []string{"test[A-Z_]*"}
+
+// DefaultRun are the names of the functions run as programs when Options
+// leave them unset.
+var DefaultRun = []string{"main"}
+
+// Options configures the lenses. A nil *Options uses the defaults.
+type Options struct {
+	// Tests are the patterns of the names of test functions. Nil means
+	// DefaultTests; an empty slice marks no tests.
+	Tests []string
+	// Run are the patterns of the names of the functions run as programs.
+	// Nil means DefaultRun; an empty slice marks none.
+	Run []string
+}
+
+// IsTest reports whether a top-level function of the name is a test. It
+// may be called on a nil *Options.
+func (o *Options) IsTest(name string) bool {
+	if o != nil && o.Tests != nil {
+		return matches(o.Tests, name)
+	}
+	return matches(DefaultTests, name)
+}
+
+// IsRun reports whether a top-level function of the name is run as a
+// program, which takes precedence over being a test. It may be called on
+// a nil *Options.
+func (o *Options) IsRun(name string) bool {
+	if o != nil && o.Run != nil {
+		return matches(o.Run, name)
+	}
+	return matches(DefaultRun, name)
+}
+
+// Lens is a code lens: a line of text shown above a function.
+type Lens struct {
+	Kind Kind
+	// Name is the name of the function and Range the range of its name.
+	Name  string
+	Range tree_sitter.Range
+	// Title is the text shown, such as "2 references" or "run".
+	Title string
+	// References are the locations of the references counted, for a
+	// References lens.
+	References []index.Location
+}
+
+// Compute returns the lenses of the indexed file, in the order of the
+// functions, the reference count of each coming before its markers. It
+// returns nil if the file is not indexed.
+func Compute(idx *index.Index, file string, opts *Options) []Lens {
+	f := idx.File(file)
+	if f == nil {
+		return nil
+	}
+	files := idx.Files()
+	var out []Lens
+	for _, d := range f.Definitions {
+		if d.Kind != symbols.Function && d.Kind != symbols.Method {
+			continue
+		}
+		refs := references(files, f, d.Name)
+		title := fmt.Sprintf("%d references", len(refs))
+		if len(refs) == 1 {
+			title = "1 reference"
+		}
+		out = append(out, Lens{Kind: References, Name: d.Name, Range: d.SelectionRange, Title: title, References: refs})
+		if d.Container != "" {
+			continue
+		}
+		switch {
+		case opts.IsRun(d.Name):
+			out = append(out, Lens{Kind: Run, Name: d.Name, Range: d.SelectionRange, Title: "run"})
+		case opts.IsTest(d.Name):
+			out = append(out, Lens{Kind: Test, Name: d.Name, Range: d.SelectionRange, Title: "test"})
+		}
+	}
+	return out
+}
+
+// references returns the references to name, defined in f, across files.
+func references(files []*index.File, f *index.File, name string) []index.Location {
+	var out []index.Location
+	for _, other := range files {
+		if other.Path != f.Path && defines(other, name) {
+			continue
+		}
+		for _, r := range other.References {
+			if r.Name == name {
+				out = append(out, index.Location{Path: other.Path, Range: r.Range})
+			}
+		}
+	}
+	return out
+}
+
+// defines reports whether f has a definition of name, which hides those
+// of other files.
+func defines(f *index.File, name string) bool {
+	for _, d := range f.Definitions {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether name matches one of patterns. Malformed
+// patterns match nothing.
+func matches(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package codelens_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/codelens"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+func TestCompute(t *testing.T) {
+	root := filepath.FromSlash("/project")
+	idx := index.New(root)
+	idx.SetFS(vfs.Map{
+		filepath.Join(root, "lib.fe"): `function helper() -> i32 { return 1; }
+function main() -> i32 { return helper() + helper(); }
+function testHelper() -> Unit { helper(); }
+function testament() -> Unit {}
+component Tools {
+  function main() -> Unit {}
+}
+`,
+		filepath.Join(root, "use.fe"):   "function use() -> i32 { return helper(); }\n",
+		filepath.Join(root, "other.fe"): "function helper() -> i32 { return 2; }\nfunction twice() -> i32 { return helper(); }\n",
+	})
+	if err := idx.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	describe := func(lenses []codelens.Lens) string {
+		var out []string
+		for _, l := range lenses {
+			out = append(out, fmt.Sprintf("%d:%s %s", l.Range.StartPoint.Row, l.Name, l.Title))
+		}
+		return strings.Join(out, "\n")
+	}
+	want := `0:helper 4 references
+1:main 0 references
+1:main run
+2:testHelper 0 references
+2:testHelper test
+3:testament 0 references
+5:main 0 references`
+	if got := describe(codelens.Compute(idx, "lib.fe", nil)); got != want {
+		t.Errorf("lenses:\n%s\nwant\n%s", got, want)
+	}
+
+	// the references of other.fe go to its own helper.
+	for _, l := range codelens.Compute(idx, "lib.fe", nil) {
+		for _, r := range l.References {
+			if r.Path == "other.fe" {
+				t.Errorf("%s counts a reference of other.fe", l.Name)
+			}
+		}
+	}
+
+	opts := &codelens.Options{Tests: []string{"test*"}, Run: []string{}}
+	want = `0:helper 4 references
+1:main 0 references
+2:testHelper 0 references
+2:testHelper test
+3:testament 0 references
+3:testament test
+5:main 0 references`
+	if got := describe(codelens.Compute(idx, "lib.fe", opts)); got != want {
+		t.Errorf("lenses with options:\n%s\nwant\n%s", got, want)
+	}
+	if got := codelens.Compute(idx, "missing.fe", nil); got != nil {
+		t.Errorf("lenses of a missing file: %v", got)
+	}
+}
@@ -0,0 +1,319 @@
+// Package complete proposes completions at the cursor, for LSP completion
+// responses.
+//
+// What is proposed depends on where the cursor is. In an import
+// declaration it is the packages of the project. Elsewhere it is, in this
+// order: the local names visible at the cursor, as resolved by package
+// scope; the top-level definitions of the file and then of the rest of the
+// project index; and the keywords that may start a declaration, statement
+// or expression there. Where a type is expected, only types are proposed.
+// Candidates are filtered by the part of the name already typed.
+package complete
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Kind is the kind of a completion item. The values match the LSP
+// CompletionItemKind enumeration.
+type Kind int
+
+const (
+	Method    Kind = 2
+	Function  Kind = 3
+	Variable  Kind = 6
+	Class     Kind = 7
+	Interface Kind = 8
+	Module    Kind = 9
+	Enum      Kind = 13
+	Keyword   Kind = 14
+	Constant  Kind = 21
+	Struct    Kind = 22
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Method:
+		return "method"
+	case Function:
+		return "function"
+	case Variable:
+		return "variable"
+	case Class:
+		return "class"
+	case Interface:
+		return "interface"
+	case Module:
+		return "module"
+	case Enum:
+		return "enum"
+	case Keyword:
+		return "keyword"
+	case Constant:
+		return "constant"
+	case Struct:
+		return "struct"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Item is a completion candidate.
+type Item struct {
+	Label string
+	Kind  Kind
+	// Detail says where the candidate comes from, such as "parameter" or
+	// the file of a definition.
+	Detail string
+}
+
+// Keywords by the syntactic context they are valid in.
+var (
+	declarationKeywords = []string{"capability", "component", "const", "domain", "error", "function", "import", "package", "pub", "type", "use", "var"}
+	statementKeywords   = []string{"break", "const", "continue", "defer", "for", "return", "var", "while"}
+	expressionKeywords  = []string{"check", "err", "false", "function", "if", "match", "null", "ok", "true"}
+	primitiveTypes      = []string{"Bool", "Bytes", "Char", "Never", "String", "Unit", "f16", "f32", "f64", "i128", "i16", "i32", "i64", "i8", "u128", "u16", "u32", "u64", "u8", "usize"}
+)
+
+// place is the kind of position the cursor is at.
+type place int
+
+const (
+	declaration place = iota // at the start of a top-level declaration
+	statement                // at the start of a statement
+	expression
+	typ        // where a type is expected
+	importPath // in the path of an import declaration
+	member     // after a dot, where nothing is known
+)
+
+// At returns the completions at point p of tree. idx may be nil to propose
+// names from tree only.
+func At(idx *index.Index, tree *ferrule.Tree, p tree_sitter.Point) []Item {
+	src := tree.Source()
+	off := offset(src, p)
+	where, start := placeAt(tree, off)
+	prefix := string(src[start:off])
+
+	var items []Item
+	seen := make(map[string]bool)
+	add := func(label string, k Kind, detail string) {
+		if !strings.HasPrefix(label, prefix) || label == prefix || seen[label] {
+			return
+		}
+		seen[label] = true
+		items = append(items, Item{Label: label, Kind: k, Detail: detail})
+	}
+
+	switch where {
+	case member:
+		return nil
+	case importPath:
+		if idx != nil {
+			var pkgs []string
+			for _, f := range idx.Files() {
+				if f.Package != "" {
+					pkgs = append(pkgs, f.Package)
+				}
+			}
+			sort.Strings(pkgs)
+			for _, pkg := range pkgs {
+				add(pkg, Module, "")
+			}
+		}
+		return items
+	}
+
+	if where != typ && where != declaration {
+		info := scope.Resolve(tree)
+		for s := info.ScopeAt(edits.Point(src, start)); s != nil; s = s.Parent {
+			for i := len(s.Definitions) - 1; i >= 0; i-- {
+				d := s.Definitions[i]
+				if d.VisibleAt(start) && d.Node.StartByte() != start {
+					add(d.Name, localKind(d), d.Kind)
+				}
+			}
+		}
+	}
+	topLevel := func(path string, defs []index.Definition) {
+		for _, d := range defs {
+			if d.Container != "" || d.Kind == symbols.Package {
+				continue
+			}
+			k := itemKind(d.Kind)
+			if (where == typ) != isType(k) {
+				continue
+			}
+			add(d.Name, k, path)
+		}
+	}
+	if where != declaration {
+		topLevel("", index.Extract("", tree).Definitions)
+		if idx != nil {
+			for _, f := range idx.Files() {
+				topLevel(f.Path, f.Definitions)
+			}
+		}
+	}
+
+	var keywords []string
+	switch where {
+	case declaration:
+		keywords = declarationKeywords
+	case statement:
+		keywords = append(append([]string(nil), statementKeywords...), expressionKeywords...)
+		sort.Strings(keywords)
+	case expression:
+		keywords = expressionKeywords
+	case typ:
+		keywords = primitiveTypes
+	}
+	for _, kw := range keywords {
+		add(kw, Keyword, "")
+	}
+	return items
+}
+
+// placeAt classifies the position off of tree and returns the start of
+// the name being typed there.
+func placeAt(tree *ferrule.Tree, off uint) (place, uint) {
+	src := tree.Source()
+	start := off
+	for start > 0 && isWord(src[start-1]) {
+		start--
+	}
+	if line := statementText(src, start); strings.HasPrefix(line, "import ") {
+		for start > 0 && (isWord(src[start-1]) || src[start-1] == '.') {
+			start--
+		}
+		return importPath, start
+	}
+
+	// the last token before the name.
+	b := start
+	for b > 0 && isSpace(src[b-1]) {
+		b--
+	}
+	if b == 0 {
+		return declaration, start
+	}
+	prev := tree.RootNode().DescendantForByteRange(b-1, b)
+	inBlock, inArm, inRecord := false, false, false
+	for n := prev; n != nil; n = n.Parent() {
+		switch n.Kind() {
+		case kind.Block:
+			inBlock = inBlock || n.StartByte() < start && (start < n.EndByte() || n.Child(n.ChildCount()-1).IsMissing())
+		case kind.MatchArm:
+			inArm = true
+		case kind.RecordExpression:
+			inRecord = true
+		}
+	}
+	switch src[b-1] {
+	case '.':
+		return member, start
+	case ':':
+		if inRecord {
+			return expression, start
+		}
+		return typ, start
+	case '{', '}', ';':
+		if inBlock || enclosingBlock(tree, start) {
+			return statement, start
+		}
+		return declaration, start
+	}
+	if b >= 2 && string(src[b-2:b]) == "->" && !inArm {
+		return typ, start
+	}
+	if !inBlock && !enclosingBlock(tree, start) && prev.Kind() == kind.KeywordPub {
+		return declaration, start
+	}
+	return expression, start
+}
+
+// enclosingBlock reports whether off is inside a block of tree.
+func enclosingBlock(tree *ferrule.Tree, off uint) bool {
+	for n := tree.RootNode().DescendantForByteRange(off, off); n != nil; n = n.Parent() {
+		if n.Kind() == kind.Block && n.StartByte() < off && off < n.EndByte() {
+			return true
+		}
+	}
+	return false
+}
+
+// statementText returns the text between the end of the previous
+// statement or declaration and off, without leading space.
+func statementText(src []byte, off uint) string {
+	i := off
+	for i > 0 && src[i-1] != ';' && src[i-1] != '{' && src[i-1] != '}' {
+		i--
+	}
+	return strings.TrimLeft(string(src[i:off]), " \t\r\n")
+}
+
+func isWord(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+func localKind(d *scope.Definition) Kind {
+	if d.Kind == "function" {
+		return Function
+	}
+	return Variable
+}
+
+func itemKind(k symbols.Kind) Kind {
+	switch k {
+	case symbols.Function:
+		return Function
+	case symbols.Method:
+		return Method
+	case symbols.Constant:
+		return Constant
+	case symbols.Variable:
+		return Variable
+	case symbols.Struct:
+		return Struct
+	case symbols.Enum:
+		return Enum
+	case symbols.Interface:
+		return Interface
+	case symbols.Module:
+		return Module
+	}
+	return Class
+}
+
+// isType reports whether items of kind k name types.
+func isType(k Kind) bool {
+	switch k {
+	case Struct, Enum, Interface, Class:
+		return true
+	}
+	return false
+}
+
+// offset converts p to a byte offset of src.
+func offset(src []byte, p tree_sitter.Point) uint {
+	row, off := uint(0), uint(0)
+	for off < uint(len(src)) && row < p.Row {
+		if src[off] == '\n' {
+			row++
+		}
+		off++
+	}
+	return min(off+p.Column, uint(len(src)))
+}
@@ -0,0 +1,72 @@
+package complete_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/complete"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+const utilSrc = `package app.util;
+
+pub function scale(v: u32) -> u32 { return v; }
+pub type Size = u32;
+`
+
+// at returns the point of the first | in src, and src without it.
+func at(src string) (string, tree_sitter.Point) {
+	i := strings.Index(src, "|")
+	before := src[:i]
+	row := uint(strings.Count(before, "\n"))
+	col := uint(len(before) - strings.LastIndex(before, "\n") - 1)
+	return before + src[i+1:], tree_sitter.Point{Row: row, Column: col}
+}
+
+func TestAt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "util.fe"), []byte(utilSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const head = "package app;\n\ntype Sample = u32;\n\nfunction sum(first: u32, second: u32) -> u32 {\n  const total = first;\n  "
+	tests := []struct {
+		name, src, want string
+	}{
+		{"locals", head + "return s|;\n}\n", "second:variable:parameter sum:function:function scale:function:util.fe"},
+		{"statement", head + "wh|\n}\n", "while:keyword:"},
+		{"statement keywords", head + "co|\n}\n", "const:keyword: continue:keyword:"},
+		{"expression", head + "return t|;\n}\n", "total:variable:var true:keyword:"},
+		{"not yet visible", "function f() -> u32 {\n  const a = a|;\n}\n", ""},
+		{"type", "function f(x: S|) -> u32 {}\n" + head + "}\n", "Sample:class: Size:class:util.fe String:keyword:"},
+		{"return type", "function f() -> Si|\n", "Size:class:util.fe"},
+		{"declaration", "package app;\n\nc|", "capability:keyword: component:keyword: const:keyword:"},
+		{"import", "package app;\nimport app.u|", "app.util:module:"},
+		{"member", head + "return first.s|;\n}\n", ""},
+	}
+	for _, tt := range tests {
+		src, p := at(tt.src)
+		tree, err := ferrule.Parse(context.Background(), []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, it := range complete.At(idx, tree, p) {
+			got = append(got, it.Label+":"+it.Kind.String()+":"+it.Detail)
+		}
+		tree.Close()
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, strings.Join(got, " "), tt.want)
+		}
+	}
+}
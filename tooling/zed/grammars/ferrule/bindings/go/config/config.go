@@ -0,0 +1,454 @@
+// Package config reads ferrule.toml, the configuration of a ferrule
+// project, so that the formatter, the linter and the language server agree
+// on its settings.
+//
+// The file is looked up in the directory of the sources and its parents.
+// It may hold:
+//
+//	# paths the tools skip, in .gitignore syntax, relative to the file
+//	ignore = ["build/", "*.gen.fe"]
+//
+//	[format]
+//	width = 100        # break bracketed lists overrunning 100 characters
+//	local_imports = ["acme"]  # group imports of acme.* with the project's
+//	newline = "crlf"   # end lines with \r\n rather than "lf", \n
+//	bom = "keep"       # keep a UTF-8 byte order mark rather than "strip" it
+//	final_newline = false  # leave a missing final line break missing
+//
+//	[generated]
+//	paths = ["proto/*.fe", "!proto/extra.fe"]  # generated whatever their header says, or not
+//	format = true      # format generated files too
+//	lint = true        # run the analyzers on generated files too
+//
+//	[codelens]
+//	tests = ["test*", "*Spec"]  # names of test functions, as in path.Match
+//	run = ["main", "demo*"]     # names of the functions run as programs
+//
+//	[lint]
+//	shadow = false     # turn an analyzer off
+//	unused = "error"   # or change the severity of its diagnostics
+//	spelling = true    # turn on an analyzer that is off by default
+//
+// A lint setting is a boolean, or one of the severities "error",
+// "warning", "info" and "hint", or "off". Optional analyzers run only
+// when their setting is true or a severity. Unknown tables and keys are
+// errors, so that misspellings do not go unnoticed.
+//
+// Generated files, those with a header saying so (see package generated)
+// or matched by the generated paths, in .gitignore syntax, are left as
+// they are by the formatter and only checked for syntax errors by the
+// linter, unless the generated table turns that off. A path a pattern
+// starting with ! matches is not generated, whatever its header says.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/newline"
+	"github.com/karol-broda/ferrule/bindings/go/codelens"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/generated"
+	"github.com/karol-broda/ferrule/bindings/go/internal/ignore"
+)
+
+// FileName is the name of the configuration file.
+const FileName = "ferrule.toml"
+
+// Config is the configuration of a project. The zero value is the
+// configuration of a project without a configuration file.
+type Config struct {
+	// Path is the file the configuration was read from, or "".
+	Path string
+	// Dir is the directory ignore patterns are relative to: that of the
+	// file.
+	Dir string
+	// Ignore are the patterns of the paths to skip.
+	Ignore []string
+	// Format holds the options of the formatter.
+	Format format.Options
+	// Lint holds the settings of analyzers, by name.
+	Lint map[string]Rule
+	// Generated holds the settings of generated files.
+	Generated Generated
+	// CodeLens holds the naming conventions of the functions the language
+	// server marks to run or test.
+	CodeLens codelens.Options
+
+	rules, generated *ignore.Rules
+}
+
+// Generated is how the tools treat generated files.
+type Generated struct {
+	// Paths are the patterns of the paths that are generated or, starting
+	// with !, are not.
+	Paths []string
+	// Format and Lint have generated files formatted and analyzed as
+	// other files are.
+	Format, Lint bool
+}
+
+// Rule is the setting of an analyzer.
+type Rule struct {
+	// Off turns the analyzer off.
+	Off bool
+	// On turns the analyzer on, even if it is optional.
+	On bool
+	// Severity, if not zero, replaces the severity of the diagnostics of
+	// the analyzer.
+	Severity ferrule.Severity
+}
+
+// Find returns the path of the configuration file that applies in dir:
+// the first found in dir and its parents. It returns "" if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		name := filepath.Join(dir, FileName)
+		info, err := os.Stat(name)
+		if err == nil && !info.IsDir() {
+			return name, nil
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// ForDir returns the configuration that applies in dir, or the zero
+// configuration if no file is found.
+func ForDir(dir string) (*Config, error) {
+	name, err := Find(dir)
+	if err != nil || name == "" {
+		return &Config{}, err
+	}
+	return Load(name)
+}
+
+// Load reads the configuration file name.
+func Load(name string) (*Config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	c.Path = name
+	if c.Dir, err = filepath.Abs(filepath.Dir(name)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Parse parses the contents of a configuration file. Dir and Path are left
+// empty.
+func Parse(data []byte) (*Config, error) {
+	doc, err := parseTOML(data)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	c := &Config{}
+	for key, v := range doc {
+		switch key {
+		case "ignore":
+			if c.Ignore, err = stringList(key, v); err != nil {
+				return nil, err
+			}
+		case "format":
+			if err := c.parseFormat(v); err != nil {
+				return nil, err
+			}
+		case "lint":
+			if err := c.parseLint(v); err != nil {
+				return nil, err
+			}
+		case "generated":
+			if err := c.parseGenerated(v); err != nil {
+				return nil, err
+			}
+		case "codelens":
+			if err := c.parseCodeLens(v); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("config: unknown setting %s", key)
+		}
+	}
+	c.rules = (*ignore.Rules)(nil).Add("", []byte(strings.Join(c.Ignore, "\n")))
+	c.generated = (*ignore.Rules)(nil).Add("", []byte(strings.Join(c.Generated.Paths, "\n")))
+	return c, nil
+}
+
+func (c *Config) parseFormat(v any) error {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("config: format must be a table")
+	}
+	for key, v := range table {
+		switch key {
+		case "width":
+			n, ok := v.(int64)
+			if !ok || n < 0 {
+				return fmt.Errorf("config: format.width must be a non-negative integer")
+			}
+			c.Format.Width = int(n)
+		case "local_imports":
+			list, err := stringList("format."+key, v)
+			if err != nil {
+				return err
+			}
+			c.Format.LocalImports = list
+		case "newline":
+			switch v {
+			case "lf":
+				c.Format.Newline = format.LF
+			case "crlf":
+				c.Format.Newline = format.CRLF
+			default:
+				return fmt.Errorf(`config: format.newline must be "lf" or "crlf"`)
+			}
+		case "bom":
+			switch v {
+			case "keep", "strip":
+				c.Format.KeepBOM = v == "keep"
+			default:
+				return fmt.Errorf(`config: format.bom must be "keep" or "strip"`)
+			}
+		case "final_newline":
+			b, ok := v.(bool)
+			if !ok {
+				return fmt.Errorf("config: format.final_newline must be a boolean")
+			}
+			c.Format.KeepFinalNewline = !b
+		default:
+			return fmt.Errorf("config: unknown setting format.%s", key)
+		}
+	}
+	return nil
+}
+
+func (c *Config) parseGenerated(v any) error {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("config: generated must be a table")
+	}
+	for key, v := range table {
+		switch key {
+		case "paths":
+			list, err := stringList("generated."+key, v)
+			if err != nil {
+				return err
+			}
+			c.Generated.Paths = list
+		case "format", "lint":
+			b, ok := v.(bool)
+			if !ok {
+				return fmt.Errorf("config: generated.%s must be a boolean", key)
+			}
+			if key == "format" {
+				c.Generated.Format = b
+			} else {
+				c.Generated.Lint = b
+			}
+		default:
+			return fmt.Errorf("config: unknown setting generated.%s", key)
+		}
+	}
+	return nil
+}
+
+func (c *Config) parseCodeLens(v any) error {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("config: codelens must be a table")
+	}
+	for key, v := range table {
+		switch key {
+		case "tests", "run":
+			list, err := stringList("codelens."+key, v)
+			if err != nil {
+				return err
+			}
+			for _, p := range list {
+				if _, err := path.Match(p, ""); err != nil {
+					return fmt.Errorf("config: codelens.%s: bad pattern %q", key, p)
+				}
+			}
+			if key == "tests" {
+				c.CodeLens.Tests = list
+			} else {
+				c.CodeLens.Run = list
+			}
+		default:
+			return fmt.Errorf("config: unknown setting codelens.%s", key)
+		}
+	}
+	return nil
+}
+
+var severities = map[string]ferrule.Severity{
+	"error":   ferrule.SeverityError,
+	"warning": ferrule.SeverityWarning,
+	"info":    ferrule.SeverityInformation,
+	"hint":    ferrule.SeverityHint,
+}
+
+func (c *Config) parseLint(v any) error {
+	table, ok := v.(map[string]any)
+	if !ok {
+		return fmt.Errorf("config: lint must be a table")
+	}
+	c.Lint = make(map[string]Rule, len(table))
+	for name, v := range table {
+		var r Rule
+		switch v := v.(type) {
+		case bool:
+			r.Off, r.On = !v, v
+		case string:
+			if v == "off" {
+				r.Off = true
+			} else if r.Severity = severities[v]; r.Severity == 0 {
+				return fmt.Errorf("config: lint.%s: unknown severity %q", name, v)
+			}
+			r.On = !r.Off
+		default:
+			return fmt.Errorf("config: lint.%s must be a boolean or a severity", name)
+		}
+		c.Lint[name] = r
+	}
+	return nil
+}
+
+// stringList returns v as a list of strings.
+func stringList(key string, v any) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("config: %s must be an array of strings", key)
+	}
+	out := make([]string, len(list))
+	for i, e := range list {
+		if out[i], ok = e.(string); !ok {
+			return nil, fmt.Errorf("config: %s must be an array of strings", key)
+		}
+	}
+	return out, nil
+}
+
+// Ignored reports whether the ignore patterns exclude path, a file or, if
+// isDir is set, a directory. A path below an excluded directory is
+// excluded too. Paths outside Dir are never excluded.
+func (c *Config) Ignored(path string, isDir bool) bool {
+	ignored, _ := c.match(c.rules, path, isDir)
+	return ignored
+}
+
+// IsGenerated reports whether the file at path, whose source is src, is
+// generated: whether the generated paths match it, if they mention it,
+// or else whether its header says so.
+func (c *Config) IsGenerated(path string, src []byte) bool {
+	if gen, matched := c.match(c.generated, path, false); matched {
+		return gen
+	}
+	return generated.Is(src)
+}
+
+// match matches path, relative to Dir, and the directories leading to it
+// against rules, as Ignored does. A directory the rules exclude decides
+// for the paths below it. Paths outside Dir match nothing.
+func (c *Config) match(rules *ignore.Rules, path string, isDir bool) (ignored, matched bool) {
+	if rules == nil {
+		return false, false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, false
+	}
+	rel, err := filepath.Rel(c.Dir, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		ig, m := rules.Match(strings.Join(parts[:i+1], "/"), !last || isDir)
+		if ig {
+			return true, true
+		}
+		matched = matched || m && last
+	}
+	return false, matched
+}
+
+// Analyzers returns the analyzers of all that the configuration leaves on,
+// optional ones only if it turns them on, in order, with the severities it
+// sets. newline.Analyzer is replaced by one checking the line endings the
+// format table asks for. It fails if the configuration names an analyzer
+// not in all.
+func (c *Config) Analyzers(all []*analysis.Analyzer) ([]*analysis.Analyzer, error) {
+	known := make(map[string]bool, len(all))
+	for _, a := range all {
+		known[a.Name] = true
+	}
+	var unknown []string
+	for name := range c.Lint {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		where := "config"
+		if c.Path != "" {
+			where = c.Path
+		}
+		return nil, fmt.Errorf("%s: unknown analyzers %s", where, strings.Join(unknown, ", "))
+	}
+	var out []*analysis.Analyzer
+	for _, a := range all {
+		r := c.Lint[a.Name]
+		if a == newline.Analyzer {
+			a = newline.For(c.Format)
+		}
+		switch {
+		case r.Off, a.Optional && !r.On:
+		case r.Severity != 0:
+			out = append(out, withSeverity(a, r.Severity))
+		default:
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+// withSeverity returns a copy of a whose diagnostics all have severity s.
+func withSeverity(a *analysis.Analyzer, s ferrule.Severity) *analysis.Analyzer {
+	cp := *a
+	cp.Severity = s
+	cp.Run = func(p *analysis.Pass) error {
+		report := p.Report
+		p.Report = func(d analysis.Diagnostic) {
+			d.Severity = s
+			report(d)
+		}
+		return a.Run(p)
+	}
+	return &cp
+}
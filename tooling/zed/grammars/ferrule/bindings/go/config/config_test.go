@@ -0,0 +1,204 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/newline"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/shadow"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unreachable"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/unused"
+	"github.com/karol-broda/ferrule/bindings/go/config"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+)
+
+const sample = `# project settings
+ignore = [
+  "build/",   # generated
+  '*.gen.fe',
+]
+
+[format]
+width = 100
+local_imports = ["acme"]
+newline = "crlf"
+bom = "keep"
+final_newline = false
+
+[generated]
+paths = ["proto/*.fe", "!proto/extra.fe"]
+lint = true
+
+[codelens]
+tests = ["test*", "*Spec"]
+run = []
+
+[lint]
+shadow = false
+unused = "error"
+"unreachable" = true
+`
+
+func TestParse(t *testing.T) {
+	c, err := config.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(c.Ignore, " ") != "build/ *.gen.fe" || c.Format.Width != 100 || strings.Join(c.Format.LocalImports, " ") != "acme" {
+		t.Errorf("Ignore = %q, Format = %+v", c.Ignore, c.Format)
+	}
+	if c.Format.Newline != format.CRLF || !c.Format.KeepBOM || !c.Format.KeepFinalNewline {
+		t.Errorf("Format = %+v", c.Format)
+	}
+	if strings.Join(c.Generated.Paths, " ") != "proto/*.fe !proto/extra.fe" || c.Generated.Format || !c.Generated.Lint {
+		t.Errorf("Generated = %+v", c.Generated)
+	}
+	if strings.Join(c.CodeLens.Tests, " ") != "test* *Spec" || c.CodeLens.Run == nil || len(c.CodeLens.Run) != 0 {
+		t.Errorf("CodeLens = %+v", c.CodeLens)
+	}
+	want := map[string]config.Rule{
+		"shadow":      {Off: true},
+		"unused":      {On: true, Severity: ferrule.SeverityError},
+		"unreachable": {On: true},
+	}
+	if len(c.Lint) != len(want) {
+		t.Errorf("Lint = %+v", c.Lint)
+	}
+	for name, r := range want {
+		if c.Lint[name] != r {
+			t.Errorf("Lint[%s] = %+v, want %+v", name, c.Lint[name], r)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"colour = 1\n":                   "unknown setting colour",
+		"[format]\nwidht = 80\n":         "unknown setting format.widht",
+		"[format]\nwidth = \"80\"\n":     "format.width must be",
+		"[format]\nlocal_imports = 1\n":  "format.local_imports must be",
+		"[format]\nnewline = \"cr\"\n":   "format.newline must be",
+		"[format]\nbom = true\n":         "format.bom must be",
+		"[lint]\nunused = \"fatal\"\n":   `unknown severity "fatal"`,
+		"[generated]\nformat = 1\n":      "generated.format must be a boolean",
+		"[generated]\npath = []\n":       "unknown setting generated.path",
+		"ignore = \"build\"\n":           "ignore must be an array",
+		"[codelens]\ntests = [\"[\"]\n":  `codelens.tests: bad pattern "["`,
+		"[codelens]\nbench = []\n":       "unknown setting codelens.bench",
+		"[format]\nwidth = 80 80\n":      "line 2: unexpected",
+		"[format]\n[format]\n":           "line 2: table [format] defined twice",
+		"ignore = [\"a\",\n\"b\"\nwidth": "line 3: expected ',' or ']'",
+		"x = \"unterminated\n":           "line 1: unterminated string",
+	}
+	for src, want := range tests {
+		_, err := config.Parse([]byte(src))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", src, err, want)
+		}
+	}
+}
+
+func TestForDir(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "src", "app")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, config.FileName), []byte(sample), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := config.ForDir(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Path != filepath.Join(root, config.FileName) || c.Format.Width != 100 {
+		t.Errorf("Path = %q, Format = %+v", c.Path, c.Format)
+	}
+	ignored := map[string]bool{
+		"src/app/main.fe":  false,
+		"build/out.fe":     true,
+		"src/build/out.fe": true,
+		"src/x.gen.fe":     true,
+		"build":            true,
+	}
+	for name, want := range ignored {
+		if got := c.Ignored(filepath.Join(root, filepath.FromSlash(name)), name == "build"); got != want {
+			t.Errorf("Ignored(%s) = %v, want %v", name, got, want)
+		}
+	}
+	if c.Ignored(filepath.Join(filepath.Dir(root), "build", "x.fe"), false) {
+		t.Error("path outside the project ignored")
+	}
+
+	header := []byte("// Code generated by protoc-gen-ferrule. DO NOT EDIT.\n")
+	generated := []struct {
+		name string
+		src  []byte
+		want bool
+	}{
+		{"proto/api.fe", nil, true},
+		{"proto/extra.fe", header, false},
+		{"src/app/main.fe", header, true},
+		{"src/app/main.fe", nil, false},
+	}
+	for _, tt := range generated {
+		if got := c.IsGenerated(filepath.Join(root, filepath.FromSlash(tt.name)), tt.src); got != tt.want {
+			t.Errorf("IsGenerated(%s, %q) = %v, want %v", tt.name, tt.src, got, tt.want)
+		}
+	}
+
+	c, err = config.ForDir(t.TempDir())
+	if err != nil || c.Path != "" || c.Ignored("build/x.fe", false) {
+		t.Errorf("without a file: %+v, %v", c, err)
+	}
+}
+
+func TestAnalyzers(t *testing.T) {
+	c, err := config.Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := []*analysis.Analyzer{newline.Analyzer, shadow.Analyzer, unreachable.Analyzer, unused.Analyzer}
+	got, err := c.Analyzers(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// newline follows format.newline = "crlf".
+	if len(got) != 3 || got[0] == newline.Analyzer || got[0].Name != "newline" || got[1] != unreachable.Analyzer || got[2].Name != "unused" {
+		t.Fatalf("Analyzers = %v", got)
+	}
+
+	tree, err := ferrule.Parse(context.Background(), []byte("function f() -> i32 { const x = 1; return 2; }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	diags, err := analysis.Run(tree, got...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 2 || diags[0].Severity != ferrule.SeverityError || diags[1].Message != "line ends with LF, not CRLF" {
+		t.Errorf("diagnostics = %v", diags)
+	}
+
+	if _, err := c.Analyzers(all[2:]); err == nil || !strings.Contains(err.Error(), "unknown analyzers shadow") {
+		t.Errorf("got %v, want an error naming shadow", err)
+	}
+
+	optional := &analysis.Analyzer{Name: "optional", Doc: "off by default", Optional: true, Run: shadow.Analyzer.Run}
+	if got, err := (&config.Config{}).Analyzers([]*analysis.Analyzer{optional}); err != nil || len(got) != 0 {
+		t.Errorf("without settings: Analyzers = %v, %v", got, err)
+	}
+	c, err = config.Parse([]byte("[lint]\noptional = \"hint\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Analyzers([]*analysis.Analyzer{optional}); err != nil || len(got) != 1 || got[0].Severity != ferrule.SeverityHint {
+		t.Errorf("turned on: Analyzers = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseTOML reads the subset of TOML that configuration files need: tables
+// and dotted table headers, bare and quoted keys, and values that are
+// basic or literal strings, decimal integers, booleans or arrays of those,
+// arrays spanning several lines included. Tables become nested maps.
+func parseTOML(data []byte) (map[string]any, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("file is not valid UTF-8")
+	}
+	d := &decoder{src: string(data), line: 1}
+	root := map[string]any{}
+	table := root
+	defined := map[string]bool{}
+	for {
+		d.skipBlank()
+		if d.eof() {
+			return root, nil
+		}
+		if d.peek() == '[' {
+			d.pos++
+			if d.peek() == '[' {
+				return nil, d.errorf("arrays of tables are not supported")
+			}
+			path, err := d.keyPath()
+			if err != nil {
+				return nil, err
+			}
+			d.spaces()
+			if !d.consume(']') {
+				return nil, d.errorf("expected ']' after table name")
+			}
+			name := strings.Join(path, ".")
+			if defined[name] {
+				return nil, d.errorf("table [%s] defined twice", name)
+			}
+			defined[name] = true
+			if table, err = d.descend(root, path); err != nil {
+				return nil, err
+			}
+		} else {
+			path, err := d.keyPath()
+			if err != nil {
+				return nil, err
+			}
+			d.spaces()
+			if !d.consume('=') {
+				return nil, d.errorf("expected '=' after key %s", strings.Join(path, "."))
+			}
+			d.spaces()
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			parent, err := d.descend(table, path[:len(path)-1])
+			if err != nil {
+				return nil, err
+			}
+			key := path[len(path)-1]
+			if _, ok := parent[key]; ok {
+				return nil, d.errorf("key %s set twice", strings.Join(path, "."))
+			}
+			parent[key] = v
+		}
+		if err := d.endLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type decoder struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", d.line, fmt.Sprintf(format, args...))
+}
+
+func (d *decoder) eof() bool { return d.pos >= len(d.src) }
+
+func (d *decoder) peek() byte {
+	if d.eof() {
+		return 0
+	}
+	return d.src[d.pos]
+}
+
+func (d *decoder) consume(c byte) bool {
+	if d.peek() == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// spaces skips spaces and tabs.
+func (d *decoder) spaces() {
+	for d.peek() == ' ' || d.peek() == '\t' {
+		d.pos++
+	}
+}
+
+// skipBlank skips whitespace, line breaks and comments.
+func (d *decoder) skipBlank() {
+	for !d.eof() {
+		switch c := d.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			d.pos++
+		case c == '\n':
+			d.pos++
+			d.line++
+		case c == '#':
+			for !d.eof() && d.peek() != '\n' {
+				d.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endLine checks that nothing but a comment follows on the line.
+func (d *decoder) endLine() error {
+	d.spaces()
+	if d.peek() == '#' {
+		for !d.eof() && d.peek() != '\n' {
+			d.pos++
+		}
+	}
+	d.consume('\r')
+	if !d.eof() && !d.consume('\n') {
+		return d.errorf("unexpected %q", d.rest())
+	}
+	d.line++
+	return nil
+}
+
+// rest returns the remainder of the current line, for error messages.
+func (d *decoder) rest() string {
+	end := strings.IndexByte(d.src[d.pos:], '\n')
+	if end < 0 {
+		return d.src[d.pos:]
+	}
+	return strings.TrimRight(d.src[d.pos:d.pos+end], "\r")
+}
+
+// descend returns the table at path below t, creating the missing ones.
+func (d *decoder) descend(t map[string]any, path []string) (map[string]any, error) {
+	for _, k := range path {
+		switch v := t[k].(type) {
+		case nil:
+			sub := map[string]any{}
+			t[k] = sub
+			t = sub
+		case map[string]any:
+			t = v
+		default:
+			return nil, d.errorf("%s is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+// keyPath reads a dotted key.
+func (d *decoder) keyPath() ([]string, error) {
+	var path []string
+	for {
+		d.spaces()
+		k, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, k)
+		d.spaces()
+		if !d.consume('.') {
+			return path, nil
+		}
+	}
+}
+
+// key reads a bare or quoted key.
+func (d *decoder) key() (string, error) {
+	switch d.peek() {
+	case '"':
+		return d.basicString()
+	case '\'':
+		return d.literalString()
+	}
+	start := d.pos
+	for c := d.peek(); c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'; c = d.peek() {
+		d.pos++
+	}
+	if d.pos == start {
+		return "", d.errorf("expected a key, found %q", d.rest())
+	}
+	return d.src[start:d.pos], nil
+}
+
+// value reads a value.
+func (d *decoder) value() (any, error) {
+	switch c := d.peek(); {
+	case c == '"':
+		if strings.HasPrefix(d.src[d.pos:], `"""`) {
+			return nil, d.errorf("multi-line strings are not supported")
+		}
+		return d.basicString()
+	case c == '\'':
+		return d.literalString()
+	case c == '[':
+		return d.array()
+	case strings.HasPrefix(d.src[d.pos:], "true"):
+		d.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(d.src[d.pos:], "false"):
+		d.pos += len("false")
+		return false, nil
+	case c == '+' || c == '-' || '0' <= c && c <= '9':
+		start := d.pos
+		d.pos++
+		for c := d.peek(); c == '_' || '0' <= c && c <= '9'; c = d.peek() {
+			d.pos++
+		}
+		n, err := strconv.ParseInt(strings.ReplaceAll(d.src[start:d.pos], "_", ""), 10, 64)
+		if err != nil {
+			return nil, d.errorf("invalid integer %q", d.src[start:d.pos])
+		}
+		return n, nil
+	}
+	return nil, d.errorf("expected a value, found %q", d.rest())
+}
+
+// array reads an array, which may span several lines.
+func (d *decoder) array() ([]any, error) {
+	d.pos++
+	out := []any{}
+	for {
+		d.skipBlank()
+		if d.consume(']') {
+			return out, nil
+		}
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		d.skipBlank()
+		if d.consume(']') {
+			return out, nil
+		}
+		if !d.consume(',') {
+			return nil, d.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// basicString reads a string in double quotes.
+func (d *decoder) basicString() (string, error) {
+	d.pos++
+	var b strings.Builder
+	for {
+		if d.eof() || d.peek() == '\n' {
+			return "", d.errorf("unterminated string")
+		}
+		c := d.src[d.pos]
+		d.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if d.eof() {
+				return "", d.errorf("unterminated string")
+			}
+			e := d.src[d.pos]
+			d.pos++
+			switch e {
+			case 'b':
+				b.WriteByte('\b')
+			case 't':
+				b.WriteByte('\t')
+			case 'n':
+				b.WriteByte('\n')
+			case 'f':
+				b.WriteByte('\f')
+			case 'r':
+				b.WriteByte('\r')
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if d.pos+n > len(d.src) {
+					return "", d.errorf("invalid escape")
+				}
+				r, err := strconv.ParseUint(d.src[d.pos:d.pos+n], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", d.errorf("invalid escape")
+				}
+				b.WriteRune(rune(r))
+				d.pos += n
+			default:
+				return "", d.errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// literalString reads a string in single quotes, which has no escapes.
+func (d *decoder) literalString() (string, error) {
+	d.pos++
+	end := strings.IndexAny(d.src[d.pos:], "'\n")
+	if end < 0 || d.src[d.pos+end] != '\'' {
+		return "", d.errorf("unterminated string")
+	}
+	s := d.src[d.pos : d.pos+end]
+	d.pos += end + 1
+	return s, nil
+}
@@ -0,0 +1,265 @@
+// Package deps extracts the package import graph of a ferrule project and
+// finds the cycles in it.
+//
+// The graph is built from a project index: every package declared by an
+// indexed file is a node, and an import declaration in any of its files is
+// an edge to the imported package. Imported packages that no indexed file
+// declares appear as external nodes. Files without a package declaration
+// belong to no node and their imports are ignored.
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+// Graph is the import graph of a project.
+type Graph struct {
+	// Packages lists every node, sorted by name.
+	Packages []*Package
+	// Edges lists every import edge, sorted by importer and then by
+	// imported package.
+	Edges []*Edge
+
+	byName map[string]*Package
+	out    map[string][]*Edge
+}
+
+// Package is a node of the graph.
+type Package struct {
+	Name string `json:"name"`
+	// Files lists the files declaring the package, sorted.
+	Files []string `json:"files,omitempty"`
+	// External is set for packages that are imported but not declared in
+	// the project.
+	External bool `json:"external,omitempty"`
+}
+
+// Edge is an import of package To by package From.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Sites are the import declarations giving rise to the edge.
+	Sites []index.Location `json:"-"`
+}
+
+// Build returns the import graph of the files in idx.
+func Build(idx *index.Index) *Graph {
+	g := &Graph{byName: make(map[string]*Package), out: make(map[string][]*Edge)}
+	node := func(name string) *Package {
+		p := g.byName[name]
+		if p == nil {
+			p = &Package{Name: name, External: true}
+			g.byName[name] = p
+			g.Packages = append(g.Packages, p)
+		}
+		return p
+	}
+
+	files := idx.Files()
+	for _, f := range files {
+		if f.Package != "" {
+			p := node(f.Package)
+			p.External = false
+			p.Files = append(p.Files, f.Path)
+		}
+	}
+	edges := make(map[[2]string]*Edge)
+	for _, f := range files {
+		if f.Package == "" {
+			continue
+		}
+		for _, imp := range f.Imports {
+			node(imp.Path)
+			key := [2]string{f.Package, imp.Path}
+			e := edges[key]
+			if e == nil {
+				e = &Edge{From: f.Package, To: imp.Path}
+				edges[key] = e
+				g.Edges = append(g.Edges, e)
+			}
+			e.Sites = append(e.Sites, index.Location{Path: f.Path, Range: imp.Range})
+		}
+	}
+
+	sort.Slice(g.Packages, func(i, j int) bool { return g.Packages[i].Name < g.Packages[j].Name })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	for _, e := range g.Edges {
+		g.out[e.From] = append(g.out[e.From], e)
+	}
+	return g
+}
+
+// Package returns the node named name, or nil.
+func (g *Graph) Package(name string) *Package { return g.byName[name] }
+
+// Imports returns the edges leaving the package name, sorted by imported
+// package.
+func (g *Graph) Imports(name string) []*Edge { return g.out[name] }
+
+// Cycles returns one import cycle for every group of packages that import
+// each other, directly or not. A cycle is given as the list of packages
+// along it, starting and ending with the alphabetically first member of
+// the group, and following the shortest way around. A package importing
+// itself forms a cycle of its own. Cycles are sorted by their first
+// package.
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, scc := range g.components() {
+		inside := make(map[string]bool, len(scc))
+		for _, name := range scc {
+			inside[name] = true
+		}
+		start := scc[0]
+		if len(scc) == 1 && !g.importsItself(start) {
+			continue
+		}
+		cycles = append(cycles, g.shortestCycle(start, inside))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+func (g *Graph) importsItself(name string) bool {
+	for _, e := range g.out[name] {
+		if e.To == name {
+			return true
+		}
+	}
+	return false
+}
+
+// shortestCycle finds the shortest path from start back to itself that
+// stays inside the component.
+func (g *Graph) shortestCycle(start string, inside map[string]bool) []string {
+	prev := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range g.out[cur] {
+			if !inside[e.To] {
+				continue
+			}
+			if e.To == start {
+				path := []string{start}
+				for n := cur; n != start; n = prev[n] {
+					path = append(path, n)
+				}
+				path = append(path, start)
+				for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return path
+			}
+			if _, seen := prev[e.To]; !seen {
+				prev[e.To] = cur
+				queue = append(queue, e.To)
+			}
+		}
+	}
+	return nil
+}
+
+// components returns the strongly connected components of the graph with
+// Tarjan's algorithm, each sorted by name.
+func (g *Graph) components() [][]string {
+	indexOf := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var out [][]string
+	var visit func(string)
+	visit = func(v string) {
+		indexOf[v] = len(indexOf)
+		low[v] = indexOf[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, e := range g.out[v] {
+			if _, seen := indexOf[e.To]; !seen {
+				visit(e.To)
+				low[v] = min(low[v], low[e.To])
+			} else if onStack[e.To] {
+				low[v] = min(low[v], indexOf[e.To])
+			}
+		}
+		if low[v] == indexOf[v] {
+			var scc []string
+			for {
+				n := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[n] = false
+				scc = append(scc, n)
+				if n == v {
+					break
+				}
+			}
+			sort.Strings(scc)
+			out = append(out, scc)
+		}
+	}
+	for _, p := range g.Packages {
+		if _, seen := indexOf[p.Name]; !seen {
+			visit(p.Name)
+		}
+	}
+	return out
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. External
+// packages are drawn dashed and the edges of import cycles in red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	inCycle := make(map[[2]string]bool)
+	for _, c := range g.Cycles() {
+		for i := 0; i+1 < len(c); i++ {
+			inCycle[[2]string{c[i], c[i+1]}] = true
+		}
+	}
+	var b strings.Builder
+	b.WriteString("digraph imports {\n\tnode [shape=box];\n")
+	for _, p := range g.Packages {
+		fmt.Fprintf(&b, "\t%s", strconv.Quote(p.Name))
+		if p.External {
+			b.WriteString(" [style=dashed]")
+		}
+		b.WriteString(";\n")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s", strconv.Quote(e.From), strconv.Quote(e.To))
+		if inCycle[[2]string{e.From, e.To}] {
+			b.WriteString(" [color=red]")
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the packages, edges and cycles of the graph as a JSON
+// object.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	cycles := g.Cycles()
+	if cycles == nil {
+		cycles = [][]string{}
+	}
+	return enc.Encode(struct {
+		Packages []*Package `json:"packages"`
+		Edges    []*Edge    `json:"edges"`
+		Cycles   [][]string `json:"cycles"`
+	}{g.Packages, g.Edges, cycles})
+}
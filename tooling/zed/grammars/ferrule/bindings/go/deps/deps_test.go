@@ -0,0 +1,97 @@
+package deps_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/deps"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+func build(t *testing.T, files map[string]string) *deps.Graph {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deps.Build(idx)
+}
+
+func TestCycles(t *testing.T) {
+	g := build(t, map[string]string{
+		"a.fe":  "package a;\nimport b;\nimport std.io;\n",
+		"b.fe":  "package b;\nimport c;\n",
+		"b2.fe": "package b;\nimport c;\nimport d;\n",
+		"c.fe":  "package c;\nimport a;\n",
+		"d.fe":  "package d;\n",
+		"e.fe":  "package e;\nimport e;\n",
+		"f.fe":  "function main() -> Unit {}\n",
+	})
+
+	var names []string
+	for _, p := range g.Packages {
+		names = append(names, p.Name)
+	}
+	if want := []string{"a", "b", "c", "d", "e", "std.io"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("packages %v, want %v", names, want)
+	}
+	if p := g.Package("std.io"); p == nil || !p.External {
+		t.Errorf("std.io is not external: %+v", p)
+	}
+	if p := g.Package("b"); !reflect.DeepEqual(p.Files, []string{"b.fe", "b2.fe"}) {
+		t.Errorf("files of b: %v", p.Files)
+	}
+	if e := g.Imports("b"); len(e) != 2 || e[0].To != "c" || len(e[0].Sites) != 2 {
+		t.Errorf("imports of b: %+v", e)
+	}
+
+	want := [][]string{{"a", "b", "c", "a"}, {"e", "e"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles %v, want %v", got, want)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	g := build(t, map[string]string{
+		"a.fe": "package a;\nimport b;\n",
+		"b.fe": "package b;\nimport a;\nimport ext;\n",
+	})
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"ext" [style=dashed];`, `"a" -> "b" [color=red];`, `"b" -> "ext";`} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT lacks %q:\n%s", want, dot.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Packages []struct{ Name string }
+		Edges    []struct{ From, To string }
+		Cycles   [][]string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Packages) != 3 || len(got.Edges) != 3 || !reflect.DeepEqual(got.Cycles, [][]string{{"a", "b", "a"}}) {
+		t.Errorf("JSON output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,191 @@
+// Package directive recognizes the directives tools read from line
+// comments:
+//
+//	// ferrule:generate ferrule-nodegen -o nodes.fe
+//	// ferrule:disable shadow kept for the debugger
+//	// ferrule:deprecated use addAll instead
+//
+// A directive is a line comment whose text starts with Prefix and a name
+// of letters, digits and hyphens, ended by white space or the end of the
+// comment. The rest of the comment holds its arguments, which each
+// directive reads as it likes; Fields splits them as a command line.
+//
+// The directives known so far are ferrule:disable and its older spelling
+// ferrule:ignore, which turn analyzers off (see analysis.Suppression);
+// ferrule:deprecated, which marks the declaration it documents as
+// deprecated (see doc.Comment); ferrule:tags, which labels the test it
+// documents for runners to select; and ferrule:generate, reserved for the
+// commands a build runs to generate code.
+package directive
+
+import (
+	"fmt"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/literal"
+)
+
+// Prefix starts every directive.
+const Prefix = "ferrule:"
+
+// The names of the directives tools know.
+const (
+	Disable    = "disable"
+	Ignore     = "ignore"
+	Deprecated = "deprecated"
+	Generate   = "generate"
+	Tags       = "tags"
+)
+
+// Directive is a directive in a comment of a tree.
+type Directive struct {
+	// Comment is the comment holding the directive.
+	Comment *tree_sitter.Node
+	// Name is the name of the directive, without Prefix.
+	Name string
+	// Args is the text after the name, without surrounding white space.
+	Args string
+	// Range covers the directive, from Prefix to the end of the comment,
+	// and ArgsRange its arguments.
+	Range, ArgsRange tree_sitter.Range
+	// Trailing reports that the comment follows code on its line, rather
+	// than standing on a line of its own.
+	Trailing bool
+}
+
+// Parse parses the text of a line comment, with or without its leading
+// slashes, and reports whether it is a directive.
+func Parse(comment string) (name, args string, ok bool) {
+	name, args, _, ok = parse(comment)
+	return name, args, ok
+}
+
+// parse is Parse, also returning the offset of Prefix in comment.
+func parse(comment string) (name, args string, at int, ok bool) {
+	text := strings.TrimLeft(strings.TrimPrefix(comment, "//"), " \t")
+	at = len(comment) - len(text)
+	text = strings.TrimRight(text, " \t\r")
+	rest, ok := strings.CutPrefix(text, Prefix)
+	if !ok {
+		return "", "", 0, false
+	}
+	end := 0
+	for end < len(rest) && nameByte(rest[end]) {
+		end++
+	}
+	if end == 0 || end < len(rest) && rest[end] != ' ' && rest[end] != '\t' {
+		return "", "", 0, false
+	}
+	return rest[:end], strings.TrimSpace(rest[end:]), at, true
+}
+
+func nameByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-'
+}
+
+// All returns the directives in the comments of tree, in source order. The
+// nodes belong to tree.
+func All(tree *ferrule.Tree) []*Directive {
+	src := tree.Source()
+	var out []*Directive
+	cursor := tree.RootNode().Walk()
+	defer cursor.Close()
+	for {
+		if n := cursor.Node(); n.Kind() == kind.LineComment {
+			if d := at(n, src); d != nil {
+				out = append(out, d)
+			}
+		} else if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return out
+			}
+		}
+	}
+}
+
+// Named returns the directives of tree named name, in source order.
+func Named(tree *ferrule.Tree, name string) []*Directive {
+	var out []*Directive
+	for _, d := range All(tree) {
+		if d.Name == name {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// at returns the directive in the line comment n, or nil.
+func at(n *tree_sitter.Node, src []byte) *Directive {
+	text := n.Utf8Text(src)
+	name, args, off, ok := parse(text)
+	if !ok {
+		return nil
+	}
+	// a comment ending a line of a file with \r\n line breaks holds the \r.
+	end := len(strings.TrimRight(text, " \t\r"))
+	span := func(from, to int) tree_sitter.Range {
+		p, q := n.StartPosition(), n.StartPosition()
+		p.Column += uint(from)
+		q.Column += uint(to)
+		return tree_sitter.Range{StartByte: n.StartByte() + uint(from), EndByte: n.StartByte() + uint(to), StartPoint: p, EndPoint: q}
+	}
+	lineStart := n.StartByte() - n.StartPosition().Column
+	return &Directive{
+		Comment:   n,
+		Name:      name,
+		Args:      args,
+		Range:     span(off, end),
+		ArgsRange: span(end-len(args), end),
+		Trailing:  strings.TrimSpace(string(src[lineStart:n.StartByte()])) != "",
+	}
+}
+
+// Fields splits the arguments of d at white space. An argument in double
+// quotes may hold white space and the escape sequences of a string
+// literal.
+func (d *Directive) Fields() ([]string, error) {
+	return Fields(d.Args)
+}
+
+// Fields splits args as Directive.Fields does.
+func Fields(args string) ([]string, error) {
+	var out []string
+	for {
+		args = strings.TrimLeft(args, " \t")
+		if args == "" {
+			return out, nil
+		}
+		if args[0] != '"' {
+			end := strings.IndexAny(args, " \t")
+			if end < 0 {
+				end = len(args)
+			}
+			out = append(out, args[:end])
+			args = args[end:]
+			continue
+		}
+		end := 1
+		for end < len(args) && args[end] != '"' {
+			if args[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(args) {
+			return nil, fmt.Errorf("directive: unterminated quoted argument %s", args)
+		}
+		s, err := literal.Unquote(args[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("directive: argument %s: %w", args[:end+1], err)
+		}
+		out = append(out, s)
+		args = args[end+1:]
+	}
+}
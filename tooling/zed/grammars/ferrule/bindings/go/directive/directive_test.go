@@ -0,0 +1,81 @@
+package directive_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/directive"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestAll(t *testing.T) {
+	src := "// ferrule:generate ferrule-nodegen -o \"out dir/nodes.fe\"\n" +
+		"const a = 1; //ferrule:disable unused\r\n" +
+		"// ferrule:disabled not a directive\n" +
+		"// see ferrule:deprecated\n" +
+		"// ferrule:deprecated\n" +
+		"function f() -> Unit {}\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	ds := directive.All(tree)
+	var got []string
+	for _, d := range ds {
+		got = append(got, d.Name+"|"+d.Args+"|"+src[d.ArgsRange.StartByte:d.ArgsRange.EndByte])
+	}
+	want := []string{
+		`generate|ferrule-nodegen -o "out dir/nodes.fe"|ferrule-nodegen -o "out dir/nodes.fe"`,
+		"disable|unused|unused",
+		"disabled|not a directive|not a directive",
+		"deprecated||",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("directives %q, want %q", got, want)
+	}
+	if ds[0].Trailing || !ds[1].Trailing {
+		t.Errorf("trailing %v, %v", ds[0].Trailing, ds[1].Trailing)
+	}
+	if r := ds[1].Range; r.StartPoint.Row != 1 || r.StartPoint.Column != 15 || src[r.StartByte:r.EndByte] != "ferrule:disable unused" {
+		t.Errorf("range %+v", r)
+	}
+	fields, err := ds[0].Fields()
+	if err != nil || !reflect.DeepEqual(fields, []string{"ferrule-nodegen", "-o", "out dir/nodes.fe"}) {
+		t.Errorf("fields %q, %v", fields, err)
+	}
+	if n := len(directive.Named(tree, directive.Deprecated)); n != 1 {
+		t.Errorf("%d deprecated directives", n)
+	}
+}
+
+func TestFields(t *testing.T) {
+	if got, err := directive.Fields(`a "b\tc" "d\""`); err != nil || !reflect.DeepEqual(got, []string{"a", "b\tc", `d"`}) {
+		t.Errorf("Fields = %q, %v", got, err)
+	}
+	for _, args := range []string{`"open`, `"bad\q"`} {
+		if _, err := directive.Fields(args); err == nil {
+			t.Errorf("Fields(%q) succeeded", args)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		comment, name, args string
+		ok                  bool
+	}{
+		{"// ferrule:ignore shadow", "ignore", "shadow", true},
+		{"ferrule:deprecated", "deprecated", "", true},
+		{"// ferrule:", "", "", false},
+		{"// ferrule:x=y", "", "", false},
+		{"// note ferrule:ignore", "", "", false},
+	} {
+		name, args, ok := directive.Parse(tt.comment)
+		if name != tt.name || args != tt.args || ok != tt.ok {
+			t.Errorf("Parse(%q) = %q, %q, %v", tt.comment, name, args, ok)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Package doc extracts the documentation of ferrule code: the doc comments
+// of declarations, and the API of a package built from them.
+//
+// A doc comment is a run of line comments, or a block comment, that ends on
+// the line just before a declaration, with no blank line in between:
+//
+//	// Add returns the sum of x and y.
+//	//
+//	// @param x the first operand
+//	// @param y the second operand
+//	// @returns x + y
+//	//
+//	// ```
+//	// const three = add(1, 2);
+//	// ```
+//	function add(x: i32, y: i32) -> i32 { return x + y; }
+//
+// The comment of the package declaration documents the package. Within a
+// comment, lines starting with @param name or @returns begin tags, which
+// extend to the next tag or blank line, and fenced code blocks are
+// examples. Lines holding directives, such as ferrule:disable, are left
+// out; one of ferrule:deprecated marks the declaration as deprecated, and
+// those of ferrule:tags label it for test runners. The remaining text is
+// the comment's prose.
+//
+// Examples returns the examples of a file with their place in it, so that
+// tools can check that they still parse and are formatted; the examples
+// and examplefmt analyzers of ferrule-lint do.
+package doc
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/directive"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Comment is a parsed doc comment.
+type Comment struct {
+	// Text is the prose of the comment, without its tags, examples and
+	// directives.
+	Text     string
+	Params   []Param
+	Returns  string
+	Examples []string
+	// Deprecated reports that the comment holds a ferrule:deprecated
+	// directive, and DeprecatedNote is the text after it, which tells what
+	// to use instead.
+	Deprecated     bool
+	DeprecatedNote string
+	// Tags are the arguments of the ferrule:tags directives of the
+	// comment, in order, such as "slow" and "network" for
+	//
+	//	// ferrule:tags slow network
+	Tags []string
+}
+
+// Param documents a parameter.
+type Param struct {
+	Name string
+	Text string
+}
+
+// IsZero reports whether the comment documents nothing.
+func (c Comment) IsZero() bool {
+	return c.Text == "" && len(c.Params) == 0 && c.Returns == "" && len(c.Examples) == 0 && !c.Deprecated && len(c.Tags) == 0
+}
+
+// Decl is a documented declaration.
+type Decl struct {
+	Name string
+	Kind symbols.Kind
+	// Signature is the declaration without its body, as returned by
+	// Signature.
+	Signature string
+	// Public reports whether the declaration is marked pub.
+	Public bool
+	Doc    Comment
+	Range  tree_sitter.Range
+	// Members are the declarations of a component.
+	Members []Decl
+}
+
+// Package is the documentation of a package.
+type Package struct {
+	// Path is the path of the package declaration.
+	Path string
+	Doc  Comment
+	// Files are the names of the files documented, if known.
+	Files []string
+	Decls []Decl
+}
+
+// Extract returns the documentation of the file parsed as tree.
+func Extract(tree *ferrule.Tree) *Package {
+	src := tree.Source()
+	root := tree.RootNode()
+	p := &Package{}
+	var decls func(syms []symbols.Symbol) []Decl
+	decls = func(syms []symbols.Symbol) []Decl {
+		var out []Decl
+		for _, s := range syms {
+			n := declAt(root, s.Range)
+			if n == nil {
+				continue
+			}
+			if n.Kind() == kind.PackageDeclaration {
+				if p.Path == "" {
+					p.Path = s.Name
+					p.Doc = Parse(Text(n, src))
+				}
+				continue
+			}
+			first := n.Child(0)
+			out = append(out, Decl{
+				Name:      s.Name,
+				Kind:      s.Kind,
+				Signature: Signature(n, src),
+				Public:    first != nil && first.Kind() == kind.KeywordPub,
+				Doc:       Parse(Text(n, src)),
+				Range:     s.Range,
+				Members:   decls(s.Children),
+			})
+		}
+		return out
+	}
+	p.Decls = decls(symbols.Outline(tree))
+	return p
+}
+
+// Add adds the declarations of q, another file of the package, to p. The
+// package comment of q is used if p has none.
+func (p *Package) Add(q *Package) {
+	if p.Path == "" {
+		p.Path = q.Path
+	}
+	if p.Doc.IsZero() {
+		p.Doc = q.Doc
+	}
+	p.Files = append(p.Files, q.Files...)
+	p.Decls = append(p.Decls, q.Decls...)
+}
+
+// Exported returns a copy of p with only the declarations marked pub.
+func (p *Package) Exported() *Package {
+	var public func(ds []Decl) []Decl
+	public = func(ds []Decl) []Decl {
+		var out []Decl
+		for _, d := range ds {
+			if d.Public {
+				d.Members = public(d.Members)
+				out = append(out, d)
+			}
+		}
+		return out
+	}
+	q := *p
+	q.Decls = public(p.Decls)
+	return &q
+}
+
+// declAt returns the declaration below n that covers exactly r.
+func declAt(n *tree_sitter.Node, r tree_sitter.Range) *tree_sitter.Node {
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		c := n.NamedChild(i)
+		switch {
+		case c.StartByte() == r.StartByte && c.EndByte() == r.EndByte:
+			return c
+		case c.StartByte() <= r.StartByte && r.EndByte <= c.EndByte():
+			return declAt(c, r)
+		}
+	}
+	return nil
+}
+
+// Text returns the doc comment of the declaration decl with the comment
+// markers removed, or "" if it has none.
+func Text(decl *tree_sitter.Node, src []byte) string {
+	var lines []string
+	for _, l := range commentLines(decl, src) {
+		lines = append(lines, l.text)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// comments returns the comments making up the doc comment of decl, in
+// source order.
+func comments(decl *tree_sitter.Node) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	line := decl.StartPosition().Row
+	for c := decl.PrevSibling(); c != nil; c = c.PrevSibling() {
+		if k := c.Kind(); k != kind.LineComment && k != kind.BlockComment || c.EndPosition().Row+1 != line {
+			break
+		}
+		// A comment after code on the same line belongs to that code.
+		if p := c.PrevSibling(); p != nil && p.EndPosition().Row == c.StartPosition().Row {
+			break
+		}
+		out = append(out, c)
+		line = c.StartPosition().Row
+		if c.Kind() == kind.BlockComment {
+			break
+		}
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// line is a line of a doc comment without its comment markers, with the
+// offset and position in the file of its first byte.
+type line struct {
+	text string
+	off  uint
+	pos  tree_sitter.Point
+}
+
+// commentLines returns the lines of the doc comment of decl.
+func commentLines(decl *tree_sitter.Node, src []byte) []line {
+	var out []line
+	for _, c := range comments(decl) {
+		text, off, pos := c.Utf8Text(src), c.StartByte(), c.StartPosition()
+		if c.Kind() == kind.BlockComment {
+			out = append(out, blockLines(text, off, pos)...)
+			continue
+		}
+		rest := strings.TrimPrefix(strings.TrimLeft(strings.TrimPrefix(text, "//"), "/"), " ")
+		skip := uint(len(text) - len(rest))
+		out = append(out, line{text: rest, off: off + skip, pos: tree_sitter.Point{Row: pos.Row, Column: pos.Column + skip}})
+	}
+	return out
+}
+
+// blockLines returns the lines of a block comment, starting at off and pos,
+// without its markers and the asterisks that may start its lines.
+func blockLines(text string, off uint, pos tree_sitter.Point) []line {
+	body := strings.TrimLeft(strings.TrimPrefix(text, "/*"), "*")
+	off += uint(len(text) - len(body))
+	col := pos.Column + uint(len(text)-len(body))
+	var out []line
+	for i, l := range strings.Split(strings.TrimSuffix(body, "*/"), "\n") {
+		next := off + uint(len(l)) + 1
+		l = strings.TrimRight(l, " \t")
+		skip := 0
+		if t := strings.TrimLeft(l, " \t"); strings.HasPrefix(t, "*") {
+			skip = len(l) - len(t) + 1
+			if strings.HasPrefix(l[skip:], " ") {
+				skip++
+			}
+		} else if i == 0 && strings.HasPrefix(l, " ") {
+			skip = 1
+		}
+		out = append(out, line{text: l[skip:], off: off + uint(skip), pos: tree_sitter.Point{Row: pos.Row + uint(i), Column: col + uint(skip)}})
+		off, col = next, 0
+	}
+	return out
+}
+
+const (
+	none    = -2
+	returns = -1
+)
+
+// Parse parses the text of a doc comment, as returned by Text.
+func Parse(text string) Comment {
+	var c Comment
+	var prose []string
+	tag := none // the tag being continued: a parameter index or returns
+	var example []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			if fenced {
+				c.Examples = append(c.Examples, dedent(example))
+				example = nil
+			}
+			fenced, tag = !fenced, none
+		case fenced:
+			example = append(example, line)
+		case isDirective(trimmed):
+			switch name, args, _ := directive.Parse(trimmed); name {
+			case directive.Deprecated:
+				c.Deprecated, c.DeprecatedNote = true, args
+			case directive.Tags:
+				// a malformed argument list adds no tags.
+				tags, _ := directive.Fields(args)
+				c.Tags = append(c.Tags, tags...)
+			}
+			tag = none
+		case strings.HasPrefix(trimmed, "@param "):
+			name, rest, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(trimmed, "@param ")), " ")
+			c.Params = append(c.Params, Param{Name: name, Text: strings.TrimSpace(rest)})
+			tag = len(c.Params) - 1
+		case trimmed == "@returns" || strings.HasPrefix(trimmed, "@returns "):
+			c.Returns = strings.TrimSpace(strings.TrimPrefix(trimmed, "@returns"))
+			tag = returns
+		case trimmed == "":
+			tag = none
+			prose = append(prose, "")
+		case tag == returns:
+			c.Returns = strings.TrimSpace(c.Returns + " " + trimmed)
+		case tag != none:
+			c.Params[tag].Text = strings.TrimSpace(c.Params[tag].Text + " " + trimmed)
+		default:
+			prose = append(prose, line)
+		}
+	}
+	if fenced && len(example) > 0 {
+		c.Examples = append(c.Examples, dedent(example))
+	}
+	c.Text = squeezeBlank(prose)
+	return c
+}
+
+func isDirective(line string) bool {
+	_, _, ok := directive.Parse(line)
+	return ok
+}
+
+// squeezeBlank joins lines, collapsing runs of blank lines and dropping
+// those at either end.
+func squeezeBlank(lines []string) string {
+	var out []string
+	for _, l := range lines {
+		if l == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// dedent removes the indentation shared by the non-blank lines.
+func dedent(lines []string) string {
+	prefix := indentation(lines)
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentation returns the indentation shared by the non-blank lines.
+func indentation(lines []string) string {
+	prefix, first := "", true
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		if first {
+			prefix, first = indent, false
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// Signature returns the source of the declaration decl without the parts
+// that are not part of its interface: the body of a function, squeezed
+// onto one line, and the members of a component. Other declarations are
+// returned whole, with the indentation of their first line removed from
+// the others.
+func Signature(decl *tree_sitter.Node, src []byte) string {
+	switch decl.Kind() {
+	case kind.FunctionDeclaration:
+		if body := decl.ChildByFieldName(field.Body); body != nil {
+			return strings.Join(strings.Fields(string(src[decl.StartByte():body.StartByte()])), " ")
+		}
+	case kind.ComponentDeclaration:
+		for i := uint(0); i < decl.ChildCount(); i++ {
+			if c := decl.Child(i); c.Kind() == "{" {
+				return strings.Join(strings.Fields(string(src[decl.StartByte():c.StartByte()])), " ")
+			}
+		}
+	}
+	lines := strings.Split(decl.Utf8Text(src), "\n")
+	indent := src[decl.StartByte()-uint(decl.StartPosition().Column) : decl.StartByte()]
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], string(indent))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,237 @@
+package doc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+const source = `// Package geometry computes with points.
+package app.geometry;
+
+const origin = 0; // not a doc comment
+// Add returns the sum of x and y.
+//
+// @param x the first
+//   operand
+// @param y the second operand
+// @returns x + y
+//
+// ` + "```" + `
+//   const three = add(1, 2);
+// ` + "```" + `
+pub function add(x: i32, y: i32) -> i32 {
+  return x + y;
+}
+
+// Detached.
+
+function helper() -> i32 { return 0; }
+
+/**
+ * A point in the plane.
+ */
+pub type Point = {
+  x: f64,
+  y: f64
+};
+
+pub component Shapes {
+  /* Area of nothing. */
+  pub function area() -> f64 { return 0.0; }
+  function secret() -> f64 { return 1.0; }
+}
+`
+
+func extract(t *testing.T) *doc.Package {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	return doc.Extract(tree)
+}
+
+func TestExtract(t *testing.T) {
+	p := extract(t)
+	if p.Path != "app.geometry" || p.Doc.Text != "Package geometry computes with points." {
+		t.Errorf("package %q, doc %q", p.Path, p.Doc.Text)
+	}
+	var got []string
+	for _, d := range p.Decls {
+		got = append(got, d.Kind.String()+" "+d.Name+": "+d.Doc.Text)
+	}
+	want := []string{
+		"constant origin: ",
+		"function add: Add returns the sum of x and y.",
+		"function helper: ",
+		"struct Point: A point in the plane.",
+		"module Shapes: ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	add := p.Decls[1].Doc
+	if len(add.Params) != 2 || add.Params[0] != (doc.Param{Name: "x", Text: "the first operand"}) || add.Params[1].Name != "y" {
+		t.Errorf("params %+v", add.Params)
+	}
+	if add.Returns != "x + y" {
+		t.Errorf("returns %q", add.Returns)
+	}
+	if len(add.Examples) != 1 || add.Examples[0] != "const three = add(1, 2);" {
+		t.Errorf("examples %q", add.Examples)
+	}
+	if got, want := p.Decls[1].Signature, "pub function add(x: i32, y: i32) -> i32"; got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	if got, want := p.Decls[3].Signature, "pub type Point = {\n  x: f64,\n  y: f64\n};"; got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+	shapes := p.Decls[4]
+	if shapes.Signature != "pub component Shapes" || len(shapes.Members) != 2 || shapes.Members[0].Doc.Text != "Area of nothing." {
+		t.Errorf("component %+v", shapes)
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	c := doc.Parse("Scale scales v.\nferrule:deprecated use resize instead\nferrule:disable unused")
+	if c.Text != "Scale scales v." || !c.Deprecated || c.DeprecatedNote != "use resize instead" {
+		t.Errorf("comment %+v", c)
+	}
+	if got, want := c.Markdown(), "Deprecated: use resize instead\n\nScale scales v.\n"; got != want {
+		t.Errorf("markdown %q, want %q", got, want)
+	}
+}
+
+func TestTags(t *testing.T) {
+	c := doc.Parse("testFetch fetches a page.\nferrule:tags slow network\nferrule:tags \"needs db\"")
+	if c.Text != "testFetch fetches a page." || strings.Join(c.Tags, "|") != "slow|network|needs db" {
+		t.Errorf("comment %+v", c)
+	}
+	if c := doc.Parse("ferrule:tags \"open"); c.Tags != nil || !c.IsZero() {
+		t.Errorf("comment with a malformed tag %+v", c)
+	}
+}
+
+func TestExported(t *testing.T) {
+	p := extract(t).Exported()
+	var got []string
+	for _, d := range p.Decls {
+		got = append(got, d.Name)
+		for _, m := range d.Members {
+			got = append(got, d.Name+"."+m.Name)
+		}
+	}
+	if want := "add Point Shapes Shapes.area"; strings.Join(got, " ") != want {
+		t.Errorf("got %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var b strings.Builder
+	if err := doc.Markdown(&b, extract(t).Exported()); err != nil {
+		t.Fatal(err)
+	}
+	want := "# package app.geometry\n" +
+		"\nPackage geometry computes with points.\n" +
+		"\n## function add\n\n```ferrule\npub function add(x: i32, y: i32) -> i32\n```\n" +
+		"\nAdd returns the sum of x and y.\n" +
+		"\nParameters:\n\n- `x`: the first operand\n- `y`: the second operand\n" +
+		"\nReturns: x + y\n" +
+		"\nExample:\n\n```ferrule\nconst three = add(1, 2);\n```\n" +
+		"\n## struct Point\n\n```ferrule\npub type Point = {\n  x: f64,\n  y: f64\n};\n```\n" +
+		"\nA point in the plane.\n" +
+		"\n## module Shapes\n\n```ferrule\npub component Shapes\n```\n" +
+		"\n### method Shapes.area\n\n```ferrule\npub function area() -> f64\n```\n" +
+		"\nArea of nothing.\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var b strings.Builder
+	if err := doc.HTML(&b, extract(t).Exported()); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"<h1>package app.geometry</h1>",
+		`<h2 id="add">function add</h2>`,
+		"<pre><code>pub function add(x: i32, y: i32) -&gt; i32</code></pre>",
+		"<li><code>x</code>: the first operand</li>",
+		`<h3 id="Shapes.area">method Shapes.area</h3>`,
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("missing %q in:\n%s", s, b.String())
+		}
+	}
+}
+
+func TestExamples(t *testing.T) {
+	src := "/**\n" +
+		" * Scale multiplies v by k.\n" +
+		" *\n" +
+		" * ```\n" +
+		" *   const v = scale(2, 3);\n" +
+		" *   print(v +);\n" +
+		" * ```\n" +
+		" */\n" +
+		"function scale(v: i32, k: i32) -> i32 { return v * k; }\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	all := append(doc.Examples(tree), doc.Examples(extractTree(t))...)
+	if len(all) != 2 {
+		t.Fatalf("got %d examples, want 2", len(all))
+	}
+	e := all[0]
+	if e.Decl != "scale" || e.Source != "const v = scale(2, 3);\nprint(v +);" {
+		t.Errorf("example of %q: %q", e.Decl, e.Source)
+	}
+	if e.Range.StartPoint != (tree_sitter.Point{Row: 3, Column: 3}) || e.Range.EndPoint != (tree_sitter.Point{Row: 6, Column: 6}) {
+		t.Errorf("range %v-%v", e.Range.StartPoint, e.Range.EndPoint)
+	}
+	diags, err := e.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 || diags[0].Range.StartPoint.Row != 5 || src[diags[0].Range.StartByte] != ')' {
+		t.Errorf("diagnostics %v", diags)
+	}
+
+	add := all[1]
+	if add.Decl != "add" || add.Source != "const three = add(1, 2);" {
+		t.Errorf("example of %q: %q", add.Decl, add.Source)
+	}
+	if diags, err := add.Check(context.Background()); err != nil || len(diags) != 0 {
+		t.Errorf("diagnostics %v, %v", diags, err)
+	}
+	add.Source = "const three=add(1,2);"
+	if got, err := add.Format(); err != nil || got != "const three = add(1, 2);" {
+		t.Errorf("format: %q, %v", got, err)
+	}
+	start, end, repl, ok := add.Replace([]byte(source), "const a = 1;\n\nconst b = 2;")
+	if want := "const a = 1;\n//\n//   const b = 2;"; !ok || repl != want || source[start:end] != "const three = add(1, 2);" {
+		t.Errorf("replace %q with %q, %v", source[start:end], repl, ok)
+	}
+}
+
+func extractTree(t *testing.T) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
@@ -0,0 +1,280 @@
+package doc
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Example is an example of a doc comment with its place in the file, so
+// that problems with it can be reported there.
+type Example struct {
+	// Decl is the name of the documented declaration, prefixed with that
+	// of its component as in "Shapes.area", or "" for the package comment.
+	Decl string
+	// Info is the text after the opening fence.
+	Info string
+	// Source is the code of the example, as in Comment.Examples.
+	Source string
+	// Range covers the example in the file, fences included.
+	Range tree_sitter.Range
+	// lines are the lines of Source, at their place in the file.
+	lines []exampleLine
+}
+
+type exampleLine struct {
+	line
+	src uint // the offset of the line in Source
+}
+
+// Ignored reports whether the info string of e holds the word "ignore",
+// marking an example that is incomplete on purpose.
+func (e Example) Ignored() bool {
+	for _, w := range strings.FieldsFunc(e.Info, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' }) {
+		if w == "ignore" {
+			return true
+		}
+	}
+	return false
+}
+
+// Examples returns the examples of the doc comments of the file parsed as
+// tree, in source order.
+func Examples(tree *ferrule.Tree) []Example {
+	src := tree.Source()
+	root := tree.RootNode()
+	var out []Example
+	var walk func(syms []symbols.Symbol, prefix string)
+	walk = func(syms []symbols.Symbol, prefix string) {
+		for _, s := range syms {
+			n := declAt(root, s.Range)
+			if n == nil {
+				continue
+			}
+			name := prefix + s.Name
+			if n.Kind() == kind.PackageDeclaration {
+				name = ""
+			}
+			for _, e := range examples(commentLines(n, src)) {
+				e.Decl = name
+				out = append(out, e)
+			}
+			walk(s.Children, name+".")
+		}
+	}
+	walk(symbols.Outline(tree), "")
+	return out
+}
+
+// examples returns the fenced blocks of the lines of a doc comment, read
+// as Parse reads them.
+func examples(lines []line) []Example {
+	var out []Example
+	var (
+		open *line // the opening fence, nil outside blocks
+		body []line
+	)
+	finish := func(end tree_sitter.Point, endByte uint) {
+		e := newExample(open.text, body)
+		e.Range = tree_sitter.Range{StartByte: fenceStart(*open), StartPoint: fencePoint(*open), EndByte: endByte, EndPoint: end}
+		out = append(out, e)
+	}
+	for i := range lines {
+		l := lines[i]
+		trimmed := strings.TrimSpace(l.text)
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			if open == nil {
+				open, body = &lines[i], nil
+				continue
+			}
+			n := uint(len(strings.TrimRight(l.text, " \t")))
+			finish(tree_sitter.Point{Row: l.pos.Row, Column: l.pos.Column + n}, l.off+n)
+			open = nil
+		case open != nil:
+			body = append(body, l)
+		}
+	}
+	if open != nil && len(body) > 0 {
+		last := body[len(body)-1]
+		n := uint(len(last.text))
+		finish(tree_sitter.Point{Row: last.pos.Row, Column: last.pos.Column + n}, last.off+n)
+	}
+	return out
+}
+
+func fenceStart(l line) uint {
+	return l.off + uint(len(l.text)-len(strings.TrimLeft(l.text, " \t")))
+}
+
+func fencePoint(l line) tree_sitter.Point {
+	return tree_sitter.Point{Row: l.pos.Row, Column: l.pos.Column + fenceStart(l) - l.off}
+}
+
+// newExample returns the example of the fence line and the lines of its
+// body, with their shared indentation removed.
+func newExample(fence string, body []line) Example {
+	e := Example{Info: strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(fence), "`"))}
+	texts := make([]string, len(body))
+	for i, l := range body {
+		texts[i] = l.text
+	}
+	prefix := indentation(texts)
+	var src strings.Builder
+	for i, l := range body {
+		if i > 0 {
+			src.WriteByte('\n')
+		}
+		if rest, ok := strings.CutPrefix(l.text, prefix); ok {
+			skip := uint(len(prefix))
+			l = line{text: rest, off: l.off + skip, pos: tree_sitter.Point{Row: l.pos.Row, Column: l.pos.Column + skip}}
+		}
+		e.lines = append(e.lines, exampleLine{line: l, src: uint(src.Len())})
+		src.WriteString(l.text)
+	}
+	e.Source = src.String()
+	return e
+}
+
+// Map maps a range of a tree parsed from Source to the file. Positions
+// past the end of Source map to its end.
+func (e Example) Map(r tree_sitter.Range) tree_sitter.Range {
+	return tree_sitter.Range{
+		StartByte:  e.offset(r.StartByte),
+		EndByte:    e.offset(r.EndByte),
+		StartPoint: e.point(r.StartPoint),
+		EndPoint:   e.point(r.EndPoint),
+	}
+}
+
+func (e Example) offset(off uint) uint {
+	if len(e.lines) == 0 {
+		return e.Range.StartByte
+	}
+	off = min(off, uint(len(e.Source)))
+	i := sort.Search(len(e.lines), func(i int) bool { return e.lines[i].src > off }) - 1
+	l := e.lines[max(i, 0)]
+	return l.off + min(off-l.src, uint(len(l.text)))
+}
+
+func (e Example) point(p tree_sitter.Point) tree_sitter.Point {
+	if len(e.lines) == 0 {
+		return e.Range.StartPoint
+	}
+	if int(p.Row) >= len(e.lines) {
+		last := e.lines[len(e.lines)-1]
+		return tree_sitter.Point{Row: last.pos.Row, Column: last.pos.Column + uint(len(last.text))}
+	}
+	l := e.lines[p.Row]
+	return tree_sitter.Point{Row: l.pos.Row, Column: l.pos.Column + min(p.Column, uint(len(l.text)))}
+}
+
+// body wraps examples that are statements rather than declarations.
+const (
+	bodyOpen  = "function example() -> Unit {\n"
+	bodyClose = "\n}\n"
+)
+
+// Check parses the example and returns its syntax errors, with ranges in
+// the file. An example that does not parse as a file, such as a few
+// statements, is parsed as the body of a function as well, and the errors
+// are those of the reading with fewer, the body on a tie.
+func (e Example) Check(ctx context.Context) ([]ferrule.Diagnostic, error) {
+	diags, err := e.parse(ctx, "", "")
+	if err != nil || len(diags) == 0 {
+		return nil, err
+	}
+	body, err := e.parse(ctx, bodyOpen, bodyClose)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) <= len(diags) {
+		diags = body
+	}
+	for i := range diags {
+		diags[i].Range = e.Map(diags[i].Range)
+	}
+	return diags, nil
+}
+
+// parse returns the diagnostics of Source wrapped in before and after,
+// which must end in and start with a newline, with ranges in Source.
+func (e Example) parse(ctx context.Context, before, after string) ([]ferrule.Diagnostic, error) {
+	tree, err := ferrule.Parse(ctx, []byte(before+e.Source+after))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	diags := tree.Diagnostics()
+	shift := uint(len(before))
+	rows := uint(strings.Count(before, "\n"))
+	unwrap := func(off uint, p tree_sitter.Point) (uint, tree_sitter.Point) {
+		if off < shift {
+			return 0, tree_sitter.Point{}
+		}
+		return off - shift, tree_sitter.Point{Row: p.Row - rows, Column: p.Column}
+	}
+	for i := range diags {
+		r := &diags[i].Range
+		r.StartByte, r.StartPoint = unwrap(r.StartByte, r.StartPoint)
+		r.EndByte, r.EndPoint = unwrap(r.EndByte, r.EndPoint)
+	}
+	return diags, nil
+}
+
+// Format returns the example in canonical style, parsed as Check parses
+// it. It returns a *format.SyntaxError if the example does not parse.
+func (e Example) Format() (string, error) {
+	out, err := format.Source([]byte(e.Source))
+	var syntax *format.SyntaxError
+	if !errors.As(err, &syntax) {
+		return strings.TrimSuffix(string(out), "\n"), err
+	}
+	wrapped, werr := format.Source([]byte(bodyOpen + e.Source + bodyClose))
+	if werr != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(wrapped), "\n"), "\n")
+	if len(lines) < 3 {
+		return "", nil
+	}
+	lines = lines[1 : len(lines)-1]
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, format.Indent)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Replace returns the range of src, the file, holding the lines of the
+// example and the text that makes the example read text instead. Every
+// line of text is given the comment markers and indentation of the first
+// line of the example. It returns ok false if the example has no lines.
+func (e Example) Replace(src []byte, text string) (start, end uint, repl string, ok bool) {
+	if len(e.lines) == 0 {
+		return 0, 0, "", false
+	}
+	first, last := e.lines[0], e.lines[len(e.lines)-1]
+	lineStart := first.off - first.pos.Column
+	prefix := string(src[lineStart:first.off])
+	var b strings.Builder
+	for i, l := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+			if l == "" {
+				b.WriteString(strings.TrimRight(prefix, " \t"))
+			} else {
+				b.WriteString(prefix)
+			}
+		}
+		b.WriteString(l)
+	}
+	return first.off, last.off + uint(len(last.text)), b.String(), true
+}
@@ -0,0 +1,114 @@
+package doc
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Markdown writes the documentation of p as a Markdown page: a heading for
+// the package and one for each declaration, with the members of components
+// a level below.
+func Markdown(w io.Writer, p *Package) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# package %s\n", p.Path)
+	markdownComment(&b, p.Doc)
+	var decls func(ds []Decl, level, container string)
+	decls = func(ds []Decl, level, container string) {
+		for _, d := range ds {
+			fmt.Fprintf(&b, "\n%s %s %s%s\n\n", level, d.Kind, container, d.Name)
+			fmt.Fprintf(&b, "```ferrule\n%s\n```\n", d.Signature)
+			markdownComment(&b, d.Doc)
+			decls(d.Members, level+"#", d.Name+".")
+		}
+	}
+	decls(p.Decls, "##", "")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Markdown returns the comment as Markdown: its deprecation, if any, and
+// its prose followed by its tags and examples.
+func (c Comment) Markdown() string {
+	var b strings.Builder
+	markdownComment(&b, c)
+	return strings.TrimPrefix(b.String(), "\n")
+}
+
+func markdownComment(b *strings.Builder, c Comment) {
+	if c.Deprecated {
+		b.WriteString("\nDeprecated")
+		if c.DeprecatedNote != "" {
+			b.WriteString(": " + c.DeprecatedNote)
+		}
+		b.WriteString("\n")
+	}
+	if c.Text != "" {
+		fmt.Fprintf(b, "\n%s\n", c.Text)
+	}
+	if len(c.Params) > 0 {
+		b.WriteString("\nParameters:\n\n")
+		for _, p := range c.Params {
+			fmt.Fprintf(b, "- `%s`: %s\n", p.Name, p.Text)
+		}
+	}
+	if c.Returns != "" {
+		fmt.Fprintf(b, "\nReturns: %s\n", c.Returns)
+	}
+	for _, e := range c.Examples {
+		fmt.Fprintf(b, "\nExample:\n\n```ferrule\n%s\n```\n", e)
+	}
+}
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"paragraphs": func(s string) []string { return strings.Split(s, "\n\n") },
+	"args":       func(d Decl, level int, container string) declArgs { return declArgs{d, level, container} },
+	"inc":        func(n int) int { return n + 1 },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>package {{.Path}}</title>
+</head>
+<body>
+<h1>package {{.Path}}</h1>
+{{template "comment" .Doc}}
+{{- range .Decls}}{{template "decl" (args . 2 "")}}{{end}}
+</body>
+</html>
+{{define "decl"}}
+<h{{.Level}} id="{{.Container}}{{.Decl.Name}}">{{.Decl.Kind}} {{.Container}}{{.Decl.Name}}</h{{.Level}}>
+<pre><code>{{.Decl.Signature}}</code></pre>
+{{template "comment" .Decl.Doc}}
+{{- $d := .}}{{range .Decl.Members}}{{template "decl" (args . (inc $d.Level) (print $d.Decl.Name "."))}}{{end}}
+{{- end}}
+{{define "comment"}}
+{{- if .Deprecated}}<p>Deprecated{{with .DeprecatedNote}}: {{.}}{{end}}</p>
+{{end}}
+{{- range paragraphs .Text}}{{if .}}<p>{{.}}</p>
+{{end}}{{end}}
+{{- with .Params}}<p>Parameters:</p>
+<ul>
+{{range .}}<li><code>{{.Name}}</code>: {{.Text}}</li>
+{{end}}</ul>
+{{end}}
+{{- with .Returns}}<p>Returns: {{.}}</p>
+{{end}}
+{{- range .Examples}}<p>Example:</p>
+<pre><code>{{.}}</code></pre>
+{{end}}
+{{- end}}`))
+
+// declArgs are the arguments of the decl template.
+type declArgs struct {
+	Decl      Decl
+	Level     int
+	Container string
+}
+
+// HTML writes the documentation of p as a standalone HTML page laid out
+// like that of Markdown.
+func HTML(w io.Writer, p *Package) error {
+	return page.Execute(w, p)
+}
@@ -0,0 +1,70 @@
+package dump
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// maxLabelText is the number of bytes of source text shown in the label
+// of a leaf node.
+const maxLabelText = 24
+
+// DOT returns the named nodes of tree as a graph in the Graphviz DOT
+// language. Nodes are labelled with their kind, leaves also with their
+// text, and edges with the field the child fills. ERROR nodes and missing
+// nodes are drawn in red.
+func DOT(tree *ferrule.Tree) string {
+	var b strings.Builder
+	b.WriteString("digraph tree {\n\tnode [shape=box, fontname=monospace];\n\tedge [fontname=monospace];\n")
+	id := 0
+	var visit func(n *tree_sitter.Node) int
+	visit = func(n *tree_sitter.Node) int {
+		self := id
+		id++
+		label := n.Kind()
+		switch {
+		case n.IsMissing():
+			label = "MISSING " + label
+		case n.NamedChildCount() == 0:
+			text := n.Utf8Text(tree.Source())
+			if len(text) > maxLabelText {
+				cut := maxLabelText
+				for !utf8.RuneStart(text[cut]) {
+					cut--
+				}
+				text = text[:cut] + "…"
+			}
+			label += "\n" + text
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%s", self, strconv.Quote(label))
+		switch {
+		case n.IsError():
+			b.WriteString(", color=red, fontcolor=red")
+		case n.IsMissing():
+			b.WriteString(", color=red, fontcolor=red, style=dashed")
+		}
+		b.WriteString("];\n")
+		for i := uint(0); i < n.ChildCount(); i++ {
+			c := n.Child(i)
+			if !c.IsNamed() && !c.IsMissing() {
+				continue
+			}
+			child := visit(c)
+			fmt.Fprintf(&b, "\tn%d -> n%d", self, child)
+			if f := n.FieldNameForChild(uint32(i)); f != "" {
+				fmt.Fprintf(&b, " [label=%s]", strconv.Quote(f))
+			}
+			b.WriteString(";\n")
+		}
+		return self
+	}
+	visit(tree.RootNode())
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,130 @@
+// Package dump renders parse trees for people and for other programs: as
+// indented S-expressions in the format of the tree-sitter test corpus, and
+// as JSON documents with a stable schema that tools written in other
+// languages can consume without linking the grammar.
+package dump
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// SExpr returns the named nodes below n as an S-expression, one node per
+// line and indented by depth, with field names as prefixes. Missing nodes
+// inserted by error recovery are written as (MISSING kind). The output
+// matches the expected trees of the test corpus, so it can be pasted into
+// a corpus file as is.
+func SExpr(n *tree_sitter.Node) string {
+	var b strings.Builder
+	sexpr(&b, n, "", 0)
+	return b.String()
+}
+
+func sexpr(b *strings.Builder, n *tree_sitter.Node, field string, depth int) {
+	if depth > 0 {
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat("  ", depth))
+	}
+	if field != "" {
+		b.WriteString(field)
+		b.WriteString(": ")
+	}
+	b.WriteByte('(')
+	switch {
+	case n.IsMissing() && n.IsNamed():
+		b.WriteString("MISSING ")
+		b.WriteString(n.Kind())
+	case n.IsMissing():
+		b.WriteString("MISSING ")
+		b.WriteString(strconv.Quote(n.Kind()))
+	default:
+		b.WriteString(n.Kind())
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if !c.IsNamed() && !c.IsMissing() {
+			continue
+		}
+		sexpr(b, c, n.FieldNameForChild(uint32(i)), depth+1)
+	}
+	b.WriteByte(')')
+}
+
+// SchemaVersion is the version of the JSON schema written by JSON. It is
+// raised whenever a field changes meaning or disappears; new fields may be
+// added without raising it.
+const SchemaVersion = 1
+
+// Document is the top-level object written by JSON.
+type Document struct {
+	Version int   `json:"version"`
+	Root    *Node `json:"root"`
+}
+
+// Node is one node of a JSON dump.
+type Node struct {
+	Kind string `json:"kind"`
+	// Named is false for anonymous tokens such as punctuation and
+	// keywords.
+	Named bool `json:"named"`
+	// Field is the name of the field the node fills in its parent, if any.
+	Field   string `json:"field,omitempty"`
+	Error   bool   `json:"error,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+	Extra   bool   `json:"extra,omitempty"`
+	Range   Range  `json:"range"`
+	// Text is the source text of a node without children. It is only
+	// present when the dump was made with the source.
+	Text     *string `json:"text,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Range is the extent of a node, with zero-based rows and byte columns.
+type Range struct {
+	StartByte uint  `json:"startByte"`
+	EndByte   uint  `json:"endByte"`
+	Start     Point `json:"start"`
+	End       Point `json:"end"`
+}
+
+// Point is a position in the source.
+type Point struct {
+	Row    uint `json:"row"`
+	Column uint `json:"column"`
+}
+
+// JSON returns n and every node below it, anonymous tokens included, as an
+// indented JSON Document. If src is not nil, the text of every leaf node is
+// included.
+func JSON(n *tree_sitter.Node, src []byte) ([]byte, error) {
+	return json.MarshalIndent(Document{Version: SchemaVersion, Root: convert(n, "", src)}, "", "  ")
+}
+
+func convert(n *tree_sitter.Node, field string, src []byte) *Node {
+	r := n.Range()
+	out := &Node{
+		Kind:    n.Kind(),
+		Named:   n.IsNamed(),
+		Field:   field,
+		Error:   n.IsError(),
+		Missing: n.IsMissing(),
+		Extra:   n.IsExtra(),
+		Range: Range{
+			StartByte: r.StartByte,
+			EndByte:   r.EndByte,
+			Start:     Point{Row: r.StartPoint.Row, Column: r.StartPoint.Column},
+			End:       Point{Row: r.EndPoint.Row, Column: r.EndPoint.Column},
+		},
+	}
+	if n.ChildCount() == 0 && src != nil {
+		text := n.Utf8Text(src)
+		out.Text = &text
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		out.Children = append(out.Children, convert(n.Child(i), n.FieldNameForChild(uint32(i)), src))
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package dump_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func parse(t *testing.T, src string) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+func TestSExpr(t *testing.T) {
+	tree := parse(t, "function add(x: i32, y: i32) -> i32 {\n  return x + y;\n}\n")
+	want := `(source_file
+  (function_declaration
+    name: (identifier)
+    parameters: (parameter_list
+      (parameter
+        name: (identifier)
+        type: (primitive_type))
+      (parameter
+        name: (identifier)
+        type: (primitive_type)))
+    return_type: (primitive_type)
+    body: (block
+      (return_statement
+        (binary_expression
+          (identifier)
+          (identifier))))))`
+	if got := dump.SExpr(tree.RootNode()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSExprMissing(t *testing.T) {
+	tree := parse(t, "package app\n")
+	want := "(source_file\n  (package_declaration\n    path: (package_path\n      (identifier))\n    (MISSING \";\")))"
+	if got := dump.SExpr(tree.RootNode()); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tree := parse(t, "package app;\n")
+	data, err := dump.JSON(tree.RootNode(), tree.Source())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc dump.Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != dump.SchemaVersion || doc.Root.Kind != "source_file" {
+		t.Fatalf("document %+v", doc)
+	}
+	decl := doc.Root.Children[0]
+	if decl.Kind != "package_declaration" || len(decl.Children) != 3 {
+		t.Fatalf("declaration %+v", decl)
+	}
+	kw, path, semi := decl.Children[0], decl.Children[1], decl.Children[2]
+	if kw.Named || kw.Text == nil || *kw.Text != "package" {
+		t.Errorf("keyword %+v", kw)
+	}
+	if path.Field != "path" || path.Text != nil || path.Range != (dump.Range{StartByte: 8, EndByte: 11, Start: dump.Point{Column: 8}, End: dump.Point{Column: 11}}) {
+		t.Errorf("path %+v", path)
+	}
+	if semi.Kind != ";" || semi.Range.StartByte != 11 {
+		t.Errorf("semicolon %+v", semi)
+	}
+
+	data, err = dump.JSON(tree.RootNode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = dump.Document{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Root.Children[0].Children[0].Text != nil {
+		t.Error("text included without source")
+	}
+}
+
+func TestDOT(t *testing.T) {
+	tree := parse(t, "const x = ;\n")
+	got := dump.DOT(tree)
+	for _, want := range []string{
+		"digraph tree {\n",
+		"\tn0 [label=\"source_file\"];\n",
+		"\tn2 [label=\"identifier\\nx\"];\n\tn1 -> n2 [label=\"name\"];\n",
+		"color=red",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package edits translates editor-style text changes into tree-sitter edits
+// so trees can be reparsed incrementally.
+//
+// Changes are addressed the way the Language Server Protocol addresses
+// them: by zero-based line and a character offset counted in UTF-16 code
+// units. Tree-sitter needs byte offsets and byte-based columns instead, and
+// this package does the conversion.
+package edits
+
+import (
+	"context"
+	"errors"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/position"
+)
+
+// ErrInvalidRange is returned for a change whose start lies after its end.
+var ErrInvalidRange = errors.New("edits: range start is after range end")
+
+// Position is a zero-based line and UTF-16 character offset.
+type Position struct {
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// Range is a half-open span between two positions.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Change mirrors an LSP TextDocumentContentChangeEvent. A nil Range
+// replaces the whole document.
+type Change struct {
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
+}
+
+// Parser is satisfied by *ferrule.Parser and *ferrule.ParserPool.
+type Parser interface {
+	Parse(ctx context.Context, src []byte, old *ferrule.Tree) (*ferrule.Tree, error)
+}
+
+// Offset converts pos to a byte offset into src.
+//
+// As the LSP specification requires, a character past the end of its line
+// resolves to the end of the line and a line past the end of the document
+// resolves to the end of the document. A character that falls inside a
+// surrogate pair resolves to the end of that code point.
+//
+// Offset indexes src on every call; callers converting many positions of
+// one buffer should build a position.Index once instead.
+func Offset(src []byte, pos Position) uint {
+	return position.New(src).Offset(position.Position(pos))
+}
+
+// PositionOf converts a byte offset into src to a position, the inverse of
+// Offset. An offset inside a multi-byte character resolves to the start of
+// that character. Like Offset, it indexes src on every call.
+func PositionOf(src []byte, offset uint) Position {
+	return Position(position.New(src).Position(offset))
+}
+
+// RangeOf converts the byte range of a tree-sitter range to positions.
+func RangeOf(src []byte, r tree_sitter.Range) Range {
+	return RangeIn(position.New(src), r)
+}
+
+// RangeIn is like RangeOf, converting with the index x of the source.
+func RangeIn(x *position.Index, r tree_sitter.Range) Range {
+	return Range{Start: Position(x.Position(r.StartByte)), End: Position(x.Position(r.EndByte))}
+}
+
+// Point returns the tree-sitter point of a byte offset: the number of
+// preceding newlines and the byte distance from the last one.
+func Point(src []byte, offset uint) tree_sitter.Point {
+	var p tree_sitter.Point
+	start := uint(0)
+	for i := uint(0); i < offset && i < uint(len(src)); i++ {
+		if src[i] == '\n' {
+			p.Row++
+			start = i + 1
+		}
+	}
+	p.Column = offset - start
+	return p
+}
+
+// LineStart returns the offset of the start of the zero-based row of src,
+// counting lines as Point does, or the end of src if it has fewer lines.
+func LineStart(src []byte, row uint) uint {
+	off := uint(0)
+	for ; row > 0 && off < uint(len(src)); off++ {
+		if src[off] == '\n' {
+			row--
+		}
+	}
+	return off
+}
+
+// Apply applies c to src. It returns the new source and the tree-sitter
+// edit describing the change.
+func Apply(src []byte, c Change) ([]byte, tree_sitter.InputEdit, error) {
+	start, end := uint(0), uint(len(src))
+	if c.Range != nil {
+		start = Offset(src, c.Range.Start)
+		end = Offset(src, c.Range.End)
+		if start > end {
+			return nil, tree_sitter.InputEdit{}, ErrInvalidRange
+		}
+	}
+
+	out := make([]byte, 0, uint(len(src))-(end-start)+uint(len(c.Text)))
+	out = append(out, src[:start]...)
+	out = append(out, c.Text...)
+	out = append(out, src[end:]...)
+
+	newEnd := start + uint(len(c.Text))
+	edit := tree_sitter.InputEdit{
+		StartByte:      start,
+		OldEndByte:     end,
+		NewEndByte:     newEnd,
+		StartPosition:  Point(src, start),
+		OldEndPosition: Point(src, end),
+		NewEndPosition: Point(out, newEnd),
+	}
+	return out, edit, nil
+}
+
+// Reparse applies changes in order to tree and parses the result
+// incrementally. It returns the new tree and the ranges whose syntactic
+// structure changed.
+//
+// tree is left as it was, also when a change is invalid or parsing fails;
+// the caller still owns it and must close it.
+func Reparse(ctx context.Context, p Parser, tree *ferrule.Tree, changes []Change) (*ferrule.Tree, []tree_sitter.Range, error) {
+	src := tree.Source()
+	inputs := make([]tree_sitter.InputEdit, 0, len(changes))
+	for _, c := range changes {
+		next, edit, err := Apply(src, c)
+		if err != nil {
+			return nil, nil, err
+		}
+		inputs = append(inputs, edit)
+		src = next
+	}
+
+	// The old tree must be edited to match src, on a copy.
+	old := tree.Clone()
+	defer old.Close()
+	for i := range inputs {
+		old.Raw().Edit(&inputs[i])
+	}
+	updated, err := p.Parse(ctx, src, old)
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, old.Raw().ChangedRanges(updated.Raw()), nil
+}
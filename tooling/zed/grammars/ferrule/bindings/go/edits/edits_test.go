@@ -0,0 +1,186 @@
+package edits_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestOffset(t *testing.T) {
+	src := []byte("const a = \"😀x\";\r\nconst b = 2;\n")
+	cases := []struct {
+		pos  edits.Position
+		want uint
+	}{
+		{edits.Position{Line: 0, Character: 0}, 0},
+		{edits.Position{Line: 0, Character: 11}, 11},
+		// the emoji is two UTF-16 units and four bytes.
+		{edits.Position{Line: 0, Character: 13}, 15},
+		// splitting the surrogate pair rounds up to the end of the rune.
+		{edits.Position{Line: 0, Character: 12}, 15},
+		// past the end of the line clamps before the line break.
+		{edits.Position{Line: 0, Character: 99}, 18},
+		{edits.Position{Line: 1, Character: 6}, 26},
+		{edits.Position{Line: 7, Character: 0}, uint(len(src))},
+	}
+	for _, c := range cases {
+		if got := edits.Offset(src, c.pos); got != c.want {
+			t.Errorf("Offset(%+v) = %d, want %d", c.pos, got, c.want)
+		}
+	}
+}
+
+func TestPositionOf(t *testing.T) {
+	src := []byte("const a = \"😀x\";\r\nconst b = 2;\n")
+	cases := []struct {
+		offset uint
+		want   edits.Position
+	}{
+		{0, edits.Position{Line: 0, Character: 0}},
+		{11, edits.Position{Line: 0, Character: 11}},
+		{15, edits.Position{Line: 0, Character: 13}},
+		// inside the emoji resolves to its start.
+		{13, edits.Position{Line: 0, Character: 11}},
+		// between \r and \n is still the end of the first line.
+		{19, edits.Position{Line: 0, Character: 16}},
+		{26, edits.Position{Line: 1, Character: 6}},
+		{99, edits.Position{Line: 2, Character: 0}},
+	}
+	for _, c := range cases {
+		if got := edits.PositionOf(src, c.offset); got != c.want {
+			t.Errorf("PositionOf(%d) = %+v, want %+v", c.offset, got, c.want)
+		}
+		if c.offset <= uint(len(src)) && c.offset != 13 && c.offset != 19 {
+			if back := edits.Offset(src, c.want); back != c.offset {
+				t.Errorf("Offset(PositionOf(%d)) = %d", c.offset, back)
+			}
+		}
+	}
+}
+
+func TestLineStart(t *testing.T) {
+	src := []byte("a\r\n\nbc\n")
+	for row, want := range []uint{0, 3, 4, 7, 7} {
+		if got := edits.LineStart(src, uint(row)); got != want {
+			t.Errorf("LineStart(%d) = %d, want %d", row, got, want)
+		}
+		if row < 4 {
+			if p := edits.Point(src, want); p.Row != uint(row) || p.Column != 0 {
+				t.Errorf("Point(LineStart(%d)) = %+v", row, p)
+			}
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	src := []byte("ab\ncd\n")
+	out, edit, err := edits.Apply(src, edits.Change{
+		Range: &edits.Range{Start: edits.Position{Line: 0, Character: 1}, End: edits.Position{Line: 1, Character: 1}},
+		Text:  "X\nY",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "aX\nYd\n" {
+		t.Errorf("got %q", out)
+	}
+	if edit.StartByte != 1 || edit.OldEndByte != 4 || edit.NewEndByte != 4 {
+		t.Errorf("unexpected byte range %+v", edit)
+	}
+	if edit.OldEndPosition.Row != 1 || edit.OldEndPosition.Column != 1 || edit.NewEndPosition.Row != 1 || edit.NewEndPosition.Column != 1 {
+		t.Errorf("unexpected points %+v", edit)
+	}
+
+	_, _, err = edits.Apply(src, edits.Change{
+		Range: &edits.Range{Start: edits.Position{Line: 1}, End: edits.Position{Line: 0}},
+	})
+	if err != edits.ErrInvalidRange {
+		t.Errorf("got %v, want ErrInvalidRange", err)
+	}
+}
+
+func TestReparse(t *testing.T) {
+	ctx := context.Background()
+	src := []byte("function f() -> i32 {\n  return 1;\n}\n")
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	updated, changed, err := edits.Reparse(ctx, p, tree, []edits.Change{
+		{
+			Range: &edits.Range{Start: edits.Position{Line: 1, Character: 9}, End: edits.Position{Line: 1, Character: 10}},
+			Text:  "a + b",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer updated.Close()
+
+	want := "function f() -> i32 {\n  return a + b;\n}\n"
+	if string(updated.Source()) != want {
+		t.Fatalf("got source %q", updated.Source())
+	}
+	fresh, err := ferrule.Parse(ctx, []byte(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if got, want := updated.RootNode().ToSexp(), fresh.RootNode().ToSexp(); got != want {
+		t.Errorf("incremental tree differs from a full parse:\n%s\n%s", got, want)
+	}
+	if len(changed) == 0 {
+		t.Error("expected changed ranges")
+	}
+}
+
+// TestReparseInvalid checks that a change list failing part way leaves the
+// tree as it was, so that it can still be reparsed against its source.
+func TestReparseInvalid(t *testing.T) {
+	ctx := context.Background()
+	src := []byte("const a = 1;\nconst b = 2;\n")
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	before := tree.RootNode().ToSexp()
+
+	insert := edits.Change{
+		Range: &edits.Range{Start: edits.Position{Line: 0, Character: 0}, End: edits.Position{Line: 0, Character: 0}},
+		Text:  "const z = 0;\n",
+	}
+	backwards := edits.Change{
+		Range: &edits.Range{Start: edits.Position{Line: 1}, End: edits.Position{Line: 0}},
+	}
+	if _, _, err := edits.Reparse(ctx, p, tree, []edits.Change{insert, backwards}); err != edits.ErrInvalidRange {
+		t.Fatalf("got %v, want ErrInvalidRange", err)
+	}
+	root := tree.RootNode()
+	if root.ToSexp() != before || root.EndByte() != uint(len(src)) || root.NamedChild(1).StartByte() != 13 {
+		t.Fatalf("tree edited by a failed reparse: %s, ending at %d", root.ToSexp(), root.EndByte())
+	}
+
+	updated, _, err := edits.Reparse(ctx, p, tree, []edits.Change{insert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer updated.Close()
+	if got := updated.RootNode().NamedChild(2).Utf8Text(updated.Source()); got != "const b = 2;" {
+		t.Errorf("third declaration %q", got)
+	}
+}
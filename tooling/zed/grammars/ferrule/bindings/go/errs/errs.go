@@ -0,0 +1,202 @@
+// Package errs finds the places where a syntax tree is unfinished, so that
+// editors can go beyond "syntax error" and say what is missing: the nodes
+// the parser made up to recover from an error, and the constructs that lack
+// parts the grammar requires.
+package errs
+
+import (
+	"fmt"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// MissingNode is a node the parser inserted during error recovery. It is
+// empty and sits where the text it stands for should be.
+type MissingNode struct {
+	Node *tree_sitter.Node
+	// Kind is the kind of the node: a token such as ";" or a named kind
+	// such as "identifier".
+	Kind string
+	// Parent is the node it belongs to.
+	Parent *tree_sitter.Node
+	// Field is the field of Parent it fills, if any.
+	Field string
+}
+
+// Hint describes what is missing, such as "unterminated string literal" or
+// "missing ';' after return statement".
+func (m MissingNode) Hint() string {
+	parent := ""
+	if m.Parent != nil {
+		parent = m.Parent.Kind()
+	}
+	switch {
+	case m.Kind == `"` && parent == kind.StringLiteral, m.Kind == "'" && parent == kind.CharLiteral:
+		return "unterminated " + humanize(parent)
+	case m.Kind == ")" || m.Kind == "]" || m.Kind == "}" || m.Kind == ">":
+		return fmt.Sprintf("unclosed %s, missing '%s'", humanize(parent), m.Kind)
+	case m.Kind == ";" && parent != "":
+		return fmt.Sprintf("missing ';' after %s", humanize(parent))
+	}
+	what := "'" + m.Kind + "'"
+	switch {
+	case m.Field != "":
+		what = humanize(m.Field)
+	case m.Node.IsNamed():
+		what = humanize(m.Kind)
+	}
+	if parent == "" {
+		return "missing " + what
+	}
+	return fmt.Sprintf("missing %s in %s", what, humanize(parent))
+}
+
+// Missing returns the MISSING nodes of tree in source order.
+func Missing(tree *ferrule.Tree) []MissingNode {
+	var out []MissingNode
+	walk(tree.RootNode(), func(n *tree_sitter.Node) {
+		for i := uint(0); i < n.ChildCount(); i++ {
+			if c := n.Child(i); c.IsMissing() {
+				out = append(out, MissingNode{Node: c, Kind: c.Kind(), Parent: n, Field: n.FieldNameForChild(uint32(i))})
+			}
+		}
+	})
+	return out
+}
+
+// IncompleteNode is a construct that lacks parts the grammar requires.
+type IncompleteNode struct {
+	// Node is the construct, or the ERROR node holding what was parsed of
+	// it when the parser could not make a node of its kind.
+	Node *tree_sitter.Node
+	// Kind is the kind of the construct.
+	Kind string
+	// Fields are the required fields that are absent or missing, where
+	// known.
+	Fields []string
+	// Missing are the MISSING children of the construct.
+	Missing []MissingNode
+}
+
+// Hint describes what is incomplete, such as "function declaration without
+// body" or "unterminated string literal".
+func (c IncompleteNode) Hint() string {
+	what := humanize(c.Kind)
+	switch {
+	case len(c.Fields) > 0:
+		var fields []string
+		for _, f := range c.Fields {
+			fields = append(fields, humanize(f))
+		}
+		return what + " without " + strings.Join(fields, " and ")
+	case len(c.Missing) > 0:
+		return c.Missing[0].Hint()
+	case c.Kind == kind.StringLiteral || c.Kind == kind.CharLiteral || c.Kind == kind.BlockComment:
+		return "unterminated " + what
+	}
+	return "incomplete " + what
+}
+
+// starts maps the keywords that begin top-level declarations to the kinds
+// of those.
+var starts = map[string]string{
+	kind.KeywordCapability: kind.CapabilityDeclaration,
+	kind.KeywordComponent:  kind.ComponentDeclaration,
+	kind.KeywordConst:      kind.ConstDeclaration,
+	kind.KeywordVar:        kind.ConstDeclaration,
+	kind.KeywordDomain:     kind.DomainDeclaration,
+	kind.KeywordError:      kind.ErrorDeclaration,
+	kind.KeywordFunction:   kind.FunctionDeclaration,
+	kind.KeywordImport:     kind.ImportDeclaration,
+	kind.KeywordPackage:    kind.PackageDeclaration,
+	kind.KeywordType:       kind.TypeDeclaration,
+	kind.KeywordUse:        kind.UseDeclaration,
+}
+
+// Incomplete returns the incomplete constructs of tree, outer ones before
+// the ones they contain.
+//
+// A construct is incomplete when it lacks a field listed in field.Required
+// for its kind or has MISSING children. When the parser could not make a
+// node of the construct at all, the ERROR node holding its beginning is
+// reported, with the kind the beginning shows: a declaration keyword, or
+// an opening quote or comment delimiter that is never closed.
+func Incomplete(tree *ferrule.Tree) []IncompleteNode {
+	var out []IncompleteNode
+	walk(tree.RootNode(), func(n *tree_sitter.Node) {
+		if n.IsError() {
+			if k := unfinished(n); k != "" {
+				out = append(out, IncompleteNode{Node: n, Kind: k})
+			}
+			return
+		}
+		if !n.IsNamed() {
+			return
+		}
+		c := IncompleteNode{Node: n, Kind: n.Kind()}
+		for _, f := range field.Required[n.Kind()] {
+			if child := n.ChildByFieldName(f); child == nil || child.IsMissing() {
+				c.Fields = append(c.Fields, f)
+			}
+		}
+		for i := uint(0); i < n.ChildCount(); i++ {
+			if child := n.Child(i); child.IsMissing() {
+				c.Missing = append(c.Missing, MissingNode{Node: child, Kind: child.Kind(), Parent: n, Field: n.FieldNameForChild(uint32(i))})
+			}
+		}
+		if len(c.Fields) > 0 || len(c.Missing) > 0 {
+			out = append(out, c)
+		}
+	})
+	return out
+}
+
+// unfinished returns the kind of construct the ERROR node n is the
+// unfinished beginning of, or "".
+func unfinished(n *tree_sitter.Node) string {
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		switch {
+		case c.Kind() == `"` && !c.IsNamed():
+			return kind.StringLiteral
+		case c.Kind() == "'" && !c.IsNamed():
+			return kind.CharLiteral
+		case c.Kind() == "/" && i+1 < n.ChildCount() && n.Child(i+1).Kind() == "*" && c.EndByte() == n.Child(i+1).StartByte():
+			return kind.BlockComment
+		}
+	}
+	first := n.Child(0)
+	if first != nil && first.Kind() == kind.KeywordPub {
+		first = n.Child(1)
+	}
+	if first != nil && n.Parent() != nil && n.Parent().Kind() == kind.SourceFile {
+		return starts[first.Kind()]
+	}
+	return ""
+}
+
+// walk calls f for n and every node below it, in depth-first order.
+func walk(n *tree_sitter.Node, f func(*tree_sitter.Node)) {
+	cursor := n.Walk()
+	defer cursor.Close()
+	for {
+		f(cursor.Node())
+		if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return
+			}
+		}
+	}
+}
+
+func humanize(kind string) string {
+	return strings.ReplaceAll(kind, "_", " ")
+}
@@ -0,0 +1,70 @@
+package errs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/errs"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func parse(t *testing.T, src string) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+func TestMissing(t *testing.T) {
+	tests := []struct {
+		src, kind, parent, hint string
+	}{
+		{"const c = 'a;", "'", "char_literal", "unterminated char literal"},
+		{"function f() -> i32 { return 1;", "}", "block", "unclosed block, missing '}'"},
+		{"function f() -> i32 { return add(1, 2; }", ")", "call_expression", "unclosed call expression, missing ')'"},
+		{"import std.io", ";", "import_declaration", "missing ';' after import declaration"},
+		{"type T = ;", "type_identifier", "type_declaration", "missing type in type declaration"},
+	}
+	for _, tt := range tests {
+		got := errs.Missing(parse(t, tt.src))
+		if len(got) != 1 {
+			t.Errorf("%q: %d missing nodes, want 1", tt.src, len(got))
+			continue
+		}
+		m := got[0]
+		if m.Kind != tt.kind || m.Parent.Kind() != tt.parent || m.Hint() != tt.hint {
+			t.Errorf("%q: got %s in %s, %q; want %s in %s, %q", tt.src, m.Kind, m.Parent.Kind(), m.Hint(), tt.kind, tt.parent, tt.hint)
+		}
+	}
+	if got := errs.Missing(parse(t, "const a = 1;\n")); len(got) != 0 {
+		t.Errorf("complete source: %d missing nodes, want 0", len(got))
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		src, kind, hint string
+	}{
+		{"const s = \"abc;\n", "string_literal", "unterminated string literal"},
+		{"function f() -> i32 { /* x }", "block_comment", "unterminated block comment"},
+		{"pub function f(x: i32) -> i32", "function_declaration", "incomplete function declaration"},
+		{"type T = ;", "type_declaration", "type declaration without type"},
+		{"import std.io", "import_declaration", "missing ';' after import declaration"},
+	}
+	for _, tt := range tests {
+		got := errs.Incomplete(parse(t, tt.src))
+		if len(got) != 1 {
+			t.Errorf("%q: %d incomplete constructs, want 1", tt.src, len(got))
+			continue
+		}
+		if c := got[0]; c.Kind != tt.kind || c.Hint() != tt.hint {
+			t.Errorf("%q: got %s, %q; want %s, %q", tt.src, c.Kind, c.Hint(), tt.kind, tt.hint)
+		}
+	}
+	if got := errs.Incomplete(parse(t, "function f() -> i32 { return 1; }\n")); len(got) != 0 {
+		t.Errorf("complete source: %d incomplete constructs, want 0", len(got))
+	}
+}
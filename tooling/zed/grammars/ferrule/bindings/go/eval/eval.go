@@ -0,0 +1,295 @@
+// Package eval folds constant expressions of ferrule source.
+//
+// Const evaluates an expression made of literals, parentheses and the
+// unary and binary operators on them: arithmetic and bitwise operators
+// on integers, arithmetic on floats, ++ concatenation of strings,
+// comparisons and the boolean operators. Integers are exact, however
+// large, so that lint rules can tell which types a value fits. Names
+// are not resolved, so an expression referring to a constant is not
+// constant itself.
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/literal"
+)
+
+// ErrNotConstant is returned for expressions whose value is not known
+// before the program runs.
+var ErrNotConstant = errors.New("eval: not a constant expression")
+
+// An Error is a constant expression that has no value, such as a
+// division by zero.
+type Error struct {
+	// Node is the innermost expression at fault.
+	Node    *tree_sitter.Node
+	Message string
+}
+
+func (e *Error) Error() string { return "eval: " + e.Message }
+
+// maxShift bounds shift counts, well past the width of any integer type.
+const maxShift = 1024
+
+// Const returns the value of the expression n of the source src. It
+// returns ErrNotConstant if n is not a constant expression, and an
+// *Error if it is one that fails, as by dividing by zero or mixing
+// kinds.
+func Const(n *tree_sitter.Node, src []byte) (Value, error) {
+	if n == nil || n.IsError() || n.IsMissing() {
+		return Value{}, ErrNotConstant
+	}
+	switch n.Kind() {
+	case kind.IntegerLiteral:
+		return integer(n, src)
+	case kind.FloatLiteral:
+		f, err := literal.Float(n.Utf8Text(src))
+		if err != nil {
+			return Value{}, &Error{n, "float literal out of range"}
+		}
+		return Value{Kind: Float, Float: f}, nil
+	case kind.StringLiteral:
+		s, err := literal.Unquote(n.Utf8Text(src))
+		if err != nil {
+			return Value{}, &Error{n, "malformed string literal"}
+		}
+		return Value{Kind: String, Text: s}, nil
+	case kind.CharLiteral:
+		r, err := literal.UnquoteChar(n.Utf8Text(src))
+		if err != nil {
+			return Value{}, &Error{n, "malformed character literal"}
+		}
+		return Value{Kind: Char, Char: r}, nil
+	case kind.BooleanLiteral:
+		return Value{Kind: Bool, Bool: n.Utf8Text(src) == "true"}, nil
+	case kind.ParenthesizedExpression:
+		return Const(operand(n, 0), src)
+	case kind.UnaryExpression:
+		return unary(n, src)
+	case kind.BinaryExpression:
+		return binary(n, src)
+	}
+	return Value{}, ErrNotConstant
+}
+
+func integer(n *tree_sitter.Node, src []byte) (Value, error) {
+	i, err := literal.Int(n.Utf8Text(src))
+	if err != nil {
+		return Value{}, &Error{n, "malformed integer literal"}
+	}
+	return Value{Kind: Int, Int: i}, nil
+}
+
+// operand returns the i-th named child of n that is not a comment.
+func operand(n *tree_sitter.Node, i int) *tree_sitter.Node {
+	for j := uint(0); j < n.NamedChildCount(); j++ {
+		c := n.NamedChild(j)
+		if c.IsExtra() {
+			continue
+		}
+		if i == 0 {
+			return c
+		}
+		i--
+	}
+	return nil
+}
+
+// operator returns the first anonymous child of n that is not a comment.
+func operator(n *tree_sitter.Node) string {
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if c := n.Child(i); !c.IsNamed() && !c.IsExtra() {
+			return c.Kind()
+		}
+	}
+	return ""
+}
+
+func unary(n *tree_sitter.Node, src []byte) (Value, error) {
+	x, err := Const(operand(n, 0), src)
+	if err != nil {
+		return Value{}, err
+	}
+	op := operator(n)
+	switch {
+	case op == "-" && x.Kind == Int:
+		return Value{Kind: Int, Int: new(big.Int).Neg(x.Int)}, nil
+	case op == "-" && x.Kind == Float:
+		return Value{Kind: Float, Float: -x.Float}, nil
+	case op == "~" && x.Kind == Int:
+		return Value{Kind: Int, Int: new(big.Int).Not(x.Int)}, nil
+	case op == "!" && x.Kind == Bool:
+		return Value{Kind: Bool, Bool: !x.Bool}, nil
+	}
+	return Value{}, &Error{n, fmt.Sprintf("operator %s not defined on %s", op, x.Kind)}
+}
+
+func binary(n *tree_sitter.Node, src []byte) (Value, error) {
+	op := operator(n)
+	switch op {
+	case "=", "is", "..", "..=":
+		return Value{}, ErrNotConstant
+	}
+	x, err := Const(operand(n, 0), src)
+	if err != nil {
+		return Value{}, err
+	}
+	y, err := Const(operand(n, 1), src)
+	if err != nil {
+		return Value{}, err
+	}
+	if x.Kind != y.Kind {
+		return Value{}, &Error{n, fmt.Sprintf("mismatched kinds %s and %s", x.Kind, y.Kind)}
+	}
+	switch op {
+	case "==":
+		return Value{Kind: Bool, Bool: x.Equal(y)}, nil
+	case "!=":
+		return Value{Kind: Bool, Bool: !x.Equal(y)}, nil
+	case "<", "<=", ">", ">=":
+		if c, ok := compare(x, y); ok {
+			return Value{Kind: Bool, Bool: ordered(op, c)}, nil
+		}
+	case "&&":
+		if x.Kind == Bool {
+			return Value{Kind: Bool, Bool: x.Bool && y.Bool}, nil
+		}
+	case "||":
+		if x.Kind == Bool {
+			return Value{Kind: Bool, Bool: x.Bool || y.Bool}, nil
+		}
+	case "++":
+		if x.Kind == String {
+			return Value{Kind: String, Text: x.Text + y.Text}, nil
+		}
+	default:
+		switch x.Kind {
+		case Int:
+			return intOp(n, op, x.Int, y.Int)
+		case Float:
+			return floatOp(n, op, x.Float, y.Float)
+		}
+	}
+	return Value{}, &Error{n, fmt.Sprintf("operator %s not defined on %s", op, x.Kind)}
+}
+
+// compare returns the order of x and y, of one kind, if they have one.
+func compare(x, y Value) (int, bool) {
+	switch x.Kind {
+	case Int:
+		return x.Int.Cmp(y.Int), true
+	case Float:
+		if math.IsNaN(x.Float) || math.IsNaN(y.Float) {
+			// Every comparison with NaN is false, which no order gives.
+			return 2, true
+		}
+		switch {
+		case x.Float < y.Float:
+			return -1, true
+		case x.Float > y.Float:
+			return 1, true
+		}
+		return 0, true
+	case String:
+		return strings.Compare(x.Text, y.Text), true
+	case Char:
+		switch {
+		case x.Char < y.Char:
+			return -1, true
+		case x.Char > y.Char:
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func ordered(op string, c int) bool {
+	if c == 2 {
+		return false
+	}
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+func intOp(n *tree_sitter.Node, op string, x, y *big.Int) (Value, error) {
+	z := new(big.Int)
+	switch op {
+	case "+":
+		z.Add(x, y)
+	case "-":
+		z.Sub(x, y)
+	case "*":
+		z.Mul(x, y)
+	case "/", "%":
+		if y.Sign() == 0 {
+			return Value{}, &Error{n, "division by zero"}
+		}
+		if op == "/" {
+			z.Quo(x, y)
+		} else {
+			z.Rem(x, y)
+		}
+	case "&":
+		z.And(x, y)
+	case "|":
+		z.Or(x, y)
+	case "^":
+		z.Xor(x, y)
+	case "<<", ">>":
+		if y.Sign() < 0 {
+			return Value{}, &Error{n, "negative shift count " + y.String()}
+		}
+		if !y.IsUint64() || y.Uint64() > maxShift {
+			return Value{}, &Error{n, "shift count " + y.String() + " too large"}
+		}
+		if op == "<<" {
+			z.Lsh(x, uint(y.Uint64()))
+		} else {
+			z.Rsh(x, uint(y.Uint64()))
+		}
+	default:
+		return Value{}, &Error{n, fmt.Sprintf("operator %s not defined on %s", op, Int)}
+	}
+	return Value{Kind: Int, Int: z}, nil
+}
+
+func floatOp(n *tree_sitter.Node, op string, x, y float64) (Value, error) {
+	var z float64
+	switch op {
+	case "+":
+		z = x + y
+	case "-":
+		z = x - y
+	case "*":
+		z = x * y
+	case "/":
+		if y == 0 {
+			return Value{}, &Error{n, "division by zero"}
+		}
+		z = x / y
+	case "%":
+		if y == 0 {
+			return Value{}, &Error{n, "division by zero"}
+		}
+		z = math.Mod(x, y)
+	default:
+		return Value{}, &Error{n, fmt.Sprintf("operator %s not defined on %s", op, Float)}
+	}
+	return Value{Kind: Float, Float: z}, nil
+}
@@ -0,0 +1,103 @@
+package eval_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/eval"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// constOf evaluates expr as the value of a const declaration.
+func constOf(t *testing.T, expr string) (eval.Value, error) {
+	t.Helper()
+	src := []byte("const x = " + expr + ";\n")
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if tree.HasError() {
+		t.Fatalf("%s: syntax errors: %v", expr, tree.Diagnostics())
+	}
+	return eval.Const(tree.RootNode().NamedChild(0).ChildByFieldName("value"), src)
+}
+
+func TestConst(t *testing.T) {
+	for _, tt := range []struct{ expr, want string }{
+		{"1_000", "1000"},
+		{"0xff + 0b1 + 0o7", "263"},
+		{"2 * (3 + 4) - -1", "15"},
+		{"7 / 2", "3"},
+		{"-7 % 3", "-1"},
+		{"1 << 100", "1267650600228229401496703205376"},
+		{"~0 & 0xf | 0x10 ^ 1", "31"},
+		{"1.5 * 2.0", "3.0"},
+		{"1.0e300 * 1.0e10", "+Inf"},
+		{`"a\tb" ++ "\"c\""`, `"a\tb\"c\""`},
+		{`'\n'`, `'\n'`},
+		{"1 < 2 && !(2.5 >= 3.0)", "true"},
+		{`"a" == "b" || 'x' != 'x'`, "false"},
+	} {
+		v, err := constOf(t, tt.expr)
+		if err != nil {
+			t.Errorf("Const(%s): %v", tt.expr, err)
+			continue
+		}
+		if v.String() != tt.want {
+			t.Errorf("Const(%s) = %s, want %s", tt.expr, v, tt.want)
+		}
+	}
+}
+
+func TestConstErrors(t *testing.T) {
+	for _, tt := range []struct{ expr, want string }{
+		{"1 / (2 - 2)", "eval: division by zero"},
+		{"1.0 % 0.0", "eval: division by zero"},
+		{"1 + 1.0", "eval: mismatched kinds integer and float"},
+		{`-"a"`, "eval: operator - not defined on string"},
+		{"1 << -1", "eval: negative shift count -1"},
+		{"true + false", "eval: operator + not defined on boolean"},
+	} {
+		_, err := constOf(t, tt.expr)
+		var e *eval.Error
+		if !errors.As(err, &e) || err.Error() != tt.want {
+			t.Errorf("Const(%s) error = %v, want %s", tt.expr, err, tt.want)
+		}
+	}
+	for _, expr := range []string{"y + 1", "f(1)", "0..10"} {
+		if _, err := constOf(t, expr); err != eval.ErrNotConstant {
+			t.Errorf("Const(%s) error = %v, want ErrNotConstant", expr, err)
+		}
+	}
+}
+
+func TestFits(t *testing.T) {
+	for _, tt := range []struct {
+		expr, typ string
+		want      bool
+	}{
+		{"127", "i8", true},
+		{"128", "i8", false},
+		{"-128", "i8", true},
+		{"-129", "i8", false},
+		{"255", "u8", true},
+		{"-1", "u8", false},
+		{"1 << 64", "u64", false},
+		{"(1 << 64) - 1", "usize", true},
+		{"70000", "f16", false},
+		{"1.0e39", "f32", false},
+		{"1.0e39", "f64", true},
+		{`"s"`, "String", true},
+		{"1", "String", false},
+	} {
+		v, err := constOf(t, tt.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Fits(tt.typ); got != tt.want {
+			t.Errorf("%s fits %s = %v, want %v", tt.expr, tt.typ, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/karol-broda/ferrule/bindings/go/literal"
+)
+
+// Kind is the type of a constant.
+type Kind int
+
+const (
+	Int Kind = iota
+	Float
+	String
+	Char
+	Bool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Int:
+		return "integer"
+	case Float:
+		return "float"
+	case String:
+		return "string"
+	case Char:
+		return "char"
+	case Bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// A Value is the result of a constant expression. Integers are
+// unbounded, as ferrule literals carry no type of their own; Fits tells
+// whether one suits a given type.
+type Value struct {
+	Kind Kind
+	// Int is the value of an integer. It must not be modified.
+	Int   *big.Int
+	Float float64
+	// Text is the value of a string.
+	Text string
+	Char rune
+	Bool bool
+}
+
+// Equal reports whether v and w are the same constant: of the same kind
+// and value. Floats compare as numbers, so that NaN equals nothing.
+func (v Value) Equal(w Value) bool {
+	if v.Kind != w.Kind {
+		return false
+	}
+	switch v.Kind {
+	case Int:
+		return v.Int.Cmp(w.Int) == 0
+	case Float:
+		return v.Float == w.Float
+	case String:
+		return v.Text == w.Text
+	case Char:
+		return v.Char == w.Char
+	}
+	return v.Bool == w.Bool
+}
+
+// intRanges are the bounds of the integer types, in bits and
+// signedness. usize is taken to be 64 bits wide.
+var intRanges = map[string]struct {
+	bits   uint
+	signed bool
+}{
+	"i8": {8, true}, "i16": {16, true}, "i32": {32, true}, "i64": {64, true}, "i128": {128, true},
+	"u8": {8, false}, "u16": {16, false}, "u32": {32, false}, "u64": {64, false}, "u128": {128, false},
+	"usize": {64, false},
+}
+
+// floatMax are the largest finite values of the float types.
+var floatMax = map[string]float64{
+	"f16": 65504,
+	"f32": math.MaxFloat32,
+	"f64": math.MaxFloat64,
+}
+
+// Fits reports whether v can be held by the primitive type named typ:
+// an integer in the range of an integer or float type, a float within
+// the range of a float type, and strings, chars and booleans by String,
+// Char and Bool.
+func (v Value) Fits(typ string) bool {
+	switch v.Kind {
+	case Int:
+		if r, ok := intRanges[typ]; ok {
+			if !r.signed {
+				return v.Int.Sign() >= 0 && uint(v.Int.BitLen()) <= r.bits
+			}
+			min := new(big.Int).Lsh(big.NewInt(-1), r.bits-1)
+			max := new(big.Int).Sub(new(big.Int).Neg(min), big.NewInt(1))
+			return v.Int.Cmp(min) >= 0 && v.Int.Cmp(max) <= 0
+		}
+		if m, ok := floatMax[typ]; ok {
+			f, _ := new(big.Float).SetInt(v.Int).Float64()
+			return math.Abs(f) <= m
+		}
+	case Float:
+		m, ok := floatMax[typ]
+		return ok && !math.IsInf(v.Float, 0) && math.Abs(v.Float) <= m
+	case String:
+		return typ == "String"
+	case Char:
+		return typ == "Char"
+	case Bool:
+		return typ == "Bool"
+	}
+	return false
+}
+
+// String returns v spelled as a ferrule literal.
+func (v Value) String() string {
+	switch v.Kind {
+	case Int:
+		return v.Int.String()
+	case Float:
+		return literal.FormatFloat(v.Float)
+	case String:
+		return literal.Quote(v.Text)
+	case Char:
+		return literal.QuoteChar(v.Char)
+	}
+	return strconv.FormatBool(v.Bool)
+}
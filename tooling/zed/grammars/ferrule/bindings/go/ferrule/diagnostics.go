@@ -0,0 +1,320 @@
+package ferrule
+
+import (
+	"fmt"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Severity ranks a diagnostic. The values match the LSP DiagnosticSeverity
+// enumeration.
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+	SeverityInformation
+	SeverityHint
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Diagnostic is a problem found in a source file.
+type Diagnostic struct {
+	Range    tree_sitter.Range
+	Severity Severity
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Range.StartPoint.Row+1, d.Range.StartPoint.Column+1, d.Severity, d.Message)
+}
+
+// maxSnippet bounds how much of an unexpected token is quoted in a message.
+const maxSnippet = 24
+
+// Diagnostics reports the syntax errors in tree, which must have been parsed
+// from src. ERROR nodes become "unexpected ..." diagnostics and MISSING
+// nodes "expected ..." ones, both phrased in terms of the construct they
+// appear in. Where the parser stopped at a token it could not shift, the
+// diagnostic points at that token and lists the tokens that were expected
+// instead, when there are few; a misspelled keyword is reported as such,
+// with the keyword it is closest to. Errors nested inside an ERROR node are
+// not reported separately.
+func Diagnostics(tree *tree_sitter.Tree, src []byte) []Diagnostic {
+	var out []Diagnostic
+	cursor := tree.Walk()
+	defer cursor.Close()
+
+	for {
+		n := cursor.Node()
+		descend := n.HasError()
+		switch {
+		case n.IsError():
+			out = append(out, errorDiagnostic(n, src))
+			descend = false
+		case n.IsMissing():
+			out = append(out, Diagnostic{Range: n.Range(), Severity: SeverityError, Message: missingMessage(n)})
+		}
+
+		if descend && cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return out
+			}
+		}
+	}
+}
+
+// Diagnostics reports the syntax errors in the tree.
+func (t *Tree) Diagnostics() []Diagnostic {
+	return Diagnostics(t.inner, t.Source())
+}
+
+func errorDiagnostic(n *tree_sitter.Node, src []byte) Diagnostic {
+	if id, keyword := misspelling(n, src); id != nil {
+		return Diagnostic{
+			Range:    id.Range(),
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("unknown '%s', did you mean '%s'?", id.Utf8Text(src), keyword),
+		}
+	}
+	if u := unexpectedToken(n); u != nil {
+		return Diagnostic{Range: u.Range(), Severity: SeverityError, Message: unexpectedTokenMessage(n, u, src)}
+	}
+	return Diagnostic{Range: n.Range(), Severity: SeverityError, Message: unexpectedMessage(n, src)}
+}
+
+func unexpectedMessage(n *tree_sitter.Node, src []byte) string {
+	// a lone well-formed expression wrapped in an error is almost always a
+	// statement missing its terminator.
+	if c := n.NamedChild(0); n.ChildCount() == 1 && c != nil && !c.IsError() && !c.HasError() {
+		if r := n.Parent(); r != nil && r.Kind() == kind.Block {
+			return "expected ';' after " + humanize(c.Kind())
+		}
+	}
+	text := snippet(n, src)
+	where := enclosing(n.Parent())
+	if text == "" {
+		return "unexpected end of input" + where
+	}
+	return fmt.Sprintf("unexpected '%s'%s", text, where)
+}
+
+// snippet returns the first word of the text of n, shortened.
+func snippet(n *tree_sitter.Node, src []byte) string {
+	text := strings.TrimSpace(n.Utf8Text(src))
+	if i := strings.IndexAny(text, " \t\r\n"); i > 0 {
+		text = text[:i]
+	}
+	if len(text) > maxSnippet {
+		text = text[:maxSnippet] + "..."
+	}
+	return text
+}
+
+// unexpectedToken returns the token among the children of the ERROR node n
+// that the parser could not shift, or nil. The children before it are
+// what was parsed of the construct the error is in.
+func unexpectedToken(n *tree_sitter.Node) *tree_sitter.Node {
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if c.ChildCount() == 0 && !c.IsExtra() && !c.IsMissing() && c.ParseState() != 0 && c.NextParseState() == 0 {
+			// the parser also gives up on tokens after the one that broke
+			// the construct; those are valid where they are.
+			if valid(c.ParseState(), c.GrammarId()) {
+				return nil
+			}
+			return c
+		}
+	}
+	return nil
+}
+
+// valid reports whether the token sym may follow in parse state.
+func valid(state, sym uint16) bool {
+	it := Language().LookaheadIterator(state)
+	if it == nil {
+		return false
+	}
+	defer it.Close()
+	for _, s := range it.Iter() {
+		if s == sym {
+			return true
+		}
+	}
+	return false
+}
+
+func unexpectedTokenMessage(n, u *tree_sitter.Node, src []byte) string {
+	text := snippet(u, src)
+	if list, end := listContext(n, u); list != "" {
+		return fmt.Sprintf("unexpected '%s' while parsing %s, expected ',' or '%s'", text, list, end)
+	}
+	msg := fmt.Sprintf("unexpected '%s'%s", text, enclosing(n.Parent()))
+	if tokens := expectedTokens(u.ParseState()); tokens != "" {
+		msg += ", expected " + tokens
+	}
+	return msg
+}
+
+// lists maps the kinds of list elements to the lists they make up and the
+// tokens closing those.
+var lists = map[string][2]string{
+	kind.Parameter:     {"parameter list", ")"},
+	kind.RecordField:   {"record type", "}"},
+	kind.TypeParameter: {"type parameter list", ">"},
+}
+
+// containers maps the kinds of nodes holding comma-separated lists whose
+// elements have no kind of their own to the lists and their closing tokens.
+var containers = map[string][2]string{
+	kind.CallExpression:  {"argument list", ")"},
+	kind.ParameterList:   {"parameter list", ")"},
+	kind.ArrayExpression: {"array", "]"},
+	kind.RecordType:      {"record type", "}"},
+	kind.RecordBody:      {"record type", "}"},
+	kind.TypeParameters:  {"type parameter list", ">"},
+	kind.EffectsClause:   {"effects clause", "]"},
+}
+
+// listContext returns the list that u comes after an element of, within or
+// before the ERROR node n, and the token closing that list.
+func listContext(n, u *tree_sitter.Node) (string, string) {
+	var prev *tree_sitter.Node
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if c.Id() == u.Id() {
+			break
+		}
+		if !c.IsExtra() {
+			prev = c
+		}
+	}
+	if prev != nil {
+		if l, ok := lists[prev.Kind()]; ok {
+			return l[0], l[1]
+		}
+		return "", ""
+	}
+	before := n.PrevSibling()
+	for before != nil && before.IsExtra() {
+		before = before.PrevSibling()
+	}
+	parent := n.Parent()
+	if parent == nil || before == nil || !before.IsNamed() || parent.Child(0).Id() == before.Id() {
+		return "", ""
+	}
+	if l, ok := containers[parent.Kind()]; ok {
+		return l[0], l[1]
+	}
+	return "", ""
+}
+
+// maxExpected bounds how many expected tokens a message lists.
+const maxExpected = 4
+
+// expectedTokens lists the tokens valid in the parse state, or returns ""
+// if there are too many to be helpful.
+func expectedTokens(state uint16) string {
+	lang := Language()
+	it := lang.LookaheadIterator(state)
+	if it == nil {
+		return ""
+	}
+	defer it.Close()
+	var tokens []string
+	for _, sym := range it.Iter() {
+		if lang.NodeKindIsNamed(sym) || !lang.NodeKindIsVisible(sym) {
+			continue
+		}
+		tokens = append(tokens, "'"+lang.NodeKindForId(sym)+"'")
+	}
+	if len(tokens) == 0 || len(tokens) > maxExpected {
+		return ""
+	}
+	if len(tokens) == 1 {
+		return tokens[0]
+	}
+	return strings.Join(tokens[:len(tokens)-1], ", ") + " or " + tokens[len(tokens)-1]
+}
+
+func missingMessage(n *tree_sitter.Node) string {
+	what := describeKind(n)
+	parent := n.Parent()
+	switch n.Kind() {
+	case ";":
+		if parent != nil && parent.Kind() != kind.SourceFile {
+			return fmt.Sprintf("expected %s after %s", what, humanize(parent.Kind()))
+		}
+	case ")", "]", "}", ">":
+		if parent != nil {
+			return fmt.Sprintf("expected %s to close %s", what, closes(parent.Kind()))
+		}
+	}
+	return "expected " + what + enclosing(parent)
+}
+
+// enclosing names the construct enclosing a diagnostic, e.g. " in call
+// expression".
+func enclosing(parent *tree_sitter.Node) string {
+	if parent == nil || parent.IsError() {
+		return ""
+	}
+	if parent.Kind() == kind.SourceFile {
+		return " at top level"
+	}
+	return " in " + humanize(parent.Kind())
+}
+
+func describeKind(n *tree_sitter.Node) string {
+	if n.IsNamed() {
+		return article(humanize(n.Kind()))
+	}
+	return "'" + n.Kind() + "'"
+}
+
+func humanize(k string) string {
+	return strings.ReplaceAll(k, "_", " ")
+}
+
+// closes names what a closing bracket terminates inside the given rule.
+func closes(k string) string {
+	switch k {
+	case kind.CallExpression:
+		return "argument list"
+	case kind.IndexExpression:
+		return "index"
+	case kind.TypeParameters, kind.GenericType:
+		return "type parameter list"
+	}
+	return humanize(k)
+}
+
+func article(noun string) string {
+	if noun == "" {
+		return noun
+	}
+	switch noun[0] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return "an " + noun
+	}
+	return "a " + noun
+}
@@ -0,0 +1,54 @@
+package ferrule_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestDiagnostics(t *testing.T) {
+	cases := []struct {
+		src, want string
+	}{
+		{"function f() -> i32 { return add(1, 2; }", "1:38: error: expected ')' to close argument list"},
+		{"function f( -> i32 { }", "1:12: error: expected ')' to close parameter list"},
+		{"function f() -> i32 { const x = 1 return x; }", "1:34: error: expected ';' after const declaration"},
+		{"function f() -> i32 { foo(1) }", "1:23: error: expected ';' after call expression"},
+		{"function f() -> i32 { @@ }", "1:23: error: unexpected '@@' in block"},
+		{"function f(x: i32 ] -> i32 { }", "1:19: error: unexpected ']' while parsing parameter list, expected ',' or ')'"},
+		{"function f() -> i32 { foo(1 2); }", "1:29: error: unexpected '2' while parsing argument list, expected ',' or ')'"},
+		{"function f(x i32) -> i32 { }", "1:14: error: unexpected 'i32' in parameter list, expected ':'"},
+		{"fucntion f() -> i32 { }", "1:1: error: unknown 'fucntion', did you mean 'function'?"},
+		{"pub fucntion f() -> i32 { }", "1:5: error: unknown 'fucntion', did you mean 'function'?"},
+		{"improt std.io;", "1:1: error: unknown 'improt', did you mean 'import'?"},
+		{"function f() -> i32 { retrun x; }", "1:23: error: unknown 'retrun', did you mean 'return'?"},
+		{"function f() -> i32 { cosnt x = 1; }", "1:23: error: unknown 'cosnt', did you mean 'const'?"},
+	}
+	for _, c := range cases {
+		tree, err := ferrule.Parse(context.Background(), []byte(c.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diags := tree.Diagnostics()
+		tree.Close()
+		if len(diags) != 1 {
+			t.Errorf("%q: got %d diagnostics %v, want 1", c.src, len(diags), diags)
+			continue
+		}
+		if got := diags[0].String(); got != c.want {
+			t.Errorf("%q: got %q, want %q", c.src, got, c.want)
+		}
+	}
+}
+
+func TestDiagnosticsClean(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(hello))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if diags := ferrule.Diagnostics(tree.Raw(), tree.Source()); len(diags) != 0 {
+		t.Errorf("unexpected diagnostics %v", diags)
+	}
+}
@@ -0,0 +1,150 @@
+// Package ferrule is the high-level entry point for parsing ferrule source
+// from Go.
+//
+// It owns the parser lifecycle so callers do not have to repeat the
+// NewParser/SetLanguage/Parse sequence, and it honors context deadlines so
+// pathological inputs cannot stall a caller indefinitely. ParseWithLimits
+// goes further for input that is not trusted, bounding its size and the
+// size, nesting and parse time of its tree.
+package ferrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+)
+
+// ErrNoTree is returned when the underlying parser gives up without
+// producing a tree for a reason other than cancellation.
+var ErrNoTree = errors.New("ferrule: parser returned no tree")
+
+var (
+	languageOnce sync.Once
+	language     *tree_sitter.Language
+)
+
+// Language returns the ferrule tree-sitter language. The value is shared
+// and safe for concurrent use.
+func Language() *tree_sitter.Language {
+	languageOnce.Do(func() {
+		language = tree_sitter.NewLanguage(tree_sitter_ferrule.Language())
+	})
+	return language
+}
+
+// ABIError reports that the grammar was generated for a tree-sitter ABI
+// the linked tree-sitter runtime cannot load.
+type ABIError struct {
+	// Grammar is the ABI version of the grammar.
+	Grammar uint32
+	// Min and Max bound the ABI versions the runtime supports.
+	Min, Max uint32
+}
+
+func (e *ABIError) Error() string {
+	return fmt.Sprintf("ferrule: grammar %s uses tree-sitter ABI %d, but the tree-sitter runtime supports ABI %d to %d; regenerate the parser or update go-tree-sitter",
+		tree_sitter_ferrule.Version(), e.Grammar, e.Min, e.Max)
+}
+
+// abiErr is checked once, at initialization, so that a mismatched parser
+// fails loudly before it is used.
+var abiErr = checkABI(tree_sitter_ferrule.GrammarABI())
+
+func checkABI(abi uint32) error {
+	if abi < tree_sitter.MIN_COMPATIBLE_LANGUAGE_VERSION || abi > tree_sitter.LANGUAGE_VERSION {
+		return &ABIError{Grammar: abi, Min: tree_sitter.MIN_COMPATIBLE_LANGUAGE_VERSION, Max: tree_sitter.LANGUAGE_VERSION}
+	}
+	return nil
+}
+
+// CheckABI returns an *ABIError if the grammar cannot be loaded by the
+// tree-sitter runtime, in which case NewParser and all parsing functions
+// fail with that error.
+func CheckABI() error { return abiErr }
+
+// Parser parses ferrule source. A Parser is not safe for concurrent use.
+type Parser struct {
+	inner *tree_sitter.Parser
+}
+
+// NewParser returns a parser configured for the ferrule grammar.
+func NewParser() (*Parser, error) {
+	if abiErr != nil {
+		return nil, abiErr
+	}
+	inner := tree_sitter.NewParser()
+	if err := inner.SetLanguage(Language()); err != nil {
+		inner.Close()
+		return nil, err
+	}
+	return &Parser{inner: inner}, nil
+}
+
+// Parse parses src, reusing old for an incremental parse when it is non-nil.
+// old must already have been edited to match src.
+//
+// If ctx is cancelled or its deadline passes while parsing, Parse stops and
+// returns ctx.Err().
+func (p *Parser) Parse(ctx context.Context, src []byte, old *Tree) (*Tree, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	length := len(src)
+	read := func(i int, _ tree_sitter.Point) []byte {
+		if i < length {
+			return src[i:]
+		}
+		return []byte{}
+	}
+	inner, err := p.parse(ctx, read, int64(length), old)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{inner: inner, source: src}, nil
+}
+
+// parse parses the size bytes of input read returns in chunks.
+func (p *Parser) parse(ctx context.Context, read func(int, tree_sitter.Point) []byte, size int64, old *Tree) (*tree_sitter.Tree, error) {
+	var oldTree *tree_sitter.Tree
+	if old != nil {
+		oldTree = old.inner
+	}
+	return observe(ctx, size, old != nil, func() (*tree_sitter.Tree, error) {
+		return p.run(ctx, read, oldTree)
+	})
+}
+
+// run parses the input read returns, reusing oldTree if it is not nil.
+func (p *Parser) run(ctx context.Context, read func(int, tree_sitter.Point) []byte, oldTree *tree_sitter.Tree) (*tree_sitter.Tree, error) {
+	options := &tree_sitter.ParseOptions{
+		ProgressCallback: func(tree_sitter.ParseState) bool {
+			return ctx.Err() != nil
+		},
+	}
+	inner := p.inner.ParseWithOptions(read, oldTree, options)
+	if inner == nil {
+		// a cancelled parse leaves state behind that the next call would
+		// otherwise try to resume.
+		p.inner.Reset()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoTree
+	}
+	return inner, nil
+}
+
+// Close releases the parser.
+func (p *Parser) Close() {
+	p.inner.Close()
+}
+
+// Parse parses src with a parser taken from a process-wide pool.
+func Parse(ctx context.Context, src []byte) (*Tree, error) {
+	return defaultPool.Parse(ctx, src, nil)
+}
@@ -0,0 +1,226 @@
+package ferrule_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+const hello = `package example.hello;
+
+function add(x: i32, y: i32) -> i32 {
+  return x + y;
+}
+`
+
+func TestParse(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(hello))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	if tree.HasError() {
+		t.Errorf("unexpected syntax error: %s", tree.RootNode().ToSexp())
+	}
+	fns := tree.Root().FunctionDeclarations()
+	if len(fns) != 1 || fns[0].Name().Text(tree.Source()) != "add" {
+		t.Errorf("unexpected functions in %s", tree.RootNode().ToSexp())
+	}
+	offset := uint(strings.Index(hello, "x + y"))
+	if n := tree.NamedNodeAt(offset); tree.Text(n) != "x" {
+		t.Errorf("NamedNodeAt(%d) = %q", offset, tree.Text(n))
+	}
+}
+
+func TestParseCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ferrule.Parse(ctx, []byte(hello)); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
+
+// cancelAfter is a context whose Err starts failing after it has been
+// consulted a given number of times, which lets the test cancel a parse
+// while it is in progress.
+type cancelAfter struct {
+	context.Context
+	calls, limit int
+}
+
+func (c *cancelAfter) Err() error {
+	c.calls++
+	if c.calls > c.limit {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestParseCancelledMidway(t *testing.T) {
+	src := []byte(strings.Repeat(hello[len("package example.hello;\n"):], 2000))
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx := &cancelAfter{Context: context.Background(), limit: 2}
+	if _, err := p.Parse(ctx, src, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+
+	// the parser must be usable again after a cancelled parse.
+	tree, err := p.Parse(context.Background(), []byte(hello), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if tree.HasError() {
+		t.Errorf("unexpected syntax error after reuse: %s", tree.RootNode().ToSexp())
+	}
+}
+
+// chunkReader is a reader that remembers the largest read asked of it.
+type chunkReader struct {
+	*strings.Reader
+	largest int
+}
+
+func (r *chunkReader) ReadAt(p []byte, off int64) (int, error) {
+	r.largest = max(r.largest, len(p))
+	return r.Reader.ReadAt(p, off)
+}
+
+func TestParseReader(t *testing.T) {
+	// several chunks, with multi-byte characters across chunk boundaries.
+	body := hello[len("package example.hello;\n"):] + "// é😀日本\n"
+	src := "package example.hello;\n" + strings.Repeat(body, 2500)
+	r := &chunkReader{Reader: strings.NewReader(src)}
+	tree, err := ferrule.ParseReader(context.Background(), r, int64(len(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if r.largest == 0 || r.largest >= len(src) {
+		t.Errorf("largest read is %d bytes of %d", r.largest, len(src))
+	}
+
+	want, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer want.Close()
+	if tree.RootNode().ToSexp() != want.RootNode().ToSexp() {
+		t.Error("trees differ from a parse of the whole input")
+	}
+	fns := tree.Root().FunctionDeclarations()
+	if len(fns) != 2500 {
+		t.Fatalf("got %d functions, want 2500", len(fns))
+	}
+	if got := tree.Text(fns[1000].Raw().ChildByFieldName("name")); got != "add" {
+		t.Errorf("Text of name = %q before loading", got)
+	}
+	if err := tree.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if string(tree.Source()) != src {
+		t.Error("Source differs from the input")
+	}
+}
+
+func TestParseReaderShort(t *testing.T) {
+	r := strings.NewReader(hello)
+	if _, err := ferrule.ParseReader(context.Background(), r, int64(len(hello))+10); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestCheckABI(t *testing.T) {
+	if err := ferrule.CheckABI(); err != nil {
+		t.Fatal(err)
+	}
+	err := &ferrule.ABIError{Grammar: 16, Min: 13, Max: 15}
+	if msg := err.Error(); !strings.Contains(msg, "ABI 16") || !strings.Contains(msg, "13 to 15") {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestParseWithStats(t *testing.T) {
+	tree, stats, err := ferrule.ParseWithStats(context.Background(), []byte(hello))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if stats.Bytes != int64(len(hello)) || stats.Incremental || stats.Duration <= 0 || stats.BytesPerSecond() <= 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.Nodes != int(tree.RootNode().DescendantCount()) {
+		t.Errorf("Nodes = %d, want %d", stats.Nodes, tree.RootNode().DescendantCount())
+	}
+	// source_file > function_declaration > block > return_statement >
+	// binary_expression > identifier.
+	if stats.MaxDepth != 6 || stats.Errors != 0 || stats.Missing != 0 {
+		t.Errorf("MaxDepth, Errors, Missing = %d, %d, %d, want 6, 0, 0", stats.MaxDepth, stats.Errors, stats.Missing)
+	}
+
+	tree, stats, err = ferrule.ParseWithStats(context.Background(), []byte("const a = 1 2;\nfunction f() -> i32 { return 1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if stats.Errors != 1 || stats.Missing != 1 {
+		t.Errorf("Errors, Missing = %d, %d, want 1, 1 in %s", stats.Errors, stats.Missing, tree.RootNode().ToSexp())
+	}
+}
+
+type key struct{}
+
+// recorder is an Observer keeping what it is told.
+type recorder struct {
+	starts int
+	stats  []ferrule.Stats
+	errs   []error
+}
+
+func (r *recorder) ParseStart(ctx context.Context, size int64, incremental bool) context.Context {
+	r.starts++
+	return context.WithValue(ctx, key{}, r.starts)
+}
+
+func (r *recorder) ParseEnd(ctx context.Context, stats ferrule.Stats, err error) {
+	if ctx.Value(key{}) != r.starts {
+		panic("ParseEnd got a context ParseStart did not return")
+	}
+	r.stats = append(r.stats, stats)
+	r.errs = append(r.errs, err)
+}
+
+func TestObserver(t *testing.T) {
+	r := &recorder{}
+	ferrule.SetObserver(r)
+	defer ferrule.SetObserver(nil)
+
+	tree, err := ferrule.Parse(context.Background(), []byte(hello))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ferrule.Parse(ctx, []byte(hello))
+	if len(r.stats) != 1 || r.stats[0].Bytes != int64(len(hello)) || r.stats[0].Nodes == 0 || r.errs[0] != nil {
+		t.Errorf("observed %+v, %v", r.stats, r.errs)
+	}
+
+	ferrule.SetObserver(nil)
+	tree, _ = ferrule.Parse(context.Background(), []byte(hello))
+	tree.Close()
+	if r.starts != 1 {
+		t.Errorf("%d parses observed after the observer was removed", r.starts-1)
+	}
+}
@@ -0,0 +1,159 @@
+package ferrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Limits bound what a parse may take, so that a service parsing input it
+// does not trust cannot be made to spend unbounded time, memory or stack
+// on it. A zero field sets no limit.
+type Limits struct {
+	// Bytes is the size of the largest input parsed in full.
+	Bytes int64
+	// Nodes is the largest number of nodes of a tree, anonymous ones
+	// included.
+	Nodes int
+	// Depth is the deepest nesting of a node, the root being at depth 1.
+	// Code recursing over a tree needs stack in proportion to it.
+	Depth int
+	// Duration is the longest a parse may run.
+	Duration time.Duration
+}
+
+// ErrLimitExceeded is matched, with errors.Is, by the *LimitError of a
+// parse that went over its Limits.
+var ErrLimitExceeded = errors.New("ferrule: parse limit exceeded")
+
+// LimitError reports the limit a parse went over.
+type LimitError struct {
+	// Limit is the name of the field of Limits that was exceeded, such as
+	// "Nodes".
+	Limit string
+	// Max is its value, in nanoseconds for Duration.
+	Max int64
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "Bytes":
+		return fmt.Sprintf("ferrule: input larger than the limit of %d bytes", e.Max)
+	case "Nodes":
+		return fmt.Sprintf("ferrule: tree has more than the limit of %d nodes", e.Max)
+	case "Depth":
+		return fmt.Sprintf("ferrule: tree nested deeper than the limit of %d levels", e.Max)
+	case "Duration":
+		return fmt.Sprintf("ferrule: parse took longer than the limit of %v", time.Duration(e.Max))
+	}
+	return fmt.Sprintf("ferrule: parse exceeds the %s limit of %d", e.Limit, e.Max)
+}
+
+// Is reports whether target is ErrLimitExceeded.
+func (e *LimitError) Is(target error) bool { return target == ErrLimitExceeded }
+
+// errDuration is the cause of the context of a parse that ran out of its
+// Duration, to tell it from a deadline of the caller.
+var errDuration = errors.New("ferrule: parse duration limit")
+
+// ParseWithLimits is like Parse, within limits. A parse going over one of
+// them fails with a *LimitError, returned along with what can be saved of
+// the tree, which the caller must close when not nil:
+//
+//   - for input over Bytes, the tree of its first Bytes bytes, cut at a
+//     character boundary, as far as it is within the other limits;
+//   - for a tree over Nodes or Depth, the whole tree, which code that
+//     recurses over it or copies it should leave alone;
+//   - for a parse over Duration, nothing, as the parser keeps no tree of
+//     a parse it stops.
+//
+// The nodes of a tree are counted only as far as needed to tell that it
+// is within its limits.
+func (p *Parser) ParseWithLimits(ctx context.Context, src []byte, old *Tree, limits Limits) (*Tree, error) {
+	if limits.Bytes > 0 && int64(len(src)) > limits.Bytes {
+		cut := int(limits.Bytes)
+		for cut > 0 && !utf8.RuneStart(src[cut]) {
+			cut--
+		}
+		exceeded := &LimitError{Limit: "Bytes", Max: limits.Bytes}
+		rest := limits
+		rest.Bytes = 0
+		// old was edited to match all of src, not the part kept.
+		tree, err := p.ParseWithLimits(ctx, src[:cut], nil, rest)
+		switch {
+		case errors.Is(err, ErrLimitExceeded):
+			// a part over the other limits is not worth keeping either.
+			if tree != nil {
+				tree.Close()
+			}
+			return nil, exceeded
+		case err != nil:
+			return nil, err
+		}
+		return tree, exceeded
+	}
+	if limits.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, limits.Duration, errDuration)
+		defer cancel()
+	}
+	tree, err := p.Parse(ctx, src, old)
+	if err != nil {
+		if context.Cause(ctx) == errDuration {
+			err = &LimitError{Limit: "Duration", Max: int64(limits.Duration)}
+		}
+		return nil, err
+	}
+	return tree, within(tree.inner, limits)
+}
+
+// ParseWithLimits parses src with a parser borrowed from the pool. See
+// Parser.ParseWithLimits.
+func (pp *ParserPool) ParseWithLimits(ctx context.Context, src []byte, old *Tree, limits Limits) (*Tree, error) {
+	p, err := pp.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer pp.Put(p)
+	return p.ParseWithLimits(ctx, src, old, limits)
+}
+
+// ParseWithLimits parses src with a parser taken from a process-wide pool.
+// See Parser.ParseWithLimits.
+func ParseWithLimits(ctx context.Context, src []byte, limits Limits) (*Tree, error) {
+	return defaultPool.ParseWithLimits(ctx, src, nil, limits)
+}
+
+// within returns a *LimitError if tree has more nodes or deeper nesting
+// than limits allow, walking it no further than it takes to tell.
+func within(tree *tree_sitter.Tree, limits Limits) error {
+	if limits.Nodes <= 0 && limits.Depth <= 0 {
+		return nil
+	}
+	cursor := tree.Walk()
+	defer cursor.Close()
+	nodes, depth := 0, 1
+	for {
+		nodes++
+		switch {
+		case limits.Nodes > 0 && nodes > limits.Nodes:
+			return &LimitError{Limit: "Nodes", Max: int64(limits.Nodes)}
+		case limits.Depth > 0 && depth > limits.Depth:
+			return &LimitError{Limit: "Depth", Max: int64(limits.Depth)}
+		}
+		if cursor.GotoFirstChild() {
+			depth++
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return nil
+			}
+			depth--
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ferrule_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestParseWithLimits(t *testing.T) {
+	ctx := context.Background()
+	if tree, err := ferrule.ParseWithLimits(ctx, []byte(hello), ferrule.Limits{Bytes: 1 << 20, Nodes: 1000, Depth: 50}); err != nil {
+		t.Errorf("within limits: %v", err)
+	} else {
+		tree.Close()
+	}
+
+	tests := []struct {
+		name   string
+		src    string
+		limits ferrule.Limits
+		limit  string
+		tree   bool
+	}{
+		{"bytes", hello, ferrule.Limits{Bytes: int64(strings.Index(hello, "function"))}, "Bytes", true},
+		{"bytes and nodes", hello, ferrule.Limits{Bytes: int64(len(hello) - 1), Nodes: 5}, "Bytes", false},
+		{"nodes", hello, ferrule.Limits{Nodes: 10}, "Nodes", true},
+		{"depth", "const x = " + strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100) + ";\n", ferrule.Limits{Depth: 20}, "Depth", true},
+		{"duration", strings.Repeat(hello[len("package example.hello;\n"):], 2000), ferrule.Limits{Duration: time.Nanosecond}, "Duration", false},
+	}
+	for _, tt := range tests {
+		tree, err := ferrule.ParseWithLimits(ctx, []byte(tt.src), tt.limits)
+		if tree != nil {
+			defer tree.Close()
+		}
+		var le *ferrule.LimitError
+		if !errors.Is(err, ferrule.ErrLimitExceeded) || !errors.As(err, &le) || le.Limit != tt.limit {
+			t.Errorf("%s: got %v, want a %s limit error", tt.name, err, tt.limit)
+		}
+		if (tree != nil) != tt.tree {
+			t.Errorf("%s: got tree %v, want %v", tt.name, tree != nil, tt.tree)
+		}
+	}
+}
+
+func TestParseWithLimitsTruncated(t *testing.T) {
+	src := []byte("const s = \"héllo\";\n")
+	cut := strings.Index(string(src), "é") + 1
+	tree, err := ferrule.ParseWithLimits(context.Background(), src, ferrule.Limits{Bytes: int64(cut)})
+	if !errors.Is(err, ferrule.ErrLimitExceeded) {
+		t.Fatalf("got %v, want ErrLimitExceeded", err)
+	}
+	defer tree.Close()
+	// the cut falls inside é and moves back before it.
+	if got := string(tree.Source()); got != string(src[:cut-1]) {
+		t.Errorf("parsed %q, want %q", got, src[:cut-1])
+	}
+}
+
+func TestParseWithLimitsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := ferrule.ParseWithLimits(ctx, []byte(hello), ferrule.Limits{Duration: time.Hour})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ferrule.ErrLimitExceeded) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
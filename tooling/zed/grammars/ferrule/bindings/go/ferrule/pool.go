@@ -0,0 +1,76 @@
+package ferrule
+
+import (
+	"context"
+	"runtime"
+)
+
+// ParserPool keeps idle parsers around so concurrent workloads can parse
+// many files without constructing a parser per file or sharing one parser
+// across goroutines.
+//
+// Unlike sync.Pool it never drops parsers silently: tree-sitter parsers own
+// C memory that the garbage collector cannot reclaim, so parsers beyond the
+// pool's capacity are closed when they are returned.
+//
+// A ParserPool is safe for concurrent use.
+type ParserPool struct {
+	idle chan *Parser
+}
+
+// NewParserPool returns a pool that retains up to size idle parsers. A size
+// of zero or less uses GOMAXPROCS.
+func NewParserPool(size int) *ParserPool {
+	if size <= 0 {
+		size = runtime.GOMAXPROCS(0)
+	}
+	return &ParserPool{idle: make(chan *Parser, size)}
+}
+
+// Get returns an idle parser, creating one when none is available.
+func (pp *ParserPool) Get() (*Parser, error) {
+	select {
+	case p := <-pp.idle:
+		return p, nil
+	default:
+		return NewParser()
+	}
+}
+
+// Put returns p to the pool. p must not be used afterwards.
+func (pp *ParserPool) Put(p *Parser) {
+	if p == nil {
+		return
+	}
+	p.inner.Reset()
+	select {
+	case pp.idle <- p:
+	default:
+		p.Close()
+	}
+}
+
+// Parse parses src with a parser borrowed from the pool.
+func (pp *ParserPool) Parse(ctx context.Context, src []byte, old *Tree) (*Tree, error) {
+	p, err := pp.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer pp.Put(p)
+	return p.Parse(ctx, src, old)
+}
+
+// Close releases the idle parsers. Parsers that are checked out at the time
+// may still be put back afterwards.
+func (pp *ParserPool) Close() {
+	for {
+		select {
+		case p := <-pp.idle:
+			p.Close()
+		default:
+			return
+		}
+	}
+}
+
+var defaultPool = NewParserPool(0)
@@ -0,0 +1,59 @@
+package ferrule_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+func TestParserPoolConcurrent(t *testing.T) {
+	pool := ferrule.NewParserPool(2)
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := []byte(fmt.Sprintf("function f%d() -> i32 { return %d; }\n", i, i))
+			tree, err := pool.Parse(context.Background(), src, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer tree.Close()
+			want := fmt.Sprintf("f%d", i)
+			if got := tree.Root().FunctionDeclarations()[0].Name().Text(src); got != want {
+				errs <- fmt.Errorf("got function %q, want %q", got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestParserPoolReuse(t *testing.T) {
+	pool := ferrule.NewParserPool(1)
+	defer pool.Close()
+
+	p, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Put(p)
+	again, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != p {
+		t.Error("expected the idle parser to be reused")
+	}
+	pool.Put(again)
+}
@@ -0,0 +1,125 @@
+package ferrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// chunkSize is the size of the pieces ParseReader reads its input in.
+const chunkSize = 64 << 10
+
+// ParseReader parses the first size bytes of r, read in chunks, without
+// holding the whole input in memory. It suits large files, opened with
+// os.Open or memory mapped. The parser still keeps a copy of each chunk
+// until the parse ends, but the tree returned does not: its Text method
+// reads the text of a node from r, and Source reads all of it on first use.
+// r must not change while the tree is in use.
+//
+// A stream that is not an io.ReaderAt has to be read into memory and
+// passed to Parse, since the parser revisits earlier input.
+//
+// Errors from r end the parse and are returned.
+func (p *Parser) ParseReader(ctx context.Context, r io.ReaderAt, size int64, old *Tree) (*Tree, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, min(size, chunkSize))
+	var readErr error
+	read := func(i int, _ tree_sitter.Point) []byte {
+		off := int64(i)
+		if readErr != nil || off >= size {
+			return []byte{}
+		}
+		want := min(int64(len(buf)), size-off)
+		n, err := r.ReadAt(buf[:want], off)
+		if int64(n) < want {
+			if err == nil || errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			readErr = fmt.Errorf("ferrule: reading at offset %d: %w", off, err)
+			return []byte{}
+		}
+		return buf[:n]
+	}
+	inner, err := p.parse(ctx, read, size, old)
+	if readErr != nil {
+		if inner != nil {
+			inner.Close()
+		}
+		return nil, readErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{inner: inner, input: &input{r: r, size: size}}, nil
+}
+
+// ParseReader parses the first size bytes of r with a parser borrowed from
+// the pool. See Parser.ParseReader.
+func (pp *ParserPool) ParseReader(ctx context.Context, r io.ReaderAt, size int64, old *Tree) (*Tree, error) {
+	p, err := pp.Get()
+	if err != nil {
+		return nil, err
+	}
+	defer pp.Put(p)
+	return p.ParseReader(ctx, r, size, old)
+}
+
+// ParseReader parses the first size bytes of r with a parser taken from a
+// process-wide pool. See Parser.ParseReader.
+func ParseReader(ctx context.Context, r io.ReaderAt, size int64) (*Tree, error) {
+	return defaultPool.ParseReader(ctx, r, size, nil)
+}
+
+// input is the source of a tree parsed from a reader.
+type input struct {
+	r    io.ReaderAt
+	size int64
+
+	mu     sync.Mutex
+	done   bool
+	source []byte
+	err    error
+}
+
+// load reads the whole input, the first time it is called.
+func (in *input) load() ([]byte, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.done {
+		return in.source, in.err
+	}
+	in.done = true
+	src := make([]byte, in.size)
+	if n, err := in.r.ReadAt(src, 0); n < len(src) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		in.err = fmt.Errorf("ferrule: reading source: %w", err)
+		return nil, in.err
+	}
+	in.source = src
+	return src, nil
+}
+
+// loaded returns the input if it has been read.
+func (in *input) loaded() []byte {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.source
+}
+
+// text reads the bytes between start and end.
+func (in *input) text(start, end uint) (string, error) {
+	buf := make([]byte, end-start)
+	n, err := in.r.ReadAt(buf, int64(start))
+	if n == len(buf) {
+		return string(buf), nil
+	}
+	return "", fmt.Errorf("ferrule: reading at offset %d: %w", start, err)
+}
@@ -0,0 +1,154 @@
+package ferrule
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Stats describes one parse.
+type Stats struct {
+	// Bytes is the size of the input.
+	Bytes int64
+	// Incremental is set when an old tree was reused.
+	Incremental bool
+	// Duration is the time spent parsing.
+	Duration time.Duration
+	// Nodes counts the nodes of the tree, anonymous ones included.
+	Nodes int
+	// MaxDepth is the depth of the deepest node, the root being at depth 1.
+	MaxDepth int
+	// Errors counts the ERROR nodes, and Missing the missing nodes
+	// inserted by error recovery.
+	Errors, Missing int
+}
+
+// BytesPerSecond returns the parse throughput.
+func (s Stats) BytesPerSecond() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// measure adds the counts of the nodes of tree to s.
+func (s *Stats) measure(tree *tree_sitter.Tree) {
+	cursor := tree.Walk()
+	defer cursor.Close()
+	depth := 1
+	for {
+		n := cursor.Node()
+		s.Nodes++
+		s.MaxDepth = max(s.MaxDepth, depth)
+		switch {
+		case n.IsError():
+			s.Errors++
+		case n.IsMissing():
+			s.Missing++
+		}
+		if cursor.GotoFirstChild() {
+			depth++
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return
+			}
+			depth--
+		}
+	}
+}
+
+// ParseWithStats is like Parse but also describes the parse. Counting the
+// nodes walks the whole tree, which takes a fraction of the time the parse
+// took.
+func (p *Parser) ParseWithStats(ctx context.Context, src []byte, old *Tree) (*Tree, Stats, error) {
+	stats := Stats{Bytes: int64(len(src)), Incremental: old != nil}
+	start := time.Now()
+	tree, err := p.Parse(ctx, src, old)
+	stats.Duration = time.Since(start)
+	if err != nil {
+		return nil, stats, err
+	}
+	stats.measure(tree.inner)
+	return tree, stats, nil
+}
+
+// ParseWithStats parses src with a parser borrowed from the pool. See
+// Parser.ParseWithStats.
+func (pp *ParserPool) ParseWithStats(ctx context.Context, src []byte, old *Tree) (*Tree, Stats, error) {
+	p, err := pp.Get()
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	defer pp.Put(p)
+	return p.ParseWithStats(ctx, src, old)
+}
+
+// ParseWithStats parses src with a parser taken from a process-wide pool.
+// See Parser.ParseWithStats.
+func ParseWithStats(ctx context.Context, src []byte) (*Tree, Stats, error) {
+	return defaultPool.ParseWithStats(ctx, src, nil)
+}
+
+// An Observer is told about every parse of the package, for instance to
+// record it as an OpenTelemetry span:
+//
+//	func (o otelObserver) ParseStart(ctx context.Context, size int64, incremental bool) context.Context {
+//		ctx, _ = o.tracer.Start(ctx, "ferrule.Parse", trace.WithAttributes(attribute.Int64("bytes", size)))
+//		return ctx
+//	}
+//
+//	func (o otelObserver) ParseEnd(ctx context.Context, stats ferrule.Stats, err error) {
+//		span := trace.SpanFromContext(ctx)
+//		span.SetAttributes(attribute.Int("nodes", stats.Nodes), attribute.Int("errors", stats.Errors))
+//		if err != nil {
+//			span.RecordError(err)
+//		}
+//		span.End()
+//	}
+//
+// Its methods are called from the goroutine parsing, and may be called
+// from several at once.
+type Observer interface {
+	// ParseStart is called before a parse of size bytes. The context it
+	// returns is passed to ParseEnd; parsing itself still uses the
+	// caller's.
+	ParseStart(ctx context.Context, size int64, incremental bool) context.Context
+	// ParseEnd is called after the parse with its statistics and the
+	// error it failed with, if any. The node counts are zero if it failed.
+	ParseEnd(ctx context.Context, stats Stats, err error)
+}
+
+var observer atomic.Pointer[Observer]
+
+// SetObserver makes o the observer of all parses that start afterwards,
+// replacing any observer set before. A nil o removes it. While an observer
+// is set, every parse walks its tree to count the nodes.
+func SetObserver(o Observer) {
+	if o == nil {
+		observer.Store(nil)
+		return
+	}
+	observer.Store(&o)
+}
+
+// observe runs parse under the current observer, if any.
+func observe(ctx context.Context, size int64, incremental bool, parse func() (*tree_sitter.Tree, error)) (*tree_sitter.Tree, error) {
+	o := observer.Load()
+	if o == nil {
+		return parse()
+	}
+	octx := (*o).ParseStart(ctx, size, incremental)
+	stats := Stats{Bytes: size, Incremental: incremental}
+	start := time.Now()
+	tree, err := parse()
+	stats.Duration = time.Since(start)
+	if err == nil {
+		stats.measure(tree)
+	}
+	(*o).ParseEnd(octx, stats, err)
+	return tree, err
+}
@@ -0,0 +1,96 @@
+package ferrule
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Keywords that may start a top-level declaration or a statement, the
+// candidates for the correction of a misspelled keyword.
+var (
+	declarationKeywords = []string{"capability", "component", "const", "domain", "error", "function", "import", "package", "type", "use", "var"}
+	statementKeywords   = []string{"break", "const", "continue", "defer", "for", "if", "match", "return", "var", "while"}
+)
+
+// misspelling finds a misspelled keyword that caused the ERROR node n: an
+// identifier at the start of a top-level declaration, or at the start of a
+// statement and followed by n. It returns the identifier and the keyword it
+// is closest to, or nil.
+func misspelling(n *tree_sitter.Node, src []byte) (*tree_sitter.Node, string) {
+	if parent := n.Parent(); parent != nil && parent.Kind() == kind.SourceFile {
+		first := n.Child(0)
+		if first != nil && first.Kind() == kind.KeywordPub {
+			first = n.Child(1)
+		}
+		if first != nil && first.Kind() == kind.Identifier {
+			if kw := closest(first.Utf8Text(src), declarationKeywords); kw != "" {
+				return first, kw
+			}
+		}
+		return nil, ""
+	}
+	id := n.PrevSibling()
+	if id == nil || id.Kind() != kind.Identifier {
+		return nil, ""
+	}
+	// the identifier must begin the statement it is in.
+	stmt := id.Parent()
+	for stmt != nil && stmt.Kind() != kind.ExpressionStatement && stmt.StartByte() == id.StartByte() {
+		stmt = stmt.Parent()
+	}
+	if stmt == nil || stmt.Kind() != kind.ExpressionStatement || stmt.StartByte() != id.StartByte() {
+		return nil, ""
+	}
+	if kw := closest(id.Utf8Text(src), statementKeywords); kw != "" {
+		return id, kw
+	}
+	return nil, ""
+}
+
+// closest returns the candidate within a small edit distance of word, or
+// "" if there is none. One edit is allowed in words of up to four letters
+// and two in longer ones.
+func closest(word string, candidates []string) string {
+	limit := 1
+	if len(word) > 4 {
+		limit = 2
+	}
+	best, dist := "", limit+1
+	for _, c := range candidates {
+		if c == word {
+			return ""
+		}
+		if d := distance(word, c); d < dist {
+			best, dist = c, d
+		}
+	}
+	return best
+}
+
+// distance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and transpositions of
+// adjacent bytes turning one into the other.
+func distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,85 @@
+package ferrule
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+)
+
+// Tree is a parsed ferrule document together with the source it was parsed
+// from.
+type Tree struct {
+	inner  *tree_sitter.Tree
+	source []byte
+	// input is set on trees parsed from a reader, whose source is read
+	// when needed.
+	input *input
+}
+
+// Raw returns the underlying tree-sitter tree.
+func (t *Tree) Raw() *tree_sitter.Tree { return t.inner }
+
+// Source returns the text the tree was parsed from. It must not be modified.
+//
+// The source of a tree parsed by ParseReader is read on the first call, and
+// is nil if that fails; call Load to learn why.
+func (t *Tree) Source() []byte {
+	if t.input != nil {
+		src, _ := t.input.load()
+		return src
+	}
+	return t.source
+}
+
+// Load reads the source of a tree parsed by ParseReader into memory. It
+// does nothing for other trees.
+func (t *Tree) Load() error {
+	if t.input == nil {
+		return nil
+	}
+	_, err := t.input.load()
+	return err
+}
+
+// RootNode returns the raw root node.
+func (t *Tree) RootNode() *tree_sitter.Node { return t.inner.RootNode() }
+
+// Root returns the typed root node.
+func (t *Tree) Root() *ast.SourceFile { return ast.Root(t.inner) }
+
+// PathAt returns the path of named nodes enclosing the point p, from the
+// root down; see ast.PathAt.
+func (t *Tree) PathAt(p tree_sitter.Point) ast.Path { return ast.PathAt(t.inner, t.Source(), p) }
+
+// HasError reports whether the tree contains syntax errors.
+func (t *Tree) HasError() bool { return t.inner.RootNode().HasError() }
+
+// Text returns the source text covered by n. For a tree parsed by
+// ParseReader whose source has not been loaded, only that text is read; it
+// is empty if reading fails.
+func (t *Tree) Text(n *tree_sitter.Node) string {
+	if t.input != nil {
+		if src := t.input.loaded(); src != nil {
+			return n.Utf8Text(src)
+		}
+		text, _ := t.input.text(n.StartByte(), n.EndByte())
+		return text
+	}
+	return n.Utf8Text(t.source)
+}
+
+// NamedNodeAt returns the smallest named node spanning the byte offset.
+func (t *Tree) NamedNodeAt(offset uint) *tree_sitter.Node {
+	return t.inner.RootNode().NamedDescendantForByteRange(offset, offset)
+}
+
+// Clone returns an independent copy of the tree sharing the same source.
+func (t *Tree) Clone() *Tree {
+	return &Tree{inner: t.inner.Clone(), source: t.source, input: t.input}
+}
+
+// Close releases the tree. Nodes obtained from it must not be used
+// afterwards.
+func (t *Tree) Close() {
+	t.inner.Close()
+}
@@ -0,0 +1,112 @@
+// Package ferruletest runs snapshot tests of the parser over a corpus of
+// source files, for grammar contributors and for tools that depend on the
+// shape of the trees.
+//
+// Every source file of a corpus is parsed and its tree, written by
+// dump.SExpr, compared with a golden file next to it named after the
+// source with the extension ".sexp". Running the tests with -update
+// writes the golden files instead:
+//
+//	go test ./... -run TestCorpus -update
+//
+// The -update flag is registered by this package, so test packages using
+// it must not define a flag of the same name.
+package ferruletest
+
+import (
+	"context"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Ext is the extension of golden files.
+const Ext = ".sexp"
+
+var update = flag.Bool("update", false, "update the golden files of ferruletest corpora")
+
+// RunCorpus runs a subtest for every file below dir, other than golden
+// files and files whose names start with "." or "_". A subtest fails when
+// the tree of its file differs from the golden file, or when there is no
+// golden file.
+func RunCorpus(t *testing.T, dir string) {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if path != dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && filepath.Ext(name) != Ext {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ferruletest: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("ferruletest: no files in %s", dir)
+	}
+	for _, path := range files {
+		name, _ := filepath.Rel(dir, path)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			check(t, path)
+		})
+	}
+}
+
+// Golden returns the path of the golden file of the source file path.
+func Golden(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + Ext
+}
+
+func check(t *testing.T, path string) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	got := dump.SExpr(tree.RootNode()) + "\n"
+
+	golden := Golden(path)
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	data, err := os.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("no golden file %s; run the test with -update to create it", golden)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if got == want {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	line := 0
+	for line < len(gotLines) && line < len(wantLines) && gotLines[line] == wantLines[line] {
+		line++
+	}
+	t.Errorf("tree differs from %s at line %d; run the test with -update to accept it:\n%s", golden, line+1, got)
+}
@@ -0,0 +1,17 @@
+package ferruletest_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferruletest"
+)
+
+func TestCorpus(t *testing.T) {
+	ferruletest.RunCorpus(t, "testdata/corpus")
+}
+
+func TestGolden(t *testing.T) {
+	if got, want := ferruletest.Golden("a/b.fe"), "a/b.sexp"; got != want {
+		t.Errorf("Golden = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,18 @@
+package field_test
+
+import (
+	"testing"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+func TestFieldsExistInGrammar(t *testing.T) {
+	language := tree_sitter.NewLanguage(tree_sitter_ferrule.Language())
+	for _, f := range []string{field.Name, field.Body, field.ReturnType, field.Alternative} {
+		if language.FieldIdForName(f) == 0 {
+			t.Errorf("field %q is not known to the grammar", f)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Code generated by ferrule-nodegen from node-types.json. DO NOT EDIT.
+
+// Package field defines the field names of the ferrule grammar.
+package field
+
+const (
+	Alternative = "alternative"
+	Body        = "body"
+	Condition   = "condition"
+	Consequence = "consequence"
+	Name        = "name"
+	Parameters  = "parameters"
+	Path        = "path"
+	ReturnType  = "return_type"
+	Type        = "type"
+	Value       = "value"
+)
+
+// Required lists, by node kind, the fields that every node of that kind
+// has when it is parsed without errors.
+var Required = map[string][]string{
+	"capability_declaration": {Name},
+	"component_declaration":  {Name},
+	"const_declaration":      {Name, Value},
+	"domain_declaration":     {Name},
+	"error_declaration":      {Name},
+	"function_declaration":   {Body, Name, Parameters, ReturnType},
+	"if_statement":           {Condition, Consequence},
+	"import_declaration":     {Path},
+	"package_declaration":    {Path},
+	"parameter":              {Name, Type},
+	"type_declaration":       {Name, Type},
+	"type_parameter":         {Name},
+}
@@ -0,0 +1,3 @@
+package field
+
+//go:generate go run ../cmd/ferrule-nodegen -what fields -o fields.go ../../../src/node-types.json
@@ -0,0 +1,140 @@
+// Package fingerprint identifies syntax nodes in ways that survive edits,
+// so that caches, annotations and review comments attached to code can be
+// found again once the code has changed.
+//
+// Node derives an ID from the content of a node: its kind and its tokens,
+// without layout and comments. The ID stays the same for as long as the
+// code it covers does, wherever the code moves, which suits caches keyed
+// by code. Identical code has the same ID wherever it is.
+//
+// Map correlates the nodes of two versions of a file, matching them as
+// package astdiff does, so that an annotation on a node whose code has
+// changed can follow it to the new version.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/astdiff"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// ID is the fingerprint of a node.
+type ID [16]byte
+
+// String returns the ID in hexadecimal.
+func (id ID) String() string { return hex.EncodeToString(id[:]) }
+
+// Parse parses an ID returned by String.
+func Parse(s string) (ID, error) {
+	var id ID
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(id) {
+		return ID{}, fmt.Errorf("fingerprint: invalid ID %q", s)
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// Node returns the fingerprint of n, a node of a tree parsed from src.
+// Comments and the zero width nodes inserted by error recovery do not
+// count.
+func Node(n *tree_sitter.Node, src []byte) ID {
+	return sum(n, src, nil)
+}
+
+// sum returns the fingerprint of n, calling each, if not nil, with the
+// fingerprints of n and its descendants, children first.
+func sum(n *tree_sitter.Node, src []byte, each func(*tree_sitter.Node, ID)) ID {
+	h := sha256.New()
+	h.Write([]byte(n.Kind()))
+	if n.ChildCount() == 0 {
+		h.Write([]byte{0})
+		h.Write([]byte(n.Utf8Text(src)))
+	} else {
+		h.Write([]byte{'('})
+		for i := uint(0); i < n.ChildCount(); i++ {
+			c := n.Child(i)
+			if c.IsMissing() || c.Kind() == kind.LineComment || c.Kind() == kind.BlockComment {
+				continue
+			}
+			id := sum(c, src, each)
+			h.Write(id[:])
+		}
+		h.Write([]byte{')'})
+	}
+	var id ID
+	copy(id[:], h.Sum(nil))
+	if each != nil {
+		each(n, id)
+	}
+	return id
+}
+
+// Find returns the nodes of tree whose fingerprint is id, in preorder; an
+// ancestor comes before a child with the same fingerprint, as when one
+// wraps the other.
+func Find(tree *ferrule.Tree, id ID) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	sum(tree.RootNode(), tree.Source(), func(n *tree_sitter.Node, got ID) {
+		if got == id {
+			out = append(out, n)
+		}
+	})
+	// sum visits children first: reversed, ancestors come before their
+	// descendants, and sorting by position puts siblings back in order.
+	slices.Reverse(out)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].StartByte() != out[j].StartByte() {
+			return out[i].StartByte() < out[j].StartByte()
+		}
+		return out[i].EndByte() > out[j].EndByte()
+	})
+	return out
+}
+
+// A Mapping correlates the nodes of two versions of a file.
+type Mapping struct {
+	pairs []astdiff.Pair
+	toNew map[uintptr]*tree_sitter.Node
+	toOld map[uintptr]*tree_sitter.Node
+}
+
+// Map matches the nodes of oldTree with those of newTree. The trees must
+// stay open for as long as the mapping is used.
+func Map(oldTree, newTree *ferrule.Tree) *Mapping {
+	m := &Mapping{
+		pairs: astdiff.Match(oldTree, newTree),
+		toNew: make(map[uintptr]*tree_sitter.Node),
+		toOld: make(map[uintptr]*tree_sitter.Node),
+	}
+	for _, p := range m.pairs {
+		m.toNew[p.Old.Id()] = p.New
+		m.toOld[p.New.Id()] = p.Old
+	}
+	return m
+}
+
+// New returns the node of the new tree matched with n, a node of the old
+// one, or nil if n has no counterpart, having been deleted.
+func (m *Mapping) New(n *tree_sitter.Node) *tree_sitter.Node {
+	return m.toNew[n.Id()]
+}
+
+// Old returns the node of the old tree matched with n, a node of the new
+// one, or nil if n was inserted.
+func (m *Mapping) Old(n *tree_sitter.Node) *tree_sitter.Node {
+	return m.toOld[n.Id()]
+}
+
+// Pairs returns the matched nodes in preorder of the old tree.
+func (m *Mapping) Pairs() []astdiff.Pair {
+	return m.pairs
+}
@@ -0,0 +1,108 @@
+package fingerprint_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/fingerprint"
+)
+
+const base = `function add(x: i32, y: i32) -> i32 {
+  return x + y;
+}
+
+function main() -> i32 {
+  const a = add(1, 2);
+  return a;
+}
+`
+
+func parse(t *testing.T, src string) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+// decl returns the i-th top-level declaration of tree.
+func decl(tree *ferrule.Tree, i uint) *tree_sitter.Node {
+	return tree.RootNode().NamedChild(i)
+}
+
+func TestNode(t *testing.T) {
+	old := parse(t, base)
+	id := fingerprint.Node(decl(old, 0), old.Source())
+
+	tests := []struct {
+		name, src string
+		same      bool
+	}{
+		{"layout", strings.ReplaceAll(base, "  return x + y;", "    return x+y; // sum"), true},
+		{"moved", strings.Replace(base, "function main", "function other() -> i32 { return 0; }\n\nfunction main", 1), true},
+		{"renamed", strings.Replace(base, "add(x", "plus(x", 1), false},
+		{"changed", strings.Replace(base, "x + y", "x - y", 1), false},
+	}
+	for _, tt := range tests {
+		tree := parse(t, tt.src)
+		if got := fingerprint.Node(decl(tree, 0), tree.Source()); (got == id) != tt.same {
+			t.Errorf("%s: same fingerprint %v, want %v", tt.name, got == id, tt.same)
+		}
+	}
+
+	parsed, err := fingerprint.Parse(id.String())
+	if err != nil || parsed != id {
+		t.Errorf("Parse(%s) = %s, %v", id, parsed, err)
+	}
+	if _, err := fingerprint.Parse("abc"); err == nil {
+		t.Error("Parse accepted a short ID")
+	}
+}
+
+func TestFind(t *testing.T) {
+	tree := parse(t, base)
+	ident := decl(tree, 1).ChildByFieldName("body").NamedChild(1).NamedChild(0)
+	if ident.Utf8Text(tree.Source()) != "a" {
+		t.Fatalf("found %q", ident.Utf8Text(tree.Source()))
+	}
+	found := fingerprint.Find(tree, fingerprint.Node(ident, tree.Source()))
+	var got []string
+	for _, n := range found {
+		got = append(got, fmt.Sprintf("%s@%d", n.Kind(), n.StartByte()))
+	}
+	if len(found) != 2 || found[0].StartByte() >= found[1].StartByte() || found[1].StartByte() != ident.StartByte() {
+		t.Errorf("found %v", got)
+	}
+}
+
+func TestMap(t *testing.T) {
+	old := parse(t, base)
+	updated := parse(t, strings.Replace(base, "  const a = add(1, 2);\n", "  // two\n  const a = add(1, 20);\n", 1))
+	m := fingerprint.Map(old, updated)
+
+	oldConst := decl(old, 1).ChildByFieldName("body").NamedChild(0)
+	newConst := m.New(oldConst)
+	if newConst == nil || newConst.Utf8Text(updated.Source()) != "const a = add(1, 20);" {
+		t.Fatalf("const maps to %v", newConst)
+	}
+	if back := m.Old(newConst); back == nil || back.StartByte() != oldConst.StartByte() {
+		t.Errorf("const maps back to %v", back)
+	}
+	if fingerprint.Node(oldConst, old.Source()) == fingerprint.Node(newConst, updated.Source()) {
+		t.Error("changed const kept its fingerprint")
+	}
+	comment := decl(updated, 1).ChildByFieldName("body").NamedChild(0)
+	if m.Old(comment) != nil {
+		t.Errorf("inserted %s maps to the old tree", comment.Kind())
+	}
+	if len(m.Pairs()) == 0 || m.Pairs()[0].Old.Kind() != "source_file" {
+		t.Errorf("pairs %v", m.Pairs())
+	}
+}
@@ -0,0 +1,148 @@
+// Package folding computes code folding ranges for ferrule source.
+//
+// Regions come from the bundled folds query, which covers blocks, bodies
+// of declarations, match arms, parameter and argument lists and block
+// comments. On top of that, runs of line comments and of consecutive
+// import declarations fold as one region each.
+package folding
+
+import (
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var folds = query.MustCompile(string(queries.Folds))
+
+// Kind classifies a folding range. The values match the LSP
+// FoldingRangeKind enumeration.
+type Kind string
+
+const (
+	Comment Kind = "comment"
+	Imports Kind = "imports"
+	Region  Kind = "region"
+)
+
+// Range is a foldable region, between two zero-based lines inclusive.
+// When a region ends with a closing bracket on a line of its own, EndLine
+// is the line before it, so the bracket stays visible once folded.
+type Range struct {
+	StartLine uint32 `json:"startLine"`
+	EndLine   uint32 `json:"endLine"`
+	Kind      Kind   `json:"kind,omitempty"`
+}
+
+// Options configures Ranges.
+type Options struct {
+	// MinLines is the least number of lines a region must span to be
+	// reported. Values below 2 mean 2, since a single line cannot fold.
+	MinLines int
+}
+
+// Ranges returns the folding ranges of tree ordered by start line. Of
+// several regions starting on the same line only the longest is kept, as
+// editors show a single fold marker per line. A nil opts uses the
+// defaults.
+func Ranges(tree *ferrule.Tree, opts *Options) []Range {
+	minLines := 2
+	if opts != nil && opts.MinLines > minLines {
+		minLines = opts.MinLines
+	}
+
+	var out []Range
+	add := func(r Range) {
+		if int(r.EndLine)-int(r.StartLine)+1 >= minLines {
+			out = append(out, r)
+		}
+	}
+	for _, n := range folds.Matches(tree.Root(), tree.Source()) {
+		add(region(n.Raw()))
+	}
+	runs(tree.RootNode(), add)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].StartLine != out[j].StartLine {
+			return out[i].StartLine < out[j].StartLine
+		}
+		return out[i].EndLine > out[j].EndLine
+	})
+	kept := out[:0]
+	for _, r := range out {
+		if len(kept) == 0 || kept[len(kept)-1].StartLine != r.StartLine {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func region(n *tree_sitter.Node) Range {
+	r := Range{StartLine: uint32(n.StartPosition().Row), EndLine: uint32(n.EndPosition().Row), Kind: Region}
+	if n.Kind() == kind.BlockComment {
+		r.Kind = Comment
+		return r
+	}
+	last := n
+	for last.ChildCount() > 0 {
+		last = last.Child(last.ChildCount() - 1)
+	}
+	switch last.Kind() {
+	case "}", ")", "]":
+		if last.StartPosition().Row > n.StartPosition().Row && !sharesLine(last) {
+			r.EndLine--
+		}
+	}
+	return r
+}
+
+// sharesLine reports whether a closing bracket has other tokens before it
+// on its line.
+func sharesLine(closing *tree_sitter.Node) bool {
+	prev := closing.PrevSibling()
+	return prev != nil && prev.EndPosition().Row == closing.StartPosition().Row
+}
+
+// runs reports the runs of line comments and import declarations found
+// among the children of n and, recursively, of its descendants.
+func runs(n *tree_sitter.Node, add func(Range)) {
+	var comments, imports []*tree_sitter.Node
+	flush := func(run []*tree_sitter.Node, k Kind) {
+		if len(run) > 1 {
+			add(Range{StartLine: uint32(run[0].StartPosition().Row), EndLine: uint32(run[len(run)-1].EndPosition().Row), Kind: k})
+		}
+	}
+	// extend appends c to run, first flushing run when a blank line
+	// separates the two.
+	extend := func(run []*tree_sitter.Node, c *tree_sitter.Node, k Kind) []*tree_sitter.Node {
+		if len(run) > 0 && c.StartPosition().Row > run[len(run)-1].EndPosition().Row+1 {
+			flush(run, k)
+			run = nil
+		}
+		return append(run, c)
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		switch c.Kind() {
+		case kind.LineComment:
+			comments = extend(comments, c, Comment)
+			continue
+		case kind.ImportDeclaration:
+			imports = extend(imports, c, Imports)
+			continue
+		}
+		flush(comments, Comment)
+		comments = nil
+		flush(imports, Imports)
+		imports = nil
+		if c.ChildCount() > 0 {
+			runs(c, add)
+		}
+	}
+	flush(comments, Comment)
+	flush(imports, Imports)
+}
@@ -0,0 +1,71 @@
+package folding_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/folding"
+)
+
+const source = `package app;
+
+import std.io { print };
+import std.fs { read };
+
+// Entry point of the
+// application.
+function main(
+  a: u32,
+  b: u32,
+) -> Unit {
+  match a {
+    1 -> {
+      print("one");
+      print("again");
+    }
+    _ -> print("other")
+  };
+  /* a long
+     comment */
+  const short = 1;
+}
+`
+
+func TestRanges(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	format := func(rs []folding.Range) string {
+		var lines []string
+		for _, r := range rs {
+			lines = append(lines, fmt.Sprintf("%d-%d %s", r.StartLine, r.EndLine, r.Kind))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	got := format(folding.Ranges(tree, nil))
+	want := strings.Join([]string{
+		"2-3 imports",
+		"5-6 comment",
+		"7-9 region",
+		"10-20 region",
+		"11-16 region",
+		"12-14 region",
+		"18-19 comment",
+	}, "\n")
+	if got != want {
+		t.Errorf("Ranges:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = format(folding.Ranges(tree, &folding.Options{MinLines: 5}))
+	want = strings.Join([]string{"10-20 region", "11-16 region"}, "\n")
+	if got != want {
+		t.Errorf("Ranges with MinLines 5:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Package format implements the canonical formatting of ferrule source.
+//
+// The style is fixed, in the spirit of gofmt: two-space indentation, one
+// statement per line, single spaces around binary operators and at most one
+// blank line between items. Comments are preserved. Bracketed lists such as
+// argument lists and record literals stay on one line unless the source
+// already breaks them after the opening bracket, or a line width is set
+// and they would overrun it, in which case every element gets its own line
+// and a trailing comma.
+package format
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/indent"
+)
+
+// Indent is the string used for one level of indentation.
+const Indent = indent.Unit
+
+// SyntaxError is returned when the input does not parse cleanly. The
+// formatter refuses to guess at the structure of broken code.
+type SyntaxError struct {
+	Diagnostics []ferrule.Diagnostic
+}
+
+func (e *SyntaxError) Error() string {
+	if len(e.Diagnostics) == 0 {
+		return "format: source has syntax errors"
+	}
+	msgs := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		msgs[i] = d.String()
+	}
+	return "format: " + strings.Join(msgs, "; ")
+}
+
+// Options adjust the canonical style.
+type Options struct {
+	// Width is the length in characters beyond which a line holding a
+	// bracketed list is broken, one element of the list per line. Zero
+	// leaves lists on one line.
+	Width int
+	// LocalImports are package paths whose packages, and those under
+	// them, OrganizeImports groups with the project's own.
+	LocalImports []string
+	// Newline is the line break ending the lines, those of block comments
+	// included. Line breaks inside string literals are left alone.
+	Newline Newline
+	// KeepBOM keeps a UTF-8 byte order mark starting the source, which is
+	// otherwise dropped.
+	KeepBOM bool
+	// KeepFinalNewline ends the output with a line break only if the
+	// source ends with one, instead of always.
+	KeepFinalNewline bool
+}
+
+// Newline is a line break.
+type Newline int
+
+const (
+	// LF is \n, as written on Unix.
+	LF Newline = iota
+	// CRLF is \r\n, as written on Windows.
+	CRLF
+)
+
+func (n Newline) String() string {
+	if n == CRLF {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// Text returns the line break, \n or \r\n.
+func (n Newline) Text() string {
+	if n == CRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// BOM is the UTF-8 byte order mark.
+const BOM = "\xef\xbb\xbf"
+
+// Source formats src and returns the canonical text.
+func Source(src []byte) ([]byte, error) {
+	return Options{}.Source(src)
+}
+
+// Tree formats an already parsed tree.
+func Tree(tree *ferrule.Tree) ([]byte, error) {
+	return Options{}.Tree(tree)
+}
+
+// Source is like the package-level Source with the options o.
+func (o Options) Source(src []byte) ([]byte, error) {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	return o.Tree(tree)
+}
+
+// Tree is like the package-level Tree with the options o.
+func (o Options) Tree(tree *ferrule.Tree) ([]byte, error) {
+	if tree.HasError() {
+		return nil, &SyntaxError{Diagnostics: tree.Diagnostics()}
+	}
+	src := tree.Source()
+	p := &printer{src: src, width: o.Width, eol: o.Newline.Text()}
+	p.node(tree.RootNode())
+	var out []byte
+	if o.KeepBOM && bytes.HasPrefix(src, []byte(BOM)) {
+		out = append(out, BOM...)
+	}
+	body := bytes.TrimRight(p.out.Bytes(), " \r\n")
+	if len(body) == 0 {
+		return append([]byte{}, out...), nil
+	}
+	out = append(out, body...)
+	if o.KeepFinalNewline && !endsLine(src) {
+		return out, nil
+	}
+	return append(out, p.eol...), nil
+}
+
+// endsLine reports whether src ends with a line break.
+func endsLine(src []byte) bool {
+	return len(src) > 0 && (src[len(src)-1] == '\n' || src[len(src)-1] == '\r')
+}
+
+// Check reports whether src is already formatted.
+func Check(src []byte) (bool, error) {
+	out, err := Source(src)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(out, src), nil
+}
@@ -0,0 +1,262 @@
+package format_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range inputs {
+		src, err := os.ReadFile(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := format.Source(src)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		golden := strings.TrimSuffix(in, ".input") + ".golden"
+		if *update {
+			if err := os.WriteFile(golden, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: output differs from %s:\n%s", in, golden, got)
+		}
+	}
+}
+
+// TestCorpus formats every snippet of the grammar's test corpus and checks
+// that formatting is idempotent and leaves the syntax tree unchanged.
+func TestCorpus(t *testing.T) {
+	files, err := filepath.Glob("../../../test/corpus/*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := regexp.MustCompile(`(?m)^={10,}\n(.*)\n={10,}\n`)
+	divider := regexp.MustCompile(`(?m)^-{10,}$`)
+	inputs, _ := filepath.Glob("testdata/*.input")
+	var cases [][2]string
+	for _, in := range inputs {
+		src, _ := os.ReadFile(in)
+		cases = append(cases, [2]string{in, string(src)})
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := header.FindAllStringSubmatch(string(data), -1)
+		bodies := header.Split(string(data), -1)[1:]
+		for i, body := range bodies {
+			cases = append(cases, [2]string{names[i][1], divider.Split(body, 2)[0]})
+		}
+	}
+
+	for _, c := range cases {
+		name, src := c[0], []byte(c[1])
+		once, err := format.Source(src)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		twice, err := format.Source(once)
+		if err != nil {
+			t.Errorf("%s: reformatting: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(once, twice) {
+			t.Errorf("%s: formatting is not idempotent:\n%s\n---\n%s", name, once, twice)
+		}
+		if before, after := sexp(t, src), sexp(t, once); before != after {
+			t.Errorf("%s: formatting changed the tree:\n%s\n%s", name, before, after)
+		}
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	_, err := format.Source([]byte("function f( -> i32 {}"))
+	if _, ok := err.(*format.SyntaxError); !ok {
+		t.Errorf("got %v, want *format.SyntaxError", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ok, err := format.Check([]byte("const x = 1;\n"))
+	if err != nil || !ok {
+		t.Errorf("Check = %v, %v; want true", ok, err)
+	}
+	ok, err = format.Check([]byte("const x=1;"))
+	if err != nil || ok {
+		t.Errorf("Check = %v, %v; want false", ok, err)
+	}
+}
+
+func sexp(t *testing.T, src []byte) string {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	return tree.RootNode().ToSexp()
+}
+
+func TestRange(t *testing.T) {
+	src := []byte("function f() -> Unit {\n  const x = 1;\n      if x==0 {return;}\n  const y = foo(1,2) ;\n}\n")
+	tests := []struct {
+		name       string
+		start, end tree_sitter.Point
+		want       string
+	}{
+		{"statement", tree_sitter.Point{Row: 2, Column: 9}, tree_sitter.Point{Row: 2, Column: 9}, "function f() -> Unit {\n  const x = 1;\n  if x == 0 {\n    return;\n  }\n  const y = foo(1,2) ;\n}\n"},
+		{"nested", tree_sitter.Point{Row: 3, Column: 16}, tree_sitter.Point{Row: 3, Column: 17}, "function f() -> Unit {\n  const x = 1;\n      if x==0 {return;}\n  const y = foo(1, 2);\n}\n"},
+	}
+	for _, tt := range tests {
+		e, err := format.Range(src, tt.start, tt.end)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := string(e.Apply(src)); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRangeSyntaxError(t *testing.T) {
+	// the error in g does not stop f from being formatted.
+	src := []byte("function f() -> Unit { const x=1; }\nfunction g() -> Unit { foo(; }\n")
+	e, err := format.Range(src, tree_sitter.Point{Row: 0, Column: 25}, tree_sitter.Point{Row: 0, Column: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Text != "const x = 1;" {
+		t.Errorf("Text = %q", e.Text)
+	}
+	if _, err := format.Range(src, tree_sitter.Point{Row: 1, Column: 25}, tree_sitter.Point{Row: 1, Column: 25}); err == nil {
+		t.Error("Range over broken code succeeded")
+	}
+}
+
+func TestWidth(t *testing.T) {
+	src := []byte("function f() -> i32 {\n  return add(first_argument, second_argument, [1, 2, 3]);\n}\n")
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{0, string(src)},
+		{80, string(src)},
+		{40, "function f() -> i32 {\n  return add(\n    first_argument,\n    second_argument,\n    [1, 2, 3],\n  );\n}\n"},
+	}
+	for _, tt := range tests {
+		got, err := format.Options{Width: tt.width}.Source(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("width %d: got\n%s\nwant\n%s", tt.width, got, tt.want)
+		}
+		again, err := format.Options{Width: tt.width}.Source(got)
+		if err != nil || !bytes.Equal(again, got) {
+			t.Errorf("width %d: formatting is not idempotent:\n%s", tt.width, again)
+		}
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	src := "\xef\xbb\xbfconst x = 1; // one\r\n/* a\r\n b */\nconst s = \"a\r\nb\";"
+	tests := []struct {
+		opts format.Options
+		want string
+	}{
+		{format.Options{}, "const x = 1; // one\n/* a\n b */\nconst s = \"a\r\nb\";\n"},
+		{format.Options{Newline: format.CRLF}, "const x = 1; // one\r\n/* a\r\n b */\r\nconst s = \"a\r\nb\";\r\n"},
+		{format.Options{KeepBOM: true, KeepFinalNewline: true}, "\xef\xbb\xbfconst x = 1; // one\n/* a\n b */\nconst s = \"a\r\nb\";"},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.Source([]byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.opts, got, tt.want)
+		}
+		again, err := tt.opts.Source(got)
+		if err != nil || !bytes.Equal(again, got) {
+			t.Errorf("%+v: formatting is not idempotent: %q", tt.opts, again)
+		}
+	}
+}
+
+func TestOrganizeImports(t *testing.T) {
+	tests := []struct {
+		name  string
+		local []string
+		src   string
+		want  string
+	}{
+		{
+			"groups",
+			nil,
+			"package app.main;\n\nimport app.util;\nimport json.parse as parse;\nimport std.io;\nimport app.net; // sockets\nimport std.fmt;\n\nconst x = 1;\n",
+			"package app.main;\n\nimport std.fmt;\nimport std.io;\n\nimport json.parse as parse;\n\nimport app.net; // sockets\nimport app.util;\n\nconst x = 1;\n",
+		},
+		{
+			"duplicates and comments",
+			nil,
+			"// header\n\n// io things\nimport std.io;\nimport std.fmt;\nimport std.io;\n",
+			"// header\n\nimport std.fmt;\n// io things\nimport std.io;\n",
+		},
+		{
+			"local prefixes",
+			[]string{"acme"},
+			"import acme.log;\nimport std.io;\nimport zlib;\n",
+			"import std.io;\n\nimport zlib;\n\nimport acme.log;\n",
+		},
+		{
+			"runs",
+			nil,
+			"import b;\nimport a;\nconst x = 1;\nimport d;\nimport c;\n",
+			"import a;\nimport b;\nconst x = 1;\nimport c;\nimport d;\n",
+		},
+		{"organized", nil, "import std.io;\n\nimport b;\n", "import std.io;\n\nimport b;\n"},
+	}
+	for _, tt := range tests {
+		got, err := format.Options{LocalImports: tt.local}.OrganizeImports([]byte(tt.src))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+	if _, err := format.OrganizeImports([]byte("import a\n")); err == nil {
+		t.Error("OrganizeImports of broken code succeeded")
+	}
+}
@@ -0,0 +1,215 @@
+package format
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/trivia"
+)
+
+// Import groups, in the order they are laid out.
+const (
+	stdGroup = iota
+	thirdPartyGroup
+	localGroup
+)
+
+// OrganizeImports sorts the imports of src, drops duplicates and lays
+// them out in groups separated by blank lines: the standard library
+// first, then other packages, then those of the project, which are the
+// packages under the first component of the file's package path. Each
+// run of consecutive import declarations is organized on its own, and
+// the comments on the lines just above an import, or ending its line,
+// move with it. The rest of the source is left alone; formatting is up
+// to Source.
+func OrganizeImports(src []byte) ([]byte, error) {
+	return Options{}.OrganizeImports(src)
+}
+
+// OrganizeImports is like the package-level OrganizeImports with the
+// options o, whose LocalImports count as the project's too.
+func (o Options) OrganizeImports(src []byte) ([]byte, error) {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	edits, err := o.ImportEdits(tree)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(edits) - 1; i >= 0; i-- {
+		src = edits[i].Apply(src)
+	}
+	return src, nil
+}
+
+// ImportEdits returns the edits OrganizeImports makes to tree, in source
+// order. There are none if the imports are organized already.
+func (o Options) ImportEdits(tree *ferrule.Tree) ([]Edit, error) {
+	if tree.HasError() {
+		return nil, &SyntaxError{Diagnostics: tree.Diagnostics()}
+	}
+	src := tree.Source()
+	root := tree.RootNode()
+	m := trivia.Attach(tree)
+	own := ""
+	var edits []Edit
+	var run []*tree_sitter.Node
+	flush := func() {
+		if e, ok := o.organize(src, m, own, run); ok {
+			edits = append(edits, e)
+		}
+		run = nil
+	}
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		c := root.NamedChild(i)
+		switch {
+		case c.IsExtra():
+			// Comments travel with the imports as trivia.
+		case c.Kind() == kind.ImportDeclaration:
+			run = append(run, c)
+		default:
+			if c.Kind() == kind.PackageDeclaration {
+				own, _, _ = strings.Cut(pathOf(c, src), ".")
+			}
+			flush()
+		}
+	}
+	flush()
+	return edits, nil
+}
+
+// anImport is an import declaration with the comments that go with it.
+type anImport struct {
+	path, alias string
+	group       int
+	comments    []string // on the lines above
+	trailing    string   // ending the line
+}
+
+func (i anImport) String() string {
+	var b strings.Builder
+	for _, c := range i.comments {
+		b.WriteString(c)
+		b.WriteByte('\n')
+	}
+	b.WriteString("import " + i.path)
+	if i.alias != "" {
+		b.WriteString(" as " + i.alias)
+	}
+	b.WriteByte(';')
+	if i.trailing != "" {
+		b.WriteString(" " + i.trailing)
+	}
+	return b.String()
+}
+
+// organize returns the edit organizing a run of imports, if it changes
+// anything.
+func (o Options) organize(src []byte, m *trivia.Map, own string, run []*tree_sitter.Node) (Edit, bool) {
+	if len(run) == 0 {
+		return Edit{}, false
+	}
+	first, last := run[0], run[len(run)-1]
+	e := Edit{Start: first.StartByte(), End: last.EndByte(), StartPoint: first.StartPosition(), EndPoint: last.EndPosition()}
+	var imports []anImport
+	for k, n := range run {
+		imp := anImport{path: pathOf(n, src)}
+		if alias := n.ChildByFieldName(field.Path).NextNamedSibling(); alias != nil && alias.Kind() == kind.Identifier {
+			imp.alias = alias.Utf8Text(src)
+		}
+		imp.group = o.group(imp.path, own)
+		for _, t := range m.Leading(n) {
+			switch {
+			case t.Kind != trivia.BlankLines:
+				if k == 0 && len(imp.comments) == 0 {
+					e.Start, e.StartPoint = t.Range.StartByte, t.Range.StartPoint
+				}
+				imp.comments = append(imp.comments, t.Text)
+			case k == 0:
+				// What lies above a blank line belongs to what precedes.
+				imp.comments = nil
+				e.Start, e.StartPoint = first.StartByte(), first.StartPosition()
+			}
+		}
+		for _, t := range m.Trailing(n) {
+			if t.Kind != trivia.BlankLines && t.Range.StartPoint.Row == n.EndPosition().Row {
+				imp.trailing = t.Text
+				if n == last {
+					e.End, e.EndPoint = t.Range.EndByte, t.Range.EndPoint
+				}
+			}
+		}
+		imports = append(imports, imp)
+	}
+
+	sort.SliceStable(imports, func(i, j int) bool {
+		a, b := imports[i], imports[j]
+		if a.group != b.group {
+			return a.group < b.group
+		}
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		return a.alias < b.alias
+	})
+	var b strings.Builder
+	for i, imp := range imports {
+		if i > 0 {
+			prev := imports[i-1]
+			if prev.path == imp.path && prev.alias == imp.alias {
+				continue
+			}
+			b.WriteByte('\n')
+			if prev.group != imp.group {
+				b.WriteByte('\n')
+			}
+		}
+		b.WriteString(imp.String())
+	}
+	e.Text = b.String()
+	return e, e.Text != string(src[e.Start:e.End])
+}
+
+// group returns the group of the import of path in a file of the
+// project own.
+func (o Options) group(path, own string) int {
+	under := func(prefix string) bool {
+		return prefix != "" && (path == prefix || strings.HasPrefix(path, prefix+"."))
+	}
+	if under("std") {
+		return stdGroup
+	}
+	if under(own) {
+		return localGroup
+	}
+	for _, p := range o.LocalImports {
+		if under(p) {
+			return localGroup
+		}
+	}
+	return thirdPartyGroup
+}
+
+// pathOf returns the package path of an import or package declaration,
+// without spaces or comments.
+func pathOf(decl *tree_sitter.Node, src []byte) string {
+	p := decl.ChildByFieldName(field.Path)
+	if p == nil {
+		return ""
+	}
+	var parts []string
+	for i := uint(0); i < p.NamedChildCount(); i++ {
+		if c := p.NamedChild(i); c.Kind() == kind.Identifier {
+			parts = append(parts, c.Utf8Text(src))
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,498 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+type Node = tree_sitter.Node
+
+// space is the whitespace owed before the next token. Requests only ever
+// strengthen it, so a newline asked for by a line comment cannot be
+// downgraded by a later request for a single space.
+type space int
+
+const (
+	none space = iota
+	single
+	newline
+	blank
+)
+
+type printer struct {
+	src []byte
+	// width is the line length lists are broken beyond, or zero.
+	width int
+	// eol is the line break written.
+	eol     string
+	out     bytes.Buffer
+	depth   int
+	pending space
+	// lastRow is the source row on which the last written token ended.
+	lastRow uint
+}
+
+func (p *printer) need(s space) {
+	if s > p.pending {
+		p.pending = s
+	}
+}
+
+func (p *printer) write(s string) {
+	if p.out.Len() > 0 {
+		switch p.pending {
+		case single:
+			p.out.WriteByte(' ')
+		case blank:
+			p.out.WriteString(p.eol)
+			fallthrough
+		case newline:
+			p.out.WriteString(p.eol)
+			p.out.WriteString(strings.Repeat(Indent, p.depth))
+		}
+	}
+	p.pending = none
+	p.out.WriteString(s)
+}
+
+func (p *printer) token(n *Node) {
+	text := n.Utf8Text(p.src)
+	// the line breaks of string literals are part of their value, but those
+	// of comments follow the file.
+	switch n.Kind() {
+	case kind.LineComment:
+		text = strings.TrimSuffix(text, "\r")
+	case kind.BlockComment:
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		if p.eol != "\n" {
+			text = strings.ReplaceAll(text, "\n", p.eol)
+		}
+	}
+	p.write(text)
+	p.lastRow = n.EndPosition().Row
+}
+
+func (p *printer) node(n *Node) {
+	if atomic(n) {
+		p.token(n)
+		return
+	}
+	kids := children(n)
+	switch n.Kind() {
+	case kind.SourceFile:
+		p.lines(n, groups(kids, nil), true)
+	case kind.Block, kind.MatchStatement, kind.MatchExpression, kind.ComponentDeclaration:
+		p.braced(n, kids, nil, n.Kind() == kind.ComponentDeclaration)
+	case kind.CapabilityDeclaration:
+		p.braced(n, kids, startsMember, false)
+	case kind.DomainDeclaration:
+		if hasToken(kids, "{") {
+			p.braced(n, kids, nil, false)
+		} else {
+			p.seq(n, kids)
+		}
+	case kind.UnionType:
+		p.union(n, kids)
+	case kind.ParameterList, kind.CallExpression, kind.ArrayExpression, kind.RecordExpression,
+		kind.RecordBody, kind.RecordType, kind.TypeParameters, kind.GenericType,
+		kind.FunctionType, kind.EffectsClause, kind.DestructuringPattern:
+		p.list(n, kids)
+	default:
+		p.seq(n, kids)
+	}
+}
+
+// seq prints kids on the current line, separated according to spaced.
+func (p *printer) seq(parent *Node, kids []*Node) {
+	p.seqAfter(parent, nil, kids)
+}
+
+// seqAfter is like seq for kids that follow prev, which has already been
+// printed.
+func (p *printer) seqAfter(parent, prev *Node, kids []*Node) {
+	for _, k := range kids {
+		if isComment(k) {
+			p.comment(k)
+			prev = k
+			continue
+		}
+		if prev != nil && (isComment(prev) || spaced(parent, prev, k)) {
+			p.need(single)
+		}
+		p.node(k)
+		prev = k
+	}
+}
+
+// comment prints c, keeping it on the line of the previous token when it
+// was there in the source.
+func (p *printer) comment(c *Node) {
+	if p.out.Len() > 0 && c.StartPosition().Row == p.lastRow {
+		p.need(single)
+	} else {
+		p.need(newline)
+	}
+	p.token(c)
+	if c.Kind() == kind.LineComment {
+		p.need(newline)
+	}
+}
+
+// lines prints each group on its own line. Blank lines between groups are
+// preserved, collapsed to one. When separate is set, declarations that span
+// several lines are always set apart by a blank line.
+func (p *printer) lines(n *Node, gs [][]*Node, separate bool) {
+	var prev []*Node
+	for _, g := range gs {
+		switch {
+		case prev == nil:
+			p.need(newline)
+		case isComment(g[0]) && g[0].StartPosition().Row == prev[len(prev)-1].EndPosition().Row:
+			// a trailing comment stays on the line it annotates.
+		case blankBetween(prev[len(prev)-1], g[0]):
+			p.need(blank)
+		case separate && !isComment(prev[len(prev)-1]) && !isComment(g[0]) && (isBig(prev) || isBig(g)):
+			p.need(blank)
+		default:
+			p.need(newline)
+		}
+		p.seq(n, g)
+		prev = g
+	}
+}
+
+// braced prints a construct whose body sits between braces with one item
+// per line, such as a block or a match.
+func (p *printer) braced(n *Node, kids []*Node, starts func(*Node, []*Node) bool, separate bool) {
+	open, close := indexOf(kids, "{"), lastIndexOf(kids, "}")
+	p.seq(n, kids[:open])
+	if open > 0 {
+		p.need(single)
+	}
+	p.token(kids[open])
+	body := kids[open+1 : close]
+	if len(body) > 0 {
+		p.depth++
+		p.lines(n, groups(body, starts), separate)
+		p.depth--
+		p.need(newline)
+	}
+	p.token(kids[close])
+	p.seqAfter(n, kids[close], kids[close+1:])
+}
+
+// list prints a bracketed, comma separated list. It stays on one line
+// unless the source breaks the line after the opening bracket, the list
+// holds a line comment or the line would grow longer than the width.
+func (p *printer) list(n *Node, kids []*Node) {
+	open := indexOfAny(kids, "(", "[", "{", "<")
+	close := lastIndexOfAny(kids, ")", "]", "}", ">")
+	if open < 0 || close < open {
+		p.seq(n, kids)
+		return
+	}
+	p.seq(n, kids[:open])
+	if open > 0 && spaced(n, kids[open-1], kids[open]) {
+		p.need(single)
+	}
+	p.token(kids[open])
+
+	items := splitList(kids[open+1 : close])
+	switch {
+	case len(items) == 0:
+	case multiline(kids[open], kids[open+1:close]):
+		p.broken(n, items)
+	case p.width > 0:
+		// print the list flat, nested lists included, and again broken if
+		// that overran the line; the nested lists then get their own try.
+		mark, depth, pending, lastRow := p.out.Len(), p.depth, p.pending, p.lastRow
+		width := p.width
+		p.width = 0
+		p.flat(n, items, kids[open].Kind() == "{")
+		p.width = width
+		if p.column() > width {
+			p.out.Truncate(mark)
+			p.depth, p.pending, p.lastRow = depth, pending, lastRow
+			p.broken(n, items)
+		}
+	default:
+		p.flat(n, items, kids[open].Kind() == "{")
+	}
+	p.token(kids[close])
+	p.seqAfter(n, kids[close], kids[close+1:])
+}
+
+// broken prints the items of a list one per line, each followed by a
+// comma.
+func (p *printer) broken(n *Node, items [][]*Node) {
+	p.depth++
+	var prev *Node
+	for _, item := range items {
+		first := item[0]
+		switch {
+		case isComment(first):
+			// comment handles placement itself.
+		case prev != nil && blankBetween(prev, first):
+			p.need(blank)
+		default:
+			p.need(newline)
+		}
+		p.seq(n, item)
+		if !isComment(first) {
+			p.write(",")
+		}
+		prev = item[len(item)-1]
+	}
+	p.depth--
+	p.need(newline)
+}
+
+// flat prints the items of a list on the current line, padded with spaces
+// inside braces.
+func (p *printer) flat(n *Node, items [][]*Node, braces bool) {
+	if braces {
+		p.need(single)
+	}
+	for i, item := range items {
+		if i > 0 && !isComment(item[0]) {
+			if !isComment(items[i-1][0]) {
+				p.write(",")
+			}
+			p.need(single)
+		}
+		p.seq(n, item)
+	}
+	if braces {
+		p.need(single)
+	}
+}
+
+// column returns the length in characters of the last line written.
+func (p *printer) column() int {
+	out := p.out.Bytes()
+	return utf8.RuneCount(out[bytes.LastIndexByte(out, '\n')+1:])
+}
+
+// union prints the variants of a union type, one per line when the source
+// already spread them over several lines.
+func (p *printer) union(n *Node, kids []*Node) {
+	if n.StartPosition().Row == n.EndPosition().Row {
+		p.seq(n, kids)
+		return
+	}
+	p.depth++
+	for i, k := range kids {
+		switch {
+		case isComment(k):
+			p.comment(k)
+			continue
+		case k.Kind() == "|":
+			p.need(newline)
+		case i > 0:
+			p.need(single)
+		}
+		p.node(k)
+	}
+	p.depth--
+}
+
+// spaced reports whether a space separates two adjacent children of parent
+// on the same line.
+func spaced(parent, prev, next *Node) bool {
+	l, r := lastLeaf(prev).Kind(), firstLeaf(next).Kind()
+	switch r {
+	case ",", ";", ")", "]", ".", ":":
+		return false
+	}
+	switch l {
+	case "(", "[", ".":
+		return false
+	}
+	switch parent.Kind() {
+	case kind.UnaryExpression:
+		return false
+	case kind.CallExpression, kind.IndexExpression:
+		if r == "(" || r == "[" {
+			return false
+		}
+	case kind.GenericType, kind.TypeParameters:
+		if l == "<" || r == "<" || r == ">" {
+			return false
+		}
+	case kind.BinaryExpression:
+		if l == ".." || l == "..=" || r == ".." || r == "..=" {
+			return false
+		}
+	}
+	switch next.Kind() {
+	case kind.ParameterList, kind.TypeParameters:
+		return false
+	}
+	return true
+}
+
+// groups splits the body of a braced construct into lines. A line ends with
+// a semicolon or a complete named node; starts can force a new line before
+// a given child. Comments always form their own group so they can be
+// placed independently.
+func groups(kids []*Node, starts func(*Node, []*Node) bool) [][]*Node {
+	var out [][]*Node
+	var cur []*Node
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, cur)
+			cur = nil
+		}
+	}
+	for _, k := range kids {
+		if isComment(k) && len(cur) == 0 {
+			out = append(out, []*Node{k})
+			continue
+		}
+		if starts != nil && starts(k, cur) {
+			flush()
+		}
+		cur = append(cur, k)
+		switch {
+		case k.Kind() == ";":
+			flush()
+		case len(cur) == 1 && k.IsNamed() && !isComment(k) && starts == nil:
+			flush()
+		case len(cur) > 1 && k.Kind() == kind.Block && cur[0].Kind() == "defer":
+			flush()
+		}
+	}
+	flush()
+	return out
+}
+
+// startsMember splits capability members, whose trailing semicolon is
+// optional.
+func startsMember(k *Node, cur []*Node) bool {
+	return k.Kind() == kind.Identifier && len(cur) > 0
+}
+
+// splitList returns the elements of a comma separated list with the commas
+// removed. Comments become elements of their own.
+func splitList(kids []*Node) [][]*Node {
+	var out [][]*Node
+	var cur []*Node
+	for _, k := range kids {
+		switch {
+		case k.Kind() == ",":
+			if len(cur) > 0 {
+				out = append(out, cur)
+			}
+			cur = nil
+		case isComment(k) && len(cur) == 0:
+			out = append(out, []*Node{k})
+		default:
+			cur = append(cur, k)
+		}
+	}
+	if len(cur) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func multiline(open *Node, interior []*Node) bool {
+	for _, k := range interior {
+		if k.Kind() == kind.LineComment {
+			return true
+		}
+	}
+	return len(interior) > 0 && interior[0].StartPosition().Row > open.EndPosition().Row
+}
+
+func isBig(g []*Node) bool {
+	first, last := g[0], g[len(g)-1]
+	switch first.Kind() {
+	case kind.FunctionDeclaration, kind.DomainDeclaration, kind.ComponentDeclaration, kind.CapabilityDeclaration:
+		return true
+	}
+	return last.EndPosition().Row > first.StartPosition().Row
+}
+
+func blankBetween(prev, next *Node) bool {
+	return next.StartPosition().Row > prev.EndPosition().Row+1
+}
+
+// atomic reports whether n is printed verbatim from the source.
+func atomic(n *Node) bool {
+	switch n.Kind() {
+	case kind.StringLiteral, kind.CharLiteral:
+		return true
+	}
+	return n.ChildCount() == 0
+}
+
+func isComment(n *Node) bool {
+	return n.Kind() == kind.LineComment || n.Kind() == kind.BlockComment
+}
+
+func children(n *Node) []*Node {
+	out := make([]*Node, n.ChildCount())
+	for i := range out {
+		out[i] = n.Child(uint(i))
+	}
+	return out
+}
+
+func firstLeaf(n *Node) *Node {
+	for !atomic(n) {
+		n = n.Child(0)
+	}
+	return n
+}
+
+func lastLeaf(n *Node) *Node {
+	for !atomic(n) {
+		n = n.Child(n.ChildCount() - 1)
+	}
+	return n
+}
+
+func hasToken(kids []*Node, tok string) bool {
+	return indexOf(kids, tok) >= 0
+}
+
+func indexOf(kids []*Node, tok string) int {
+	return indexOfAny(kids, tok)
+}
+
+func lastIndexOf(kids []*Node, tok string) int {
+	return lastIndexOfAny(kids, tok)
+}
+
+func indexOfAny(kids []*Node, toks ...string) int {
+	for i, k := range kids {
+		if !k.IsNamed() && contains(toks, k.Kind()) {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexOfAny(kids []*Node, toks ...string) int {
+	for i := len(kids) - 1; i >= 0; i-- {
+		if !kids[i].IsNamed() && contains(toks, kids[i].Kind()) {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(set []string, s string) bool {
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package format
+
+import (
+	"context"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/indent"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Edit replaces the bytes of the source between Start and End with Text.
+type Edit struct {
+	Start, End           uint
+	StartPoint, EndPoint tree_sitter.Point
+	Text                 string
+}
+
+// Apply returns src with the edit applied.
+func (e Edit) Apply(src []byte) []byte {
+	out := make([]byte, 0, len(src)-int(e.End-e.Start)+len(e.Text))
+	out = append(out, src[:e.Start]...)
+	out = append(out, e.Text...)
+	return append(out, src[e.End:]...)
+}
+
+// Range formats the smallest construct that encloses the points start and
+// end and occupies whole lines: a statement, match arm, member or
+// top-level item. The rest of the source is left alone, so the file as a
+// whole may contain syntax errors as long as the construct itself does not.
+//
+// The returned edit also covers the indentation in front of the construct,
+// so it is re-indented to its canonical depth.
+func Range(src []byte, start, end tree_sitter.Point) (Edit, error) {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return Edit{}, err
+	}
+	defer tree.Close()
+	return TreeRange(tree, start, end)
+}
+
+// TreeRange is like Range for an already parsed tree.
+func TreeRange(tree *ferrule.Tree, start, end tree_sitter.Point) (Edit, error) {
+	return Options{}.TreeRange(tree, start, end)
+}
+
+// TreeRange is like the package-level TreeRange with the options o.
+func (o Options) TreeRange(tree *ferrule.Tree, start, end tree_sitter.Point) (Edit, error) {
+	src := tree.Source()
+	n := enclosingItem(tree.RootNode().NamedDescendantForPointRange(start, end))
+	if n.HasError() {
+		var diags []ferrule.Diagnostic
+		for _, d := range tree.Diagnostics() {
+			if d.Range.StartByte >= n.StartByte() && d.Range.EndByte <= n.EndByte() {
+				diags = append(diags, d)
+			}
+		}
+		return Edit{}, &SyntaxError{Diagnostics: diags}
+	}
+	if n.Kind() == kind.SourceFile {
+		out, err := o.Tree(tree)
+		if err != nil {
+			return Edit{}, err
+		}
+		return Edit{End: uint(len(src)), EndPoint: n.EndPosition(), Text: string(out)}, nil
+	}
+
+	p := &printer{src: src, width: o.Width, eol: o.Newline.Text(), depth: indent.Of(n), lastRow: n.StartPosition().Row}
+	p.node(n)
+
+	e := Edit{
+		Start:      n.StartByte(),
+		End:        n.EndByte(),
+		StartPoint: n.StartPosition(),
+		EndPoint:   n.EndPosition(),
+		Text:       p.out.String(),
+	}
+	if lineStart := e.Start - e.StartPoint.Column; strings.TrimSpace(string(src[lineStart:e.Start])) == "" {
+		e.Start, e.StartPoint.Column = lineStart, 0
+		e.Text = strings.Repeat(Indent, p.depth) + e.Text
+	}
+	return e, nil
+}
+
+// enclosingItem climbs from n to the nearest node that the printer lays
+// out on lines of its own.
+func enclosingItem(n *Node) *Node {
+	for ; n.Parent() != nil; n = n.Parent() {
+		if isLineContainer(n.Parent()) && !isComment(n) && n.IsNamed() {
+			return n
+		}
+	}
+	return n
+}
+
+func isLineContainer(n *Node) bool {
+	switch n.Kind() {
+	case kind.SourceFile, kind.Block, kind.MatchStatement, kind.MatchExpression,
+		kind.ComponentDeclaration, kind.DomainDeclaration:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package fuzz
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Edit replaces the bytes between Start and End of a source with Text.
+// Offsets past the end of the source are clamped to it.
+type Edit struct {
+	Start, End uint
+	Text       string
+}
+
+func (e Edit) String() string {
+	return fmt.Sprintf("[%d:%d] -> %q", e.Start, e.End, e.Text)
+}
+
+// apply returns src with e applied, and the tree-sitter edit describing
+// the change.
+func (e Edit) apply(src []byte) ([]byte, tree_sitter.InputEdit) {
+	end := min(e.End, uint(len(src)))
+	start := min(e.Start, end)
+	next := make([]byte, 0, uint(len(src))-(end-start)+uint(len(e.Text)))
+	next = append(next, src[:start]...)
+	next = append(next, e.Text...)
+	next = append(next, src[end:]...)
+	newEnd := start + uint(len(e.Text))
+	return next, tree_sitter.InputEdit{
+		StartByte:      start,
+		OldEndByte:     end,
+		NewEndByte:     newEnd,
+		StartPosition:  point(src, start),
+		OldEndPosition: point(src, end),
+		NewEndPosition: point(next, newEnd),
+	}
+}
+
+// Divergence is the error Check returns when an incremental reparse gives
+// a tree different from that of a full parse.
+type Divergence struct {
+	// Source is the text parsed first, and Edits the edits applied to it
+	// in order, the last of them causing the divergence.
+	Source []byte
+	Edits  []Edit
+	// Result is the text after the edits, and Incremental and Full are
+	// the trees of it, written by dump.SExpr.
+	Result            []byte
+	Incremental, Full string
+}
+
+func (d *Divergence) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fuzz: incremental reparse differs from full parse after %d edits of %q:\n", len(d.Edits), d.Source)
+	for i, e := range d.Edits {
+		fmt.Fprintf(&b, "  %d. %v\n", i+1, e)
+	}
+	fmt.Fprintf(&b, "result %q\n--- incremental:\n%s\n--- full:\n%s", d.Result, d.Incremental, d.Full)
+	return b.String()
+}
+
+// Check parses src, then applies edits one at a time, reparsing the edited
+// tree incrementally after each and comparing the result with a full parse
+// of the edited text. It returns a *Divergence for the first edit after
+// which the trees differ, or an error from parsing.
+func Check(ctx context.Context, src []byte, edits []Edit) error {
+	p, err := ferrule.NewParser()
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, src, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { tree.Close() }()
+	cur := src
+	for i, e := range edits {
+		next, edit := e.apply(cur)
+		tree.Raw().Edit(&edit)
+		incremental, err := p.Parse(ctx, next, tree)
+		if err != nil {
+			return err
+		}
+		tree.Close()
+		tree = incremental
+		full, err := p.Parse(ctx, next, nil)
+		if err != nil {
+			return err
+		}
+		got, want := dump.SExpr(incremental.RootNode()), dump.SExpr(full.RootNode())
+		full.Close()
+		if got != want {
+			return &Divergence{Source: src, Edits: edits[:i+1], Result: next, Incremental: got, Full: want}
+		}
+		cur = next
+	}
+	return nil
+}
+
+// fragments are the texts random edits insert: tokens of the language,
+// pieces of them and the delimiters error recovery is most sensitive to.
+var fragments = []string{
+	"", " ", "\n", "\r\n", "\t", "x", "foo", "1", "0x1f", "1.5", "'c'", `"s"`,
+	`"`, "'", "\\", "{", "}", "(", ")", "[", "]", "<", ">", ",", ";", ":",
+	".", "..", "->", "=", "==", "+", "-", "*", "/", "//", "/*", "*/", "?",
+	"function", "function f() -> i32 { return 1; }", "const", "const a = 1;",
+	"var", "type", "type T = i32;", "if", "else", "while", "for", "in",
+	"return", "match", "pub", "import", "package", "domain", "error", "use",
+	"effects", "true", "false", "i32", "String", "é", "日本", "😀",
+}
+
+// RandomEdits returns n random edits of src, each addressing the text the
+// edits before it produce: insertions, deletions and replacements of
+// fragments of the language.
+func RandomEdits(r *rand.Rand, src []byte, n int) []Edit {
+	size := len(src)
+	edits := make([]Edit, n)
+	for i := range edits {
+		start := uint(r.Intn(size + 1))
+		end := start
+		if r.Intn(3) > 0 {
+			end = min(start+uint(r.Intn(16)), uint(size))
+		}
+		text := fragments[r.Intn(len(fragments))]
+		if r.Intn(4) == 0 {
+			text = ""
+		}
+		edits[i] = Edit{Start: start, End: end, Text: text}
+		size += len(text) - int(end-start)
+	}
+	return edits
+}
+
+// Minimize returns a shorter edit sequence on which Check still returns a
+// *Divergence, removing edits, then shrinking the remaining ones as long
+// as the sequence keeps diverging. edits must diverge to begin with.
+func Minimize(ctx context.Context, src []byte, edits []Edit) []Edit {
+	diverges := func(edits []Edit) bool {
+		_, ok := Check(ctx, src, edits).(*Divergence)
+		return ok
+	}
+	if err, ok := Check(ctx, src, edits).(*Divergence); ok {
+		edits = err.Edits
+	}
+	edits = append([]Edit(nil), edits...)
+	for i := len(edits) - 1; i >= 0; i-- {
+		try := append(append([]Edit(nil), edits[:i]...), edits[i+1:]...)
+		if diverges(try) {
+			edits = try
+		}
+	}
+	for i := range edits {
+		for shrunk := true; shrunk; {
+			shrunk = false
+			e := edits[i]
+			for _, c := range shrink(e) {
+				edits[i] = c
+				if diverges(edits) {
+					shrunk = true
+					break
+				}
+				edits[i] = e
+			}
+		}
+	}
+	return edits
+}
+
+// shrink returns the edits one step smaller than e: deleting or inserting
+// one byte less.
+func shrink(e Edit) []Edit {
+	var out []Edit
+	if e.End > e.Start {
+		out = append(out, Edit{e.Start, e.Start, e.Text}, Edit{e.Start, e.End - 1, e.Text}, Edit{e.Start + 1, e.End, e.Text})
+	}
+	if e.Text != "" {
+		out = append(out, Edit{e.Start, e.End, ""}, Edit{e.Start, e.End, e.Text[1:]}, Edit{e.Start, e.End, e.Text[:len(e.Text)-1]})
+	}
+	return out
+}
+
+// Differential applies runs sequences of n random edits, generated from
+// seed, to src, and fails t with the minimized sequence if an incremental
+// reparse diverges from a full parse.
+func Differential(t testing.TB, src []byte, seed int64, runs, n int) {
+	t.Helper()
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(seed))
+	for run := 0; run < runs; run++ {
+		edits := RandomEdits(r, src, n)
+		err := Check(ctx, src, edits)
+		if _, ok := err.(*Divergence); ok {
+			t.Fatalf("seed %d, run %d: %v", seed, run, Check(ctx, src, Minimize(ctx, src, edits)))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package fuzz provides fuzz targets for the parser and the formatter, with
+// seed corpora, for the native fuzzing of go test.
+//
+// Go only runs fuzz targets declared in test files, so a package fuzzes with
+// them by declaring targets that call these:
+//
+//	func FuzzParse(f *testing.F) { fuzz.FuzzParse(f) }
+//
+// and running, for example:
+//
+//	go test -run '^$' -fuzz FuzzParse ./bindings/go/fuzz
+//
+// Inputs that fail are minimized and written to testdata/fuzz/<target> of
+// the package fuzzed, and replayed by every later go test run; commit them
+// as regression tests. The checks behind the targets are exported too, to
+// reproduce a failure in an ordinary test.
+package fuzz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/dump"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+)
+
+// Timeout bounds each parse and each formatting of an input. An input that
+// takes longer fails as a hang.
+var Timeout = 5 * time.Second
+
+// FuzzParse checks that any input parses, within Timeout and without
+// crashing, to a tree that spans it.
+func FuzzParse(f *testing.F) {
+	for _, s := range Seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		Parse(t, src)
+	})
+}
+
+// FuzzFormatRoundTrip checks that formatting is idempotent and keeps the
+// syntax tree of every input that parses without errors.
+func FuzzFormatRoundTrip(f *testing.F) {
+	for _, s := range Seeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		FormatRoundTrip(t, src)
+	})
+}
+
+// FuzzIncrementalEdit checks that replacing the bytes between start and
+// end of an input with text and reparsing incrementally gives the tree a
+// full parse gives.
+func FuzzIncrementalEdit(f *testing.F) {
+	for _, s := range Seeds {
+		n := uint16(len(s))
+		f.Add([]byte(s), n/2, n/2, []byte("x"))
+		f.Add([]byte(s), n/3, n/2, []byte(""))
+		f.Add([]byte(s), uint16(0), n/4, []byte("{ \""))
+	}
+	f.Fuzz(func(t *testing.T, src []byte, start, end uint16, text []byte) {
+		IncrementalEdit(t, src, uint(start), uint(end), text)
+	})
+}
+
+// Parse parses src and fails t if parsing crashes, does not finish within
+// Timeout, or gives a tree that does not end where src does.
+func Parse(t testing.TB, src []byte) *ferrule.Tree {
+	t.Helper()
+	tree := parse(t, src, nil)
+	if end := tree.RootNode().EndByte(); end != uint(len(src)) {
+		t.Fatalf("tree of %d bytes of input ends at byte %d", len(src), end)
+	}
+	return tree
+}
+
+// FormatRoundTrip formats src, if it parses without errors, and fails t if
+// the result does not parse without errors to the same tree, formats
+// differently, or formatting does not finish within Timeout.
+func FormatRoundTrip(t testing.TB, src []byte) {
+	t.Helper()
+	tree := Parse(t, src)
+	if tree.HasError() {
+		return
+	}
+	once := formatWithin(t, src)
+	twice := formatWithin(t, once)
+	if !bytes.Equal(once, twice) {
+		t.Fatalf("formatting is not idempotent:\n--- once:\n%s\n--- twice:\n%s", once, twice)
+	}
+	got, want := dump.SExpr(Parse(t, once).RootNode()), dump.SExpr(tree.RootNode())
+	if got != want {
+		t.Fatalf("formatting changed the tree:\n--- formatted:\n%s\n--- tree before:\n%s\n--- tree after:\n%s", once, want, got)
+	}
+}
+
+// IncrementalEdit parses src, replaces the bytes between start and end
+// with text, both clamped to src, and fails t if reparsing incrementally
+// gives a tree different from that of a full parse of the result, or
+// parsing does not finish within Timeout.
+func IncrementalEdit(t testing.TB, src []byte, start, end uint, text []byte) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	err := Check(ctx, src, []Edit{{Start: start, End: end, Text: string(text)}})
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("parse did not finish within %v", Timeout)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// parse parses src within Timeout and closes the tree when t ends.
+func parse(t testing.TB, src []byte, old *ferrule.Tree) *ferrule.Tree {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, src, old)
+	if errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("parse of %d bytes did not finish within %v", len(src), Timeout)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+// formatWithin formats src and fails t if that does not finish within
+// Timeout. The formatter cannot be interrupted, so a hung formatting is
+// left running.
+func formatWithin(t testing.TB, src []byte) []byte {
+	t.Helper()
+	type result struct {
+		out []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := format.Source(src)
+		done <- result{out, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("formatting %q: %v", src, r.err)
+		}
+		return r.out
+	case <-time.After(Timeout):
+		t.Fatalf("formatting of %d bytes did not finish within %v", len(src), Timeout)
+		return nil
+	}
+}
+
+// point returns the tree-sitter point of the byte offset off in src.
+func point(src []byte, off uint) tree_sitter.Point {
+	line := bytes.LastIndexByte(src[:off], '\n') + 1
+	return tree_sitter.Point{Row: uint(bytes.Count(src[:off], []byte("\n"))), Column: off - uint(line)}
+}
@@ -0,0 +1,36 @@
+package fuzz_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/fuzz"
+)
+
+func FuzzParse(f *testing.F)           { fuzz.FuzzParse(f) }
+func FuzzFormatRoundTrip(f *testing.F) { fuzz.FuzzFormatRoundTrip(f) }
+func FuzzIncrementalEdit(f *testing.F) { fuzz.FuzzIncrementalEdit(f) }
+
+// TestDifferential edits every seed at random and checks that incremental
+// reparses agree with full parses.
+func TestDifferential(t *testing.T) {
+	runs := 20
+	if testing.Short() {
+		runs = 2
+	}
+	for i, s := range fuzz.Seeds {
+		fuzz.Differential(t, []byte(s), int64(i), runs, 10)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	src := []byte("function f() -> i32 {\n  return 1;\n}\n")
+	edits := []fuzz.Edit{
+		{Start: 31, End: 32, Text: "x + 2"},
+		{Start: 0, End: 0, Text: "pub "},
+		{Start: 100, End: 200, Text: "\nconst a = \"b"},
+	}
+	if err := fuzz.Check(context.Background(), src, edits); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,32 @@
+package fuzz
+
+// Seeds are the inputs every target starts from: small programs covering
+// the grammar, and fragments of broken code of the kinds error recovery has
+// to cope with.
+var Seeds = []string{
+	"",
+	"\n",
+	"package example.hello;\n\nimport std.io as io;\n",
+	"domain IoError {\n  NotFound { path: String }\n  Denied { path: String }\n}\n\nuse error IoError;\n",
+	"function add(x: i32, y: i32) -> i32 {\n  return x + y;\n}\n",
+	"pub function main() -> i32 effects [io] {\n  const x: i32 = 10;\n  var y = x * 2 + 1;\n  if y == 21 {\n    return 0;\n  } else {\n    return 1;\n  }\n}\n",
+	"type Point = { x: f64, y: f64 };\ntype Pair<T> = { first: T, second: T };\n",
+	"function f() -> Unit {\n  while true { break; }\n  for i in 0..10 { continue; }\n}\n",
+	"const s = \"a \\\"quoted\\\" string\\n\";\nconst c = 'x';\n",
+	"// line comment\n/* block\n   comment */\nconst a = 1;\n",
+	"function f(x: i32",
+	"function f() -> i32 { return 1;",
+	"const s = \"unterminated;\n",
+	"const c = 'a;",
+	"/* unterminated comment",
+	"type T = ;",
+	"import std.io",
+	"functoin f() -> i32 { return 1; }",
+	"((((((((((((((((((((((((((((((((",
+	"{{{{{{{{{{{{{{{{}}}}}}}}",
+	"const a = 1 + + + ;",
+	"pub pub pub",
+	"\r\r\n\t \x00",
+	"const é = \"日本😀\";\n",
+	"\xff\xfe\xfd",
+}
@@ -0,0 +1,67 @@
+// Package generated recognizes generated ferrule files, which the
+// formatter and the linter leave alone: their code is rewritten by the
+// tool that generated it, not edited.
+//
+// As in Go, a file is generated if it holds a line comment matching
+//
+//	^// Code generated .* DO NOT EDIT\.$
+//
+// among the comments leading it, before its first line of code:
+//
+//	// Code generated by protoc-gen-ferrule from api.proto. DO NOT EDIT.
+//
+//	package api;
+//
+// A byte order mark, a shebang line and blank lines may come before. The
+// paths settings of package config mark files as generated, or not, without
+// regard to their header.
+package generated
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"regexp"
+)
+
+var header = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// Is reports whether src is the source of a generated file.
+func Is(src []byte) bool {
+	ok, _ := Reader(bytes.NewReader(src))
+	return ok
+}
+
+// Reader reports whether the file read from r is generated, reading no
+// further than its first line of code.
+func Reader(r io.Reader) (bool, error) {
+	br := bufio.NewReader(r)
+	for first := true; ; first = false {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// a line this long is no header; what it starts with tells
+			// whether it is code.
+			line = bytes.Clone(line[:min(len(line), 64)])
+			for err == bufio.ErrBufferFull {
+				_, err = br.ReadSlice('\n')
+			}
+		}
+		if first {
+			line = bytes.TrimPrefix(line, []byte("\xef\xbb\xbf"))
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		switch {
+		case header.Match(line):
+			return true, nil
+		case first && bytes.HasPrefix(line, []byte("#!")):
+		case len(bytes.TrimSpace(line)) > 0 && !bytes.HasPrefix(bytes.TrimSpace(line), []byte("//")):
+			return false, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package generated_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/generated"
+)
+
+func TestIs(t *testing.T) {
+	const code = "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\n"
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{code + "\npackage api;\n", true},
+		{"// Copyright 2026 the authors.\n\n" + code + "package api;\n", true},
+		{"\xef\xbb\xbf#!/usr/bin/env ferrule\n" + strings.TrimSuffix(code, "\n") + "\r\nconst x = 1;\r\n", true},
+		{code, true},
+		{"package api;\n\n" + code, false},
+		{"/* Code generated by hand. DO NOT EDIT. */\n", false},
+		{"// Code generated by protoc-gen-ferrule. Do not edit.\n", false},
+		{"// Code generated DO NOT EDIT.\n", false},
+		{"const long = \"" + strings.Repeat("x", 5000) + "\";\n" + code, false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := generated.Is([]byte(tt.src)); got != tt.want {
+			t.Errorf("Is(%.60q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
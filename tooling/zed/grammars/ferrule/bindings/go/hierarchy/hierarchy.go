@@ -0,0 +1,200 @@
+// Package hierarchy computes the call hierarchy of the functions of a
+// ferrule project from its index: the functions that call a function, and
+// those it calls, with the ranges of the calls. Each level is computed on
+// demand, so that editors can expand the tree one function at a time.
+//
+// Calls are the call references of the index, matched to functions by
+// name as hover does: a name defined in the file of the call denotes that
+// definition, and otherwise every function of the project with the name.
+// A call belongs to the innermost function whose range holds it; calls
+// outside functions, such as in the values of top-level constants, belong
+// to none and are left out.
+package hierarchy
+
+import (
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Item is a function or method of the project.
+type Item struct {
+	Path string
+	index.Definition
+}
+
+// Call is a call between two functions.
+type Call struct {
+	// Item is the calling function of an incoming call and the called one
+	// of an outgoing call.
+	Item Item
+	// Ranges are those of the called names, in the file of the caller,
+	// in order.
+	Ranges []tree_sitter.Range
+}
+
+// At returns the functions at p in the file path: the function whose name
+// is at p, or the functions a call at p may denote. It returns nil when
+// there is neither.
+func At(idx *index.Index, path string, p tree_sitter.Point) []Item {
+	f := idx.File(path)
+	if f == nil {
+		return nil
+	}
+	for _, d := range f.Definitions {
+		if isFunction(d) && contains(d.SelectionRange, p) {
+			return []Item{{Path: path, Definition: d}}
+		}
+	}
+	for _, r := range f.References {
+		if r.Kind == "call" && contains(r.Range, p) {
+			return targets(idx, f, r.Name)
+		}
+	}
+	return nil
+}
+
+// Callers returns the incoming calls of item: the functions calling it,
+// ordered by path and position, each with the calls it makes.
+func Callers(idx *index.Index, item Item) []Call {
+	var out []Call
+	for _, f := range idx.Files() {
+		if f.Path != item.Path && defines(f, item.Name) {
+			continue
+		}
+		byCaller := make(map[int]int)
+		for _, r := range f.References {
+			if r.Kind != "call" || r.Name != item.Name {
+				continue
+			}
+			caller := enclosing(f, r.Range)
+			if caller < 0 {
+				continue
+			}
+			i, ok := byCaller[caller]
+			if !ok {
+				i = len(out)
+				byCaller[caller] = i
+				out = append(out, Call{Item: Item{Path: f.Path, Definition: f.Definitions[caller]}})
+			}
+			out[i].Ranges = append(out[i].Ranges, r.Range)
+		}
+	}
+	sortCalls(out)
+	return out
+}
+
+// Callees returns the outgoing calls of item: the functions it calls,
+// ordered by path and position, each with the calls made to it. Calls of
+// names that no function of the project has, such as builtins, are left
+// out.
+func Callees(idx *index.Index, item Item) []Call {
+	f := idx.File(item.Path)
+	if f == nil {
+		return nil
+	}
+	self := -1
+	for i, d := range f.Definitions {
+		if d.SelectionRange == item.SelectionRange && d.Name == item.Name {
+			self = i
+		}
+	}
+	if self < 0 {
+		// the index is out of date.
+		return nil
+	}
+	var out []Call
+	byCallee := make(map[Item]int)
+	for _, r := range f.References {
+		if r.Kind != "call" || enclosing(f, r.Range) != self {
+			continue
+		}
+		for _, callee := range targets(idx, f, r.Name) {
+			i, ok := byCallee[callee]
+			if !ok {
+				i = len(out)
+				byCallee[callee] = i
+				out = append(out, Call{Item: callee})
+			}
+			out[i].Ranges = append(out[i].Ranges, r.Range)
+		}
+	}
+	sortCalls(out)
+	return out
+}
+
+// targets returns the functions a call of name in f may denote.
+func targets(idx *index.Index, f *index.File, name string) []Item {
+	var out []Item
+	for _, d := range f.Definitions {
+		if d.Name == name && isFunction(d) {
+			out = append(out, Item{Path: f.Path, Definition: d})
+		}
+	}
+	if out != nil || defines(f, name) {
+		return out
+	}
+	for _, other := range idx.Files() {
+		if other.Path == f.Path {
+			continue
+		}
+		for _, d := range other.Definitions {
+			if d.Name == name && isFunction(d) {
+				out = append(out, Item{Path: other.Path, Definition: d})
+			}
+		}
+	}
+	return out
+}
+
+// defines reports whether f has a top-level definition of name, which
+// hides those of other files.
+func defines(f *index.File, name string) bool {
+	for _, d := range f.Definitions {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosing returns the index in f.Definitions of the innermost function
+// whose range holds r, or -1.
+func enclosing(f *index.File, r tree_sitter.Range) int {
+	found := -1
+	for i, d := range f.Definitions {
+		if !isFunction(d) || d.Range.StartByte > r.StartByte || d.Range.EndByte < r.EndByte {
+			continue
+		}
+		if found < 0 || d.Range.EndByte-d.Range.StartByte < f.Definitions[found].Range.EndByte-f.Definitions[found].Range.StartByte {
+			found = i
+		}
+	}
+	return found
+}
+
+func isFunction(d index.Definition) bool {
+	return d.Kind == symbols.Function || d.Kind == symbols.Method
+}
+
+// contains reports whether p is within r, its end included.
+func contains(r tree_sitter.Range, p tree_sitter.Point) bool {
+	return !before(p, r.StartPoint) && !before(r.EndPoint, p)
+}
+
+func before(a, b tree_sitter.Point) bool {
+	return a.Row < b.Row || a.Row == b.Row && a.Column < b.Column
+}
+
+func sortCalls(calls []Call) {
+	sort.SliceStable(calls, func(i, j int) bool {
+		a, b := calls[i].Item, calls[j].Item
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Range.StartByte < b.Range.StartByte
+	})
+}
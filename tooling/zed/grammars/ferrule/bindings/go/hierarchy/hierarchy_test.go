@@ -0,0 +1,114 @@
+package hierarchy_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/hierarchy"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+var project = map[string]string{
+	"main.fe": `package app;
+
+function main() -> Unit {
+  run(1);
+  print(scale(2));
+}
+
+function run(n: u32) -> u32 {
+  return scale(n) + scale(n + 1);
+}
+`,
+	"util.fe": `package app.util;
+
+pub function scale(v: u32) -> u32 {
+  return v * 2;
+}
+`,
+	"other.fe": `package other;
+
+function scale(v: u32) -> u32 {
+  return v;
+}
+
+function twice() -> u32 {
+  return scale(scale(1));
+}
+`,
+}
+
+func build(t *testing.T) *index.Index {
+	t.Helper()
+	root := t.TempDir()
+	for name, src := range project {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// summary renders calls as "path:name(row:column ...)".
+func summary(calls []hierarchy.Call) []string {
+	var out []string
+	for _, c := range calls {
+		s := fmt.Sprintf("%s:%s(", c.Item.Path, c.Item.Name)
+		for i, r := range c.Ranges {
+			if i > 0 {
+				s += " "
+			}
+			s += fmt.Sprintf("%d:%d", r.StartPoint.Row, r.StartPoint.Column)
+		}
+		out = append(out, s+")")
+	}
+	return out
+}
+
+func TestCallers(t *testing.T) {
+	idx := build(t)
+	items := hierarchy.At(idx, "util.fe", tree_sitter.Point{Row: 2, Column: 15})
+	if len(items) != 1 || items[0].Name != "scale" {
+		t.Fatalf("At: got %+v, want scale", items)
+	}
+	got := summary(hierarchy.Callers(idx, items[0]))
+	want := []string{"main.fe:main(4:8)", "main.fe:run(8:9 8:20)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCallees(t *testing.T) {
+	idx := build(t)
+	items := hierarchy.At(idx, "main.fe", tree_sitter.Point{Row: 3, Column: 3})
+	if len(items) != 1 || items[0].Name != "run" {
+		t.Fatalf("At: got %+v, want run", items)
+	}
+	main := hierarchy.At(idx, "main.fe", tree_sitter.Point{Row: 2, Column: 10})
+	if len(main) != 1 {
+		t.Fatalf("At: got %+v, want main", main)
+	}
+	got := summary(hierarchy.Callees(idx, main[0]))
+	// scale is defined twice outside main.fe, and either may be meant.
+	want := []string{"main.fe:run(3:2)", "other.fe:scale(4:8)", "util.fe:scale(4:8)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	twice := hierarchy.At(idx, "other.fe", tree_sitter.Point{Row: 6, Column: 10})
+	got = summary(hierarchy.Callees(idx, twice[0]))
+	want = []string{"other.fe:scale(7:9 7:15)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
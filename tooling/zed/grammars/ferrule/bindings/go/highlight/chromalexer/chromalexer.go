@@ -0,0 +1,138 @@
+// Package chromalexer adapts the ferrule highlighter to
+// github.com/alecthomas/chroma, so that tools built on chroma, such as Hugo
+// and glow, can highlight ferrule code.
+//
+// Importing the package registers Lexer with chroma's lexer registry under
+// the names "ferrule" and "fe" and the file pattern *.fe:
+//
+//	import _ "github.com/karol-broda/ferrule/bindings/go/highlight/chromalexer"
+//
+// Tokens come from the tree-sitter parse and the bundled highlights query,
+// not from regular expressions, so they agree with what editors show.
+package chromalexer
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Lexer is the ferrule lexer, registered with lexers.Registry.
+var Lexer = lexers.Register(&lexer{config: &chroma.Config{
+	Name:      "Ferrule",
+	Aliases:   []string{"ferrule", "fe"},
+	Filenames: []string{"*.fe"},
+	MimeTypes: []string{"text/x-ferrule"},
+}})
+
+// tokenTypes maps capture names of the highlights query to chroma token
+// types. Captures missing here use their closest parent, as in
+// highlight.Theme.
+var tokenTypes = map[string]chroma.TokenType{
+	"boolean":            chroma.KeywordConstant,
+	"comment":            chroma.Comment,
+	"constant.builtin":   chroma.KeywordConstant,
+	"constructor":        chroma.NameClass,
+	"function":           chroma.NameFunction,
+	"keyword":            chroma.Keyword,
+	"keyword.import":     chroma.KeywordNamespace,
+	"keyword.modifier":   chroma.KeywordReserved,
+	"module":             chroma.NameNamespace,
+	"number":             chroma.LiteralNumber,
+	"operator":           chroma.Operator,
+	"property":           chroma.NameProperty,
+	"punctuation":        chroma.Punctuation,
+	"string":             chroma.LiteralString,
+	"string.escape":      chroma.LiteralStringEscape,
+	"type":               chroma.NameClass,
+	"type.builtin":       chroma.KeywordType,
+	"variable":           chroma.NameVariable,
+	"variable.builtin":   chroma.NameBuiltinPseudo,
+	"variable.parameter": chroma.NameVariable,
+}
+
+type lexer struct {
+	config   *chroma.Config
+	registry *chroma.LexerRegistry
+	analyser func(text string) float32
+}
+
+func (l *lexer) Config() *chroma.Config { return l.config }
+
+func (l *lexer) SetRegistry(r *chroma.LexerRegistry) chroma.Lexer {
+	l.registry = r
+	return l
+}
+
+func (l *lexer) SetAnalyser(analyser func(text string) float32) chroma.Lexer {
+	l.analyser = analyser
+	return l
+}
+
+// AnalyseText scores text by whether it parses as ferrule with at least one
+// declaration, unless another analyser was set.
+func (l *lexer) AnalyseText(text string) float32 {
+	if l.analyser != nil {
+		return l.analyser(text)
+	}
+	tree, err := ferrule.Parse(context.Background(), []byte(text))
+	if err != nil {
+		return 0
+	}
+	defer tree.Close()
+	root := tree.RootNode()
+	if root.HasError() {
+		return 0
+	}
+	for i := uint(0); i < root.NamedChildCount(); i++ {
+		switch root.NamedChild(i).Kind() {
+		case kind.PackageDeclaration, kind.FunctionDeclaration, kind.DomainDeclaration:
+			return 0.5
+		}
+	}
+	return 0
+}
+
+func (l *lexer) Tokenise(_ *chroma.TokeniseOptions, text string) (chroma.Iterator, error) {
+	tree, err := ferrule.Parse(context.Background(), []byte(text))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+
+	var tokens []chroma.Token
+	for _, s := range highlight.Spans(tree) {
+		value := text[s.Start:s.End]
+		typ := tokenType(s.Capture, value)
+		if n := len(tokens); n > 0 && tokens[n-1].Type == typ {
+			tokens[n-1].Value += value
+			continue
+		}
+		tokens = append(tokens, chroma.Token{Type: typ, Value: value})
+	}
+	return chroma.Literator(tokens...), nil
+}
+
+func tokenType(capture, value string) chroma.TokenType {
+	for name := capture; name != ""; {
+		if t, ok := tokenTypes[name]; ok {
+			return t
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	if strings.TrimFunc(value, unicode.IsSpace) == "" {
+		return chroma.TextWhitespace
+	}
+	return chroma.Text
+}
@@ -0,0 +1,57 @@
+package chromalexer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+
+	"github.com/karol-broda/ferrule/bindings/go/highlight/chromalexer"
+)
+
+func TestRegistered(t *testing.T) {
+	for _, l := range []chroma.Lexer{lexers.Get("ferrule"), lexers.Get("fe"), lexers.Match("main.fe")} {
+		if l != chromalexer.Lexer {
+			t.Errorf("registry returned %v, want the ferrule lexer", l)
+		}
+	}
+}
+
+func TestTokenise(t *testing.T) {
+	const src = "function add(x: i32) -> i32 { return x + 1; } // done\n"
+	it, err := chromalexer.Lexer.Tokenise(nil, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text strings.Builder
+	types := make(map[string]chroma.TokenType)
+	for _, tok := range it.Tokens() {
+		text.WriteString(tok.Value)
+		types[strings.TrimSpace(tok.Value)] = tok.Type
+	}
+	if text.String() != src {
+		t.Errorf("tokens do not reproduce the source: %q", text.String())
+	}
+	want := map[string]chroma.TokenType{
+		"function": chroma.Keyword,
+		"add":      chroma.NameFunction,
+		"i32":      chroma.KeywordType,
+		"1":        chroma.LiteralNumber,
+		"// done":  chroma.Comment,
+	}
+	for value, typ := range want {
+		if types[value] != typ {
+			t.Errorf("%q has type %v, want %v", value, types[value], typ)
+		}
+	}
+}
+
+func TestAnalyseText(t *testing.T) {
+	if s := chromalexer.Lexer.AnalyseText("package demo;\nfunction main() -> Unit {}\n"); s <= 0 {
+		t.Errorf("score for ferrule source = %v", s)
+	}
+	if s := chromalexer.Lexer.AnalyseText("def main():\n    pass\n"); s != 0 {
+		t.Errorf("score for python source = %v", s)
+	}
+}
@@ -0,0 +1,107 @@
+// Package highlight renders ferrule source with syntax highlighting.
+//
+// Highlighting is driven by the bundled highlights query. Every byte of the
+// source takes the capture name of the innermost node captured around it;
+// when several patterns capture the same node, the one appearing first in
+// the query wins, so the query lists specific patterns before fallbacks.
+//
+// A Highlighter made by WithInjections also highlights the languages the
+// injections query finds embedded in the source, such as the SQL of
+// sql("select 1"), with the grammars of a Registry. An Incremental
+// highlights a document as it is edited, querying again only around the
+// edits.
+package highlight
+
+import (
+	"sort"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var highlights = query.MustCompile(string(queries.Highlights))
+
+// A Span is a run of source text sharing one capture name, such as
+// "keyword.control". Capture is empty for text that is not highlighted.
+type Span struct {
+	Start, End uint
+	Capture    string
+}
+
+// Spans splits the source of tree into highlighted runs. The spans are
+// contiguous and cover the whole source. Embedded languages are left as
+// they are; see WithInjections.
+func Spans(tree *ferrule.Tree) []Span {
+	return (&Highlighter{}).Spans(tree)
+}
+
+// canvas holds the capture painted on every byte of the source.
+type canvas struct {
+	names []string
+	index map[string]int32
+	paint []int32
+}
+
+func newCanvas(size int) *canvas {
+	return &canvas{names: []string{""}, index: map[string]int32{"": 0}, paint: make([]int32, size)}
+}
+
+// capture is a capture of a highlights query, in bytes of the source.
+type capture struct {
+	start, end uint
+	name       string
+	pattern    uint
+}
+
+// apply paints the captures of q under root, a tree of the text starting
+// at byte base of the source, over what is painted already.
+func (c *canvas) apply(q *query.Query, root ast.Node, src []byte, base uint) {
+	var captures []capture
+	for cp, n := range q.Matches(root, src) {
+		r := n.Raw()
+		if r.StartByte() < r.EndByte() {
+			captures = append(captures, capture{base + r.StartByte(), base + r.EndByte(), cp.Name, cp.Pattern})
+		}
+	}
+	c.paintAll(captures)
+}
+
+// paintAll paints captures over what is painted already.
+func (c *canvas) paintAll(captures []capture) {
+	// paint outer captures first so inner ones overwrite them, and for equal
+	// ranges later patterns first so the earliest pattern is painted last.
+	sort.SliceStable(captures, func(i, j int) bool {
+		a, b := captures[i], captures[j]
+		if la, lb := a.end-a.start, b.end-b.start; la != lb {
+			return la > lb
+		}
+		return a.pattern > b.pattern
+	})
+	for _, cp := range captures {
+		id, ok := c.index[cp.name]
+		if !ok {
+			id = int32(len(c.names))
+			c.index[cp.name] = id
+			c.names = append(c.names, cp.name)
+		}
+		for i := cp.start; i < min(cp.end, uint(len(c.paint))); i++ {
+			c.paint[i] = id
+		}
+	}
+}
+
+// spans returns the runs of equal paint.
+func (c *canvas) spans() []Span {
+	var spans []Span
+	for i := 0; i < len(c.paint); {
+		j := i + 1
+		for j < len(c.paint) && c.paint[j] == c.paint[i] {
+			j++
+		}
+		spans = append(spans, Span{Start: uint(i), End: uint(j), Capture: c.names[c.paint[i]]})
+		i = j
+	}
+	return spans
+}
@@ -0,0 +1,202 @@
+package highlight_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+const source = `// add things
+function add(x: i32) -> i32 { return x + "a\n"; }
+`
+
+func spans(t *testing.T) []highlight.Span {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return highlight.Spans(tree)
+}
+
+func TestSpans(t *testing.T) {
+	got := make(map[string]string)
+	end := uint(0)
+	for _, s := range spans(t) {
+		if s.Start != end {
+			t.Fatalf("span %v does not start where the previous one ended (%d)", s, end)
+		}
+		end = s.End
+		if s.Capture != "" {
+			got[source[s.Start:s.End]] = s.Capture
+		}
+	}
+	if end != uint(len(source)) {
+		t.Errorf("spans end at %d, want %d", end, len(source))
+	}
+	want := map[string]string{
+		"// add things": "comment",
+		"function":      "keyword",
+		"add":           "function",
+		"return":        "keyword.control",
+		"i32":           "type.builtin",
+		"x":             "variable",
+		`\n`:            "string.escape",
+		`"a`:            "string",
+		"+":             "operator",
+	}
+	for text, capture := range want {
+		if got[text] != capture {
+			t.Errorf("%q highlighted as %q, want %q", text, got[text], capture)
+		}
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	ctx := context.Background()
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, []byte(source), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { tree.Close() }()
+
+	h := highlight.NewIncremental()
+	h.Spans(tree, nil)
+	for _, text := range []string{"const y = 1;\n", "// ", "\n"} {
+		src := tree.Source()
+		at := edits.PositionOf(src, uint(strings.Index(string(src), "function")))
+		next, edit, err := edits.Apply(src, edits.Change{Range: &edits.Range{Start: at, End: at}, Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Raw().Edit(&edit)
+		h.Edit(&edit)
+		updated, err := p.Parse(ctx, next, tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := tree.Raw().ChangedRanges(updated.Raw())
+		tree.Close()
+		tree = updated
+
+		got, want := h.Spans(tree, changed), highlight.Spans(tree)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("after inserting %q: spans %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := highlight.HTML(&buf, spans(t), []byte(source), ""); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<span class="fe-keyword fe-keyword-control">return</span>`,
+		`<span class="fe-string">&#34;a</span>`,
+		`<span class="fe-comment">// add things</span>` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("HTML output lacks %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := highlight.CSS(&buf, highlight.DefaultTheme, "x-"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ".x-keyword-control { color: #c678dd; font-weight: bold; }\n") {
+		t.Errorf("unexpected CSS:\n%s", buf.String())
+	}
+}
+
+func TestANSI(t *testing.T) {
+	theme := highlight.Theme{
+		"comment": {Italic: true},
+		"keyword": {Color: "#ff0000", Bold: true},
+	}
+	var buf bytes.Buffer
+	if err := highlight.ANSI(&buf, spans(t), []byte(source), theme); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"\x1b[3m// add things\x1b[0m\n",
+		"\x1b[1;38;2;255;0;0mfunction\x1b[0m add",
+		"\x1b[1;38;2;255;0;0mreturn\x1b[0m",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("ANSI output lacks %q:\n%q", want, out)
+		}
+	}
+}
+
+func TestInjections(t *testing.T) {
+	const src = "function f() -> i32 { return sql(\"const x = 1;\") + json(\"[]\"); }\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	got := highlight.Injections(tree)
+	inner := strings.Index(src, "const")
+	want := []highlight.Injection{
+		{Start: uint(inner), End: uint(inner + len("const x = 1;")), Language: "sql"},
+		{Start: uint(strings.Index(src, "[]")), End: uint(strings.Index(src, "[]") + 2), Language: "json"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Injections() = %v, want %v", got, want)
+	}
+
+	// stand in for a SQL grammar with the ferrule one.
+	registry := highlight.NewRegistry()
+	if err := registry.Register("sql", ferrule.Language(), string(queries.Highlights), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("broken", ferrule.Language(), "(nonsense", ""); err == nil {
+		t.Error("Register accepted a malformed query")
+	}
+	captures := func(spans []highlight.Span) map[string]string {
+		out := make(map[string]string)
+		for _, s := range spans {
+			out[src[s.Start:s.End]] = s.Capture
+		}
+		return out
+	}
+	plain := captures(highlight.Spans(tree))
+	injected := captures(highlight.WithInjections(registry).Spans(tree))
+	if plain["const"] != "" || plain[`"const x = 1;"`] != "string" {
+		t.Errorf("plain highlighting of the SQL string: %v", plain)
+	}
+	if injected["const"] != "keyword" || injected[`"`] != "string" {
+		t.Errorf("injected highlighting: const is %q, quotes are %q", injected["const"], injected[`"`])
+	}
+	if injected[`"[]"`] != "string" {
+		t.Errorf("unregistered language highlighted as %q, want string", injected[`"[]"`])
+	}
+}
+
+func TestLookup(t *testing.T) {
+	theme := highlight.Theme{"keyword": {Bold: true}}
+	if s, ok := theme.Lookup("keyword.control.return"); !ok || !s.Bold {
+		t.Errorf("Lookup did not fall back to the parent capture")
+	}
+	if _, ok := theme.Lookup("string"); ok {
+		t.Errorf("Lookup found a missing capture")
+	}
+}
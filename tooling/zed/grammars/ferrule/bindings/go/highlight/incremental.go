@@ -0,0 +1,43 @@
+package highlight
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+)
+
+// Incremental highlights the successive trees of a document being edited,
+// running the highlights query again only around the changes, as
+// query.CachedRunner does. Embedded languages are left as they are.
+type Incremental struct {
+	runner *query.CachedRunner
+}
+
+// NewIncremental returns an Incremental with nothing highlighted yet.
+func NewIncremental() *Incremental {
+	return &Incremental{runner: query.NewCachedRunner(highlights)}
+}
+
+// Edit records an edit made to the tree last highlighted.
+func (h *Incremental) Edit(edit *tree_sitter.InputEdit) { h.runner.Edit(edit) }
+
+// Spans splits the source of tree into highlighted runs, as the package
+// function does. changed lists the ranges ChangedRanges reports between
+// the tree last highlighted and this one.
+func (h *Incremental) Spans(tree *ferrule.Tree, changed []tree_sitter.Range) []Span {
+	src := tree.Source()
+	c := newCanvas(len(src))
+	var captures []capture
+	for _, cp := range h.runner.Run(tree.Root(), src, changed) {
+		if cp.Range.StartByte < cp.Range.EndByte {
+			captures = append(captures, capture{cp.Range.StartByte, cp.Range.EndByte, cp.Name, cp.Pattern})
+		}
+	}
+	c.paintAll(captures)
+	return c.spans()
+}
+
+// Reset forgets the highlighting, for a document replaced rather than
+// edited.
+func (h *Incremental) Reset() { h.runner.Reset() }
@@ -0,0 +1,200 @@
+package highlight
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var injections = query.MustCompile(string(queries.Injections))
+
+// maxDepth bounds how deeply languages are embedded in one another.
+const maxDepth = 4
+
+// A Registry holds the grammars of the languages that may be embedded in
+// ferrule source, by the names the injections query gives them, such as
+// "sql". It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	langs map[string]*grammar
+}
+
+type grammar struct {
+	lang                   *tree_sitter.Language
+	highlights, injections *query.Query
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{langs: make(map[string]*grammar)}
+}
+
+// Register adds the language name, parsed with lang and highlighted with
+// the highlights query. The injections query, if not empty, finds the
+// languages embedded in it in turn, with the captures @injection.content
+// and @injection.language or the property injection.language. A language
+// registered again replaces the earlier one.
+func (r *Registry) Register(name string, lang *tree_sitter.Language, highlights, injections string) error {
+	g := &grammar{lang: lang}
+	var err error
+	if g.highlights, err = query.ForLanguage(lang, highlights); err != nil {
+		return fmt.Errorf("highlight: %s highlights: %w", name, err)
+	}
+	if injections != "" {
+		if g.injections, err = query.ForLanguage(lang, injections); err != nil {
+			g.highlights.Close()
+			return fmt.Errorf("highlight: %s injections: %w", name, err)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.langs[name] = g
+	return nil
+}
+
+// Languages returns the names of the registered languages, sorted.
+func (r *Registry) Languages() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.langs))
+	for name := range r.langs {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (r *Registry) lookup(name string) *grammar {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.langs[name]
+}
+
+// A Highlighter highlights ferrule source and the languages embedded in
+// it. The zero value highlights ferrule only.
+type Highlighter struct {
+	registry *Registry
+}
+
+// WithInjections returns a highlighter that parses the text the
+// injections query marks with the grammar registered for its language,
+// and paints the captures of that grammar's highlights query over those
+// of the ferrule one. Text whose language is not registered keeps its
+// ferrule highlighting, as does embedded text the embedded grammar does
+// not capture.
+func WithInjections(registry *Registry) *Highlighter {
+	return &Highlighter{registry: registry}
+}
+
+// Spans splits the source of tree into highlighted runs. The spans are
+// contiguous and cover the whole source.
+func (h *Highlighter) Spans(tree *ferrule.Tree) []Span {
+	src := tree.Source()
+	c := newCanvas(len(src))
+	c.apply(highlights, tree.Root(), src, 0)
+	if h.registry != nil {
+		h.inject(c, injections, tree.Root(), src, 0, 1)
+	}
+	return c.spans()
+}
+
+// An Injection is a region of the source in another language.
+type Injection struct {
+	Start, End uint
+	Language   string
+}
+
+// Injections returns the regions of the source of tree that the
+// injections query marks, in source order, whether or not their language
+// is registered. Nested injections are not included.
+func Injections(tree *ferrule.Tree) []Injection {
+	return find(injections, tree.Root(), tree.Source(), 0)
+}
+
+// find returns the injections q marks under root, a tree of the text
+// starting at byte base of the source.
+func find(q *query.Query, root ast.Node, src []byte, base uint) []Injection {
+	type match struct {
+		content  *tree_sitter.Node
+		language string
+	}
+	byMatch := make(map[uint]*match)
+	var order []uint
+	for c, n := range q.Matches(root, src) {
+		m := byMatch[c.Match]
+		if m == nil {
+			m = &match{}
+			byMatch[c.Match] = m
+			order = append(order, c.Match)
+		}
+		switch c.Name {
+		case "injection.content":
+			m.content = n.Raw()
+			if lang := c.Properties["injection.language"]; lang != "" && m.language == "" {
+				m.language = lang
+			}
+		case "injection.language":
+			m.language = n.Text(src)
+		}
+	}
+	var out []Injection
+	for _, id := range order {
+		m := byMatch[id]
+		if m.content == nil || m.language == "" {
+			continue
+		}
+		start, end := contents(m.content, src)
+		out = append(out, Injection{Start: base + start, End: base + end, Language: m.language})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	return out
+}
+
+// contents returns the range of the text of n inside its delimiters, for
+// string literals, or the whole of n.
+func contents(n *tree_sitter.Node, src []byte) (uint, uint) {
+	start, end := n.StartByte(), n.EndByte()
+	if first, last := n.Child(0), n.Child(n.ChildCount()-1); n.ChildCount() >= 2 &&
+		!first.IsNamed() && !last.IsNamed() && first.Kind() == last.Kind() && first.Kind() == `"` {
+		start, end = first.EndByte(), last.StartByte()
+	}
+	return start, end
+}
+
+// inject paints the languages q finds embedded under root, a tree of the
+// text starting at byte base of the source.
+func (h *Highlighter) inject(c *canvas, q *query.Query, root ast.Node, src []byte, base uint, depth int) {
+	for _, in := range find(q, root, src, base) {
+		g := h.registry.lookup(in.Language)
+		if g == nil || in.Start >= in.End {
+			continue
+		}
+		text := src[in.Start-base : in.End-base]
+		parser := tree_sitter.NewParser()
+		if err := parser.SetLanguage(g.lang); err != nil {
+			parser.Close()
+			continue
+		}
+		tree := parser.Parse(text, nil)
+		parser.Close()
+		if tree == nil {
+			continue
+		}
+		embedded := ast.Wrap(tree.RootNode())
+		c.apply(g.highlights, embedded, text, in.Start)
+		if g.injections != nil && depth < maxDepth {
+			h.inject(c, g.injections, embedded, text, in.Start, depth+1)
+		}
+		tree.Close()
+	}
+}
@@ -0,0 +1,101 @@
+package highlight
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+)
+
+// DefaultClassPrefix is the prefix of the CSS classes written by HTML when
+// none is given.
+const DefaultClassPrefix = "fe-"
+
+// HTML writes the source of tree as HTML-escaped text in which highlighted
+// runs are wrapped in spans carrying the classes of their capture and its
+// parents, e.g. <span class="fe-keyword fe-keyword-control">. It writes no
+// surrounding <pre> element. An empty prefix means DefaultClassPrefix.
+func HTML(w io.Writer, spans []Span, src []byte, prefix string) error {
+	if prefix == "" {
+		prefix = DefaultClassPrefix
+	}
+	bw := bufio.NewWriter(w)
+	for _, s := range spans {
+		text := html.EscapeString(string(src[s.Start:s.End]))
+		if s.Capture == "" {
+			bw.WriteString(text)
+			continue
+		}
+		fmt.Fprintf(bw, `<span class="%s">%s</span>`, classes(prefix, s.Capture), text)
+	}
+	return bw.Flush()
+}
+
+// ANSI writes the source with the styles of theme as 24-bit terminal color
+// escape sequences. Styles are reset at the end of every line, so the
+// output can be paged or cut line by line.
+func ANSI(w io.Writer, spans []Span, src []byte, theme Theme) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range spans {
+		style, ok := theme.Lookup(s.Capture)
+		seq := sgr(style)
+		if !ok || seq == "" {
+			bw.Write(src[s.Start:s.End])
+			continue
+		}
+		// restart the style on every line of multi-line spans.
+		start := s.Start
+		for i := s.Start; i <= s.End; i++ {
+			if i < s.End && src[i] != '\n' {
+				continue
+			}
+			if start < i {
+				bw.WriteString(seq)
+				bw.Write(src[start:i])
+				bw.WriteString("\x1b[0m")
+			}
+			if i < s.End {
+				bw.WriteByte('\n')
+			}
+			start = i + 1
+		}
+	}
+	return bw.Flush()
+}
+
+// sgr returns the escape sequence selecting style, or "" if it changes
+// nothing.
+func sgr(s Style) string {
+	var params string
+	add := func(p string) {
+		if params != "" {
+			params += ";"
+		}
+		params += p
+	}
+	if s.Bold {
+		add("1")
+	}
+	if s.Italic {
+		add("3")
+	}
+	if r, g, b, ok := parseHex(s.Color); ok {
+		add(fmt.Sprintf("38;2;%d;%d;%d", r, g, b))
+	}
+	if params == "" {
+		return ""
+	}
+	return "\x1b[" + params + "m"
+}
+
+func parseHex(color string) (r, g, b uint8, ok bool) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
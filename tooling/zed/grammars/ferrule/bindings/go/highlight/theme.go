@@ -0,0 +1,107 @@
+package highlight
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Style is how one capture is rendered.
+type Style struct {
+	// Color is a CSS hex color such as "#c678dd". Empty keeps the default
+	// foreground.
+	Color  string
+	Bold   bool
+	Italic bool
+}
+
+// Theme maps capture names to styles. A capture without an entry of its
+// own uses its closest parent: "keyword.control" falls back to "keyword".
+type Theme map[string]Style
+
+// Lookup returns the style for a capture name.
+func (t Theme) Lookup(capture string) (Style, bool) {
+	for capture != "" {
+		if s, ok := t[capture]; ok {
+			return s, true
+		}
+		i := strings.LastIndexByte(capture, '.')
+		if i < 0 {
+			break
+		}
+		capture = capture[:i]
+	}
+	return Style{}, false
+}
+
+// DefaultTheme is a dark theme covering the captures of the bundled query.
+var DefaultTheme = Theme{
+	"boolean":            {Color: "#d19a66"},
+	"comment":            {Color: "#7f848e", Italic: true},
+	"constant.builtin":   {Color: "#d19a66"},
+	"constructor":        {Color: "#e5c07b"},
+	"function":           {Color: "#61afef"},
+	"keyword":            {Color: "#c678dd"},
+	"keyword.control":    {Color: "#c678dd", Bold: true},
+	"module":             {Color: "#e5c07b"},
+	"number":             {Color: "#d19a66"},
+	"operator":           {Color: "#56b6c2"},
+	"property":           {Color: "#e06c75"},
+	"punctuation":        {Color: "#abb2bf"},
+	"string":             {Color: "#98c379"},
+	"string.escape":      {Color: "#56b6c2"},
+	"type":               {Color: "#e5c07b"},
+	"type.builtin":       {Color: "#e5c07b", Italic: true},
+	"type.definition":    {Color: "#e5c07b", Bold: true},
+	"variable.builtin":   {Color: "#e06c75", Italic: true},
+	"variable.parameter": {Color: "#e06c75"},
+}
+
+// CSS writes a stylesheet for the theme, with one rule per entry for the
+// class names HTML produces with the given prefix. Rules for longer names
+// come later, so they override the rules of their parents.
+func CSS(w io.Writer, theme Theme, prefix string) error {
+	names := make([]string, 0, len(theme))
+	for name := range theme {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := theme[name]
+		var decls []string
+		if s.Color != "" {
+			decls = append(decls, "color: "+s.Color)
+		}
+		if s.Bold {
+			decls = append(decls, "font-weight: bold")
+		}
+		if s.Italic {
+			decls = append(decls, "font-style: italic")
+		}
+		if len(decls) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, ".%s { %s; }\n", className(prefix, name), strings.Join(decls, "; ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// className turns a capture name into a CSS class: "keyword.control"
+// becomes prefix + "keyword-control".
+func className(prefix, capture string) string {
+	return prefix + strings.ReplaceAll(capture, ".", "-")
+}
+
+// classes returns the classes of a capture and all its parents, e.g.
+// "fe-keyword fe-keyword-control".
+func classes(prefix, capture string) string {
+	parts := strings.Split(capture, ".")
+	out := make([]string, len(parts))
+	for i := range parts {
+		out[i] = className(prefix, strings.Join(parts[:i+1], "."))
+	}
+	return strings.Join(out, " ")
+}
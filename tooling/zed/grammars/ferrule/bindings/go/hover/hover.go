@@ -0,0 +1,134 @@
+// Package hover describes the symbol under the cursor: its declaration,
+// doc comment and location, for LSP hover responses and editor plugins.
+//
+// Local names, such as parameters and constants in function bodies, are
+// resolved within the file as described in package scope. Other names are
+// looked up among the top-level definitions of the file, then those of the
+// rest of the project index.
+package hover
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+// File is the file the cursor is in: its path in the index and its parse
+// tree, which may reflect unsaved changes.
+type File struct {
+	Path string
+	Tree *ferrule.Tree
+}
+
+// Hover describes a symbol.
+type Hover struct {
+	// Range is that of the name under the cursor.
+	Range tree_sitter.Range
+	// Signature is the declaration of the symbol, without a function's
+	// body.
+	Signature string
+	Doc       doc.Comment
+	// Definition is the location of the defining name.
+	Definition index.Location
+}
+
+// Markdown formats h as a code block holding the signature, the doc
+// comment and a line giving the location of the definition.
+func (h *Hover) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "```ferrule\n%s\n```\n", h.Signature)
+	if c := h.Doc.Markdown(); c != "" {
+		b.WriteString("\n" + c)
+	}
+	p := h.Definition.Range.StartPoint
+	fmt.Fprintf(&b, "\nDefined in %s:%d:%d\n", h.Definition.Path, p.Row+1, p.Column+1)
+	return b.String()
+}
+
+// At describes the symbol whose name is at p in file. It returns nil when
+// there is no name at p or its definition cannot be found. Definitions in
+// other files are read from disk, below the root of idx.
+func At(idx *index.Index, file File, p tree_sitter.Point) (*Hover, error) {
+	src := file.Tree.Source()
+	n := file.Tree.RootNode().NamedDescendantForPointRange(p, p)
+	if n == nil || (n.Kind() != kind.Identifier && n.Kind() != kind.TypeIdentifier) {
+		return nil, nil
+	}
+	if def := scope.Resolve(file.Tree).ResolveAt(p); def != nil {
+		h := describe(def.Node, src)
+		h.Range = n.Range()
+		h.Definition = index.Location{Path: file.Path, Range: def.Node.Range()}
+		return h, nil
+	}
+
+	name := n.Utf8Text(src)
+	loc, ok := lookup(idx, file, name)
+	if !ok {
+		return nil, nil
+	}
+	defSrc := src
+	defTree := file.Tree
+	if loc.Path != file.Path {
+		text, err := charset.ReadFile(filepath.Join(idx.Root(), filepath.FromSlash(loc.Path)))
+		if err != nil {
+			return nil, err
+		}
+		data := text.Source
+		t, err := ferrule.Parse(context.Background(), data)
+		if err != nil {
+			return nil, err
+		}
+		defer t.Close()
+		defSrc, defTree = data, t
+	}
+	ident := defTree.RootNode().NamedDescendantForByteRange(loc.Range.StartByte, loc.Range.EndByte)
+	if ident == nil || ident.Utf8Text(defSrc) != name {
+		// the index is out of date.
+		return nil, nil
+	}
+	h := describe(ident, defSrc)
+	h.Range = n.Range()
+	h.Definition = loc
+	return h, nil
+}
+
+// lookup finds the top-level definition of name, in file if possible.
+func lookup(idx *index.Index, file File, name string) (index.Location, bool) {
+	for _, d := range index.Extract(file.Path, file.Tree).Definitions {
+		if d.Name == name {
+			return index.Location{Path: file.Path, Range: d.SelectionRange}, true
+		}
+	}
+	for _, loc := range idx.Definitions(name) {
+		if loc.Path != file.Path {
+			return loc, true
+		}
+	}
+	return index.Location{}, false
+}
+
+// describe returns the signature and doc comment of the declaration that
+// ident names.
+func describe(ident *tree_sitter.Node, src []byte) *Hover {
+	decl := ident.Parent()
+	if decl == nil {
+		return &Hover{Signature: ident.Utf8Text(src)}
+	}
+	switch decl.Kind() {
+	case kind.Parameter:
+		return &Hover{Signature: decl.Utf8Text(src)}
+	case kind.ForStatement, kind.Pattern, kind.DestructuringPattern, kind.PackagePath:
+		return &Hover{Signature: ident.Utf8Text(src)}
+	}
+	return &Hover{Signature: doc.Signature(decl, src), Doc: doc.Parse(doc.Text(decl, src))}
+}
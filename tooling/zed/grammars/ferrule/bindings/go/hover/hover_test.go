@@ -0,0 +1,95 @@
+package hover_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/hover"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+)
+
+const mainSrc = `package app;
+
+// Origin is where it starts.
+type Origin = Point;
+
+function main(n: u32) -> u32 {
+  const x: u32 = n;
+  return scale(x);
+}
+
+function old() -> u32 {
+  return legacy(1);
+}
+`
+
+const utilSrc = `package app.util;
+
+// Scale scales v.
+//
+// @param v the value
+pub function scale(v: u32) -> u32 {
+  return v * 2;
+}
+
+// Legacy doubles v.
+//
+// ferrule:deprecated use scale instead
+pub function legacy(v: u32) -> u32 {
+  return scale(v);
+}
+`
+
+func TestAt(t *testing.T) {
+	root := t.TempDir()
+	for name, src := range map[string]string{"main.fe": mainSrc, "util.fe": utilSrc} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := ferrule.Parse(context.Background(), []byte(mainSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	file := hover.File{Path: "main.fe", Tree: tree}
+
+	tests := []struct {
+		row, column uint
+		want        string
+	}{
+		{7, 10, "```ferrule\npub function scale(v: u32) -> u32\n```\n" +
+			"\nScale scales v.\n\nParameters:\n\n- `v`: the value\n" +
+			"\nDefined in util.fe:6:14\n"},
+		{7, 15, "```ferrule\nconst x: u32 = n;\n```\n\nDefined in main.fe:7:9\n"},
+		{6, 17, "```ferrule\nn: u32\n```\n\nDefined in main.fe:6:15\n"},
+		{3, 6, "```ferrule\ntype Origin = Point;\n```\n\nOrigin is where it starts.\n\nDefined in main.fe:4:6\n"},
+		{11, 10, "```ferrule\npub function legacy(v: u32) -> u32\n```\n" +
+			"\nDeprecated: use scale instead\n\nLegacy doubles v.\n" +
+			"\nDefined in util.fe:13:14\n"},
+		{3, 16, ""},
+		{5, 2, ""},
+	}
+	for _, tt := range tests {
+		h, err := hover.At(idx, file, tree_sitter.Point{Row: tt.row, Column: tt.column})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if h != nil {
+			got = h.Markdown()
+		}
+		if got != tt.want {
+			t.Errorf("%d:%d: got:\n%s\nwant:\n%s", tt.row, tt.column, got, tt.want)
+		}
+	}
+}
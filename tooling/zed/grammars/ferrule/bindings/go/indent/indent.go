@@ -0,0 +1,211 @@
+// Package indent computes the indentation of lines of ferrule source from
+// the parse tree, as the formatter lays them out: one level for each
+// block, match, component, capability or domain body enclosing a line, for
+// each bracketed list broken after its opening bracket and for the
+// variants of a union type written on lines of their own.
+//
+// The tree may hold syntax errors, as it does while code is being typed.
+// Brackets the parser could not match, such as the brace of a function
+// whose body is not written yet, indent the lines after them all the
+// same, so that an editor can indent a new line before the code around it
+// is complete.
+package indent
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Unit is one level of indentation.
+const Unit = "  "
+
+// Of returns the indentation depth of a line starting with n.
+func Of(n *tree_sitter.Node) int {
+	return depth(n, n.StartByte(), n.StartPosition().Row)
+}
+
+// At returns the indentation depth of a line starting at the byte offset
+// off of the source of tree, with what is there or, on a blank line, with
+// what would be typed there.
+func At(tree *ferrule.Tree, off uint) int {
+	n := unclosed(tree.RootNode().DescendantForByteRange(off, off), off)
+	return depth(n, off, edits.Point(tree.Source(), off).Row)
+}
+
+// Line returns the indentation depth of the zero-based line row of the
+// source of tree. It reports false for a line that starts inside a string
+// literal or block comment, whose indentation is part of its text.
+func Line(tree *ferrule.Tree, row uint) (int, bool) {
+	src := tree.Source()
+	off := edits.LineStart(src, row)
+	for off < uint(len(src)) && (src[off] == ' ' || src[off] == '\t') {
+		off++
+	}
+	n := unclosed(tree.RootNode().DescendantForByteRange(off, off), off)
+	for a := n; a != nil; a = a.Parent() {
+		switch a.Kind() {
+		case kind.StringLiteral, kind.CharLiteral, kind.BlockComment:
+			if a.StartByte() < off && off < a.EndByte() {
+				return 0, false
+			}
+		}
+	}
+	return depth(n, off, row), true
+}
+
+// unclosed descends from n, the smallest node enclosing off, into the
+// nodes before off that the parser closed with brackets or other tokens
+// of its own: a function whose body is not closed yet ends at its opening
+// brace, but the lines after it are still inside it.
+func unclosed(n *tree_sitter.Node, off uint) *tree_sitter.Node {
+	for {
+		var last *tree_sitter.Node
+		for i := n.ChildCount(); i > 0; i-- {
+			if c := n.Child(i - 1); c.EndByte() <= off && !c.IsMissing() {
+				last = c
+				break
+			}
+		}
+		if last == nil || !lastLeaf(last).IsMissing() {
+			return n
+		}
+		n = last
+	}
+}
+
+func lastLeaf(n *tree_sitter.Node) *tree_sitter.Node {
+	for n.ChildCount() > 0 {
+		n = n.Child(n.ChildCount() - 1)
+	}
+	return n
+}
+
+// depth sums the levels n and its ancestors add to a line starting at the
+// offset off, on row.
+func depth(n *tree_sitter.Node, off, row uint) int {
+	d := 0
+	for a := n; a != nil; a = a.Parent() {
+		d += unmatched(a, off)
+		switch {
+		case a.Kind() == kind.UnionType:
+			if (row > a.StartPosition().Row || a.StartPosition().Row != a.EndPosition().Row) && a.StartByte() <= off && off < a.EndByte() {
+				d++
+			}
+		case body(a):
+			if open, close := brackets(a, "{", "}"); open != nil && inside(open, close, off) {
+				d++
+			}
+		case list(a):
+			if open, close := brackets(a, "(", "[", "{", "<", ")", "]", "}", ">"); open != nil && inside(open, close, off) && broken(a, open, off, row) {
+				d++
+			}
+		}
+	}
+	return d
+}
+
+// body reports whether the lines between the braces of n are indented
+// whether or not they were so in the source.
+func body(n *tree_sitter.Node) bool {
+	switch n.Kind() {
+	case kind.Block, kind.MatchStatement, kind.MatchExpression, kind.ComponentDeclaration,
+		kind.CapabilityDeclaration, kind.DomainDeclaration:
+		return true
+	}
+	return false
+}
+
+// list reports whether n is a bracketed list, indented when broken.
+func list(n *tree_sitter.Node) bool {
+	switch n.Kind() {
+	case kind.ParameterList, kind.CallExpression, kind.ArrayExpression, kind.RecordExpression,
+		kind.RecordBody, kind.RecordType, kind.TypeParameters, kind.GenericType,
+		kind.FunctionType, kind.EffectsClause, kind.DestructuringPattern:
+		return true
+	}
+	return false
+}
+
+// brackets returns the first opening and the last closing bracket among
+// the children of n, the opening ones listed before the closing ones in
+// toks. A bracket the parser inserted counts as missing.
+func brackets(n *tree_sitter.Node, toks ...string) (open, close *tree_sitter.Node) {
+	opening, closing := toks[:len(toks)/2], toks[len(toks)/2:]
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if c.IsNamed() || c.IsMissing() {
+			continue
+		}
+		if open == nil && contains(opening, c.Kind()) {
+			open = c
+		} else if open != nil && contains(closing, c.Kind()) {
+			close = c
+		}
+	}
+	return open, close
+}
+
+// inside reports whether off lies after open and before close, if any.
+func inside(open, close *tree_sitter.Node, off uint) bool {
+	return open.EndByte() <= off && (close == nil || off < close.StartByte())
+}
+
+// broken reports whether the list n is broken after its opening bracket,
+// so that its elements are on lines of their own: the first element, or
+// the line at off if it comes first, starts on a later row than the
+// bracket, or a line comment inside the list ends a line.
+func broken(n, open *tree_sitter.Node, off, row uint) bool {
+	first := row
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if c.StartByte() < open.EndByte() || c.IsMissing() {
+			continue
+		}
+		if c.Kind() == kind.LineComment {
+			return true
+		}
+		if c.StartByte() <= off {
+			first = min(first, c.StartPosition().Row)
+		}
+	}
+	return first > open.EndPosition().Row
+}
+
+// unmatched returns the number of opening brackets before off that the
+// parser could not match with a closing bracket before off: those among
+// the children of n if it is an ERROR node, and those of the ERROR nodes
+// among its children.
+func unmatched(n *tree_sitter.Node, off uint) int {
+	open := 0
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		switch {
+		case c.EndByte() > off:
+			continue
+		case c.IsError():
+			open += unmatched(c, off)
+			continue
+		case c.IsNamed() || !n.IsError():
+			continue
+		}
+		switch c.Kind() {
+		case "{", "(", "[":
+			open++
+		case "}", ")", "]":
+			open = max(open-1, 0)
+		}
+	}
+	return open
+}
+
+func contains(set []string, s string) bool {
+	for _, v := range set {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
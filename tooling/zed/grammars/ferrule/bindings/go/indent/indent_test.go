@@ -0,0 +1,74 @@
+package indent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/indent"
+)
+
+// The sources mark each line with the depth it should have; the lines are
+// stripped of their indentation before parsing.
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+	}{
+		{"formatted", []string{
+			"0 function main(a: u32) -> Unit {",
+			"1 match a {",
+			"2 1 -> {",
+			"3 print(",
+			"4 \"one\",",
+			"3 );",
+			"2 }",
+			"2 _ -> print(\"other\", function() {",
+			"3 return;",
+			"2 })",
+			"1 };",
+			"1",
+			"1 /* a",
+			"- comment */",
+			"0 }",
+		}},
+		{"unclosed", []string{
+			"0 function main() -> Unit {",
+			"1 const a = f(",
+			"2",
+		}},
+		{"missing braces", []string{
+			"0 function main() -> Unit {",
+			"1 if true {",
+			"2",
+		}},
+		{"union", []string{
+			"0 type Shape =",
+			"1 | Circle",
+			"1 | Square;",
+		}},
+	}
+	for _, tt := range tests {
+		var src strings.Builder
+		want := make([]string, len(tt.lines))
+		for i, l := range tt.lines {
+			want[i], l, _ = strings.Cut(l, " ")
+			src.WriteString(l + "\n")
+		}
+		tree, err := ferrule.Parse(context.Background(), []byte(src.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range tt.lines {
+			got := "-"
+			if d, ok := indent.Line(tree, uint(i)); ok {
+				got = string(rune('0' + d))
+			}
+			if got != want[i] {
+				t.Errorf("%s: line %d (%q) depth %s, want %s", tt.name, i, tt.lines[i], got, want[i])
+			}
+		}
+		tree.Close()
+	}
+}
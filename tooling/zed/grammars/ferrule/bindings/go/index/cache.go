@@ -0,0 +1,118 @@
+package index
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// CacheDir is the directory, relative to the root of a project, where Open
+// and WriteCache keep the index. It is never indexed.
+const CacheDir = ".ferrule-cache"
+
+// cacheFile is the name of the index in CacheDir.
+const cacheFile = "index"
+
+// cacheMagic starts a cached index, followed by the gzip-compressed gob
+// encoding of the saved index. The digit is the version of the encoding.
+const cacheMagic = "ferrule-index 2\n"
+
+// Open returns the index of the project at root: the one cached under
+// CacheDir if it can be read, brought up to date as by Refresh, and the
+// one built by Build otherwise. A cache that is missing, damaged or of
+// another version is ignored. The updated index is written back to the
+// cache; failing to do so is not an error, as the cache may be read-only.
+func Open(ctx context.Context, root string) (*Index, error) {
+	idx, err := readCache(root)
+	if err != nil {
+		idx = New(root)
+	}
+	if err := idx.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	idx.WriteCache()
+	return idx, nil
+}
+
+// WriteCache writes the index to CacheDir below its root, to be read back
+// by Open. The directory is created as needed, with a .gitignore file that
+// keeps it out of version control.
+func (idx *Index) WriteCache() error {
+	dir := filepath.Join(idx.root, CacheDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	ignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(ignore); errors.Is(err, fs.ErrNotExist) {
+		if err := os.WriteFile(ignore, []byte("*\n"), 0o644); err != nil {
+			return err
+		}
+	}
+	// write .tmp and rename it, so that readers see the old index or the
+	// new one.
+	tmp, err := os.CreateTemp(dir, cacheFile+"*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	err = encodeCache(w, idx)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("index: writing cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, cacheFile))
+}
+
+func encodeCache(w io.Writer, idx *Index) error {
+	if _, err := io.WriteString(w, cacheMagic); err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(saved{Version: formatVersion, Files: idx.Files()}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readCache reads the index cached below root.
+func readCache(root string) (*Index, error) {
+	f, err := os.Open(filepath.Join(root, CacheDir, cacheFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(cacheMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != cacheMagic {
+		return nil, errors.New("index: not a cached index of this version")
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var s saved
+	if err := gob.NewDecoder(zr).Decode(&s); err != nil {
+		return nil, fmt.Errorf("index: reading cache: %w", err)
+	}
+	if s.Version != formatVersion {
+		return nil, fmt.Errorf("index: unsupported format version %d", s.Version)
+	}
+	idx := New(root)
+	for _, f := range s.Files {
+		idx.files[f.Path] = f
+	}
+	return idx, nil
+}
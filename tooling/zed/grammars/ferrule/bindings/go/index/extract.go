@@ -0,0 +1,110 @@
+package index
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var tags = query.MustCompile(string(queries.Tags))
+
+// Extract builds the index entry of the file name from its parse tree.
+// ModTime and Size are left for the caller to fill in.
+func Extract(name string, tree *ferrule.Tree) *File {
+	src := tree.Source()
+	f := &File{Path: name, Hash: hash(src), SyntaxErrors: len(tree.Diagnostics())}
+
+	for _, c := range namedChildren(tree.RootNode()) {
+		switch c.Kind() {
+		case kind.PackageDeclaration:
+			if p := c.ChildByFieldName(field.Path); p != nil && f.Package == "" {
+				f.Package = p.Utf8Text(src)
+			}
+		case kind.ImportDeclaration:
+			p := c.ChildByFieldName(field.Path)
+			if p == nil {
+				continue
+			}
+			imp := Import{Path: p.Utf8Text(src), Range: c.Range()}
+			for _, id := range namedChildren(c) {
+				if id.Kind() == kind.Identifier {
+					imp.Alias = id.Utf8Text(src)
+				}
+			}
+			f.Imports = append(f.Imports, imp)
+		}
+	}
+
+	deprecated := make(map[tree_sitter.Range]string)
+	var visit func(ds []doc.Decl)
+	visit = func(ds []doc.Decl) {
+		for _, d := range ds {
+			if d.Doc.Deprecated {
+				deprecated[d.Range] = d.Doc.DeprecatedNote
+			}
+			visit(d.Members)
+		}
+	}
+	visit(doc.Extract(tree).Decls)
+
+	var flatten func(container string, syms []symbols.Symbol)
+	flatten = func(container string, syms []symbols.Symbol) {
+		for _, s := range syms {
+			note, dep := deprecated[s.Range]
+			f.Definitions = append(f.Definitions, Definition{
+				Name:           s.Name,
+				Kind:           s.Kind,
+				Container:      container,
+				Range:          s.Range,
+				SelectionRange: s.SelectionRange,
+				Deprecated:     dep,
+				DeprecatedNote: note,
+			})
+			flatten(s.Name, s.Children)
+		}
+	}
+	flatten("", symbols.Outline(tree))
+
+	type found struct {
+		name *tree_sitter.Node
+		kind string
+	}
+	byMatch := make(map[uint]*found)
+	var order []uint
+	for c, n := range tags.Matches(tree.Root(), src) {
+		m := byMatch[c.Match]
+		if m == nil {
+			m = &found{}
+			byMatch[c.Match] = m
+			order = append(order, c.Match)
+		}
+		switch {
+		case c.Name == "name":
+			m.name = n.Raw()
+		case strings.HasPrefix(c.Name, "reference."):
+			m.kind = strings.TrimPrefix(c.Name, "reference.")
+		}
+	}
+	for _, id := range order {
+		if m := byMatch[id]; m.kind != "" && m.name != nil {
+			f.References = append(f.References, Reference{Name: m.name.Utf8Text(src), Kind: m.kind, Range: m.name.Range()})
+		}
+	}
+	return f
+}
+
+func namedChildren(n *tree_sitter.Node) []*tree_sitter.Node {
+	out := make([]*tree_sitter.Node, n.NamedChildCount())
+	for i := range out {
+		out[i] = n.NamedChild(uint(i))
+	}
+	return out
+}
@@ -0,0 +1,436 @@
+// Package index maintains a symbol index of a ferrule project: for every
+// .fe file below a root directory, its package, imports, top-level
+// definitions and references.
+//
+// An index is built by walking the root, skipping .git directories and
+// whatever .gitignore and .ferruleignore files exclude, and parsing the
+// files found in parallel. It can be saved and loaded again, and Refresh
+// then reparses only the files whose content changed since: files whose
+// size or modification time changed are read again, and reparsed unless
+// they hash the same as an indexed file. Open keeps such an index in the
+// project itself, under .ferrule-cache. Files are identified by slash-separated
+// paths relative to the root. Files stored in UTF-16 or Latin-1 are decoded
+// with package charset, and every range is into the decoded UTF-8 text.
+//
+// The files are read from the disk or, after SetFS, from a virtual file
+// system, such as one overlaying an editor's unsaved buffers on the disk.
+//
+// Definitions are looked up by name with Definitions, or searched for by
+// approximate name with SearchSymbols, as editors do for workspace symbols.
+//
+// An Index is safe for concurrent use.
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/internal/ignore"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// formatVersion is the version of the saved index format. Indexes saved
+// in another format are rejected by Load.
+const formatVersion = 2
+
+// File is the index entry of one source file.
+type File struct {
+	Path    string    `json:"path"`
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+	// Hash is the hex-encoded SHA-256 hash of the content.
+	Hash string `json:"hash,omitempty"`
+	// Package is the path of the package declaration, if any.
+	Package      string       `json:"package,omitempty"`
+	Imports      []Import     `json:"imports,omitempty"`
+	Definitions  []Definition `json:"definitions,omitempty"`
+	References   []Reference  `json:"references,omitempty"`
+	SyntaxErrors int          `json:"syntaxErrors,omitempty"`
+}
+
+// Import is an import declaration.
+type Import struct {
+	Path  string            `json:"path"`
+	Alias string            `json:"alias,omitempty"`
+	Range tree_sitter.Range `json:"range"`
+}
+
+// Definition is a declaration of the file outline; see package symbols.
+type Definition struct {
+	Name string       `json:"name"`
+	Kind symbols.Kind `json:"kind"`
+	// Container is the name of the enclosing definition, such as the
+	// component of a member.
+	Container      string            `json:"container,omitempty"`
+	Range          tree_sitter.Range `json:"range"`
+	SelectionRange tree_sitter.Range `json:"selectionRange"`
+	// Deprecated reports that the doc comment of the definition holds a
+	// ferrule:deprecated directive, and DeprecatedNote is its text; see
+	// doc.Comment.
+	Deprecated     bool   `json:"deprecated,omitempty"`
+	DeprecatedNote string `json:"deprecatedNote,omitempty"`
+}
+
+// Reference is a use of a name found by a @reference capture of the tags
+// query. Kind is the capture suffix, such as "call".
+type Reference struct {
+	Name  string            `json:"name"`
+	Kind  string            `json:"kind"`
+	Range tree_sitter.Range `json:"range"`
+}
+
+// Location is a range in an indexed file.
+type Location struct {
+	Path  string
+	Range tree_sitter.Range
+}
+
+// Index is the symbol index of the project below a root directory.
+type Index struct {
+	root string
+
+	mu    sync.RWMutex
+	fsys  vfs.FS
+	files map[string]*File
+}
+
+// New returns an empty index of the project at root.
+func New(root string) *Index {
+	return &Index{root: root, fsys: vfs.OS, files: make(map[string]*File)}
+}
+
+// SetFS makes the index read its files from fsys, the files of the project
+// being those below root in it, from the next Refresh or Update on.
+func (idx *Index) SetFS(fsys vfs.FS) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.fsys = fsys
+}
+
+// FS returns the file system the index reads its files from.
+func (idx *Index) FS() vfs.FS {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.fsys
+}
+
+// Build indexes the project at root.
+func Build(ctx context.Context, root string) (*Index, error) {
+	idx := New(root)
+	if err := idx.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// Root returns the root directory of the project.
+func (idx *Index) Root() string { return idx.root }
+
+// Refresh brings the index up to date with the files of its file system:
+// it indexes new files, reindexes those whose size or modification time
+// changed and drops those that disappeared or became ignored.
+func (idx *Index) Refresh(ctx context.Context) error {
+	_, err := idx.refresh(ctx)
+	return err
+}
+
+// refresh is Refresh, returning the scan it was based on.
+func (idx *Index) refresh(ctx context.Context) (*scan, error) {
+	fsys := idx.FS()
+	s, err := walk(fsys, idx.root)
+	if err != nil {
+		return nil, err
+	}
+	found := s.files
+
+	idx.mu.RLock()
+	var stale []string
+	for name, info := range found {
+		if f := idx.files[name]; f == nil || !f.ModTime.Equal(info.ModTime()) || f.Size != info.Size() {
+			stale = append(stale, name)
+		}
+	}
+	known := idx.byHash()
+	idx.mu.RUnlock()
+	sort.Strings(stale)
+
+	pool := ferrule.NewParserPool(0)
+	defer pool.Close()
+	results := make([]*File, len(stale))
+	errs := make([]error, len(stale))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.GOMAXPROCS(0); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = idx.parse(ctx, fsys, pool, known, stale[i], found[stale[i]])
+			}
+		}()
+	}
+	for i := range stale {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for name := range idx.files {
+		if _, ok := found[name]; !ok {
+			delete(idx.files, name)
+		}
+	}
+	for _, f := range results {
+		idx.files[f.Path] = f
+	}
+	return s, nil
+}
+
+// Update reindexes the single file name, or drops it from the index if it
+// no longer exists. Ignore rules are not consulted.
+func (idx *Index) Update(ctx context.Context, name string) error {
+	fsys := idx.FS()
+	info, err := fsys.Stat(idx.osPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		idx.Remove(name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	pool := ferrule.NewParserPool(1)
+	defer pool.Close()
+	idx.mu.RLock()
+	known := idx.byHash()
+	idx.mu.RUnlock()
+	f, err := idx.parse(ctx, fsys, pool, known, name, info)
+	if err != nil {
+		return err
+	}
+	idx.Put(f)
+	return nil
+}
+
+// Put adds f to the index, replacing any entry for the same path. Tools
+// that hold parse trees of their own, such as editors with unsaved
+// changes, use it together with Extract.
+func (idx *Index) Put(f *File) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.files[f.Path] = f
+}
+
+// Remove drops the file name from the index.
+func (idx *Index) Remove(name string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	delete(idx.files, name)
+}
+
+// File returns the entry of the file name, or nil if it is not indexed.
+// The entry must not be modified.
+func (idx *Index) File(name string) *File {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.files[name]
+}
+
+// Files returns the indexed files ordered by path. The entries must not be
+// modified.
+func (idx *Index) Files() []*File {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	out := make([]*File, 0, len(idx.files))
+	for _, f := range idx.files {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Definitions returns the locations of the definitions named name across
+// the project, ordered by path and position.
+func (idx *Index) Definitions(name string) []Location {
+	var out []Location
+	for _, f := range idx.Files() {
+		for _, d := range f.Definitions {
+			if d.Name == name {
+				out = append(out, Location{Path: f.Path, Range: d.SelectionRange})
+			}
+		}
+	}
+	return out
+}
+
+// References returns the locations of the references to name across the
+// project, ordered by path and position.
+func (idx *Index) References(name string) []Location {
+	var out []Location
+	for _, f := range idx.Files() {
+		for _, r := range f.References {
+			if r.Name == name {
+				out = append(out, Location{Path: f.Path, Range: r.Range})
+			}
+		}
+	}
+	return out
+}
+
+// Importers returns the paths of the files that import the package pkg.
+func (idx *Index) Importers(pkg string) []string {
+	var out []string
+	for _, f := range idx.Files() {
+		for _, imp := range f.Imports {
+			if imp.Path == pkg {
+				out = append(out, f.Path)
+				break
+			}
+		}
+	}
+	return out
+}
+
+type saved struct {
+	Version int     `json:"version"`
+	Files   []*File `json:"files"`
+}
+
+// Save writes the index to w, to be read back with Load.
+func (idx *Index) Save(w io.Writer) error {
+	return json.NewEncoder(w).Encode(saved{Version: formatVersion, Files: idx.Files()})
+}
+
+// Load reads an index saved by Save and attaches it to the project at
+// root. The result reflects the files at the time they were saved; call
+// Refresh to catch up with changes made since.
+func Load(r io.Reader, root string) (*Index, error) {
+	var s saved
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	if s.Version != formatVersion {
+		return nil, fmt.Errorf("index: unsupported format version %d", s.Version)
+	}
+	idx := New(root)
+	for _, f := range s.Files {
+		idx.files[f.Path] = f
+	}
+	return idx, nil
+}
+
+func (idx *Index) osPath(name string) string {
+	return filepath.Join(idx.root, filepath.FromSlash(name))
+}
+
+// byHash returns the indexed files by hash. idx.mu must be held.
+func (idx *Index) byHash() map[string]*File {
+	out := make(map[string]*File, len(idx.files))
+	for _, f := range idx.files {
+		if f.Hash != "" {
+			out[f.Hash] = f
+		}
+	}
+	return out
+}
+
+// parse indexes the file name of fsys, copying the entry of a known file
+// with the same content rather than parsing it again.
+func (idx *Index) parse(ctx context.Context, fsys vfs.FS, pool *ferrule.ParserPool, known map[string]*File, name string, info fs.FileInfo) (*File, error) {
+	data, err := fsys.ReadFile(idx.osPath(name))
+	if err != nil {
+		return nil, err
+	}
+	src := charset.Decode(data).Source
+	if same := known[hash(src)]; same != nil {
+		f := *same
+		f.Path, f.ModTime, f.Size = name, info.ModTime(), info.Size()
+		return &f, nil
+	}
+	tree, err := pool.Parse(ctx, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	defer tree.Close()
+	f := Extract(name, tree)
+	f.ModTime, f.Size = info.ModTime(), info.Size()
+	return f, nil
+}
+
+// scan is the result of walking the project.
+type scan struct {
+	// files holds the .fe files that are not ignored, keyed by their
+	// slash-separated path relative to the root.
+	files map[string]fs.FileInfo
+	// rules holds the ignore rules in effect in every directory visited,
+	// "" being the root.
+	rules map[string]*ignore.Rules
+}
+
+// walk scans the project at root in fsys.
+func walk(fsys vfs.FS, root string) (*scan, error) {
+	s := &scan{files: make(map[string]fs.FileInfo)}
+	rules := ignore.NewWalker(fsys, root)
+	err := vfs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, skip, err := rules.Skip(p, d.IsDir())
+		if err != nil {
+			return err
+		}
+		if skip || d.IsDir() && name == CacheDir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || filepath.Ext(name) != ".fe" || !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		s.files[name] = info
+		return nil
+	})
+	s.rules = rules.Rules()
+	return s, err
+}
+
+// hash returns the hex-encoded SHA-256 hash of src.
+func hash(src []byte) string {
+	sum := sha256.Sum256(src)
+	return hex.EncodeToString(sum[:])
+}
+
+// parentDir returns the directory of the slash-separated path name, ""
+// for names at the root.
+func parentDir(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
@@ -0,0 +1,350 @@
+package index_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+func write(t *testing.T, root, name, src string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func paths(files []*index.File) []string {
+	var out []string
+	for _, f := range files {
+		out = append(out, f.Path)
+	}
+	return out
+}
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, ".gitignore", "build/\n")
+	write(t, root, "main.fe", "package app;\nimport app.util as u;\n\nfunction main() -> Unit {\n  helper(1);\n}\n")
+	write(t, root, "util/util.fe", "package app.util;\n\ncomponent Tools {\n  // ferrule:deprecated use Tools.help\n  function helper(x: u32) -> u32 { return x; }\n}\n")
+	write(t, root, "util/.gitignore", "*_gen.fe\n")
+	write(t, root, "util/x_gen.fe", "function generated() -> Unit {}\n")
+	write(t, root, "build/out.fe", "function built() -> Unit {}\n")
+	write(t, root, "README.md", "not ferrule\n")
+
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := paths(idx.Files()), []string{"main.fe", "util/util.fe"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("files %v, want %v", got, want)
+	}
+
+	main := idx.File("main.fe")
+	if main.Package != "app" || len(main.Imports) != 1 || main.Imports[0].Path != "app.util" || main.Imports[0].Alias != "u" {
+		t.Errorf("main.fe: package %q, imports %+v", main.Package, main.Imports)
+	}
+	if got := idx.Importers("app.util"); !reflect.DeepEqual(got, []string{"main.fe"}) {
+		t.Errorf("Importers = %v", got)
+	}
+
+	defs := idx.Definitions("helper")
+	if len(defs) != 1 || defs[0].Path != "util/util.fe" || defs[0].Range.StartPoint.Row != 4 {
+		t.Errorf("Definitions(helper) = %+v", defs)
+	}
+	var member *index.Definition
+	for i, d := range idx.File("util/util.fe").Definitions {
+		if d.Name == "helper" {
+			member = &idx.File("util/util.fe").Definitions[i]
+		}
+	}
+	if member == nil || member.Container != "Tools" || member.Kind != symbols.Method || !member.Deprecated || member.DeprecatedNote != "use Tools.help" {
+		t.Errorf("helper definition %+v", member)
+	}
+	refs := idx.References("helper")
+	if len(refs) != 1 || refs[0].Path != "main.fe" || refs[0].Range.StartPoint.Row != 4 {
+		t.Errorf("References(helper) = %+v", refs)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	root := t.TempDir()
+	wide := []byte{0xff, 0xfe}
+	for _, c := range "function wide() -> Unit {}\n" {
+		wide = append(wide, byte(c), 0)
+	}
+	write(t, root, "wide.fe", string(wide))
+	write(t, root, "latin.fe", "// caf\xe9\nfunction latin() -> Unit {}\n")
+
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defs := idx.Definitions("wide"); len(defs) != 1 || defs[0].Range.StartByte != 9 {
+		t.Errorf("Definitions(wide) = %+v", defs)
+	}
+	// é takes two bytes decoded.
+	if defs := idx.Definitions("latin"); len(defs) != 1 || defs[0].Range.StartByte != 18 {
+		t.Errorf("Definitions(latin) = %+v", defs)
+	}
+}
+
+func TestFS(t *testing.T) {
+	ctx := context.Background()
+	root := filepath.FromSlash("/project")
+	files := vfs.NewOverlay(vfs.Map{
+		filepath.Join(root, ".gitignore"):       "gen/\n",
+		filepath.Join(root, "main.fe"):          "function main() -> Unit {}\n",
+		filepath.Join(root, "gen", "out.fe"):    "function out() -> Unit {}\n",
+		filepath.Join(root, "lib", "helper.fe"): "function helper() -> Unit {}\n",
+	})
+	idx := index.New(root)
+	idx.SetFS(files)
+	if err := idx.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := paths(idx.Files()), []string{"lib/helper.fe", "main.fe"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("files %v, want %v", got, want)
+	}
+
+	// a buffer replaces the file it is set for, or adds one.
+	files.Set(filepath.Join(root, "main.fe"), []byte("function start() -> Unit {}\n"))
+	files.Set(filepath.Join(root, "draft.fe"), []byte("function draft() -> Unit {}\n"))
+	if err := idx.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Definitions("start")) != 1 || len(idx.Definitions("main")) != 0 || len(idx.Definitions("draft")) != 1 {
+		t.Errorf("definitions after setting buffers: %+v", idx.Files())
+	}
+	files.Remove(filepath.Join(root, "draft.fe"))
+	if err := idx.Update(ctx, "draft.fe"); err != nil {
+		t.Fatal(err)
+	}
+	if idx.File("draft.fe") != nil {
+		t.Error("draft.fe still indexed after its buffer was removed")
+	}
+}
+
+func TestRefreshAndPersistence(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	write(t, root, "a.fe", "function a() -> Unit {}\n")
+	write(t, root, "b.fe", "function b() -> Unit {}\n")
+	idx, err := index.Build(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := idx.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := index.Load(&buf, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(paths(loaded.Files()), []string{"a.fe", "b.fe"}) || len(loaded.Definitions("b")) != 1 {
+		t.Fatalf("loaded index differs: %v", paths(loaded.Files()))
+	}
+
+	write(t, root, "b.fe", "function b2() -> Unit {}\n")
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(root, "b.fe"), later, later)
+	os.Remove(filepath.Join(root, "a.fe"))
+	write(t, root, "c.fe", "function c() -> Unit {}\n")
+	if err := loaded.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(loaded.Files()); !reflect.DeepEqual(got, []string{"b.fe", "c.fe"}) {
+		t.Errorf("after refresh: %v", got)
+	}
+	if len(loaded.Definitions("b")) != 0 || len(loaded.Definitions("b2")) != 1 {
+		t.Errorf("b.fe was not reindexed")
+	}
+
+	write(t, root, "c.fe", "function c() -> Unit {\n  a();\n}\n")
+	if err := loaded.Update(ctx, "c.fe"); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.References("a")) != 1 {
+		t.Errorf("Update did not pick up the new reference")
+	}
+	os.Remove(filepath.Join(root, "c.fe"))
+	if err := loaded.Update(ctx, "c.fe"); err != nil || loaded.File("c.fe") != nil {
+		t.Errorf("Update of a removed file: %v, %v", err, loaded.File("c.fe"))
+	}
+
+	if _, err := index.Load(bytes.NewReader([]byte(`{"version":99}`)), root); err == nil {
+		t.Error("Load accepted an unknown format version")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, "a.fe", "function a() -> Unit {}\n")
+
+	updates := make(chan []string, 16)
+	w, err := index.Watch(context.Background(), root, &index.WatchOptions{
+		Debounce: 20 * time.Millisecond,
+		OnUpdate: func(paths []string) { updates <- paths },
+		OnError:  func(err error) { t.Error(err) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	idx := w.Index()
+	if len(idx.Definitions("a")) != 1 {
+		t.Fatalf("initial index lacks a")
+	}
+
+	wait := func(want ...string) {
+		t.Helper()
+		select {
+		case got := <-updates:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("updated %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update for %v", want)
+		}
+	}
+
+	write(t, root, "a.fe", "function a() -> Unit {}\nfunction b() -> Unit {}\n")
+	wait("a.fe")
+	if len(idx.Definitions("b")) != 1 {
+		t.Errorf("b was not indexed")
+	}
+	// the second change is parsed against the retained tree.
+	write(t, root, "a.fe", "function a() -> Unit {\n  b();\n}\nfunction b() -> Unit {}\n")
+	wait("a.fe")
+	if len(idx.References("b")) != 1 {
+		t.Errorf("reference to b was not indexed")
+	}
+
+	write(t, root, "sub/c.fe", "function c() -> Unit {}\n")
+	wait("sub/c.fe")
+	write(t, root, "sub/d.fe", "function d() -> Unit {}\n")
+	wait("sub/d.fe")
+
+	os.Remove(filepath.Join(root, "a.fe"))
+	wait("a.fe")
+	if got := paths(idx.Files()); !reflect.DeepEqual(got, []string{"sub/c.fe", "sub/d.fe"}) {
+		t.Errorf("files %v", got)
+	}
+}
+
+func TestSearchSymbols(t *testing.T) {
+	idx := index.New(t.TempDir())
+	def := func(name string, kind symbols.Kind) index.Definition { return index.Definition{Name: name, Kind: kind} }
+	idx.Put(&index.File{Path: "a.fe", Definitions: []index.Definition{
+		def("parse", symbols.Function),
+		def("Parser", symbols.Struct),
+		def("parse_all", symbols.Function),
+		def("reparse", symbols.Function),
+		def("print_tree", symbols.Function),
+		def("pointer_at", symbols.Function),
+	}})
+	idx.Put(&index.File{Path: "b.fe", Definitions: []index.Definition{def("PARSE", symbols.Constant)}})
+
+	names := func(found []index.Symbol) []string {
+		var out []string
+		for _, s := range found {
+			out = append(out, s.Name)
+		}
+		return out
+	}
+	tests := []struct {
+		query string
+		opts  *index.SearchOptions
+		want  []string
+	}{
+		// prefixes in case come before the others.
+		{"parse", nil, []string{"parse", "PARSE", "parse_all", "Parser", "reparse"}},
+		{"Parse", &index.SearchOptions{Kinds: []symbols.Kind{symbols.Function}}, []string{"parse", "parse_all", "reparse"}},
+		// word starts score above letters elsewhere.
+		{"pt", nil, []string{"print_tree", "pointer_at"}},
+		{"pa", &index.SearchOptions{Limit: 2}, []string{"parse", "parse_all"}},
+		{"zz", nil, nil},
+	}
+	for _, tt := range tests {
+		if got := names(idx.SearchSymbols(tt.query, tt.opts)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchSymbols(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+	if got := idx.SearchSymbols("parse", nil); got[0].Match != index.Exact || got[1].Match != index.ExactFold || got[2].Match != index.Prefix || got[4].Match != index.Subsequence {
+		t.Errorf("matches %+v", got)
+	}
+}
+
+func BenchmarkSearchSymbols(b *testing.B) {
+	idx := index.New(b.TempDir())
+	words := []string{"parse", "tree", "node", "print", "walk", "scope", "index", "query"}
+	for f := 0; f < 1000; f++ {
+		file := &index.File{Path: fmt.Sprintf("f%d.fe", f)}
+		for d := 0; d < 100; d++ {
+			name := fmt.Sprintf("%s_%s%d", words[(f+d)%len(words)], words[d%len(words)], f)
+			file.Definitions = append(file.Definitions, index.Definition{Name: name, Kind: symbols.Function})
+		}
+		idx.Put(file)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.SearchSymbols("prtr", &index.SearchOptions{Limit: 100})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	write(t, root, "a.fe", "function a() -> Unit {}\n")
+	idx, err := index.Open(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Definitions("a")) != 1 {
+		t.Fatalf("a was not indexed")
+	}
+	if _, err := os.Stat(filepath.Join(root, index.CacheDir, ".gitignore")); err != nil {
+		t.Fatal(err)
+	}
+
+	// an entry with the hash of a.fe stands for it, whatever its
+	// modification time.
+	f := *idx.File("a.fe")
+	f.ModTime = time.Time{}
+	f.Definitions = []index.Definition{{Name: "cached", Kind: symbols.Function}}
+	idx.Put(&f)
+	if err := idx.WriteCache(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, root, "b.fe", "function a() -> Unit {}\n")
+	idx, err = index.Open(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Definitions("cached")) != 2 || len(idx.Definitions("a")) != 0 {
+		t.Errorf("files of known content were reparsed: %+v", idx.Files())
+	}
+	if got := paths(idx.Files()); !reflect.DeepEqual(got, []string{"a.fe", "b.fe"}) {
+		t.Errorf("files %v", got)
+	}
+
+	write(t, root, index.CacheDir+"/index", "garbage")
+	idx, err = index.Open(ctx, root)
+	if err != nil || len(idx.Definitions("a")) != 2 {
+		t.Errorf("Open with a damaged cache: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Match ranks, from best to worst, the ways a name can match a search
+// query. Letters are compared without regard to ASCII case, except that
+// an exact match in case ranks above one that differs in case.
+type Match int
+
+const (
+	// Exact is a name equal to the query.
+	Exact Match = iota
+	// ExactFold is a name equal to the query but for case.
+	ExactFold
+	// Prefix is a name starting with the query.
+	Prefix
+	// Subsequence is a name holding the letters of the query in order.
+	Subsequence
+)
+
+// SearchOptions restrict a symbol search.
+type SearchOptions struct {
+	// Kinds, if not empty, limits the results to the definitions of these
+	// kinds.
+	Kinds []symbols.Kind
+	// Limit, if positive, is the most results returned.
+	Limit int
+}
+
+// Symbol is a definition found by a search.
+type Symbol struct {
+	Path string
+	Definition
+	Match Match
+	// Score orders the matches of a kind, higher first: a prefix scores
+	// for matching in case, and a subsequence for letters that start words
+	// of the name or follow the previous letter matched.
+	Score int
+}
+
+// SearchSymbols returns the definitions of the project whose names match
+// query, best first: by Match, then Score, shorter names, names, paths
+// and positions. The empty query matches every definition as a prefix.
+func (idx *Index) SearchSymbols(query string, opts *SearchOptions) []Symbol {
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	var kinds map[symbols.Kind]bool
+	if len(opts.Kinds) > 0 {
+		kinds = make(map[symbols.Kind]bool, len(opts.Kinds))
+		for _, k := range opts.Kinds {
+			kinds[k] = true
+		}
+	}
+
+	idx.mu.RLock()
+	var out []Symbol
+	for _, f := range idx.files {
+		for _, d := range f.Definitions {
+			if kinds != nil && !kinds[d.Kind] {
+				continue
+			}
+			m, score, ok := match(d.Name, query)
+			if !ok {
+				continue
+			}
+			out = append(out, Symbol{Path: f.Path, Definition: d, Match: m, Score: score})
+			if opts.Limit > 0 && len(out) >= 4*opts.Limit {
+				// keep the best, so that broad queries on large projects
+				// sort little at a time.
+				sortSymbols(out)
+				out = out[:opts.Limit]
+			}
+		}
+	}
+	idx.mu.RUnlock()
+
+	sortSymbols(out)
+	if opts.Limit > 0 && len(out) > opts.Limit {
+		out = out[:opts.Limit:opts.Limit]
+	}
+	return out
+}
+
+func sortSymbols(syms []Symbol) {
+	sort.Slice(syms, func(i, j int) bool {
+		a, b := &syms[i], &syms[j]
+		switch {
+		case a.Match != b.Match:
+			return a.Match < b.Match
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case len(a.Name) != len(b.Name):
+			return len(a.Name) < len(b.Name)
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		case a.Path != b.Path:
+			return a.Path < b.Path
+		}
+		return a.SelectionRange.StartByte < b.SelectionRange.StartByte
+	})
+}
+
+// match reports how name matches query, and the score of a subsequence.
+// It does not allocate, as it runs on every name of the project.
+func match(name, query string) (Match, int, bool) {
+	switch {
+	case name == query:
+		return Exact, 0, true
+	case len(name) < len(query):
+		return 0, 0, false
+	case len(name) == len(query) && equalFold(name, query):
+		return ExactFold, 0, true
+	case name[:len(query)] == query:
+		return Prefix, 1, true
+	case equalFold(name[:len(query)], query):
+		return Prefix, 0, true
+	}
+	greedy, ok := subsequence(name, query, false)
+	if !ok {
+		return 0, 0, false
+	}
+	if words, _ := subsequence(name, query, true); words > greedy {
+		return Subsequence, words, true
+	}
+	return Subsequence, greedy, true
+}
+
+// subsequence matches the letters of query in order in name and returns
+// the score of the match. Each letter matches the next one of name, or
+// with words the next that starts a word if there is one.
+func subsequence(name, query string, words bool) (int, bool) {
+	score, prev := 0, -2
+	i := 0
+	for j := 0; j < len(query); j++ {
+		c := lower(query[j])
+		next := -1
+		for k := i; k < len(name); k++ {
+			if lower(name[k]) != c {
+				continue
+			}
+			if next < 0 {
+				next = k
+			}
+			if !words || startsWord(name, k) {
+				next = k
+				break
+			}
+		}
+		if next < 0 {
+			return 0, false
+		}
+		if startsWord(name, next) {
+			score += 2
+		}
+		if next == prev+1 {
+			score++
+		}
+		prev, i = next, next+1
+	}
+	return score, true
+}
+
+// startsWord reports whether the letter at i starts a word of name: it is
+// the first, follows an underscore, or is an upper-case letter following
+// a lower-case one.
+func startsWord(name string, i int) bool {
+	if i == 0 || name[i-1] == '_' {
+		return true
+	}
+	return isUpper(name[i]) && !isUpper(name[i-1]) && name[i-1] != '_'
+}
+
+func equalFold(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if lower(a[i]) != lower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lower(c byte) byte {
+	if isUpper(c) {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isUpper(c byte) bool { return 'A' <= c && c <= 'Z' }
@@ -0,0 +1,296 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/internal/ignore"
+)
+
+// DefaultDebounce is the quiet period Watch waits for by default.
+const DefaultDebounce = 100 * time.Millisecond
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Debounce is how long to wait after a file system event for further
+	// ones before reindexing, so that a burst of changes, such as a branch
+	// checkout, is handled in one batch. Zero means DefaultDebounce.
+	Debounce time.Duration
+	// OnUpdate, if set, is called after each batch with the paths of the
+	// files that were reindexed or removed, in order.
+	OnUpdate func(paths []string)
+	// OnError, if set, receives the errors met while reindexing. They do
+	// not stop the watcher.
+	OnError func(error)
+}
+
+// Watcher keeps an index up to date with the files below its root.
+type Watcher struct {
+	idx    *Index
+	opts   WatchOptions
+	fsw    *fsnotify.Watcher
+	parser *ferrule.Parser
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// rules holds the ignore rules of every watched directory.
+	rules map[string]*ignore.Rules
+	// trees holds the last tree of each file changed while watching, so
+	// the next change is parsed incrementally.
+	trees map[string]*ferrule.Tree
+}
+
+// Watch builds the index of the project at root and keeps it up to date
+// until ctx is done or the watcher is closed. A nil opts uses the
+// defaults.
+//
+// Changed files are reparsed incrementally against the tree of their
+// previous version. Trees are only retained for files that changed while
+// watching, so memory use grows with the files being worked on rather than
+// with the size of the project. Changes to directories and to .gitignore
+// and .ferruleignore files trigger a Refresh of the whole index.
+func Watch(ctx context.Context, root string, opts *WatchOptions) (*Watcher, error) {
+	w := &Watcher{
+		idx:   New(root),
+		trees: make(map[string]*ferrule.Tree),
+		done:  make(chan struct{}),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Debounce <= 0 {
+		w.opts.Debounce = DefaultDebounce
+	}
+
+	var err error
+	if w.parser, err = ferrule.NewParser(); err != nil {
+		return nil, err
+	}
+	if w.fsw, err = fsnotify.NewWatcher(); err != nil {
+		w.parser.Close()
+		return nil, err
+	}
+	if err := w.rescan(ctx); err != nil {
+		w.fsw.Close()
+		w.parser.Close()
+		return nil, err
+	}
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.loop(ctx)
+	return w, nil
+}
+
+// Index returns the index being maintained.
+func (w *Watcher) Index() *Index { return w.idx }
+
+// Close stops watching and waits for a batch in progress to finish.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) loop(ctx context.Context) {
+	defer close(w.done)
+	defer w.release()
+
+	pending := make(map[string]fsnotify.Op)
+	timer := time.NewTimer(w.opts.Debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			pending[ev.Name] |= ev.Op
+			timer.Reset(w.opts.Debounce)
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			w.report(err)
+		case <-timer.C:
+			w.process(ctx, pending)
+			pending = make(map[string]fsnotify.Op)
+		}
+	}
+}
+
+func (w *Watcher) release() {
+	w.fsw.Close()
+	for _, t := range w.trees {
+		t.Close()
+	}
+	w.parser.Close()
+}
+
+func (w *Watcher) report(err error) {
+	if w.opts.OnError != nil && err != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// process handles one batch of events.
+func (w *Watcher) process(ctx context.Context, events map[string]fsnotify.Op) {
+	var changed []string
+	rescan := false
+	for p, op := range events {
+		rel, err := filepath.Rel(w.idx.root, p)
+		if err != nil {
+			continue
+		}
+		name := filepath.ToSlash(rel)
+		_, isDir := w.rules[name]
+		if ignore.IsFile(filepath.Base(name)) || isDir {
+			rescan = true
+			continue
+		}
+		if op.Has(fsnotify.Create) {
+			if info, err := os.Stat(p); err == nil && info.IsDir() {
+				rescan = true
+				continue
+			}
+		}
+		if filepath.Ext(name) == ".fe" {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+
+	var updated []string
+	if rescan {
+		before := make(map[string]*File)
+		for _, f := range w.idx.Files() {
+			before[f.Path] = f
+		}
+		if err := w.rescan(ctx); err != nil {
+			w.report(err)
+		}
+		for _, f := range w.idx.Files() {
+			if before[f.Path] != f {
+				updated = append(updated, f.Path)
+			}
+			delete(before, f.Path)
+		}
+		for name := range before {
+			updated = append(updated, name)
+		}
+	}
+	for _, name := range changed {
+		rules, watched := w.rules[parentDir(name)]
+		if !watched || rules.Ignored(name, false) {
+			continue
+		}
+		if err := w.update(ctx, name); err != nil {
+			w.report(err)
+			continue
+		}
+		updated = append(updated, name)
+	}
+	if len(updated) > 0 && w.opts.OnUpdate != nil {
+		sort.Strings(updated)
+		w.opts.OnUpdate(slices.Compact(updated))
+	}
+}
+
+// rescan refreshes the whole index and watches the directories found.
+func (w *Watcher) rescan(ctx context.Context) error {
+	s, err := w.idx.refresh(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for dir := range s.rules {
+		if _, ok := w.rules[dir]; ok {
+			continue
+		}
+		if err := w.fsw.Add(w.idx.osPath(dir)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	w.rules = s.rules
+	for name, t := range w.trees {
+		if _, ok := s.files[name]; !ok {
+			t.Close()
+			delete(w.trees, name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// update reindexes one file, reusing its previous tree when there is one.
+func (w *Watcher) update(ctx context.Context, name string) error {
+	p, fsys := w.idx.osPath(name), w.idx.FS()
+	info, err := fsys.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		w.idx.Remove(name)
+		if t := w.trees[name]; t != nil {
+			t.Close()
+			delete(w.trees, name)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := fsys.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	src := charset.Decode(data).Source
+
+	old := w.trees[name]
+	if old != nil {
+		edit := diff(old.Source(), src)
+		old.Raw().Edit(&edit)
+	}
+	tree, err := w.parser.Parse(ctx, src, old)
+	if old != nil {
+		old.Close()
+		delete(w.trees, name)
+	}
+	if err != nil {
+		return err
+	}
+	w.trees[name] = tree
+	f := Extract(name, tree)
+	f.ModTime, f.Size = info.ModTime(), info.Size()
+	w.idx.Put(f)
+	return nil
+}
+
+// diff describes the change from old to new as a single edit of the span
+// between their common prefix and suffix.
+func diff(old, new []byte) tree_sitter.InputEdit {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	return tree_sitter.InputEdit{
+		StartByte:      uint(prefix),
+		OldEndByte:     uint(len(old) - suffix),
+		NewEndByte:     uint(len(new) - suffix),
+		StartPosition:  edits.Point(old, uint(prefix)),
+		OldEndPosition: edits.Point(old, uint(len(old)-suffix)),
+		NewEndPosition: edits.Point(new, uint(len(new)-suffix)),
+	}
+}
@@ -0,0 +1,85 @@
+// Package batch reads and writes the records of the batch mode of
+// ferrulefmt and ferrule-lint, which take many files over one standard
+// input so that editors and pre-commit hooks need not start a process per
+// file.
+//
+// A record is a path and a source, each followed by a NUL byte:
+//
+//	path NUL source NUL
+//
+// Records are read and answered one at a time, so a client may write a
+// record and wait for its answer before writing the next. Sources cannot
+// hold NUL bytes, which ferrule source never does.
+package batch
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Record is a file sent in batch mode. Path need not exist: it names the
+// file in messages and selects the configuration that applies.
+type Record struct {
+	Path   string
+	Source []byte
+}
+
+// Reader reads records.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a reader of the records of r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF once the input ends between
+// records.
+func (r *Reader) Read() (Record, error) {
+	path, err := r.field()
+	if err == io.EOF && len(path) == 0 {
+		return Record{}, io.EOF
+	}
+	if err != nil {
+		return Record{}, truncated(err)
+	}
+	if len(path) == 0 {
+		return Record{}, errors.New("batch: empty path")
+	}
+	src, err := r.field()
+	if err != nil {
+		return Record{}, truncated(err)
+	}
+	return Record{Path: string(path), Source: src}, nil
+}
+
+// field returns the bytes up to the next NUL, which is dropped.
+func (r *Reader) field() ([]byte, error) {
+	b, err := r.r.ReadBytes(0)
+	if err != nil {
+		return b, err
+	}
+	return b[:len(b)-1], nil
+}
+
+func truncated(err error) error {
+	if err == io.EOF {
+		return errors.New("batch: input ends inside a record")
+	}
+	return err
+}
+
+// Write writes a record to w, as the answer to the record of the same
+// path.
+func Write(w io.Writer, path string, data []byte) error {
+	if _, err := io.WriteString(w, path+"\x00"); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte{0})
+	return err
+}
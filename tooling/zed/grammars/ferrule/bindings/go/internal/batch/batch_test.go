@@ -0,0 +1,43 @@
+package batch_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/batch"
+)
+
+func TestRead(t *testing.T) {
+	r := batch.NewReader(strings.NewReader("a.fe\x00const x=1;\x00empty.fe\x00\x00"))
+	for _, want := range []batch.Record{
+		{Path: "a.fe", Source: []byte("const x=1;")},
+		{Path: "empty.fe", Source: []byte{}},
+	} {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Path != want.Path || !bytes.Equal(got.Source, want.Source) {
+			t.Errorf("Read() = %q %q, want %q %q", got.Path, got.Source, want.Path, want.Source)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read() at the end = %v, want io.EOF", err)
+	}
+
+	for _, in := range []string{"a.fe", "a.fe\x00const x", "\x00src\x00"} {
+		if _, err := batch.NewReader(strings.NewReader(in)).Read(); err == nil || err == io.EOF {
+			t.Errorf("Read() of %q = %v, want an error", in, err)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	batch.Write(&b, "a.fe", []byte("const x = 1;\n"))
+	if got := b.String(); got != "a.fe\x00const x = 1;\n\x00" {
+		t.Errorf("Write() wrote %q", got)
+	}
+}
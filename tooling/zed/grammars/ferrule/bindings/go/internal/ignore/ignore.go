@@ -0,0 +1,183 @@
+// Package ignore matches paths against .gitignore files.
+//
+// It implements the pattern syntax documented in gitignore(5): comments,
+// negation with '!', directory-only patterns ending in '/', patterns
+// anchored by a slash, the wildcards '*', '?' and '[...]', and '**' for
+// any number of directories. Rules read from a directory apply below it,
+// and rules of deeper directories take precedence over those of their
+// ancestors.
+//
+// As in git, a file inside an ignored directory cannot be re-included:
+// walkers are expected to skip ignored directories rather than to test
+// every path below them. A Walker does so for the walks of the tools,
+// which all read the same Files.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// Rules is the set of ignore rules in effect in a directory. The zero
+// value and nil ignore nothing.
+type Rules struct {
+	parent *Rules
+	// dir is the slash-separated directory the patterns were read from,
+	// relative to the root of the walk; "" for the root itself.
+	dir      string
+	patterns []pattern
+}
+
+type pattern struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Add returns the rules in effect in dir, which lies below the directory of
+// r, given the contents of the ignore file found there. The rules of r
+// still apply; when data holds no patterns r itself is returned.
+func (r *Rules) Add(dir string, data []byte) *Rules {
+	var patterns []pattern
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		if p, ok := compile(sc.Text()); ok {
+			patterns = append(patterns, p)
+		}
+	}
+	if len(patterns) == 0 {
+		return r
+	}
+	return &Rules{parent: r, dir: dir, patterns: patterns}
+}
+
+// AddFile is like Add for the file named name in dir, which is found at
+// osDir in fsys. A missing file adds nothing.
+func (r *Rules) AddFile(fsys vfs.FS, dir, osDir, name string) (*Rules, error) {
+	data, err := fsys.ReadFile(filepath.Join(osDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	return r.Add(dir, data), nil
+}
+
+// Ignored reports whether the slash-separated path, relative to the root
+// of the walk, is ignored. isDir says whether it names a directory.
+func (r *Rules) Ignored(name string, isDir bool) bool {
+	ignored, _ := r.Match(name, isDir)
+	return ignored
+}
+
+// Match is like Ignored, also reporting whether a pattern matched name at
+// all, so that a path a negated pattern re-includes can be told from one
+// no pattern mentions.
+func (r *Rules) Match(name string, isDir bool) (ignored, matched bool) {
+	for ; r != nil; r = r.parent {
+		rel := name
+		if r.dir != "" {
+			if !strings.HasPrefix(name, r.dir+"/") {
+				continue
+			}
+			rel = name[len(r.dir)+1:]
+		}
+		for i := len(r.patterns) - 1; i >= 0; i-- {
+			p := r.patterns[i]
+			if p.dirOnly && !isDir {
+				continue
+			}
+			if p.re.MatchString(rel) {
+				return !p.negate, true
+			}
+		}
+	}
+	return false, false
+}
+
+// compile translates one line of an ignore file.
+func compile(line string) (pattern, bool) {
+	line = trimTrailingSpace(line)
+	if line == "" || line[0] == '#' {
+		return pattern{}, false
+	}
+	var p pattern
+	if line[0] == '!' {
+		p.negate, line = true, line[1:]
+	} else if line[0] == '\\' && len(line) > 1 && (line[1] == '#' || line[1] == '!') {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return pattern{}, false
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			re.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(line[i:], "**") && i+2 == len(line):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			re.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		default:
+			re.WriteString(regexp.QuoteMeta(line[i : i+1]))
+		}
+	}
+	re.WriteString("$")
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return pattern{}, false
+	}
+	p.re = compiled
+	return p, true
+}
+
+// trimTrailingSpace removes trailing spaces unless they are escaped with a
+// backslash.
+func trimTrailingSpace(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,55 @@
+package ignore_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/ignore"
+)
+
+func TestIgnored(t *testing.T) {
+	var root *ignore.Rules
+	root = root.Add("", []byte(`
+# build output
+/build/
+*.tmp
+gen/**/*.fe
+!keep.tmp
+\#hash
+doc/*.md
+`))
+	sub := root.Add("pkg", []byte("local.fe\n!important.tmp\n"))
+
+	for _, tt := range []struct {
+		rules *ignore.Rules
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{root, "build", true, true},
+		{root, "build", false, false},
+		{root, "src/build", true, false},
+		{root, "a.tmp", false, true},
+		{root, "deep/dir/a.tmp", false, true},
+		{root, "keep.tmp", false, false},
+		{root, "gen/x.fe", false, true},
+		{root, "gen/a/b/x.fe", false, true},
+		{root, "src/gen/x.fe", false, false},
+		{root, "#hash", false, true},
+		{root, "doc/a.md", false, true},
+		{root, "doc/sub/a.md", false, false},
+		{root, "main.fe", false, false},
+		{sub, "pkg/local.fe", false, true},
+		{sub, "local.fe", false, false},
+		{sub, "pkg/important.tmp", false, false},
+		{sub, "pkg/other.tmp", false, true},
+	} {
+		if got := tt.rules.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+
+	var none *ignore.Rules
+	if none.Ignored("a.tmp", false) {
+		t.Error("nil rules ignore a path")
+	}
+}
@@ -0,0 +1,86 @@
+package ignore
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// Files are the names of the ignore files the tools read in every
+// directory they walk: .gitignore, then .ferruleignore, whose rules take
+// precedence over those of .gitignore in the same directory.
+var Files = []string{".gitignore", ".ferruleignore"}
+
+// IsFile reports whether base is the name of one of Files.
+func IsFile(base string) bool {
+	for _, name := range Files {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AddFiles is like AddFile for each of names in turn. The rules of the
+// files read are kept when another cannot be read.
+func (r *Rules) AddFiles(fsys vfs.FS, dir, osDir string, names []string) (*Rules, error) {
+	var errs []error
+	for _, name := range names {
+		next, err := r.AddFile(fsys, dir, osDir, name)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		r = next
+	}
+	return r, errors.Join(errs...)
+}
+
+// A Walker keeps the rules in effect in the directories of a walk that
+// visits a directory before what it holds, as vfs.WalkDir and
+// filepath.WalkDir do, reading the ignore files of each directory entered.
+type Walker struct {
+	fsys  vfs.FS
+	root  string
+	rules map[string]*Rules
+}
+
+// NewWalker returns a walker for the tree at root in fsys, reading Files.
+func NewWalker(fsys vfs.FS, root string) *Walker {
+	return &Walker{fsys: vfs.Or(fsys), root: root, rules: make(map[string]*Rules)}
+}
+
+// Skip reports whether the walk should skip p, which it met below the
+// root, a directory if isDir: a .git directory, or a path the rules
+// ignore. The root itself is never skipped. It also returns the
+// slash-separated path of p relative to the root, "" for the root.
+func (w *Walker) Skip(p string, isDir bool) (name string, skip bool, err error) {
+	rel, err := filepath.Rel(w.root, p)
+	if err != nil {
+		return "", false, err
+	}
+	name = filepath.ToSlash(rel)
+	if name == "." {
+		name = ""
+	}
+	dir := path.Dir(name)
+	if dir == "." {
+		dir = ""
+	}
+	if name != "" && (isDir && path.Base(name) == ".git" || w.rules[dir].Ignored(name, isDir)) {
+		return name, true, nil
+	}
+	if isDir {
+		r, err := w.rules[dir].AddFiles(w.fsys, name, p, Files)
+		w.rules[name] = r
+		if err != nil {
+			return name, false, err
+		}
+	}
+	return name, false, nil
+}
+
+// Rules returns the rules in effect in each directory entered, by its
+// slash-separated path relative to the root, "" being the root.
+func (w *Walker) Rules() map[string]*Rules { return w.rules }
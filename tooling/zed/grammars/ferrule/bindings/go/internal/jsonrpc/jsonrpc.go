@@ -0,0 +1,171 @@
+// Package jsonrpc reads and writes the JSON-RPC 2.0 messages of
+// ferrule-lsp and ferruled.
+//
+// The two frame messages differently. ferrule-lsp speaks the base protocol
+// of the Language Server Protocol, a Content-Length header followed by the
+// JSON body, which NewConn reads and writes. ferruled takes one message per
+// line, as JSON with no newlines inside, which NewLineConn reads and
+// writes. Either way a Conn may be written from several goroutines.
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The error codes of JSON-RPC. Servers define their own in the range
+// -32000 to -32099.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// maxLine bounds the size of a message of NewLineConn.
+const maxLine = 64 << 20
+
+// Error is the error object of a response.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Request is an incoming request or notification; notifications have no
+// ID.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *Error          `json:"error"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Conn reads and writes messages.
+type Conn struct {
+	// FailureCode is the code of the replies to requests failing with an
+	// error other than an *Error; InternalError if zero.
+	FailureCode int
+
+	read  func() ([]byte, error)
+	frame func(body []byte) []byte
+	mu    sync.Mutex
+	w     io.Writer
+}
+
+// NewConn returns a connection framing messages as in the Language Server
+// Protocol.
+func NewConn(r io.Reader, w io.Writer) *Conn {
+	tr := textproto.NewReader(bufio.NewReader(r))
+	read := func() ([]byte, error) {
+		header, err := tr.ReadMIMEHeader()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(header) == 0 {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(tr.R, body); err != nil {
+			return nil, err
+		}
+		return body, nil
+	}
+	frame := func(body []byte) []byte {
+		return append(fmt.Appendf(nil, "Content-Length: %d\r\n\r\n", len(body)), body...)
+	}
+	return &Conn{read: read, frame: frame, w: w}
+}
+
+// NewLineConn returns a connection framing messages one per line. Reading
+// skips blank lines.
+func NewLineConn(r io.Reader, w io.Writer) *Conn {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64<<10), maxLine)
+	read := func() ([]byte, error) {
+		for s.Scan() {
+			if line := bytes.TrimSpace(s.Bytes()); len(line) > 0 {
+				return line, nil
+			}
+		}
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	frame := func(body []byte) []byte { return append(body, '\n') }
+	return &Conn{read: read, frame: frame, w: w}
+}
+
+// Read returns the body of the next message, or io.EOF at the end of the
+// stream. The body is valid until the next call.
+func (c *Conn) Read() ([]byte, error) { return c.read() }
+
+// Write sends v as a message.
+func (c *Conn) Write(v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	msg := c.frame(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.w.Write(msg)
+	return err
+}
+
+// Reply answers the request id with result, or with err if it is not nil.
+func (c *Conn) Reply(id json.RawMessage, result any, err error) error {
+	if err != nil {
+		var rerr *Error
+		if !errors.As(err, &rerr) {
+			code := c.FailureCode
+			if code == 0 {
+				code = InternalError
+			}
+			rerr = &Error{Code: code, Message: err.Error()}
+		}
+		return c.Write(errorResponse{JSONRPC: "2.0", ID: id, Error: rerr})
+	}
+	return c.Write(response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+// Notify sends a notification.
+func (c *Conn) Notify(method string, params any) error {
+	return c.Write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
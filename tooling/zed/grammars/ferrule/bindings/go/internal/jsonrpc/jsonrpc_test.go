@@ -0,0 +1,80 @@
+package jsonrpc_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/jsonrpc"
+)
+
+func TestConn(t *testing.T) {
+	want := []string{
+		`{"jsonrpc":"2.0","id":1,"result":"<a & b>"}`,
+		`{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"failed"}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"bad","data":[1]}}`,
+		`{"jsonrpc":"2.0","method":"note","params":null}`,
+	}
+	for _, tt := range []struct {
+		name  string
+		new   func(io.Reader, io.Writer) *jsonrpc.Conn
+		first string
+	}{
+		{"header", jsonrpc.NewConn, "Content-Length: 43\r\n\r\n" + want[0]},
+		{"line", jsonrpc.NewLineConn, want[0] + "\n"},
+	} {
+		var out bytes.Buffer
+		c := tt.new(nil, &out)
+		c.Reply([]byte("1"), "<a & b>", nil)
+		c.Reply([]byte("2"), nil, errors.New("failed"))
+		c.Reply([]byte("3"), nil, &jsonrpc.Error{Code: jsonrpc.InvalidParams, Message: "bad", Data: []int{1}})
+		c.Notify("note", nil)
+		if !strings.HasPrefix(out.String(), tt.first) {
+			t.Errorf("%s: wrote %q, want it to start with %q", tt.name, out.String(), tt.first)
+		}
+
+		r := tt.new(&out, nil)
+		for _, w := range want {
+			body, err := r.Read()
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if string(body) != w {
+				t.Errorf("%s: read %s, want %s", tt.name, body, w)
+			}
+		}
+		if _, err := r.Read(); err != io.EOF {
+			t.Errorf("%s: read %v at the end, want EOF", tt.name, err)
+		}
+	}
+}
+
+func TestFailureCode(t *testing.T) {
+	var out bytes.Buffer
+	c := jsonrpc.NewLineConn(nil, &out)
+	c.FailureCode = -32803
+	c.Reply([]byte("1"), nil, errors.New("failed"))
+	if got, want := out.String(), `{"jsonrpc":"2.0","id":1,"error":{"code":-32803,"message":"failed"}}`+"\n"; got != want {
+		t.Errorf("wrote %s, want %s", got, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	c := jsonrpc.NewLineConn(strings.NewReader("\n  {}  \n\n[]\n"), nil)
+	for _, want := range []string{"{}", "[]"} {
+		if body, err := c.Read(); err != nil || string(body) != want {
+			t.Errorf("Read() = %q, %v, want %q", body, err, want)
+		}
+	}
+
+	for _, in := range []string{
+		"Content-Length: x\r\n\r\n{}",
+		"Content-Length: 10\r\n\r\n{}",
+	} {
+		if _, err := jsonrpc.NewConn(strings.NewReader(in), nil).Read(); err == nil || err == io.EOF {
+			t.Errorf("Read() of %q = %v, want an error", in, err)
+		}
+	}
+}
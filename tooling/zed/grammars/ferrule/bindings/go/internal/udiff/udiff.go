@@ -0,0 +1,138 @@
+// Package udiff reads which lines unified diffs change, as printed by
+// diff -u and git diff, so that tools can limit themselves to changed
+// code.
+//
+// Only the new side of a diff counts: a file is changed at the lines the
+// diff adds to it, and deleted files and lines leave nothing to report.
+package udiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lines is a range of lines, numbered from one, End included.
+type Lines struct {
+	Start, End int
+}
+
+// File is a file of the new side of a diff and the lines the diff adds.
+type File struct {
+	// Path is the name of the file, without the b/ prefix git adds.
+	Path string
+	// Lines are the added lines, in order and not overlapping.
+	Lines []Lines
+}
+
+// Overlaps reports whether any line from start to end, both included, was
+// added.
+func (f *File) Overlaps(start, end int) bool {
+	i := sort.Search(len(f.Lines), func(i int) bool { return f.Lines[i].End >= start })
+	return i < len(f.Lines) && f.Lines[i].Start <= end
+}
+
+// Parse returns the files the diff changes, in the order of the diff.
+// Lines of the diff outside hunks, such as git's headers, are skipped.
+func Parse(diff []byte) ([]File, error) {
+	var out []File
+	var cur *File
+	s := bufio.NewScanner(bytes.NewReader(diff))
+	s.Buffer(nil, 1<<30)
+	row, left := 0, 0 // the next line of the new side, and those left in the hunk
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if left > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				cur.add(row)
+				row++
+				left--
+			case strings.HasPrefix(line, " "), line == "":
+				row++
+				left--
+			case strings.HasPrefix(line, "-"), strings.HasPrefix(line, `\`):
+			default:
+				return nil, fmt.Errorf("udiff: line %d: unexpected %q in hunk", n, line)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			out = append(out, File{Path: path(line[len("+++ "):])})
+			cur = &out[len(out)-1]
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("udiff: line %d: hunk before file header", n)
+			}
+			start, count, ok := newRange(line)
+			if !ok {
+				return nil, fmt.Errorf("udiff: line %d: malformed hunk header %q", n, line)
+			}
+			row, left = start, count
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	files := out[:0]
+	for _, f := range out {
+		if f.Path != "/dev/null" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func (f *File) add(row int) {
+	if n := len(f.Lines); n > 0 && f.Lines[n-1].End == row-1 {
+		f.Lines[n-1].End = row
+		return
+	}
+	f.Lines = append(f.Lines, Lines{row, row})
+}
+
+// path returns the name of a +++ header: without the timestamp diff -u
+// follows it with, and without git's prefix.
+func path(name string) string {
+	if i := strings.IndexByte(name, '\t'); i >= 0 {
+		name = name[:i]
+	}
+	if unquoted, err := strconv.Unquote(name); err == nil && strings.HasPrefix(name, `"`) {
+		name = unquoted
+	}
+	if name == "/dev/null" {
+		return name
+	}
+	if rest, ok := strings.CutPrefix(name, "b/"); ok {
+		return rest
+	}
+	return name
+}
+
+// newRange returns the start and length of the new side of a hunk header
+// such as "@@ -1,3 +1,4 @@".
+func newRange(header string) (start, count int, ok bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 4 || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, false
+	}
+	r := fields[2][1:]
+	count = 1
+	if s, c, found := strings.Cut(r, ","); found {
+		r = s
+		n, err := strconv.Atoi(c)
+		if err != nil {
+			return 0, 0, false
+		}
+		count = n
+	}
+	start, err := strconv.Atoi(r)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, count, true
+}
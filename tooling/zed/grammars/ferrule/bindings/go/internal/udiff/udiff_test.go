@@ -0,0 +1,73 @@
+package udiff_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/internal/udiff"
+)
+
+const diff = `diff --git a/main.fe b/main.fe
+index 1111111..2222222 100644
+--- a/main.fe
++++ b/main.fe
+@@ -1,4 +1,5 @@
+ const a = 1;
+-const b = 2;
++const b = 3;
++const c = 4;
+ const d = 5;
+ 
+@@ -10,0 +12 @@ function f() -> Unit {
++  g();
+diff --git a/gone.fe b/gone.fe
+deleted file mode 100644
+--- a/gone.fe
++++ /dev/null
+@@ -1 +0,0 @@
+-const x = 1;
+--- old/util.fe	2024-01-01 00:00:00
++++ new/util.fe	2024-01-02 00:00:00
+@@ -3,2 +3,2 @@
+-const y = 1;
++const y = 2;
+ const z = 3;
+\ No newline at end of file
+`
+
+func TestParse(t *testing.T) {
+	files, err := udiff.Parse([]byte(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []udiff.File{
+		{Path: "main.fe", Lines: []udiff.Lines{{2, 3}, {12, 12}}},
+		{Path: "new/util.fe", Lines: []udiff.Lines{{3, 3}}},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Parse() = %+v, want %+v", files, want)
+	}
+
+	f := files[0]
+	for _, tt := range []struct {
+		start, end int
+		want       bool
+	}{
+		{1, 1, false},
+		{1, 2, true},
+		{3, 3, true},
+		{4, 11, false},
+		{5, 20, true},
+		{13, 13, false},
+	} {
+		if got := f.Overlaps(tt.start, tt.end); got != tt.want {
+			t.Errorf("Overlaps(%d, %d) = %v, want %v", tt.start, tt.end, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"@@ -1 +1 @@\n+x\n", "+++ b/a.fe\n@@ -1 +x @@\n", "+++ b/a.fe\n@@ -1 +1,2 @@\n+a\n?b\n"} {
+		if _, err := udiff.Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", bad)
+		}
+	}
+}
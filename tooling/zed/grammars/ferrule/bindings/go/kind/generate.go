@@ -0,0 +1,3 @@
+package kind
+
+//go:generate go run ../cmd/ferrule-nodegen -what kinds -o kinds.go ../../../src/node-types.json
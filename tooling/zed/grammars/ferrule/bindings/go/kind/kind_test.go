@@ -0,0 +1,25 @@
+package kind_test
+
+import (
+	"testing"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+func TestKindsExistInGrammar(t *testing.T) {
+	language := tree_sitter.NewLanguage(tree_sitter_ferrule.Language())
+	named := []string{kind.SourceFile, kind.FunctionDeclaration, kind.MatchExpression, kind.TypeIdentifier}
+	for _, k := range named {
+		if language.IdForNodeKind(k, true) == 0 {
+			t.Errorf("named kind %q is not known to the grammar", k)
+		}
+	}
+	keywords := []string{kind.KeywordFunction, kind.KeywordPub, kind.KeywordUnit}
+	for _, k := range keywords {
+		if language.IdForNodeKind(k, false) == 0 {
+			t.Errorf("keyword %q is not known to the grammar", k)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Code generated by ferrule-nodegen from node-types.json. DO NOT EDIT.
+
+// Package kind defines the node kinds of the ferrule grammar.
+package kind
+
+// Named node kinds.
+const (
+	Error                   = "ERROR"
+	AnonymousFunction       = "anonymous_function"
+	ArrayExpression         = "array_expression"
+	BinaryExpression        = "binary_expression"
+	Block                   = "block"
+	BlockComment            = "block_comment"
+	BooleanLiteral          = "boolean_literal"
+	CallExpression          = "call_expression"
+	CapabilityDeclaration   = "capability_declaration"
+	CharLiteral             = "char_literal"
+	CheckExpression         = "check_expression"
+	ComponentDeclaration    = "component_declaration"
+	ConstDeclaration        = "const_declaration"
+	DestructuringPattern    = "destructuring_pattern"
+	DomainDeclaration       = "domain_declaration"
+	EffectsClause           = "effects_clause"
+	ErrExpression           = "err_expression"
+	ErrorClause             = "error_clause"
+	ErrorDeclaration        = "error_declaration"
+	ErrorVariant            = "error_variant"
+	EscapeSequence          = "escape_sequence"
+	ExpressionStatement     = "expression_statement"
+	FloatLiteral            = "float_literal"
+	ForStatement            = "for_statement"
+	FunctionDeclaration     = "function_declaration"
+	FunctionType            = "function_type"
+	GenericType             = "generic_type"
+	Identifier              = "identifier"
+	IfExpression            = "if_expression"
+	IfStatement             = "if_statement"
+	ImportDeclaration       = "import_declaration"
+	IndexExpression         = "index_expression"
+	IntegerLiteral          = "integer_literal"
+	LineComment             = "line_comment"
+	MatchArm                = "match_arm"
+	MatchExpression         = "match_expression"
+	MatchStatement          = "match_statement"
+	MemberExpression        = "member_expression"
+	OkExpression            = "ok_expression"
+	PackageDeclaration      = "package_declaration"
+	PackagePath             = "package_path"
+	Parameter               = "parameter"
+	ParameterList           = "parameter_list"
+	ParenthesizedExpression = "parenthesized_expression"
+	Pattern                 = "pattern"
+	PrimitiveType           = "primitive_type"
+	RecordBody              = "record_body"
+	RecordExpression        = "record_expression"
+	RecordField             = "record_field"
+	RecordType              = "record_type"
+	ReturnStatement         = "return_statement"
+	SourceFile              = "source_file"
+	StringLiteral           = "string_literal"
+	TypeDeclaration         = "type_declaration"
+	TypeIdentifier          = "type_identifier"
+	TypeParameter           = "type_parameter"
+	TypeParameters          = "type_parameters"
+	UnaryExpression         = "unary_expression"
+	UnionType               = "union_type"
+	UnionVariant            = "union_variant"
+	UseDeclaration          = "use_declaration"
+	WhileStatement          = "while_statement"
+)
+
+// Anonymous keyword tokens.
+const (
+	KeywordBool       = "Bool"
+	KeywordBytes      = "Bytes"
+	KeywordChar       = "Char"
+	KeywordNever      = "Never"
+	KeywordString     = "String"
+	KeywordUnit       = "Unit"
+	KeywordAs         = "as"
+	KeywordBreak      = "break"
+	KeywordCap        = "cap"
+	KeywordCapability = "capability"
+	KeywordCheck      = "check"
+	KeywordComponent  = "component"
+	KeywordConst      = "const"
+	KeywordContinue   = "continue"
+	KeywordDefer      = "defer"
+	KeywordDomain     = "domain"
+	KeywordEffects    = "effects"
+	KeywordElse       = "else"
+	KeywordErr        = "err"
+	KeywordError      = "error"
+	KeywordF16        = "f16"
+	KeywordF32        = "f32"
+	KeywordF64        = "f64"
+	KeywordFalse      = "false"
+	KeywordFor        = "for"
+	KeywordFunction   = "function"
+	KeywordI128       = "i128"
+	KeywordI16        = "i16"
+	KeywordI32        = "i32"
+	KeywordI64        = "i64"
+	KeywordI8         = "i8"
+	KeywordIf         = "if"
+	KeywordImport     = "import"
+	KeywordIn         = "in"
+	KeywordInout      = "inout"
+	KeywordIs         = "is"
+	KeywordMatch      = "match"
+	KeywordNull       = "null"
+	KeywordOk         = "ok"
+	KeywordOut        = "out"
+	KeywordPackage    = "package"
+	KeywordPub        = "pub"
+	KeywordReadonly   = "readonly"
+	KeywordReturn     = "return"
+	KeywordTrue       = "true"
+	KeywordType       = "type"
+	KeywordU128       = "u128"
+	KeywordU16        = "u16"
+	KeywordU32        = "u32"
+	KeywordU64        = "u64"
+	KeywordU8         = "u8"
+	KeywordUse        = "use"
+	KeywordUsize      = "usize"
+	KeywordVar        = "var"
+	KeywordWhere      = "where"
+	KeywordWhile      = "while"
+)
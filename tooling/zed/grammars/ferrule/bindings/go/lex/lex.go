@@ -0,0 +1,314 @@
+// Package lex presents a parse tree as a flat stream of tokens, for tools
+// that want words rather than trees: syntax-aware word diffs, spell
+// checkers and simple highlighters.
+//
+// The tokens are the leaves of the tree, but for string and character
+// literals, which are one token each, and comments, which are trivia
+// along with white space and line breaks. Each token carries the trivia
+// around it, attached as compilers usually attach it:
+//
+//   - the trivia after a token up to and including the end of its line is
+//     trailing trivia of the token;
+//   - any other trivia is leading trivia of the next token.
+//
+// The stream ends with a token of class EOF, without text, that holds the
+// trivia after the last line of code as its leading trivia. The leading
+// trivia, text and trailing trivia of the tokens, concatenated in order,
+// give back the source.
+package lex
+
+import (
+	"fmt"
+	"iter"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Class is the broad category of a token.
+type Class int
+
+const (
+	// Identifier is a name, of a value or of a type.
+	Identifier Class = iota
+	// Keyword is a reserved word, including the names of primitive types
+	// and the boolean literals.
+	Keyword
+	// String is a string or character literal.
+	String
+	// Number is an integer or floating-point literal.
+	Number
+	// Operator is a symbol such as + or ->.
+	Operator
+	// Punctuation is a bracket or a separator: one of ( ) [ ] { } , ; : .
+	Punctuation
+	// Invalid is text the parser could not make sense of.
+	Invalid
+	// EOF ends the stream.
+	EOF
+)
+
+func (c Class) String() string {
+	switch c {
+	case Identifier:
+		return "identifier"
+	case Keyword:
+		return "keyword"
+	case String:
+		return "string"
+	case Number:
+		return "number"
+	case Operator:
+		return "operator"
+	case Punctuation:
+		return "punctuation"
+	case Invalid:
+		return "invalid"
+	case EOF:
+		return "EOF"
+	}
+	return fmt.Sprintf("Class(%d)", int(c))
+}
+
+// TriviaKind is the kind of a piece of trivia.
+type TriviaKind int
+
+const (
+	// Space is a run of spaces and tabs, or of other text outside any
+	// token.
+	Space TriviaKind = iota
+	// Newline is a line break: \n, \r\n or a lone \r.
+	Newline
+	LineComment
+	BlockComment
+)
+
+func (k TriviaKind) String() string {
+	switch k {
+	case Space:
+		return "space"
+	case Newline:
+		return "newline"
+	case LineComment:
+		return "line comment"
+	case BlockComment:
+		return "block comment"
+	}
+	return fmt.Sprintf("TriviaKind(%d)", int(k))
+}
+
+// Trivia is text between tokens.
+type Trivia struct {
+	Kind  TriviaKind
+	Text  string
+	Range tree_sitter.Range
+}
+
+// Token is a token of the source.
+type Token struct {
+	// Kind is the kind of the tree node, such as identifier, "function"
+	// or "{"; "" for EOF.
+	Kind  string
+	Class Class
+	Text  string
+	Range tree_sitter.Range
+	// InError reports that the token lies inside an ERROR node, where the
+	// parser recovered from a syntax error.
+	InError           bool
+	Leading, Trailing []Trivia
+}
+
+// Tokens returns the token stream of tree, parsed from src.
+func Tokens(tree *ferrule.Tree, src []byte) iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		s := &stream{src: src, yield: yield}
+		if !s.walk(tree.RootNode()) {
+			return
+		}
+		end := uint(len(src))
+		p := advance(s.endPoint, src[min(s.end, end):])
+		s.gap(tree_sitter.Range{StartByte: s.end, EndByte: end, StartPoint: s.endPoint, EndPoint: p})
+		s.push(Token{Class: EOF, Range: tree_sitter.Range{StartByte: end, EndByte: end, StartPoint: p, EndPoint: p}})
+		s.flush()
+	}
+}
+
+// stream turns the leaves of a tree into tokens, one token late so that
+// the trivia after each can be split between it and the next.
+type stream struct {
+	src   []byte
+	yield func(Token) bool
+	// prev is the token waiting for its trailing trivia, if have is set.
+	prev Token
+	have bool
+	// pending is the trivia since prev.
+	pending []Trivia
+	// end is where the last token or comment ended.
+	end      uint
+	endPoint tree_sitter.Point
+	stopped  bool
+}
+
+// walk streams the tokens below n and reports whether to go on.
+func (s *stream) walk(n *tree_sitter.Node) bool {
+	cursor := n.Walk()
+	defer cursor.Close()
+	errors := 0 // the ERROR nodes entered
+	for {
+		n := cursor.Node()
+		k := n.Kind()
+		descend := false
+		switch {
+		case k == kind.LineComment || k == kind.BlockComment:
+			s.gapTo(n)
+			tk := LineComment
+			if k == kind.BlockComment {
+				tk = BlockComment
+			}
+			s.pending = append(s.pending, Trivia{Kind: tk, Text: n.Utf8Text(s.src), Range: n.Range()})
+			s.end, s.endPoint = n.EndByte(), n.EndPosition()
+		case n.IsMissing() || n.StartByte() == n.EndByte() && n.ChildCount() == 0:
+			// zero-width nodes the parser inserted hold no text.
+		case k == kind.StringLiteral || k == kind.CharLiteral || n.ChildCount() == 0:
+			s.gapTo(n)
+			s.push(Token{Kind: k, Class: classOf(n), Text: n.Utf8Text(s.src), Range: n.Range(), InError: errors > 0 || n.IsError()})
+			s.end, s.endPoint = n.EndByte(), n.EndPosition()
+		default:
+			descend = true
+		}
+		if s.stopped {
+			return false
+		}
+		if descend && cursor.GotoFirstChild() {
+			if n.IsError() {
+				errors++
+			}
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return true
+			}
+			if cursor.Node().IsError() {
+				errors--
+			}
+		}
+	}
+}
+
+// gapTo records the trivia between the last token or comment and n.
+func (s *stream) gapTo(n *tree_sitter.Node) {
+	s.gap(tree_sitter.Range{StartByte: s.end, EndByte: n.StartByte(), StartPoint: s.endPoint, EndPoint: n.StartPosition()})
+}
+
+// gap records the white space and line breaks of r as trivia.
+func (s *stream) gap(r tree_sitter.Range) {
+	if r.StartByte >= r.EndByte {
+		return
+	}
+	text := s.src[r.StartByte:r.EndByte]
+	at, p := r.StartByte, r.StartPoint
+	for len(text) > 0 {
+		n, k := 0, Space
+		switch text[0] {
+		case '\n':
+			n, k = 1, Newline
+		case '\r':
+			n, k = 1, Newline
+			if len(text) > 1 && text[1] == '\n' {
+				n = 2
+			}
+		default:
+			for n < len(text) && text[n] != '\n' && text[n] != '\r' {
+				n++
+			}
+		}
+		end := advance(p, text[:n])
+		s.pending = append(s.pending, Trivia{Kind: k, Text: string(text[:n]), Range: tree_sitter.Range{
+			StartByte: at, EndByte: at + uint(n), StartPoint: p, EndPoint: end,
+		}})
+		text, at, p = text[n:], at+uint(n), end
+	}
+}
+
+// push hands the trivia pending to the previous token and t, and yields
+// the previous token.
+func (s *stream) push(t Token) {
+	split := 0
+	if s.have {
+		for split < len(s.pending) {
+			split++
+			if s.pending[split-1].Kind == Newline {
+				break
+			}
+		}
+		if split > 0 {
+			s.prev.Trailing = s.pending[:split:split]
+		}
+		if !s.yield(s.prev) {
+			s.stopped = true
+			return
+		}
+	}
+	if split < len(s.pending) {
+		t.Leading = s.pending[split:]
+	}
+	s.prev, s.have, s.pending = t, true, nil
+}
+
+// flush yields the last token.
+func (s *stream) flush() {
+	if s.have && !s.stopped {
+		s.yield(s.prev)
+	}
+}
+
+// punctuation are the tokens of class Punctuation.
+var punctuation = map[string]bool{
+	"(": true, ")": true, "[": true, "]": true, "{": true, "}": true,
+	",": true, ";": true, ":": true, ".": true,
+}
+
+func classOf(n *tree_sitter.Node) Class {
+	k := n.Kind()
+	switch {
+	case n.IsError():
+		return Invalid
+	case k == kind.StringLiteral || k == kind.CharLiteral:
+		return String
+	case k == kind.IntegerLiteral || k == kind.FloatLiteral:
+		return Number
+	case k == kind.PrimitiveType || k == kind.BooleanLiteral:
+		return Keyword
+	case n.IsNamed():
+		return Identifier
+	case punctuation[k]:
+		return Punctuation
+	case isWord(k):
+		return Keyword
+	}
+	return Operator
+}
+
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	c := s[0]
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// advance returns the point after text, starting at p. Rows end at \n,
+// as tree-sitter counts them.
+func advance(p tree_sitter.Point, text []byte) tree_sitter.Point {
+	for _, c := range text {
+		if c == '\n' {
+			p.Row, p.Column = p.Row+1, 0
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
@@ -0,0 +1,116 @@
+package lex_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/lex"
+)
+
+func tokens(t *testing.T, src string) []lex.Token {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	var out []lex.Token
+	for tok := range lex.Tokens(tree, tree.Source()) {
+		out = append(out, tok)
+	}
+	return out
+}
+
+func TestTokens(t *testing.T) {
+	src := "// doc\nfunction f(x: i32) -> String { /* b */\r\n  return \"a\\nb\" + 1.5; // t\n}\n\n// end\n"
+	toks := tokens(t, src)
+
+	// the stream covers the source.
+	var b strings.Builder
+	for _, tok := range toks {
+		for _, tr := range tok.Leading {
+			b.WriteString(tr.Text)
+		}
+		b.WriteString(tok.Text)
+		for _, tr := range tok.Trailing {
+			b.WriteString(tr.Text)
+		}
+	}
+	if b.String() != src {
+		t.Errorf("tokens give back %q", b.String())
+	}
+
+	var got []string
+	for _, tok := range toks {
+		got = append(got, fmt.Sprintf("%s:%s", tok.Class, tok.Text))
+	}
+	want := "keyword:function identifier:f punctuation:( identifier:x punctuation:: keyword:i32 punctuation:) " +
+		"operator:-> keyword:String punctuation:{ keyword:return string:\"a\\nb\" operator:+ number:1.5 punctuation:; " +
+		"punctuation:} EOF:"
+	if strings.Join(got, " ") != want {
+		t.Errorf("tokens\n%s\nwant\n%s", strings.Join(got, " "), want)
+	}
+
+	trivia := func(ts []lex.Trivia) string {
+		var out []string
+		for _, tr := range ts {
+			out = append(out, tr.Kind.String())
+		}
+		return strings.Join(out, ",")
+	}
+	for _, tt := range []struct {
+		i                 int
+		leading, trailing string
+	}{
+		{0, "line comment,newline", "space"},
+		{9, "", "space,block comment,newline"},
+		{10, "space", "space"},
+		{14, "", "space,line comment,newline"},
+		{15, "", "newline"},
+		{16, "newline,line comment,newline", ""},
+	} {
+		tok := toks[tt.i]
+		if l, tr := trivia(tok.Leading), trivia(tok.Trailing); l != tt.leading || tr != tt.trailing {
+			t.Errorf("%q: leading %s, trailing %s, want %s and %s", tok.Text, l, tr, tt.leading, tt.trailing)
+		}
+	}
+	if r := toks[10].Range; r.StartPoint.Row != 2 || r.StartPoint.Column != 2 {
+		t.Errorf("return at %v", r.StartPoint)
+	}
+	if r := toks[9].Trailing[2].Range; r.StartByte != uint(strings.Index(src, "\r\n")) || r.EndPoint.Row != 2 {
+		t.Errorf("CRLF at %+v", r)
+	}
+}
+
+func TestTokensError(t *testing.T) {
+	toks := tokens(t, "const x = ) 1;\n")
+	var got []string
+	for _, tok := range toks {
+		if tok.InError {
+			got = append(got, fmt.Sprintf("%s:%s", tok.Class, tok.Text))
+		}
+	}
+	if strings.Join(got, " ") != "punctuation:)" || toks[len(toks)-1].Class != lex.EOF {
+		t.Errorf("tokens in error %v", got)
+	}
+}
+
+func TestTokensStop(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("const x = 1;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	n := 0
+	for range lex.Tokens(tree, tree.Source()) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("read %d tokens", n)
+	}
+}
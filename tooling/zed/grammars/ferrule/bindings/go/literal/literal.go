@@ -0,0 +1,284 @@
+// Package literal decodes ferrule literals into Go values and encodes Go
+// values as literals, so that the tools reading and writing them agree on
+// what a literal means.
+//
+// Integers may be written in decimal, or in hexadecimal, binary or octal
+// after 0x, 0b or 0o, with underscores between digits; they decode to a
+// *big.Int, as they are exact however large. Floats have digits on both
+// sides of the point and an optional exponent, and decode to a float64.
+// Strings and characters are quoted with " and ' and may hold the escape
+// sequences \n, \r, \t, \0, \\, \' and \"; they decode to a string and a
+// rune. Booleans decode to a bool.
+//
+// Formatting the value a literal decodes to gives the literal in its
+// canonical spelling: decimal integers without underscores, floats in the
+// shortest form that reads back the same, and strings escaped only where
+// they have to be.
+package literal
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+var (
+	// ErrSyntax is returned, wrapped, for text that is not a literal of
+	// the kind asked for.
+	ErrSyntax = errors.New("literal: invalid syntax")
+	// ErrRange is returned, wrapped, for a float literal too large for a
+	// float64.
+	ErrRange = errors.New("literal: value out of range")
+)
+
+// Parse returns the value of the literal n of the source src: a *big.Int
+// for an integer literal, a float64, a string, a rune or a bool. It
+// returns an error wrapping ErrSyntax if n is not a literal or a
+// malformed one, as the parser leaves inside syntax errors.
+func Parse(n *tree_sitter.Node, src []byte) (any, error) {
+	if n == nil {
+		return nil, fmt.Errorf("%w: no literal", ErrSyntax)
+	}
+	text := n.Utf8Text(src)
+	switch n.Kind() {
+	case kind.IntegerLiteral:
+		return Int(text)
+	case kind.FloatLiteral:
+		return Float(text)
+	case kind.StringLiteral:
+		return Unquote(text)
+	case kind.CharLiteral:
+		return UnquoteChar(text)
+	case kind.BooleanLiteral:
+		return Bool(text)
+	}
+	return nil, fmt.Errorf("%w: %s is not a literal", ErrSyntax, n.Kind())
+}
+
+// Int returns the value of an integer literal.
+func Int(text string) (*big.Int, error) {
+	digits, base := text, 10
+	if len(text) > 2 && text[0] == '0' {
+		switch text[1] {
+		case 'x':
+			base = 16
+		case 'b':
+			base = 2
+		case 'o':
+			base = 8
+		}
+	}
+	if base != 10 {
+		digits = text[2:]
+	}
+	if digits == "" || strings.ContainsAny(digits[:1], "_+-") {
+		return nil, fmt.Errorf("%w: integer %q", ErrSyntax, text)
+	}
+	i, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+	if !ok || i.Sign() < 0 {
+		return nil, fmt.Errorf("%w: integer %q", ErrSyntax, text)
+	}
+	return i, nil
+}
+
+// Float returns the value of a float literal. It returns an error
+// wrapping ErrRange if the value overflows a float64.
+func Float(text string) (float64, error) {
+	mantissa, _, _ := strings.Cut(strings.ToLower(text), "e")
+	whole, frac, ok := strings.Cut(mantissa, ".")
+	if !ok || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w: float %q", ErrSyntax, text)
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		return 0, fmt.Errorf("%w: float %q", ErrRange, text)
+	case err != nil:
+		return 0, fmt.Errorf("%w: float %q", ErrSyntax, text)
+	}
+	return f, nil
+}
+
+// digits reports whether s is a run of decimal digits and underscores
+// starting with a digit.
+func digits(s string) bool {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && s[i] != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Unquote returns the value of a string literal, its escape sequences
+// decoded.
+func Unquote(text string) (string, error) {
+	if len(text) < 2 || text[0] != '"' || text[len(text)-1] != '"' {
+		return "", fmt.Errorf("%w: string %q", ErrSyntax, text)
+	}
+	s, ok := unescape(text[1:len(text)-1], '"')
+	if !ok {
+		return "", fmt.Errorf("%w: string %q", ErrSyntax, text)
+	}
+	return s, nil
+}
+
+// UnquoteChar returns the value of a character literal.
+func UnquoteChar(text string) (rune, error) {
+	if len(text) < 2 || text[0] != '\'' || text[len(text)-1] != '\'' {
+		return 0, fmt.Errorf("%w: character %q", ErrSyntax, text)
+	}
+	s, ok := unescape(text[1:len(text)-1], '\'')
+	r, size := utf8.DecodeRuneInString(s)
+	if !ok || size == 0 || size != len(s) || r == utf8.RuneError && size == 1 {
+		return 0, fmt.Errorf("%w: character %q", ErrSyntax, text)
+	}
+	return r, nil
+}
+
+// Bool returns the value of a boolean literal.
+func Bool(text string) (bool, error) {
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: boolean %q", ErrSyntax, text)
+}
+
+// unescape decodes the escape sequences of the inside of a literal quoted
+// with quote, and reports whether it is well formed: every backslash
+// starts an escape sequence, and quote appears only escaped.
+func unescape(s string, quote byte) (string, bool) {
+	if !strings.ContainsAny(s, `\`+string(quote)) {
+		return s, true
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return "", false
+		case '\\':
+		default:
+			b.WriteByte(s[i])
+			continue
+		}
+		if i++; i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '0':
+			b.WriteByte(0)
+		case '\\', '\'', '"':
+			b.WriteByte(s[i])
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// Format returns the literal for v, one of the types Parse returns or an
+// int or int64 for an integer. A negative number is spelled as the
+// negation of a literal, since ferrule has no negative literals.
+func Format(v any) (string, error) {
+	switch v := v.(type) {
+	case *big.Int:
+		return FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return FormatFloat(v), nil
+	case string:
+		return Quote(v), nil
+	case rune:
+		return QuoteChar(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("literal: no literal for %T", v)
+}
+
+// FormatInt returns the integer literal for i in base 2, 8, 10 or 16,
+// with the prefix of the base and lower-case digits.
+func FormatInt(i *big.Int, base int) string {
+	prefix := map[int]string{2: "0b", 8: "0o", 16: "0x"}[base]
+	if prefix == "" && base != 10 {
+		panic(fmt.Sprintf("literal: unsupported base %d", base))
+	}
+	if i.Sign() < 0 {
+		return "-" + prefix + new(big.Int).Neg(i).Text(base)
+	}
+	return prefix + i.Text(base)
+}
+
+// FormatFloat returns the float literal for f: the shortest decimal that
+// reads back as f, with a point. Infinities and NaN, which have no
+// literal, are spelled as strconv spells them.
+func FormatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	switch {
+	case strings.Contains(s, ".") || math.IsInf(f, 0) || math.IsNaN(f):
+	case strings.Contains(s, "e"):
+		s = strings.Replace(s, "e", ".0e", 1)
+	default:
+		s += ".0"
+	}
+	return s
+}
+
+// Quote returns the string literal for s.
+func Quote(s string) string {
+	return `"` + escape(s, '"') + `"`
+}
+
+// QuoteChar returns the character literal for r.
+func QuoteChar(r rune) string {
+	return "'" + escape(string(r), '\'') + "'"
+}
+
+// escape returns s with the characters a literal quoted with quote cannot
+// hold verbatim written as escape sequences.
+func escape(s string, quote rune) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\\':
+			b.WriteString(`\\`)
+		case 0:
+			b.WriteString(`\0`)
+		case quote:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
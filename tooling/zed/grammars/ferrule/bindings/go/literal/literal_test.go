@@ -0,0 +1,100 @@
+package literal_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/literal"
+)
+
+func TestParse(t *testing.T) {
+	src := "const a = [1_000, 0xFF_ff, 0b101, 0o17, 1_0.5e-1, \"a\\tb\\\"\", '\\'', 'é', true];\n"
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	want := []string{"1000", "65535", "5", "15", "1.05", `"a\tb\""`, `'\''`, "'é'", "true"}
+	var got []string
+	var walk func(n *tree_sitter.Node)
+	walk = func(n *tree_sitter.Node) {
+		if v, err := literal.Parse(n, tree.Source()); err == nil {
+			s, err := literal.Format(v)
+			if err != nil {
+				t.Error(err)
+			}
+			got = append(got, s)
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(tree.RootNode())
+	if len(got) != len(want) {
+		t.Fatalf("literals %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("literal %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	for _, text := range []string{"", "0x", "0x_1", "-1", "+1", "1a"} {
+		if _, err := literal.Int(text); !errors.Is(err, literal.ErrSyntax) {
+			t.Errorf("Int(%q) error %v", text, err)
+		}
+	}
+	for _, text := range []string{"1", ".5", "1.", "1.5e", "x.5"} {
+		if _, err := literal.Float(text); !errors.Is(err, literal.ErrSyntax) {
+			t.Errorf("Float(%q) error %v", text, err)
+		}
+	}
+	if _, err := literal.Float("1.0e400"); !errors.Is(err, literal.ErrRange) {
+		t.Errorf("Float(1.0e400) error %v", err)
+	}
+	for _, text := range []string{`"`, `"a\"`, `"\q"`, `"a"b"`, `'ab'`, `''`} {
+		_, err := literal.Unquote(text)
+		if text[0] == '\'' {
+			_, err = literal.UnquoteChar(text)
+		}
+		if !errors.Is(err, literal.ErrSyntax) {
+			t.Errorf("unquoting %q: error %v", text, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{big.NewInt(42), "42"},
+		{-3, "-3"},
+		{1e6, "1.0e+06"},
+		{2.0, "2.0"},
+		{"line\n\x00\\", `"line\n\0\\"`},
+		{'"', `'"'`},
+		{"'", `"'"`},
+		{false, "false"},
+	}
+	for _, tt := range tests {
+		got, err := literal.Format(tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("Format(%#v) = %s, %v, want %s", tt.v, got, err, tt.want)
+		}
+	}
+	if got := literal.FormatInt(big.NewInt(255), 16); got != "0xff" {
+		t.Errorf("FormatInt(255, 16) = %s", got)
+	}
+	if _, err := literal.Format(struct{}{}); err == nil {
+		t.Error("Format(struct{}{}) succeeded")
+	}
+}
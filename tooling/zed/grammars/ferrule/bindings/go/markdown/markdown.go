@@ -0,0 +1,199 @@
+// Package markdown checks the ferrule examples of Markdown documents: the
+// fenced code blocks whose info string starts with "ferrule", as in
+//
+//	```ferrule
+//	const answer = 42;
+//	```
+//
+// Fences follow CommonMark: a run of at least three backticks or tildes,
+// indented by up to three spaces, closed by a run of the same character at
+// least as long, or else by the end of the document. Fences inside block
+// quotes and list items are not recognized. The positions of a block map
+// back to the document, so diagnostics point into the Markdown file.
+//
+// A block whose info string holds the word "ignore", as in
+// "```ferrule ignore", is returned by Blocks but skipped by Check, for
+// examples that are incomplete on purpose.
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Language is the first word of the info string of ferrule blocks.
+const Language = "ferrule"
+
+// Block is a ferrule code block of a document.
+type Block struct {
+	// Info is the info string after the opening fence.
+	Info string
+	// Source is the text of the block, without the indentation of its
+	// fence.
+	Source []byte
+	// Line is the zero-based line of the document holding the first line
+	// of Source.
+	Line uint
+	// lines maps the lines of Source to the document.
+	lines []line
+}
+
+// line is a line of a block: its offset in Source, the offset of the same
+// byte in the document and the number of bytes of indentation removed
+// before it.
+type line struct {
+	src, doc, indent uint
+}
+
+// Ignored reports whether the info string of b asks for it to be skipped.
+func (b Block) Ignored() bool {
+	for _, w := range strings.FieldsFunc(b.Info, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' }) {
+		if w == "ignore" {
+			return true
+		}
+	}
+	return false
+}
+
+// Offset returns the offset in the document of the byte at off in Source.
+func (b Block) Offset(off uint) uint {
+	i := sort.Search(len(b.lines), func(i int) bool { return b.lines[i].src > off }) - 1
+	if i < 0 {
+		return 0
+	}
+	return b.lines[i].doc + off - b.lines[i].src
+}
+
+// Range maps a range of a tree parsed from Source to the document.
+func (b Block) Range(r tree_sitter.Range) tree_sitter.Range {
+	return tree_sitter.Range{
+		StartByte:  b.Offset(r.StartByte),
+		EndByte:    b.Offset(r.EndByte),
+		StartPoint: b.point(r.StartPoint),
+		EndPoint:   b.point(r.EndPoint),
+	}
+}
+
+func (b Block) point(p tree_sitter.Point) tree_sitter.Point {
+	indent := uint(0)
+	if int(p.Row) < len(b.lines) {
+		indent = b.lines[p.Row].indent
+	}
+	return tree_sitter.Point{Row: b.Line + p.Row, Column: p.Column + indent}
+}
+
+// Blocks returns the ferrule blocks of the document md, in order.
+func Blocks(md []byte) []Block {
+	var out []Block
+	var (
+		fence  []byte // of the open block, nil outside blocks
+		indent int
+		wanted bool // whether the open block is a ferrule one
+		cur    Block
+	)
+	off, row := uint(0), uint(0)
+	for rest := md; len(rest) > 0; row++ {
+		n := bytes.IndexByte(rest, '\n') + 1
+		if n == 0 {
+			n = len(rest)
+		}
+		text := rest[:n]
+		rest = rest[n:]
+		content := bytes.TrimRight(text, "\r\n")
+
+		switch {
+		case fence == nil:
+			var info string
+			if fence, indent, info = opening(content); fence != nil {
+				lang, _, _ := strings.Cut(info, " ")
+				wanted = strings.TrimRight(lang, ",") == Language
+				cur = Block{Info: info, Source: []byte{}, Line: row + 1}
+			}
+		case closing(content, fence):
+			if wanted {
+				out = append(out, cur)
+			}
+			fence = nil
+		case wanted:
+			strip := 0
+			for strip < indent && strip < len(text) && text[strip] == ' ' {
+				strip++
+			}
+			cur.lines = append(cur.lines, line{src: uint(len(cur.Source)), doc: off + uint(strip), indent: uint(strip)})
+			cur.Source = append(cur.Source, text[strip:]...)
+		}
+		off += uint(n)
+	}
+	if fence != nil && wanted {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// opening returns the fence of an opening fence line, its indentation and
+// the info string, or a nil fence.
+func opening(s []byte) ([]byte, int, string) {
+	n := 0
+	for n < len(s) && n < 4 && s[n] == ' ' {
+		n++
+	}
+	if n > 3 || n == len(s) || (s[n] != '`' && s[n] != '~') {
+		return nil, 0, ""
+	}
+	c := s[n]
+	m := n
+	for m < len(s) && s[m] == c {
+		m++
+	}
+	if m-n < 3 {
+		return nil, 0, ""
+	}
+	info := strings.TrimSpace(string(s[m:]))
+	if c == '`' && strings.Contains(info, "`") {
+		return nil, 0, ""
+	}
+	return s[n:m], n, info
+}
+
+// closing reports whether s closes a block opened by fence.
+func closing(s, fence []byte) bool {
+	n := 0
+	for n < len(s) && n < 4 && s[n] == ' ' {
+		n++
+	}
+	if n > 3 {
+		return false
+	}
+	m := n
+	for m < len(s) && s[m] == fence[0] {
+		m++
+	}
+	return m-n >= len(fence) && len(bytes.TrimSpace(s[m:])) == 0
+}
+
+// Check parses the ferrule blocks of md that are not ignored and returns
+// their syntax errors, with ranges in md.
+func Check(ctx context.Context, md []byte) ([]ferrule.Diagnostic, error) {
+	var out []ferrule.Diagnostic
+	for _, b := range Blocks(md) {
+		if b.Ignored() {
+			continue
+		}
+		tree, err := ferrule.Parse(ctx, b.Source)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range tree.Diagnostics() {
+			d.Range = b.Range(d.Range)
+			out = append(out, d)
+		}
+		tree.Close()
+	}
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package markdown_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/markdown"
+)
+
+const doc = "# Examples\n" +
+	"\n" +
+	"```ferrule\n" +
+	"const a = 1;\n" +
+	"```\n" +
+	"\n" +
+	"```go\n" +
+	"func main() {}\n" +
+	"```\n" +
+	"\n" +
+	"  ~~~~ ferrule\n" +
+	"  function f() -> i32 {\n" +
+	"    return (1;\n" +
+	"  }\n" +
+	"  ~~~~\n" +
+	"\n" +
+	"```ferrule ignore\n" +
+	"const = ;\n" +
+	"```\n"
+
+func TestBlocks(t *testing.T) {
+	blocks := markdown.Blocks([]byte(doc))
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+	if got := string(blocks[0].Source); got != "const a = 1;\n" || blocks[0].Line != 3 {
+		t.Errorf("first block %q at line %d", got, blocks[0].Line)
+	}
+	if got := string(blocks[1].Source); got != "function f() -> i32 {\n  return (1;\n}\n" || blocks[1].Info != "ferrule" {
+		t.Errorf("second block %q, info %q", got, blocks[1].Info)
+	}
+	if !blocks[2].Ignored() || blocks[0].Ignored() {
+		t.Errorf("Ignored() = %v, %v", blocks[0].Ignored(), blocks[2].Ignored())
+	}
+
+	// "return" on the second line of the block is indented by two more
+	// bytes in the document.
+	b := blocks[1]
+	off := uint(strings.Index(string(b.Source), "return"))
+	if got, want := b.Offset(off), uint(strings.Index(doc, "return")); got != want {
+		t.Errorf("Offset(%d) = %d, want %d", off, got, want)
+	}
+
+	unclosed := markdown.Blocks([]byte("```ferrule\nconst a = 1;\n"))
+	if len(unclosed) != 1 || string(unclosed[0].Source) != "const a = 1;\n" {
+		t.Errorf("unclosed block: %+v", unclosed)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	diags, err := markdown.Check(context.Background(), []byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diags) != 1 {
+		t.Fatalf("got %v, want one diagnostic", diags)
+	}
+	d := diags[0]
+	if d.Range.StartPoint.Row != 12 || d.Range.StartPoint.Column < 4 {
+		t.Errorf("diagnostic at %v, want line 13 past the indentation: %s", d.Range.StartPoint, d)
+	}
+	if text := doc[d.Range.StartByte:d.Range.EndByte]; !strings.Contains("return (1;", text) {
+		t.Errorf("diagnostic covers %q", text)
+	}
+}
@@ -0,0 +1,327 @@
+// Package merge implements a three-way merge of ferrule sources that works
+// on syntax nodes rather than lines.
+//
+// The children of a node are merged as sequences in the manner of diff3:
+// the children that are unchanged on both sides anchor the merge, and each
+// stretch between anchors is taken from whichever side changed it. When
+// both sides changed the same child, the merge descends into it. When both
+// sides added different declarations or statements at the same place in a
+// file, a block or a component, both additions are kept, ours first, unless
+// they declare the same name. Anything else changed on both sides is a
+// conflict, written out with the usual conflict markers.
+//
+// Sources with syntax errors are merged line by line instead.
+package merge
+
+import (
+	"context"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Options configures Merge. The zero value is ready to use.
+type Options struct {
+	// OursLabel and TheirsLabel follow the conflict markers; they default
+	// to "ours" and "theirs".
+	OursLabel, TheirsLabel string
+	// MarkerSize is the length of the conflict markers, 7 by default.
+	MarkerSize int
+}
+
+// unionKinds are the nodes whose children may be added on both sides.
+var unionKinds = map[string]bool{
+	kind.SourceFile:           true,
+	kind.Block:                true,
+	kind.ComponentDeclaration: true,
+}
+
+// Merge merges the changes from base to ours and from base to theirs. It
+// returns the result and the number of conflicts marked in it.
+func Merge(base, ours, theirs []byte, opts *Options) ([]byte, int, error) {
+	m := &merger{opts: Options{OursLabel: "ours", TheirsLabel: "theirs", MarkerSize: 7}}
+	if opts != nil {
+		if opts.OursLabel != "" {
+			m.opts.OursLabel = opts.OursLabel
+		}
+		if opts.TheirsLabel != "" {
+			m.opts.TheirsLabel = opts.TheirsLabel
+		}
+		if opts.MarkerSize > 0 {
+			m.opts.MarkerSize = opts.MarkerSize
+		}
+	}
+
+	var trees [3]*ferrule.Tree
+	for i, src := range [][]byte{base, ours, theirs} {
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer tree.Close()
+		trees[i] = tree
+	}
+	if trees[0].HasError() || trees[1].HasError() || trees[2].HasError() {
+		r := m.sequence(lines(base), lines(ours), lines(theirs), false, true)
+		return []byte(r.text), r.conflicts, nil
+	}
+
+	r := m.sequence(top(trees[0]), top(trees[1]), top(trees[2]), true, true)
+	return []byte(r.text), r.conflicts, nil
+}
+
+// top returns the top-level nodes of tree as elements, followed by an
+// empty one holding the trailing space of the file.
+func top(tree *ferrule.Tree) []element {
+	src := tree.Source()
+	es := children(element{node: tree.RootNode(), src: src})
+	end := uint(0)
+	if len(es) > 0 {
+		es[0].gap = string(src[:es[0].node.StartByte()])
+		end = es[len(es)-1].node.EndByte()
+	}
+	return append(es, element{gap: string(src[end:])})
+}
+
+// element is one item of a merged sequence: a syntax node, or a line when
+// merging line by line. gap is the text between the previous item and this
+// one.
+type element struct {
+	node *tree_sitter.Node
+	src  []byte
+	gap  string
+	text string
+}
+
+func (e element) full() string { return e.gap + e.text }
+
+func lines(src []byte) []element {
+	var out []element
+	for _, l := range strings.SplitAfter(string(src), "\n") {
+		if l != "" {
+			out = append(out, element{text: l})
+		}
+	}
+	return out
+}
+
+// children returns the children of e as elements.
+func children(e element) []element {
+	var out []element
+	prev := e.node.StartByte()
+	for i := uint(0); i < e.node.ChildCount(); i++ {
+		c := e.node.Child(i)
+		if c.IsMissing() {
+			continue
+		}
+		out = append(out, element{
+			node: c,
+			src:  e.src,
+			gap:  string(e.src[prev:c.StartByte()]),
+			text: c.Utf8Text(e.src),
+		})
+		prev = c.EndByte()
+	}
+	return out
+}
+
+type merger struct {
+	opts Options
+}
+
+// result is the outcome of merging part of the sources. A part that cannot
+// be merged within itself is not ok; the conflict is then marked at the
+// enclosing declaration or statement.
+type result struct {
+	text      string
+	conflicts int
+	ok        bool
+}
+
+// node merges the nodes o, a and b, without their gaps.
+func (m *merger) node(o, a, b element) result {
+	switch {
+	case a.text == b.text || o.text == b.text:
+		return result{text: a.text, ok: true}
+	case o.text == a.text:
+		return result{text: b.text, ok: true}
+	case o.node.Kind() == a.node.Kind() && a.node.Kind() == b.node.Kind() && o.node.ChildCount() > 0 && a.node.ChildCount() > 0 && b.node.ChildCount() > 0:
+		container := unionKinds[o.node.Kind()]
+		return m.sequence(children(o), children(a), children(b), container, container)
+	}
+	return result{}
+}
+
+// sequence merges the sequences o, a and b. union allows additions on
+// both sides at the same place, and markers allows conflicts to be marked
+// between the elements rather than reported to the caller.
+func (m *merger) sequence(o, a, b []element, union, markers bool) result {
+	var out strings.Builder
+	conflicts := 0
+	ma, mb := lcs(o, a), lcs(o, b)
+	oi, ai, bi := 0, 0, 0
+	for k := 0; k <= len(o); k++ {
+		if k < len(o) && (ma[k] < 0 || mb[k] < 0) {
+			continue
+		}
+		// Next anchor at k, or the end of the sequences.
+		ae, be := len(a), len(b)
+		if k < len(o) {
+			ae, be = ma[k], mb[k]
+		}
+		r := m.chunk(o[oi:k], a[ai:ae], b[bi:be], union)
+		marked := !r.ok
+		if marked {
+			if !markers {
+				return result{}
+			}
+			r = m.conflict(out.String(), a[ai:ae], b[bi:be])
+		}
+		out.WriteString(r.text)
+		conflicts += r.conflicts
+		if k < len(o) {
+			// The space before the anchor is merged like the rest; the
+			// conflict markers end the line already.
+			gap := a[ae].gap
+			if gap == o[k].gap {
+				gap = b[be].gap
+			}
+			if marked {
+				gap = strings.TrimPrefix(gap, "\n")
+			}
+			out.WriteString(gap + a[ae].text)
+			oi, ai, bi = k+1, ae+1, be+1
+		}
+	}
+	return result{text: out.String(), conflicts: conflicts, ok: true}
+}
+
+// chunk merges a stretch between anchors, gaps included.
+func (m *merger) chunk(o, a, b []element, union bool) result {
+	switch {
+	case same(a, b) || same(b, o):
+		return result{text: join(a), ok: true}
+	case same(a, o):
+		return result{text: join(b), ok: true}
+	case len(o) == 1 && len(a) == 1 && len(b) == 1 && o[0].node != nil:
+		r := m.node(o[0], a[0], b[0])
+		r.text = a[0].gap + r.text
+		return r
+	case len(o) == 0 && union && declarations(a) && declarations(b) && !clash(a, b):
+		return result{text: join(a) + join(b), ok: true}
+	}
+	return result{}
+}
+
+func join(es []element) string {
+	var b strings.Builder
+	for _, e := range es {
+		b.WriteString(e.full())
+	}
+	return b.String()
+}
+
+// conflict marks a conflict between a and b, to follow the text before.
+// The markers and both sides are put on lines of their own.
+func (m *merger) conflict(before string, a, b []element) result {
+	var out strings.Builder
+	newline := func() {
+		if s := before + out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	side := func(es []element) {
+		for i, e := range es {
+			if i == 0 {
+				out.WriteString(strings.TrimLeft(e.gap, "\n"))
+				out.WriteString(e.text)
+			} else {
+				out.WriteString(e.full())
+			}
+		}
+		newline()
+	}
+	newline()
+	out.WriteString(strings.Repeat("<", m.opts.MarkerSize) + " " + m.opts.OursLabel + "\n")
+	side(a)
+	out.WriteString(strings.Repeat("=", m.opts.MarkerSize) + "\n")
+	side(b)
+	out.WriteString(strings.Repeat(">", m.opts.MarkerSize) + " " + m.opts.TheirsLabel + "\n")
+	return result{text: out.String(), conflicts: 1, ok: true}
+}
+
+func same(a, b []element) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].text != b[i].text {
+			return false
+		}
+	}
+	return true
+}
+
+// declarations reports whether es consists of named nodes only, such as
+// declarations and statements.
+func declarations(es []element) bool {
+	for _, e := range es {
+		if e.node == nil || !e.node.IsNamed() {
+			return false
+		}
+	}
+	return true
+}
+
+// clash reports whether a and b declare the same name.
+func clash(a, b []element) bool {
+	names := make(map[string]bool)
+	for _, e := range a {
+		if n := e.node.ChildByFieldName(field.Name); n != nil {
+			names[e.node.Kind()+" "+n.Utf8Text(e.src)] = true
+		}
+	}
+	for _, e := range b {
+		if n := e.node.ChildByFieldName(field.Name); n != nil && names[e.node.Kind()+" "+n.Utf8Text(e.src)] {
+			return true
+		}
+	}
+	return false
+}
+
+// lcs returns, for each element of o, the index of its partner in a
+// longest common subsequence of o and a, or -1.
+func lcs(o, a []element) []int {
+	n, k := len(o), len(a)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, k+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := k - 1; j >= 0; j-- {
+			if o[i].text == a[j].text {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	out := make([]int, n)
+	for i, j := 0, 0; i < n; {
+		switch {
+		case j < k && o[i].text == a[j].text:
+			out[i] = j
+			i, j = i+1, j+1
+		case j < k && table[i][j+1] >= table[i+1][j]:
+			j++
+		default:
+			out[i] = -1
+			i++
+		}
+	}
+	return out
+}
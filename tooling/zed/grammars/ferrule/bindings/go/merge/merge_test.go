@@ -0,0 +1,117 @@
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/merge"
+)
+
+const base = `package app;
+
+function f() -> i32 {
+  const a = 1;
+  const b = 2;
+  return a + b;
+}
+`
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name, ours, theirs, want string
+		conflicts                int
+	}{
+		{
+			name:   "additions at the same place",
+			ours:   base + "\nfunction g() -> i32 {\n  return 1;\n}\n",
+			theirs: base + "\nfunction h() -> i32 {\n  return 2;\n}\n",
+			want:   base + "\nfunction g() -> i32 {\n  return 1;\n}\n\nfunction h() -> i32 {\n  return 2;\n}\n",
+		},
+		{
+			name: "changes to different statements",
+			ours: `package app;
+
+function f() -> i32 {
+  const a = 10;
+  const b = 2;
+  return a + b;
+}
+`,
+			theirs: `package app;
+
+function f() -> i32 {
+  const a = 1;
+  const b = 2;
+  log(b);
+  return a * b;
+}
+`,
+			want: `package app;
+
+function f() -> i32 {
+  const a = 10;
+  const b = 2;
+  log(b);
+  return a * b;
+}
+`,
+		},
+		{
+			name: "conflicting changes",
+			ours: `package app;
+
+function f() -> i32 {
+  const a = 10;
+  const b = 2;
+  return a + b;
+}
+`,
+			theirs: `package app;
+
+function f() -> i32 {
+  const a = 11;
+  const b = 2;
+  return a + b;
+}
+`,
+			want: `package app;
+
+function f() -> i32 {
+<<<<<<< ours
+  const a = 10;
+=======
+  const a = 11;
+>>>>>>> theirs
+  const b = 2;
+  return a + b;
+}
+`,
+			conflicts: 1,
+		},
+		{
+			name:      "same name added twice",
+			ours:      base + "\nfunction g() -> i32 {\n  return 1;\n}\n",
+			theirs:    base + "\nfunction g() -> i32 {\n  return 2;\n}\n",
+			want:      base + "<<<<<<< ours\nfunction g() -> i32 {\n  return 1;\n}\n=======\nfunction g() -> i32 {\n  return 2;\n}\n>>>>>>> theirs\n",
+			conflicts: 1,
+		},
+		{
+			name:   "syntax errors",
+			ours:   "a(\nb\nc\n",
+			theirs: "a\nb\nc(\n",
+			want:   "a(\nb\nc(\n",
+		},
+	}
+	for _, tt := range tests {
+		b := base
+		if tt.name == "syntax errors" {
+			b = "a\nb\nc\n"
+		}
+		got, n, err := merge.Merge([]byte(b), []byte(tt.ours), []byte(tt.theirs), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want || n != tt.conflicts {
+			t.Errorf("%s: got %d conflicts:\n%q\nwant %d:\n%q", tt.name, n, got, tt.conflicts, tt.want)
+		}
+	}
+}
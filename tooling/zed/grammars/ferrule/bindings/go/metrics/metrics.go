@@ -0,0 +1,224 @@
+// Package metrics measures the complexity of ferrule functions, for
+// reports and for budgets enforced in CI.
+//
+// Cyclomatic complexity counts the paths through a function: one, plus one
+// for every if, while and for, every match arm after the first, every arm
+// guard, every && and || and every check expression, which may leave the
+// function early.
+//
+// Cognitive complexity, after G. Ann Campbell's white paper, weighs how
+// hard the control flow is to follow: every if, else, while, for and
+// match adds one, and those other than else add the depth they are nested
+// at besides; a run of the same boolean operator adds one. The bodies of
+// control structures and of anonymous functions nest.
+//
+// Anonymous functions count towards the function they appear in.
+package metrics
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Metrics are the measurements of a function.
+type Metrics struct {
+	Cyclomatic int `json:"cyclomatic"`
+	Cognitive  int `json:"cognitive"`
+	// Nesting is the deepest nesting of control structures and anonymous
+	// functions in the body.
+	Nesting int `json:"nesting"`
+	// Lines is the number of lines the declaration spans.
+	Lines int `json:"lines"`
+	// CodeLines is the number of those holding more than comments and
+	// blanks.
+	CodeLines int `json:"codeLines"`
+}
+
+// Complexity measures fn.
+func Complexity(fn *ast.FunctionDeclaration) Metrics {
+	n := fn.Raw()
+	m := Metrics{
+		Cyclomatic: 1,
+		Lines:      int(n.EndPosition().Row-n.StartPosition().Row) + 1,
+	}
+	rows := make(map[uint]bool)
+	countRows(n, rows)
+	m.CodeLines = len(rows)
+	if body := n.ChildByFieldName(field.Body); body != nil {
+		c := &counter{m: &m}
+		c.visit(body, 0)
+	}
+	return m
+}
+
+// Function is a function of a file with its measurements.
+type Function struct {
+	// Name is the name of the function, prefixed with that of the
+	// component it belongs to as in "Cache.get".
+	Name string `json:"name"`
+	// Line is the one-based line of the declaration.
+	Line int `json:"line"`
+	Metrics
+}
+
+// File measures the top-level functions of tree and those of its
+// components, in source order.
+func File(tree *ferrule.Tree) []Function {
+	src := tree.Source()
+	var out []Function
+	add := func(prefix string, fn *ast.FunctionDeclaration) {
+		name := ""
+		if id := fn.Name(); id != nil {
+			name = prefix + id.Text(src)
+		}
+		out = append(out, Function{Name: name, Line: int(fn.Raw().StartPosition().Row) + 1, Metrics: Complexity(fn)})
+	}
+	for _, item := range ast.Root(tree.Raw()).Items() {
+		switch item := item.(type) {
+		case *ast.FunctionDeclaration:
+			add("", item)
+		case *ast.ComponentDeclaration:
+			prefix := ""
+			if id := item.Name(); id != nil {
+				prefix = id.Text(src) + "."
+			}
+			for _, fn := range item.FunctionDeclarations() {
+				add(prefix, fn)
+			}
+		}
+	}
+	return out
+}
+
+// countRows marks the rows on which the leaves below n other than
+// comments start.
+func countRows(n *tree_sitter.Node, rows map[uint]bool) {
+	if n.Kind() == kind.LineComment || n.Kind() == kind.BlockComment {
+		return
+	}
+	if n.ChildCount() == 0 {
+		if n.StartByte() < n.EndByte() {
+			rows[n.StartPosition().Row] = true
+		}
+		return
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		countRows(n.Child(i), rows)
+	}
+}
+
+type counter struct {
+	m *Metrics
+}
+
+// visit counts n, found at nesting depth depth.
+func (c *counter) visit(n *tree_sitter.Node, depth int) {
+	c.m.Nesting = max(c.m.Nesting, depth)
+	switch n.Kind() {
+	case kind.IfStatement:
+		c.m.Cyclomatic++
+		c.m.Cognitive += 1 + depth
+		c.ifChain(n, depth)
+		return
+	case kind.WhileStatement, kind.ForStatement:
+		c.m.Cyclomatic++
+		c.m.Cognitive += 1 + depth
+		c.children(n, depth, func(child *tree_sitter.Node) bool { return child.Kind() == kind.Block })
+		return
+	case kind.MatchStatement, kind.MatchExpression:
+		c.m.Cognitive += 1 + depth
+		arms := 0
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			child := n.NamedChild(i)
+			if child.Kind() != kind.MatchArm {
+				c.visit(child, depth)
+				continue
+			}
+			if arms++; arms > 1 {
+				c.m.Cyclomatic++
+			}
+			c.arm(child, depth+1)
+		}
+		return
+	case kind.AnonymousFunction:
+		c.children(n, depth, func(child *tree_sitter.Node) bool { return child.Kind() == kind.Block })
+		return
+	case kind.CheckExpression:
+		c.m.Cyclomatic++
+	case kind.BinaryExpression:
+		if op := logical(n); op != "" {
+			c.m.Cyclomatic++
+			// a run of the same operator counts once, at its top.
+			if parent := n.Parent(); parent == nil || logical(parent) != op {
+				c.m.Cognitive++
+			}
+		}
+	}
+	c.children(n, depth, nil)
+}
+
+// children visits the named children of n, one level deeper if nests
+// says so.
+func (c *counter) children(n *tree_sitter.Node, depth int, nests func(*tree_sitter.Node) bool) {
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		child := n.NamedChild(i)
+		if nests != nil && nests(child) {
+			c.visit(child, depth+1)
+		} else {
+			c.visit(child, depth)
+		}
+	}
+}
+
+// ifChain visits the condition and branches of the if statement n, whose
+// else if branches add one each without regard to nesting.
+func (c *counter) ifChain(n *tree_sitter.Node, depth int) {
+	if cond := n.ChildByFieldName(field.Condition); cond != nil {
+		c.visit(cond, depth)
+	}
+	if body := n.ChildByFieldName(field.Consequence); body != nil {
+		c.visit(body, depth+1)
+	}
+	alt := n.ChildByFieldName(field.Alternative)
+	switch {
+	case alt == nil:
+	case alt.Kind() == kind.IfStatement:
+		c.m.Cyclomatic++
+		c.m.Cognitive++
+		c.ifChain(alt, depth)
+	default:
+		c.m.Cognitive++
+		c.visit(alt, depth+1)
+	}
+}
+
+// arm visits a match arm, whose guard adds a path.
+func (c *counter) arm(n *tree_sitter.Node, depth int) {
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if n.Child(i).Kind() == kind.KeywordIf {
+			c.m.Cyclomatic++
+		}
+	}
+	c.m.Nesting = max(c.m.Nesting, depth)
+	c.children(n, depth, nil)
+}
+
+// logical returns the operator of n if it is a && or || expression.
+func logical(n *tree_sitter.Node) string {
+	if n.Kind() != kind.BinaryExpression {
+		return ""
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if child := n.Child(i); !child.IsNamed() {
+			if op := child.Kind(); op == "&&" || op == "||" {
+				return op
+			}
+			return ""
+		}
+	}
+	return ""
+}
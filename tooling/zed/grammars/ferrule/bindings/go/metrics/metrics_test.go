@@ -0,0 +1,72 @@
+package metrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/metrics"
+)
+
+const source = `function flat(n: i32) -> i32 {
+  return n;
+}
+
+function nested(n: i32) -> i32 {
+  // comments and blank lines are not code
+
+  for i in items {
+    if i > n && i < 10 && ok(i) {
+      return i;
+    } else if i == 0 || done() {
+      break;
+    } else {
+      log(i);
+    }
+  }
+  match n {
+    0 -> { return 0; }
+    x if x > 100 -> { return check big(x); }
+    _ -> { return 1; }
+  }
+}
+
+component Cache {
+  function get(key: i32) -> i32 {
+    while key > 0 {
+      const f = function(x: i32) -> i32 {
+        if x > 0 { return x; }
+        return 0;
+      };
+      return f(key);
+    }
+    return 0;
+  }
+}
+`
+
+func TestFile(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if tree.HasError() {
+		t.Fatalf("syntax errors: %v", tree.Diagnostics())
+	}
+
+	want := []metrics.Function{
+		{Name: "flat", Line: 1, Metrics: metrics.Metrics{Cyclomatic: 1, Cognitive: 0, Nesting: 0, Lines: 3, CodeLines: 3}},
+		{Name: "nested", Line: 5, Metrics: metrics.Metrics{Cyclomatic: 11, Cognitive: 8, Nesting: 2, Lines: 18, CodeLines: 16}},
+		{Name: "Cache.get", Line: 25, Metrics: metrics.Metrics{Cyclomatic: 3, Cognitive: 4, Nesting: 3, Lines: 10, CodeLines: 10}},
+	}
+	got := metrics.File(tree)
+	if len(got) != len(want) {
+		t.Fatalf("got %d functions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %+v, want %+v", got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Package ontype computes the edits an editor makes as a character is
+// typed: it indents the line the cursor is on to its depth in the tree, see
+// package indent, and, when a line break is typed after an opening bracket
+// the parser found no closing bracket for, closes it on a line of its own
+// below the cursor.
+//
+// Ferrule has no end keywords; its blocks, bodies and lists all end with a
+// bracket, so the closing brackets are the only endings inserted.
+package ontype
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/indent"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Triggers are the characters Edits has edits for.
+var Triggers = []string{"\n", "}", ")", "]"}
+
+// closers are the closing brackets, by opening bracket.
+var closers = map[string]string{"{": "}", "(": ")", "[": "]"}
+
+// Edits returns the edits to src, the source of tree, after typed was
+// typed ending at p: a line break, so that p is at the start of a new line,
+// or a closing bracket. The edits are ordered and do not overlap.
+func Edits(tree *ferrule.Tree, src []byte, p tree_sitter.Point, typed string) []format.Edit {
+	var out []format.Edit
+	if e, ok := reindent(tree, src, p.Row); ok {
+		out = append(out, e)
+	}
+	if typed == "\n" && p.Row > 0 {
+		if e, ok := closeBracket(tree, src, p.Row); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// reindent returns the edit indenting the line row to its depth, if its
+// indentation differs.
+func reindent(tree *ferrule.Tree, src []byte, row uint) (format.Edit, bool) {
+	depth, ok := indent.Line(tree, row)
+	if !ok {
+		return format.Edit{}, false
+	}
+	start := edits.LineStart(src, row)
+	end := start
+	for end < uint(len(src)) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	want := strings.Repeat(indent.Unit, depth)
+	if string(src[start:end]) == want {
+		return format.Edit{}, false
+	}
+	return edit(src, start, end, want), true
+}
+
+// closeBracket returns the edit closing the bracket that ends the line
+// before row on a line after row, if the parser found it unclosed and
+// nothing follows the cursor on row.
+func closeBracket(tree *ferrule.Tree, src []byte, row uint) (format.Edit, bool) {
+	open := lastToken(tree, src, row-1)
+	if open == nil || closers[open.Kind()] == "" || !unclosed(open) {
+		return format.Edit{}, false
+	}
+	end := lineEnd(src, edits.LineStart(src, row))
+	if strings.TrimSpace(string(src[edits.LineStart(src, row):end])) != "" {
+		return format.Edit{}, false
+	}
+	depth, ok := indent.Line(tree, open.StartPosition().Row)
+	if !ok {
+		return format.Edit{}, false
+	}
+	eol := "\n"
+	if prev := edits.LineStart(src, row); prev >= 2 && src[prev-2] == '\r' {
+		eol = "\r\n"
+	}
+	return edit(src, end, end, eol+strings.Repeat(indent.Unit, depth)+closers[open.Kind()]), true
+}
+
+// lastToken returns the last token on the line row other than comments, or
+// nil for a line without one.
+func lastToken(tree *ferrule.Tree, src []byte, row uint) *tree_sitter.Node {
+	start := edits.LineStart(src, row)
+	end := lineEnd(src, start)
+	for end > start && strings.ContainsRune(" \t\r", rune(src[end-1])) {
+		end--
+	}
+	if end == start {
+		return nil
+	}
+	n := tree.RootNode().DescendantForByteRange(end-1, end-1)
+	for n != nil && (n.Kind() == kind.LineComment || n.Kind() == kind.BlockComment || n.IsMissing()) {
+		n = prevLeaf(n)
+	}
+	if n == nil || n.StartPosition().Row != row {
+		return nil
+	}
+	return n
+}
+
+func prevLeaf(n *tree_sitter.Node) *tree_sitter.Node {
+	for ; n != nil; n = n.Parent() {
+		if s := n.PrevSibling(); s != nil {
+			for s.ChildCount() > 0 {
+				s = s.Child(s.ChildCount() - 1)
+			}
+			return s
+		}
+	}
+	return nil
+}
+
+// unclosed reports whether the parser found no closing bracket for open:
+// the one matching it among the nodes after it is a bracket it inserted,
+// or, inside an ERROR node, there is none. The children are gone through
+// by index, as NextSibling skips the zero-width brackets the parser
+// inserted.
+func unclosed(open *tree_sitter.Node) bool {
+	parent := open.Parent()
+	if parent == nil {
+		return false
+	}
+	i := uint(0)
+	for i < parent.ChildCount() && parent.Child(i).Id() != open.Id() {
+		i++
+	}
+	nested := 0
+	for i++; i < parent.ChildCount(); i++ {
+		s := parent.Child(i)
+		switch s.Kind() {
+		case open.Kind():
+			nested++
+		case closers[open.Kind()]:
+			if nested == 0 {
+				return s.IsMissing()
+			}
+			nested--
+		}
+	}
+	return parent.IsError()
+}
+
+func edit(src []byte, start, end uint, text string) format.Edit {
+	return format.Edit{
+		Start:      start,
+		End:        end,
+		StartPoint: edits.Point(src, start),
+		EndPoint:   edits.Point(src, end),
+		Text:       text,
+	}
+}
+
+// lineEnd returns the offset of the line break ending the line starting at
+// off, before any \r, or the end of src.
+func lineEnd(src []byte, off uint) uint {
+	for off < uint(len(src)) && src[off] != '\n' {
+		off++
+	}
+	if off > 0 && off < uint(len(src)) && src[off-1] == '\r' {
+		off--
+	}
+	return off
+}
@@ -0,0 +1,49 @@
+package ontype_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/ontype"
+)
+
+// The sources mark the cursor with |, just after the typed character.
+func TestEdits(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		typed string
+		want  string
+	}{
+		{"open block", "function main() -> Unit {\n|\n", "\n", "function main() -> Unit {\n  \n}\n"},
+		{"nested", "function main() -> Unit {\n  if true {\n|", "\n", "function main() -> Unit {\n  if true {\n    \n  }"},
+		{"open list", "const a = f(\n|", "\n", "const a = f(\n  \n)"},
+		{"closed", "function main() -> Unit {\n|}\n", "\n", "function main() -> Unit {\n}\n"},
+		{"comment", "function main() -> Unit { // entry\n|\n", "\n", "function main() -> Unit { // entry\n  \n}\n"},
+		{"text after cursor", "const a = f(\n|b\n", "\n", "const a = f(\n  b\n"},
+		{"closing bracket", "function main() -> Unit {\n  const a = 1;\n  }|\n", "}", "function main() -> Unit {\n  const a = 1;\n}\n"},
+		{"crlf", "function main() -> Unit {\r\n|\r\n", "\n", "function main() -> Unit {\r\n  \r\n}\r\n"},
+	}
+	for _, tt := range tests {
+		at := strings.Index(tt.src, "|")
+		src := []byte(tt.src[:at] + tt.src[at+1:])
+		p := tree_sitter.Point{Row: uint(strings.Count(tt.src[:at], "\n")), Column: uint(at - strings.LastIndex(tt.src[:at], "\n") - 1)}
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := src
+		es := ontype.Edits(tree, src, p, tt.typed)
+		for i := len(es) - 1; i >= 0; i-- {
+			got = es[i].Apply(got)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+		tree.Close()
+	}
+}
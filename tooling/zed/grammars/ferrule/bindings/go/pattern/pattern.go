@@ -0,0 +1,353 @@
+// Package pattern implements structural search over ferrule syntax trees
+// with patterns written as ferrule code.
+//
+// A pattern is a declaration, a statement or an expression in which
+// metavariables stand for parts of the code:
+//
+//	$x      matches any single node and binds it to x
+//	$_      matches any single node without binding it
+//	$$$xs   matches any sequence of sibling nodes, possibly empty
+//	$$$     like $$$xs, without binding
+//
+// A metavariable used more than once must match identical code at every
+// use, so $x + $x matches a + a but not a + b. Metavariables whose name
+// starts with an upper-case letter may stand where the grammar expects a
+// type name, as in const $x: $T = $v.
+//
+// Matching compares the syntax trees, so it is not affected by layout or
+// comments: match $x { $$$ } finds every match expression however it is
+// formatted. A statement consisting of a metavariable alone, as in
+// { $$$body }, needs no semicolon, and a metavariable may stand for match
+// arms.
+package pattern
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Pattern is a compiled pattern.
+type Pattern struct {
+	source string
+	tree   *ferrule.Tree
+	root   *tree_sitter.Node
+	metas  map[string]meta
+	names  []string
+}
+
+// meta is a metavariable of a pattern, keyed by its placeholder.
+type meta struct {
+	name     string
+	variadic bool
+}
+
+// Binding is the code bound to a metavariable by a match.
+type Binding struct {
+	// Nodes are the named nodes matched, in order. A metavariable that is
+	// not variadic matches exactly one.
+	Nodes []*tree_sitter.Node
+	// StartByte and EndByte delimit the matched code, separators between
+	// the nodes included. They are equal for an empty sequence.
+	StartByte, EndByte uint
+}
+
+// Text returns the matched code.
+func (b Binding) Text(src []byte) string { return string(src[b.StartByte:b.EndByte]) }
+
+// Match is an occurrence of a pattern.
+type Match struct {
+	Node *tree_sitter.Node
+	// Bindings holds the code bound to each named metavariable.
+	Bindings map[string]Binding
+}
+
+var metavariable = regexp.MustCompile(`\$\$\$([A-Za-z_][A-Za-z0-9_]*)?|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Compile parses a pattern.
+func Compile(source string) (*Pattern, error) {
+	p := &Pattern{source: source, metas: make(map[string]meta)}
+	seen := make(map[string]bool)
+	text := metavariable.ReplaceAllStringFunc(source, func(s string) string {
+		m := meta{name: strings.TrimLeft(s, "$"), variadic: strings.HasPrefix(s, "$$$")}
+		if m.name == "_" {
+			m.name = ""
+		}
+		if m.name != "" && !seen[m.name] {
+			seen[m.name] = true
+			p.names = append(p.names, m.name)
+		}
+		placeholder := fmt.Sprintf("__mv%d", len(p.metas))
+		if m.name != "" && m.name[0] >= 'A' && m.name[0] <= 'Z' {
+			placeholder = "M" + placeholder
+		}
+		p.metas[placeholder] = m
+		return placeholder
+	})
+
+	// Try the pattern as a declaration first, then as a statement and as
+	// an expression in the body of a function. The errors around
+	// metavariables that hasErrors tolerates are only accepted once the
+	// strict attempts have failed.
+	const fn = "function __pattern() -> Unit {\n"
+	for _, try := range []struct {
+		prefix, suffix string
+		lenient        bool
+	}{
+		{"", "", false},
+		{fn, "\n}\n", false},
+		{fn, ";\n}\n", true},
+		{fn, "\n}\n", true},
+		{"", "", true},
+	} {
+		tree, err := ferrule.Parse(context.Background(), []byte(try.prefix+text+try.suffix))
+		if err != nil {
+			return nil, err
+		}
+		nodes := named(tree.RootNode())
+		if try.prefix != "" && len(nodes) == 1 && nodes[0].Kind() == kind.FunctionDeclaration {
+			nodes = named(nodes[0].ChildByFieldName(field.Body))
+		}
+		p.tree = tree
+		if len(nodes) != 1 || !try.lenient && tree.HasError() || p.hasErrors(tree.RootNode()) {
+			tree.Close()
+			continue
+		}
+		p.root = nodes[0]
+		if s := p.root; s.Kind() == kind.ExpressionStatement && p.meta(s) == nil && try.lenient {
+			p.root = named(s)[0]
+		}
+		if p.meta(p.root) != nil {
+			p.Close()
+			return nil, fmt.Errorf("pattern: %q is a lone metavariable", source)
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("pattern: cannot parse %q", source)
+}
+
+// hasErrors reports whether the tree below n has errors other than
+// missing semicolons and error nodes around a lone metavariable. These
+// are tolerated so that metavariables can stand for statements and for
+// match arms.
+func (p *Pattern) hasErrors(n *tree_sitter.Node) bool {
+	if n.IsError() && p.meta(n) == nil || n.IsMissing() && n.Kind() != ";" {
+		return true
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if p.hasErrors(n.Child(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the source of the pattern.
+func (p *Pattern) String() string { return p.source }
+
+// Metavariables returns the names of the named metavariables of the
+// pattern, in order of first appearance.
+func (p *Pattern) Metavariables() []string { return p.names }
+
+// Close releases the resources held by the pattern.
+func (p *Pattern) Close() { p.tree.Close() }
+
+// Find returns the matches of the pattern in tree, ordered by position.
+// Matches may nest.
+func (p *Pattern) Find(tree *ferrule.Tree) []Match {
+	src := tree.Source()
+	var out []Match
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		if sameKind(p.root.Kind(), n.Kind()) {
+			m := &matcher{p: p, src: src, bindings: make(map[string]Binding)}
+			if m.node(p.root, n) {
+				out = append(out, Match{Node: n, Bindings: m.bindings})
+			}
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(tree.RootNode())
+	return out
+}
+
+// meta returns the metavariable n stands for, or nil. A node whose only
+// child stands for a metavariable stands for it too, such as a statement
+// made of a metavariable whose semicolon is missing.
+func (p *Pattern) meta(n *tree_sitter.Node) *meta {
+	for cs := significant(n); len(cs) == 1; cs = significant(n) {
+		n = cs[0]
+	}
+	if n.ChildCount() != 0 || n.Kind() != kind.Identifier && n.Kind() != kind.TypeIdentifier {
+		return nil
+	}
+	m, ok := p.metas[n.Utf8Text(p.tree.Source())]
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// equivalent lists the kinds that the grammar distinguishes by context
+// only, and that patterns therefore treat as the same.
+var equivalent = map[string]string{
+	kind.MatchStatement: kind.MatchExpression,
+	kind.IfStatement:    kind.IfExpression,
+}
+
+func sameKind(a, b string) bool {
+	if e, ok := equivalent[a]; ok {
+		a = e
+	}
+	if e, ok := equivalent[b]; ok {
+		b = e
+	}
+	return a == b
+}
+
+// significant returns the children of n that take part in matching: all
+// but comments and the zero-width nodes inserted by error recovery.
+func significant(n *tree_sitter.Node) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if (!c.IsExtra() || c.IsError()) && !c.IsMissing() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// named returns the named children of n other than comments.
+func named(n *tree_sitter.Node) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		if c := n.NamedChild(i); !c.IsExtra() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+type matcher struct {
+	p        *Pattern
+	src      []byte
+	bindings map[string]Binding
+}
+
+func (m *matcher) node(p, t *tree_sitter.Node) bool {
+	if mv := m.p.meta(p); mv != nil {
+		if !t.IsNamed() {
+			return false
+		}
+		return m.bind(mv, []*tree_sitter.Node{t}, t.StartByte())
+	}
+	if !sameKind(p.Kind(), t.Kind()) || t.IsError() {
+		return false
+	}
+	pc, tc := significant(p), significant(t)
+	if len(pc) == 0 && len(tc) == 0 {
+		return p.Utf8Text(m.p.tree.Source()) == t.Utf8Text(m.src)
+	}
+	return m.list(pc, tc, t.EndByte())
+}
+
+// list matches a sequence of pattern siblings against target siblings.
+// end is the position of an empty tail of the target.
+func (m *matcher) list(pc, tc []*tree_sitter.Node, end uint) bool {
+	if len(pc) == 0 {
+		return len(tc) == 0
+	}
+	if mv := m.p.meta(pc[0]); mv != nil && mv.variadic {
+		at := end
+		if len(tc) > 0 {
+			at = tc[0].StartByte()
+		}
+		for k := 0; k <= len(tc); k++ {
+			saved := m.save()
+			if m.bind(mv, tc[:k], at) && m.list(pc[1:], tc[k:], end) {
+				return true
+			}
+			m.bindings = saved
+		}
+		return false
+	}
+	if len(tc) == 0 {
+		return false
+	}
+	saved := m.save()
+	if m.node(pc[0], tc[0]) && m.list(pc[1:], tc[1:], end) {
+		return true
+	}
+	m.bindings = saved
+	return false
+}
+
+func (m *matcher) save() map[string]Binding {
+	saved := make(map[string]Binding, len(m.bindings))
+	for k, v := range m.bindings {
+		saved[k] = v
+	}
+	return saved
+}
+
+// bind binds the metavariable to the sibling nodes ns, starting at start,
+// or checks that ns is identical to the code it is already bound to.
+func (m *matcher) bind(mv *meta, ns []*tree_sitter.Node, start uint) bool {
+	b := Binding{StartByte: start, EndByte: start}
+	if len(ns) > 0 {
+		b.EndByte = ns[len(ns)-1].EndByte()
+	}
+	for _, n := range ns {
+		if n.IsNamed() && !n.IsExtra() {
+			b.Nodes = append(b.Nodes, n)
+		}
+	}
+	if mv.name == "" {
+		return true
+	}
+	prev, ok := m.bindings[mv.name]
+	if !ok {
+		m.bindings[mv.name] = b
+		return true
+	}
+	if len(prev.Nodes) != len(b.Nodes) {
+		return false
+	}
+	for i := range prev.Nodes {
+		if !identical(prev.Nodes[i], b.Nodes[i], m.src) {
+			return false
+		}
+	}
+	return true
+}
+
+// identical reports whether a and b are the same code, ignoring layout and
+// comments.
+func identical(a, b *tree_sitter.Node, src []byte) bool {
+	if a.Kind() != b.Kind() {
+		return false
+	}
+	ac, bc := significant(a), significant(b)
+	if len(ac) != len(bc) {
+		return false
+	}
+	if len(ac) == 0 {
+		return a.Utf8Text(src) == b.Utf8Text(src)
+	}
+	for i := range ac {
+		if !identical(ac[i], bc[i], src) {
+			return false
+		}
+	}
+	return true
+}
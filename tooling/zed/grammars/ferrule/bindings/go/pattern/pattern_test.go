@@ -0,0 +1,100 @@
+package pattern_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/pattern"
+)
+
+const source = `package app;
+
+function main(a: i32, b: i32) -> i32 {
+  const x = add(a, a);
+  const y = add(a, /* second */ b);
+  log();
+  const z = match x {
+    1 -> y
+    _ -> 0
+  };
+  if x == y {
+    log();
+    return 1;
+  }
+  return add(add(1, 2), 3);
+}
+`
+
+// find returns the matches of the pattern as "text {bindings}" strings.
+func find(t *testing.T, pat string) []string {
+	t.Helper()
+	p, err := pattern.Compile(pat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	var out []string
+	for _, m := range p.Find(tree) {
+		var bs []string
+		for _, name := range p.Metavariables() {
+			bs = append(bs, fmt.Sprintf("%s=%q", name, m.Bindings[name].Text(tree.Source())))
+		}
+		text := strings.Join(strings.Fields(m.Node.Utf8Text(tree.Source())), " ")
+		out = append(out, text+" {"+strings.Join(bs, " ")+"}")
+	}
+	return out
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"add($x, $y)", []string{
+			`add(a, a) {x="a" y="a"}`,
+			`add(a, /* second */ b) {x="a" y="b"}`,
+			`add(add(1, 2), 3) {x="add(1, 2)" y="3"}`,
+			`add(1, 2) {x="1" y="2"}`,
+		}},
+		{"add($x, $x)", []string{`add(a, a) {x="a"}`}},
+		{"add($$$args)", []string{
+			`add(a, a) {args="a, a"}`,
+			`add(a, /* second */ b) {args="a, /* second */ b"}`,
+			`add(add(1, 2), 3) {args="add(1, 2), 3"}`,
+			`add(1, 2) {args="1, 2"}`,
+		}},
+		{"log($$$)", []string{"log() {}", "log() {}"}},
+		{"match $v { $$$arms }", []string{`match x { 1 -> y _ -> 0 } {v="x" arms="1 -> y\n    _ -> 0"}`}},
+		{"if $c { $$$body }", []string{`if x == y { log(); return 1; } {c="x == y" body="log();\n    return 1;"}`}},
+		{"return $_;", []string{"return 1; {}", "return add(add(1, 2), 3); {}"}},
+		{"const $n = add($$$);", []string{`const x = add(a, a); {n="x"}`, `const y = add(a, /* second */ b); {n="y"}`}},
+		{"function $f($$$ps) -> $T { $$$ }", []string{
+			`function main(a: i32, b: i32) -> i32 { const x = add(a, a); const y = add(a, /* second */ b); log(); const z = match x { 1 -> y _ -> 0 }; if x == y { log(); return 1; } return add(add(1, 2), 3); } {f="main" ps="a: i32, b: i32" T="i32"}`,
+		}},
+		{"sub($x, $y)", nil},
+	}
+	for _, tt := range tests {
+		if got := find(t, tt.pattern); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pat := range []string{"add(", "$x", "$$$body"} {
+		if p, err := pattern.Compile(pat); err == nil {
+			p.Close()
+			t.Errorf("%q compiled", pat)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// Package position converts between the ways positions in a source buffer
+// are counted: byte offsets, as tree-sitter and Go strings count them; rune
+// offsets; UTF-16 code unit offsets, as the Language Server Protocol and
+// JavaScript count them; tree-sitter points, a row and a byte column; and
+// LSP positions, a line and a UTF-16 character.
+//
+// An Index is built once per buffer, in one pass, and answers each
+// conversion by a binary search over its lines followed by a scan of the
+// line found, which is skipped for lines of ASCII text.
+//
+// LSP positions end lines at \n, \r\n or a lone \r, as the protocol says
+// and package edits, which converts them with an Index, relies on; a
+// character past the end of its line resolves to the end of the line, and
+// a line past the end of the buffer to the end of the buffer. Tree-sitter
+// points end rows at \n only.
+package position
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// Position is a zero-based line and UTF-16 character offset. It converts
+// to edits.Position.
+type Position struct {
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// line is a line of the buffer.
+type line struct {
+	start uint // offset of the first byte
+	end   uint // offset of the line break, or of the end of the buffer
+	// runes and units count the runes and UTF-16 code units before start.
+	runes, units uint
+	ascii        bool
+}
+
+// Index is a line index of a source buffer. It must not be used after the
+// buffer is modified.
+type Index struct {
+	src   []byte
+	lines []line
+	// rows are the offsets of the tree-sitter rows, when they differ from
+	// the lines because of lone carriage returns.
+	rows []uint
+	// runes and units are the totals of the buffer.
+	runes, units uint
+}
+
+// New returns the index of src.
+func New(src []byte) *Index {
+	x := &Index{src: src}
+	cur := line{ascii: true}
+	lone := false
+	for i := 0; i < len(src); {
+		c := src[i]
+		if c == '\n' || c == '\r' {
+			cur.end = uint(i)
+			i++
+			if c == '\r' {
+				if i < len(src) && src[i] == '\n' {
+					i++
+				} else {
+					lone = true
+				}
+			}
+			x.lines = append(x.lines, cur)
+			brk := uint(i) - cur.end
+			x.runes += brk
+			x.units += brk
+			cur = line{start: uint(i), runes: x.runes, units: x.units, ascii: true}
+			continue
+		}
+		if c < utf8.RuneSelf {
+			x.runes++
+			x.units++
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(src[i:])
+		cur.ascii = false
+		x.runes++
+		x.units += utf16Len(r)
+		i += size
+	}
+	cur.end = uint(len(src))
+	x.lines = append(x.lines, cur)
+	if lone {
+		x.rows = []uint{0}
+		for i, c := range src {
+			if c == '\n' {
+				x.rows = append(x.rows, uint(i+1))
+			}
+		}
+	}
+	return x
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r.
+func utf16Len(r rune) uint {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Source returns the indexed buffer.
+func (x *Index) Source() []byte { return x.src }
+
+// Lines returns the number of lines, counting the one after a final line
+// break.
+func (x *Index) Lines() int { return len(x.lines) }
+
+// LineStart returns the byte offset of the start of line i, or the end of
+// the buffer if there is no such line.
+func (x *Index) LineStart(i int) uint {
+	if i < 0 || i >= len(x.lines) {
+		return uint(len(x.src))
+	}
+	return x.lines[i].start
+}
+
+// lineOf returns the index of the line holding the byte at off.
+func (x *Index) lineOf(off uint) int {
+	return sort.Search(len(x.lines), func(i int) bool { return x.lines[i].start > off }) - 1
+}
+
+// clamp limits off to the buffer and moves it back to the start of the
+// character it falls in.
+func (x *Index) clamp(off uint) uint {
+	if off >= uint(len(x.src)) {
+		return uint(len(x.src))
+	}
+	for off > 0 && !utf8.RuneStart(x.src[off]) {
+		off--
+	}
+	return off
+}
+
+// count returns the runes and UTF-16 code units in src[start:end].
+func (x *Index) count(start, end uint) (runes, units uint) {
+	for i := start; i < end; {
+		r, size := utf8.DecodeRune(x.src[i:end])
+		runes++
+		units += utf16Len(r)
+		i += uint(size)
+	}
+	return runes, units
+}
+
+// Rune returns the number of runes before the byte offset off. An offset
+// inside a character counts as its start.
+func (x *Index) Rune(off uint) uint {
+	off = x.clamp(off)
+	if off == uint(len(x.src)) {
+		return x.runes
+	}
+	l := x.lines[x.lineOf(off)]
+	if l.ascii {
+		return l.runes + off - l.start
+	}
+	runes, _ := x.count(l.start, off)
+	return l.runes + runes
+}
+
+// UTF16 returns the number of UTF-16 code units before the byte offset
+// off. An offset inside a character counts as its start.
+func (x *Index) UTF16(off uint) uint {
+	off = x.clamp(off)
+	if off == uint(len(x.src)) {
+		return x.units
+	}
+	l := x.lines[x.lineOf(off)]
+	if l.ascii {
+		return l.units + off - l.start
+	}
+	_, units := x.count(l.start, off)
+	return l.units + units
+}
+
+// RuneOffset returns the byte offset of the rune at rune offset r, or the
+// end of the buffer if r is past it.
+func (x *Index) RuneOffset(r uint) uint {
+	if r >= x.runes {
+		return uint(len(x.src))
+	}
+	l := x.lines[sort.Search(len(x.lines), func(i int) bool { return x.lines[i].runes > r })-1]
+	if l.ascii && l.start+r-l.runes <= l.end {
+		return l.start + r - l.runes
+	}
+	off, n := l.start, l.runes
+	for n < r {
+		_, size := utf8.DecodeRune(x.src[off:])
+		off += uint(size)
+		n++
+	}
+	return off
+}
+
+// UTF16Offset returns the byte offset of the character at UTF-16 offset u,
+// or the end of the buffer if u is past it. An offset inside a surrogate
+// pair resolves to the end of its character.
+func (x *Index) UTF16Offset(u uint) uint {
+	if u >= x.units {
+		return uint(len(x.src))
+	}
+	l := x.lines[sort.Search(len(x.lines), func(i int) bool { return x.lines[i].units > u })-1]
+	if l.ascii && l.start+u-l.units <= l.end {
+		return l.start + u - l.units
+	}
+	off, n := l.start, l.units
+	for n < u {
+		r, size := utf8.DecodeRune(x.src[off:])
+		off += uint(size)
+		n += utf16Len(r)
+	}
+	return off
+}
+
+// Point returns the tree-sitter point of the byte offset off.
+func (x *Index) Point(off uint) tree_sitter.Point {
+	off = min(off, uint(len(x.src)))
+	if x.rows != nil {
+		row := sort.Search(len(x.rows), func(i int) bool { return x.rows[i] > off }) - 1
+		return tree_sitter.Point{Row: uint(row), Column: off - x.rows[row]}
+	}
+	i := x.lineOf(off)
+	return tree_sitter.Point{Row: uint(i), Column: off - x.lines[i].start}
+}
+
+// PointOffset returns the byte offset of the tree-sitter point p. A column
+// past the end of its row resolves to the end of the row.
+func (x *Index) PointOffset(p tree_sitter.Point) uint {
+	starts := len(x.lines)
+	start := func(i int) uint { return x.lines[i].start }
+	if x.rows != nil {
+		starts = len(x.rows)
+		start = func(i int) uint { return x.rows[i] }
+	}
+	if p.Row >= uint(starts) {
+		return uint(len(x.src))
+	}
+	end := uint(len(x.src))
+	if int(p.Row)+1 < starts {
+		end = start(int(p.Row)+1) - 1
+	}
+	return min(start(int(p.Row))+p.Column, end)
+}
+
+// Position returns the LSP position of the byte offset off. An offset
+// inside a character resolves to its start, and one inside a line break
+// to the end of the line.
+func (x *Index) Position(off uint) Position {
+	off = x.clamp(off)
+	i := x.lineOf(off)
+	l := x.lines[i]
+	off = min(off, l.end)
+	char := off - l.start
+	if !l.ascii {
+		_, char = x.count(l.start, off)
+	}
+	return Position{Line: uint32(i), Character: uint32(char)}
+}
+
+// Offset returns the byte offset of the LSP position pos.
+func (x *Index) Offset(pos Position) uint {
+	if int(pos.Line) >= len(x.lines) {
+		return uint(len(x.src))
+	}
+	l := x.lines[pos.Line]
+	if l.ascii {
+		return min(l.start+uint(pos.Character), l.end)
+	}
+	off, n := l.start, uint(0)
+	for n < uint(pos.Character) && off < l.end {
+		r, size := utf8.DecodeRune(x.src[off:l.end])
+		off += uint(size)
+		n += utf16Len(r)
+	}
+	return off
+}
@@ -0,0 +1,124 @@
+package position_test
+
+import (
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/position"
+)
+
+var sources = []string{
+	"",
+	"const a = 1;\n",
+	"const a = \"😀x\";\r\nconst b = 2;\n",
+	"// é\rconst ü = \"日本\";\n\n\tx\r\n",
+	"tail without line break: ñ",
+}
+
+// positionOf is the position of the byte at off in s, counted the slow
+// way: off moved back to the start of its character and out of a \r\n,
+// then the line breaks before it and the UTF-16 units on its line.
+func positionOf(s string, off int) edits.Position {
+	for off > 0 && off < len(s) && !utf8.RuneStart(s[off]) {
+		off--
+	}
+	line, start := 0, 0
+	for i := 0; i < off; i++ {
+		if s[i] == '\n' || s[i] == '\r' && (i+1 == len(s) || s[i+1] != '\n') {
+			line, start = line+1, i+1
+		}
+	}
+	text := s[start:off]
+	if len(text) > 0 && text[len(text)-1] == '\r' {
+		text = text[:len(text)-1]
+	}
+	return edits.Position{Line: uint32(line), Character: uint32(len(utf16.Encode([]rune(text))))}
+}
+
+// Every conversion agrees with a count from the start of the buffer, and
+// points with those of package edits.
+func TestPositions(t *testing.T) {
+	for _, s := range sources {
+		src := []byte(s)
+		x := position.New(src)
+		for off := uint(0); off <= uint(len(src)); off++ {
+			want := positionOf(s, int(off))
+			got := x.Position(off)
+			if edits.Position(got) != want {
+				t.Errorf("%q: Position(%d) = %+v, want %+v", s, off, got, want)
+			}
+			if back := x.Offset(got); off < uint(len(src)) && utf8.RuneStart(src[off]) && !(src[off] == '\n' && off > 0 && src[off-1] == '\r') && back != off {
+				t.Errorf("%q: Offset(%+v) = %d, want %d", s, got, back, off)
+			}
+			if p, want := x.Point(off), edits.Point(src, off); p != want {
+				t.Errorf("%q: Point(%d) = %+v, want %+v", s, off, p, want)
+			} else if back := x.PointOffset(p); back != off {
+				t.Errorf("%q: PointOffset(%+v) = %d, want %d", s, p, back, off)
+			}
+		}
+		for line := uint32(0); line < 8; line++ {
+			for char := uint32(0); char < 24; char++ {
+				// past the end of its line, a position resolves to the end
+				// of the line, and inside a surrogate pair to its end.
+				pos := position.Position{Line: line, Character: char}
+				got := x.Position(x.Offset(pos))
+				if int(line) < x.Lines() && (got.Line != line || got.Character > char+1) {
+					t.Errorf("%q: Position(Offset(%+v)) = %+v", s, pos, got)
+				}
+			}
+		}
+	}
+}
+
+func TestRunesAndUTF16(t *testing.T) {
+	for _, s := range sources {
+		src := []byte(s)
+		x := position.New(src)
+		for off := 0; off <= len(src); off++ {
+			if off < len(src) && !utf8.RuneStart(src[off]) {
+				continue
+			}
+			prefix := []rune(s[:off])
+			runes, units := uint(len(prefix)), uint(len(utf16.Encode(prefix)))
+			if got := x.Rune(uint(off)); got != runes {
+				t.Errorf("%q: Rune(%d) = %d, want %d", s, off, got, runes)
+			}
+			if got := x.UTF16(uint(off)); got != units {
+				t.Errorf("%q: UTF16(%d) = %d, want %d", s, off, got, units)
+			}
+			if got := x.RuneOffset(runes); got != uint(off) {
+				t.Errorf("%q: RuneOffset(%d) = %d, want %d", s, runes, got, off)
+			}
+			if got := x.UTF16Offset(units); got != uint(off) {
+				t.Errorf("%q: UTF16Offset(%d) = %d, want %d", s, units, got, off)
+			}
+		}
+	}
+
+	x := position.New([]byte("a😀b"))
+	// inside a character, offsets resolve to its start; inside a surrogate
+	// pair, UTF-16 offsets resolve to its end.
+	if got := x.Rune(3); got != 1 {
+		t.Errorf("Rune(3) = %d, want 1", got)
+	}
+	if got := x.UTF16Offset(2); got != 5 {
+		t.Errorf("UTF16Offset(2) = %d, want 5", got)
+	}
+	if got := x.RuneOffset(99); got != 6 {
+		t.Errorf("RuneOffset(99) = %d, want 6", got)
+	}
+}
+
+func TestLines(t *testing.T) {
+	x := position.New([]byte("a\r\nb\rc\n"))
+	if got := x.Lines(); got != 4 {
+		t.Errorf("Lines() = %d, want 4", got)
+	}
+	for i, want := range []uint{0, 3, 5, 7, 7} {
+		if got := x.LineStart(i); got != want {
+			t.Errorf("LineStart(%d) = %d, want %d", i, got, want)
+		}
+	}
+}
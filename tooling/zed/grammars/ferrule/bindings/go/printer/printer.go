@@ -0,0 +1,195 @@
+// Package printer turns typed syntax trees back into ferrule source.
+//
+// The trees printed are made of ast.Synthetic nodes: copies of parsed code
+// made with ast.Modify, possibly changed since, and nodes built from
+// scratch. Copied code is reproduced exactly, comments and layout
+// included, so printing an unchanged copy returns the source it was made
+// from. Built code is laid out in the canonical style of package format:
+// declarations, statements and match arms on lines of their own, indented
+// by format.Indent, and single spaces between tokens where that style has
+// them. Code built into a copied tree is indented to match its
+// surroundings.
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// lined are the kinds of node whose named children after the opening brace
+// go on lines of their own.
+var lined = map[string]bool{
+	kind.Block:                true,
+	kind.ComponentDeclaration: true,
+	kind.MatchStatement:       true,
+	kind.MatchExpression:      true,
+	kind.DomainDeclaration:    true,
+}
+
+// grouped are the kinds of top-level declaration that are not separated
+// by blank lines when they follow one of the same kind.
+var grouped = map[string]bool{
+	kind.ImportDeclaration: true,
+	kind.UseDeclaration:    true,
+	kind.ConstDeclaration:  true,
+}
+
+// keywords are the tokens that take a space before an opening parenthesis
+// or bracket, unlike the names of called functions.
+var keywords = map[string]bool{
+	"return": true, "if": true, "while": true, "match": true, "in": true,
+	"check": true, "ok": true, "else": true, "defer": true, "=": true,
+}
+
+// Print returns the source text of n. It fails when the tree holds nodes
+// that are not synthetic, whose text is not known.
+func Print(n ast.Node) ([]byte, error) {
+	text, err := render(n, "")
+	if err != nil {
+		return nil, err
+	}
+	if s := n.(*ast.Synthetic); s.Kind() == kind.SourceFile && !s.Parsed() && text != "" {
+		text += "\n"
+	}
+	return []byte(text), nil
+}
+
+// piece is a printed child: its node and text.
+type piece struct {
+	node *ast.Synthetic
+	text string
+}
+
+// render returns the text of n, which starts on a line indented by indent.
+func render(n ast.Node, indent string) (string, error) {
+	s, ok := n.(*ast.Synthetic)
+	if !ok {
+		if n == nil {
+			return "", fmt.Errorf("printer: nil node")
+		}
+		return "", fmt.Errorf("printer: %s node is not synthetic; copy it with ast.Modify", n.Kind())
+	}
+	var b strings.Builder
+	for _, c := range s.Comments {
+		b.WriteString(c)
+		if strings.HasPrefix(c, "//") {
+			b.WriteString("\n" + indent)
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	parts := s.Parts()
+	if len(parts) == 0 {
+		b.WriteString(s.Value)
+		return b.String(), nil
+	}
+
+	if s.Parsed() {
+		for _, p := range parts {
+			gap := p.Gap()
+			b.WriteString(gap)
+			text, err := render(p.Node, lineIndent(indent, b.String()))
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+		}
+		b.WriteString(s.Tail())
+		return b.String(), nil
+	}
+
+	inner := indent + format.Indent
+	var prev *piece
+	braced := false // past the opening brace of a lined node
+	for i, p := range parts {
+		child, ok := p.Node.(*ast.Synthetic)
+		if !ok {
+			_, err := render(p.Node, indent)
+			return "", err
+		}
+		var sep, childIndent string
+		switch {
+		case i == 0:
+		case s.Kind() == kind.SourceFile:
+			sep = "\n"
+			if !grouped[child.Kind()] || prev.node.Kind() != child.Kind() {
+				sep = "\n\n"
+			}
+		case braced && child.Named():
+			sep, childIndent = "\n"+inner, inner
+		case braced && child.Kind() == "}" && prev.node.Kind() != "{":
+			sep = "\n" + indent
+		case space(s.Kind(), prev.node, prev.text, child):
+			sep = " "
+		}
+		if childIndent == "" {
+			childIndent = indent
+		}
+		text, err := render(child, childIndent)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(sep + text)
+		prev = &piece{child, text}
+		if lined[s.Kind()] && child.Kind() == "{" {
+			braced = true
+		}
+	}
+	return b.String(), nil
+}
+
+// space reports whether the built child next follows prev, printed as
+// text, after a space in a node of kind parent.
+func space(parent string, prev *ast.Synthetic, text string, next *ast.Synthetic) bool {
+	first := firstToken(next)
+	switch {
+	case text == "" || first == "":
+		return false
+	case text == "{" && first == "}":
+		// An empty block or record reads {} in the canonical style.
+		return false
+	case strings.ContainsAny(first[:1], ",;)]."), first == ":":
+		return false
+	case strings.HasSuffix(text, "(") || strings.HasSuffix(text, "[") || strings.HasSuffix(text, "."):
+		return false
+	case parent == kind.UnaryExpression && !prev.Named():
+		return false
+	case next.Kind() == kind.TypeParameters:
+		return false
+	case parent == kind.GenericType || parent == kind.TypeParameters:
+		return first != "<" && first != ">" && text != "<"
+	case first == "(" || first == "[":
+		switch parent {
+		case kind.CallExpression, kind.IndexExpression, kind.FunctionDeclaration, kind.AnonymousFunction:
+			return keywords[text]
+		}
+	}
+	return true
+}
+
+// firstToken returns the text of the first token of n.
+func firstToken(n *ast.Synthetic) string {
+	for len(n.Parts()) > 0 {
+		next, ok := n.Parts()[0].Node.(*ast.Synthetic)
+		if !ok {
+			return ""
+		}
+		n = next
+	}
+	return n.Value
+}
+
+// lineIndent returns the indentation of the last line of text, or indent
+// if text holds no line break.
+func lineIndent(indent, text string) string {
+	i := strings.LastIndexByte(text, '\n')
+	if i < 0 {
+		return indent
+	}
+	line := text[i+1:]
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
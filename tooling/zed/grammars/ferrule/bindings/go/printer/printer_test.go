@@ -0,0 +1,153 @@
+package printer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/printer"
+)
+
+const source = `
+package app; // the app
+
+// Add adds.
+function add(x: i32, y: i32) -> i32 {
+  const z = x +   y; /* odd spacing */
+  return z;
+}
+  // trailing
+`
+
+func modify(t *testing.T, src string) *ast.Synthetic {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	return ast.Modify(tree.Root(), tree.Source())
+}
+
+func printed(t *testing.T, n ast.Node) string {
+	t.Helper()
+	out, err := printer.Print(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestRoundTrip(t *testing.T) {
+	srcs := map[string]string{"source": source}
+	files, _ := filepath.Glob("../../../examples/*.fe")
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs[name] = string(data)
+	}
+	for name, src := range srcs {
+		if got := printed(t, modify(t, src)); got != src {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", name, got, src)
+		}
+	}
+}
+
+// find returns the first node of kind k below n.
+func find(n ast.Node, k string) *ast.Synthetic {
+	for d := range n.Descendants() {
+		if d.Kind() == k {
+			return d.(*ast.Synthetic)
+		}
+	}
+	return nil
+}
+
+func TestModified(t *testing.T) {
+	file := modify(t, source)
+	fn := find(file, kind.FunctionDeclaration)
+	fn.SetField("name", ast.NewLeaf(kind.Identifier, "sum"))
+	body := fn.FieldNode("body").(*ast.Synthetic)
+	call := ast.NewNode(kind.CallExpression,
+		ast.Child(ast.NewLeaf(kind.Identifier, "log")),
+		ast.Child(ast.NewToken("(")),
+		ast.Child(ast.NewLeaf(kind.Identifier, "z")),
+		ast.Child(ast.NewToken(")")),
+	)
+	stmt := ast.NewNode(kind.ExpressionStatement, ast.Child(call), ast.Child(ast.NewToken(";")))
+	stmt.Comments = []string{"// trace"}
+	body.Insert(2, ast.Child(stmt))
+
+	want := `
+package app; // the app
+
+// Add adds.
+function sum(x: i32, y: i32) -> i32 {
+  const z = x +   y; /* odd spacing */
+  // trace
+  log(z);
+  return z;
+}
+  // trailing
+`
+	if got := printed(t, file); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuilt(t *testing.T) {
+	id := func(s string) ast.Node { return ast.NewLeaf(kind.Identifier, s) }
+	tok := func(s string) ast.Node { return ast.NewToken(s) }
+	param := func(name, typ string) ast.Part {
+		return ast.Child(ast.NewNode(kind.Parameter,
+			ast.Field("name", id(name)), ast.Child(tok(":")), ast.Field("type", ast.NewLeaf(kind.PrimitiveType, typ))))
+	}
+	ret := ast.NewNode(kind.ReturnStatement,
+		ast.Child(tok("return")),
+		ast.Child(ast.NewNode(kind.BinaryExpression, ast.Child(id("x")), ast.Child(tok("*")), ast.Child(id("y")))),
+		ast.Child(tok(";")))
+	fn := ast.NewNode(kind.FunctionDeclaration,
+		ast.Child(tok("pub")),
+		ast.Child(tok("function")),
+		ast.Field("name", id("mul")),
+		ast.Field("parameters", ast.NewNode(kind.ParameterList, ast.Child(tok("(")), param("x", "i32"), ast.Child(tok(",")), param("y", "i32"), ast.Child(tok(")")))),
+		ast.Child(tok("->")),
+		ast.Field("return_type", ast.NewLeaf(kind.PrimitiveType, "i32")),
+		ast.Field("body", ast.NewNode(kind.Block, ast.Child(tok("{")), ast.Child(ret), ast.Child(tok("}")))),
+	)
+	fn.Comments = []string{"// Mul multiplies."}
+	file := ast.NewNode(kind.SourceFile,
+		ast.Child(ast.NewNode(kind.PackageDeclaration, ast.Child(tok("package")), ast.Field("path", ast.NewNode(kind.PackagePath, ast.Child(id("app")))), ast.Child(tok(";")))),
+		ast.Child(fn),
+	)
+	want := "package app;\n\n// Mul multiplies.\npub function mul(x: i32, y: i32) -> i32 {\n  return x * y;\n}\n"
+	got := printed(t, file)
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	tree, err := ferrule.Parse(context.Background(), []byte(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if tree.HasError() {
+		t.Errorf("printed code does not parse: %v", tree.Diagnostics())
+	}
+}
+
+func TestNotSynthetic(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	if _, err := printer.Print(tree.Root()); err == nil {
+		t.Error("printing a parsed node succeeded")
+	}
+}
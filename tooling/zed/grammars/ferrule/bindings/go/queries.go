@@ -0,0 +1,28 @@
+package tree_sitter_ferrule
+
+import "github.com/karol-broda/ferrule/queries"
+
+// The query accessors below return the query files bundled with this
+// version of the grammar. The returned slices are shared and must not be
+// modified.
+
+// Get the syntax highlighting query.
+func HighlightsQuery() []byte { return queries.Highlights }
+
+// Get the local variable and scope query.
+func LocalsQuery() []byte { return queries.Locals }
+
+// Get the language injection query.
+func InjectionsQuery() []byte { return queries.Injections }
+
+// Get the code navigation tags query.
+func TagsQuery() []byte { return queries.Tags }
+
+// Get the code folding query.
+func FoldsQuery() []byte { return queries.Folds }
+
+// Get the indentation query.
+func IndentsQuery() []byte { return queries.Indents }
+
+// Get the text objects query.
+func TextObjectsQuery() []byte { return queries.TextObjects }
@@ -0,0 +1,75 @@
+package query
+
+import (
+	"fmt"
+	"sync"
+)
+
+// cache holds the queries compiled by Cached, keyed by query source.
+// Compilation errors are cached as well, so a broken query is not
+// recompiled on every call.
+var cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	hits    uint64
+	misses  uint64
+}
+
+type cacheEntry struct {
+	q   *Query
+	err error
+}
+
+// Cached returns the compiled form of source, compiling it on first use.
+// The returned query is shared by every caller asking for the same source
+// and lives for the rest of the program; its Close method does nothing.
+func Cached(source string) (*Query, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if e, ok := cache.entries[source]; ok {
+		cache.hits++
+		return e.q, e.err
+	}
+	cache.misses++
+	q, err := New(source)
+	if q != nil {
+		q.shared = true
+	}
+	if cache.entries == nil {
+		cache.entries = make(map[string]cacheEntry)
+	}
+	cache.entries[source] = cacheEntry{q, err}
+	return q, err
+}
+
+// MustCompile is like Cached but panics if the query does not compile. It
+// is meant for package-level variables holding fixed queries.
+func MustCompile(source string) *Query {
+	q, err := Cached(source)
+	if err != nil {
+		panic(fmt.Sprintf("query: compiling %q: %v", source, err))
+	}
+	return q
+}
+
+// CacheStats describes the use of the query cache.
+type CacheStats struct {
+	Hits, Misses uint64
+	// Entries is the number of distinct sources compiled so far.
+	Entries int
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Stats returns the current cache statistics.
+func Stats() CacheStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return CacheStats{Hits: cache.hits, Misses: cache.misses, Entries: len(cache.entries)}
+}
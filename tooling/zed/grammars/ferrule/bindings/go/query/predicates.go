@@ -0,0 +1,228 @@
+package query
+
+import (
+	"bytes"
+	"maps"
+	"regexp"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// A predicate is one #...? condition of a pattern, as understood by
+// Neovim and Zed:
+//
+//	#eq? @a @b / #eq? @a "text"   text equality
+//	#match? @a "regexp"           regular expression search
+//	#any-of? @a "x" "y" ...       text is one of the strings
+//	#contains? @a "x" ...         text contains one of the strings
+//	#has-parent? @a kind ...      parent node is of one of the kinds
+//	#has-ancestor? @a kind ...    some ancestor is of one of the kinds
+//
+// Each may be negated with a not- prefix. For captures that match several
+// nodes, a predicate must hold for every node, or for at least one with an
+// any- prefix. A predicate over a capture that matched nothing holds.
+// Other predicates are ignored, as editors do with ones they do not know.
+type predicate struct {
+	op      string
+	negate  bool
+	any     bool
+	capture uint
+	// other is the second capture of #eq? @a @b.
+	other  *uint
+	values []string
+	re     *regexp.Regexp
+}
+
+// takePredicates moves the text predicates out of q, so that the raw
+// cursor no longer filters on them, and collects them together with the
+// general predicates into one list per pattern.
+func takePredicates(q *tree_sitter.Query) [][]predicate {
+	out := make([][]predicate, q.PatternCount())
+	for i := range out {
+		for _, tp := range q.TextPredicates[i] {
+			p := predicate{negate: !tp.Positive, any: !tp.MatchAllNodes, capture: tp.CaptureId}
+			switch tp.Type {
+			case tree_sitter.TextPredicateTypeEqCapture:
+				other := tp.Value.(uint)
+				p.op, p.other = "eq", &other
+			case tree_sitter.TextPredicateTypeEqString:
+				p.op, p.values = "eq", []string{tp.Value.(string)}
+			case tree_sitter.TextPredicateTypeMatchString:
+				p.op, p.re = "match", tp.Value.(*regexp.Regexp)
+			case tree_sitter.TextPredicateTypeAnyString:
+				p.op, p.values = "any-of", tp.Value.([]string)
+			}
+			out[i] = append(out[i], p)
+		}
+		q.TextPredicates[i] = nil
+
+		for _, gp := range q.GeneralPredicates(uint(i)) {
+			p, ok := generalPredicate(gp)
+			if ok {
+				out[i] = append(out[i], p)
+			}
+		}
+	}
+	return out
+}
+
+func generalPredicate(gp tree_sitter.QueryPredicate) (predicate, bool) {
+	op, negate := strings.CutPrefix(strings.TrimSuffix(gp.Operator, "?"), "not-")
+	switch op {
+	case "contains", "has-parent", "has-ancestor":
+	default:
+		return predicate{}, false
+	}
+	if len(gp.Args) == 0 || gp.Args[0].CaptureId == nil {
+		return predicate{}, false
+	}
+	p := predicate{op: op, negate: negate, capture: *gp.Args[0].CaptureId}
+	for _, a := range gp.Args[1:] {
+		if a.String != nil {
+			p.values = append(p.values, *a.String)
+		}
+	}
+	return p, true
+}
+
+// satisfied reports whether the predicates hold for the captures of a match.
+func satisfied(preds []predicate, captures []tree_sitter.QueryCapture, src []byte) bool {
+	for i := range preds {
+		if !preds[i].holds(captures, src) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *predicate) holds(captures []tree_sitter.QueryCapture, src []byte) bool {
+	nodes := nodesFor(captures, p.capture)
+	if p.other != nil {
+		others := nodesFor(captures, *p.other)
+		n := min(len(nodes), len(others))
+		return p.combine(n, func(i int) bool {
+			return bytes.Equal(text(nodes[i], src), text(others[i], src))
+		})
+	}
+	return p.combine(len(nodes), func(i int) bool { return p.test(nodes[i], src) })
+}
+
+// combine applies the quantifier and negation of p to the results of test
+// for n nodes.
+func (p *predicate) combine(n int, test func(int) bool) bool {
+	if n == 0 {
+		return true
+	}
+	for i := 0; i < n; i++ {
+		ok := test(i) != p.negate
+		if p.any && ok {
+			return true
+		}
+		if !p.any && !ok {
+			return false
+		}
+	}
+	return !p.any
+}
+
+func (p *predicate) test(n *tree_sitter.Node, src []byte) bool {
+	t := text(n, src)
+	switch p.op {
+	case "eq", "any-of":
+		for _, v := range p.values {
+			if string(t) == v {
+				return true
+			}
+		}
+	case "match":
+		return p.re.Match(t)
+	case "contains":
+		for _, v := range p.values {
+			if bytes.Contains(t, []byte(v)) {
+				return true
+			}
+		}
+	case "has-parent":
+		if parent := n.Parent(); parent != nil {
+			return p.hasKind(parent)
+		}
+	case "has-ancestor":
+		for a := n.Parent(); a != nil; a = a.Parent() {
+			if p.hasKind(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *predicate) hasKind(n *tree_sitter.Node) bool {
+	for _, v := range p.values {
+		if n.Kind() == v {
+			return true
+		}
+	}
+	return false
+}
+
+func nodesFor(captures []tree_sitter.QueryCapture, index uint) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := range captures {
+		if uint(captures[i].Index) == index {
+			out = append(out, &captures[i].Node)
+		}
+	}
+	return out
+}
+
+func text(n *tree_sitter.Node, src []byte) []byte {
+	return src[n.StartByte():n.EndByte()]
+}
+
+// properties collects the #set! directives of every pattern. The result
+// maps a pattern and capture index to the key/value pairs that apply to
+// that capture: those set for the whole pattern merged with those set for
+// the capture itself.
+func properties(q *tree_sitter.Query) []map[uint]map[string]string {
+	out := make([]map[uint]map[string]string, q.PatternCount())
+	ncaptures := uint(len(q.CaptureNames()))
+	for i := range out {
+		settings := q.PropertySettings(uint(i))
+		if len(settings) == 0 {
+			continue
+		}
+		base := make(map[string]string)
+		for _, s := range settings {
+			if s.CaptureId == nil {
+				base[s.Key] = value(s)
+			}
+		}
+		out[i] = make(map[uint]map[string]string)
+		for c := uint(0); c < ncaptures; c++ {
+			var props map[string]string
+			for _, s := range settings {
+				if s.CaptureId != nil && *s.CaptureId == c {
+					if props == nil {
+						props = maps.Clone(base)
+					}
+					props[s.Key] = value(s)
+				}
+			}
+			if props == nil {
+				props = base
+			}
+			if len(props) > 0 {
+				out[i][c] = props
+			}
+		}
+	}
+	return out
+}
+
+func value(s tree_sitter.QueryProperty) string {
+	if s.Value == nil {
+		return ""
+	}
+	return *s.Value
+}
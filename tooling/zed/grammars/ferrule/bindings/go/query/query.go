@@ -0,0 +1,111 @@
+// Package query runs tree-sitter queries over ferrule syntax trees, and
+// over the trees of languages embedded in ferrule source.
+package query
+
+import (
+	"iter"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Query is a compiled query over the ferrule grammar. It is safe for
+// concurrent use; every traversal gets a cursor of its own.
+type Query struct {
+	inner      *tree_sitter.Query
+	predicates [][]predicate
+	properties []map[uint]map[string]string
+	// shared is set on queries owned by the cache.
+	shared bool
+}
+
+// New compiles the query source. Errors are of type *tree_sitter.QueryError
+// and carry the position of the problem.
+func New(source string) (*Query, error) {
+	return ForLanguage(ferrule.Language(), source)
+}
+
+// ForLanguage compiles the query source over the grammar lang, such as
+// that of a language embedded in ferrule strings. The nodes Matches then
+// yields are wrapped by kind as if they were ferrule nodes, so only the
+// methods of ast.Node apply to them.
+func ForLanguage(lang *tree_sitter.Language, source string) (*Query, error) {
+	q, qerr := tree_sitter.NewQuery(lang, source)
+	if qerr != nil {
+		return nil, qerr
+	}
+	return &Query{inner: q, predicates: takePredicates(q), properties: properties(q)}, nil
+}
+
+// Raw returns the underlying tree-sitter query.
+func (q *Query) Raw() *tree_sitter.Query { return q.inner }
+
+// CaptureNames returns the names of the query's captures, indexed by
+// capture index.
+func (q *Query) CaptureNames() []string { return q.inner.CaptureNames() }
+
+// Close releases the query. It does nothing for queries obtained from
+// Cached or MustCompile.
+func (q *Query) Close() {
+	if !q.shared {
+		q.inner.Close()
+	}
+}
+
+// Capture identifies one capture of a match.
+type Capture struct {
+	// Name is the capture name without the leading '@'.
+	Name string
+	// Index is the index of the capture in CaptureNames.
+	Index uint
+	// Pattern is the index of the pattern that matched.
+	Pattern uint
+	// Match identifies the match within one traversal, so captures of the
+	// same match can be grouped.
+	Match uint
+	// Properties holds the #set! directives of the pattern that apply to
+	// this capture. It is shared and must not be modified.
+	Properties map[string]string
+}
+
+// Matches yields the captures of every match of q under root, match by
+// match, together with the captured node. Matches whose predicates do not
+// hold for src are skipped; see predicate for the ones understood.
+func (q *Query) Matches(root ast.Node, src []byte) iter.Seq2[Capture, ast.Node] {
+	return q.matches(root, src, nil)
+}
+
+// matches is Matches, limited to the matches intersecting the byte range
+// within when it is not nil.
+func (q *Query) matches(root ast.Node, src []byte, within *span) iter.Seq2[Capture, ast.Node] {
+	return func(yield func(Capture, ast.Node) bool) {
+		cursor := tree_sitter.NewQueryCursor()
+		defer cursor.Close()
+		if within != nil {
+			cursor.SetByteRange(within.start, within.end)
+		}
+		names := q.inner.CaptureNames()
+		matches := cursor.Matches(q.inner, root.Raw(), src)
+		var id uint
+		for m := matches.Next(); m != nil; m = matches.Next() {
+			if !satisfied(q.predicates[m.PatternIndex], m.Captures, src) {
+				continue
+			}
+			for _, c := range m.Captures {
+				capture := Capture{
+					Name:       names[c.Index],
+					Index:      uint(c.Index),
+					Pattern:    m.PatternIndex,
+					Match:      id,
+					Properties: q.properties[m.PatternIndex][uint(c.Index)],
+				}
+				if !yield(capture, ast.Wrap(&c.Node)) {
+					return
+				}
+			}
+			id++
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package query_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+)
+
+const source = `function add(x: i32, y: i32) -> i32 { return x + y; }
+function main() -> Unit { add(1, 2); }
+`
+
+func TestMatches(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	q, err := query.New(`(function_declaration name: (identifier) @name parameters: (parameter_list) @params)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	var got []string
+	for c, n := range q.Matches(tree.Root(), tree.Source()) {
+		got = append(got, fmt.Sprintf("%d:%s=%s", c.Match, c.Name, n.Text(tree.Source())))
+	}
+	want := "0:name=add 0:params=(x: i32, y: i32) 1:name=main 1:params=()"
+	if strings.Join(got, " ") != want {
+		t.Errorf("captures = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	// breaking out early must not leak the cursor or panic.
+	for _, n := range q.Matches(tree.Root(), tree.Source()) {
+		if _, ok := n.(*ast.Identifier); !ok {
+			t.Errorf("first capture is %T", n)
+		}
+		break
+	}
+}
+
+func TestNewError(t *testing.T) {
+	if _, err := query.New(`(no_such_node) @x`); err == nil {
+		t.Error("New accepted an unknown node kind")
+	}
+}
+
+func TestCached(t *testing.T) {
+	const src = `(call_expression) @call`
+	before := query.Stats()
+	a := query.MustCompile(src)
+	b, err := query.Cached(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != b {
+		t.Error("Cached compiled the same source twice")
+	}
+	b.Close() // must not free the shared query.
+	if len(a.CaptureNames()) != 1 {
+		t.Errorf("capture names = %q", a.CaptureNames())
+	}
+
+	if _, err := query.Cached(`(broken`); err == nil {
+		t.Error("Cached accepted a malformed query")
+	}
+	if _, err := query.Cached(`(broken`); err == nil {
+		t.Error("Cached forgot the compilation error")
+	}
+
+	after := query.Stats()
+	if hits, misses := after.Hits-before.Hits, after.Misses-before.Misses; hits != 2 || misses != 2 {
+		t.Errorf("hits = %d, misses = %d; want 2 and 2", hits, misses)
+	}
+	if after.Entries != before.Entries+2 || after.HitRate() <= 0 {
+		t.Errorf("stats = %+v", after)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile did not panic on a malformed query")
+		}
+	}()
+	query.MustCompile(`(broken`)
+}
+
+func TestPredicates(t *testing.T) {
+	const src = `function add(x: i32, y: i32) -> i32 { return x + y; }
+function main() -> Unit { print(x); add(1, 2); log_info(1); }
+`
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`((identifier) @id (#eq? @id "x"))`, "x x x"},
+		{`((identifier) @id (#not-eq? @id "x") (#has-parent? @id parameter))`, "y"},
+		{`(call_expression (identifier) @fn (#match? @fn "^log_"))`, "log_info"},
+		{`(call_expression (identifier) @fn (#not-match? @fn "^log_"))`, "print x add"},
+		{`(call_expression (identifier) @fn (#any-of? @fn "print" "add"))`, "print add"},
+		{`(call_expression . (identifier) @fn (#not-any-of? @fn "print" "add"))`, "log_info"},
+		{`((identifier) @id (#contains? @id "ain" "dd") (#has-ancestor? @id block))`, "add"},
+		{`(binary_expression (identifier) @a (identifier) @b (#eq? @a @b))`, ""},
+		{`(binary_expression (identifier) @a (identifier) @b (#not-eq? @a @b))`, "x y"},
+		{`((identifier) @id (#unknown? @id) (#eq? @id "y"))`, "y y"},
+	}
+	for _, tt := range tests {
+		q, err := query.New(tt.query)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, n := range q.Matches(tree.Root(), tree.Source()) {
+			got = append(got, n.Text(tree.Source()))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.query, strings.Join(got, " "), tt.want)
+		}
+		q.Close()
+	}
+}
+
+func TestSetProperties(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte("function add(x: i32) -> i32 { return x; }\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	q, err := query.New(`(function_declaration name: (identifier) @name body: (block) @body
+  (#set! kind "function")
+  (#set! @body fold "true"))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	props := make(map[string]map[string]string)
+	for c := range q.Matches(tree.Root(), tree.Source()) {
+		props[c.Name] = c.Properties
+	}
+	if p := props["name"]; p["kind"] != "function" || p["fold"] != "" {
+		t.Errorf("name properties = %v", p)
+	}
+	if p := props["body"]; p["kind"] != "function" || p["fold"] != "true" {
+		t.Errorf("body properties = %v", p)
+	}
+}
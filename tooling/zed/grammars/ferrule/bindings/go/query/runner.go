@@ -0,0 +1,229 @@
+package query
+
+import (
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ast"
+)
+
+// CachedRunner runs a query over the successive trees of a document being
+// edited. It keeps the captures of its last run and, on the next, runs the
+// query again only where the document changed, so that an editor
+// reparsing on every keystroke pays for the text around the edit rather
+// than for the whole file.
+//
+// Every edit made to the old tree is passed to Edit as well, and Run is
+// then given the new tree and the ranges ChangedRanges reports between
+// the two. The matches touching neither an edit nor a changed range are
+// kept, moved along by the edits; the others are found again. A
+// CachedRunner is not safe for concurrent use.
+type CachedRunner struct {
+	q       *Query
+	ran     bool
+	matches []cachedMatch
+	// edited holds the ranges of the edits since the last run, in the
+	// coordinates of the edited text.
+	edited []span
+}
+
+// CachedCapture is a capture found by a CachedRunner. It records the range
+// of the captured node rather than the node, which does not outlive its
+// tree.
+type CachedCapture struct {
+	Capture
+	Range tree_sitter.Range
+}
+
+// cachedMatch holds the captures of one match and the bytes they cover.
+type cachedMatch struct {
+	captures []CachedCapture
+	span
+}
+
+// span is a range of bytes.
+type span struct{ start, end uint }
+
+// touches reports whether s and t overlap or are adjacent, so that an
+// insertion at the edge of a match counts as a change to it.
+func (s span) touches(t span) bool { return s.start <= t.end && t.start <= s.end }
+
+// NewCachedRunner returns a runner of q with nothing cached.
+func NewCachedRunner(q *Query) *CachedRunner {
+	return &CachedRunner{q: q}
+}
+
+// Edit records an edit of the text, as tree_sitter.Tree.Edit does for a
+// tree: the cached captures after it move, and those it overlaps are
+// dropped.
+func (r *CachedRunner) Edit(edit *tree_sitter.InputEdit) {
+	if !r.ran {
+		return
+	}
+	old := span{edit.StartByte, edit.OldEndByte}
+	kept := r.matches[:0]
+	for _, m := range r.matches {
+		switch {
+		case m.end < old.start:
+			kept = append(kept, m)
+		case m.start >= old.end:
+			m.start, m.end = move(m.start, edit), move(m.end, edit)
+			for i := range m.captures {
+				m.captures[i].Range = moveRange(m.captures[i].Range, edit)
+			}
+			kept = append(kept, m)
+		}
+	}
+	r.matches = kept
+
+	added := span{edit.StartByte, edit.NewEndByte}
+	edited := r.edited[:0]
+	for _, s := range r.edited {
+		switch {
+		case s.end < old.start:
+			edited = append(edited, s)
+		case s.start > old.end:
+			edited = append(edited, span{move(s.start, edit), move(s.end, edit)})
+		default:
+			// an earlier edit the new one overlaps grows into it.
+			added.start = min(added.start, s.start)
+			if s.end > old.end {
+				added.end = max(added.end, move(s.end, edit))
+			}
+		}
+	}
+	r.edited = append(edited, added)
+}
+
+// move returns the offset b of the text before edit, at or after its old
+// end, in the text after it.
+func move(b uint, edit *tree_sitter.InputEdit) uint {
+	return b - edit.OldEndByte + edit.NewEndByte
+}
+
+func moveRange(rg tree_sitter.Range, edit *tree_sitter.InputEdit) tree_sitter.Range {
+	point := func(p tree_sitter.Point) tree_sitter.Point {
+		if p.Row == edit.OldEndPosition.Row {
+			p.Column = p.Column - edit.OldEndPosition.Column + edit.NewEndPosition.Column
+		}
+		p.Row = p.Row - edit.OldEndPosition.Row + edit.NewEndPosition.Row
+		return p
+	}
+	return tree_sitter.Range{
+		StartByte:  move(rg.StartByte, edit),
+		EndByte:    move(rg.EndByte, edit),
+		StartPoint: point(rg.StartPoint),
+		EndPoint:   point(rg.EndPoint),
+	}
+}
+
+// Run returns the captures of the query under root, a tree of src, match
+// by match in order of position. changed lists the ranges whose structure
+// changed since the tree of the last run, as ChangedRanges reports them;
+// the first run, and the first after Reset, runs over the whole tree and
+// ignores it. The captures are the runner's own and stay valid until its
+// next Run.
+func (r *CachedRunner) Run(root ast.Node, src []byte, changed []tree_sitter.Range) []CachedCapture {
+	if !r.ran {
+		r.matches = r.collect(root, src, nil)
+		r.ran = true
+		r.edited = r.edited[:0]
+		return r.captures()
+	}
+	dirty := append([]span(nil), r.edited...)
+	for _, c := range changed {
+		dirty = append(dirty, span{c.StartByte, c.EndByte})
+	}
+	r.edited = r.edited[:0]
+	if len(dirty) == 0 {
+		return r.captures()
+	}
+	dirty = merge(dirty)
+
+	kept := r.matches[:0]
+	for _, m := range r.matches {
+		if !touchesAny(m.span, dirty) {
+			kept = append(kept, m)
+		}
+	}
+	r.matches = kept
+	for i, d := range dirty {
+		// the cursor keeps the nodes overlapping its range, which an
+		// empty range has none of: widen it by a byte on each side.
+		within := span{d.start - min(d.start, 1), min(d.end+1, uint(len(src)))}
+		for _, m := range r.collect(root, src, &within) {
+			// matches touching several ranges are kept for the first.
+			if m.touches(d) && !touchesAny(m.span, dirty[:i]) {
+				r.matches = append(r.matches, m)
+			}
+		}
+	}
+	sort.SliceStable(r.matches, func(i, j int) bool {
+		a, b := r.matches[i], r.matches[j]
+		if a.start != b.start {
+			return a.start < b.start
+		}
+		return a.captures[0].Pattern < b.captures[0].Pattern
+	})
+	return r.captures()
+}
+
+// Reset drops the cached captures, so that the next run is over the whole
+// tree, as for a document replaced rather than edited.
+func (r *CachedRunner) Reset() {
+	r.ran = false
+	r.matches = nil
+	r.edited = nil
+}
+
+// collect returns the matches of the query under root, within the given
+// bytes if not nil.
+func (r *CachedRunner) collect(root ast.Node, src []byte, within *span) []cachedMatch {
+	var out []cachedMatch
+	for c, n := range r.q.matches(root, src, within) {
+		rg := n.Raw().Range()
+		if len(out) == 0 || out[len(out)-1].captures[0].Match != c.Match {
+			out = append(out, cachedMatch{span: span{rg.StartByte, rg.EndByte}})
+		}
+		m := &out[len(out)-1]
+		m.captures = append(m.captures, CachedCapture{Capture: c, Range: rg})
+		m.start, m.end = min(m.start, rg.StartByte), max(m.end, rg.EndByte)
+	}
+	return out
+}
+
+// captures flattens the cached matches, numbering them in order.
+func (r *CachedRunner) captures() []CachedCapture {
+	var out []CachedCapture
+	for i, m := range r.matches {
+		for j := range m.captures {
+			m.captures[j].Match = uint(i)
+		}
+		out = append(out, m.captures...)
+	}
+	return out
+}
+
+// merge sorts spans and joins those that touch.
+func merge(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		if last := &out[len(out)-1]; last.touches(s) {
+			last.end = max(last.end, s.end)
+		} else {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func touchesAny(s span, spans []span) bool {
+	for _, t := range spans {
+		if s.touches(t) {
+			return true
+		}
+	}
+	return false
+}
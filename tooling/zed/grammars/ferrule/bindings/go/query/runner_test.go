@@ -0,0 +1,92 @@
+package query_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+// describe renders captures as sorted name@range lines, for comparing the
+// cached ones with those of a run from scratch.
+func describe(captures []query.CachedCapture) string {
+	var out []string
+	for _, c := range captures {
+		r := c.Range
+		out = append(out, fmt.Sprintf("%s@%d-%d(%d:%d-%d:%d)", c.Name, r.StartByte, r.EndByte, r.StartPoint.Row, r.StartPoint.Column, r.EndPoint.Row, r.EndPoint.Column))
+	}
+	slices.Sort(out)
+	return strings.Join(out, "\n")
+}
+
+func TestCachedRunner(t *testing.T) {
+	q := query.MustCompile(string(queries.Highlights))
+	ctx := context.Background()
+	p, err := ferrule.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	tree, err := p.Parse(ctx, []byte(source), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { tree.Close() }()
+
+	r := query.NewCachedRunner(q)
+	r.Run(tree.Root(), tree.Source(), nil)
+
+	// each step replaces the first occurrence of old, in the text left by
+	// the steps before it.
+	steps := []struct{ old, new string }{
+		{"x + y", "x * y"},
+		{"add(1, 2)", "add(1, 2); add(3, 4)"},
+		{"function main", "// a comment\nfunction main"},
+		{"y: i32", "y: i32, z: i32"},
+		{"; add(3, 4)", ""},
+		{"return", "retur"},
+		{"retur", "return"},
+	}
+	for _, step := range steps {
+		src := tree.Source()
+		at := strings.Index(string(src), step.old)
+		if at < 0 {
+			t.Fatalf("%q not in %q", step.old, src)
+		}
+		rg := edits.Range{Start: edits.PositionOf(src, uint(at)), End: edits.PositionOf(src, uint(at+len(step.old)))}
+		next, edit, err := edits.Apply(src, edits.Change{Range: &rg, Text: step.new})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Raw().Edit(&edit)
+		r.Edit(&edit)
+		updated, err := p.Parse(ctx, next, tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		changed := tree.Raw().ChangedRanges(updated.Raw())
+		tree.Close()
+		tree = updated
+
+		got := describe(r.Run(tree.Root(), tree.Source(), changed))
+		want := describe(query.NewCachedRunner(q).Run(tree.Root(), tree.Source(), nil))
+		if got != want {
+			t.Errorf("after %q -> %q, cached captures differ:\n%s\nwant\n%s", step.old, step.new, got, want)
+		}
+	}
+
+	// with nothing changed the cache is returned as is.
+	if got, want := describe(r.Run(tree.Root(), tree.Source(), nil)), describe(query.NewCachedRunner(q).Run(tree.Root(), tree.Source(), nil)); got != want {
+		t.Errorf("unchanged run differs:\n%s\nwant\n%s", got, want)
+	}
+	r.Reset()
+	if got := r.Run(tree.Root(), tree.Source(), nil); len(got) == 0 {
+		t.Error("no captures after Reset")
+	}
+}
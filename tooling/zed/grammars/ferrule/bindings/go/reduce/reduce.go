@@ -0,0 +1,246 @@
+// Package reduce shrinks ferrule sources while they keep a property, to
+// turn the file that shows a bug of the grammar or of a tool into a small
+// case reproducing it:
+//
+//	small, err := reduce.Minimize(src, func(src []byte) bool {
+//		return bytes.Contains(lint(src), []byte("shadow"))
+//	})
+//
+// Minimize removes whole nodes of the syntax tree rather than arbitrary
+// bytes, so that what is left stays close to code a person would write:
+// it tries to drop runs of the children of each node, largest first as
+// in delta debugging, and to replace nodes by one of their named children,
+// as an expression by one of its operands. It parses the source again
+// after every round of reductions and stops when a round finds none,
+// finally dropping the blank lines and trailing spaces the removals left.
+//
+// The result is minimal in that no single node can be removed from it,
+// not in that no smaller source has the property. The predicate is called
+// with a fresh slice every time, which it may keep, and never twice with
+// the same source.
+package reduce
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// ErrNoProperty is returned by Minimize when the predicate does not hold
+// for the source it is given.
+var ErrNoProperty = errors.New("reduce: predicate does not hold for the source")
+
+// Minimize returns a source for which predicate holds, obtained from src
+// by removing or replacing syntax nodes until none can be. It returns
+// ErrNoProperty if predicate does not hold for src, and the error of a
+// failed parse along with the smallest source found until then.
+func Minimize(src []byte, predicate func(src []byte) bool) ([]byte, error) {
+	r := &reducer{predicate: predicate, tested: make(map[[sha256.Size]byte]bool)}
+	if !r.test(src) {
+		return nil, ErrNoProperty
+	}
+	for {
+		next, err := r.round(src)
+		if err != nil {
+			return src, err
+		}
+		if next == nil {
+			break
+		}
+		src = next
+	}
+	if t := tidy(src); !bytes.Equal(t, src) && r.test(t) {
+		src = t
+	}
+	return src, nil
+}
+
+type reducer struct {
+	predicate func([]byte) bool
+	// tested holds the results of the predicate by the hash of the source.
+	tested map[[sha256.Size]byte]bool
+}
+
+// test reports whether the predicate holds for src, calling it only for a
+// source not seen before.
+func (r *reducer) test(src []byte) bool {
+	sum := sha256.Sum256(src)
+	if ok, seen := r.tested[sum]; seen {
+		return ok
+	}
+	ok := r.predicate(bytes.Clone(src))
+	r.tested[sum] = ok
+	return ok
+}
+
+// edit replaces the bytes from start to end of a source with text.
+type edit struct {
+	start, end uint
+	text       []byte
+}
+
+// round makes the reductions it can find in one walk over the tree of
+// src, breadth first so that larger nodes go before the nodes they hold.
+// It returns the reduced source, or nil if there is no reduction to make.
+func (r *reducer) round(src []byte) ([]byte, error) {
+	tree, err := ferrule.Parse(context.Background(), src)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	rd := &pass{r: r, src: src}
+	queue := []*tree_sitter.Node{tree.RootNode()}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if rd.edited(n.StartByte(), n.EndByte()) {
+			continue
+		}
+		if n.Parent() != nil {
+			rd.hoist(n)
+		}
+		kids := children(n)
+		rd.removeRuns(kids)
+		queue = append(queue, kids...)
+	}
+	if len(rd.edits) == 0 {
+		return nil, nil
+	}
+	return rd.apply(nil), nil
+}
+
+// pass holds the reductions found in a round, as edits of its source
+// that neither overlap nor nest.
+type pass struct {
+	r     *reducer
+	src   []byte
+	edits []edit // sorted by start
+}
+
+// children returns the children of n that cover some bytes, leaving out
+// those a parser inserted for missing tokens.
+func children(n *tree_sitter.Node) []*tree_sitter.Node {
+	var out []*tree_sitter.Node
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if c := n.Child(i); c.EndByte() > c.StartByte() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// removeRuns tries to remove runs of the sibling nodes kids: all of them,
+// then halves, quarters and so on down to single nodes.
+func (p *pass) removeRuns(kids []*tree_sitter.Node) {
+	removed := make([]bool, len(kids))
+	for size := len(kids); size >= 1; size /= 2 {
+		for i := 0; i < len(kids); i += size {
+			first, last := -1, -1
+			for j := i; j < min(i+size, len(kids)); j++ {
+				if !removed[j] {
+					if first < 0 {
+						first = j
+					}
+					last = j
+				}
+			}
+			if first < 0 {
+				continue
+			}
+			if p.try(edit{start: kids[first].StartByte(), end: kids[last].EndByte()}) {
+				for j := first; j <= last; j++ {
+					removed[j] = true
+				}
+			}
+		}
+	}
+}
+
+// hoist tries to replace n by each of its named children in turn, those
+// as large as it left out.
+func (p *pass) hoist(n *tree_sitter.Node) {
+	for i := uint(0); i < n.NamedChildCount(); i++ {
+		c := n.NamedChild(i)
+		if c.IsExtra() || c.EndByte() == c.StartByte() {
+			continue
+		}
+		if p.try(edit{start: n.StartByte(), end: n.EndByte(), text: p.src[c.StartByte():c.EndByte()]}) {
+			return
+		}
+	}
+}
+
+// edited reports whether the bytes from start to end lie within an edit
+// made already, so that the nodes there are gone.
+func (p *pass) edited(start, end uint) bool {
+	i := sort.Search(len(p.edits), func(i int) bool { return p.edits[i].end > start })
+	return i < len(p.edits) && p.edits[i].start <= start && end <= p.edits[i].end
+}
+
+// try makes e if it shrinks the source and the predicate holds for the
+// source with it, reporting whether it did. An edit covering others
+// replaces them; one within another is not tried.
+func (p *pass) try(e edit) bool {
+	if uint(len(e.text)) >= e.end-e.start || p.edited(e.start, e.end) {
+		return false
+	}
+	if !p.r.test(p.apply(&e)) {
+		return false
+	}
+	kept := p.edits[:0]
+	for _, f := range p.edits {
+		if f.start < e.start || f.end > e.end {
+			kept = append(kept, f)
+		}
+	}
+	p.edits = kept
+	i := sort.Search(len(p.edits), func(i int) bool { return p.edits[i].start >= e.start })
+	p.edits = append(p.edits, edit{})
+	copy(p.edits[i+1:], p.edits[i:])
+	p.edits[i] = e
+	return true
+}
+
+// apply returns the source of the pass with its edits made, and extra
+// too if it is not nil. The edits within extra are left out.
+func (p *pass) apply(extra *edit) []byte {
+	out := make([]byte, 0, len(p.src))
+	at := uint(0)
+	put := func(e edit) {
+		out = append(out, p.src[at:e.start]...)
+		out = append(out, e.text...)
+		at = e.end
+	}
+	done := extra == nil
+	for _, e := range p.edits {
+		if !done && extra.start <= e.start {
+			put(*extra)
+			done = true
+		}
+		if e.start < at {
+			continue
+		}
+		put(e)
+	}
+	if !done {
+		put(*extra)
+	}
+	return append(out, p.src[at:]...)
+}
+
+// tidy drops the blank lines of src and the trailing spaces of its lines.
+func tidy(src []byte) []byte {
+	var out []byte
+	for _, line := range bytes.Split(src, []byte("\n")) {
+		if line = bytes.TrimRight(line, " \t\r"); len(line) > 0 {
+			out = append(append(out, line...), '\n')
+		}
+	}
+	return out
+}
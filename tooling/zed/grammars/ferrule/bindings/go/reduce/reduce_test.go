@@ -0,0 +1,139 @@
+package reduce_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/analysis/passes/shadow"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/reduce"
+)
+
+const program = `package demo;
+
+import std.io;
+
+// scale scales v.
+function scale(v: u32) -> u32 {
+  const factor = 2;
+  return v * factor + 1;
+}
+
+function main() -> Unit {
+  const x = scale(1);
+  if x > 2 {
+    const x = 3;
+    io.println(x);
+  }
+  while x < 10 {
+    io.println("loop");
+  }
+}
+
+type Point = { x: i32, y: i32 };
+`
+
+func TestMinimizeLint(t *testing.T) {
+	// the shadowed x keeps a shadow diagnostic.
+	shadows := func(src []byte) bool {
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tree.Close()
+		diags, err := analysis.Run(tree, shadow.Analyzer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(diags) > 0 && !tree.RootNode().HasError()
+	}
+	calls := 0
+	seen := make(map[string]bool)
+	got, err := reduce.Minimize([]byte(program), func(src []byte) bool {
+		calls++
+		if seen[string(src)] {
+			t.Errorf("predicate called twice with %q", src)
+		}
+		seen[string(src)] = true
+		return shadows(src)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !shadows(got) {
+		t.Fatalf("result does not shadow:\n%s", got)
+	}
+	for _, gone := range []string{"import", "scale(", "while", "Point", "println", "//"} {
+		if bytes.Contains(got, []byte(gone)) {
+			t.Errorf("result keeps %s:\n%s", gone, got)
+		}
+	}
+	if len(got) >= len(program)/4 {
+		t.Errorf("result of %d bytes from %d:\n%s", len(got), len(program), got)
+	}
+	t.Logf("%d calls:\n%s", calls, got)
+}
+
+func TestMinimizeErrors(t *testing.T) {
+	// a source with a syntax error around the @ keeps one.
+	broken := func(src []byte) bool {
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tree.Close()
+		for _, d := range tree.Diagnostics() {
+			if strings.Contains(string(src[d.Range.StartByte:d.Range.EndByte]), "@") {
+				return true
+			}
+		}
+		return false
+	}
+	src := strings.Replace(program, "v * factor", "v @ factor", 1)
+	got, err := reduce.Minimize([]byte(src), broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !broken(got) || len(got) > 20 {
+		t.Errorf("result %q", got)
+	}
+}
+
+func TestMinimizeEmpty(t *testing.T) {
+	got, err := reduce.Minimize([]byte(program), func([]byte) bool { return true })
+	if err != nil || len(got) != 0 {
+		t.Errorf("Minimize = %q, %v; want an empty source", got, err)
+	}
+	if _, err := reduce.Minimize([]byte(program), func([]byte) bool { return false }); !errors.Is(err, reduce.ErrNoProperty) {
+		t.Errorf("Minimize of a source without the property: %v", err)
+	}
+}
+
+func TestMinimizeNodes(t *testing.T) {
+	// the declaration of Point is kept, without its fields.
+	got, err := reduce.Minimize([]byte(program), func(src []byte) bool {
+		tree, err := ferrule.Parse(context.Background(), src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tree.Close()
+		root := tree.RootNode()
+		for i := uint(0); i < root.NamedChildCount(); i++ {
+			if n := root.NamedChild(i); n.Kind() == kind.TypeDeclaration && !n.HasError() {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "type Point = {  };\n"; string(got) != want {
+		t.Errorf("result %q, want %q", got, want)
+	}
+}
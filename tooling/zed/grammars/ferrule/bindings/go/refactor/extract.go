@@ -0,0 +1,425 @@
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/eval"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/format"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+// ErrNoExtract is returned when the selection given to ExtractFunction is
+// neither an expression nor a run of whole statements of one block.
+var ErrNoExtract = errors.New("refactor: selection is not an expression or a run of statements")
+
+// ExtractFunction returns the edits that move the code selected in src,
+// the source of tree, into a new function declared after the one holding
+// it, and replace it with a call. The selection, white space around it
+// aside, must be one expression or whole statements of one block.
+//
+// The local names the code uses but does not define become parameters of
+// the new function, typed as they are declared; constants declared
+// without a type take that of their value. An extracted expression is
+// returned with its type, extracted statements return Unit. The new
+// function is named extracted, or extracted2 and so on if the file uses
+// that name.
+//
+// ExtractFunction refuses, returning an error and no edits, when the type
+// of a parameter or of the expression cannot be told, when the code
+// assigns a name it does not define, returns, breaks out of a loop around
+// it or propagates errors, or when code after the statements uses a name
+// they define.
+func ExtractFunction(tree *ferrule.Tree, src []byte, selection tree_sitter.Range) ([]Edit, error) {
+	if tree.HasError() {
+		return nil, errors.New("refactor: file has syntax errors")
+	}
+	start, end := selection.StartByte, selection.EndByte
+	for start < end && isSpace(src[start]) {
+		start++
+	}
+	for end > start && isSpace(src[end-1]) {
+		end--
+	}
+	if start == end {
+		return nil, ErrNoExtract
+	}
+	expr, stmts := selected(tree.RootNode(), start, end)
+	if expr == nil && stmts == nil {
+		return nil, ErrNoExtract
+	}
+	anchor := expr
+	if anchor == nil {
+		anchor = stmts[0]
+	}
+	fn := anchor
+	for fn != nil && fn.Kind() != kind.FunctionDeclaration {
+		fn = fn.Parent()
+	}
+	if fn == nil {
+		return nil, ErrNoExtract
+	}
+
+	info := scope.Resolve(tree)
+	inside := func(n *tree_sitter.Node) bool { return n.StartByte() >= start && n.EndByte() <= end }
+	x := &extraction{src: src, info: info}
+	var params []*scope.Definition
+	seen := make(map[*scope.Definition]bool)
+	err := walkRange(tree.RootNode(), start, end, func(n *tree_sitter.Node) error {
+		switch n.Kind() {
+		case kind.ReturnStatement:
+			return errors.New("refactor: the selection returns from the function")
+		case kind.CheckExpression, kind.ErrExpression, kind.OkExpression:
+			return errors.New("refactor: the selection propagates errors")
+		case kind.KeywordBreak, kind.KeywordContinue:
+			if !loopWithin(n, start) {
+				return fmt.Errorf("refactor: the selection uses %s outside a loop of its own", n.Kind())
+			}
+		case kind.BinaryExpression:
+			if left := n.NamedChild(0); n.Child(1).Kind() == "=" && left.Kind() == kind.Identifier {
+				if d := info.ResolveAt(left.StartPosition()); d != nil && !inside(d.Node) {
+					return fmt.Errorf("refactor: the selection assigns %s, which it does not declare", d.Name)
+				}
+			}
+		case kind.Identifier:
+			d := info.ResolveAt(n.StartPosition())
+			if d == nil || d.Node.StartByte() == n.StartByte() || inside(d.Node) || d.Scope == info.Root || seen[d] {
+				return nil
+			}
+			if d.Kind == "function" {
+				return fmt.Errorf("refactor: the selection uses the local function %s", d.Name)
+			}
+			seen[d] = true
+			params = append(params, d)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stmts != nil {
+		for _, d := range info.Definitions {
+			if !inside(d.Node) {
+				continue
+			}
+			for _, r := range info.ReferencesOf(d) {
+				if r.StartByte() >= end {
+					return nil, fmt.Errorf("refactor: %s is declared in the selection and used at line %d", d.Name, r.StartPosition().Row+1)
+				}
+			}
+		}
+	}
+
+	name := freeName(tree.RootNode(), src, "extracted")
+	var decl, args strings.Builder
+	decl.WriteString("function " + name)
+	if tp := childOfKind(fn, kind.TypeParameters); tp != nil {
+		decl.WriteString(tree.Text(tp))
+	}
+	decl.WriteByte('(')
+	for i, d := range params {
+		typ := x.typeOfDefinition(d)
+		if typ == "" {
+			return nil, fmt.Errorf("refactor: cannot tell the type of %s", d.Name)
+		}
+		if i > 0 {
+			decl.WriteString(", ")
+			args.WriteString(", ")
+		}
+		decl.WriteString(d.Name + ": " + typ)
+		args.WriteString(d.Name)
+	}
+	ret := "Unit"
+	if expr != nil {
+		if ret = x.typeOf(expr); ret == "" {
+			return nil, errors.New("refactor: cannot tell the type of the selection")
+		}
+	}
+	decl.WriteString(") -> " + ret)
+	if eff := childOfKind(fn, kind.EffectsClause); eff != nil {
+		decl.WriteString(" " + tree.Text(eff))
+	}
+	decl.WriteString(" {\n")
+	call := name + "(" + args.String() + ")"
+	if expr != nil {
+		decl.WriteString(format.Indent + "return " + string(src[start:end]) + ";\n")
+	} else {
+		decl.WriteString(reindent(src, start, end))
+		call += ";"
+	}
+	decl.WriteString("}")
+
+	top := fn
+	for top.Parent() != nil && top.Parent().Kind() != kind.SourceFile {
+		top = top.Parent()
+	}
+	at := top.Range()
+	indent := lineIndentAt(src, top.StartByte())
+	body := strings.ReplaceAll(decl.String(), "\n", "\n"+indent)
+	body = strings.ReplaceAll(body, "\n"+indent+"\n", "\n\n")
+	return []Edit{
+		{Range: tree_sitter.Range{StartByte: start, EndByte: end, StartPoint: edits.Point(src, start), EndPoint: edits.Point(src, end)}, NewText: call},
+		{Range: tree_sitter.Range{StartByte: at.EndByte, EndByte: at.EndByte, StartPoint: at.EndPoint, EndPoint: at.EndPoint}, NewText: "\n\n" + indent + body},
+	}, nil
+}
+
+// selected returns the expression spanning exactly the bytes from start to
+// end, or else the statements of one block that do, or neither.
+func selected(root *tree_sitter.Node, start, end uint) (*tree_sitter.Node, []*tree_sitter.Node) {
+	n := root.NamedDescendantForByteRange(start, end)
+	for n != nil && n.Parent() != nil && n.Parent().StartByte() == start && n.Parent().EndByte() == end && n.Parent().Kind() != kind.ExpressionStatement {
+		n = n.Parent()
+	}
+	if n == nil {
+		return nil, nil
+	}
+	if n.StartByte() == start && n.EndByte() == end && isExpression(n) {
+		return n, nil
+	}
+	block := n
+	for block != nil && block.Kind() != kind.Block {
+		block = block.Parent()
+	}
+	if block == nil || block.Parent() == nil || block.Parent().Kind() == kind.SourceFile {
+		return nil, nil
+	}
+	var stmts []*tree_sitter.Node
+	for i := uint(1); i+1 < block.ChildCount(); i++ {
+		c := block.Child(i)
+		switch {
+		case c.EndByte() <= start || c.StartByte() >= end:
+		case c.StartByte() < start || c.EndByte() > end:
+			return nil, nil
+		default:
+			stmts = append(stmts, c)
+		}
+	}
+	if len(stmts) == 0 || stmts[0].StartByte() != start || stmts[len(stmts)-1].EndByte() != end {
+		return nil, nil
+	}
+	return nil, stmts
+}
+
+// isExpression reports whether n is an expression other than a lone name,
+// which is not worth a function.
+func isExpression(n *tree_sitter.Node) bool {
+	switch n.Kind() {
+	case kind.ArrayExpression, kind.BinaryExpression, kind.BooleanLiteral, kind.CallExpression,
+		kind.CharLiteral, kind.FloatLiteral, kind.IfExpression, kind.IndexExpression,
+		kind.IntegerLiteral, kind.MatchExpression, kind.MemberExpression,
+		kind.ParenthesizedExpression, kind.RecordExpression, kind.StringLiteral, kind.UnaryExpression:
+		return true
+	}
+	return false
+}
+
+// walkRange calls f for every node of the tree below root that lies
+// between start and end, stopping at the first error.
+func walkRange(root *tree_sitter.Node, start, end uint, f func(*tree_sitter.Node) error) error {
+	cursor := root.Walk()
+	defer cursor.Close()
+	for {
+		n := cursor.Node()
+		if n.EndByte() > start && n.StartByte() < end {
+			if n.StartByte() >= start && n.EndByte() <= end {
+				if err := f(n); err != nil {
+					return err
+				}
+			}
+			if cursor.GotoFirstChild() {
+				continue
+			}
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				return nil
+			}
+		}
+	}
+}
+
+// loopWithin reports whether the break or continue token n is in a loop,
+// or an anonymous function, starting at or after start.
+func loopWithin(n *tree_sitter.Node, start uint) bool {
+	for p := n.Parent(); p != nil && p.StartByte() >= start; p = p.Parent() {
+		switch p.Kind() {
+		case kind.WhileStatement, kind.ForStatement, kind.AnonymousFunction:
+			return true
+		}
+	}
+	return false
+}
+
+// extraction tells the types of names and expressions of a file.
+type extraction struct {
+	src  []byte
+	info *scope.Info
+}
+
+// typeOfDefinition returns the declared type of d, or that of the value of
+// an untyped constant, or "".
+func (x *extraction) typeOfDefinition(d *scope.Definition) string {
+	decl := d.Node.Parent()
+	if typ := decl.ChildByFieldName(field.Type); typ != nil && decl.Kind() == kind.Parameter {
+		return typ.Utf8Text(x.src)
+	}
+	if decl.Kind() != kind.ConstDeclaration {
+		return ""
+	}
+	for i := uint(0); i < decl.ChildCount(); i++ {
+		if decl.Child(i).Kind() == ":" {
+			return decl.Child(i).NextNamedSibling().Utf8Text(x.src)
+		}
+	}
+	if v := decl.ChildByFieldName(field.Value); v != nil {
+		return x.typeOf(v)
+	}
+	return ""
+}
+
+// typeOf returns the type of the expression n as far as it can be told
+// from literals, declarations and operators, or "".
+func (x *extraction) typeOf(n *tree_sitter.Node) string {
+	switch n.Kind() {
+	case kind.ParenthesizedExpression:
+		return x.typeOf(n.NamedChild(0))
+	case kind.Identifier:
+		if d := x.info.ResolveAt(n.StartPosition()); d != nil && d.Kind != "function" {
+			return x.typeOfDefinition(d)
+		}
+		return ""
+	case kind.UnaryExpression:
+		if n.Child(0).Kind() == "!" {
+			return "Bool"
+		}
+		return x.typeOf(n.NamedChild(0))
+	case kind.BinaryExpression:
+		switch n.Child(1).Kind() {
+		case "==", "!=", "<", "<=", ">", ">=", "is", "&&", "||":
+			return "Bool"
+		case "++":
+			return "String"
+		case "=", "..", "..=":
+			return ""
+		}
+		if t := x.typeOf(n.NamedChild(0)); t != "" {
+			return t
+		}
+		return x.typeOf(n.NamedChild(1))
+	case kind.CallExpression:
+		callee := n.NamedChild(0)
+		if callee.Kind() != kind.Identifier {
+			return ""
+		}
+		d := x.info.ResolveAt(callee.StartPosition())
+		if d == nil || d.Kind != "function" || d.Node.Parent().Kind() != kind.FunctionDeclaration {
+			return ""
+		}
+		if ret := d.Node.Parent().ChildByFieldName(field.ReturnType); ret != nil {
+			return ret.Utf8Text(x.src)
+		}
+		return ""
+	}
+	v, err := eval.Const(n, x.src)
+	if err != nil {
+		return ""
+	}
+	switch v.Kind {
+	case eval.Int:
+		return "i32"
+	case eval.Float:
+		return "f64"
+	case eval.String:
+		return "String"
+	case eval.Char:
+		return "Char"
+	case eval.Bool:
+		return "Bool"
+	}
+	return ""
+}
+
+// reindent returns the lines of src from start to end indented by one
+// level in place of their common indentation, each ending in a newline.
+// The first line is taken to start at the indentation of its line.
+func reindent(src []byte, start, end uint) string {
+	lines := strings.Split(lineIndentAt(src, start)+string(src[start:end]), "\n")
+	common := ""
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		ind := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		if i == 0 || len(ind) < len(common) {
+			common = ind
+		}
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			b.WriteString(format.Indent + strings.TrimPrefix(l, common))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// lineIndentAt returns the white space starting the line of the byte at
+// offset.
+func lineIndentAt(src []byte, offset uint) string {
+	i := int(offset)
+	for i > 0 && src[i-1] != '\n' {
+		i--
+	}
+	j := i
+	for j < len(src) && (src[j] == ' ' || src[j] == '\t') {
+		j++
+	}
+	return string(src[i:j])
+}
+
+// freeName returns base, or base followed by the smallest number from 2
+// up, whichever no identifier below root spells.
+func freeName(root *tree_sitter.Node, src []byte, base string) string {
+	used := make(map[string]bool)
+	cursor := root.Walk()
+	defer cursor.Close()
+	for done := false; !done; {
+		if n := cursor.Node(); n.Kind() == kind.Identifier {
+			used[n.Utf8Text(src)] = true
+		}
+		if cursor.GotoFirstChild() {
+			continue
+		}
+		for !cursor.GotoNextSibling() {
+			if !cursor.GotoParent() {
+				done = true
+				break
+			}
+		}
+	}
+	name := base
+	for k := 2; used[name]; k++ {
+		name = fmt.Sprintf("%s%d", base, k)
+	}
+	return name
+}
+
+// childOfKind returns the first child of n of kind k, or nil.
+func childOfKind(n *tree_sitter.Node, k string) *tree_sitter.Node {
+	for i := uint(0); i < n.ChildCount(); i++ {
+		if c := n.Child(i); c.Kind() == k {
+			return c
+		}
+	}
+	return nil
+}
+
+func isSpace(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' }
@@ -0,0 +1,78 @@
+package refactor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// extract runs ExtractFunction on src with the selection marked by « and ».
+func extract(t *testing.T, marked string) (string, error) {
+	t.Helper()
+	start := strings.Index(marked, "«")
+	src := strings.Replace(marked, "«", "", 1)
+	end := strings.Index(src, "»")
+	src = strings.Replace(src, "»", "", 1)
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	edits, err := refactor.ExtractFunction(tree, tree.Source(), tree_sitter.Range{StartByte: uint(start), EndByte: uint(end)})
+	if err != nil {
+		return "", err
+	}
+	return string(refactor.Apply(tree.Source(), edits)), nil
+}
+
+func TestExtractFunction(t *testing.T) {
+	tests := []struct{ name, src, want string }{
+		{
+			"expression",
+			"function f(a: i32, b: String) -> i32 {\n  const k = 3;\n  return «a * k + 1»;\n}\n",
+			"function f(a: i32, b: String) -> i32 {\n  const k = 3;\n  return extracted(a, k);\n}\n\nfunction extracted(a: i32, k: i32) -> i32 {\n  return a * k + 1;\n}\n",
+		},
+		{
+			"statements",
+			"function f(n: u32) -> Unit effects [io] {\n  const a = 1;\n  «const s: String = \"n\";\n  print(s, n);\n  if n > 0 {\n    print(a);\n  }»\n  print(a);\n}\n",
+			"function f(n: u32) -> Unit effects [io] {\n  const a = 1;\n  extracted(n, a);\n  print(a);\n}\n\nfunction extracted(n: u32, a: i32) -> Unit effects [io] {\n  const s: String = \"n\";\n  print(s, n);\n  if n > 0 {\n    print(a);\n  }\n}\n",
+		},
+		{
+			"name taken",
+			"function extracted() -> Bool {\n  return «1 < 2»;\n}\n",
+			"function extracted() -> Bool {\n  return extracted2();\n}\n\nfunction extracted2() -> Bool {\n  return 1 < 2;\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := extract(t, tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractFunctionRefused(t *testing.T) {
+	tests := []struct{ name, src, want string }{
+		{"partial", "function f(a: i32) -> i32 {\n  return «a *» 2;\n}\n", refactor.ErrNoExtract.Error()},
+		{"return", "function f(a: i32) -> i32 {\n  «print(a);\n  return a;»\n}\n", "returns from the function"},
+		{"break", "function f(a: i32) -> Unit {\n  while true {\n    «break;»\n  }\n}\n", "break outside a loop"},
+		{"assigns", "function f() -> Unit {\n  var a = 1;\n  «a = 2;»\n  print(a);\n}\n", "assigns a"},
+		{"used after", "function f() -> Unit {\n  «const a = 1;»\n  print(a);\n}\n", "a is declared in the selection and used at line 3"},
+		{"unknown type", "function f() -> i32 {\n  const a = g();\n  return «a + 1»;\n}\n", "cannot tell the type of a"},
+	}
+	for _, tt := range tests {
+		_, err := extract(t, tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/analysis"
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+// InlineBinding returns the edits that replace every reference to the
+// local binding at p, its name or a reference to it, with the value it is
+// declared with, parenthesized where needed, and remove the declaration.
+//
+// InlineBinding refuses, returning an error and no edits, when the binding
+// is not declared in a block, is never used, or is a var assigned after
+// its declaration; when its value assigns, or calls functions and is used
+// more than once or in a loop or function of its own, so that inlining
+// would repeat or move the call; and when a name the value reads would
+// denote something else at a reference, or is a var assigned somewhere.
+func InlineBinding(tree *ferrule.Tree, src []byte, p tree_sitter.Point) ([]Edit, error) {
+	info := scope.Resolve(tree)
+	def := info.ResolveAt(p)
+	if def == nil {
+		return nil, ErrNoName
+	}
+	decl := def.Node.Parent()
+	value := decl.ChildByFieldName(field.Value)
+	if decl.Kind() != kind.ConstDeclaration || value == nil || decl.Parent().Kind() != kind.Block {
+		return nil, fmt.Errorf("refactor: %s is not a local binding", def.Name)
+	}
+	refs := info.ReferencesOf(def)
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("refactor: %s is never used", def.Name)
+	}
+	for _, r := range refs {
+		if assigned(r) {
+			return nil, fmt.Errorf("refactor: %s is assigned at line %d", def.Name, r.StartPosition().Row+1)
+		}
+	}
+
+	calls := false
+	var reads []*tree_sitter.Node
+	var err error
+	walkRange(value, value.StartByte(), value.EndByte(), func(n *tree_sitter.Node) error {
+		switch n.Kind() {
+		case kind.CallExpression:
+			calls = true
+		case kind.BinaryExpression:
+			if n.Child(1).Kind() == "=" {
+				err = fmt.Errorf("refactor: the value of %s assigns", def.Name)
+			}
+		case kind.Identifier:
+			reads = append(reads, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if calls && (len(refs) > 1 || repeated(refs[0], decl.Parent())) {
+		return nil, fmt.Errorf("refactor: the value of %s calls functions, which inlining would repeat or move", def.Name)
+	}
+	for _, id := range reads {
+		d := info.ResolveAt(id.StartPosition())
+		if d != nil && d.Kind == "var" && isVar(d) {
+			for _, r := range info.ReferencesOf(d) {
+				if assigned(r) {
+					return nil, fmt.Errorf("refactor: the value of %s reads %s, which is assigned at line %d", def.Name, d.Name, r.StartPosition().Row+1)
+				}
+			}
+		}
+		name := tree.Text(id)
+		for _, r := range refs {
+			if info.ScopeAt(r.StartPosition()).Lookup(name, r.StartByte()) != d {
+				return nil, fmt.Errorf("refactor: %s would denote something else at line %d", name, r.StartPosition().Row+1)
+			}
+		}
+	}
+
+	text := tree.Text(value)
+	out := make([]Edit, 0, len(refs)+1)
+	for _, r := range refs {
+		with := text
+		if needsParens(value.Kind(), r.Parent().Kind()) {
+			with = "(" + text + ")"
+		}
+		out = append(out, Edit{Range: r.Range(), NewText: with})
+	}
+	del := analysis.Delete(src, decl)
+	out = append(out, Edit{
+		Range: tree_sitter.Range{StartByte: del.Start, EndByte: del.End, StartPoint: edits.Point(src, del.Start), EndPoint: edits.Point(src, del.End)},
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Range.StartByte < out[j].Range.StartByte })
+	return out, nil
+}
+
+// assigned reports whether the reference r is the left side of an
+// assignment.
+func assigned(r *tree_sitter.Node) bool {
+	p := r.Parent()
+	return p.Kind() == kind.BinaryExpression && p.Child(1).Kind() == "=" && p.NamedChild(0).Id() == r.Id()
+}
+
+// isVar reports whether d is declared with var.
+func isVar(d *scope.Definition) bool {
+	decl := d.Node.Parent()
+	return decl.Kind() == kind.ConstDeclaration && decl.Child(0).Kind() == kind.KeywordVar
+}
+
+// repeated reports whether the reference r may run more than once per
+// run of block, being in a loop or function inside it.
+func repeated(r, block *tree_sitter.Node) bool {
+	for p := r.Parent(); p != nil && p.Id() != block.Id(); p = p.Parent() {
+		switch p.Kind() {
+		case kind.WhileStatement, kind.ForStatement, kind.AnonymousFunction:
+			return true
+		}
+	}
+	return false
+}
+
+// needsParens reports whether a value of kind value must be parenthesized
+// to take the place of an identifier whose parent is of kind parent.
+func needsParens(value, parent string) bool {
+	switch value {
+	case kind.BinaryExpression, kind.UnaryExpression, kind.IfExpression, kind.MatchExpression, kind.AnonymousFunction, kind.CheckExpression:
+	default:
+		return false
+	}
+	switch parent {
+	case kind.BinaryExpression, kind.UnaryExpression, kind.MemberExpression, kind.IndexExpression, kind.CallExpression:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package refactor_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// inline runs InlineBinding on src at the position marked by «.
+func inline(t *testing.T, marked string) (string, error) {
+	t.Helper()
+	at := strings.Index(marked, "«")
+	src := strings.Replace(marked, "«", "", 1)
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	fixes, err := refactor.InlineBinding(tree, tree.Source(), edits.Point(tree.Source(), uint(at)))
+	if err != nil {
+		return "", err
+	}
+	return string(refactor.Apply(tree.Source(), fixes)), nil
+}
+
+func TestInlineBinding(t *testing.T) {
+	tests := []struct{ name, src, want string }{
+		{
+			"const",
+			"function f(a: i32) -> i32 {\n  const «b = a + 1; // one more\n  return b * b;\n}\n",
+			"function f(a: i32) -> i32 {\n  return (a + 1) * (a + 1);\n}\n",
+		},
+		{
+			"at a reference",
+			"function f(a: i32) -> i32 {\n  var b = a;\n  print(b);\n  return «b;\n}\n",
+			"function f(a: i32) -> i32 {\n  print(a);\n  return a;\n}\n",
+		},
+		{
+			"single call",
+			"function f() -> i32 {\n  const «b = g();\n  return b;\n}\n",
+			"function f() -> i32 {\n  return g();\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := inline(t, tt.src)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInlineBindingRefused(t *testing.T) {
+	tests := []struct{ name, src, want string }{
+		{"nothing", "function f() -> i32 {\n  return «1;\n}\n", refactor.ErrNoName.Error()},
+		{"parameter", "function f(«a: i32) -> i32 {\n  return a;\n}\n", "a is not a local binding"},
+		{"unused", "function f() -> Unit {\n  const «a = 1;\n}\n", "a is never used"},
+		{"assigned", "function f() -> i32 {\n  var «a = 1;\n  a = 2;\n  return a;\n}\n", "a is assigned at line 3"},
+		{"calls twice", "function f() -> i32 {\n  const «a = g();\n  return a + a;\n}\n", "calls functions"},
+		{"calls in a loop", "function f() -> Unit {\n  const «a = g();\n  while true {\n    print(a);\n  }\n}\n", "calls functions"},
+		{"reads assigned var", "function f() -> i32 {\n  var n = 1;\n  const «a = n;\n  n = 2;\n  return a;\n}\n", "reads n, which is assigned at line 4"},
+		{"shadowed", "function f(n: i32) -> i32 {\n  const «a = n;\n  if true {\n    const n = 2;\n    return a;\n  }\n  return 0;\n}\n", "n would denote something else at line 5"},
+	}
+	for _, tt := range tests {
+		_, err := inline(t, tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
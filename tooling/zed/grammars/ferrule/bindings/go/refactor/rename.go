@@ -0,0 +1,133 @@
+// Package refactor implements source-to-source refactorings of ferrule
+// files, for command line tools and language servers alike.
+//
+// Refactorings do not modify the source. They return the text edits that
+// carry them out, which callers apply with Apply or translate into
+// protocol edits.
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+// ErrNoName is returned when a refactoring is requested at a position that
+// holds no local name.
+var ErrNoName = errors.New("refactor: no local name at position")
+
+// Edit replaces the source covered by Range with NewText.
+type Edit struct {
+	Range   tree_sitter.Range
+	NewText string
+}
+
+// Apply returns src with the edits applied. The edits must not overlap.
+func Apply(src []byte, edits []Edit) []byte {
+	sorted := append([]Edit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Range.StartByte < sorted[j].Range.StartByte })
+	var out []byte
+	last := uint(0)
+	for _, e := range sorted {
+		out = append(out, src[last:e.Range.StartByte]...)
+		out = append(out, e.NewText...)
+		last = e.Range.EndByte
+	}
+	return append(out, src[last:]...)
+}
+
+// Rename returns the edits that rename the local name at p, either its
+// definition or one of its references, to newName. The edits cover the
+// definition and every reference to it in the file, in source order.
+//
+// Rename refuses, returning an error and no edits, when newName is not a
+// valid identifier, when it is already defined in the same scope, when an
+// inner definition of newName would hide the renamed name from one of its
+// references, or when the renamed name would in turn capture references to
+// an outer or unresolved newName.
+func Rename(tree *ferrule.Tree, p tree_sitter.Point, newName string) ([]Edit, error) {
+	if !validIdentifier(newName) {
+		return nil, fmt.Errorf("refactor: %q is not a valid identifier", newName)
+	}
+	info := scope.Resolve(tree)
+	def := info.ResolveAt(p)
+	if def == nil {
+		return nil, ErrNoName
+	}
+	if def.Name == newName {
+		return nil, nil
+	}
+
+	for _, d := range def.Scope.Definitions {
+		if d.Name == newName {
+			return nil, conflict(def, d, "already declared")
+		}
+	}
+	// every reference must still find the renamed definition first.
+	for _, r := range info.ReferencesOf(def) {
+		if d := info.ScopeAt(r.StartPosition()).Lookup(newName, r.StartByte()); d != nil && within(d.Scope, def.Scope) {
+			return nil, conflict(def, d, "would be shadowed by")
+		}
+	}
+	// no reference to another newName may find the renamed definition.
+	others := append([]*tree_sitter.Node(nil), info.Unresolved...)
+	for _, d := range info.Definitions {
+		if d.Name == newName {
+			others = append(others, info.ReferencesOf(d)...)
+		}
+	}
+	for _, r := range others {
+		if tree.Text(r) != newName {
+			continue
+		}
+		s := info.ScopeAt(r.StartPosition())
+		if !within(s, def.Scope) || !def.VisibleAt(r.StartByte()) {
+			continue
+		}
+		if d := s.Lookup(newName, r.StartByte()); d == nil || !within(d.Scope, def.Scope) {
+			return nil, fmt.Errorf("refactor: renaming %s to %s at line %d would capture the reference at line %d",
+				def.Name, newName, def.Node.StartPosition().Row+1, r.StartPosition().Row+1)
+		}
+	}
+
+	edits := []Edit{{Range: def.Node.Range(), NewText: newName}}
+	for _, r := range info.ReferencesOf(def) {
+		edits = append(edits, Edit{Range: r.Range(), NewText: newName})
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Range.StartByte < edits[j].Range.StartByte })
+	return edits, nil
+}
+
+func conflict(def, other *scope.Definition, what string) error {
+	return fmt.Errorf("refactor: renaming %s at line %d: %s %s at line %d",
+		def.Name, def.Node.StartPosition().Row+1, what, other.Name, other.Node.StartPosition().Row+1)
+}
+
+// within reports whether s is outer or one of its descendants.
+func within(s, outer *scope.Scope) bool {
+	for ; s != nil; s = s.Parent {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// validIdentifier reports whether name is a lower-case identifier that is
+// not a keyword of the language.
+func validIdentifier(name string) bool {
+	for i, r := range name {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z':
+		case i > 0 && ('A' <= r && r <= 'Z' || '0' <= r && r <= '9'):
+		default:
+			return false
+		}
+	}
+	return name != "" && ferrule.Language().IdForNodeKind(name, false) == 0
+}
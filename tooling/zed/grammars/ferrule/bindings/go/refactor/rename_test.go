@@ -0,0 +1,84 @@
+package refactor_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+const source = `function main(n: u32) -> u32 {
+  const total = n + 1;
+  const other = 2;
+  if total > 1 {
+    const inner = total;
+    print(inner + total + n);
+  }
+  return total * other;
+}
+`
+
+func TestRename(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	edits, err := refactor.Rename(tree, tree_sitter.Point{Row: 3, Column: 5}, "sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 5 {
+		t.Errorf("got %d edits, want 5", len(edits))
+	}
+	got := string(refactor.Apply(tree.Source(), edits))
+	if want := strings.ReplaceAll(source, "total", "sum"); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// renaming a name to itself is a no-op.
+	if edits, err := refactor.Rename(tree, tree_sitter.Point{Row: 1, Column: 8}, "total"); err != nil || edits != nil {
+		t.Errorf("rename to itself = %v, %v", edits, err)
+	}
+}
+
+func TestRenameRefused(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	for _, tt := range []struct {
+		name    string
+		p       tree_sitter.Point
+		newName string
+		want    string
+	}{
+		{"keyword", tree_sitter.Point{Row: 1, Column: 8}, "match", "not a valid identifier"},
+		{"type name", tree_sitter.Point{Row: 1, Column: 8}, "Total", "not a valid identifier"},
+		{"same scope", tree_sitter.Point{Row: 1, Column: 8}, "other", "already declared"},
+		{"shadowed", tree_sitter.Point{Row: 1, Column: 8}, "inner", "would be shadowed by inner"},
+		{"captures outer", tree_sitter.Point{Row: 4, Column: 10}, "n", "would capture"},
+		{"captures unresolved", tree_sitter.Point{Row: 2, Column: 8}, "print", "would capture the reference at line 6"},
+		{"no name", tree_sitter.Point{Row: 5, Column: 5}, "x", refactor.ErrNoName.Error()},
+	} {
+		edits, err := refactor.Rename(tree, tt.p, tt.newName)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want %q", tt.name, err, tt.want)
+		}
+		if edits != nil {
+			t.Errorf("%s: got edits %v", tt.name, edits)
+		}
+	}
+
+	if _, err := refactor.Rename(tree, tree_sitter.Point{Row: 0, Column: 0}, "x"); !errors.Is(err, refactor.ErrNoName) {
+		t.Errorf("rename of a keyword: %v, want ErrNoName", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package rewrite implements codemods: rules that replace the code matching
+// a structural pattern with a template.
+//
+// A rule pairs a pattern, as described in package pattern, with a
+// replacement in which $name and $$$name stand for the code bound to the
+// metavariables of the pattern:
+//
+//	old_api($x, $$$rest)  ==>  new_api(ctx, $x, $$$rest)
+//
+// Bound code is copied verbatim, comments and layout included. Comments in
+// the matched code outside of any metavariable used by the replacement
+// would otherwise be lost, so they are kept in front of it.
+//
+// Like the refactorings of package refactor, rewriting does not modify the
+// source but returns the edits that carry it out.
+package rewrite
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/pattern"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+)
+
+// Rule is a pattern and its replacement.
+type Rule struct {
+	Pattern     *pattern.Pattern
+	Replacement string
+}
+
+var metavariable = regexp.MustCompile(`\$\$\$([A-Za-z_][A-Za-z0-9_]*)|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// NewRule compiles a rule. Every metavariable of the replacement must be
+// bound by the pattern.
+func NewRule(pat, replacement string) (*Rule, error) {
+	p, err := pattern.Compile(pat)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metavariable.FindAllStringSubmatch(replacement, -1) {
+		name := m[1] + m[2]
+		if !slices.Contains(p.Metavariables(), name) {
+			p.Close()
+			return nil, fmt.Errorf("rewrite: %s in replacement %q is not bound by pattern %q", m[0], replacement, pat)
+		}
+	}
+	return &Rule{Pattern: p, Replacement: replacement}, nil
+}
+
+// Close releases the resources held by the rule.
+func (r *Rule) Close() { r.Pattern.Close() }
+
+// ParseRules reads a file of rules. Each rule is a pattern, a line holding
+// only ==>, and the replacement; rules are separated by lines holding only
+// ---. Both the pattern and the replacement may span several lines, and
+// leading and trailing blank lines around them are ignored.
+func ParseRules(data []byte) ([]*Rule, error) {
+	var rules []*Rule
+	fail := func(err error) ([]*Rule, error) {
+		for _, r := range rules {
+			r.Close()
+		}
+		return nil, err
+	}
+	chunks := splitLines(string(data), "---")
+	for i, chunk := range chunks {
+		if strings.TrimSpace(chunk) == "" && i == len(chunks)-1 {
+			break
+		}
+		parts := splitLines(chunk, "==>")
+		if len(parts) != 2 {
+			return fail(fmt.Errorf("rewrite: rule %d: want pattern ==> replacement", i+1))
+		}
+		r, err := NewRule(strings.TrimSpace(parts[0]), strings.Trim(parts[1], "\n"))
+		if err != nil {
+			return fail(fmt.Errorf("rule %d: %w", i+1, err))
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// splitLines splits s around the lines that consist of sep alone.
+func splitLines(s, sep string) []string {
+	var out []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if strings.TrimSpace(line) == sep {
+			out = append(out, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteString(line)
+	}
+	return append(out, cur.String())
+}
+
+// Conflict is a match that was not rewritten because it overlaps code
+// already rewritten by another match.
+type Conflict struct {
+	Rule  *Rule
+	Range tree_sitter.Range
+	// With is the rule of the match that was rewritten instead.
+	With *Rule
+}
+
+// Rewrite returns the edits that apply rules to tree, in source order,
+// and the matches left out because they overlap others.
+//
+// Matches are considered in source order, the outermost first and, for the
+// same code, in the order of the rules. A match overlapping one already
+// accepted is a conflict. Rewriting the result again will apply the rules
+// to code that was nested in a rewritten match.
+func Rewrite(tree *ferrule.Tree, rules []*Rule) ([]refactor.Edit, []Conflict) {
+	type candidate struct {
+		rule  *Rule
+		order int
+		match pattern.Match
+	}
+	var cands []candidate
+	for i, r := range rules {
+		for _, m := range r.Pattern.Find(tree) {
+			cands = append(cands, candidate{r, i, m})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := cands[i].match.Node, cands[j].match.Node
+		if a.StartByte() != b.StartByte() {
+			return a.StartByte() < b.StartByte()
+		}
+		if a.EndByte() != b.EndByte() {
+			return a.EndByte() > b.EndByte()
+		}
+		return cands[i].order < cands[j].order
+	})
+
+	src := tree.Source()
+	var edits []refactor.Edit
+	var conflicts []Conflict
+	var last *candidate
+	for i := range cands {
+		c := &cands[i]
+		if last != nil && c.match.Node.StartByte() < last.match.Node.EndByte() {
+			conflicts = append(conflicts, Conflict{Rule: c.rule, Range: c.match.Node.Range(), With: last.rule})
+			continue
+		}
+		last = c
+		text := expand(c.rule.Replacement, c.match, src)
+		if text == c.match.Node.Utf8Text(src) {
+			continue
+		}
+		edits = append(edits, refactor.Edit{Range: c.match.Node.Range(), NewText: text})
+	}
+	return edits, conflicts
+}
+
+// expand instantiates the replacement for match m, keeping the comments
+// that the replacement would drop.
+func expand(replacement string, m pattern.Match, src []byte) string {
+	var kept []pattern.Binding
+	text := metavariable.ReplaceAllStringFunc(replacement, func(s string) string {
+		b := m.Bindings[strings.TrimLeft(s, "$")]
+		kept = append(kept, b)
+		return b.Text(src)
+	})
+
+	var comments bytes.Buffer
+	indent := indentation(src, m.Node.StartByte())
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		for _, b := range kept {
+			if b.StartByte <= n.StartByte() && n.EndByte() <= b.EndByte {
+				return
+			}
+		}
+		switch n.Kind() {
+		case kind.LineComment:
+			comments.WriteString(n.Utf8Text(src) + "\n" + indent)
+			return
+		case kind.BlockComment:
+			comments.WriteString(n.Utf8Text(src) + " ")
+			return
+		}
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(m.Node)
+	return comments.String() + text
+}
+
+// indentation returns the white space that starts the line holding offset.
+func indentation(src []byte, offset uint) string {
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := start
+	for end < int(offset) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
@@ -0,0 +1,96 @@
+package rewrite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/refactor"
+	"github.com/karol-broda/ferrule/bindings/go/rewrite"
+)
+
+func apply(t *testing.T, src string, rules []*rewrite.Rule) (string, []rewrite.Conflict) {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	edits, conflicts := rewrite.Rewrite(tree, rules)
+	return string(refactor.Apply(tree.Source(), edits)), conflicts
+}
+
+func rule(t *testing.T, pat, repl string) *rewrite.Rule {
+	t.Helper()
+	r, err := rewrite.NewRule(pat, repl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(r.Close)
+	return r
+}
+
+func TestRewrite(t *testing.T) {
+	src := `function main() -> Unit {
+  old_api(a, b, c);
+  old_api(
+    x, // the target
+    y
+  );
+  check old_api(z, w);
+}
+`
+	want := `function main() -> Unit {
+  new_api(ctx, a, b, c);
+  // the target
+  new_api(ctx, x, y);
+  check new_api(ctx, z, w);
+}
+`
+	got, conflicts := apply(t, src, []*rewrite.Rule{rule(t, "old_api($x, $$$rest)", "new_api(ctx, $x, $$$rest)")})
+	if got != want || len(conflicts) != 0 {
+		t.Errorf("got:\n%s\nwant:\n%s\nconflicts %v", got, want, conflicts)
+	}
+}
+
+func TestRewriteKeepsComments(t *testing.T) {
+	src := "function main() -> Unit {\n  const v = pair(/* left */ a, b);\n}\n"
+	want := "function main() -> Unit {\n  const v = /* left */ a;\n}\n"
+	if got, _ := apply(t, src, []*rewrite.Rule{rule(t, "pair($a, $_)", "$a")}); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRewriteConflicts(t *testing.T) {
+	src := "function main() -> Unit {\n  f(f(1));\n}\n"
+	outer := rule(t, "f($x)", "g($x)")
+	inner := rule(t, "f(1)", "1")
+	got, conflicts := apply(t, src, []*rewrite.Rule{inner, outer})
+	if want := "function main() -> Unit {\n  g(f(1));\n}\n"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if len(conflicts) != 2 || conflicts[0].Rule != inner || conflicts[1].Rule != outer || conflicts[0].With != outer || conflicts[1].With != outer {
+		t.Errorf("conflicts %+v", conflicts)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := rewrite.ParseRules([]byte("old($x)\n==>\nnew($x)\n---\n\nlog($$$args)\n==>\ntrace($$$args)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, r := range rules {
+			r.Close()
+		}
+	}()
+	if len(rules) != 2 || rules[0].Pattern.String() != "old($x)" || rules[1].Replacement != "trace($$$args)" {
+		t.Fatalf("rules %+v", rules)
+	}
+
+	for _, bad := range []string{"old($x)\nnew($x)\n", "old($x)\n==>\nnew($y)\n"} {
+		if _, err := rewrite.ParseRules([]byte(bad)); err == nil {
+			t.Errorf("%q parsed", bad)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package scip
+
+import "encoding/binary"
+
+// The messages below mirror the subset of scip.proto that the emitter
+// fills in, with the field numbers of the schema. They are encoded by hand
+// to keep the module free of a protobuf runtime.
+
+// Index is the root message of a SCIP index.
+type Index struct {
+	Metadata  Metadata
+	Documents []Document
+}
+
+// Metadata describes the tool and project an index was produced for.
+type Metadata struct {
+	ToolInfo    ToolInfo
+	ProjectRoot string
+}
+
+// ToolInfo names the indexer.
+type ToolInfo struct {
+	Name      string
+	Version   string
+	Arguments []string
+}
+
+// Document is the index of one source file.
+type Document struct {
+	RelativePath string
+	Language     string
+	Occurrences  []Occurrence
+	Symbols      []SymbolInformation
+}
+
+// Occurrence is a use or definition of a symbol at a range of a document.
+// Range holds the start line, start character, end line and end character,
+// zero-based; the end line is omitted when it equals the start line.
+// Characters are UTF-8 code units.
+type Occurrence struct {
+	Range       []int32
+	Symbol      string
+	SymbolRoles int32
+}
+
+// Symbol roles of an occurrence, as a bit set.
+const (
+	RoleDefinition int32 = 0x1
+	RoleImport     int32 = 0x2
+)
+
+// SymbolInformation documents a symbol defined in a document.
+type SymbolInformation struct {
+	Symbol        string
+	Documentation []string
+	DisplayName   string
+	// EnclosingSymbol is the symbol of the definition containing this one.
+	EnclosingSymbol string
+}
+
+// Enum values of the schema used by the emitter.
+const (
+	textEncodingUTF8             = 1
+	positionEncodingUTF8FromLine = 1
+)
+
+// Marshal returns the protobuf encoding of x.
+func (x *Index) Marshal() []byte {
+	var e encoder
+	e.message(1, x.Metadata.marshal())
+	for i := range x.Documents {
+		e.message(2, x.Documents[i].marshal())
+	}
+	return e.buf
+}
+
+func (m *Metadata) marshal() []byte {
+	// the version is left at UnspecifiedProtocolVersion, the only value
+	// the schema defines.
+	var e, t encoder
+	t.string(1, m.ToolInfo.Name)
+	t.string(2, m.ToolInfo.Version)
+	for _, a := range m.ToolInfo.Arguments {
+		t.string(3, a)
+	}
+	e.message(2, t.buf)
+	e.string(3, m.ProjectRoot)
+	e.varint(4, textEncodingUTF8)
+	return e.buf
+}
+
+func (d *Document) marshal() []byte {
+	var e encoder
+	e.string(1, d.RelativePath)
+	for i := range d.Occurrences {
+		e.message(2, d.Occurrences[i].marshal())
+	}
+	for i := range d.Symbols {
+		e.message(3, d.Symbols[i].marshal())
+	}
+	e.string(4, d.Language)
+	e.varint(6, positionEncodingUTF8FromLine)
+	return e.buf
+}
+
+func (o *Occurrence) marshal() []byte {
+	var e encoder
+	var packed []byte
+	for _, v := range o.Range {
+		packed = binary.AppendUvarint(packed, uint64(uint32(v)))
+	}
+	e.bytes(1, packed)
+	e.string(2, o.Symbol)
+	e.varint(3, uint64(uint32(o.SymbolRoles)))
+	return e.buf
+}
+
+func (s *SymbolInformation) marshal() []byte {
+	var e encoder
+	e.string(1, s.Symbol)
+	for _, d := range s.Documentation {
+		e.string(3, d)
+	}
+	e.string(6, s.DisplayName)
+	e.string(8, s.EnclosingSymbol)
+	return e.buf
+}
+
+// encoder appends protobuf fields to buf, omitting those at their zero
+// value as proto3 does.
+type encoder struct {
+	buf []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (e *encoder) tag(field, wire int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field<<3|wire))
+}
+
+func (e *encoder) varint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(field int, s string) { e.bytes(field, []byte(s)) }
+
+// message encodes an embedded message. Unlike other fields an empty
+// message is still written, since its presence is significant.
+func (e *encoder) message(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
@@ -0,0 +1,149 @@
+// Package scip emits SCIP indexes of ferrule projects, for code
+// navigation on platforms such as Sourcegraph.
+//
+// The index is derived from a project index of package index and covers
+// what it knows without type checking: definitions listed in the file
+// outlines, calls of functions whose name resolves in the calling package
+// or one it imports, and import declarations. Symbols use the scheme
+// "scip-ferrule" with the package path as namespace, as in
+//
+//	scip-ferrule . . . app/server/Server#start().
+package scip
+
+import (
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Scheme is the symbol scheme of the emitted symbols.
+const Scheme = "scip-ferrule"
+
+// Build returns the SCIP index of idx. toolVersion is recorded in the
+// metadata, and projectRoot, a file:// URI, as the root the document paths
+// are relative to.
+func Build(idx *index.Index, projectRoot, toolVersion string) *Index {
+	x := &Index{Metadata: Metadata{
+		ToolInfo:    ToolInfo{Name: "ferrule-scip", Version: toolVersion},
+		ProjectRoot: projectRoot,
+	}}
+
+	// globals maps a package and a definition name to the symbols so
+	// named, for resolving calls.
+	globals := make(map[string]map[string][]string)
+	files := idx.Files()
+	for _, f := range files {
+		for _, d := range f.Definitions {
+			if d.Kind == symbols.Package {
+				continue
+			}
+			if globals[f.Package] == nil {
+				globals[f.Package] = make(map[string][]string)
+			}
+			globals[f.Package][d.Name] = append(globals[f.Package][d.Name], symbolOf(f, d))
+		}
+	}
+
+	for _, f := range files {
+		doc := Document{RelativePath: f.Path, Language: "ferrule"}
+		for _, d := range f.Definitions {
+			sym := symbolOf(f, d)
+			doc.Occurrences = append(doc.Occurrences, Occurrence{
+				Range:       rangeOf(d.SelectionRange),
+				Symbol:      sym,
+				SymbolRoles: RoleDefinition,
+			})
+			info := SymbolInformation{Symbol: sym, DisplayName: d.Name}
+			if d.Container != "" {
+				info.EnclosingSymbol = containerSymbol(f, d)
+			}
+			doc.Symbols = append(doc.Symbols, info)
+		}
+		for _, imp := range f.Imports {
+			doc.Occurrences = append(doc.Occurrences, Occurrence{
+				Range:       rangeOf(imp.Range),
+				Symbol:      namespace(imp.Path),
+				SymbolRoles: RoleImport,
+			})
+		}
+		for _, r := range f.References {
+			candidates := globals[f.Package][r.Name]
+			if len(candidates) == 0 {
+				for _, imp := range f.Imports {
+					candidates = append(candidates, globals[imp.Path][r.Name]...)
+				}
+			}
+			if len(candidates) != 1 {
+				continue
+			}
+			doc.Occurrences = append(doc.Occurrences, Occurrence{Range: rangeOf(r.Range), Symbol: candidates[0]})
+		}
+		x.Documents = append(x.Documents, doc)
+	}
+	return x
+}
+
+// namespace returns the symbol of a package.
+func namespace(pkg string) string {
+	s := Scheme + " . . . "
+	if pkg == "" {
+		return s
+	}
+	return s + strings.ReplaceAll(pkg, ".", "/") + "/"
+}
+
+// symbolOf returns the symbol of the definition d of file f.
+func symbolOf(f *index.File, d index.Definition) string {
+	if d.Kind == symbols.Package {
+		return namespace(d.Name)
+	}
+	prefix := namespace(f.Package)
+	if d.Container != "" {
+		prefix = containerSymbol(f, d)
+	}
+	return prefix + descriptor(d.Name, d.Kind)
+}
+
+// containerSymbol returns the symbol of the definition enclosing d.
+func containerSymbol(f *index.File, d index.Definition) string {
+	for _, c := range f.Definitions {
+		if c.Name == d.Container && c.Range.StartByte <= d.Range.StartByte && d.Range.EndByte <= c.Range.EndByte && c.Range != d.Range {
+			return symbolOf(f, c)
+		}
+	}
+	return namespace(f.Package) + descriptor(d.Container, symbols.Module)
+}
+
+// descriptor returns the SCIP descriptor of a name: a type for type-like
+// definitions, a method for functions and a term otherwise.
+func descriptor(name string, k symbols.Kind) string {
+	name = escape(name)
+	switch k {
+	case symbols.Function, symbols.Method:
+		return name + "()."
+	case symbols.Module, symbols.Class, symbols.Struct, symbols.Enum, symbols.Interface, symbols.TypeAlias:
+		return name + "#"
+	}
+	return name + "."
+}
+
+// escape backquotes names that are not simple identifiers.
+func escape(name string) string {
+	for _, r := range name {
+		if !(r == '_' || r == '+' || r == '-' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+	return name
+}
+
+func rangeOf(r tree_sitter.Range) []int32 {
+	start, end := r.StartPoint, r.EndPoint
+	if start.Row == end.Row {
+		return []int32{int32(start.Row), int32(start.Column), int32(end.Column)}
+	}
+	return []int32{int32(start.Row), int32(start.Column), int32(end.Row), int32(end.Column)}
+}
@@ -0,0 +1,98 @@
+package scip_test
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/scip"
+)
+
+func TestBuild(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"server.fe": "package app.server;\nimport app.util;\n\ncomponent Server {\n  function start() -> Unit {\n    help();\n  }\n}\n",
+		"util.fe":   "package app.util;\n\nfunction help() -> Unit {}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := scip.Build(idx, "file:///project", "v1")
+	if len(x.Documents) != 2 || x.Documents[0].RelativePath != "server.fe" {
+		t.Fatalf("documents %+v", x.Documents)
+	}
+
+	doc := x.Documents[0]
+	var got []string
+	for _, o := range doc.Occurrences {
+		got = append(got, o.Symbol)
+	}
+	want := []string{
+		"scip-ferrule . . . app/server/",
+		"scip-ferrule . . . app/server/Server#",
+		"scip-ferrule . . . app/server/Server#start().",
+		"scip-ferrule . . . app/util/",
+		"scip-ferrule . . . app/util/help().",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("occurrences:\n%q\nwant:\n%q", got, want)
+	}
+	if o := doc.Occurrences[4]; !reflect.DeepEqual(o.Range, []int32{5, 4, 8}) || o.SymbolRoles != 0 {
+		t.Errorf("call occurrence %+v", o)
+	}
+	if o := doc.Occurrences[3]; o.SymbolRoles != scip.RoleImport {
+		t.Errorf("import occurrence %+v", o)
+	}
+	if s := doc.Symbols[2]; s.EnclosingSymbol != "scip-ferrule . . . app/server/Server#" {
+		t.Errorf("start has enclosing symbol %q", s.EnclosingSymbol)
+	}
+
+	fields := decode(t, x.Marshal())
+	if len(fields[1]) != 1 || len(fields[2]) != 2 {
+		t.Fatalf("index fields %v", fields)
+	}
+	meta := decode(t, fields[1][0])
+	if string(meta[3][0]) != "file:///project" || string(decode(t, meta[2][0])[1][0]) != "ferrule-scip" {
+		t.Errorf("metadata %q", meta)
+	}
+	if d := decode(t, fields[2][0]); string(d[1][0]) != "server.fe" || len(d[2]) != 5 || len(d[3]) != 3 {
+		t.Errorf("document fields: path %q, %d occurrences, %d symbols", d[1][0], len(d[2]), len(d[3]))
+	}
+}
+
+// decode splits a protobuf message into its length-delimited fields,
+// skipping varints.
+func decode(t *testing.T, b []byte) map[int][][]byte {
+	t.Helper()
+	out := make(map[int][][]byte)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("bad key")
+		}
+		b = b[n:]
+		switch key & 7 {
+		case 0:
+			_, n = binary.Uvarint(b)
+			b = b[n:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			b = b[n:]
+			out[int(key>>3)] = append(out[int(key>>3)], b[:l])
+			b = b[l:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return out
+}
@@ -0,0 +1,277 @@
+// Package scope resolves the local names of a ferrule file: it builds the
+// tree of scopes described by the bundled locals query and binds every
+// reference to the definition it denotes.
+//
+// The rules follow the usual tree-sitter locals conventions. A definition
+// belongs to the innermost @local.scope that contains it, except that a
+// function is defined in the scope around its declaration. Functions and
+// top-level names are visible throughout their scope; any other name is
+// visible from the end of its declaration, so a later definition of the
+// same name in one block hides the earlier one from that point on. A
+// reference resolves to the nearest visible definition in its scope or an
+// enclosing one, and is left unresolved when there is none.
+package scope
+
+import (
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var locals = query.MustCompile(string(queries.Locals))
+
+// Scope is a region of the file in which names are defined.
+type Scope struct {
+	// Node is the node that opens the scope; the file scope's node is the
+	// root of the tree.
+	Node     *tree_sitter.Node
+	Parent   *Scope
+	Children []*Scope
+	// Definitions lists the names defined directly in the scope, in source
+	// order.
+	Definitions []*Definition
+}
+
+// Definition is a name introduced by a @local.definition capture.
+type Definition struct {
+	Name string
+	// Kind is the capture suffix: "function", "parameter" or "var".
+	Kind string
+	// Node is the defining identifier.
+	Node  *tree_sitter.Node
+	Scope *Scope
+	// References lists the identifiers that resolve to the definition, in
+	// source order.
+	References []*tree_sitter.Node
+
+	// visibleFrom is the byte offset from which references may resolve to
+	// the definition; zero for hoisted names.
+	visibleFrom uint
+}
+
+// Info is the result of resolving a file. The nodes it holds belong to the
+// tree it was built from and share its lifetime.
+type Info struct {
+	Root *Scope
+	// Definitions lists every definition in source order.
+	Definitions []*Definition
+	// Unresolved lists the references that denote no local name, such as
+	// imported or built-in functions, in source order.
+	Unresolved []*tree_sitter.Node
+
+	tree *ferrule.Tree
+	// byStart maps the start byte of each definition and resolved
+	// reference to its definition.
+	byStart map[uint]*Definition
+}
+
+// Resolve builds the scopes of tree and resolves its references.
+func Resolve(tree *ferrule.Tree) *Info {
+	root := tree.RootNode()
+	info := &Info{Root: &Scope{Node: root}, tree: tree, byStart: make(map[uint]*Definition)}
+
+	var scopes []*tree_sitter.Node
+	var refs []*tree_sitter.Node
+	type def struct {
+		node *tree_sitter.Node
+		kind string
+	}
+	var defs []def
+	for c, n := range locals.Matches(tree.Root(), tree.Source()) {
+		switch {
+		case c.Name == "local.scope":
+			scopes = append(scopes, n.Raw())
+		case c.Name == "local.reference":
+			refs = append(refs, n.Raw())
+		case strings.HasPrefix(c.Name, "local.definition."):
+			defs = append(defs, def{n.Raw(), strings.TrimPrefix(c.Name, "local.definition.")})
+		}
+	}
+
+	// Matches come in order of start byte; an outer scope starting at the
+	// same byte as an inner one must come first.
+	sort.SliceStable(scopes, func(i, j int) bool {
+		if scopes[i].StartByte() != scopes[j].StartByte() {
+			return scopes[i].StartByte() < scopes[j].StartByte()
+		}
+		return scopes[i].EndByte() > scopes[j].EndByte()
+	})
+	for _, n := range scopes {
+		if n.StartByte() == root.StartByte() && n.EndByte() == root.EndByte() && n.Kind() == root.Kind() {
+			continue
+		}
+		parent := info.Root.innermost(n.StartByte(), n.EndByte())
+		parent.Children = append(parent.Children, &Scope{Node: n, Parent: parent})
+	}
+
+	for _, d := range defs {
+		if _, dup := info.byStart[d.node.StartByte()]; dup {
+			continue
+		}
+		s := info.Root.innermost(d.node.StartByte(), d.node.EndByte())
+		if d.kind == "function" && s.Parent != nil && s.Node.Id() == d.node.Parent().Id() {
+			s = s.Parent
+		}
+		def := &Definition{
+			Name:  tree.Text(d.node),
+			Kind:  d.kind,
+			Node:  d.node,
+			Scope: s,
+		}
+		if d.kind != "function" && s != info.Root {
+			def.visibleFrom = visibleFrom(d.node)
+		}
+		s.Definitions = append(s.Definitions, def)
+		info.Definitions = append(info.Definitions, def)
+		info.byStart[d.node.StartByte()] = def
+	}
+
+	for _, r := range refs {
+		if _, isDef := info.byStart[r.StartByte()]; isDef || !isReference(r) {
+			continue
+		}
+		if d := info.Root.innermost(r.StartByte(), r.EndByte()).Lookup(tree.Text(r), r.StartByte()); d != nil {
+			d.References = append(d.References, r)
+			info.byStart[r.StartByte()] = d
+		} else {
+			info.Unresolved = append(info.Unresolved, r)
+		}
+	}
+	return info
+}
+
+// VisibleAt reports whether references at byte offset off may resolve to
+// d, provided they lie within its scope.
+func (d *Definition) VisibleAt(off uint) bool { return d.visibleFrom <= off }
+
+// ResolveAt returns the definition of the identifier at p, which may be
+// the defining identifier itself or a reference to it. It returns nil when
+// there is no identifier at p or it does not resolve to a local name.
+func (info *Info) ResolveAt(p tree_sitter.Point) *Definition {
+	n := info.tree.RootNode().NamedDescendantForPointRange(p, p)
+	if n == nil || n.Kind() != kind.Identifier {
+		return nil
+	}
+	return info.byStart[n.StartByte()]
+}
+
+// ReferencesOf returns the references to d, in source order.
+func (info *Info) ReferencesOf(d *Definition) []*tree_sitter.Node {
+	return d.References
+}
+
+// ScopeAt returns the innermost scope that contains p.
+func (info *Info) ScopeAt(p tree_sitter.Point) *Scope {
+	s := info.Root
+	for {
+		next := (*Scope)(nil)
+		for _, c := range s.Children {
+			if pointIn(p, c.Node) {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return s
+		}
+		s = next
+	}
+}
+
+// Lookup returns the definition that name denotes at byte offset off
+// within s, searching the enclosing scopes as references do.
+func (s *Scope) Lookup(name string, off uint) *Definition {
+	for ; s != nil; s = s.Parent {
+		if d := s.lookup(name, off); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// lookup returns the last definition of name in s that is visible at off.
+func (s *Scope) lookup(name string, off uint) *Definition {
+	var found *Definition
+	for _, d := range s.Definitions {
+		if d.Name == name && d.VisibleAt(off) {
+			found = d
+		}
+	}
+	return found
+}
+
+// innermost returns the deepest scope under s that contains the byte range.
+func (s *Scope) innermost(start, end uint) *Scope {
+	for {
+		next := (*Scope)(nil)
+		for _, c := range s.Children {
+			if c.Node.StartByte() <= start && end <= c.Node.EndByte() {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return s
+		}
+		s = next
+	}
+}
+
+// visibleFrom returns the offset from which the name defined by ident can
+// be referred to.
+func visibleFrom(ident *tree_sitter.Node) uint {
+	parent := ident.Parent()
+	switch parent.Kind() {
+	case kind.ConstDeclaration:
+		return parent.EndByte()
+	case kind.ForStatement:
+		// the iterable is evaluated outside the loop variable's reach.
+		if iterable := parent.NamedChild(1); iterable != nil {
+			return iterable.EndByte()
+		}
+	}
+	return ident.EndByte()
+}
+
+// isReference reports whether the identifier n may refer to a local name.
+// Member properties, record field names and the identifiers inside
+// declarations that cannot mention locals are not references.
+func isReference(n *tree_sitter.Node) bool {
+	parent := n.Parent()
+	if parent == nil {
+		return true
+	}
+	switch parent.Kind() {
+	case kind.MemberExpression:
+		if first := parent.NamedChild(0); first == nil || first.Id() != n.Id() {
+			return false
+		}
+	case kind.RecordExpression:
+		if next := n.NextSibling(); next != nil && next.Kind() == ":" {
+			return false
+		}
+	}
+	for a := parent; a != nil; a = a.Parent() {
+		switch a.Kind() {
+		case kind.PackageDeclaration, kind.ImportDeclaration, kind.TypeDeclaration, kind.DomainDeclaration,
+			kind.ErrorDeclaration, kind.CapabilityDeclaration, kind.UseDeclaration:
+			return false
+		}
+	}
+	return true
+}
+
+func pointIn(p tree_sitter.Point, n *tree_sitter.Node) bool {
+	start, end := n.StartPosition(), n.EndPosition()
+	return !less(p, start) && !less(end, p)
+}
+
+func less(a, b tree_sitter.Point) bool {
+	return a.Row < b.Row || a.Row == b.Row && a.Column < b.Column
+}
@@ -0,0 +1,106 @@
+package scope_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+)
+
+const source = `const limit = 10;
+
+function main(n: u32) -> u32 {
+  const x = n + limit;
+  const x = x * 2;
+  for i in items(x) {
+    print(i);
+  }
+  return match x {
+    Point { a, b } -> a + b;
+    y -> y + helper(n);
+  };
+}
+
+function helper(v: u32) -> u32 {
+  return p.n + v;
+}
+`
+
+func parse(t *testing.T) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.HasError() {
+		t.Fatalf("source has syntax errors: %v", tree.Diagnostics())
+	}
+	return tree
+}
+
+func TestResolve(t *testing.T) {
+	tree := parse(t)
+	defer tree.Close()
+	info := scope.Resolve(tree)
+
+	var got []string
+	for _, d := range info.Definitions {
+		var rows []string
+		for _, r := range info.ReferencesOf(d) {
+			rows = append(rows, fmt.Sprint(r.StartPosition().Row+1))
+		}
+		got = append(got, fmt.Sprintf("%s %s %d: [%s]", d.Kind, d.Name, d.Node.StartPosition().Row+1, strings.Join(rows, " ")))
+	}
+	want := []string{
+		"var limit 1: [4]",
+		"function main 3: []",
+		"parameter n 3: [4 11]",
+		"var x 4: [5]",
+		"var x 5: [6 9]",
+		"var i 6: [7]",
+		"var a 10: [10]",
+		"var b 10: [10]",
+		"var y 11: [11]",
+		"function helper 15: [11]",
+		"parameter v 15: [16]",
+	}
+	if g, w := strings.Join(got, "\n"), strings.Join(want, "\n"); g != w {
+		t.Errorf("definitions:\n%s\nwant:\n%s", g, w)
+	}
+}
+
+func TestResolveAt(t *testing.T) {
+	tree := parse(t)
+	defer tree.Close()
+	info := scope.Resolve(tree)
+
+	for _, tt := range []struct {
+		p    tree_sitter.Point
+		want string // "name row" of the definition, or ""
+	}{
+		{tree_sitter.Point{Row: 4, Column: 12}, "x 4"},  // x in x * 2
+		{tree_sitter.Point{Row: 4, Column: 8}, "x 5"},   // the second x itself
+		{tree_sitter.Point{Row: 10, Column: 20}, "n 3"}, // n in helper(n)
+		{tree_sitter.Point{Row: 15, Column: 11}, ""},    // n in p.n
+		{tree_sitter.Point{Row: 15, Column: 9}, ""},     // p is not defined
+		{tree_sitter.Point{Row: 2, Column: 0}, ""},      // keyword
+		{tree_sitter.Point{Row: 10, Column: 13}, "helper 15"},
+	} {
+		got := ""
+		if d := info.ResolveAt(tt.p); d != nil {
+			got = fmt.Sprintf("%s %d", d.Name, d.Node.StartPosition().Row+1)
+		}
+		if got != tt.want {
+			t.Errorf("ResolveAt(%v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+
+	if s := info.ScopeAt(tree_sitter.Point{Row: 6, Column: 4}); s.Node.Kind() != "block" || s.Parent.Node.Kind() != "for_statement" {
+		t.Errorf("ScopeAt in loop body = %s in %s", s.Node.Kind(), s.Parent.Node.Kind())
+	}
+}
@@ -0,0 +1,99 @@
+// Package selection computes the nested ranges an editor grows a selection
+// through, as the LSP selectionRange request asks for: a name, the call it
+// is an argument of, the statement, the block and the function around it,
+// and so on up to the whole file.
+//
+// Each range is a node of the tree enclosing the one before. Nodes that
+// would only add punctuation to the range before, such as the parentheses
+// of an argument list with a single argument or the semicolon ending a
+// statement, are skipped, as are single punctuation tokens, so that each
+// step selects something new worth selecting.
+package selection
+
+import (
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+)
+
+// Range is a selection and the larger one it expands to, nil for the
+// whole file.
+type Range struct {
+	Range  tree_sitter.Range
+	Parent *Range
+}
+
+// Expand returns, for each of ranges, the smallest selection containing it
+// and, through Parent, the ones it expands to. Only the byte offsets of
+// ranges are used. A selection can be equal to the range it was computed
+// for; an editor growing an existing selection moves on to its Parent.
+func Expand(tree *ferrule.Tree, ranges []tree_sitter.Range) []*Range {
+	out := make([]*Range, len(ranges))
+	for i, r := range ranges {
+		out[i] = expand(tree, r.StartByte, r.EndByte)
+	}
+	return out
+}
+
+func expand(tree *ferrule.Tree, start, end uint) *Range {
+	root := tree.RootNode()
+	n := root.DescendantForByteRange(start, end)
+	if start == end && start > 0 && punctuation(n) {
+		// a cursor between a name and the punctuation after it selects
+		// the name.
+		if prev := root.DescendantForByteRange(start-1, start-1); prev.EndByte() == start && !punctuation(prev) {
+			n = prev
+		}
+	}
+
+	var chain []tree_sitter.Range
+	for child := (*tree_sitter.Node)(nil); n != nil; child, n = n, n.Parent() {
+		switch {
+		case n.StartByte() == n.EndByte(), punctuation(n):
+			continue
+		case child != nil && onlyAdds(n, child):
+			continue
+		}
+		r := n.Range()
+		if len(chain) > 0 && chain[len(chain)-1].StartByte == r.StartByte && chain[len(chain)-1].EndByte == r.EndByte {
+			continue
+		}
+		chain = append(chain, r)
+	}
+
+	var sel *Range
+	for i := len(chain) - 1; i >= 0; i-- {
+		sel = &Range{Range: chain[i], Parent: sel}
+	}
+	return sel
+}
+
+// onlyAdds reports whether the children of n other than child, the one
+// the selection grows from, are all punctuation.
+func onlyAdds(n, child *tree_sitter.Node) bool {
+	if n.Parent() == nil {
+		// the file itself is always worth selecting.
+		return false
+	}
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if c.StartByte() == child.StartByte() && c.EndByte() == child.EndByte() {
+			continue
+		}
+		if !punctuation(c) && c.StartByte() != c.EndByte() {
+			return false
+		}
+	}
+	return true
+}
+
+// punctuations are the kinds of the tokens that are not worth selecting
+// on their own.
+var punctuations = map[string]bool{
+	"(": true, ")": true, "[": true, "]": true, "{": true, "}": true,
+	",": true, ";": true, ":": true, ".": true,
+}
+
+func punctuation(n *tree_sitter.Node) bool {
+	return !n.IsNamed() && punctuations[n.Kind()]
+}
@@ -0,0 +1,59 @@
+package selection_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/selection"
+)
+
+const source = `package app;
+
+function main(a: u32) -> Unit {
+  print(add(a, 1));
+}
+`
+
+func TestExpand(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	at := func(s string) tree_sitter.Range {
+		off := uint(strings.Index(source, s))
+		return tree_sitter.Range{StartByte: off, EndByte: off}
+	}
+	tests := []struct {
+		name string
+		r    tree_sitter.Range
+		want []string
+	}{
+		{"name", at("dd("), []string{"add", "add(a, 1)", "print(add(a, 1))", "function", "package"}},
+		// the statement adds only a semicolon, the block only braces.
+		{"after name", at("(add"), []string{"print", "print(add(a, 1))", "function", "package"}},
+		{"selection", tree_sitter.Range{StartByte: at("a, 1").StartByte, EndByte: at(", 1").StartByte + 3}, []string{"add(a, 1)", "print(add(a, 1))", "function", "package"}},
+	}
+	ranges := make([]tree_sitter.Range, len(tests))
+	for i, tt := range tests {
+		ranges[i] = tt.r
+	}
+	for i, sel := range selection.Expand(tree, ranges) {
+		var got []string
+		for ; sel != nil; sel = sel.Parent {
+			text := source[sel.Range.StartByte:sel.Range.EndByte]
+			if len(text) > 8 && strings.Contains(text, "\n") {
+				text = strings.Fields(text)[0]
+			}
+			got = append(got, text)
+		}
+		if strings.Join(got, " | ") != strings.Join(tests[i].want, " | ") {
+			t.Errorf("%s: %q, want %q", tests[i].name, got, tests[i].want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package semantictokens encodes highlighting as LSP semantic tokens.
+//
+// Tokens come from the bundled highlights query via package highlight and
+// are mapped onto the standard token types and modifiers of the protocol,
+// listed in Legend. The encoding is the one the protocol prescribes: five
+// integers per token giving the line delta, start character delta, length,
+// token type and modifier bit set, with positions counted in UTF-16 code
+// units. Tokens never span lines, so clients without multiline token
+// support are served too.
+package semantictokens
+
+import (
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+	"github.com/karol-broda/ferrule/bindings/go/position"
+)
+
+// Token types, as indexes into Legend.TokenTypes.
+const (
+	Namespace uint32 = iota
+	Type
+	Enum
+	EnumMember
+	Parameter
+	Variable
+	Property
+	Function
+	Keyword
+	Comment
+	String
+	Number
+	Operator
+)
+
+// Token modifiers, as bits of the modifier set.
+const (
+	Declaration uint32 = 1 << iota
+	Readonly
+	DefaultLibrary
+)
+
+// Legend is the SemanticTokensLegend to announce in the server
+// capabilities.
+var Legend = struct {
+	TokenTypes     []string `json:"tokenTypes"`
+	TokenModifiers []string `json:"tokenModifiers"`
+}{
+	TokenTypes: []string{
+		"namespace", "type", "enum", "enumMember", "parameter", "variable",
+		"property", "function", "keyword", "comment", "string", "number", "operator",
+	},
+	TokenModifiers: []string{"declaration", "readonly", "defaultLibrary"},
+}
+
+type mapping struct {
+	typ       uint32
+	modifiers uint32
+}
+
+// captures maps highlight capture names to token types. Captures missing
+// here use their closest parent; punctuation is deliberately absent.
+var captures = map[string]mapping{
+	"boolean":            {Keyword, 0},
+	"comment":            {Comment, 0},
+	"constant.builtin":   {Keyword, DefaultLibrary},
+	"constructor":        {EnumMember, 0},
+	"function":           {Function, 0},
+	"keyword":            {Keyword, 0},
+	"module":             {Namespace, 0},
+	"number":             {Number, 0},
+	"operator":           {Operator, 0},
+	"property":           {Property, 0},
+	"string":             {String, 0},
+	"type":               {Type, 0},
+	"type.builtin":       {Type, DefaultLibrary},
+	"type.definition":    {Type, Declaration},
+	"variable":           {Variable, 0},
+	"variable.builtin":   {Variable, DefaultLibrary},
+	"variable.parameter": {Parameter, Declaration},
+}
+
+func lookup(capture string) (mapping, bool) {
+	for capture != "" {
+		if m, ok := captures[capture]; ok {
+			return m, true
+		}
+		i := strings.LastIndexByte(capture, '.')
+		if i < 0 {
+			break
+		}
+		capture = capture[:i]
+	}
+	return mapping{}, false
+}
+
+// Encode returns the semantic tokens of the whole document, as the data of
+// a textDocument/semanticTokens/full response. lines is the index of the
+// tree's source.
+func Encode(tree *ferrule.Tree, lines *position.Index) []uint32 {
+	return encode(tree, lines, 0, uint(len(tree.Source())))
+}
+
+// EncodeRange returns the tokens overlapping r, as the data of a
+// textDocument/semanticTokens/range response.
+func EncodeRange(tree *ferrule.Tree, lines *position.Index, r edits.Range) []uint32 {
+	start, end := lines.Offset(position.Position(r.Start)), lines.Offset(position.Position(r.End))
+	return encode(tree, lines, start, end)
+}
+
+func encode(tree *ferrule.Tree, lines *position.Index, start, end uint) []uint32 {
+	e := encoder{src: tree.Source(), lines: lines}
+	for _, s := range highlight.Spans(tree) {
+		m, ok := lookup(s.Capture)
+		if !ok || s.End <= start || s.Start >= end {
+			continue
+		}
+		e.span(s.Start, s.End, m)
+	}
+	return e.data
+}
+
+// encoder converts spans, which come in order, to tokens relative to the
+// previous one.
+type encoder struct {
+	src   []byte
+	lines *position.Index
+	// lastLine and lastCol are where the previous token started.
+	lastLine, lastCol uint32
+	data              []uint32
+}
+
+// span emits the tokens for the bytes between start and end, one per line
+// and without surrounding whitespace.
+func (e *encoder) span(start, end uint, m mapping) {
+	for start < end {
+		lineEnd := start
+		for lineEnd < end && e.src[lineEnd] != '\n' && e.src[lineEnd] != '\r' {
+			lineEnd++
+		}
+		s, t := start, lineEnd
+		for s < t && isSpace(e.src[s]) {
+			s++
+		}
+		for t > s && isSpace(e.src[t-1]) {
+			t--
+		}
+		if s < t {
+			from, to := e.lines.Position(s), e.lines.Position(t)
+			deltaLine, deltaCol := from.Line-e.lastLine, from.Character
+			if deltaLine == 0 {
+				deltaCol = from.Character - e.lastCol
+			}
+			e.data = append(e.data, deltaLine, deltaCol, to.Character-from.Character, m.typ, m.modifiers)
+			e.lastLine, e.lastCol = from.Line, from.Character
+		}
+		start = lineEnd + 1
+		if lineEnd < end && e.src[lineEnd] == '\r' && lineEnd+1 < end && e.src[lineEnd+1] == '\n' {
+			start++
+		}
+	}
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' }
+
+// Edit is a SemanticTokensEdit: it replaces DeleteCount integers of the
+// previous data, starting at Start, with Data.
+type Edit struct {
+	Start       uint32   `json:"start"`
+	DeleteCount uint32   `json:"deleteCount"`
+	Data        []uint32 `json:"data,omitempty"`
+}
+
+// Delta returns the edits turning the data of a previous response into
+// next, for a textDocument/semanticTokens/full/delta response. It returns
+// a single edit covering everything between the common prefix and suffix,
+// or none if the data is unchanged.
+func Delta(prev, next []uint32) []Edit {
+	prefix := 0
+	for prefix < len(prev) && prefix < len(next) && prev[prefix] == next[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(prev)-prefix && suffix < len(next)-prefix && prev[len(prev)-1-suffix] == next[len(next)-1-suffix] {
+		suffix++
+	}
+	if prefix == len(prev) && prefix == len(next) {
+		return nil
+	}
+	return []Edit{{
+		Start:       uint32(prefix),
+		DeleteCount: uint32(len(prev) - prefix - suffix),
+		Data:        next[prefix : len(next)-suffix],
+	}}
+}
@@ -0,0 +1,89 @@
+package semantictokens_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/edits"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/position"
+	st "github.com/karol-broda/ferrule/bindings/go/semantictokens"
+)
+
+func parse(t *testing.T, src string) *ferrule.Tree {
+	t.Helper()
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tree.Close)
+	return tree
+}
+
+func TestEncode(t *testing.T) {
+	tree := parse(t, "const s = \"😀\"; /* a\n  b */\nconst n = 1;\n")
+	got := st.Encode(tree, position.New(tree.Source()))
+	if len(got)%5 != 0 {
+		t.Fatalf("data length %d is not a multiple of 5", len(got))
+	}
+	// decode the relative positions; the emoji counts as two UTF-16 units.
+	type token struct{ line, col, length, typ uint32 }
+	var tokens []token
+	line, col := uint32(0), uint32(0)
+	for i := 0; i+5 <= len(got); i += 5 {
+		if got[i] > 0 {
+			col = 0
+		}
+		line += got[i]
+		col += got[i+1]
+		tokens = append(tokens, token{line, col, got[i+2], got[i+3]})
+	}
+	for _, w := range []token{
+		{0, 0, 5, st.Keyword},
+		{0, 6, 1, st.Variable},
+		{0, 10, 4, st.String},
+		{0, 16, 4, st.Comment},
+		{1, 2, 4, st.Comment},
+		{2, 0, 5, st.Keyword},
+		{2, 10, 1, st.Number},
+	} {
+		found := false
+		for _, tok := range tokens {
+			if tok == w {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing token %+v in %+v", w, tokens)
+		}
+	}
+}
+
+func TestEncodeRange(t *testing.T) {
+	tree := parse(t, "const a = 1;\nconst b = 2;\nconst c = 3;\n")
+	got := st.EncodeRange(tree, position.New(tree.Source()), edits.Range{Start: edits.Position{Line: 1}, End: edits.Position{Line: 2}})
+	full := st.Encode(tree, position.New(tree.Source()))
+	if len(got) == 0 || len(got) >= len(full) {
+		t.Fatalf("range returned %d integers of %d", len(got), len(full))
+	}
+	// the first token of the range is encoded relative to the document start.
+	if got[0] != 1 || got[1] != 0 || got[3] != st.Keyword {
+		t.Errorf("first token = %v", got[:5])
+	}
+}
+
+func TestDelta(t *testing.T) {
+	prev := []uint32{0, 0, 5, 8, 0, 0, 6, 1, 5, 0, 1, 0, 5, 8, 0}
+	next := []uint32{0, 0, 5, 8, 0, 0, 6, 3, 5, 0, 1, 0, 5, 8, 0}
+	want := []st.Edit{{Start: 7, DeleteCount: 1, Data: []uint32{3}}}
+	if got := st.Delta(prev, next); !reflect.DeepEqual(got, want) {
+		t.Errorf("Delta() = %+v, want %+v", got, want)
+	}
+	if got := st.Delta(prev, prev); got != nil {
+		t.Errorf("Delta of equal data = %+v", got)
+	}
+	if got := st.Delta(prev[:5], prev); len(got) != 1 || got[0].Start != 5 || got[0].DeleteCount != 0 || len(got[0].Data) != 10 {
+		t.Errorf("Delta of appended tokens = %+v", got)
+	}
+}
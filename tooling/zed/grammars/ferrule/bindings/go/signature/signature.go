@@ -0,0 +1,203 @@
+// Package signature provides signature help: the parameters of the
+// function being called at the cursor, and which of them the cursor is
+// on, as in LSP signatureHelp responses.
+//
+// The call is found in the syntax tree even while its arguments are being
+// typed, as long as the parser has kept its opening parenthesis. The
+// callee is resolved within the file first, then through the project
+// index.
+package signature
+
+import (
+	"context"
+	"path/filepath"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/charset"
+	"github.com/karol-broda/ferrule/bindings/go/doc"
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/scope"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+// Help is the signature of a called function.
+type Help struct {
+	// Label is the declaration of the function without its body.
+	Label      string
+	Doc        doc.Comment
+	Parameters []Parameter
+	// ActiveParameter is the index of the argument the cursor is in. It
+	// may be past the last parameter when too many arguments are given.
+	ActiveParameter int
+}
+
+// Parameter is a parameter of the function.
+type Parameter struct {
+	// Label is the parameter's declaration, such as "x: i32".
+	Label string
+	// Doc is the text of its @param tag.
+	Doc string
+}
+
+// At returns the signature help for the cursor at point p of tree, or nil
+// when p is not within the arguments of a call to a known function. idx
+// may be nil to look for the callee in tree only; definitions in other
+// files are read from disk, below the root of idx.
+func At(idx *index.Index, tree *ferrule.Tree, p tree_sitter.Point) (*Help, error) {
+	src := tree.Source()
+	callee, active := call(tree, p)
+	if callee == nil {
+		return nil, nil
+	}
+	name := callee
+	if callee.Kind() == kind.MemberExpression {
+		name = callee.NamedChild(callee.NamedChildCount() - 1)
+	}
+	if name == nil || name.Kind() != kind.Identifier {
+		return nil, nil
+	}
+
+	if def := scope.Resolve(tree).ResolveAt(name.StartPosition()); def != nil {
+		if decl := def.Node.Parent(); decl.Kind() == kind.FunctionDeclaration {
+			return help(decl, src, active), nil
+		}
+		return nil, nil
+	}
+	text := name.Utf8Text(src)
+	for _, d := range index.Extract("", tree).Definitions {
+		if d.Name == text && (d.Kind == symbols.Function || d.Kind == symbols.Method) {
+			if decl := declOf(tree, d.SelectionRange); decl != nil {
+				return help(decl, src, active), nil
+			}
+		}
+	}
+	if idx == nil {
+		return nil, nil
+	}
+	for _, loc := range idx.Definitions(text) {
+		text, err := charset.ReadFile(filepath.Join(idx.Root(), filepath.FromSlash(loc.Path)))
+		if err != nil {
+			return nil, err
+		}
+		data := text.Source
+		t, err := ferrule.Parse(context.Background(), data)
+		if err != nil {
+			return nil, err
+		}
+		var h *Help
+		if decl := declOf(t, loc.Range); decl != nil {
+			h = help(decl, data, active)
+		}
+		t.Close()
+		if h != nil {
+			return h, nil
+		}
+	}
+	return nil, nil
+}
+
+// call finds the innermost call whose argument list p is in. It returns
+// the callee and the index of the argument at p.
+func call(tree *ferrule.Tree, p tree_sitter.Point) (*tree_sitter.Node, int) {
+	src := tree.Source()
+	off := pointOffset(tree, p)
+	// Start from the token before p, which is in the call even when the
+	// parser gave up on it.
+	b := off
+	for b > 0 && isSpace(src[b-1]) {
+		b--
+	}
+	if b == 0 {
+		return nil, 0
+	}
+	for n := tree.RootNode().DescendantForByteRange(b-1, b); n != nil; n = n.Parent() {
+		if n.Kind() != kind.CallExpression && !n.IsError() {
+			continue
+		}
+		// The last opening parenthesis before p that is not closed before
+		// p, and the callee just before it.
+		open := -1
+		for i := uint(0); i < n.ChildCount(); i++ {
+			c := n.Child(i)
+			if c.StartByte() >= off {
+				break
+			}
+			switch {
+			case c.Kind() == "(" && i > 0 && callable(n.Child(i-1)):
+				open = int(i)
+			case c.Kind() == ")" && !c.IsMissing() && c.EndByte() <= off:
+				open = -1
+			}
+		}
+		if open < 0 {
+			continue
+		}
+		active := 0
+		for i := uint(open) + 1; i < n.ChildCount(); i++ {
+			if c := n.Child(i); c.StartByte() >= off {
+				break
+			} else if c.Kind() == "," {
+				active++
+			}
+		}
+		return n.Child(uint(open) - 1), active
+	}
+	return nil, 0
+}
+
+func isSpace(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+
+func callable(n *tree_sitter.Node) bool {
+	return n.Kind() == kind.Identifier || n.Kind() == kind.MemberExpression
+}
+
+// pointOffset converts p to a byte offset of the source of tree.
+func pointOffset(tree *ferrule.Tree, p tree_sitter.Point) uint {
+	src := tree.Source()
+	row, off := uint(0), uint(0)
+	for off < uint(len(src)) && row < p.Row {
+		if src[off] == '\n' {
+			row++
+		}
+		off++
+	}
+	return min(off+p.Column, uint(len(src)))
+}
+
+// declOf returns the function declaration whose name is at r.
+func declOf(tree *ferrule.Tree, r tree_sitter.Range) *tree_sitter.Node {
+	n := tree.RootNode().NamedDescendantForByteRange(r.StartByte, r.EndByte)
+	if n == nil || n.Parent() == nil || n.Parent().Kind() != kind.FunctionDeclaration {
+		return nil
+	}
+	return n.Parent()
+}
+
+func help(decl *tree_sitter.Node, src []byte, active int) *Help {
+	c := doc.Parse(doc.Text(decl, src))
+	h := &Help{Label: doc.Signature(decl, src), Doc: c, ActiveParameter: active}
+	params := decl.ChildByFieldName(field.Parameters)
+	if params == nil {
+		return h
+	}
+	for i := uint(0); i < params.NamedChildCount(); i++ {
+		param := params.NamedChild(i)
+		if param.Kind() != kind.Parameter {
+			continue
+		}
+		p := Parameter{Label: param.Utf8Text(src)}
+		if name := param.ChildByFieldName(field.Name); name != nil {
+			for _, d := range c.Params {
+				if d.Name == name.Utf8Text(src) {
+					p.Doc = d.Text
+				}
+			}
+		}
+		h.Parameters = append(h.Parameters, p)
+	}
+	return h
+}
@@ -0,0 +1,100 @@
+package signature_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/index"
+	"github.com/karol-broda/ferrule/bindings/go/signature"
+)
+
+const utilSrc = `package app.util;
+
+// Clamp limits v.
+//
+// @param v the value
+// @param hi the upper bound
+pub function clamp(v: i32, hi: i32) -> i32 {
+  return v;
+}
+`
+
+// at returns the point of the first | in src, and src without it.
+func at(src string) (string, tree_sitter.Point) {
+	i := strings.Index(src, "|")
+	before := src[:i]
+	row := uint(strings.Count(before, "\n"))
+	col := uint(len(before) - strings.LastIndex(before, "\n") - 1)
+	return before + src[i+1:], tree_sitter.Point{Row: row, Column: col}
+}
+
+func TestAt(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "util.fe"), []byte(utilSrc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := index.Build(context.Background(), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const local = "function add(x: i32, y: i32) -> i32 {\n  return x + y;\n}\n\n"
+	tests := []struct {
+		src    string
+		label  string
+		active int
+	}{
+		{local + "function main() -> i32 {\n  return add(|1, 2);\n}\n", "function add(x: i32, y: i32) -> i32", 0},
+		{local + "function main() -> i32 {\n  return add(1, |2);\n}\n", "function add(x: i32, y: i32) -> i32", 1},
+		{local + "function main() -> i32 {\n  return add(1, clamp(3, |4));\n}\n", "pub function clamp(v: i32, hi: i32) -> i32", 1},
+		{local + "function main() -> i32 {\n  return add(1, clamp(3, 4)|);\n}\n", "function add(x: i32, y: i32) -> i32", 1},
+		{"function main() -> i32 {\n  return m.clamp(1, |\n}\n", "pub function clamp(v: i32, hi: i32) -> i32", 1},
+		{local + "function main() -> i32 {\n  return add(1, 2)|;\n}\n", "", 0},
+		{"function main() -> i32 {\n  return unknown(|);\n}\n", "", 0},
+	}
+	for _, tt := range tests {
+		src, p := at(tt.src)
+		tree, err := ferrule.Parse(context.Background(), []byte(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := signature.At(idx, tree, p)
+		tree.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		label, active := "", 0
+		if h != nil {
+			label, active = h.Label, h.ActiveParameter
+		}
+		if label != tt.label || active != tt.active {
+			t.Errorf("%q: got %q, %d; want %q, %d", tt.src, label, active, tt.label, tt.active)
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	src, p := at(utilSrc + "\nfunction main() -> i32 {\n  return clamp(|);\n}\n")
+	tree, err := ferrule.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	h, err := signature.At(nil, tree, p)
+	if err != nil || h == nil {
+		t.Fatalf("At = %v, %v", h, err)
+	}
+	want := []signature.Parameter{{"v: i32", "the value"}, {"hi: i32", "the upper bound"}}
+	if len(h.Parameters) != 2 || h.Parameters[0] != want[0] || h.Parameters[1] != want[1] {
+		t.Errorf("parameters %+v, want %+v", h.Parameters, want)
+	}
+	if h.Doc.Text != "Clamp limits v." {
+		t.Errorf("doc %q", h.Doc.Text)
+	}
+}
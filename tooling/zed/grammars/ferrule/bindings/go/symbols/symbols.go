@@ -0,0 +1,247 @@
+// Package symbols extracts the outline of a ferrule file: its package,
+// declarations and the members of components, for LSP documentSymbol
+// responses and editor breadcrumbs.
+//
+// The outline is driven by the bundled tags query. Every @definition.*
+// capture that is not local to a function body becomes a Symbol, and
+// symbols nest according to the source ranges of their declarations.
+package symbols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/field"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+	"github.com/karol-broda/ferrule/bindings/go/query"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+var tags = query.MustCompile(string(queries.Tags))
+
+// Kind is the kind of a symbol. The values match the LSP SymbolKind
+// enumeration.
+type Kind int
+
+const (
+	Module    Kind = 2
+	Package   Kind = 4
+	Class     Kind = 5
+	Method    Kind = 6
+	Enum      Kind = 10
+	Interface Kind = 11
+	Function  Kind = 12
+	Variable  Kind = 13
+	Constant  Kind = 14
+	Struct    Kind = 23
+	TypeAlias Kind = 26
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Module:
+		return "module"
+	case Package:
+		return "package"
+	case Class:
+		return "class"
+	case Method:
+		return "method"
+	case Enum:
+		return "enum"
+	case Interface:
+		return "interface"
+	case Function:
+		return "function"
+	case Variable:
+		return "variable"
+	case Constant:
+		return "constant"
+	case Struct:
+		return "struct"
+	case TypeAlias:
+		return "type"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Symbol is a declaration in the outline.
+type Symbol struct {
+	Name string
+	Kind Kind
+	// Detail is a one-line summary, such as a function's signature.
+	Detail string
+	// Range covers the whole declaration, SelectionRange only its name.
+	Range          tree_sitter.Range
+	SelectionRange tree_sitter.Range
+	Children       []Symbol
+}
+
+// Outline returns the top-level symbols of tree with their children.
+func Outline(tree *ferrule.Tree) []Symbol {
+	src := tree.Source()
+	type found struct {
+		decl, name *tree_sitter.Node
+		tag        string
+	}
+	byMatch := make(map[uint]*found)
+	var order []uint
+	for c, n := range tags.Matches(tree.Root(), src) {
+		f := byMatch[c.Match]
+		if f == nil {
+			f = &found{}
+			byMatch[c.Match] = f
+			order = append(order, c.Match)
+		}
+		switch {
+		case c.Name == "name":
+			f.name = n.Raw()
+		case strings.HasPrefix(c.Name, "definition."):
+			f.decl, f.tag = n.Raw(), strings.TrimPrefix(c.Name, "definition.")
+		}
+	}
+
+	var flat []*Symbol
+	for _, id := range order {
+		f := byMatch[id]
+		if f.decl == nil || f.name == nil || local(f.decl) {
+			continue
+		}
+		flat = append(flat, &Symbol{
+			Name:           f.name.Utf8Text(src),
+			Kind:           kindOf(f.tag, f.decl),
+			Detail:         detail(f.decl, src),
+			Range:          f.decl.Range(),
+			SelectionRange: f.name.Range(),
+		})
+	}
+	sort.SliceStable(flat, func(i, j int) bool {
+		a, b := flat[i].Range, flat[j].Range
+		if a.StartByte != b.StartByte {
+			return a.StartByte < b.StartByte
+		}
+		return a.EndByte > b.EndByte
+	})
+	return nest(flat)
+}
+
+// nest arranges symbols sorted by position into a tree by containment.
+func nest(flat []*Symbol) []Symbol {
+	var roots []*Symbol
+	var stack []*Symbol
+	parentOf := make(map[*Symbol]*Symbol)
+	for _, s := range flat {
+		for len(stack) > 0 && stack[len(stack)-1].Range.EndByte <= s.Range.StartByte {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parentOf[s] = stack[len(stack)-1]
+		} else {
+			roots = append(roots, s)
+		}
+		stack = append(stack, s)
+	}
+	// build children bottom-up so copies include their own children.
+	children := make(map[*Symbol][]*Symbol)
+	for _, s := range flat {
+		if p := parentOf[s]; p != nil {
+			children[p] = append(children[p], s)
+		}
+	}
+	var build func(s *Symbol) Symbol
+	build = func(s *Symbol) Symbol {
+		out := *s
+		for _, c := range children[s] {
+			out.Children = append(out.Children, build(c))
+		}
+		return out
+	}
+	out := make([]Symbol, len(roots))
+	for i, r := range roots {
+		out[i] = build(r)
+	}
+	return out
+}
+
+// local reports whether decl is declared inside a function body.
+func local(decl *tree_sitter.Node) bool {
+	for p := decl.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == kind.Block {
+			return true
+		}
+	}
+	return false
+}
+
+func kindOf(tag string, decl *tree_sitter.Node) Kind {
+	switch tag {
+	case "function":
+		if p := decl.Parent(); p != nil && p.Kind() == kind.ComponentDeclaration {
+			return Method
+		}
+		return Function
+	case "interface":
+		return Interface
+	case "module":
+		if decl.Kind() == kind.PackageDeclaration {
+			return Package
+		}
+		return Module
+	case "constant":
+		if c := decl.Child(0); c != nil && c.Kind() == kind.KeywordVar {
+			return Variable
+		}
+		return Constant
+	case "type":
+		switch decl.Kind() {
+		case kind.DomainDeclaration:
+			return Enum
+		case kind.ErrorDeclaration:
+			return Struct
+		}
+		if t := decl.ChildByFieldName(field.Type); t != nil {
+			switch t.Kind() {
+			case kind.RecordType:
+				return Struct
+			case kind.UnionType:
+				return Enum
+			}
+		}
+		return TypeAlias
+	}
+	return Variable
+}
+
+// detail summarizes a declaration: the signature of a function and the
+// type of a constant or alias.
+func detail(decl *tree_sitter.Node, src []byte) string {
+	switch decl.Kind() {
+	case kind.FunctionDeclaration:
+		params := decl.ChildByFieldName(field.Parameters)
+		body := decl.ChildByFieldName(field.Body)
+		if params == nil || body == nil {
+			return ""
+		}
+		return squeeze(string(src[params.StartByte():body.StartByte()]))
+	case kind.TypeDeclaration:
+		if t := decl.ChildByFieldName(field.Type); t != nil && t.Kind() != kind.RecordType && t.Kind() != kind.UnionType {
+			return squeeze(t.Utf8Text(src))
+		}
+	case kind.ConstDeclaration:
+		for i := uint(0); i+1 < decl.ChildCount(); i++ {
+			if decl.Child(i).Kind() == ":" {
+				return squeeze(decl.Child(i + 1).Utf8Text(src))
+			}
+		}
+	}
+	return ""
+}
+
+// squeeze collapses runs of whitespace to single spaces.
+func squeeze(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,74 @@
+package symbols_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/symbols"
+)
+
+const source = `package app.server;
+
+const port: u16 = 8080;
+var hits = 0;
+
+type Point = { x: f64, y: f64 };
+type Id = u64;
+domain IoError { NotFound { path: String } }
+capability Fs { read: (String) -> Bytes }
+
+component Server {
+  function start(p: u16) -> Unit {
+    const local = 1;
+  }
+  type Handle = u32;
+}
+
+function main() -> Unit {}
+`
+
+func TestOutline(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+
+	var lines []string
+	var dump func(ss []symbols.Symbol, indent string)
+	dump = func(ss []symbols.Symbol, indent string) {
+		for _, s := range ss {
+			line := fmt.Sprintf("%s%s %s", indent, s.Kind, s.Name)
+			if s.Detail != "" {
+				line += " " + s.Detail
+			}
+			lines = append(lines, line)
+			dump(s.Children, indent+"  ")
+		}
+	}
+	outline := symbols.Outline(tree)
+	dump(outline, "")
+
+	want := `package app.server
+constant port u16
+variable hits
+struct Point
+type Id u64
+enum IoError
+interface Fs
+module Server
+  method start (p: u16) -> Unit
+  type Handle u32
+function main () -> Unit`
+	if got := strings.Join(lines, "\n"); got != want {
+		t.Errorf("outline:\n%s\nwant:\n%s", got, want)
+	}
+
+	server := outline[7]
+	if server.SelectionRange.StartPoint.Row != 10 || server.Range.EndPoint.Row != 15 {
+		t.Errorf("Server ranges = %+v, %+v", server.Range, server.SelectionRange)
+	}
+}
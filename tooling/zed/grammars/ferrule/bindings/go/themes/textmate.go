@@ -0,0 +1,532 @@
+package themes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/query"
+)
+
+// Grammar is a TextMate grammar, as in a .tmLanguage.json file.
+type Grammar struct {
+	Name      string    `json:"name"`
+	ScopeName string    `json:"scopeName"`
+	FileTypes []string  `json:"fileTypes"`
+	Patterns  []Pattern `json:"patterns"`
+}
+
+// Pattern is a rule of a TextMate grammar: Name scopes the text Match
+// matches in a line, or the region from Begin to End, within which
+// Patterns apply.
+type Pattern struct {
+	Name     string    `json:"name"`
+	Match    string    `json:"match,omitempty"`
+	Begin    string    `json:"begin,omitempty"`
+	End      string    `json:"end,omitempty"`
+	Patterns []Pattern `json:"patterns,omitempty"`
+}
+
+// Generate derives a TextMate grammar from a highlights query and the
+// grammar.json of the parser, such as queries.Highlights and the
+// src/grammar.json of the ferrule grammar.
+//
+// It translates the patterns of the query that TextMate can express, those
+// that capture tokens on their own:
+//
+//	["if" "else"] @keyword.control
+//	(integer_literal) @number
+//
+// A token of the grammar.json becomes a regular expression, or a region
+// when it opens and closes with fixed strings, such as a string literal,
+// the tokens captured within which apply inside it only. Patterns that depend
+// on the nodes around a token, such as that of the names of declared
+// functions, are left out, and those identifiers take the scope of the
+// fallback pattern of the query, if any: TextMate matches a line at a
+// time, without a syntax tree. Where the parser takes the longest token at
+// a position, TextMate takes the first pattern that matches there, so the
+// patterns are ordered by the length of the shortest text they match,
+// longest first and as in the query for equal lengths.
+func Generate(highlights, grammarJSON []byte) (*Grammar, error) {
+	var g grammar
+	if err := json.Unmarshal(grammarJSON, &g); err != nil {
+		return nil, fmt.Errorf("themes: grammar: %w", err)
+	}
+	if g.Name == "" || len(g.Rules) == 0 {
+		return nil, errors.New("themes: grammar: no name or rules")
+	}
+	g.aliases = make(map[string]*rule)
+	for _, r := range g.Rules {
+		g.findAliases(r)
+	}
+	q, err := query.New(string(highlights))
+	if err != nil {
+		return nil, fmt.Errorf("themes: highlights: %w", err)
+	}
+	defer q.Close()
+
+	var tokens []token
+	captures := make(map[string]string)
+	var order []string
+	first := make(map[string]uint)
+	for i := uint(0); i < q.Raw().PatternCount(); i++ {
+		text := highlights[q.Raw().StartByteForPattern(i):q.Raw().EndByteForPattern(i)]
+		strs, kind, capture, ok := bare(string(text))
+		if !ok {
+			continue
+		}
+		if kind != "" {
+			if _, seen := captures[kind]; !seen {
+				captures[kind] = capture
+				first[kind] = i
+				order = append(order, kind)
+			}
+			continue
+		}
+		for _, s := range strs {
+			tokens = append(tokens, token{text: s, capture: capture, pattern: i})
+		}
+	}
+
+	var out []ordered
+	inner := make(map[string]bool)
+	for _, kind := range order {
+		p, err := g.pattern(kind, captures, inner)
+		if err != nil {
+			// the token has no form TextMate can match.
+			continue
+		}
+		p.pattern = first[kind]
+		out = append(out, p)
+	}
+	// the tokens of regions only, such as escape sequences, match in them.
+	kept := out[:0]
+	for _, p := range out {
+		if !inner[p.kind] {
+			kept = append(kept, p)
+		}
+	}
+	out = append(kept, tokenPatterns(tokens)...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].min != out[j].min {
+			return out[i].min > out[j].min
+		}
+		return out[i].pattern < out[j].pattern
+	})
+	gr := &Grammar{Name: g.Name, ScopeName: "source." + g.Name, FileTypes: []string{"fe"}, Patterns: []Pattern{}}
+	for _, p := range out {
+		gr.Patterns = append(gr.Patterns, p.Pattern)
+	}
+	return gr, nil
+}
+
+// ordered is a pattern with the length of the shortest text it matches
+// and the index of the pattern of the query it comes from.
+type ordered struct {
+	Pattern
+	min     int
+	pattern uint
+	// kind is the node kind of the pattern, if it matches one.
+	kind string
+}
+
+// token is an anonymous token a query captures.
+type token struct {
+	text, capture string
+	pattern       uint
+}
+
+var (
+	barePattern = regexp.MustCompile(`^(?:\(([a-z_][a-z0-9_]*)\)|"((?:[^"\\]|\\.)*)"|\[((?:\s*"(?:[^"\\]|\\.)*")+)\s*\])\s*@([\w.]+)$`)
+	quoted      = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+)
+
+// bare parses the text of a query pattern capturing a node kind or tokens
+// on their own, without fields, parents or predicates. The text may end
+// with the comments that come before the next pattern.
+func bare(text string) (strs []string, kind, capture string, ok bool) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), ";") {
+			lines = append(lines, line)
+		}
+	}
+	m := barePattern.FindStringSubmatch(strings.TrimSpace(strings.Join(lines, "\n")))
+	if m == nil {
+		return nil, "", "", false
+	}
+	if m[1] != "" {
+		return nil, m[1], m[4], true
+	}
+	list := m[3]
+	if list == "" {
+		list = `"` + m[2] + `"`
+	}
+	for _, lit := range quoted.FindAllString(list, -1) {
+		s, err := strconv.Unquote(lit)
+		if err != nil {
+			return nil, "", "", false
+		}
+		strs = append(strs, s)
+	}
+	return strs, "", m[4], true
+}
+
+// tokenPatterns returns the patterns of the tokens, a token captured twice
+// keeping its first capture: one for the words of each capture and one for
+// the rest, longest first as alternatives match in order.
+func tokenPatterns(tokens []token) []ordered {
+	seen := make(map[string]bool)
+	type group struct {
+		capture string
+		words   bool
+		texts   []string
+		pattern uint
+	}
+	var groups []*group
+	byKey := make(map[string]*group)
+	for _, t := range tokens {
+		if seen[t.text] || t.text == "" {
+			continue
+		}
+		seen[t.text] = true
+		words := isWord(t.text)
+		key := fmt.Sprint(t.capture, words)
+		grp := byKey[key]
+		if grp == nil {
+			grp = &group{capture: t.capture, words: words, pattern: t.pattern}
+			byKey[key] = grp
+			groups = append(groups, grp)
+		}
+		grp.texts = append(grp.texts, t.text)
+	}
+	var out []ordered
+	for _, grp := range groups {
+		sort.SliceStable(grp.texts, func(i, j int) bool { return len(grp.texts[i]) > len(grp.texts[j]) })
+		alts := make([]string, len(grp.texts))
+		for i, s := range grp.texts {
+			alts[i] = regexp.QuoteMeta(s)
+		}
+		re := "(?:" + strings.Join(alts, "|") + ")"
+		out = append(out, ordered{Pattern: Pattern{Name: Scope(grp.capture), Match: bounded(re)}, min: len(grp.texts[len(grp.texts)-1]), pattern: grp.pattern})
+	}
+	return out
+}
+
+// grammar is the part of a grammar.json read.
+type grammar struct {
+	Name  string           `json:"name"`
+	Rules map[string]*rule `json:"rules"`
+	// aliases holds the content of the named aliases by name, for kinds
+	// such as type_identifier that have no rule of their own.
+	aliases map[string]*rule
+}
+
+// rule is a rule of a grammar.json.
+type rule struct {
+	Type    string  `json:"type"`
+	Value   any     `json:"value"`
+	Name    string  `json:"name"`
+	Named   bool    `json:"named"`
+	Content *rule   `json:"content"`
+	Members []*rule `json:"members"`
+}
+
+func (g *grammar) findAliases(r *rule) {
+	if r == nil {
+		return
+	}
+	if s, ok := r.Value.(string); ok && r.Type == "ALIAS" && r.Named && g.aliases[s] == nil {
+		g.aliases[s] = r.Content
+	}
+	g.findAliases(r.Content)
+	for _, m := range r.Members {
+		g.findAliases(m)
+	}
+}
+
+// errNotLexical is the error of a rule made of other than text.
+var errNotLexical = errors.New("themes: rule is not lexical")
+
+// pattern returns the pattern of the node kind, which captures maps to
+// its capture, and adds the kinds matched within it to inner.
+func (g *grammar) pattern(kind string, captures map[string]string, inner map[string]bool) (ordered, error) {
+	r := g.Rules[kind]
+	if r == nil {
+		r = g.aliases[kind]
+	}
+	if r == nil {
+		return ordered{}, errNotLexical
+	}
+	name := Scope(captures[kind])
+	if begin, middle, end, token, ok := delimited(r); ok {
+		p := Pattern{Name: name, Begin: regexp.QuoteMeta(begin)}
+		if token {
+			// the lexer matches the rest of the token up to its end, which
+			// is what a search for it from within finds first.
+			re, err := g.regex(&rule{Type: "SEQ", Members: append(middle, &rule{Type: "STRING", Value: end})}, nil)
+			if err != nil {
+				return ordered{}, err
+			}
+			p.End = re
+		} else {
+			p.End = regexp.QuoteMeta(end)
+			for _, sym := range g.symbols(middle) {
+				if _, ok := captures[sym]; ok && sym != kind {
+					if ip, err := g.pattern(sym, captures, inner); err == nil {
+						p.Patterns = append(p.Patterns, ip.Pattern)
+						inner[sym] = true
+					}
+				}
+			}
+		}
+		return ordered{Pattern: p, min: len(begin), kind: kind}, nil
+	}
+	re, err := g.regex(r, nil)
+	if err != nil {
+		return ordered{}, err
+	}
+	return ordered{Pattern: Pattern{Name: name, Match: bounded(re)}, min: minLen(re), kind: kind}, nil
+}
+
+// delimited reports whether r is a sequence opening and closing with
+// strings, returning them, what lies between them, and whether r is a
+// single token of the lexer rather than a node whose parts are tokens.
+func delimited(r *rule) (begin string, middle []*rule, end string, token bool, ok bool) {
+	for r.Content != nil && (r.Type == "TOKEN" || r.Type == "IMMEDIATE_TOKEN" || strings.HasPrefix(r.Type, "PREC")) {
+		if r.Type == "TOKEN" || r.Type == "IMMEDIATE_TOKEN" {
+			token = true
+		}
+		r = r.Content
+	}
+	if r.Type != "SEQ" || len(r.Members) < 3 {
+		return "", nil, "", false, false
+	}
+	first, last := r.Members[0], r.Members[len(r.Members)-1]
+	b, ok1 := first.Value.(string)
+	e, ok2 := last.Value.(string)
+	if first.Type != "STRING" || last.Type != "STRING" || !ok1 || !ok2 || b == "" || e == "" {
+		return "", nil, "", false, false
+	}
+	return b, r.Members[1 : len(r.Members)-1], e, token, true
+}
+
+// symbols returns the names of the rules rs refer to.
+func (g *grammar) symbols(rs []*rule) []string {
+	var out []string
+	var visit func(r *rule)
+	visit = func(r *rule) {
+		if r == nil {
+			return
+		}
+		if r.Type == "SYMBOL" {
+			out = append(out, r.Name)
+		}
+		visit(r.Content)
+		for _, m := range r.Members {
+			visit(m)
+		}
+	}
+	for _, r := range rs {
+		visit(r)
+	}
+	return out
+}
+
+// regex returns a regular expression matching the text of r, inlining the
+// rules it refers to, which must themselves be made of text. inlining
+// holds the rules being inlined, to reject recursive ones.
+func (g *grammar) regex(r *rule, inlining map[string]bool) (string, error) {
+	switch r.Type {
+	case "STRING":
+		s, _ := r.Value.(string)
+		return regexp.QuoteMeta(s), nil
+	case "PATTERN":
+		s, _ := r.Value.(string)
+		return "(?:" + s + ")", nil
+	case "BLANK":
+		return "", nil
+	case "TOKEN", "IMMEDIATE_TOKEN", "PREC", "PREC_LEFT", "PREC_RIGHT", "PREC_DYNAMIC", "FIELD", "ALIAS":
+		if r.Content == nil {
+			return "", errNotLexical
+		}
+		return g.regex(r.Content, inlining)
+	case "REPEAT", "REPEAT1":
+		if r.Content == nil {
+			return "", errNotLexical
+		}
+		re, err := g.regex(r.Content, inlining)
+		if err != nil {
+			return "", err
+		}
+		if r.Type == "REPEAT" {
+			return "(?:" + re + ")*", nil
+		}
+		return "(?:" + re + ")+", nil
+	case "SEQ":
+		var b strings.Builder
+		for _, m := range r.Members {
+			re, err := g.regex(m, inlining)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(re)
+		}
+		return b.String(), nil
+	case "CHOICE":
+		var alts []string
+		optional := false
+		for _, m := range r.Members {
+			if m.Type == "BLANK" {
+				optional = true
+				continue
+			}
+			re, err := g.regex(m, inlining)
+			if err != nil {
+				return "", err
+			}
+			alts = append(alts, re)
+		}
+		re := "(?:" + strings.Join(alts, "|") + ")"
+		if optional {
+			re += "?"
+		}
+		return re, nil
+	case "SYMBOL":
+		target := g.Rules[r.Name]
+		if target == nil || inlining[r.Name] {
+			return "", errNotLexical
+		}
+		if inlining == nil {
+			inlining = make(map[string]bool)
+		}
+		inlining[r.Name] = true
+		defer delete(inlining, r.Name)
+		return g.regex(target, inlining)
+	}
+	return "", errNotLexical
+}
+
+// isWord reports whether s is made of word characters, those of \w.
+func isWord(s string) bool {
+	for _, c := range []byte(s) {
+		if !wordByte(c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func wordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// bounded returns re with word boundaries at the edges where every text it
+// matches starts or ends with a word character, so that the keyword in
+// matches neither the start of index nor the end of within, and a number
+// not the digits ending a name.
+func bounded(re string) string {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return re
+	}
+	if edge(parsed, true) {
+		re = `\b` + re
+	}
+	if edge(parsed, false) {
+		re += `\b`
+	}
+	return re
+}
+
+// edge reports whether every text re matches, but the empty text, starts
+// with a word character if first is set, or ends with one otherwise.
+func edge(re *syntax.Regexp, first bool) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		c := re.Rune[0]
+		if !first {
+			c = re.Rune[len(re.Rune)-1]
+		}
+		return c < 0x80 && wordByte(byte(c))
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for c := re.Rune[i]; c <= re.Rune[i+1]; c++ {
+				if c >= 0x80 || !wordByte(byte(c)) {
+					return false
+				}
+			}
+		}
+		return len(re.Rune) > 0
+	case syntax.OpConcat:
+		n := len(re.Sub)
+		for i := range re.Sub {
+			sub := re.Sub[i]
+			if !first {
+				sub = re.Sub[n-1-i]
+			}
+			if !edge(sub, first) {
+				return false
+			}
+			if minRegexp(sub) > 0 {
+				return true
+			}
+		}
+		return false
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !edge(sub, first) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return edge(re.Sub[0], first)
+	}
+	return false
+}
+
+// minLen returns the length of the shortest text re matches, in runes.
+func minLen(re string) int {
+	parsed, err := syntax.Parse(re, syntax.Perl)
+	if err != nil {
+		return 0
+	}
+	return minRegexp(parsed)
+}
+
+func minRegexp(re *syntax.Regexp) int {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune)
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return 1
+	case syntax.OpConcat:
+		n := 0
+		for _, sub := range re.Sub {
+			n += minRegexp(sub)
+		}
+		return n
+	case syntax.OpAlternate:
+		n := -1
+		for _, sub := range re.Sub {
+			if m := minRegexp(sub); n < 0 || m < n {
+				n = m
+			}
+		}
+		return max(n, 0)
+	case syntax.OpCapture, syntax.OpPlus:
+		return minRegexp(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min * minRegexp(re.Sub[0])
+	}
+	return 0
+}
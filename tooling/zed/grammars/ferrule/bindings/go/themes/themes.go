@@ -0,0 +1,122 @@
+// Package themes carries the highlighting of ferrule over to editors that
+// do not run tree-sitter, by way of TextMate scopes: the dotted names,
+// such as "keyword.control.ferrule", that TextMate, Sublime Text, VS Code
+// and Shiki grammars give tokens and their color themes style.
+//
+// Scopes maps the capture names of the bundled highlights query to scopes,
+// Generate derives a TextMate grammar from the query and the grammar of
+// the parser, and ColorTheme turns a highlight.Theme into a color theme
+// over those scopes, so that all of them follow the same source of truth.
+package themes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+)
+
+// Language ends the scopes of ferrule tokens, as in "string.quoted.ferrule".
+const Language = "ferrule"
+
+// ScopeName is the scope of a whole ferrule file.
+const ScopeName = "source." + Language
+
+// Scopes maps capture names to TextMate scopes, without the language
+// suffix. A capture without an entry of its own uses its closest parent:
+// "function.call" falls back to "function". Every entry has a scope of
+// its own, so that a theme can style each capture apart.
+var Scopes = map[string]string{
+	"boolean":               "constant.language.boolean",
+	"comment":               "comment",
+	"constant.builtin":      "constant.language",
+	"constructor":           "variable.other.enummember",
+	"function":              "entity.name.function",
+	"keyword":               "keyword.other",
+	"keyword.control":       "keyword.control",
+	"keyword.import":        "keyword.control.import",
+	"keyword.modifier":      "storage.modifier",
+	"module":                "entity.name.namespace",
+	"number":                "constant.numeric",
+	"operator":              "keyword.operator",
+	"property":              "variable.other.property",
+	"punctuation":           "punctuation",
+	"punctuation.bracket":   "punctuation.section.brackets",
+	"punctuation.delimiter": "punctuation.separator",
+	"string":                "string.quoted",
+	"string.escape":         "constant.character.escape",
+	"type":                  "entity.name.type",
+	"type.builtin":          "support.type.primitive",
+	"type.definition":       "entity.name.type.definition",
+	"variable":              "variable.other",
+	"variable.builtin":      "variable.language",
+	"variable.parameter":    "variable.parameter",
+}
+
+// Scope returns the scope of a capture name, language suffix included. A
+// capture neither it nor a parent of which has an entry in Scopes keeps
+// its own name, which is a scope as good as any for themes that know it.
+func Scope(capture string) string {
+	for name := capture; name != ""; {
+		if s, ok := Scopes[name]; ok {
+			return s + "." + Language
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return capture + "." + Language
+}
+
+// Theme is a color theme over TextMate scopes, in the JSON form of the
+// color themes of VS Code, whose tokenColors are the settings of a
+// .tmTheme file.
+type Theme struct {
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	TokenColors []TokenColor `json:"tokenColors"`
+}
+
+// TokenColor styles the tokens of some scopes.
+type TokenColor struct {
+	Scope    []string      `json:"scope"`
+	Settings TokenSettings `json:"settings"`
+}
+
+// TokenSettings is the style of a TokenColor.
+type TokenSettings struct {
+	Foreground string `json:"foreground,omitempty"`
+	// FontStyle is "bold", "italic" or "bold italic", or empty.
+	FontStyle string `json:"fontStyle,omitempty"`
+}
+
+// ColorTheme returns the theme of the given name and type, "dark" or
+// "light", that styles the scope of every capture of theme as theme styles
+// the capture. Captures sharing a style share a rule; rules are ordered by
+// their first scope.
+func ColorTheme(name, typ string, theme highlight.Theme) *Theme {
+	byStyle := make(map[TokenSettings][]string)
+	for capture, s := range theme {
+		var styles []string
+		if s.Bold {
+			styles = append(styles, "bold")
+		}
+		if s.Italic {
+			styles = append(styles, "italic")
+		}
+		settings := TokenSettings{Foreground: s.Color, FontStyle: strings.Join(styles, " ")}
+		if settings == (TokenSettings{}) {
+			continue
+		}
+		byStyle[settings] = append(byStyle[settings], Scope(capture))
+	}
+	t := &Theme{Name: name, Type: typ, TokenColors: []TokenColor{}}
+	for settings, scopes := range byStyle {
+		sort.Strings(scopes)
+		t.TokenColors = append(t.TokenColors, TokenColor{Scope: scopes, Settings: settings})
+	}
+	sort.Slice(t.TokenColors, func(i, j int) bool { return t.TokenColors[i].Scope[0] < t.TokenColors[j].Scope[0] })
+	return t
+}
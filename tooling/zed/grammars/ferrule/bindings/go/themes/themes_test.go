@@ -0,0 +1,113 @@
+package themes_test
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/highlight"
+	"github.com/karol-broda/ferrule/bindings/go/themes"
+	"github.com/karol-broda/ferrule/queries"
+)
+
+func TestScope(t *testing.T) {
+	for capture, want := range map[string]string{
+		"keyword.control": "keyword.control.ferrule",
+		"function.call":   "entity.name.function.ferrule",
+		"type.builtin":    "support.type.primitive.ferrule",
+		"label":           "label.ferrule",
+	} {
+		if got := themes.Scope(capture); got != want {
+			t.Errorf("Scope(%q) = %q, want %q", capture, got, want)
+		}
+	}
+}
+
+func TestColorTheme(t *testing.T) {
+	theme := themes.ColorTheme("Ferrule Dark", "dark", highlight.Theme{
+		"keyword":  {Color: "#c678dd"},
+		"module":   {Color: "#c678dd"},
+		"comment":  {Color: "#7f848e", Italic: true},
+		"property": {},
+	})
+	if len(theme.TokenColors) != 2 {
+		t.Fatalf("token colors %+v", theme.TokenColors)
+	}
+	comment, keyword := theme.TokenColors[0], theme.TokenColors[1]
+	if strings.Join(comment.Scope, " ") != "comment.ferrule" || comment.Settings != (themes.TokenSettings{Foreground: "#7f848e", FontStyle: "italic"}) {
+		t.Errorf("comment rule %+v", comment)
+	}
+	if strings.Join(keyword.Scope, " ") != "entity.name.namespace.ferrule keyword.other.ferrule" || keyword.Settings.Foreground != "#c678dd" {
+		t.Errorf("keyword rule %+v", keyword)
+	}
+}
+
+// tokenize scopes the words and symbols of a line as a TextMate engine
+// would with the patterns of g: at each position the first pattern of
+// those matching earliest wins.
+func tokenize(t *testing.T, g *themes.Grammar, line string) []string {
+	t.Helper()
+	var out []string
+	for pos := 0; pos < len(line); {
+		best, at, end := -1, len(line), 0
+		for i, p := range g.Patterns {
+			re := p.Match
+			if re == "" {
+				re = p.Begin
+			}
+			if m := regexp.MustCompile(re).FindStringIndex(line[pos:]); m != nil && pos+m[0] < at {
+				best, at, end = i, pos+m[0], pos+m[1]
+			}
+		}
+		if best < 0 {
+			break
+		}
+		p := g.Patterns[best]
+		if p.Begin != "" {
+			if m := regexp.MustCompile(p.End).FindStringIndex(line[end:]); m != nil {
+				end += m[1]
+			} else {
+				end = len(line)
+			}
+		}
+		out = append(out, line[at:end]+"="+strings.TrimSuffix(p.Name, ".ferrule"))
+		pos = end
+	}
+	return out
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := os.ReadFile("../../../src/grammar.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := themes.Generate(queries.Highlights, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ScopeName != themes.ScopeName {
+		t.Errorf("scope name %q", g.ScopeName)
+	}
+	for line, want := range map[string]string{
+		`if index >= 0x1F { return 1.5; }`:    "if=keyword.control index=variable.other >==keyword.operator 0x1F=constant.numeric {=punctuation.section.brackets return=keyword.control 1.5=constant.numeric ;=punctuation.separator }=punctuation.section.brackets",
+		`const s: String = "a\n"; // note`:    `const=keyword.other s=variable.other :=punctuation.separator String=support.type.primitive ==keyword.operator "a\n"=string.quoted ;=punctuation.separator // note=comment`,
+		`x = a / b /* c */ .. _ Point in_out`: "x=variable.other ==keyword.operator a=variable.other /=keyword.operator b=variable.other /* c */=comment ..=keyword.operator _=variable.language Point=entity.name.type in_out=variable.other",
+	} {
+		if got := strings.Join(tokenize(t, g, line), " "); got != want {
+			t.Errorf("%s\ngot  %s\nwant %s", line, got, want)
+		}
+	}
+	for _, p := range g.Patterns {
+		if p.Name == "constant.character.escape.ferrule" {
+			t.Errorf("escape sequences match outside strings")
+		}
+		if p.Begin == `"` && (len(p.Patterns) != 1 || p.Patterns[0].Name != "constant.character.escape.ferrule") {
+			t.Errorf("string patterns %+v", p.Patterns)
+		}
+	}
+
+	if _, err := themes.Generate(queries.Highlights, []byte("{}")); err == nil {
+		t.Errorf("no error for an empty grammar")
+	}
+}
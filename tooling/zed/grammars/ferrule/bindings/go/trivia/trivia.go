@@ -0,0 +1,158 @@
+// Package trivia associates comments and blank lines with the syntax nodes
+// they belong to, so that tools rewriting code can carry them along.
+//
+// Comments and runs of blank lines are attached to the named nodes among
+// which they appear:
+//
+//   - a comment that starts on the line where the previous node ends is
+//     trailing trivia of that node;
+//   - any other comment or blank-line run is leading trivia of the next
+//     node, or, when nothing follows it before the end of the enclosing
+//     node, trailing trivia of the previous node;
+//   - trivia with no node on either side, as in a block holding only a
+//     comment, dangles from the enclosing node.
+package trivia
+
+import (
+	"fmt"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/kind"
+)
+
+// Kind is the kind of a piece of trivia.
+type Kind int
+
+const (
+	LineComment Kind = iota
+	BlockComment
+	// BlankLines is a run of empty lines.
+	BlankLines
+)
+
+func (k Kind) String() string {
+	switch k {
+	case LineComment:
+		return "line comment"
+	case BlockComment:
+		return "block comment"
+	case BlankLines:
+		return "blank lines"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Trivia is a comment or a run of blank lines.
+type Trivia struct {
+	Kind Kind
+	// Node is the comment node; nil for blank lines.
+	Node *tree_sitter.Node
+	// Range covers the comment, or the white space between the end of the
+	// line before the blank lines and the start of the line after them.
+	Range tree_sitter.Range
+	// Text is the text of a comment.
+	Text string
+	// Lines is the number of blank lines.
+	Lines int
+}
+
+// Map holds the trivia attached to the nodes of a tree. The nodes belong
+// to the tree it was built from and share its lifetime.
+type Map struct {
+	leading, trailing, dangling map[uintptr][]Trivia
+}
+
+// Leading returns the trivia before n, in source order.
+func (m *Map) Leading(n *tree_sitter.Node) []Trivia { return m.leading[n.Id()] }
+
+// Trailing returns the trivia after n, in source order.
+func (m *Map) Trailing(n *tree_sitter.Node) []Trivia { return m.trailing[n.Id()] }
+
+// Dangling returns the trivia inside n that is attached to none of its
+// children, in source order.
+func (m *Map) Dangling(n *tree_sitter.Node) []Trivia { return m.dangling[n.Id()] }
+
+// Attach builds the trivia map of tree.
+func Attach(tree *ferrule.Tree) *Map {
+	m := &Map{
+		leading:  make(map[uintptr][]Trivia),
+		trailing: make(map[uintptr][]Trivia),
+		dangling: make(map[uintptr][]Trivia),
+	}
+	m.visit(tree.RootNode(), tree.Source())
+	return m
+}
+
+func (m *Map) visit(n *tree_sitter.Node, src []byte) {
+	var pending []Trivia
+	var prev, last *tree_sitter.Node // the previous named node, and child
+	for i := uint(0); i < n.ChildCount(); i++ {
+		c := n.Child(i)
+		if last != nil {
+			if t, ok := blank(src, last, c); ok {
+				pending = append(pending, t)
+			}
+		}
+		last = c
+		switch {
+		case isComment(c):
+			t := comment(c, src)
+			if prev != nil && len(pending) == 0 && prev.EndPosition().Row == c.StartPosition().Row {
+				m.trailing[prev.Id()] = append(m.trailing[prev.Id()], t)
+			} else {
+				pending = append(pending, t)
+			}
+		case c.IsNamed() && !c.IsMissing():
+			if len(pending) > 0 {
+				m.leading[c.Id()] = append(m.leading[c.Id()], pending...)
+				pending = nil
+			}
+			prev = c
+			m.visit(c, src)
+		}
+	}
+	switch {
+	case len(pending) == 0:
+	case prev != nil:
+		m.trailing[prev.Id()] = append(m.trailing[prev.Id()], pending...)
+	default:
+		m.dangling[n.Id()] = append(m.dangling[n.Id()], pending...)
+	}
+}
+
+func isComment(n *tree_sitter.Node) bool {
+	return n.Kind() == kind.LineComment || n.Kind() == kind.BlockComment
+}
+
+func comment(n *tree_sitter.Node, src []byte) Trivia {
+	k := LineComment
+	if n.Kind() == kind.BlockComment {
+		k = BlockComment
+	}
+	return Trivia{Kind: k, Node: n, Range: n.Range(), Text: n.Utf8Text(src)}
+}
+
+// blank returns the blank lines between the nodes a and b, if any.
+func blank(src []byte, a, b *tree_sitter.Node) (Trivia, bool) {
+	gap := string(src[a.EndByte():b.StartByte()])
+	lines := strings.Count(gap, "\n") - 1
+	if lines < 1 {
+		return Trivia{}, false
+	}
+	first := uint(strings.IndexByte(gap, '\n'))
+	end := uint(strings.LastIndexByte(gap, '\n')) + 1
+	start := a.EndByte() + first
+	return Trivia{
+		Kind: BlankLines,
+		Range: tree_sitter.Range{
+			StartByte:  start,
+			EndByte:    a.EndByte() + end,
+			StartPoint: tree_sitter.Point{Row: a.EndPosition().Row, Column: a.EndPosition().Column + first},
+			EndPoint:   tree_sitter.Point{Row: b.StartPosition().Row},
+		},
+		Lines: lines,
+	}, true
+}
@@ -0,0 +1,82 @@
+package trivia_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+
+	"github.com/karol-broda/ferrule/bindings/go/ferrule"
+	"github.com/karol-broda/ferrule/bindings/go/trivia"
+)
+
+const source = `package app; // the app
+
+// Add adds.
+function add(x: i32, y: i32) -> i32 {
+  const z = x + y; // the sum
+
+
+  /* done */
+  return z;
+  // end of add
+}
+
+function empty() -> Unit {
+  // nothing yet
+}
+`
+
+func TestAttach(t *testing.T) {
+	tree, err := ferrule.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Close()
+	src := tree.Source()
+	m := trivia.Attach(tree)
+
+	describe := func(ts []trivia.Trivia) string {
+		var parts []string
+		for _, t := range ts {
+			if t.Kind == trivia.BlankLines {
+				parts = append(parts, fmt.Sprintf("%d blank", t.Lines))
+			} else {
+				parts = append(parts, t.Text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	var got []string
+	var visit func(n *tree_sitter.Node)
+	visit = func(n *tree_sitter.Node) {
+		line := func(which string, ts []trivia.Trivia) {
+			if len(ts) > 0 {
+				text := strings.Fields(n.Utf8Text(src))[0]
+				got = append(got, fmt.Sprintf("%s %s %s: %s", n.Kind(), text, which, describe(ts)))
+			}
+		}
+		line("leading", m.Leading(n))
+		line("trailing", m.Trailing(n))
+		line("dangling", m.Dangling(n))
+		for i := uint(0); i < n.NamedChildCount(); i++ {
+			visit(n.NamedChild(i))
+		}
+	}
+	visit(tree.RootNode())
+
+	want := []string{
+		"package_declaration package trailing: // the app",
+		"function_declaration function leading: 1 blank, // Add adds.",
+		"const_declaration const trailing: // the sum",
+		"return_statement return leading: 2 blank, /* done */",
+		"return_statement return trailing: // end of add",
+		"function_declaration function leading: 1 blank",
+		"block { dangling: // nothing yet",
+	}
+	if g, w := strings.Join(got, "\n"), strings.Join(want, "\n"); g != w {
+		t.Errorf("got:\n%s\nwant:\n%s", g, w)
+	}
+}
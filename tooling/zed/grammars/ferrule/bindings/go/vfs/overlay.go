@@ -0,0 +1,130 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Overlay is an FS showing buffers in place of the files of another, or
+// in addition to them, as an editor shows its unsaved changes. The
+// directories holding a buffer exist in the overlay even if they do not
+// below it.
+type Overlay struct {
+	base FS
+
+	mu      sync.RWMutex
+	buffers map[string]*file
+}
+
+// NewOverlay returns an overlay of base holding no buffers.
+func NewOverlay(base FS) *Overlay {
+	return &Overlay{base: base, buffers: make(map[string]*file)}
+}
+
+// Set makes src the contents of the file name, replacing any buffer set
+// for it before. The caller must not modify src afterwards. The file's
+// modification time is the time of the call.
+func (o *Overlay) Set(name string, src []byte) {
+	name = filepath.Clean(name)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.buffers[name] = &file{name: filepath.Base(name), data: src, modTime: time.Now()}
+}
+
+// Remove drops the buffer of the file name, which shows through again as
+// it is in the file system below.
+func (o *Overlay) Remove(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.buffers, filepath.Clean(name))
+}
+
+// Buffer returns the buffer set for the file name, if any.
+func (o *Overlay) Buffer(name string) ([]byte, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	f, ok := o.buffers[filepath.Clean(name)]
+	if !ok {
+		return nil, false
+	}
+	return f.data, true
+}
+
+func (o *Overlay) buffer(name string) *file {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.buffers[filepath.Clean(name)]
+}
+
+// holds reports whether some buffer lies below the directory name.
+func (o *Overlay) holds(name string) bool {
+	name = filepath.Clean(name)
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for p := range o.buffers {
+		if _, ok := below(name, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if f := o.buffer(name); f != nil {
+		return f.open(), nil
+	}
+	file, err := o.base.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && o.holds(name) {
+		return &openDir{dir{filepath.Base(filepath.Clean(name))}}, nil
+	}
+	return file, err
+}
+
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	if f := o.buffer(name); f != nil {
+		return f, nil
+	}
+	info, err := o.base.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) && o.holds(name) {
+		return dir{filepath.Base(filepath.Clean(name))}, nil
+	}
+	return info, err
+}
+
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	if f := o.buffer(name); f != nil {
+		return f.data, nil
+	}
+	return o.base.ReadFile(name)
+}
+
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := o.base.ReadDir(name)
+	if err != nil && !(errors.Is(err, fs.ErrNotExist) && o.holds(name)) {
+		return nil, err
+	}
+	merged := make(map[string]fs.DirEntry, len(entries))
+	for _, e := range entries {
+		merged[e.Name()] = e
+	}
+	name = filepath.Clean(name)
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	for p, f := range o.buffers {
+		rel, ok := below(name, p)
+		if !ok {
+			continue
+		}
+		if i := indexSeparator(rel); i >= 0 {
+			if _, ok := merged[rel[:i]]; !ok {
+				merged[rel[:i]] = fs.FileInfoToDirEntry(dir{rel[:i]})
+			}
+		} else {
+			merged[rel] = fs.FileInfoToDirEntry(f)
+		}
+	}
+	return sorted(merged), nil
+}
@@ -0,0 +1,248 @@
+// Package vfs abstracts the file system the tools of the binding read
+// sources from, so that an editor's unsaved buffers can be analyzed in
+// place of the files on disk, and tests can run against files held in
+// memory.
+//
+// Unlike those of io/fs, the names of an FS are operating system paths,
+// absolute or relative to the working directory, so that one FS serves
+// every root of a multi-root workspace and the paths the tools report are
+// those on disk. OS is the disk itself, Map a file system held in memory,
+// and an Overlay lays buffers over another FS:
+//
+//	o := vfs.NewOverlay(vfs.OS)
+//	o.Set("/src/app/main.fe", unsaved)
+//	idx := index.New("/src/app")
+//	idx.SetFS(o)
+package vfs
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FS is a read-only file system. Its methods behave as the functions of
+// package os of the same names, returning errors matching fs.ErrNotExist
+// for missing files. An FS is safe for concurrent use.
+type FS interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	// ReadDir returns the entries of the directory sorted by name.
+	ReadDir(name string) ([]fs.DirEntry, error)
+}
+
+// OS is the file system of the operating system.
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// Or returns fsys, or OS if fsys is nil, for the options of the packages
+// taking an FS, where nil means the disk.
+func Or(fsys FS) FS {
+	if fsys == nil {
+		return OS
+	}
+	return fsys
+}
+
+// WalkDir walks the tree at root in fsys as filepath.WalkDir walks the
+// disk: in lexical order, calling fn for every file and directory, with
+// the same handling of fs.SkipDir and fs.SkipAll.
+func WalkDir(fsys FS, root string, fn fs.WalkDirFunc) error {
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkDir(fsys, root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkDir(fsys FS, path string, d fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == fs.SkipDir && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := fsys.ReadDir(path)
+	if err != nil {
+		// as filepath.WalkDir does, report the error on the directory.
+		if err = fn(path, d, err); err != nil {
+			if err == fs.SkipDir {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		if err := walkDir(fsys, filepath.Join(path, e.Name()), e, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// file is a file held in memory.
+type file struct {
+	name    string
+	data    []byte
+	modTime time.Time
+}
+
+func (f *file) Name() string       { return f.name }
+func (f *file) Size() int64        { return int64(len(f.data)) }
+func (f *file) Mode() fs.FileMode  { return 0o444 }
+func (f *file) ModTime() time.Time { return f.modTime }
+func (f *file) IsDir() bool        { return false }
+func (f *file) Sys() any           { return nil }
+
+// openFile is an open file held in memory.
+type openFile struct {
+	*bytes.Reader
+	f *file
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.f, nil }
+func (f *openFile) Close() error               { return nil }
+
+func (f *file) open() fs.File { return &openFile{bytes.NewReader(f.data), f} }
+
+// dir is a directory held in memory, or one shown to exist by the files
+// held in memory below it.
+type dir struct{ name string }
+
+func (d dir) Name() string       { return d.name }
+func (d dir) Size() int64        { return 0 }
+func (d dir) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (d dir) ModTime() time.Time { return time.Time{} }
+func (d dir) IsDir() bool        { return true }
+func (d dir) Sys() any           { return nil }
+
+// openDir is an open directory held in memory. Its entries are read with
+// the ReadDir method of the FS.
+type openDir struct{ d dir }
+
+func (d *openDir) Stat() (fs.FileInfo, error) { return d.d, nil }
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.d.name, Err: errors.New("is a directory")}
+}
+func (d *openDir) Close() error { return nil }
+
+// Map is a file system held in memory, mapping the clean paths of its
+// files to their contents; the directories are those the paths hold.
+// Files have a zero modification time.
+type Map map[string]string
+
+func (m Map) lookup(name string) (fs.FileInfo, *file, error) {
+	name = filepath.Clean(name)
+	if data, ok := m[name]; ok {
+		f := &file{name: filepath.Base(name), data: []byte(data)}
+		return f, f, nil
+	}
+	if name == "." || name == string(filepath.Separator) {
+		return dir{name}, nil, nil
+	}
+	prefix := name + string(filepath.Separator)
+	for p := range m {
+		if len(p) > len(prefix) && p[:len(prefix)] == prefix {
+			return dir{filepath.Base(name)}, nil, nil
+		}
+	}
+	return nil, nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m Map) Open(name string) (fs.File, error) {
+	info, f, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		return f.open(), nil
+	}
+	return &openDir{info.(dir)}, nil
+}
+
+func (m Map) Stat(name string) (fs.FileInfo, error) {
+	info, _, err := m.lookup(name)
+	return info, err
+}
+
+func (m Map) ReadFile(name string) ([]byte, error) {
+	_, f, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return f.data, nil
+}
+
+func (m Map) ReadDir(name string) ([]fs.DirEntry, error) {
+	info, _, err := m.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errors.New("not a directory")}
+	}
+	name = filepath.Clean(name)
+	seen := make(map[string]fs.DirEntry)
+	for p, data := range m {
+		rel, ok := below(name, p)
+		if !ok {
+			continue
+		}
+		if i := indexSeparator(rel); i >= 0 {
+			seen[rel[:i]] = fs.FileInfoToDirEntry(dir{rel[:i]})
+		} else {
+			seen[rel] = fs.FileInfoToDirEntry(&file{name: rel, data: []byte(data)})
+		}
+	}
+	return sorted(seen), nil
+}
+
+// below returns the path of p relative to the directory dir, if p lies
+// below it.
+func below(dir, p string) (string, bool) {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+func indexSeparator(p string) int {
+	for i := 0; i < len(p); i++ {
+		if os.IsPathSeparator(p[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func sorted(entries map[string]fs.DirEntry) []fs.DirEntry {
+	out := make([]fs.DirEntry, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
@@ -0,0 +1,123 @@
+package vfs_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// tree lists the paths WalkDir visits below root, relative to it, with a
+// slash after directories.
+func tree(t *testing.T, fsys vfs.FS, root string) string {
+	t.Helper()
+	var out []string
+	err := vfs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == "skip" {
+			return fs.SkipDir
+		}
+		rel, _ := filepath.Rel(root, p)
+		if d.IsDir() {
+			rel += "/"
+		}
+		out = append(out, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(out, " ")
+}
+
+func TestWalkDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.fe", "a/x.fe", "a/y.fe", "skip/z.fe"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const want = "./ a/ a/x.fe a/y.fe b.fe"
+	if got := tree(t, vfs.OS, dir); got != want {
+		t.Errorf("OS: %s, want %s", got, want)
+	}
+	m := vfs.Map{}
+	for _, name := range []string{"b.fe", "a/x.fe", "a/y.fe", "skip/z.fe"} {
+		m[filepath.Join(dir, filepath.FromSlash(name))] = name
+	}
+	if got := tree(t, m, dir); got != want {
+		t.Errorf("Map: %s, want %s", got, want)
+	}
+	if err := vfs.WalkDir(m, filepath.Join(dir, "missing"), func(p string, d fs.DirEntry, err error) error { return err }); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("walking a missing root: %v", err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	m := vfs.Map{filepath.Join("src", "main.fe"): "const x = 1;\n"}
+	data, err := m.ReadFile(filepath.Join("src", ".", "main.fe"))
+	if err != nil || string(data) != "const x = 1;\n" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	if info, err := m.Stat("src"); err != nil || !info.IsDir() {
+		t.Errorf("Stat(src) = %v, %v", info, err)
+	}
+	if _, err := m.Stat(filepath.Join("src", "other.fe")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of a missing file: %v", err)
+	}
+	if _, err := m.ReadFile("src"); err == nil {
+		t.Error("ReadFile read a directory")
+	}
+	f, err := m.Open(filepath.Join("src", "main.fe"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if data, _ := io.ReadAll(f); string(data) != "const x = 1;\n" {
+		t.Errorf("read %q", data)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "main.fe")
+	if err := os.WriteFile(disk, []byte("saved"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	o := vfs.NewOverlay(vfs.OS)
+	o.Set(disk, []byte("unsaved"))
+	o.Set(filepath.Join(dir, "new", "draft.fe"), []byte("draft"))
+
+	if data, err := o.ReadFile(disk); err != nil || string(data) != "unsaved" {
+		t.Errorf("ReadFile = %q, %v", data, err)
+	}
+	if info, err := o.Stat(disk); err != nil || info.Size() != int64(len("unsaved")) {
+		t.Errorf("Stat = %v, %v", info, err)
+	}
+	if got, want := tree(t, o, dir), "./ main.fe new/ new/draft.fe"; got != want {
+		t.Errorf("walk: %s, want %s", got, want)
+	}
+	if data, ok := o.Buffer(disk); !ok || string(data) != "unsaved" {
+		t.Errorf("Buffer = %q, %v", data, ok)
+	}
+
+	o.Remove(disk)
+	o.Remove(filepath.Join(dir, "new", "draft.fe"))
+	if data, err := o.ReadFile(disk); err != nil || string(data) != "saved" {
+		t.Errorf("after Remove: ReadFile = %q, %v", data, err)
+	}
+	if _, err := o.Stat(filepath.Join(dir, "new")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("after Remove: the directory of the buffer remains: %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+// Package walk finds the ferrule sources below a directory, for the
+// command-line tools of the binding.
+//
+// Directories are read in parallel and the files found are streamed on a
+// channel as they are found, so that parsing can start before the walk
+// ends. .git directories are skipped, as is whatever the .gitignore and
+// .ferruleignore files of the tree exclude; see package ignore for the
+// syntax, which is the same for both. Generated files can be skipped as
+// well; see package generated. WalkDir skips the same directories and
+// files in a walk in lexical order.
+package walk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/karol-broda/ferrule/bindings/go/generated"
+	"github.com/karol-broda/ferrule/bindings/go/internal/ignore"
+	"github.com/karol-broda/ferrule/bindings/go/vfs"
+)
+
+// Options configures a walk. The zero value finds .fe files.
+type Options struct {
+	// Extensions are the extensions of the files wanted, with their dot.
+	// Nil means ".fe".
+	Extensions []string
+	// Interpreters are the programs a script without an extension must
+	// name on a first line starting with "#!" to be wanted, directly or
+	// through env, as in "#!/usr/bin/env ferrule". Nil means "ferrule";
+	// an empty slice disables the check.
+	Interpreters []string
+	// IgnoreFiles are the names of the files holding ignore rules, read
+	// in every directory. Rules of later names take precedence over those
+	// of earlier ones in the same directory. Nil means .gitignore and
+	// .ferruleignore; an empty slice disables ignore files.
+	IgnoreFiles []string
+	// SkipGenerated skips the files whose header says they are
+	// generated, at the cost of reading the start of every file.
+	SkipGenerated bool
+	// Workers is the number of directories read at once. Zero or less
+	// means GOMAXPROCS.
+	Workers int
+	// FS is the file system walked. Nil means the disk.
+	FS vfs.FS
+}
+
+// File is a source file found by a walk.
+type File struct {
+	// Path is the slash-separated path of the file relative to the root,
+	// and OSPath the path to open it by, joined to the root.
+	Path   string
+	OSPath string
+	Info   fs.FileInfo
+}
+
+// Files walks the tree at root and sends the files wanted by opts on the
+// channel it returns, in no particular order. The channel is closed when
+// the walk ends; the function returned then reports the errors met, after
+// which the walk continued with the other directories. The channel must
+// be drained.
+//
+// If root is a file it is sent as is, whatever its name.
+func Files(root string, opts Options) (<-chan File, func() error) {
+	w := &walker{
+		root:    root,
+		fsys:    vfs.Or(opts.FS),
+		exts:    opts.Extensions,
+		interp:  opts.Interpreters,
+		ignore:  opts.IgnoreFiles,
+		skipGen: opts.SkipGenerated,
+		out:     make(chan File, 64),
+		dirs:    make(chan dir),
+	}
+	if w.exts == nil {
+		w.exts = []string{".fe"}
+	}
+	if w.interp == nil {
+		w.interp = []string{"ferrule"}
+	}
+	if w.ignore == nil {
+		w.ignore = ignore.Files
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(w.out)
+		info, err := w.fsys.Stat(root)
+		if err != nil {
+			w.fail(err)
+			return
+		}
+		if !info.IsDir() {
+			w.out <- File{Path: filepath.ToSlash(filepath.Base(root)), OSPath: root, Info: info}
+			return
+		}
+		var workersDone sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			workersDone.Add(1)
+			go func() {
+				defer workersDone.Done()
+				for d := range w.dirs {
+					w.read(d)
+					w.pending.Done()
+				}
+			}()
+		}
+		w.pending.Add(1)
+		w.dirs <- dir{osPath: root}
+		w.pending.Wait()
+		close(w.dirs)
+		workersDone.Wait()
+	}()
+	return w.out, func() error {
+		<-done
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return errors.Join(w.errs...)
+	}
+}
+
+// WalkDir walks the tree at root in fsys as vfs.WalkDir does, in lexical
+// order and calling fn for every file and directory, but skips what Files
+// skips with the default options: .git directories and whatever the
+// .gitignore and .ferruleignore files of the tree exclude. It is for the
+// tools whose output follows the order of the files; unlike Files it
+// leaves the choice of files to fn. A nil fsys means the disk.
+func WalkDir(fsys vfs.FS, root string, fn fs.WalkDirFunc) error {
+	fsys = vfs.Or(fsys)
+	rules := ignore.NewWalker(fsys, root)
+	return vfs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(p, d, err)
+		}
+		_, skip, err := rules.Skip(p, d.IsDir())
+		if err != nil {
+			return fn(p, d, err)
+		}
+		if skip {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(p, d, nil)
+	})
+}
+
+// dir is a directory to read, with the rules in effect in its parent.
+type dir struct {
+	name, osPath string
+	rules        *ignore.Rules
+}
+
+type walker struct {
+	root    string
+	fsys    vfs.FS
+	exts    []string
+	interp  []string
+	ignore  []string
+	skipGen bool
+	out     chan File
+	dirs    chan dir
+	// pending counts the directories queued or being read.
+	pending sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errs = append(w.errs, err)
+}
+
+// read reads the directory d, sends the files wanted and queues the
+// subdirectories.
+func (w *walker) read(d dir) {
+	rules, err := d.rules.AddFiles(w.fsys, d.name, d.osPath, w.ignore)
+	if err != nil {
+		w.fail(err)
+	}
+	entries, err := w.fsys.ReadDir(d.osPath)
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	var subdirs []dir
+	for _, e := range entries {
+		name := path.Join(d.name, e.Name())
+		osPath := filepath.Join(d.osPath, e.Name())
+		if e.IsDir() {
+			if e.Name() != ".git" && !rules.Ignored(name, true) {
+				subdirs = append(subdirs, dir{name: name, osPath: osPath, rules: rules})
+			}
+			continue
+		}
+		if !e.Type().IsRegular() || rules.Ignored(name, false) || !w.wanted(e.Name(), osPath) || w.skipGen && w.isGenerated(osPath) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			w.fail(err)
+			continue
+		}
+		w.out <- File{Path: name, OSPath: osPath, Info: info}
+	}
+	w.pending.Add(len(subdirs))
+	// queueing from a worker could block every worker on a full queue,
+	// so the subdirectories are handed over from a goroutine of their own.
+	go func() {
+		for _, sub := range subdirs {
+			w.dirs <- sub
+		}
+	}()
+}
+
+// wanted reports whether the file base, found at osPath, has one of the
+// extensions wanted or, lacking an extension, names one of the wanted
+// interpreters on a shebang line.
+func (w *walker) wanted(base, osPath string) bool {
+	ext := filepath.Ext(base)
+	for _, e := range w.exts {
+		if ext == e {
+			return true
+		}
+	}
+	if ext != "" || len(w.interp) == 0 {
+		return false
+	}
+	f, err := w.fsys.Open(osPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	line, _ := bufio.NewReader(f).ReadSlice('\n')
+	prog := Interpreter(line)
+	for _, i := range w.interp {
+		if prog == i {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenerated reports whether the file at osPath is generated. A file
+// that cannot be read is not, so that reading it fails where it is used.
+func (w *walker) isGenerated(osPath string) bool {
+	f, err := w.fsys.Open(osPath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	gen, _ := generated.Reader(f)
+	return gen
+}
+
+// Interpreter returns the name of the program the shebang line line runs,
+// without its directory and looking through env, or "" if line is not a
+// shebang line.
+func Interpreter(line []byte) string {
+	rest, ok := bytes.CutPrefix(line, []byte("#!"))
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(string(rest))
+	if len(fields) == 0 {
+		return ""
+	}
+	prog := path.Base(fields[0])
+	if prog != "env" {
+		return prog
+	}
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+			return path.Base(f)
+		}
+	}
+	return ""
+}
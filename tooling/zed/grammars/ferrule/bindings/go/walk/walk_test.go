@@ -0,0 +1,172 @@
+package walk_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/walk"
+)
+
+func setup(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		name = filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// found drains a walk and returns the sorted paths found.
+func found(t *testing.T, root string, opts walk.Options) []string {
+	t.Helper()
+	files, wait := walk.Files(root, opts)
+	var paths []string
+	for f := range files {
+		if f.OSPath != filepath.Join(root, filepath.FromSlash(f.Path)) || f.Info == nil {
+			t.Errorf("%s: OSPath %s, Info %v", f.Path, f.OSPath, f.Info)
+		}
+		paths = append(paths, f.Path)
+	}
+	if err := wait(); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestFiles(t *testing.T) {
+	root := setup(t, map[string]string{
+		".gitignore":      "build/\n*.gen.fe\n",
+		".ferruleignore":  "vendor/\n!keep.gen.fe\n",
+		"main.fe":         "",
+		"keep.gen.fe":     "",
+		"drop.gen.fe":     "",
+		"notes.md":        "",
+		"build/out.fe":    "",
+		"vendor/dep.fe":   "",
+		".git/hooks/x.fe": "",
+		"a/b/c/deep.fe":   "",
+		"a/b/.gitignore":  "local.fe\n",
+		"a/b/local.fe":    "",
+		"a/local.fe":      "",
+		"scripts/run":     "#!/usr/bin/env ferrule\n",
+		"scripts/direct":  "#!/opt/bin/ferrule -q\n",
+		"scripts/sh":      "#!/bin/sh\n",
+		"scripts/data":    "ferrule\n",
+		"api.pb.fe":       "// Code generated by protoc-gen-ferrule. DO NOT EDIT.\n",
+	})
+	got := strings.Join(found(t, root, walk.Options{Workers: 2}), " ")
+	want := "a/b/c/deep.fe a/local.fe api.pb.fe keep.gen.fe main.fe scripts/direct scripts/run"
+	if got != want {
+		t.Errorf("found %s\nwant  %s", got, want)
+	}
+
+	got = strings.Join(found(t, root, walk.Options{SkipGenerated: true}), " ")
+	if want := strings.Replace(want, "api.pb.fe ", "", 1); got != want {
+		t.Errorf("skipping generated files, found %s\nwant  %s", got, want)
+	}
+
+	got = strings.Join(found(t, root, walk.Options{Extensions: []string{".md"}, Interpreters: []string{}, IgnoreFiles: []string{}}), " ")
+	if got != "notes.md" {
+		t.Errorf("found %s, want notes.md", got)
+	}
+}
+
+func TestFilesRoot(t *testing.T) {
+	root := setup(t, map[string]string{"x.txt": ""})
+	files, wait := walk.Files(filepath.Join(root, "x.txt"), walk.Options{})
+	var n int
+	for f := range files {
+		if f.Path != "x.txt" {
+			t.Errorf("Path = %q", f.Path)
+		}
+		n++
+	}
+	if err := wait(); err != nil || n != 1 {
+		t.Errorf("got %d files, %v", n, err)
+	}
+
+	files, wait = walk.Files(filepath.Join(root, "missing"), walk.Options{})
+	for range files {
+	}
+	if err := wait(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("got %v, want a not-exist error", err)
+	}
+}
+
+func TestFilesUnreadable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Getuid() == 0 {
+		t.Skip("permissions are not enforced")
+	}
+	root := setup(t, map[string]string{"ok.fe": "", "locked/x.fe": ""})
+	if err := os.Chmod(filepath.Join(root, "locked"), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(root, "locked"), 0o755)
+	files, wait := walk.Files(root, walk.Options{})
+	var paths []string
+	for f := range files {
+		paths = append(paths, f.Path)
+	}
+	if err := wait(); err == nil || len(paths) != 1 || paths[0] != "ok.fe" {
+		t.Errorf("got %v, %v; want ok.fe and an error", paths, err)
+	}
+}
+
+func TestWalkDir(t *testing.T) {
+	root := setup(t, map[string]string{
+		".gitignore":     "build/\n",
+		".ferruleignore": "gen/\n",
+		"main.fe":        "",
+		"build/out.fe":   "",
+		"gen/api.fe":     "",
+		".git/HEAD":      "",
+		"a/.gitignore":   "*.tmp\n",
+		"a/x.fe":         "",
+		"a/x.tmp":        "",
+	})
+	var got []string
+	err := walk.WalkDir(nil, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ". .ferruleignore .gitignore a a/.gitignore a/x.fe main.fe"
+	if s := strings.Join(got, " "); s != want {
+		t.Errorf("visited %s\nwant    %s", s, want)
+	}
+}
+
+func TestInterpreter(t *testing.T) {
+	tests := map[string]string{
+		"#!/usr/bin/env ferrule\n":        "ferrule",
+		"#!/usr/bin/env -S ferrule run\n": "ferrule",
+		"#! /usr/local/bin/ferrule":       "ferrule",
+		"#!/usr/bin/env A=1 ferrule":      "ferrule",
+		"#!\n":                            "",
+		"ferrule\n":                       "",
+	}
+	for line, want := range tests {
+		if got := walk.Interpreter([]byte(line)); got != want {
+			t.Errorf("Interpreter(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+//go:build cgo && !ferrule_wasm
+
+package wasm_test
+
+import (
+	"testing"
+
+	tree_sitter_ferrule "github.com/karol-broda/ferrule/bindings/go"
+	"github.com/karol-broda/ferrule/bindings/go/wasm"
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// TestSameAsCgo checks that the trees of the module are those of the cgo
+// binding, node for node, including for sources with errors.
+func TestSameAsCgo(t *testing.T) {
+	parser := tree_sitter.NewParser()
+	defer parser.Close()
+	if err := parser.SetLanguage(tree_sitter.NewLanguage(tree_sitter_ferrule.Language())); err != nil {
+		t.Fatal(err)
+	}
+	cases := corpus(t)
+	for _, src := range []string{
+		"",
+		"const x = 1 $ 2;\nconst y = 1\n",
+		"function f( -> i32 {}",
+		"const s = \"unterminated\n\x00\xff é",
+		"match x { 1 => , _ => { return (; } }",
+		"type T = { a: i32, b: }; pub function",
+	} {
+		cases = append(cases, corpusCase{name: src, src: src})
+	}
+	for _, c := range cases {
+		want := parser.Parse([]byte(c.src), nil)
+		got := parse(t, c.src)
+		if g, w := got.RootNode().ToSexp(), want.RootNode().ToSexp(); g != w {
+			t.Errorf("%q:\ngot  %s\nwant %s", c.name, g, w)
+		} else {
+			compare(t, c.name, got.RootNode(), want.RootNode(), "")
+		}
+		want.Close()
+	}
+}
+
+func compare(t *testing.T, name string, got *wasm.Node, want *tree_sitter.Node, field string) {
+	t.Helper()
+	type attrs struct {
+		kind, grammar, field               string
+		named, extra, missing, err, hasErr bool
+		start, end, children, namedKids    uint
+		startPos, endPos                   [2]uint
+	}
+	g := attrs{
+		got.Kind(), got.GrammarName(), field,
+		got.IsNamed(), got.IsExtra(), got.IsMissing(), got.IsError(), got.HasError(),
+		got.StartByte(), got.EndByte(), got.ChildCount(), got.NamedChildCount(),
+		[2]uint{got.StartPosition().Row, got.StartPosition().Column}, [2]uint{got.EndPosition().Row, got.EndPosition().Column},
+	}
+	w := attrs{
+		want.Kind(), want.GrammarName(), field,
+		want.IsNamed(), want.IsExtra(), want.IsMissing(), want.IsError(), want.HasError(),
+		want.StartByte(), want.EndByte(), want.ChildCount(), want.NamedChildCount(),
+		[2]uint{want.StartPosition().Row, want.StartPosition().Column}, [2]uint{want.EndPosition().Row, want.EndPosition().Column},
+	}
+	if g != w {
+		t.Fatalf("%q: node\ngot  %+v\nwant %+v", name, g, w)
+	}
+	for i := uint(0); i < want.ChildCount(); i++ {
+		if gf, wf := got.FieldNameForChild(uint32(i)), want.FieldNameForChild(uint32(i)); gf != wf {
+			t.Fatalf("%q: field of child %d of %s: got %q, want %q", name, i, want.Kind(), gf, wf)
+		}
+		compare(t, name, got.Child(i), want.Child(i), want.FieldNameForChild(uint32(i)))
+	}
+}
@@ -0,0 +1,3 @@
+package wasm
+
+//go:generate go run ../cmd/ferrule-wasmgen ../../../src/parser.c
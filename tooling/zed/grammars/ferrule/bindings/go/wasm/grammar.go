@@ -0,0 +1,209 @@
+// Code generated by ferrule-wasmgen from parser.c. DO NOT EDIT.
+
+package wasm
+
+const (
+	grammarABI     = 15
+	grammarVersion = "0.1.0"
+)
+
+// symbolNames are the names of the node kinds, by symbol.
+var symbolNames = [...]string{
+	"end",
+	"identifier",
+	"line_comment",
+	"block_comment",
+	"type_identifier",
+	"package",
+	";",
+	".",
+	"import",
+	"as",
+	"use",
+	"error",
+	"pub",
+	"function",
+	"->",
+	"(",
+	",",
+	")",
+	"inout",
+	"cap",
+	":",
+	"effects",
+	"[",
+	"]",
+	"<",
+	">",
+	"in",
+	"out",
+	"type",
+	"=",
+	"where",
+	"domain",
+	"|",
+	"{",
+	"}",
+	"capability",
+	"component",
+	"const",
+	"var",
+	"i8",
+	"i16",
+	"i32",
+	"i64",
+	"i128",
+	"u8",
+	"u16",
+	"u32",
+	"u64",
+	"u128",
+	"usize",
+	"f16",
+	"f32",
+	"f64",
+	"Bool",
+	"Char",
+	"String",
+	"Bytes",
+	"Unit",
+	"Never",
+	"readonly",
+	"break",
+	"continue",
+	"defer",
+	"return",
+	"if",
+	"else",
+	"match",
+	"_",
+	"for",
+	"while",
+	"null",
+	"integer_literal",
+	"float_literal",
+	"\"",
+	"string_literal_token1",
+	"'",
+	"char_literal_token1",
+	"escape_sequence",
+	"true",
+	"false",
+	"-",
+	"!",
+	"~",
+	"||",
+	"&&",
+	"==",
+	"!=",
+	"<=",
+	">=",
+	"is",
+	"^",
+	"&",
+	"<<",
+	">>",
+	"+",
+	"++",
+	"..",
+	"..=",
+	"*",
+	"/",
+	"%",
+	"ok",
+	"err",
+	"check",
+	"source_file",
+	"_top_level_item",
+	"package_declaration",
+	"package_path",
+	"import_declaration",
+	"use_declaration",
+	"function_declaration",
+	"parameter_list",
+	"parameter",
+	"error_clause",
+	"effects_clause",
+	"type_parameters",
+	"type_parameter",
+	"type_declaration",
+	"domain_declaration",
+	"error_variant",
+	"error_declaration",
+	"capability_declaration",
+	"component_declaration",
+	"const_declaration",
+	"_type",
+	"_simple_type",
+	"primitive_type",
+	"generic_type",
+	"function_type",
+	"record_type",
+	"record_body",
+	"record_field",
+	"union_type",
+	"union_variant",
+	"_statement",
+	"expression_statement",
+	"return_statement",
+	"if_statement",
+	"match_statement",
+	"match_arm",
+	"pattern",
+	"destructuring_pattern",
+	"for_statement",
+	"while_statement",
+	"block",
+	"_expression",
+	"_primary_expression",
+	"_literal",
+	"string_literal",
+	"char_literal",
+	"boolean_literal",
+	"unary_expression",
+	"binary_expression",
+	"call_expression",
+	"member_expression",
+	"index_expression",
+	"parenthesized_expression",
+	"if_expression",
+	"match_expression",
+	"anonymous_function",
+	"record_expression",
+	"array_expression",
+	"ok_expression",
+	"err_expression",
+	"check_expression",
+	"source_file_repeat1",
+	"package_path_repeat1",
+	"parameter_list_repeat1",
+	"effects_clause_repeat1",
+	"type_parameters_repeat1",
+	"domain_declaration_repeat1",
+	"domain_declaration_repeat2",
+	"capability_declaration_repeat1",
+	"component_declaration_repeat1",
+	"generic_type_repeat1",
+	"record_type_repeat1",
+	"union_type_repeat1",
+	"match_statement_repeat1",
+	"block_repeat1",
+	"string_literal_repeat1",
+	"call_expression_repeat1",
+	"record_expression_repeat1",
+}
+
+// fieldNames are the field names, by field id.
+var fieldNames = [...]string{
+	"",
+	"alternative",
+	"body",
+	"condition",
+	"consequence",
+	"name",
+	"parameters",
+	"path",
+	"return_type",
+	"type",
+	"value",
+}
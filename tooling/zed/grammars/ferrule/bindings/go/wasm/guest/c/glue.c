@@ -0,0 +1,66 @@
+/* The C side of the guest module: parses a source and writes its syntax
+   tree to a buffer of 32-bit words, eleven per node, in preorder:
+
+     symbol, grammar symbol, field id, flags, child count,
+     start byte, end byte, start row, start column, end row, end column
+
+   where the flags are, from the lowest bit, named, extra, missing, error
+   and has error. Package wasm decodes the buffer in tree.go. */
+
+#include <stdint.h>
+#include <stdlib.h>
+#include <tree_sitter/api.h>
+
+const TSLanguage *tree_sitter_ferrule(void);
+
+static TSParser *parser;
+static uint32_t *out;
+static uint32_t out_len, out_cap;
+
+static void put(uint32_t v) {
+  if (out_len == out_cap) {
+    out_cap = out_cap ? out_cap * 2 : 1024;
+    out = realloc(out, out_cap * sizeof *out);
+  }
+  out[out_len++] = v;
+}
+
+uint32_t glue_len(void) { return out_len; }
+uint32_t *glue_out(void) { return out; }
+
+/* glue_parse parses the len bytes of src, writing its syntax tree to out,
+   and returns 0, or 1 if the parser gave up. */
+int glue_parse(const char *src, uint32_t len) {
+  if (!parser) {
+    parser = ts_parser_new();
+    ts_parser_set_language(parser, tree_sitter_ferrule());
+  }
+  out_len = 0;
+  TSTree *tree = ts_parser_parse_string(parser, NULL, src, len);
+  if (!tree) return 1;
+  TSTreeCursor c = ts_tree_cursor_new(ts_tree_root_node(tree));
+  for (;;) {
+    TSNode n = ts_tree_cursor_current_node(&c);
+    TSPoint s = ts_node_start_point(n), e = ts_node_end_point(n);
+    uint32_t flags = ts_node_is_named(n) | ts_node_is_extra(n) << 1 | ts_node_is_missing(n) << 2 |
+                     ts_node_is_error(n) << 3 | ts_node_has_error(n) << 4;
+    put(ts_node_symbol(n));
+    put(ts_node_grammar_symbol(n));
+    put(ts_tree_cursor_current_field_id(&c));
+    put(flags);
+    put(ts_node_child_count(n));
+    put(ts_node_start_byte(n));
+    put(ts_node_end_byte(n));
+    put(s.row); put(s.column); put(e.row); put(e.column);
+    if (ts_tree_cursor_goto_first_child(&c)) continue;
+    while (!ts_tree_cursor_goto_next_sibling(&c)) {
+      if (!ts_tree_cursor_goto_parent(&c)) goto done;
+    }
+  }
+done:
+  ts_tree_cursor_delete(&c);
+  ts_tree_delete(tree);
+  return 0;
+}
+
+const TSLanguage *glue_language(void) { return tree_sitter_ferrule(); }
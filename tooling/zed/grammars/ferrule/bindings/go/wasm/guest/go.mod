@@ -0,0 +1,3 @@
+module github.com/karol-broda/ferrule/bindings/go/wasm/guest
+
+go 1.24
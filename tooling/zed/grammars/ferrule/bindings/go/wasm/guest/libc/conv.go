@@ -0,0 +1,184 @@
+package libc
+
+import "unsafe"
+
+// The conversions below are those that ccgo emits for C's integer
+// conversions and comparisons, named as in modernc.org/libc.
+
+func Bool(v bool) bool { return v }
+
+func BoolInt8(b bool) int8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolInt16(b bool) int16 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolUint8(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolUint16(b bool) uint16 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolUint64(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func BoolUintptr(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func Int8FromInt8(n int8) int8             { return int8(n) }
+func Int8FromInt16(n int16) int8           { return int8(n) }
+func Int8FromInt32(n int32) int8           { return int8(n) }
+func Int8FromInt64(n int64) int8           { return int8(n) }
+func Int8FromUint8(n uint8) int8           { return int8(n) }
+func Int8FromUint16(n uint16) int8         { return int8(n) }
+func Int8FromUint32(n uint32) int8         { return int8(n) }
+func Int8FromUint64(n uint64) int8         { return int8(n) }
+func Int8FromUintptr(n uintptr) int8       { return int8(n) }
+func Int16FromInt8(n int8) int16           { return int16(n) }
+func Int16FromInt16(n int16) int16         { return int16(n) }
+func Int16FromInt32(n int32) int16         { return int16(n) }
+func Int16FromInt64(n int64) int16         { return int16(n) }
+func Int16FromUint8(n uint8) int16         { return int16(n) }
+func Int16FromUint16(n uint16) int16       { return int16(n) }
+func Int16FromUint32(n uint32) int16       { return int16(n) }
+func Int16FromUint64(n uint64) int16       { return int16(n) }
+func Int16FromUintptr(n uintptr) int16     { return int16(n) }
+func Int32FromInt8(n int8) int32           { return int32(n) }
+func Int32FromInt16(n int16) int32         { return int32(n) }
+func Int32FromInt32(n int32) int32         { return int32(n) }
+func Int32FromInt64(n int64) int32         { return int32(n) }
+func Int32FromUint8(n uint8) int32         { return int32(n) }
+func Int32FromUint16(n uint16) int32       { return int32(n) }
+func Int32FromUint32(n uint32) int32       { return int32(n) }
+func Int32FromUint64(n uint64) int32       { return int32(n) }
+func Int32FromUintptr(n uintptr) int32     { return int32(n) }
+func Int64FromInt8(n int8) int64           { return int64(n) }
+func Int64FromInt16(n int16) int64         { return int64(n) }
+func Int64FromInt32(n int32) int64         { return int64(n) }
+func Int64FromInt64(n int64) int64         { return int64(n) }
+func Int64FromUint8(n uint8) int64         { return int64(n) }
+func Int64FromUint16(n uint16) int64       { return int64(n) }
+func Int64FromUint32(n uint32) int64       { return int64(n) }
+func Int64FromUint64(n uint64) int64       { return int64(n) }
+func Int64FromUintptr(n uintptr) int64     { return int64(n) }
+func Uint8FromInt8(n int8) uint8           { return uint8(n) }
+func Uint8FromInt16(n int16) uint8         { return uint8(n) }
+func Uint8FromInt32(n int32) uint8         { return uint8(n) }
+func Uint8FromInt64(n int64) uint8         { return uint8(n) }
+func Uint8FromUint8(n uint8) uint8         { return uint8(n) }
+func Uint8FromUint16(n uint16) uint8       { return uint8(n) }
+func Uint8FromUint32(n uint32) uint8       { return uint8(n) }
+func Uint8FromUint64(n uint64) uint8       { return uint8(n) }
+func Uint8FromUintptr(n uintptr) uint8     { return uint8(n) }
+func Uint16FromInt8(n int8) uint16         { return uint16(n) }
+func Uint16FromInt16(n int16) uint16       { return uint16(n) }
+func Uint16FromInt32(n int32) uint16       { return uint16(n) }
+func Uint16FromInt64(n int64) uint16       { return uint16(n) }
+func Uint16FromUint8(n uint8) uint16       { return uint16(n) }
+func Uint16FromUint16(n uint16) uint16     { return uint16(n) }
+func Uint16FromUint32(n uint32) uint16     { return uint16(n) }
+func Uint16FromUint64(n uint64) uint16     { return uint16(n) }
+func Uint16FromUintptr(n uintptr) uint16   { return uint16(n) }
+func Uint32FromInt8(n int8) uint32         { return uint32(n) }
+func Uint32FromInt16(n int16) uint32       { return uint32(n) }
+func Uint32FromInt32(n int32) uint32       { return uint32(n) }
+func Uint32FromInt64(n int64) uint32       { return uint32(n) }
+func Uint32FromUint8(n uint8) uint32       { return uint32(n) }
+func Uint32FromUint16(n uint16) uint32     { return uint32(n) }
+func Uint32FromUint32(n uint32) uint32     { return uint32(n) }
+func Uint32FromUint64(n uint64) uint32     { return uint32(n) }
+func Uint32FromUintptr(n uintptr) uint32   { return uint32(n) }
+func Uint64FromInt8(n int8) uint64         { return uint64(n) }
+func Uint64FromInt16(n int16) uint64       { return uint64(n) }
+func Uint64FromInt32(n int32) uint64       { return uint64(n) }
+func Uint64FromInt64(n int64) uint64       { return uint64(n) }
+func Uint64FromUint8(n uint8) uint64       { return uint64(n) }
+func Uint64FromUint16(n uint16) uint64     { return uint64(n) }
+func Uint64FromUint32(n uint32) uint64     { return uint64(n) }
+func Uint64FromUint64(n uint64) uint64     { return uint64(n) }
+func Uint64FromUintptr(n uintptr) uint64   { return uint64(n) }
+func UintptrFromInt8(n int8) uintptr       { return uintptr(n) }
+func UintptrFromInt16(n int16) uintptr     { return uintptr(n) }
+func UintptrFromInt32(n int32) uintptr     { return uintptr(n) }
+func UintptrFromInt64(n int64) uintptr     { return uintptr(n) }
+func UintptrFromUint8(n uint8) uintptr     { return uintptr(n) }
+func UintptrFromUint16(n uint16) uintptr   { return uintptr(n) }
+func UintptrFromUint32(n uint32) uintptr   { return uintptr(n) }
+func UintptrFromUint64(n uint64) uintptr   { return uintptr(n) }
+func UintptrFromUintptr(n uintptr) uintptr { return uintptr(n) }
+
+func SetBitFieldPtr8Uint8(p uintptr, v uint8, off int, mask uint8) {
+	*(*uint8)(unsafe.Pointer(p)) = *(*uint8)(unsafe.Pointer(p))&^mask | v<<off&mask
+}
+
+func SetBitFieldPtr16Uint8(p uintptr, v uint8, off int, mask uint16) {
+	*(*uint16)(unsafe.Pointer(p)) = *(*uint16)(unsafe.Pointer(p))&^mask | uint16(v)<<off&mask
+}
+
+func SetBitFieldPtr16Uint16(p uintptr, v uint16, off int, mask uint16) {
+	*(*uint16)(unsafe.Pointer(p)) = *(*uint16)(unsafe.Pointer(p))&^mask | v<<off&mask
+}
+
+func AssignBitFieldPtr8Uint8(p uintptr, v uint8, w, off int, mask uint8) uint8 {
+	*(*uint8)(unsafe.Pointer(p)) = *(*uint8)(unsafe.Pointer(p))&^mask | v<<off&mask
+	return v & (mask >> off)
+}
+
+func PostIncBitFieldPtr8Uint8(p uintptr, d uint8, w, off int, mask uint8) (r uint8) {
+	x0 := *(*uint8)(unsafe.Pointer(p))
+	r = x0 & mask >> off
+	*(*uint8)(unsafe.Pointer(p)) = x0&^mask | (r+d)<<off&mask
+	return r
+}
+
+func PostIncBitFieldPtr16Uint16(p uintptr, d uint16, w, off int, mask uint16) (r uint16) {
+	x0 := *(*uint16)(unsafe.Pointer(p))
+	r = x0 & mask >> off
+	*(*uint16)(unsafe.Pointer(p)) = x0&^mask | (r+d)<<off&mask
+	return r
+}
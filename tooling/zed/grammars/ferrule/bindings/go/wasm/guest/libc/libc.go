@@ -0,0 +1,299 @@
+// Package libc is the C library of the WebAssembly build of the parser:
+// the part of the C library that the tree-sitter runtime and the ferrule
+// grammar use once translated to Go by ccgo, which calls into it where the
+// C code called the C library.
+//
+// It stands in for modernc.org/libc, which does not support wasip1. Memory
+// comes from the Go heap and is kept alive by the allocator until freed,
+// the numeric conversions and bit-field helpers are those of
+// modernc.org/libc, and printf formats the few verbs that the runtime uses
+// in its logging and debugging output. Files other than the standard error
+// are not supported.
+package libc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unsafe"
+)
+
+// TLS is the thread of the C code, which holds the stack memory that Alloc
+// hands out to C functions taking the address of their locals.
+type TLS struct {
+	chunks [][]uint64
+	cur    int   // index of the chunk in use
+	sp     int   // bytes used of the chunk in use
+	saved  []int // sp of the chunks before cur
+}
+
+const chunkSize = 64 << 10
+
+// NewTLS returns a thread with an empty stack.
+func NewTLS() *TLS {
+	return &TLS{chunks: [][]uint64{make([]uint64, chunkSize/8)}}
+}
+
+// size rounds n up to a multiple of eight, and of at least eight so that
+// only the first allocation of a chunk starts at its start.
+func size(n int) int {
+	return max(n+7, 8) &^ 7
+}
+
+// Alloc allocates n bytes of stack, eight-byte aligned, which Free
+// releases; calls to Alloc and Free are nested.
+func (t *TLS) Alloc(n int) uintptr {
+	n = size(n)
+	if t.sp+n > len(t.chunks[t.cur])*8 {
+		t.saved = append(t.saved, t.sp)
+		t.cur++
+		t.sp = 0
+		if t.cur == len(t.chunks) || len(t.chunks[t.cur])*8 < n {
+			t.chunks = append(t.chunks[:t.cur], make([]uint64, max(n, chunkSize)/8))
+		}
+	}
+	p := uintptr(unsafe.Pointer(&t.chunks[t.cur][0])) + uintptr(t.sp)
+	t.sp += n
+	return p
+}
+
+// Free releases the memory of the last Alloc, of n bytes.
+func (t *TLS) Free(n int) {
+	t.sp -= size(n)
+	if t.sp == 0 && t.cur > 0 {
+		t.cur--
+		t.sp = t.saved[len(t.saved)-1]
+		t.saved = t.saved[:len(t.saved)-1]
+	}
+}
+
+// heap keeps the blocks handed out by Xmalloc alive until they are freed,
+// since the C code holds them by address only.
+var heap = make(map[uintptr][]uint64)
+
+func Xmalloc(tls *TLS, n uint64) uintptr {
+	b := make([]uint64, max(n, 1)/8+1)
+	p := uintptr(unsafe.Pointer(&b[0]))
+	heap[p] = b
+	return p
+}
+
+func Xcalloc(tls *TLS, count, size uint64) uintptr {
+	return Xmalloc(tls, count*size)
+}
+
+func Xrealloc(tls *TLS, p uintptr, n uint64) uintptr {
+	q := Xmalloc(tls, n)
+	if p != 0 {
+		copy(heap[q], heap[p])
+		Xfree(tls, p)
+	}
+	return q
+}
+
+func Xfree(tls *TLS, p uintptr) {
+	delete(heap, p)
+}
+
+func bytes(p uintptr, n uint64) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), n)
+}
+
+func Xmemcpy(tls *TLS, dst, src uintptr, n uint64) uintptr {
+	if n != 0 {
+		copy(bytes(dst, n), bytes(src, n))
+	}
+	return dst
+}
+
+func Xmemmove(tls *TLS, dst, src uintptr, n uint64) uintptr {
+	return Xmemcpy(tls, dst, src, n)
+}
+
+func Xmemset(tls *TLS, p uintptr, c int32, n uint64) uintptr {
+	b := bytes(p, n)
+	for i := range b {
+		b[i] = byte(c)
+	}
+	return p
+}
+
+func Xmemcmp(tls *TLS, l, r uintptr, n uint64) int32 {
+	for i := uint64(0); i < n; i++ {
+		a, b := *(*byte)(unsafe.Pointer(l + uintptr(i))), *(*byte)(unsafe.Pointer(r + uintptr(i)))
+		if a != b {
+			return int32(a) - int32(b)
+		}
+	}
+	return 0
+}
+
+func Xstrncmp(tls *TLS, l, r uintptr, n uint64) int32 {
+	for i := uint64(0); i < n; i++ {
+		a, b := *(*byte)(unsafe.Pointer(l + uintptr(i))), *(*byte)(unsafe.Pointer(r + uintptr(i)))
+		if a != b || a == 0 {
+			return int32(a) - int32(b)
+		}
+	}
+	return 0
+}
+
+// GoString returns the NUL-terminated string at p.
+func GoString(p uintptr) string {
+	if p == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for ; *(*byte)(unsafe.Pointer(p)) != 0; p++ {
+		b.WriteByte(*(*byte)(unsafe.Pointer(p)))
+	}
+	return b.String()
+}
+
+func Xiswalnum(tls *TLS, c uint32) int32 {
+	return BoolInt32(unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c)))
+}
+
+func Xiswspace(tls *TLS, c uint32) int32 {
+	return BoolInt32(unicode.IsSpace(rune(c)))
+}
+
+func Xclock_gettime(tls *TLS, clock int32, ts uintptr) int32 {
+	now := time.Now().UnixNano()
+	*(*[2]int64)(unsafe.Pointer(ts)) = [2]int64{now / 1e9, now % 1e9}
+	return 0
+}
+
+func X__assert_fail(tls *TLS, expr, file uintptr, line int32, fn uintptr) {
+	panic(fmt.Sprintf("%s:%d: %s: assertion failed: %s", GoString(file), line, GoString(fn), GoString(expr)))
+}
+
+func Xabort(tls *TLS) {
+	panic("abort")
+}
+
+// Xstderr is the standard error, the one file that the functions below
+// write to. The runtime opens others only to write graphs for debugging.
+var Xstderr = uintptr(unsafe.Pointer(new(int64)))
+
+func Xdup(tls *TLS, fd int32) int32 { return -1 }
+
+func Xfdopen(tls *TLS, fd int32, mode uintptr) uintptr { return 0 }
+
+func Xfclose(tls *TLS, f uintptr) int32 { return 0 }
+
+func write(f uintptr, b []byte) int32 {
+	if f == Xstderr {
+		os.Stderr.Write(b)
+	}
+	return int32(len(b))
+}
+
+func Xfputs(tls *TLS, s, f uintptr) int32 {
+	return write(f, []byte(GoString(s)))
+}
+
+func Xfputc(tls *TLS, c int32, f uintptr) int32 {
+	write(f, []byte{byte(c)})
+	return c
+}
+
+func Xfprintf(tls *TLS, f, format, va uintptr) int32 {
+	return write(f, printf(format, va))
+}
+
+func X__builtin_snprintf(tls *TLS, s uintptr, n uint64, format, va uintptr) int32 {
+	return X__builtin_vsnprintf(tls, s, n, format, va)
+}
+
+func X__builtin_vsnprintf(tls *TLS, s uintptr, n uint64, format, va uintptr) int32 {
+	b := printf(format, va)
+	if n > 0 {
+		m := copy(bytes(s, n-1), b)
+		*(*byte)(unsafe.Pointer(s + uintptr(m))) = 0
+	}
+	return int32(len(b))
+}
+
+// printf formats the arguments at va as C's printf does, for the
+// conversions d, i, u, x, X, c, s and p, with flags, widths and length
+// modifiers.
+func printf(format, va uintptr) []byte {
+	var out []byte
+	f := GoString(format)
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			out = append(out, f[i])
+			continue
+		}
+		j := i + 1
+		for j < len(f) && strings.IndexByte("-+ #0123456789.", f[j]) >= 0 {
+			j++
+		}
+		spec := "%" + f[i+1:j]
+		for j < len(f) && strings.IndexByte("hlzjt", f[j]) >= 0 {
+			j++
+		}
+		if j == len(f) {
+			break
+		}
+		i = j
+		if f[j] == '%' {
+			out = append(out, '%')
+			continue
+		}
+		arg := *(*uint64)(unsafe.Pointer(va))
+		va += 8
+		switch f[j] {
+		case 'd', 'i':
+			out = fmt.Appendf(out, spec+"d", int32(arg))
+		case 'u':
+			out = fmt.Appendf(out, spec+"d", uint32(arg))
+		case 'x', 'X':
+			out = fmt.Appendf(out, spec+string(f[j]), uint32(arg))
+		case 'c':
+			out = append(out, byte(arg))
+		case 's':
+			out = fmt.Appendf(out, spec+"s", GoString(uintptr(arg)))
+		case 'p':
+			out = fmt.Appendf(out, "%#x", arg)
+		}
+	}
+	return out
+}
+
+// VaList writes args to the stack memory at p, eight bytes each, for a
+// variadic function to read, and returns p.
+func VaList(p uintptr, args ...interface{}) uintptr {
+	r := p
+	for _, v := range args {
+		var w uint64
+		switch x := v.(type) {
+		case int32:
+			w = uint64(int64(x))
+		case int64:
+			w = uint64(x)
+		case uint16:
+			w = uint64(x)
+		case uint32:
+			w = uint64(x)
+		case uint64:
+			w = x
+		case uintptr:
+			w = uint64(x)
+		default:
+			panic(fmt.Sprintf("libc: variadic argument of type %T", v))
+		}
+		*(*uint64)(unsafe.Pointer(p)) = w
+		p += 8
+	}
+	return r
+}
+
+// The C code is single threaded, so the atomics are plain loads and stores.
+
+func AtomicLoadPUint32(p uintptr) uint32     { return *(*uint32)(unsafe.Pointer(p)) }
+func AtomicStorePUint32(p uintptr, v uint32) { *(*uint32)(unsafe.Pointer(p)) = v }
+func AtomicLoadPUint64(p uintptr) uint64     { return *(*uint64)(unsafe.Pointer(p)) }
@@ -0,0 +1,83 @@
+//go:build wasip1
+
+// Command guest is the WebAssembly module that package wasm runs: the
+// ferrule grammar and the tree-sitter runtime, translated from C to Go by
+// ccgo and built for wasip1 as a reactor, whose exports package wasm calls.
+//
+// The translated C code goes in ts.go, which the generator of package wasm
+// writes next to this file; this module does not build without it.
+package main
+
+import (
+	"encoding/json"
+	"unsafe"
+
+	"github.com/karol-broda/ferrule/bindings/go/wasm/guest/libc"
+)
+
+func main() {}
+
+var tls = libc.NewTLS()
+
+// source holds the source of the next parse; its last byte is a NUL.
+var source []byte
+
+// result keeps the bytes that the last export returned alive.
+var result []byte
+
+// pack returns the address and length of b in one word.
+func pack(b []byte) uint64 {
+	result = b
+	if len(b) == 0 {
+		return 0
+	}
+	return uint64(uintptr(unsafe.Pointer(&b[0])))<<32 | uint64(len(b))
+}
+
+// exportAlloc returns the address of a buffer of n bytes, to which the
+// host writes the source of the next parse.
+//
+//go:wasmexport alloc
+func exportAlloc(n uint32) uint32 {
+	source = make([]byte, n+1)
+	return uint32(uintptr(unsafe.Pointer(&source[0])))
+}
+
+// exportLanguage returns the metadata of the grammar, as JSON, for the
+// generator of package wasm.
+//
+//go:wasmexport language
+func exportLanguage() uint64 {
+	l := glue_language(tls)
+	m := (*TSLanguageMetadata)(unsafe.Pointer(ts_language_metadata(tls, l)))
+	var lang struct {
+		ABI     uint32   `json:"abi"`
+		Version [3]uint8 `json:"version"`
+		Symbols []string `json:"symbols"`
+		Fields  []string `json:"fields"`
+	}
+	lang.ABI = ts_language_abi_version(tls, l)
+	lang.Version = [3]uint8{m.Fmajor_version, m.Fminor_version, m.Fpatch_version}
+	for i := uint32(0); i < ts_language_symbol_count(tls, l); i++ {
+		lang.Symbols = append(lang.Symbols, libc.GoString(ts_language_symbol_name(tls, l, TSSymbol(i))))
+	}
+	for i := uint32(0); i <= ts_language_field_count(tls, l); i++ {
+		lang.Fields = append(lang.Fields, libc.GoString(ts_language_field_name_for_id(tls, l, TSFieldId(i))))
+	}
+	b, err := json.Marshal(lang)
+	if err != nil {
+		panic(err)
+	}
+	return pack(b)
+}
+
+// exportParse parses the n bytes of source and returns its syntax tree as
+// glue_parse writes it, or nothing if the parser gave up.
+//
+//go:wasmexport parse
+func exportParse(n uint32) uint64 {
+	if glue_parse(tls, uintptr(unsafe.Pointer(&source[0])), n) != 0 {
+		return pack(nil)
+	}
+	return pack(unsafe.Slice((*byte)(unsafe.Pointer(glue_out(tls))), 4*glue_len(tls)))
+}
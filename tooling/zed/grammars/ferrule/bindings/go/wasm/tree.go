@@ -0,0 +1,336 @@
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// symbolError and symbolErrorRepair are the symbols of the runtime's
+// error nodes, which are not in symbolNames.
+const (
+	symbolError       = 0xFFFF
+	symbolErrorRepair = 0xFFFE
+)
+
+// The flags of a node, as glue_parse writes them.
+const (
+	flagNamed = 1 << iota
+	flagExtra
+	flagMissing
+	flagError
+	flagHasError
+)
+
+// record is a node as glue_parse writes it, in eleven words.
+type record struct {
+	symbol, grammarSymbol, field, flags, childCount uint32
+	startByte, endByte                              uint32
+	startRow, startColumn, endRow, endColumn        uint32
+}
+
+const recordSize = 11 * 4
+
+// A Tree is the syntax tree of a source.
+type Tree struct {
+	source  []byte
+	records []record
+	parent  []int32
+	index   []int32 // index of each node among the children of its parent
+	first   []int32 // offset in kids of the children of each node
+	kids    []int32
+}
+
+// newTree decodes the nodes that glue_parse writes, in preorder, of the
+// tree of source.
+func newTree(source, data []byte) (*Tree, error) {
+	if len(data) == 0 || len(data)%recordSize != 0 {
+		return nil, fmt.Errorf("wasm: tree of %d bytes", len(data))
+	}
+	n := len(data) / recordSize
+	t := &Tree{
+		source:  source,
+		records: make([]record, n),
+		parent:  make([]int32, n),
+		index:   make([]int32, n),
+		first:   make([]int32, n+1),
+	}
+	for i := range t.records {
+		var w [11]uint32
+		for j := range w {
+			w[j] = binary.LittleEndian.Uint32(data[i*recordSize+j*4:])
+		}
+		t.records[i] = record{w[0], w[1], w[2], w[3], w[4], w[5], w[6], w[7], w[8], w[9], w[10]}
+		t.first[i+1] = t.first[i] + int32(w[4])
+	}
+	t.kids = make([]int32, t.first[n])
+
+	// Each node after the root is the next child of the innermost node
+	// that still misses children.
+	var open []int32
+	next := make([]int32, n)
+	copy(next, t.first[:n])
+	t.parent[0] = -1
+	for i := int32(1); i < int32(n); i++ {
+		if t.records[i-1].childCount > 0 {
+			open = append(open, i-1)
+		}
+		if len(open) == 0 {
+			return nil, errors.New("wasm: malformed tree")
+		}
+		p := open[len(open)-1]
+		t.parent[i] = p
+		t.index[i] = next[p] - t.first[p]
+		t.kids[next[p]] = i
+		if next[p]++; next[p] == t.first[p+1] {
+			open = open[:len(open)-1]
+		}
+	}
+	return t, nil
+}
+
+// RootNode returns the root node of the tree.
+func (t *Tree) RootNode() *Node {
+	return &Node{t, 0}
+}
+
+func (t *Tree) node(id int32) *Node {
+	if id < 0 {
+		return nil
+	}
+	return &Node{t, id}
+}
+
+// A Point is a position in a source, as a zero-based row and byte column.
+type Point struct {
+	Row    uint
+	Column uint
+}
+
+// A Node is a node of a Tree.
+type Node struct {
+	tree *Tree
+	id   int32
+}
+
+func (n *Node) rec() *record {
+	return &n.tree.records[n.id]
+}
+
+func symbolName(symbol uint32) string {
+	switch {
+	case symbol == symbolError:
+		return "ERROR"
+	case symbol == symbolErrorRepair:
+		return "_ERROR"
+	case symbol < uint32(len(symbolNames)):
+		return symbolNames[symbol]
+	}
+	return ""
+}
+
+// Kind returns the kind of the node, such as "function_declaration".
+func (n *Node) Kind() string { return symbolName(n.rec().symbol) }
+
+// KindId returns the symbol of the kind of the node.
+func (n *Node) KindId() uint16 { return uint16(n.rec().symbol) }
+
+// GrammarName returns the kind of the node in the grammar, before aliases.
+func (n *Node) GrammarName() string { return symbolName(n.rec().grammarSymbol) }
+
+// GrammarId returns the symbol of GrammarName.
+func (n *Node) GrammarId() uint16 { return uint16(n.rec().grammarSymbol) }
+
+// IsNamed reports whether the node is named in the grammar, rather than a
+// string literal of it.
+func (n *Node) IsNamed() bool { return n.rec().flags&flagNamed != 0 }
+
+// IsExtra reports whether the node is an extra, such as a comment.
+func (n *Node) IsExtra() bool { return n.rec().flags&flagExtra != 0 }
+
+// IsMissing reports whether the parser inserted the node to recover from
+// an error.
+func (n *Node) IsMissing() bool { return n.rec().flags&flagMissing != 0 }
+
+// IsError reports whether the node is an ERROR node.
+func (n *Node) IsError() bool { return n.rec().flags&flagError != 0 }
+
+// HasError reports whether the node is or contains an error.
+func (n *Node) HasError() bool { return n.rec().flags&flagHasError != 0 }
+
+// StartByte returns the offset of the start of the node.
+func (n *Node) StartByte() uint { return uint(n.rec().startByte) }
+
+// EndByte returns the offset of the end of the node.
+func (n *Node) EndByte() uint { return uint(n.rec().endByte) }
+
+// ByteRange returns StartByte and EndByte.
+func (n *Node) ByteRange() (uint, uint) { return n.StartByte(), n.EndByte() }
+
+// StartPosition returns the position of the start of the node.
+func (n *Node) StartPosition() Point {
+	r := n.rec()
+	return Point{uint(r.startRow), uint(r.startColumn)}
+}
+
+// EndPosition returns the position of the end of the node.
+func (n *Node) EndPosition() Point {
+	r := n.rec()
+	return Point{uint(r.endRow), uint(r.endColumn)}
+}
+
+// Utf8Text returns the text of the node in source.
+func (n *Node) Utf8Text(source []byte) string {
+	return string(source[n.StartByte():n.EndByte()])
+}
+
+func (n *Node) children() []int32 {
+	t := n.tree
+	return t.kids[t.first[n.id]:t.first[n.id+1]]
+}
+
+// ChildCount returns the number of children of the node.
+func (n *Node) ChildCount() uint { return uint(n.rec().childCount) }
+
+// Child returns the child at index i, or nil.
+func (n *Node) Child(i uint) *Node {
+	if kids := n.children(); i < uint(len(kids)) {
+		return n.tree.node(kids[i])
+	}
+	return nil
+}
+
+// NamedChildCount returns the number of named children of the node.
+func (n *Node) NamedChildCount() uint {
+	var count uint
+	for _, id := range n.children() {
+		if n.tree.records[id].flags&flagNamed != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// NamedChild returns the named child at index i among the named children,
+// or nil.
+func (n *Node) NamedChild(i uint) *Node {
+	for _, id := range n.children() {
+		if n.tree.records[id].flags&flagNamed == 0 {
+			continue
+		}
+		if i == 0 {
+			return n.tree.node(id)
+		}
+		i--
+	}
+	return nil
+}
+
+// FieldNameForChild returns the field name of the child at index i, or ""
+// for a child that is not in a field.
+func (n *Node) FieldNameForChild(i uint32) string {
+	if kids := n.children(); i < uint32(len(kids)) {
+		return fieldNames[n.tree.records[kids[i]].field]
+	}
+	return ""
+}
+
+// ChildByFieldName returns the first child in the named field, or nil.
+func (n *Node) ChildByFieldName(name string) *Node {
+	for _, id := range n.children() {
+		if f := n.tree.records[id].field; f != 0 && fieldNames[f] == name {
+			return n.tree.node(id)
+		}
+	}
+	return nil
+}
+
+// Parent returns the parent of the node, or nil for the root.
+func (n *Node) Parent() *Node { return n.tree.node(n.tree.parent[n.id]) }
+
+func (n *Node) sibling(delta int32, named bool) *Node {
+	p := n.tree.parent[n.id]
+	if p < 0 {
+		return nil
+	}
+	kids := (&Node{n.tree, p}).children()
+	for i := n.tree.index[n.id] + delta; 0 <= i && i < int32(len(kids)); i += delta {
+		if !named || n.tree.records[kids[i]].flags&flagNamed != 0 {
+			return n.tree.node(kids[i])
+		}
+	}
+	return nil
+}
+
+// NextSibling returns the next sibling of the node, or nil.
+func (n *Node) NextSibling() *Node { return n.sibling(1, false) }
+
+// PrevSibling returns the previous sibling of the node, or nil.
+func (n *Node) PrevSibling() *Node { return n.sibling(-1, false) }
+
+// NextNamedSibling returns the next named sibling of the node, or nil.
+func (n *Node) NextNamedSibling() *Node { return n.sibling(1, true) }
+
+// PrevNamedSibling returns the previous named sibling of the node, or nil.
+func (n *Node) PrevNamedSibling() *Node { return n.sibling(-1, true) }
+
+// ToSexp returns the node as an S-expression of its named descendants, as
+// tree-sitter prints nodes.
+func (n *Node) ToSexp() string {
+	var b strings.Builder
+	n.sexp(&b, true)
+	return b.String()
+}
+
+func (n *Node) sexp(b *strings.Builder, root bool) {
+	r := n.rec()
+	visible := n.IsNamed() || n.IsMissing()
+	switch {
+	case visible:
+		if !root {
+			b.WriteByte(' ')
+			if r.field != 0 {
+				b.WriteString(fieldNames[r.field] + ": ")
+			}
+		}
+		switch {
+		case n.IsError() && r.childCount == 0 && r.endByte > r.startByte:
+			b.WriteString("(UNEXPECTED ")
+			c, size := utf8.DecodeRune(n.tree.source[r.startByte:r.endByte])
+			switch {
+			case c == utf8.RuneError && size == 1:
+				b.WriteString("INVALID")
+			case c == 0:
+				b.WriteString(`'\0'`)
+			case c == '\n':
+				b.WriteString(`'\n'`)
+			case c == '\t':
+				b.WriteString(`'\t'`)
+			case c == '\r':
+				b.WriteString(`'\r'`)
+			case ' ' <= c && c <= '~':
+				fmt.Fprintf(b, "'%c'", c)
+			default:
+				fmt.Fprintf(b, "%d", c)
+			}
+		case n.IsMissing() && n.IsNamed():
+			b.WriteString("(MISSING " + n.Kind())
+		case n.IsMissing():
+			b.WriteString(`(MISSING "` + n.Kind() + `"`)
+		default:
+			b.WriteString("(" + n.Kind())
+		}
+	case root && r.childCount > 0:
+		b.WriteString("(" + n.Kind())
+	case root:
+		b.WriteString(`("` + n.Kind() + `")`)
+	}
+	for _, id := range n.children() {
+		(&Node{n.tree, id}).sexp(b, false)
+	}
+	if visible {
+		b.WriteByte(')')
+	}
+}
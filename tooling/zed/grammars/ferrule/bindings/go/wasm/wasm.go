@@ -0,0 +1,121 @@
+// Package wasm parses ferrule without cgo: it runs the grammar and the
+// tree-sitter runtime, compiled to WebAssembly, under wazero. The syntax
+// trees it returns are read-only copies of those of the runtime, whose
+// nodes have the methods of go-tree-sitter's Node that do not need cgo.
+//
+// It is the parser of builds with CGO_ENABLED=0 or the ferrule_wasm build
+// tag, in which the root package of the binding has no Language. The rest
+// of the packages of the binding use go-tree-sitter and need cgo.
+//
+// The module is compiled the first time a Parser is made, which takes a
+// couple of seconds; each Parser then makes an instance of it, its own
+// WebAssembly memory, in a few milliseconds. ferrule.wasm.gz is generated
+// by ferrule-wasmgen from the same parser.c and tree-sitter runtime as the
+// cgo binding, so the trees of both are the same.
+package wasm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+//go:embed ferrule.wasm.gz
+var module []byte
+
+// Version returns the semantic version of the grammar, such as "0.1.0".
+func Version() string { return grammarVersion }
+
+// GrammarABI returns the tree-sitter ABI version the parser was generated
+// for.
+func GrammarABI() uint32 { return grammarABI }
+
+var compiled struct {
+	once    sync.Once
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+	err     error
+}
+
+// compile compiles the module, once per process.
+func compile() (wazero.Runtime, wazero.CompiledModule, error) {
+	compiled.once.Do(func() {
+		ctx := context.Background()
+		zr, err := gzip.NewReader(bytes.NewReader(module))
+		if err != nil {
+			compiled.err = err
+			return
+		}
+		bin, err := io.ReadAll(zr)
+		if err != nil {
+			compiled.err = err
+			return
+		}
+		compiled.runtime = wazero.NewRuntime(ctx)
+		wasi_snapshot_preview1.MustInstantiate(ctx, compiled.runtime)
+		compiled.module, compiled.err = compiled.runtime.CompileModule(ctx, bin)
+	})
+	return compiled.runtime, compiled.module, compiled.err
+}
+
+// A Parser parses ferrule sources. A Parser is not safe for concurrent
+// use, but different parsers may be used concurrently.
+type Parser struct {
+	mod          api.Module
+	alloc, parse api.Function
+}
+
+// NewParser returns a parser, which Close releases.
+func NewParser(ctx context.Context) (*Parser, error) {
+	r, cm, err := compile()
+	if err != nil {
+		return nil, fmt.Errorf("wasm: compiling the parser: %w", err)
+	}
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithSysNanotime().
+		WithSysWalltime()
+	mod, err := r.InstantiateModule(ctx, cm, config)
+	if err != nil {
+		return nil, fmt.Errorf("wasm: %w", err)
+	}
+	return &Parser{mod: mod, alloc: mod.ExportedFunction("alloc"), parse: mod.ExportedFunction("parse")}, nil
+}
+
+// Close releases the memory of the parser. Trees it returned stay valid.
+func (p *Parser) Close(ctx context.Context) error {
+	return p.mod.Close(ctx)
+}
+
+// Parse parses source, which the tree keeps and must not be modified.
+func (p *Parser) Parse(ctx context.Context, source []byte) (*Tree, error) {
+	res, err := p.alloc.Call(ctx, uint64(len(source)))
+	if err != nil {
+		return nil, fmt.Errorf("wasm: %w", err)
+	}
+	mem := p.mod.Memory()
+	if !mem.Write(uint32(res[0]), source) {
+		return nil, errors.New("wasm: source out of memory range")
+	}
+	if res, err = p.parse.Call(ctx, uint64(len(source))); err != nil {
+		return nil, fmt.Errorf("wasm: %w", err)
+	}
+	if res[0] == 0 {
+		return nil, errors.New("wasm: parsing failed")
+	}
+	data, ok := mem.Read(uint32(res[0]>>32), uint32(res[0]))
+	if !ok {
+		return nil, errors.New("wasm: tree out of memory range")
+	}
+	return newTree(source, data)
+}
@@ -0,0 +1,137 @@
+package wasm_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/karol-broda/ferrule/bindings/go/wasm"
+)
+
+var shared struct {
+	once   sync.Once
+	parser *wasm.Parser
+	err    error
+}
+
+// parse parses src with a parser shared by the tests, since compiling the
+// module takes seconds.
+func parse(t *testing.T, src string) *wasm.Tree {
+	t.Helper()
+	shared.once.Do(func() { shared.parser, shared.err = wasm.NewParser(context.Background()) })
+	if shared.err != nil {
+		t.Fatal(shared.err)
+	}
+	tree, err := shared.parser.Parse(context.Background(), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+type corpusCase struct {
+	name, src, sexp string
+}
+
+// corpus returns the cases of the grammar's test corpus.
+func corpus(t *testing.T) []corpusCase {
+	t.Helper()
+	files, err := filepath.Glob("../../../test/corpus/*.txt")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus: %v", err)
+	}
+	header := regexp.MustCompile(`(?m)^={10,}\n(.*)\n={10,}\n`)
+	divider := regexp.MustCompile(`(?m)^-{10,}$`)
+	var cases []corpusCase
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := header.FindAllStringSubmatch(string(data), -1)
+		for i, body := range header.Split(string(data), -1)[1:] {
+			parts := divider.Split(body, 2)
+			cases = append(cases, corpusCase{names[i][1], parts[0], strings.Join(strings.Fields(parts[1]), " ")})
+		}
+	}
+	return cases
+}
+
+func TestCorpus(t *testing.T) {
+	for _, c := range corpus(t) {
+		if got := parse(t, c.src).RootNode().ToSexp(); got != c.sexp {
+			t.Errorf("%s:\ngot  %s\nwant %s", c.name, got, c.sexp)
+		}
+	}
+}
+
+func TestNode(t *testing.T) {
+	src := "const x = f(1, y);\n// done\n"
+	root := parse(t, src).RootNode()
+	if root.Kind() != "source_file" || root.Parent() != nil || root.HasError() {
+		t.Fatalf("root %s", root.ToSexp())
+	}
+	decl := root.NamedChild(0)
+	if decl.Kind() != "const_declaration" || decl.Parent().Kind() != "source_file" {
+		t.Fatalf("declaration %s", decl.ToSexp())
+	}
+	name := decl.ChildByFieldName("name")
+	if name.Utf8Text([]byte(src)) != "x" || name.StartPosition() != (wasm.Point{Row: 0, Column: 6}) {
+		t.Errorf("name %q at %v", name.Utf8Text([]byte(src)), name.StartPosition())
+	}
+	if name.PrevSibling().Kind() != "const" || name.PrevNamedSibling() != nil || name.NextNamedSibling().Kind() != "call_expression" {
+		t.Errorf("siblings of name")
+	}
+	call := decl.ChildByFieldName("value")
+	if call.NamedChildCount() != 3 || call.Child(1).Kind() != "(" || call.Child(1).IsNamed() {
+		t.Errorf("call %s", call.ToSexp())
+	}
+	if i := decl.ChildCount() - 1; decl.Child(i).Kind() != ";" || decl.Child(i+1) != nil || decl.FieldNameForChild(1) != "name" {
+		t.Errorf("children of declaration")
+	}
+	comment := root.NamedChild(1)
+	if !comment.IsExtra() || comment.Kind() != "line_comment" || comment.EndPosition() != (wasm.Point{Row: 1, Column: 7}) {
+		t.Errorf("comment %s at %v", comment.Kind(), comment.EndPosition())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	root := parse(t, "const x = 1 $ 2;\nconst y = 1\n").RootNode()
+	if !root.HasError() {
+		t.Fatalf("no error in %s", root.ToSexp())
+	}
+	for _, want := range []string{"ERROR", "MISSING"} {
+		if !strings.Contains(root.ToSexp(), want) {
+			t.Errorf("no %s in %s", want, root.ToSexp())
+		}
+	}
+	if got := parse(t, "").RootNode().ToSexp(); got != "(source_file)" {
+		t.Errorf("empty source: %s", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	data, err := os.ReadFile("../../../tree-sitter.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var config struct {
+		Metadata struct {
+			Version string `json:"version"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatal(err)
+	}
+	if got := wasm.Version(); got != config.Metadata.Version {
+		t.Errorf("Version() = %q, tree-sitter.json has %q", got, config.Metadata.Version)
+	}
+	if wasm.GrammarABI() < 14 {
+		t.Errorf("GrammarABI() = %d", wasm.GrammarABI())
+	}
+}
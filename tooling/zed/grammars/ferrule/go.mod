@@ -1,5 +1,16 @@
 module github.com/karol-broda/ferrule
 
-go 1.22
+go 1.23
 
-require github.com/tree-sitter/go-tree-sitter v0.24.0
+require (
+	github.com/alecthomas/chroma/v2 v2.14.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/tetratelabs/wazero v1.9.0
+	github.com/tree-sitter/go-tree-sitter v0.25.0
+)
+
+require (
+	github.com/dlclark/regexp2 v1.11.0 // indirect
+	github.com/mattn/go-pointer v0.0.1 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+)
@@ -9,5 +9,19 @@
   (array_expression)
 ] @fold
 
+; declarations with bodies of their own
+[
+  (component_declaration)
+  (capability_declaration)
+  (domain_declaration)
+] @fold
+
+; match arms and long parameter and argument lists
+[
+  (match_arm)
+  (parameter_list)
+  (call_expression)
+] @fold
+
 ; comments
 (block_comment) @fold
@@ -0,0 +1,15 @@
+; comments
+((line_comment) @injection.content
+  (#set! injection.language "comment"))
+
+((block_comment) @injection.content
+  (#set! injection.language "comment"))
+
+; strings passed to a function named after their language, as in
+; sql("select 1")
+((call_expression
+  .
+  (identifier) @injection.language
+  .
+  (string_literal) @injection.content)
+  (#any-of? @injection.language "sql" "regex" "json"))
@@ -17,5 +17,14 @@
 (const_declaration
   name: (identifier) @local.definition.var)
 
+(for_statement
+  . (identifier) @local.definition.var)
+
+(pattern
+  (identifier) @local.definition.var)
+
+(destructuring_pattern
+  (identifier) @local.definition.var)
+
 ; references
 (identifier) @local.reference
@@ -0,0 +1,31 @@
+// Package queries embeds the tree-sitter query files that ship with the
+// ferrule grammar so they are versioned together with the parser.
+//
+// Most callers should use the accessors in the tree_sitter_ferrule package
+// instead of importing this package directly.
+package queries
+
+import _ "embed"
+
+var (
+	//go:embed highlights.scm
+	Highlights []byte
+
+	//go:embed locals.scm
+	Locals []byte
+
+	//go:embed injections.scm
+	Injections []byte
+
+	//go:embed tags.scm
+	Tags []byte
+
+	//go:embed folds.scm
+	Folds []byte
+
+	//go:embed indents.scm
+	Indents []byte
+
+	//go:embed textobjects.scm
+	TextObjects []byte
+)
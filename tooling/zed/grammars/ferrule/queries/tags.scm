@@ -1,3 +1,7 @@
+; packages
+(package_declaration
+  path: (package_path) @name) @definition.module
+
 ; functions
 (function_declaration
   name: (identifier) @name) @definition.function
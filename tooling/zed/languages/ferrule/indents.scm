@@ -1,3 +1,6 @@
+; ferrule-lsp indents new lines and closing brackets from the parse tree
+; (textDocument/onTypeFormatting); these queries only cover editing without it.
+
 ; indent after opening braces
 [
   (block)